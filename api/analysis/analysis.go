@@ -5,65 +5,310 @@
 package analysis
 
 import (
+	"sort"
+	"strings"
 	"time"
 
 	apiContext "github.com/globocom/huskyCI/api/context"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
 	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/timeline"
 	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
 	"gopkg.in/mgo.v2/bson"
 )
 
 const logActionStart = "StartAnalysis"
 const logInfoAnalysis = "ANALYSIS"
 
-// StartAnalysis starts the analysis given a RID and a repository.
-func StartAnalysis(RID string, repository types.Repository) {
+const (
+	// ScanTypeFull states that every file of the repository was scanned.
+	ScanTypeFull = "full"
+	// ScanTypeIncremental states that only files changed since a base commit were scanned
+	// by securityTests that support it, so issues in unchanged code may be missed.
+	ScanTypeIncremental = "incremental"
+)
+
+const (
+	// SourceTypeGit states that securityTests cloned the repository.
+	SourceTypeGit = "git"
+	// SourceTypeTarball states that securityTests scanned a tarball of the repository working tree.
+	SourceTypeTarball = "tarball"
+)
+
+// StartAnalysis starts the analysis given a RID and a repository. Once interruptCtx
+// is done, its remaining securityTests are canceled and it is stored as interrupted.
+func StartAnalysis(interruptCtx goContext.Context, RID string, repository types.Repository) {
+
+	// ignores of the request are only respected once a reviewer approves them
+	repository.Ignores = approvedIgnores(RID, repository.URL, repository.Ignores, time.Now())
 
 	// step 1: create a new analysis into MongoDB based on repository received
 	if err := registerNewAnalysis(RID, repository); err != nil {
 		return
 	}
 	log.Info(logActionStart, logInfoAnalysis, 101, RID)
+	metrics.AnalysesStarted.Inc()
+	notifyGitHubStarted(RID, repository)
+	notifyGitLabStarted(RID, repository)
+	notifyBitbucketStarted(RID, repository)
 
-	// step 2: run enry as huskyCI initial step
-	enryScan := securitytest.SecTestScanInfo{}
-	enryScan.SecurityTestName = "enry"
-	allScansResults := securitytest.RunAllInfo{}
+	// the analysis timeout cancels every securityTest still running or waiting to run
+	ctx, cancel := goContext.WithTimeout(interruptCtx, apiContext.APIConfiguration.AnalysisTimeout)
+	defer cancel()
+
+	allScansResults := securitytest.RunAllInfo{ScanType: ScanTypeFull, Subpaths: repository.Subpaths}
+	// clients polling the analysis see each container as soon as it finishes
+	allScansResults.OnContainerFinished = containersUpdater(RID, nil)
+	allScansResults.OnOutput = outputTailUpdater(RID)
+	// other refs are scanned once Branch is, each one with its own results
+	refScans := []*securitytest.RunAllInfo{}
+	// an analysis that could not run due to a transient error runs again
+	attempts := 1
 
 	defer func() {
-		err := registerFinishedAnalysis(RID, &allScansResults)
+		if ctx.Err() == goContext.DeadlineExceeded {
+			log.Warning(logActionStart, logInfoAnalysis, 121, RID)
+		}
+		if interruptCtx.Err() != nil {
+			allScansResults.SetAnalysisError(inFlight.interruptCause(RID))
+		}
+		err := registerFinishedAnalysis(RID, repository.URL, &allScansResults, refScans, attempts)
 		if err != nil {
 			log.Error(logActionStart, logInfoAnalysis, 2011, err)
+			return
+		}
+		// an analysis with errors did not run every securityTest, so its findings are not a snapshot
+		if allScansResults.ErrorFound == nil {
+			findings := UnifyFindings(allScansResults.HuskyCIResults)
+			RecordTrends(repository.URL, allScansResults.Containers, findings)
+			// best-effort: the findings are still stored in the analysis itself
+			if err := RecordFindings(RID, repository.URL, findings, time.Now()); err == nil {
+				openJIRAIssues(RID, repository.URL, findings)
+			}
 		}
+		notifyFinished(RID, repository)
 	}()
 
+	var scanned bool
+	scanned, attempts = scanRefWithRetry(ctx, RID, repository, &allScansResults, apiContext.APIConfiguration.AnalysisRetryPolicy)
+	if !scanned {
+		return
+	}
+
+	scannedContainers := allScansResults.Containers
+	for _, ref := range repository.Refs {
+		if ctx.Err() != nil {
+			break
+		}
+		refRepository := repository
+		refRepository.Branch = ref
+		// the commit of the request is one of Branch
+		refRepository.CommitSHA = ""
+		refScan := &securitytest.RunAllInfo{ScanType: ScanTypeFull, Subpaths: repository.Subpaths, Ref: ref}
+		refScan.OnContainerFinished = containersUpdater(RID, scannedContainers)
+		refScan.OnOutput = allScansResults.OnOutput
+		refScans = append(refScans, refScan)
+		scanRef(ctx, RID, refRepository, refScan)
+		scannedContainers = append(scannedContainers, refScan.Containers...)
+	}
+
+	log.Info("StartAnalysis", logInfoAnalysis, 102, RID)
+}
+
+// notifyFinished sends every notification of the analysis of RID of repository once it is
+// stored as finished: its callback, Slack and the status of its commit or pull request.
+func notifyFinished(RID string, repository types.Repository) {
+	notifyCallback(RID, repository.CallbackURL)
+	notifySlack(RID, repository.URL)
+	notifyGitHubFinished(RID, repository)
+	notifyGitLabFinished(RID, repository)
+	notifyBitbucketFinished(RID, repository)
+}
+
+// scanRef runs enry and then every securityTest it selects on the Branch of repository, storing
+// their containers and findings in results. It returns whether every securityTest could run.
+func scanRef(ctx goContext.Context, RID string, repository types.Repository, results *securitytest.RunAllInfo) bool {
+
+	// step 2: run enry as huskyCI initial step
+	enryScan := securitytest.SecTestScanInfo{}
+	enryScan.SecurityTestName = "enry"
+	// every ref shares the deadline of the analysis
+	enryScan.Deadline, _ = ctx.Deadline()
+	enryScan.Context = ctx
+	enryScan.ExcludedPaths = excludedPaths(repository)
+	enryScan.Source = repository.Source
+	enryScan.ExtraEnv = repository.ExtraEnv
+	enryScan.Ignores = repository.Ignores
+	enryScan.Suppressions = approvedSuppressions(repository.URL)
+	enryScan.GitleaksAllowlist = gitleaksAllowlist(repository)
+	enryScan.NpmAuditFailOn = repository.NpmAuditFailOn
+	enryScan.Force = repository.Force
+	enryScan.CommitSHA = repository.CommitSHA
+	enryScan.HTTPSToken = httpsTokenEnabled(repository.URL)
+	enryScan.OutputHandler = results.TailOutput(enryScan.SecurityTestName, "")
+
 	if err := enryScan.New(RID, repository.URL, repository.Branch, enryScan.SecurityTestName); err != nil {
 		log.Error(logActionStart, logInfoAnalysis, 2011, err)
-		return
+		return false
 	}
 	if err := enryScan.Start(); err != nil {
-		allScansResults.SetAnalysisError(err)
-		return
+		results.SetAnalysisError(err)
+		return false
+	}
+	if enryScan.TimedOut {
+		results.SetAnalysisError(enryScan.ErrorFound)
+		return false
+	}
+	if enryScan.Canceled {
+		results.SetAnalysisError(ErrAnalysisTimeout)
+		return false
 	}
 
-	// step 3: run generic and languages security tests based on enryScan result in parallel
-	if err := allScansResults.Start(enryScan); err != nil {
-		allScansResults.SetAnalysisError(err)
-		return
+	// step 2.1: list changed files if only them should be scanned
+	if repository.IncrementalScan {
+		changedFiles, err := listChangedFiles(ctx, RID, repository)
+		if err != nil || len(changedFiles) == 0 {
+			log.Warning(logActionStart, logInfoAnalysis, 114, RID, err)
+		} else {
+			enryScan.ChangedFiles = changedFiles
+			results.ScanType = ScanTypeIncremental
+		}
 	}
 
-	log.Info("StartAnalysis", logInfoAnalysis, 102, RID)
+	// step 3: run generic and languages security tests based on enryScan result in parallel,
+	// bounded by SecurityTestParallelism
+	if err := results.Start(enryScan); err != nil {
+		results.SetAnalysisError(err)
+		return false
+	}
+	return true
+}
+
+// containersUpdater returns an OnContainerFinished that stores the containers of a ref, along
+// with the ones of the refs scanned before it, in its running analysis.
+func containersUpdater(RID string, scannedBefore []types.Container) func(containers []types.Container) {
+	return func(containers []types.Container) {
+		allContainers := append(append([]types.Container{}, scannedBefore...), containers...)
+		err := updateRunningAnalysis(RID, bson.M{"containers": allContainers})
+		if err != nil {
+			log.Error(logActionStart, logInfoAnalysis, 2011, err)
+		}
+		// containers are reported as they finish, so the last one is the one just finished
+		if len(containers) > 0 {
+			finished := containers[len(containers)-1]
+			timeline.Record(RID, finished.CID, finished.SecurityTest.Name, timeline.EventPersisted, err)
+		}
+	}
+}
+
+// outputTailUpdater returns an OnOutput that stores the tail of each running container in its
+// running analysis, so clients polling it see how far its securityTests got.
+func outputTailUpdater(RID string) func(securityTest, subpath string, lines []string) {
+	return func(securityTest, subpath string, lines []string) {
+		key := "outputTails." + OutputTailKey(securityTest, subpath)
+		if err := updateRunningAnalysis(RID, bson.M{key: lines}); err != nil {
+			log.Error(logActionStart, logInfoAnalysis, 2011, err)
+		}
+	}
+}
+
+// OutputTailKey returns the key of the tail of the container of securityTest scanning subpath
+// in the OutputTails of its analysis. MongoDB does not allow dots in keys.
+func OutputTailKey(securityTest, subpath string) string {
+	if subpath == "" {
+		return securityTest
+	}
+	return securityTest + ":" + strings.NewReplacer(".", "_", "$", "_").Replace(subpath)
+}
+
+// sourceType returns how the code of an analysis was fetched by its securityTests.
+func sourceType(source types.Source) string {
+	if source.IsTarball() {
+		return SourceTypeTarball
+	}
+	return SourceTypeGit
+}
+
+// excludedPaths returns the excluded paths of a repository or, if it sets none, the ones of config.yaml.
+func excludedPaths(repository types.Repository) []string {
+	if len(repository.ExcludedPaths) > 0 {
+		return repository.ExcludedPaths
+	}
+	return apiContext.APIConfiguration.ExcludedPaths
+}
+
+// gitleaksAllowlist returns the effective gitleaks allowlist of a repository, which
+// can not allowlist the rules tagged with gitleaksProtectedTags of config.yaml.
+func gitleaksAllowlist(repository types.Repository) *types.GitleaksAllowlist {
+	if repository.GitleaksAllowlist == nil {
+		return nil
+	}
+	allowlist := *repository.GitleaksAllowlist
+	allowlist.ProtectedTags = apiContext.APIConfiguration.GitleaksProtectedTags
+	return &allowlist
+}
+
+// extraEnvKeys returns the sorted keys of the environment variables an analysis sets.
+func extraEnvKeys(extraEnv map[string]string) []string {
+	keys := []string{}
+	for key := range extraEnv {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// listChangedFiles returns files changed in the repository branch since its base commit.
+func listChangedFiles(ctx goContext.Context, RID string, repository types.Repository) ([]string, error) {
+	gitDiffScan := securitytest.SecTestScanInfo{}
+	gitDiffScan.SecurityTestName = "gitdiff"
+	if err := gitDiffScan.New(RID, repository.URL, repository.Branch, gitDiffScan.SecurityTestName); err != nil {
+		return nil, err
+	}
+	gitDiffScan.BaseCommit = repository.BaseCommit
+	gitDiffScan.CommitSHA = repository.CommitSHA
+	gitDiffScan.HTTPSToken = httpsTokenEnabled(repository.URL)
+	gitDiffScan.Context = ctx
+	if err := gitDiffScan.Start(); err != nil {
+		return nil, err
+	}
+	if gitDiffScan.ErrorFound != nil {
+		return nil, gitDiffScan.ErrorFound
+	}
+	return gitDiffScan.ChangedFiles, nil
 }
 
 func registerNewAnalysis(RID string, repository types.Repository) error {
 
+	scanType := ScanTypeFull
+	if repository.IncrementalScan {
+		scanType = ScanTypeIncremental
+	}
+
 	newAnalysis := types.Analysis{
-		RID:       RID,
-		URL:       repository.URL,
-		Branch:    repository.Branch,
-		Status:    "running",
-		StartedAt: time.Now(),
+		RID:               RID,
+		URL:               repository.URL,
+		Branch:            repository.Branch,
+		Status:            StatusRunning,
+		StartedAt:         time.Now(),
+		ScanType:          scanType,
+		BaseCommit:        repository.BaseCommit,
+		Subpaths:          repository.Subpaths,
+		ExcludedPaths:     excludedPaths(repository),
+		SourceType:        sourceType(repository.Source),
+		ExtraEnvKeys:      extraEnvKeys(repository.ExtraEnv),
+		Ignored:           securitytest.ActiveIgnores(repository.Ignores, time.Now()),
+		GitleaksAllowlist: gitleaksAllowlist(repository),
+		NpmAuditFailOn:    repository.NpmAuditFailOn,
+		Refs:              repository.Refs,
+		CommitSHA:         repository.CommitSHA,
+		CommitAuthor:      repository.CommitAuthor,
+		PullRequestID:     repository.PullRequestID,
+		Metadata:          repository.Metadata,
+		CallbackURL:       repository.CallbackURL,
+		RetryOf:           repository.RetryOf,
 	}
 
 	if err := apiContext.APIConfiguration.DBInstance.InsertDBAnalysis(newAnalysis); err != nil {
@@ -75,28 +320,72 @@ func registerNewAnalysis(RID string, repository types.Repository) error {
 	return nil
 }
 
-func registerFinishedAnalysis(RID string, allScanResults *securitytest.RunAllInfo) error {
-	analysisQuery := map[string]interface{}{"RID": RID}
+// registerFinishedAnalysis is the single finalizer of an analysis: it computes its
+// result and risk score and stores them along with every container, and how many
+// attempts it took, in a single update.
+func registerFinishedAnalysis(RID, repositoryURL string, allScanResults *securitytest.RunAllInfo, refScans []*securitytest.RunAllInfo, attempts int) error {
+	FinalizeResults(allScanResults)
+	containers, refResults := finalizeRefs(allScanResults.Containers, refScans)
+
 	var errorString string
 	if _, ok := allScanResults.ErrorFound.(error); ok {
 		errorString = allScanResults.ErrorFound.Error()
 	} else {
 		errorString = ""
 	}
+	// best-effort: unknown CVEs are stored as they are
+	NewCVEEnricher().Enrich(&allScanResults.HuskyCIResults)
+	findings := UnifyFindings(allScanResults.HuskyCIResults)
+
 	updateAnalysisQuery := bson.M{
 		"status":         allScanResults.Status,
 		"commitAuthors":  allScanResults.CommitAuthors,
 		"result":         allScanResults.FinalResult,
-		"containers":     allScanResults.Containers,
+		"containers":     containers,
 		"huskyciresults": allScanResults.HuskyCIResults,
 		"codes":          allScanResults.Codes,
 		"errorFound":     errorString,
+		"scanType":       allScanResults.ScanType,
+		"summary":        Summarize(findings),
 		"finishedAt":     time.Now(),
+		"attemptCount":   attempts,
+		"retryCount":     attempts - 1,
+		// the raw output of every container is stored by now
+		"outputTails": nil,
+	}
+	integrity := analysisIntegrity(types.Analysis{
+		RID:            RID,
+		URL:            repositoryURL,
+		Result:         allScanResults.FinalResult,
+		ErrorFound:     errorString,
+		HuskyCIResults: allScanResults.HuskyCIResults,
+	})
+	if integrity != "" {
+		updateAnalysisQuery["integrity"] = integrity
+	}
+	if len(refResults) > 0 {
+		updateAnalysisQuery["refResults"] = refResults
+	}
+	if allScanResults.Commit != "" {
+		updateAnalysisQuery["commit"] = allScanResults.Commit
+	}
+	// an analysis with errors did not run every securityTest, so the repository keeps the
+	// risk score of its last analysis that did
+	var riskScore float64
+	if errorString == "" {
+		riskScore = repositoryRiskScore(repositoryURL, findings)
+		updateAnalysisQuery["riskScore"] = riskScore
 	}
 
-	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBAnalysisContainer(analysisQuery, updateAnalysisQuery); err != nil {
+	if err := updateRunningAnalysis(RID, updateAnalysisQuery); err != nil {
 		log.Error("registerFinishedAnalysis", logInfoAnalysis, 2011, err)
 		return err
 	}
+	if errorString == "" {
+		recordRiskScore(repositoryURL, riskScore)
+	}
+	metrics.AnalysesFinished.WithLabelValues(allScanResults.Status, allScanResults.FinalResult).Inc()
+	groups := Correlate(findings)
+	metrics.HighConfidenceFindings.Set(float64(CountHighConfidence(groups)))
 	return nil
 }
@@ -0,0 +1,284 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/archive"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrArchiveDisabled is returned when no archive backend is configured.
+var ErrArchiveDisabled = errors.New("analysis archiving is disabled")
+
+// ErrArchiveRunning is returned when the archive job is asked to run while it already is.
+var ErrArchiveRunning = errors.New("archive job already running")
+
+// archivePageSize is how many analyses ArchiveAnalyses reads at once.
+const archivePageSize = 100
+
+// retentionState is the archive job run in progress, if any, and the last one that finished.
+var retentionState = struct {
+	sync.Mutex
+	running bool
+	lastRun *types.RetentionRun
+}{}
+
+// ArchiveKey returns the key the analysis of RID is archived with.
+func ArchiveKey(RID string) string {
+	return fmt.Sprintf("analyses/%s.json.gz", RID)
+}
+
+// EncodeArchive returns analysisResult as gzipped JSON.
+func EncodeArchive(analysisResult types.Analysis) ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(analysisResult); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// DecodeArchive returns the analysis EncodeArchive encoded as data.
+func DecodeArchive(data []byte) (types.Analysis, error) {
+	analysisResult := types.Analysis{}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return analysisResult, err
+	}
+	defer zr.Close()
+	decoded, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return analysisResult, err
+	}
+	err = json.Unmarshal(decoded, &analysisResult)
+	return analysisResult, err
+}
+
+// StripOutputs returns containers without their raw output, which is what analyses are
+// archived for as it is the largest part of them.
+func StripOutputs(containers []types.Container) []types.Container {
+	stripped := make([]types.Container, len(containers))
+	copy(stripped, containers)
+	for i := range stripped {
+		stripped[i].COutput = ""
+	}
+	return stripped
+}
+
+// RestoreOutputs returns analysisResult with the raw output of each of its containers taken
+// from archived, the copy of it that was archived.
+func RestoreOutputs(analysisResult, archived types.Analysis) types.Analysis {
+	containers := make([]types.Container, len(analysisResult.Containers))
+	copy(containers, analysisResult.Containers)
+	for i := range containers {
+		if i < len(archived.Containers) && archived.Containers[i].CID == containers[i].CID {
+			containers[i].COutput = archived.Containers[i].COutput
+		}
+	}
+	analysisResult.Containers = containers
+	return analysisResult
+}
+
+// archiveBackend returns the archive backend configured, or ErrArchiveDisabled.
+func archiveBackend() (archive.Backend, error) {
+	config := apiContext.APIConfiguration.ArchiveConfig
+	if config == nil || config.Backend == "" {
+		return nil, ErrArchiveDisabled
+	}
+	return archive.NewBackend(archive.Config{
+		Backend:   config.Backend,
+		Bucket:    config.Bucket,
+		Endpoint:  config.Endpoint,
+		Region:    config.Region,
+		AccessKey: config.AccessKey,
+		SecretKey: config.SecretKey,
+		Dir:       config.Path,
+	})
+}
+
+// ArchiveAnalyses moves the raw output of every analysis that finished before olderThan to
+// backend and removes it from the database, keeping their results and summary. It returns how
+// many analyses it archived and how many it could not, which are left as they are and tried
+// again on its next run.
+func ArchiveAnalyses(backend archive.Backend, olderThan time.Time) (int, int, error) {
+	analysisQuery := map[string]interface{}{
+		"status":     bson.M{"$ne": StatusRunning},
+		"finishedAt": bson.M{"$lt": olderThan},
+		"archived":   bson.M{"$ne": true},
+	}
+	archived, failed := 0, 0
+	var firstErr error
+	for {
+		// archived analyses leave the query, so only the ones that failed are skipped
+		analyses, err := apiContext.APIConfiguration.DBInstance.FindFinishedPageDBAnalysis(analysisQuery, failed, archivePageSize)
+		if err != nil {
+			if isNotFound(err) {
+				break
+			}
+			return archived, failed, err
+		}
+		if len(analyses) == 0 {
+			break
+		}
+		for _, listed := range analyses {
+			if err := archiveAnalysis(backend, listed.RID); err != nil {
+				log.Error("ArchiveAnalyses", logInfoAnalysis, 2042, listed.RID, err)
+				if firstErr == nil {
+					firstErr = err
+				}
+				failed++
+				continue
+			}
+			archived++
+		}
+	}
+	return archived, failed, firstErr
+}
+
+// archiveAnalysis stores the analysis of RID in backend and then removes its raw output from
+// the database, so it is never removed without being stored first.
+func archiveAnalysis(backend archive.Backend, RID string) error {
+	// pages only have the fields needed to list analyses
+	analysisResult, err := FindAnalysis(RID)
+	if err != nil {
+		return err
+	}
+	data, err := EncodeArchive(analysisResult)
+	if err != nil {
+		return err
+	}
+	key := ArchiveKey(RID)
+	if err := backend.Put(key, data); err != nil {
+		return err
+	}
+	analysisQuery := map[string]interface{}{"RID": RID}
+	updateAnalysisQuery := map[string]interface{}{
+		"containers": StripOutputs(analysisResult.Containers),
+		"archived":   true,
+		"archivedAt": time.Now(),
+		"archiveKey": key,
+	}
+	return apiContext.APIConfiguration.DBInstance.UpdateOneDBAnalysis(analysisQuery, updateAnalysisQuery)
+}
+
+// RetrieveArchivedAnalysis returns analysisResult with the raw output of its containers
+// restored from the archive if it was archived, or as it is otherwise.
+func RetrieveArchivedAnalysis(analysisResult types.Analysis) (types.Analysis, error) {
+	if !analysisResult.Archived {
+		return analysisResult, nil
+	}
+	backend, err := archiveBackend()
+	if err != nil {
+		log.Error("RetrieveArchivedAnalysis", logInfoAnalysis, 2043, analysisResult.RID, err)
+		return analysisResult, err
+	}
+	data, err := backend.Get(analysisResult.ArchiveKey)
+	if err != nil {
+		log.Error("RetrieveArchivedAnalysis", logInfoAnalysis, 2043, analysisResult.RID, err)
+		return analysisResult, err
+	}
+	archived, err := DecodeArchive(data)
+	if err != nil {
+		log.Error("RetrieveArchivedAnalysis", logInfoAnalysis, 2043, analysisResult.RID, err)
+		return analysisResult, err
+	}
+	return RestoreOutputs(analysisResult, archived), nil
+}
+
+// GetRetentionPolicy returns how long analyses keep their raw output, where it is archived
+// to, when they are deleted and how the last runs of the archive and deletion jobs went.
+func GetRetentionPolicy() types.RetentionPolicy {
+	policy := types.RetentionPolicy{
+		RetentionDays:     int(apiContext.APIConfiguration.AnalysisRetention / (24 * time.Hour)),
+		MaxAgeDays:        int(apiContext.APIConfiguration.AnalysisMaxAge / (24 * time.Hour)),
+		KeepPerRepository: apiContext.APIConfiguration.AnalysisKeepPerRepository,
+	}
+	if config := apiContext.APIConfiguration.ArchiveConfig; config != nil {
+		policy.Backend = config.Backend
+	}
+	retentionState.Lock()
+	policy.Running = retentionState.running
+	if retentionState.lastRun != nil {
+		lastRun := *retentionState.lastRun
+		policy.LastRun = &lastRun
+	}
+	retentionState.Unlock()
+	deletionState.Lock()
+	defer deletionState.Unlock()
+	if deletionState.lastRun != nil {
+		lastDeletion := *deletionState.lastRun
+		policy.LastDeletion = &lastDeletion
+	}
+	return policy
+}
+
+// RunArchiveJob archives the analyses that finished longer than the retention ago and returns
+// how it went. It returns ErrArchiveRunning if it is already running.
+func RunArchiveJob() (types.RetentionRun, error) {
+	run := types.RetentionRun{StartedAt: time.Now()}
+	backend, err := archiveBackend()
+	if err != nil {
+		return run, err
+	}
+	retentionState.Lock()
+	if retentionState.running {
+		retentionState.Unlock()
+		return run, ErrArchiveRunning
+	}
+	retentionState.running = true
+	retentionState.Unlock()
+
+	run.Archived, run.Failed, err = ArchiveAnalyses(backend, run.StartedAt.Add(-apiContext.APIConfiguration.AnalysisRetention))
+	if err != nil {
+		run.Error = err.Error()
+	}
+	run.FinishedAt = time.Now()
+	log.Info("RunArchiveJob", logInfoAnalysis, 44, run.Archived)
+
+	retentionState.Lock()
+	retentionState.running = false
+	retentionState.lastRun = &run
+	retentionState.Unlock()
+	return run, nil
+}
+
+// NextArchiveRun returns when the archive job runs next after now: at midnight UTC.
+func NextArchiveRun(now time.Time) time.Time {
+	return now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+}
+
+// StartArchiveJob runs RunArchiveJob every night in background, if an archive backend is
+// configured.
+func StartArchiveJob() {
+	if _, err := archiveBackend(); err != nil {
+		if err != ErrArchiveDisabled {
+			log.Error("StartArchiveJob", logInfoAnalysis, 2042, err)
+		}
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(time.Until(NextArchiveRun(time.Now())))
+			if _, err := RunArchiveJob(); err != nil && err != ErrArchiveRunning {
+				log.Error("StartArchiveJob", logInfoAnalysis, 2042, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,80 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Archive", func() {
+
+	analysisResult := types.Analysis{
+		RID:    "RID",
+		URL:    "https://github.com/globocom/huskyCI.git",
+		Result: "failed",
+		Containers: []types.Container{
+			{CID: "gosec", COutput: `{"Issues":[]}`, CResult: "passed"},
+			{CID: "gitleaks", COutput: "[]", CResult: "failed"},
+		},
+	}
+
+	Describe("ArchiveKey", func() {
+		It("Should be a gzipped JSON of the analyses prefix", func() {
+			Expect(analysis.ArchiveKey("RID")).To(Equal("analyses/RID.json.gz"))
+		})
+	})
+
+	Describe("EncodeArchive", func() {
+		It("Should be decoded by DecodeArchive", func() {
+			data, err := analysis.EncodeArchive(analysisResult)
+			Expect(err).To(BeNil())
+			decoded, err := analysis.DecodeArchive(data)
+			Expect(err).To(BeNil())
+			Expect(decoded.RID).To(Equal("RID"))
+			Expect(decoded.Containers).To(Equal(analysisResult.Containers))
+		})
+	})
+
+	Describe("StripOutputs", func() {
+		It("Should remove the raw output of each container but keep its result", func() {
+			stripped := analysis.StripOutputs(analysisResult.Containers)
+			Expect(stripped[0].COutput).To(BeEmpty())
+			Expect(stripped[1].CResult).To(Equal("failed"))
+			Expect(analysisResult.Containers[0].COutput).ToNot(BeEmpty())
+		})
+	})
+
+	Describe("RestoreOutputs", func() {
+		It("Should restore the raw output of the containers of the archived copy", func() {
+			archived := analysisResult
+			archived.Containers = analysis.StripOutputs(analysisResult.Containers)
+			restored := analysis.RestoreOutputs(archived, analysisResult)
+			Expect(restored.Containers).To(Equal(analysisResult.Containers))
+		})
+		It("Should not restore the raw output of another container", func() {
+			archived := analysisResult
+			archived.Containers = []types.Container{{CID: "enry"}}
+			restored := analysis.RestoreOutputs(archived, analysisResult)
+			Expect(restored.Containers[0].COutput).To(BeEmpty())
+		})
+	})
+
+	Describe("NextArchiveRun", func() {
+		It("Should be the next midnight UTC", func() {
+			now := time.Date(2020, 3, 10, 15, 4, 5, 0, time.UTC)
+			Expect(analysis.NextArchiveRun(now)).To(Equal(time.Date(2020, 3, 11, 0, 0, 0, 0, time.UTC)))
+		})
+		It("Should be the next day at midnight UTC", func() {
+			now := time.Date(2020, 3, 10, 0, 0, 0, 0, time.UTC)
+			Expect(analysis.NextArchiveRun(now)).To(Equal(time.Date(2020, 3, 11, 0, 0, 0, 0, time.UTC)))
+		})
+	})
+})
@@ -0,0 +1,93 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// BitbucketState returns the state of the build status of an analysis with result.
+func BitbucketState(result string) string {
+	switch result {
+	case ResultPassed, ResultWarning:
+		return "SUCCESSFUL"
+	default:
+		return "FAILED"
+	}
+}
+
+// BitbucketBuildStatus returns the build status of a finished analysis, linking to it.
+func BitbucketBuildStatus(analysisResult types.Analysis, publicURL string) notifier.BitbucketStatus {
+	return notifier.BitbucketStatus{
+		Key:         notifier.BitbucketStatusKey,
+		State:       BitbucketState(analysisResult.Result),
+		URL:         analysisLink(analysisResult.RID, publicURL),
+		Name:        notifier.BitbucketStatusKey,
+		Description: AnalysisDescription(analysisResult),
+	}
+}
+
+// bitbucketTarget returns the client, workspace or project key and slug an analysis of
+// repository is reported to, or a nil client if it is not reported to Bitbucket. As
+// Bitbucket requires build statuses to link somewhere, HUSKYCI_API_PUBLIC_URL must be set.
+func bitbucketTarget(repository types.Repository) (*notifier.BitbucketClient, string, string) {
+	config := apiContext.APIConfiguration.BitbucketConfig
+	if config == nil || repository.CommitSHA == "" || apiContext.APIConfiguration.PublicURL == "" {
+		return nil, "", ""
+	}
+	bb := notifier.NewBitbucketClient(config.URL, config.Username, config.AppPassword, config.Token)
+	if bb == nil {
+		return nil, "", ""
+	}
+	host, owner, slug, err := util.ParseBitbucketRepository(repository.URL)
+	if err != nil || host != bb.Host() {
+		return nil, "", ""
+	}
+	return bb, owner, slug
+}
+
+// notifyBitbucketStarted sets the commit of the analysis of RID as in progress in
+// Bitbucket, in background, if it analyzes a given commit of a Bitbucket repository.
+func notifyBitbucketStarted(RID string, repository types.Repository) {
+	bb, owner, slug := bitbucketTarget(repository)
+	if bb == nil {
+		return
+	}
+	publicURL := apiContext.APIConfiguration.PublicURL
+	bb.ReportAsync(func() error {
+		return bb.SetBuildStatus(owner, slug, repository.CommitSHA, notifier.BitbucketStatus{
+			Key:         notifier.BitbucketStatusKey,
+			State:       "INPROGRESS",
+			URL:         analysisLink(RID, publicURL),
+			Name:        notifier.BitbucketStatusKey,
+			Description: "huskyCI analysis is running",
+		})
+	}, func(err error) {
+		log.Error("notifyBitbucketStarted", logInfoAnalysis, 2040, RID, err)
+	})
+}
+
+// notifyBitbucketFinished reports the finished analysis of RID to Bitbucket in background, if
+// it analyzed a given commit of a Bitbucket repository. Errors are only logged.
+func notifyBitbucketFinished(RID string, repository types.Repository) {
+	bb, owner, slug := bitbucketTarget(repository)
+	if bb == nil {
+		return
+	}
+	publicURL := apiContext.APIConfiguration.PublicURL
+	bb.ReportAsync(func() error {
+		analysisResult, err := FindAnalysis(RID)
+		if err != nil {
+			return err
+		}
+		return bb.SetBuildStatus(owner, slug, repository.CommitSHA, BitbucketBuildStatus(analysisResult, publicURL))
+	}, func(err error) {
+		log.Error("notifyBitbucketFinished", logInfoAnalysis, 2040, RID, err)
+	})
+}
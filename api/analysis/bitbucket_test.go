@@ -0,0 +1,37 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BitbucketBuildStatus", func() {
+
+	It("Should describe the result and findings of the analysis", func() {
+		analysisResult := types.Analysis{
+			RID:     "RID",
+			Result:  analysis.ResultFailed,
+			Summary: &types.SeveritySummary{High: 1},
+		}
+		Expect(analysis.BitbucketBuildStatus(analysisResult, "https://huskyci.example.com")).To(Equal(notifier.BitbucketStatus{
+			Key:         "huskyCI",
+			State:       "FAILED",
+			URL:         "https://huskyci.example.com/analysis/RID",
+			Name:        "huskyCI",
+			Description: "huskyCI analysis failed: 0 critical, 1 high, 0 medium and 0 low findings",
+		}))
+	})
+
+	It("Should only fail the commit of a failed analysis or one that could not finish", func() {
+		Expect(analysis.BitbucketState(analysis.ResultWarning)).To(Equal("SUCCESSFUL"))
+		Expect(analysis.BitbucketState(analysis.ResultError)).To(Equal("FAILED"))
+	})
+})
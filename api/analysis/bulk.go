@@ -0,0 +1,271 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/google/uuid"
+)
+
+const logActionNewBulkAnalysis = "NewBulkAnalysis"
+
+// MaxBulkRepositories is how many repositories a bulk analysis request may have, so a single
+// request can not flood the Docker hosts.
+const MaxBulkRepositories = 100
+
+// DefaultBulkConcurrency is how many analyses of a bulk analysis job run at the same time when
+// its request does not say.
+const DefaultBulkConcurrency = 5
+
+// MaxRunningBulkJobs is how many bulk analysis jobs a client may have running at the same time.
+const MaxRunningBulkJobs = 2
+
+// Status of the analysis of a repository of a bulk analysis job before it starts, or if it
+// could not, such as when the repository and branch already had an analysis running.
+const (
+	StatusQueued   = "queued"
+	StatusRejected = "rejected"
+)
+
+var (
+	// ErrBulkEmpty is returned when a bulk analysis request has no repositories.
+	ErrBulkEmpty = errors.New("repositories are required")
+	// ErrBulkTooLarge is returned when a bulk analysis request has more than MaxBulkRepositories.
+	ErrBulkTooLarge = fmt.Errorf("a bulk analysis can not have more than %d repositories", MaxBulkRepositories)
+	// ErrBulkConcurrency is returned when the maxConcurrency of a bulk analysis request is out of range.
+	ErrBulkConcurrency = fmt.Errorf("maxConcurrency must be between 0 and %d", MaxBulkRepositories)
+	// ErrBulkJobsRunning is returned when the client already has MaxRunningBulkJobs running.
+	ErrBulkJobsRunning = fmt.Errorf("no more than %d bulk analysis jobs may run at the same time", MaxRunningBulkJobs)
+	// ErrBulkAnalysisNotFound is returned when no bulk analysis job has the given ID.
+	ErrBulkAnalysisNotFound = errors.New("bulk analysis job not found")
+)
+
+// bulkAnalysisPollInterval is how often a bulk analysis job checks if its running analyses finished.
+var bulkAnalysisPollInterval = 5 * time.Second
+
+// ValidateBulkRequest returns an error if request has no repositories or more than
+// MaxBulkRepositories, if its maxConcurrency is out of range or if it has the same
+// repository and branch twice.
+func ValidateBulkRequest(request types.BulkAnalysisRequest) error {
+	if len(request.Repositories) == 0 {
+		return ErrBulkEmpty
+	}
+	if len(request.Repositories) > MaxBulkRepositories {
+		return ErrBulkTooLarge
+	}
+	if request.MaxConcurrency < 0 || request.MaxConcurrency > MaxBulkRepositories {
+		return ErrBulkConcurrency
+	}
+	requested := map[string]bool{}
+	for _, repository := range request.Repositories {
+		key := bulkRepositoryKey(repository)
+		if requested[key] {
+			return fmt.Errorf("repository %s is requested twice for branch %q", repository.URL, repository.Branch)
+		}
+		requested[key] = true
+	}
+	return nil
+}
+
+// bulkRepositoryKey identifies repository and its branch however its URL is spelled, such as
+// with a .git suffix or in upper case.
+func bulkRepositoryKey(repository types.BulkRepository) string {
+	repositoryURL := strings.ToLower(strings.TrimSuffix(repository.URL, "/"))
+	return strings.TrimSuffix(repositoryURL, ".git") + "#" + repository.Branch
+}
+
+// NewBulkAnalysis starts the analysis of each repository of request, validated with
+// ValidateBulkRequest, running at most its maxConcurrency at the same time. The analyses that
+// can not start yet are queued and started in background as the running ones finish. jobID
+// identifies the job, which is stored with status running until every analysis is done.
+// client, see ratelimit.AnalysisClient, may have no more than MaxRunningBulkJobs running, or
+// ErrBulkJobsRunning is returned.
+func NewBulkAnalysis(jobID, client string, request types.BulkAnalysisRequest) (types.BulkAnalysisJob, error) {
+	runningJobs, err := apiContext.APIConfiguration.DBInstance.FindAllDBBulkAnalysis(map[string]interface{}{"client": client, "status": StatusRunning})
+	if err != nil && !isNotFound(err) {
+		log.Error(logActionNewBulkAnalysis, logInfoAnalysis, 2046, jobID, err)
+		return types.BulkAnalysisJob{}, err
+	}
+	if len(runningJobs) >= MaxRunningBulkJobs {
+		return types.BulkAnalysisJob{}, ErrBulkJobsRunning
+	}
+	bulkJob := types.BulkAnalysisJob{
+		JobID:          jobID,
+		Client:         client,
+		MaxConcurrency: request.MaxConcurrency,
+		Status:         StatusRunning,
+		Repositories:   []types.BulkAnalysisEntry{},
+		CreatedAt:      time.Now(),
+	}
+	if bulkJob.MaxConcurrency == 0 {
+		bulkJob.MaxConcurrency = DefaultBulkConcurrency
+	}
+	for _, repository := range request.Repositories {
+		bulkJob.Repositories = append(bulkJob.Repositories, types.BulkAnalysisEntry{
+			RepositoryURL: repository.URL,
+			Branch:        repository.Branch,
+			AnalysisID:    uuid.New().String(),
+			Status:        StatusQueued,
+		})
+	}
+	if err := apiContext.APIConfiguration.DBInstance.InsertDBBulkAnalysis(bulkJob); err != nil {
+		log.Error(logActionNewBulkAnalysis, logInfoAnalysis, 2046, jobID, err)
+		return types.BulkAnalysisJob{}, err
+	}
+	metrics.BulkJobSize.Observe(float64(len(bulkJob.Repositories)))
+	log.Info(logActionNewBulkAnalysis, logInfoAnalysis, 49, jobID, len(bulkJob.Repositories))
+
+	followBulkAnalysis(bulkJob, nil)
+	return bulkJob, nil
+}
+
+// ResumeBulkAnalyses follows again the bulk analysis jobs left running when huskyCI last shut
+// down, so their queued analyses still start and they are stored as finished.
+func ResumeBulkAnalyses() error {
+	bulkJobs, err := apiContext.APIConfiguration.DBInstance.FindAllDBBulkAnalysis(map[string]interface{}{"status": StatusRunning})
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, bulkJob := range bulkJobs {
+		running := []string{}
+		for _, entry := range bulkJob.Repositories {
+			if entry.Status == StatusRunning {
+				running = append(running, entry.AnalysisID)
+			}
+		}
+		followBulkAnalysis(bulkJob, running)
+		log.Info("ResumeBulkAnalyses", logInfoAnalysis, 54, bulkJob.JobID)
+	}
+	return nil
+}
+
+// followBulkAnalysis starts the queued analyses of bulkJob, which already has the analyses of
+// running IDs running, and waits for them in background until huskyCI shuts down.
+func followBulkAnalysis(bulkJob types.BulkAnalysisJob, running []string) {
+	started := append(running, startQueuedAnalyses(&bulkJob, NewAnalysis)...)
+	storeBulkAnalysis(bulkJob)
+	// left running in the database if huskyCI is shutting down, to be resumed on startup
+	_ = inFlight.goTracked(func(stopped <-chan struct{}) {
+		waitBulkAnalysis(stopped, bulkJob, started)
+	})
+}
+
+// FindBulkAnalysis returns the bulk analysis job of a given ID. If it does not exist,
+// ErrBulkAnalysisNotFound is returned.
+func FindBulkAnalysis(jobID string) (types.BulkAnalysisJob, error) {
+	bulkJob, err := apiContext.APIConfiguration.DBInstance.FindOneDBBulkAnalysis(map[string]interface{}{"jobID": jobID})
+	if err != nil {
+		if isNotFound(err) {
+			return bulkJob, ErrBulkAnalysisNotFound
+		}
+		log.Error("FindBulkAnalysis", logInfoAnalysis, 1020, err)
+		return bulkJob, err
+	}
+	return bulkJob, nil
+}
+
+// startQueuedAnalyses starts, with start, the queued analyses of bulkJob until its
+// maxConcurrency are running and returns the IDs of the ones it started. An analysis that
+// can not start is rejected along with why, so the next one can take its place, unless huskyCI
+// is shutting down, in which case it stays queued.
+func startQueuedAnalyses(bulkJob *types.BulkAnalysisJob, start func(RID string, repository types.Repository) error) []string {
+	running := 0
+	for _, entry := range bulkJob.Repositories {
+		if entry.Status == StatusRunning {
+			running++
+		}
+	}
+	started := []string{}
+	for i := range bulkJob.Repositories {
+		if running >= bulkJob.MaxConcurrency {
+			break
+		}
+		entry := &bulkJob.Repositories[i]
+		if entry.Status != StatusQueued {
+			continue
+		}
+		repository := types.Repository{URL: entry.RepositoryURL, Branch: entry.Branch}
+		if err := start(entry.AnalysisID, repository); err != nil {
+			if err == ErrShuttingDown {
+				break
+			}
+			entry.Status = StatusRejected
+			entry.Error = err.Error()
+			continue
+		}
+		entry.Status = StatusRunning
+		started = append(started, entry.AnalysisID)
+		running++
+	}
+	return started
+}
+
+// waitBulkAnalysis polls the running analyses of bulkJob, started at the IDs of started,
+// starting the queued ones as they finish, until none is left, and stores the job as finished.
+// An analysis not finished in time, such as one never stored, is considered timed out. It
+// returns once stopped is closed, leaving the job running to be resumed, see ResumeBulkAnalyses.
+func waitBulkAnalysis(stopped <-chan struct{}, bulkJob types.BulkAnalysisJob, started []string) {
+	timeout := apiContext.APIConfiguration.AnalysisTimeout + prAnalysisStartGrace
+	deadlines := map[string]time.Time{}
+	for _, RID := range started {
+		deadlines[RID] = time.Now().Add(timeout)
+	}
+	for len(deadlines) > 0 {
+		select {
+		case <-stopped:
+			return
+		case <-time.After(bulkAnalysisPollInterval):
+		}
+		for i := range bulkJob.Repositories {
+			entry := &bulkJob.Repositories[i]
+			deadline, running := deadlines[entry.AnalysisID]
+			if !running {
+				continue
+			}
+			// an analysis just started may not be stored yet
+			analysisResult, err := FindAnalysis(entry.AnalysisID)
+			if err == nil && analysisResult.Status != StatusRunning {
+				entry.Status = analysisResult.Status
+				entry.Error = analysisResult.ErrorFound
+				delete(deadlines, entry.AnalysisID)
+			} else if time.Now().After(deadline) {
+				entry.Status = StatusTimeout
+				entry.Error = "analysis did not finish in time"
+				delete(deadlines, entry.AnalysisID)
+			}
+		}
+		for _, RID := range startQueuedAnalyses(&bulkJob, NewAnalysis) {
+			deadlines[RID] = time.Now().Add(timeout)
+		}
+		storeBulkAnalysis(bulkJob)
+	}
+	bulkJob.Status = StatusFinished
+	bulkJob.FinishedAt = time.Now()
+	storeBulkAnalysis(bulkJob)
+	log.Info(logActionNewBulkAnalysis, logInfoAnalysis, 50, bulkJob.JobID)
+}
+
+func storeBulkAnalysis(bulkJob types.BulkAnalysisJob) {
+	updatedBulkJob := map[string]interface{}{
+		"status":       bulkJob.Status,
+		"repositories": bulkJob.Repositories,
+		"finishedAt":   bulkJob.FinishedAt,
+	}
+	err := apiContext.APIConfiguration.DBInstance.UpdateOneDBBulkAnalysis(map[string]interface{}{"jobID": bulkJob.JobID}, updatedBulkJob)
+	if err != nil {
+		log.Error(logActionNewBulkAnalysis, logInfoAnalysis, 2046, bulkJob.JobID, err)
+	}
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"fmt"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/db"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Bulk analysis", func() {
+
+	Describe("ValidateBulkRequest", func() {
+		It("Should accept distinct repositories and branches", func() {
+			request := types.BulkAnalysisRequest{Repositories: []types.BulkRepository{
+				{URL: "https://github.com/globocom/huskyCI.git", Branch: "master"},
+				{URL: "https://github.com/globocom/huskyCI.git", Branch: "develop"},
+				{URL: "https://github.com/globocom/gokong.git", Branch: "master"},
+			}, MaxConcurrency: 2}
+			Expect(analysis.ValidateBulkRequest(request)).To(Succeed())
+		})
+
+		It("Should refuse requests without repositories", func() {
+			Expect(analysis.ValidateBulkRequest(types.BulkAnalysisRequest{})).To(Equal(analysis.ErrBulkEmpty))
+		})
+
+		It("Should refuse requests with more than 100 repositories", func() {
+			request := types.BulkAnalysisRequest{}
+			for i := 0; i <= analysis.MaxBulkRepositories; i++ {
+				request.Repositories = append(request.Repositories, types.BulkRepository{URL: fmt.Sprintf("https://github.com/globocom/repo%d.git", i)})
+			}
+			Expect(analysis.ValidateBulkRequest(request)).To(Equal(analysis.ErrBulkTooLarge))
+		})
+
+		It("Should refuse a negative maxConcurrency", func() {
+			request := types.BulkAnalysisRequest{Repositories: []types.BulkRepository{{URL: "https://github.com/globocom/huskyCI.git"}}, MaxConcurrency: -1}
+			Expect(analysis.ValidateBulkRequest(request)).To(Equal(analysis.ErrBulkConcurrency))
+		})
+
+		It("Should refuse the same repository and branch twice, however its URL is spelled", func() {
+			request := types.BulkAnalysisRequest{Repositories: []types.BulkRepository{
+				{URL: "https://github.com/globocom/huskyCI.git", Branch: "master"},
+				{URL: "https://github.com/globocom/huskyci", Branch: "master"},
+			}}
+			Expect(analysis.ValidateBulkRequest(request)).To(HaveOccurred())
+		})
+	})
+
+	Describe("StartQueuedAnalyses", func() {
+
+		queued := func(URLs ...string) types.BulkAnalysisJob {
+			bulkJob := types.BulkAnalysisJob{JobID: "job", MaxConcurrency: 2}
+			for _, URL := range URLs {
+				bulkJob.Repositories = append(bulkJob.Repositories, types.BulkAnalysisEntry{RepositoryURL: URL, AnalysisID: "RID-" + URL, Status: analysis.StatusQueued})
+			}
+			return bulkJob
+		}
+
+		It("Should start no more analyses than maxConcurrency", func() {
+			bulkJob := queued("a", "b", "c")
+			started := analysis.StartQueuedAnalyses(&bulkJob, func(RID string, repository types.Repository) error { return nil })
+			Expect(started).To(Equal([]string{"RID-a", "RID-b"}))
+			Expect(bulkJob.Repositories[2].Status).To(Equal(analysis.StatusQueued))
+		})
+
+		It("Should start the next queued analysis once a running one finishes", func() {
+			bulkJob := queued("a", "b", "c")
+			bulkJob.Repositories[0].Status = analysis.StatusFinished
+			bulkJob.Repositories[1].Status = analysis.StatusRunning
+			started := analysis.StartQueuedAnalyses(&bulkJob, func(RID string, repository types.Repository) error { return nil })
+			Expect(started).To(Equal([]string{"RID-c"}))
+		})
+
+		It("Should reject the analyses that can not start and start the next ones instead", func() {
+			bulkJob := queued("a", "b", "c")
+			started := analysis.StartQueuedAnalyses(&bulkJob, func(RID string, repository types.Repository) error {
+				if repository.URL == "a" {
+					return analysis.ErrAnalysisAlreadyRunning
+				}
+				return nil
+			})
+			Expect(started).To(Equal([]string{"RID-b", "RID-c"}))
+			Expect(bulkJob.Repositories[0].Status).To(Equal(analysis.StatusRejected))
+			Expect(bulkJob.Repositories[0].Error).To(Equal(analysis.ErrAnalysisAlreadyRunning.Error()))
+		})
+
+		It("Should leave the analyses queued once huskyCI is shutting down", func() {
+			bulkJob := queued("a", "b")
+			started := analysis.StartQueuedAnalyses(&bulkJob, func(RID string, repository types.Repository) error {
+				return analysis.ErrShuttingDown
+			})
+			Expect(started).To(BeEmpty())
+			Expect(bulkJob.Repositories[0].Status).To(Equal(analysis.StatusQueued))
+			Expect(bulkJob.Repositories[1].Status).To(Equal(analysis.StatusQueued))
+		})
+	})
+
+	Describe("NewBulkAnalysis", func() {
+
+		var previousConfig *apiContext.APIConfig
+
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+			apiContext.APIConfiguration = &apiContext.APIConfig{DBInstance: &bulkJobsDB{running: analysis.MaxRunningBulkJobs}}
+		})
+
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+
+		It("Should refuse a job of a client that has MaxRunningBulkJobs running", func() {
+			request := types.BulkAnalysisRequest{Repositories: []types.BulkRepository{{URL: "https://github.com/org/a.git", Branch: "main"}}}
+			_, err := analysis.NewBulkAnalysis("job", "token:uuid", request)
+			Expect(err).To(Equal(analysis.ErrBulkJobsRunning))
+		})
+	})
+})
+
+// bulkJobsDB has running bulk analysis jobs of every client. Any other request panics.
+type bulkJobsDB struct {
+	db.Requests
+	running int
+}
+
+func (fDB *bulkJobsDB) FindAllDBBulkAnalysis(mapParams map[string]interface{}) ([]types.BulkAnalysisJob, error) {
+	bulkJobs := []types.BulkAnalysisJob{}
+	for i := 0; i < fDB.running; i++ {
+		bulkJobs = append(bulkJobs, types.BulkAnalysisJob{JobID: fmt.Sprint(i), Client: fmt.Sprint(mapParams["client"]), Status: analysis.StatusRunning})
+	}
+	return bulkJobs, nil
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// Status of the delivery of the callback of an analysis.
+const (
+	CallbackDelivered = "delivered"
+	CallbackFailed    = "failed"
+)
+
+// CallbackPayload returns the payload posted to the callback URL of a finished analysis.
+// It links to the analysis if publicURL is not empty.
+func CallbackPayload(analysisResult types.Analysis, publicURL string) types.AnalysisCallback {
+	payload := types.AnalysisCallback{
+		RID:        analysisResult.RID,
+		URL:        analysisResult.URL,
+		Branch:     analysisResult.Branch,
+		Status:     analysisResult.Status,
+		Result:     analysisResult.Result,
+		Summary:    analysisResult.Summary,
+		FinishedAt: analysisResult.FinishedAt,
+	}
+	if publicURL != "" {
+		payload.Link = publicURL + "/analysis/" + analysisResult.RID
+	}
+	return payload
+}
+
+// notifyCallback posts the finished analysis of RID to callbackURL in background and
+// stores how its delivery went.
+func notifyCallback(RID, callbackURL string) {
+	if callbackURL == "" {
+		return
+	}
+	analysisResult, err := FindAnalysis(RID)
+	if err != nil {
+		log.Error("notifyCallback", logInfoAnalysis, 2034, RID, err)
+		return
+	}
+	config := apiContext.APIConfiguration
+	callback := notifier.NewCallback(callbackURL, config.CallbackSecret, config.CallbackAllowlist, config.CallbackMaxAttempts)
+	callback.DeliverAsync(CallbackPayload(analysisResult, config.PublicURL), func(attempts int, err error) {
+		delivery := types.CallbackDelivery{Status: CallbackDelivered, Attempts: attempts, UpdatedAt: time.Now()}
+		if err != nil {
+			log.Error("notifyCallback", logInfoAnalysis, 2034, RID, err)
+			delivery.Status = CallbackFailed
+			delivery.Error = err.Error()
+		} else {
+			log.Info("notifyCallback", logInfoAnalysis, 42, RID)
+		}
+		analysisQuery := map[string]interface{}{"RID": RID}
+		if err := config.DBInstance.UpdateOneDBAnalysis(analysisQuery, map[string]interface{}{"callback": delivery}); err != nil {
+			log.Error("notifyCallback", logInfoAnalysis, 2011, err)
+		}
+	})
+}
@@ -0,0 +1,51 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CallbackPayload", func() {
+
+	finishedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	analysisResult := types.Analysis{
+		RID:        "RID",
+		URL:        "https://github.com/globocom/huskyCI.git",
+		Branch:     "master",
+		Status:     analysis.StatusFinished,
+		Result:     analysis.ResultFailed,
+		FinishedAt: finishedAt,
+		Summary:    &types.SeveritySummary{High: 1},
+		Containers: []types.Container{{COutput: "raw output"}},
+	}
+
+	Context("When huskyCI has a public URL", func() {
+		It("Should summarize the analysis and link to it", func() {
+			Expect(analysis.CallbackPayload(analysisResult, "https://huskyci.example.com")).To(Equal(types.AnalysisCallback{
+				RID:        "RID",
+				URL:        "https://github.com/globocom/huskyCI.git",
+				Branch:     "master",
+				Status:     analysis.StatusFinished,
+				Result:     analysis.ResultFailed,
+				Summary:    &types.SeveritySummary{High: 1},
+				Link:       "https://huskyci.example.com/analysis/RID",
+				FinishedAt: finishedAt,
+			}))
+		})
+	})
+
+	Context("When huskyCI has no public URL", func() {
+		It("Should not link to the analysis", func() {
+			Expect(analysis.CallbackPayload(analysisResult, "").Link).To(BeEmpty())
+		})
+	})
+})
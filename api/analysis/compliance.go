@@ -0,0 +1,213 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// Compliance frameworks findings can be mapped to.
+const (
+	FrameworkOWASP  = "OWASP"
+	FrameworkCWE    = "CWE"
+	FrameworkPCIDSS = "PCI-DSS"
+	FrameworkNIST   = "NIST"
+)
+
+// ErrInvalidComplianceFilter is returned when a compliance filter is not framework:requirement.
+var ErrInvalidComplianceFilter = errors.New("compliance must be OWASP, CWE, PCI-DSS or NIST followed by :requirement")
+
+// complianceTool holds how the rules of a securityTool are mapped. Default is
+// used by securityTools whose findings are not told apart by rule, such as the
+// ones that check dependencies.
+type complianceTool struct {
+	Default *types.ComplianceMapping           `json:"default"`
+	Rules   map[string]types.ComplianceMapping `json:"rules"`
+}
+
+// complianceMapping is keyed by the lowercase name of each securityTool.
+var complianceMapping map[string]complianceTool
+
+var cweRegexp = regexp.MustCompile(`CWE-\d+`)
+
+// LoadComplianceMapping loads the JSON file that maps the rules of each
+// securityTool to compliance frameworks. Findings are not mapped until it is loaded.
+func LoadComplianceMapping(path string) error {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	tools := map[string]complianceTool{}
+	if err := json.Unmarshal(content, &tools); err != nil {
+		return err
+	}
+	mapping := map[string]complianceTool{}
+	for tool, rules := range tools {
+		mapping[strings.ToLower(tool)] = rules
+	}
+	complianceMapping = mapping
+	return nil
+}
+
+// MapCompliance returns the compliance frameworks requirements violated by a
+// vulnerability found by tool. Rules are looked up by RuleID, then by Type and
+// then by Details, as SpotBugs only reports its bug pattern there.
+func MapCompliance(tool string, vuln types.HuskyCIVulnerability) types.ComplianceMapping {
+	mapping := types.ComplianceMapping{}
+	if rules, ok := complianceMapping[strings.ToLower(tool)]; ok {
+		found := false
+		for _, key := range []string{vuln.RuleID, vuln.Type, vuln.Details} {
+			if key == "" {
+				continue
+			}
+			if ruleMapping, ok := rules.Rules[key]; ok {
+				mapping = mergeCompliance(mapping, ruleMapping)
+				found = true
+				break
+			}
+		}
+		if !found && rules.Default != nil {
+			mapping = mergeCompliance(mapping, *rules.Default)
+		}
+	}
+	// DependencyCheck reports the CWEs of each CVE it finds
+	mapping.CWE = appendUnique(mapping.CWE, cweRegexp.FindAllString(vuln.Type, -1)...)
+	return mapping
+}
+
+// FilterByCompliance returns only the findings that violate a requirement given
+// as framework:requirement, such as PCI-DSS:6.5.1. A requirement also matches the
+// ones under it, so PCI-DSS:6.5 matches PCI-DSS:6.5.1.
+func FilterByCompliance(findings []types.UnifiedFinding, compliance string) ([]types.UnifiedFinding, error) {
+	if compliance == "" {
+		return findings, nil
+	}
+	separator := strings.Index(compliance, ":")
+	if separator < 0 {
+		return nil, ErrInvalidComplianceFilter
+	}
+	framework := normalizeFramework(compliance[:separator])
+	requirement := strings.TrimSpace(compliance[separator+1:])
+	if framework == "" || requirement == "" {
+		return nil, ErrInvalidComplianceFilter
+	}
+	if framework == FrameworkCWE && !strings.HasPrefix(strings.ToUpper(requirement), "CWE-") {
+		requirement = "CWE-" + requirement
+	}
+
+	filtered := []types.UnifiedFinding{}
+	for _, finding := range findings {
+		for _, violated := range frameworkRequirements(finding.Compliance, framework) {
+			if matchesRequirement(violated, requirement) {
+				filtered = append(filtered, finding)
+				break
+			}
+		}
+	}
+	return filtered, nil
+}
+
+// SummarizeCompliance counts how many findings violate each requirement of each
+// compliance framework. Suppressed findings are not counted.
+func SummarizeCompliance(findings []types.UnifiedFinding) []types.ComplianceViolation {
+	frameworks := []string{FrameworkOWASP, FrameworkCWE, FrameworkPCIDSS, FrameworkNIST}
+	counts := map[string]map[string]int{}
+	for _, finding := range findings {
+		if finding.Suppressed {
+			continue
+		}
+		for _, framework := range frameworks {
+			for _, requirement := range frameworkRequirements(finding.Compliance, framework) {
+				if counts[framework] == nil {
+					counts[framework] = map[string]int{}
+				}
+				counts[framework][requirement]++
+			}
+		}
+	}
+
+	violations := []types.ComplianceViolation{}
+	for _, framework := range frameworks {
+		requirements := []string{}
+		for requirement := range counts[framework] {
+			requirements = append(requirements, requirement)
+		}
+		sort.Strings(requirements)
+		for _, requirement := range requirements {
+			violations = append(violations, types.ComplianceViolation{
+				Framework:   framework,
+				Requirement: requirement,
+				Findings:    counts[framework][requirement],
+			})
+		}
+	}
+	return violations
+}
+
+func normalizeFramework(framework string) string {
+	switch strings.ToUpper(strings.TrimSpace(framework)) {
+	case "OWASP":
+		return FrameworkOWASP
+	case "CWE":
+		return FrameworkCWE
+	case "PCI-DSS", "PCIDSS", "PCI":
+		return FrameworkPCIDSS
+	case "NIST":
+		return FrameworkNIST
+	}
+	return ""
+}
+
+func frameworkRequirements(mapping types.ComplianceMapping, framework string) []string {
+	switch framework {
+	case FrameworkOWASP:
+		return mapping.OWASP
+	case FrameworkCWE:
+		return mapping.CWE
+	case FrameworkPCIDSS:
+		return mapping.PCIDSS
+	case FrameworkNIST:
+		return mapping.NIST
+	}
+	return nil
+}
+
+func matchesRequirement(violated, requirement string) bool {
+	violated, requirement = strings.ToUpper(violated), strings.ToUpper(requirement)
+	return violated == requirement ||
+		strings.HasPrefix(violated, requirement+".") ||
+		strings.HasPrefix(violated, requirement+":")
+}
+
+func mergeCompliance(mapping, other types.ComplianceMapping) types.ComplianceMapping {
+	mapping.OWASP = appendUnique(mapping.OWASP, other.OWASP...)
+	mapping.CWE = appendUnique(mapping.CWE, other.CWE...)
+	mapping.PCIDSS = appendUnique(mapping.PCIDSS, other.PCIDSS...)
+	mapping.NIST = appendUnique(mapping.NIST, other.NIST...)
+	return mapping
+}
+
+func appendUnique(values []string, others ...string) []string {
+	for _, other := range others {
+		found := false
+		for _, value := range values {
+			if value == other {
+				found = true
+				break
+			}
+		}
+		if !found {
+			values = append(values, other)
+		}
+	}
+	return values
+}
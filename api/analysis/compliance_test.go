@@ -0,0 +1,203 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Compliance", func() {
+
+	BeforeEach(func() {
+		Expect(analysis.LoadComplianceMapping("../compliance.json")).To(Succeed())
+	})
+	AfterEach(func() {
+		analysis.ResetComplianceMapping()
+	})
+
+	expectCWEs := func(tool string, cwes map[string]string, vuln func(rule string) types.HuskyCIVulnerability) {
+		Expect(len(cwes)).To(BeNumerically(">=", 20))
+		for rule, cwe := range cwes {
+			mapping := analysis.MapCompliance(tool, vuln(rule))
+			Expect(mapping.CWE).To(ContainElement(cwe), rule)
+			Expect(mapping.OWASP).NotTo(BeEmpty(), rule)
+			Expect(mapping.PCIDSS).NotTo(BeEmpty(), rule)
+			Expect(mapping.NIST).NotTo(BeEmpty(), rule)
+		}
+	}
+	byRuleID := func(rule string) types.HuskyCIVulnerability {
+		return types.HuskyCIVulnerability{RuleID: rule}
+	}
+
+	Describe("MapCompliance", func() {
+
+		Context("When GoSec reports a known rule", func() {
+			It("Should map it to its CWE", func() {
+				expectCWEs("GoSec", map[string]string{
+					"G101": "CWE-798", "G102": "CWE-200", "G103": "CWE-242", "G104": "CWE-703",
+					"G106": "CWE-322", "G107": "CWE-88", "G108": "CWE-200", "G109": "CWE-190",
+					"G110": "CWE-409", "G201": "CWE-89", "G202": "CWE-89", "G203": "CWE-79",
+					"G204": "CWE-78", "G301": "CWE-276", "G302": "CWE-276", "G303": "CWE-377",
+					"G304": "CWE-22", "G305": "CWE-22", "G306": "CWE-276", "G401": "CWE-326",
+					"G402": "CWE-295", "G403": "CWE-310", "G404": "CWE-338", "G501": "CWE-327",
+					"G505": "CWE-327",
+				}, byRuleID)
+			})
+			It("Should map SQL injection to OWASP A03 and PCI-DSS 6.5.1", func() {
+				mapping := analysis.MapCompliance("GoSec", byRuleID("G201"))
+				Expect(mapping.OWASP).To(Equal([]string{"A03:2021"}))
+				Expect(mapping.PCIDSS).To(Equal([]string{"6.5.1"}))
+			})
+		})
+
+		Context("When Bandit reports a known rule", func() {
+			It("Should map it to its CWE", func() {
+				expectCWEs("Bandit", map[string]string{
+					"B101": "CWE-703", "B102": "CWE-78", "B103": "CWE-732", "B104": "CWE-605",
+					"B105": "CWE-259", "B106": "CWE-259", "B107": "CWE-259", "B108": "CWE-377",
+					"B201": "CWE-94", "B301": "CWE-502", "B303": "CWE-327", "B307": "CWE-78",
+					"B308": "CWE-79", "B311": "CWE-330", "B312": "CWE-319", "B314": "CWE-20",
+					"B323": "CWE-295", "B501": "CWE-295", "B506": "CWE-20", "B602": "CWE-78",
+					"B608": "CWE-89", "B611": "CWE-89", "B701": "CWE-94",
+				}, byRuleID)
+			})
+		})
+
+		Context("When Brakeman reports a known warning type", func() {
+			It("Should map it to its CWE", func() {
+				expectCWEs("Brakeman", map[string]string{
+					"SQL Injection": "CWE-89", "Cross-Site Scripting": "CWE-79", "Command Injection": "CWE-77",
+					"Cross-Site Request Forgery": "CWE-352", "Mass Assignment": "CWE-915", "Redirect": "CWE-601",
+					"File Access": "CWE-22", "Dynamic Render Path": "CWE-22", "Remote Code Execution": "CWE-913",
+					"Dangerous Eval": "CWE-913", "Unsafe Deserialization": "CWE-502", "Denial of Service": "CWE-20",
+					"Format Validation": "CWE-777", "Session Setting": "CWE-565", "SSL Verification Bypass": "CWE-295",
+					"Missing Encryption": "CWE-311", "Weak Hash": "CWE-328", "Unscoped Find": "CWE-285",
+					"Authentication": "CWE-287", "Basic Auth": "CWE-287", "Information Disclosure": "CWE-200",
+					"Response Splitting": "CWE-113",
+				}, func(rule string) types.HuskyCIVulnerability {
+					return types.HuskyCIVulnerability{Type: rule}
+				})
+			})
+		})
+
+		Context("When SpotBugs reports a known bug pattern", func() {
+			It("Should map it to its CWE", func() {
+				expectCWEs("SpotBugs", map[string]string{
+					"SQL_INJECTION_JDBC": "CWE-89", "SQL_INJECTION_JPA": "CWE-89", "COMMAND_INJECTION": "CWE-78",
+					"LDAP_INJECTION": "CWE-90", "XPATH_INJECTION": "CWE-643", "SPEL_INJECTION": "CWE-94",
+					"CRLF_INJECTION_LOGS": "CWE-117", "PATH_TRAVERSAL_IN": "CWE-22", "PATH_TRAVERSAL_OUT": "CWE-22",
+					"XSS_SERVLET": "CWE-79", "XSS_REQUEST_WRAPPER": "CWE-79", "XXE_DOCUMENT": "CWE-611",
+					"XXE_SAXPARSER": "CWE-611", "WEAK_MESSAGE_DIGEST_MD5": "CWE-327", "DES_USAGE": "CWE-326",
+					"ECB_MODE": "CWE-327", "STATIC_IV": "CWE-329", "PREDICTABLE_RANDOM": "CWE-330",
+					"HARD_CODE_PASSWORD": "CWE-259", "HARD_CODE_KEY": "CWE-321", "WEAK_TRUST_MANAGER": "CWE-295",
+					"UNVALIDATED_REDIRECT": "CWE-601", "INSECURE_COOKIE": "CWE-614", "OBJECT_DESERIALIZATION": "CWE-502",
+					"URLCONNECTION_SSRF_FD": "CWE-918", "SPRING_CSRF_PROTECTION_DISABLED": "CWE-352",
+				}, func(rule string) types.HuskyCIVulnerability {
+					// SpotBugs only reports its bug pattern in Details
+					return types.HuskyCIVulnerability{Type: "SECBUG", Details: rule}
+				})
+			})
+		})
+
+		Context("When GitLeaks reports a leaked secret", func() {
+			It("Should map private keys to CWE-321 and every other secret to CWE-798", func() {
+				expectCWEs("GitLeaks", map[string]string{
+					"PKCS8": "CWE-321", "RSA": "CWE-321", "SSH": "CWE-321", "PGP": "CWE-321", "EC": "CWE-321",
+					"AWS Manager ID": "CWE-798", "AWS Secret Key": "CWE-798", "AWS MWS key": "CWE-798",
+					"Facebook Secret Key": "CWE-798", "Facebook access token": "CWE-798", "Twitter Secret Key": "CWE-798",
+					"LinkedIn Secret Key": "CWE-798", "Github": "CWE-798", "Slack": "CWE-798",
+					"Google OAuth access token": "CWE-798", "Google Cloud Platform API key": "CWE-798",
+					"Heroku API key": "CWE-798", "MailChimp API key": "CWE-798", "Mailgun API key": "CWE-798",
+					"PayPal Braintree access token": "CWE-798", "Stripe API key": "CWE-798", "Twilio API key": "CWE-798",
+				}, byRuleID)
+			})
+		})
+
+		Context("When a securityTool checks dependencies", func() {
+			It("Should map every finding to vulnerable components", func() {
+				for _, tool := range []string{"Safety", "NpmAudit", "YarnAudit", "DependencyCheck"} {
+					for _, rule := range []string{"", "1523", "CVE-2019-10744", "38625"} {
+						mapping := analysis.MapCompliance(tool, byRuleID(rule))
+						Expect(mapping.CWE).To(ContainElement("CWE-1395"), tool)
+						Expect(mapping.OWASP).To(Equal([]string{"A06:2021"}), tool)
+						Expect(mapping.PCIDSS).To(Equal([]string{"6.2"}), tool)
+					}
+				}
+			})
+			It("Should add the CWEs reported by DependencyCheck", func() {
+				mapping := analysis.MapCompliance("DependencyCheck", types.HuskyCIVulnerability{RuleID: "CVE-2020-5398", Type: "CWE-79, CWE-116"})
+				Expect(mapping.CWE).To(Equal([]string{"CWE-1395", "CWE-79", "CWE-116"}))
+			})
+		})
+
+		Context("When the rule is not mapped", func() {
+			It("Should return an empty mapping", func() {
+				Expect(analysis.MapCompliance("GoSec", byRuleID("G999"))).To(Equal(types.ComplianceMapping{}))
+				Expect(analysis.MapCompliance("Unknown", byRuleID("G201"))).To(Equal(types.ComplianceMapping{}))
+			})
+		})
+	})
+
+	Describe("FilterByCompliance", func() {
+		findings := []types.UnifiedFinding{
+			{RuleID: "G201", Compliance: types.ComplianceMapping{OWASP: []string{"A03:2021"}, CWE: []string{"CWE-89"}, PCIDSS: []string{"6.5.1"}}},
+			{RuleID: "G402", Compliance: types.ComplianceMapping{OWASP: []string{"A02:2021"}, CWE: []string{"CWE-295"}, PCIDSS: []string{"6.5.4", "4.1"}}},
+			{RuleID: "G999"},
+		}
+
+		Context("When filtering by a PCI-DSS requirement", func() {
+			It("Should return the findings that violate it or a requirement under it", func() {
+				filtered, err := analysis.FilterByCompliance(findings, "PCI-DSS:6.5.1")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filtered).To(HaveLen(1))
+				Expect(filtered[0].RuleID).To(Equal("G201"))
+
+				filtered, err = analysis.FilterByCompliance(findings, "PCI-DSS:6.5")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filtered).To(HaveLen(2))
+			})
+		})
+		Context("When filtering by OWASP category or CWE number", func() {
+			It("Should match them regardless of year or prefix", func() {
+				filtered, err := analysis.FilterByCompliance(findings, "owasp:A02")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filtered).To(HaveLen(1))
+				Expect(filtered[0].RuleID).To(Equal("G402"))
+
+				filtered, err = analysis.FilterByCompliance(findings, "CWE:89")
+				Expect(err).NotTo(HaveOccurred())
+				Expect(filtered).To(HaveLen(1))
+				Expect(filtered[0].RuleID).To(Equal("G201"))
+			})
+		})
+		Context("When the filter is invalid", func() {
+			It("Should return ErrInvalidComplianceFilter", func() {
+				for _, compliance := range []string{"6.5.1", "HIPAA:164.312", "PCI-DSS:"} {
+					_, err := analysis.FilterByCompliance(findings, compliance)
+					Expect(err).To(Equal(analysis.ErrInvalidComplianceFilter))
+				}
+			})
+		})
+	})
+
+	Describe("SummarizeCompliance", func() {
+		It("Should count the unsuppressed findings of each requirement", func() {
+			findings := []types.UnifiedFinding{
+				{Compliance: types.ComplianceMapping{CWE: []string{"CWE-89"}, PCIDSS: []string{"6.5.1"}}},
+				{Compliance: types.ComplianceMapping{CWE: []string{"CWE-78"}, PCIDSS: []string{"6.5.1"}}},
+				{Compliance: types.ComplianceMapping{CWE: []string{"CWE-89"}}, Suppressed: true},
+			}
+			Expect(analysis.SummarizeCompliance(findings)).To(Equal([]types.ComplianceViolation{
+				{Framework: "CWE", Requirement: "CWE-78", Findings: 1},
+				{Framework: "CWE", Requirement: "CWE-89", Findings: 1},
+				{Framework: "PCI-DSS", Requirement: "6.5.1", Findings: 2},
+			}))
+		})
+	})
+})
@@ -0,0 +1,220 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"sort"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+const (
+	// CorrelateExact groups only findings reported at the very same line.
+	CorrelateExact = "exact"
+	// CorrelateFuzzy groups findings reported up to fuzzyLineDistance lines apart.
+	CorrelateFuzzy = "fuzzy"
+)
+
+// HighConfidenceScore is the lowest ConfidenceScore of a group reported by at least two securityTools.
+const HighConfidenceScore = 0.75
+
+const fuzzyLineDistance = 3
+
+// ruleCategories maps rule IDs of each securityTool into a semantic category,
+// so that the same issue reported by different tools can be correlated.
+var ruleCategories = map[string]string{
+	"G101": "hardcoded-credentials",
+	"B105": "hardcoded-credentials",
+	"B106": "hardcoded-credentials",
+	"B107": "hardcoded-credentials",
+	"G201": "sql-injection",
+	"G202": "sql-injection",
+	"B608": "sql-injection",
+	"G204": "command-injection",
+	"B602": "command-injection",
+	"B603": "command-injection",
+	"B604": "command-injection",
+	"B605": "command-injection",
+	"B606": "command-injection",
+	"B607": "command-injection",
+	"G304": "path-traversal",
+	"G305": "path-traversal",
+	"G401": "weak-crypto",
+	"G501": "weak-crypto",
+	"G505": "weak-crypto",
+	"B303": "weak-crypto",
+	"B304": "weak-crypto",
+	"B324": "weak-crypto",
+	"G402": "insecure-tls",
+	"B501": "insecure-tls",
+}
+
+// categoryKeywords is used when the rule ID is unknown, as Brakeman and Gitleaks
+// describe their findings instead of giving them stable IDs.
+var categoryKeywords = []struct {
+	keyword  string
+	category string
+}{
+	{"sql", "sql-injection"},
+	{"command injection", "command-injection"},
+	{"subprocess", "command-injection"},
+	{"cross-site scripting", "xss"},
+	{"xss", "xss"},
+	{"path traversal", "path-traversal"},
+	{"file access", "path-traversal"},
+	{"password", "hardcoded-credentials"},
+	{"secret", "hardcoded-credentials"},
+	{"credential", "hardcoded-credentials"},
+	{"private key", "hardcoded-credentials"},
+	{"token", "hardcoded-credentials"},
+	{"md5", "weak-crypto"},
+	{"sha1", "weak-crypto"},
+	{"tls", "insecure-tls"},
+}
+
+// Correlate groups findings of different securityTools that point to the same
+// issue, using the strategy set in HUSKYCI_CORRELATE_STRATEGY.
+func Correlate(findings []types.UnifiedFinding) []types.CorrelatedGroup {
+	strategy := CorrelateFuzzy
+	if apiContext.APIConfiguration != nil && apiContext.APIConfiguration.CorrelateStrategy != "" {
+		strategy = apiContext.APIConfiguration.CorrelateStrategy
+	}
+	return CorrelateWithStrategy(findings, strategy)
+}
+
+// CorrelateWithStrategy groups findings by file, semantic rule category and line.
+// Using CorrelateExact, lines must match. Using CorrelateFuzzy, lines may be up to
+// three lines apart from the first finding of the group. Groups are sorted by
+// ConfidenceScore, severity, file and line.
+func CorrelateWithStrategy(findings []types.UnifiedFinding, strategy string) []types.CorrelatedGroup {
+
+	maxDistance := fuzzyLineDistance
+	if strategy == CorrelateExact {
+		maxDistance = 0
+	}
+
+	sorted := make([]types.UnifiedFinding, len(findings))
+	copy(sorted, findings)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].File != sorted[j].File {
+			return sorted[i].File < sorted[j].File
+		}
+		if ruleCategory(sorted[i]) != ruleCategory(sorted[j]) {
+			return ruleCategory(sorted[i]) < ruleCategory(sorted[j])
+		}
+		return sorted[i].Line < sorted[j].Line
+	})
+
+	groups := []types.CorrelatedGroup{}
+	for _, finding := range sorted {
+		category := ruleCategory(finding)
+		if len(groups) > 0 {
+			last := &groups[len(groups)-1]
+			if last.File == finding.File && last.Category == category && finding.Line-last.Line <= maxDistance {
+				last.Findings = append(last.Findings, finding)
+				continue
+			}
+		}
+		groups = append(groups, types.CorrelatedGroup{
+			File:     finding.File,
+			Line:     finding.Line,
+			Category: category,
+			Findings: []types.UnifiedFinding{finding},
+		})
+	}
+
+	for i := range groups {
+		groups[i].Tools = groupTools(groups[i].Findings)
+		groups[i].ConfidenceScore = confidenceScore(len(groups[i].Tools))
+		sort.SliceStable(groups[i].Findings, func(a, b int) bool {
+			return severityRank(groups[i].Findings[a].Severity) > severityRank(groups[i].Findings[b].Severity)
+		})
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		if groups[i].ConfidenceScore != groups[j].ConfidenceScore {
+			return groups[i].ConfidenceScore > groups[j].ConfidenceScore
+		}
+		if groupSeverity(groups[i]) != groupSeverity(groups[j]) {
+			return groupSeverity(groups[i]) > groupSeverity(groups[j])
+		}
+		if groups[i].File != groups[j].File {
+			return groups[i].File < groups[j].File
+		}
+		return groups[i].Line < groups[j].Line
+	})
+
+	return groups
+}
+
+// SortByConfidence returns findings of high confidence correlated groups first,
+// each one with the ConfidenceScore of its group.
+func SortByConfidence(findings []types.UnifiedFinding) []types.UnifiedFinding {
+	sorted := []types.UnifiedFinding{}
+	for _, group := range Correlate(findings) {
+		for _, finding := range group.Findings {
+			finding.ConfidenceScore = group.ConfidenceScore
+			sorted = append(sorted, finding)
+		}
+	}
+	return sorted
+}
+
+// CountHighConfidence returns how many groups were reported by at least two securityTools.
+func CountHighConfidence(groups []types.CorrelatedGroup) int {
+	count := 0
+	for _, group := range groups {
+		if group.ConfidenceScore >= HighConfidenceScore {
+			count++
+		}
+	}
+	return count
+}
+
+func ruleCategory(finding types.UnifiedFinding) string {
+	if category, ok := ruleCategories[strings.ToUpper(finding.RuleID)]; ok {
+		return category
+	}
+	text := strings.ToLower(finding.RuleID + " " + finding.Description)
+	for _, categoryKeyword := range categoryKeywords {
+		if strings.Contains(text, categoryKeyword.keyword) {
+			return categoryKeyword.category
+		}
+	}
+	// unknown rules are only correlated with findings of the very same rule
+	return strings.ToLower(finding.Tool + ":" + finding.RuleID)
+}
+
+func groupTools(findings []types.UnifiedFinding) []string {
+	tools := []string{}
+	seen := map[string]bool{}
+	for _, finding := range findings {
+		if seen[finding.Tool] {
+			continue
+		}
+		seen[finding.Tool] = true
+		tools = append(tools, finding.Tool)
+	}
+	sort.Strings(tools)
+	return tools
+}
+
+// confidenceScore is 0.5 for a single securityTool and gets closer to 1 as more tools agree.
+func confidenceScore(tools int) float64 {
+	score := 1.0
+	for i := 0; i < tools; i++ {
+		score /= 2
+	}
+	return 1 - score
+}
+
+func groupSeverity(group types.CorrelatedGroup) int {
+	if len(group.Findings) == 0 {
+		return 0
+	}
+	return severityRank(group.Findings[0].Severity)
+}
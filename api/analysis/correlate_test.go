@@ -0,0 +1,57 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Correlate", func() {
+
+	findings := []types.UnifiedFinding{
+		{File: "main.go", Line: 10, Tool: "GoSec", RuleID: "G104", Severity: "LOW", Description: "Errors unhandled."},
+		{File: "db.go", Line: 20, Tool: "GoSec", RuleID: "G201", Severity: "MEDIUM", Description: "SQL string formatting"},
+		{File: "db.go", Line: 22, Tool: "Semgrep", RuleID: "go.lang.security.audit.database.string-formatted-query", Severity: "HIGH", Description: "Possible SQL injection"},
+	}
+
+	Context("When the fuzzy strategy is used", func() {
+		groups := analysis.CorrelateWithStrategy(findings, analysis.CorrelateFuzzy)
+
+		It("Should group findings of the same category a few lines apart", func() {
+			Expect(groups).To(HaveLen(2))
+			Expect(groups[0].Category).To(Equal("sql-injection"))
+			Expect(groups[0].Tools).To(Equal([]string{"GoSec", "Semgrep"}))
+			Expect(groups[0].Findings).To(HaveLen(2))
+			Expect(groups[0].Findings[0].Severity).To(Equal("HIGH"))
+		})
+		It("Should surface high confidence groups first", func() {
+			Expect(groups[0].ConfidenceScore).To(BeNumerically(">=", analysis.HighConfidenceScore))
+			Expect(groups[1].ConfidenceScore).To(BeNumerically("<", analysis.HighConfidenceScore))
+			Expect(analysis.CountHighConfidence(groups)).To(Equal(1))
+		})
+	})
+
+	Context("When the exact strategy is used", func() {
+		It("Should only group findings on the same line", func() {
+			groups := analysis.CorrelateWithStrategy(findings, analysis.CorrelateExact)
+			Expect(groups).To(HaveLen(3))
+			Expect(analysis.CountHighConfidence(groups)).To(Equal(0))
+		})
+	})
+
+	Context("When findings are more than three lines apart", func() {
+		It("Should not group them", func() {
+			apart := []types.UnifiedFinding{
+				{File: "db.go", Line: 20, Tool: "GoSec", RuleID: "G201"},
+				{File: "db.go", Line: 24, Tool: "Semgrep", Description: "SQL injection"},
+			}
+			Expect(analysis.CorrelateWithStrategy(apart, analysis.CorrelateFuzzy)).To(HaveLen(2))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// findingsCSVHeader is the first row of every findings CSV export.
+var findingsCSVHeader = []string{
+	"analysisID",
+	"repository",
+	"branch",
+	"tool",
+	"file",
+	"line",
+	"ruleID",
+	"severity",
+	"cvssScore",
+	"description",
+	"suppressed",
+	"detectedAt",
+}
+
+// findingsCSVWriter writes the findings of analyses, only of severity if it is not empty, as
+// CSV. Rows are flushed after each analysis so its writer can stream them.
+type findingsCSVWriter struct {
+	csvWriter *csv.Writer
+	severity  string
+}
+
+// newFindingsCSVWriter returns a findingsCSVWriter into w once it writes the CSV header.
+func newFindingsCSVWriter(w io.Writer, severity string) (*findingsCSVWriter, error) {
+	csvWriter := csv.NewWriter(w)
+	if err := csvWriter.Write(findingsCSVHeader); err != nil {
+		return nil, err
+	}
+	csvWriter.Flush()
+	return &findingsCSVWriter{csvWriter: csvWriter, severity: severity}, csvWriter.Error()
+}
+
+func (findingsWriter *findingsCSVWriter) write(analysis types.Analysis) error {
+	findings := FilterFindings(UnifyFindings(analysis.HuskyCIResults), findingsWriter.severity, "", "")
+	for _, finding := range findings {
+		if err := findingsWriter.csvWriter.Write(findingCSVRow(analysis, finding)); err != nil {
+			return err
+		}
+	}
+	findingsWriter.csvWriter.Flush()
+	return findingsWriter.csvWriter.Error()
+}
+
+// WriteFindingsCSV writes the findings of each analysis, only of severity if it is
+// not empty, as CSV into w. Rows are flushed after each analysis so w can stream them.
+func WriteFindingsCSV(w io.Writer, analyses []types.Analysis, severity string) error {
+	findingsWriter, err := newFindingsCSVWriter(w, severity)
+	if err != nil {
+		return err
+	}
+	for _, analysis := range analyses {
+		if err := findingsWriter.write(analysis); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteFindingsBetweenCSV writes the findings of every analysis started in [since, until), only
+// of repositoryURL and of severity if they are not empty, as CSV into w. Analyses are read one
+// at a time, see ForEachAnalysisBetween, and their rows flushed so w can stream them.
+func WriteFindingsBetweenCSV(w io.Writer, since, until time.Time, repositoryURL, severity string) error {
+	findingsWriter, err := newFindingsCSVWriter(w, severity)
+	if err != nil {
+		return err
+	}
+	return ForEachAnalysisBetween(since, until, repositoryURL, findingsWriter.write)
+}
+
+func findingCSVRow(analysis types.Analysis, finding types.UnifiedFinding) []string {
+	row := []string{
+		analysis.RID,
+		analysis.URL,
+		analysis.Branch,
+		finding.Tool,
+		finding.File,
+		strconv.Itoa(finding.Line),
+		finding.RuleID,
+		finding.Severity,
+		strconv.FormatFloat(finding.CVSSScore, 'f', -1, 64),
+		finding.Description,
+		strconv.FormatBool(finding.Suppressed),
+		analysis.FinishedAt.UTC().Format(time.RFC3339),
+	}
+	for i := range row {
+		row[i] = escapeCSVFormula(row[i])
+	}
+	return row
+}
+
+// escapeCSVFormula prevents spreadsheets from running a cell that came from a scanned
+// repository, such as a description starting with "=", as a formula.
+func escapeCSVFormula(cell string) string {
+	if cell != "" && strings.ContainsAny(cell[:1], "=+-@\t\r") {
+		return "'" + cell
+	}
+	return cell
+}
+
+// ForEachAnalysisBetween calls each with the analyses started in [since, until), only of
+// repositoryURL if it is not empty, one at a time as they are read from the database. Only
+// the fields needed to list their findings are read. It stops at the first error of each.
+func ForEachAnalysisBetween(since, until time.Time, repositoryURL string, each func(types.Analysis) error) error {
+	analysisQuery := map[string]interface{}{"startedAt": bson.M{"$gte": since, "$lt": until}}
+	if repositoryURL != "" {
+		analysisQuery["repositoryURL"] = repositoryURL
+	}
+	var eachErr error
+	err := apiContext.APIConfiguration.DBInstance.ForEachDBAnalysis(analysisQuery, func(analysisResult types.Analysis) error {
+		eachErr = each(analysisResult)
+		return eachErr
+	})
+	if err != nil && err != eachErr {
+		log.Error("ForEachAnalysisBetween", logInfoAnalysis, 1020, err)
+	}
+	return err
+}
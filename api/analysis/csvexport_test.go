@@ -0,0 +1,110 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"bytes"
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/db"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// cursorDB returns its analyses one at a time, failing with err once they end if it is set.
+// Any other request panics.
+type cursorDB struct {
+	db.Requests
+	analyses []types.Analysis
+	err      error
+}
+
+func (fDB *cursorDB) ForEachDBAnalysis(mapParams map[string]interface{}, each func(types.Analysis) error) error {
+	for _, analysisResult := range fDB.analyses {
+		if err := each(analysisResult); err != nil {
+			return err
+		}
+	}
+	return fDB.err
+}
+
+var _ = Describe("Export", func() {
+
+	Describe("WriteFindingsCSV", func() {
+		analysisResult := types.Analysis{
+			RID:        "a1b2",
+			URL:        "https://github.com/globocom/huskyCI.git",
+			Branch:     "master",
+			FinishedAt: time.Date(2020, time.March, 30, 15, 4, 5, 0, time.UTC),
+		}
+		analysisResult.HuskyCIResults.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{
+			{SecurityTool: "Bandit", Severity: "HIGH", File: "app.py", Line: "3", RuleID: "B602", Details: "subprocess call with shell=True"},
+		}
+		analysisResult.HuskyCIResults.PythonResults.HuskyCIBanditOutput.LowVulns = []types.HuskyCIVulnerability{
+			{SecurityTool: "Bandit", Severity: "LOW", File: "app.py", Line: "7", RuleID: "B101", Details: "=HYPERLINK(\"http://evil\")"},
+		}
+
+		Context("When an analysis has findings", func() {
+			It("Should write a header and a row for each finding", func() {
+				buffer := &bytes.Buffer{}
+				Expect(analysis.WriteFindingsCSV(buffer, []types.Analysis{analysisResult}, "")).To(Succeed())
+				Expect(buffer.String()).To(Equal(
+					"analysisID,repository,branch,tool,file,line,ruleID,severity,cvssScore,description,suppressed,detectedAt\n" +
+						"a1b2,https://github.com/globocom/huskyCI.git,master,Bandit,app.py,3,B602,HIGH,0,subprocess call with shell=True,false,2020-03-30T15:04:05Z\n" +
+						"a1b2,https://github.com/globocom/huskyCI.git,master,Bandit,app.py,7,B101,LOW,0,\"'=HYPERLINK(\"\"http://evil\"\")\",false,2020-03-30T15:04:05Z\n"))
+			})
+		})
+
+		Context("When a severity is given", func() {
+			It("Should only write findings of it", func() {
+				buffer := &bytes.Buffer{}
+				Expect(analysis.WriteFindingsCSV(buffer, []types.Analysis{analysisResult}, "high")).To(Succeed())
+				Expect(buffer.String()).To(ContainSubstring("B602"))
+				Expect(buffer.String()).ToNot(ContainSubstring("B101"))
+			})
+		})
+	})
+
+	Describe("WriteFindingsBetweenCSV", func() {
+		var previousConfig *apiContext.APIConfig
+		var fakeDB *cursorDB
+
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+			first := types.Analysis{RID: "a1b2", URL: "repo"}
+			first.HuskyCIResults.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{
+				{SecurityTool: "Bandit", Severity: "HIGH", File: "app.py", Line: "3", RuleID: "B602"},
+			}
+			second := types.Analysis{RID: "c3d4", URL: "repo"}
+			second.HuskyCIResults.PythonResults.HuskyCIBanditOutput.LowVulns = []types.HuskyCIVulnerability{
+				{SecurityTool: "Bandit", Severity: "LOW", File: "app.py", Line: "7", RuleID: "B101"},
+			}
+			fakeDB = &cursorDB{analyses: []types.Analysis{first, second}}
+			apiContext.APIConfiguration = &apiContext.APIConfig{DBInstance: fakeDB}
+		})
+
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+
+		It("Should write the findings of every analysis read from the cursor", func() {
+			buffer := &bytes.Buffer{}
+			Expect(analysis.WriteFindingsBetweenCSV(buffer, time.Time{}, time.Now(), "repo", "")).To(Succeed())
+			Expect(buffer.String()).To(ContainSubstring("a1b2,repo,,Bandit,app.py,3,B602"))
+			Expect(buffer.String()).To(ContainSubstring("c3d4,repo,,Bandit,app.py,7,B101"))
+		})
+		It("Should keep the rows already written when the cursor fails", func() {
+			fakeDB.err = errors.New("cursor killed")
+			buffer := &bytes.Buffer{}
+			Expect(analysis.WriteFindingsBetweenCSV(buffer, time.Time{}, time.Now(), "repo", "")).To(MatchError("cursor killed"))
+			Expect(buffer.String()).To(ContainSubstring("c3d4"))
+		})
+	})
+})
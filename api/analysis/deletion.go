@@ -0,0 +1,259 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/archive"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/stats"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrDeletionDisabled is returned when analyses are never deleted, as no maximum age is configured.
+var ErrDeletionDisabled = errors.New("analysis deletion is disabled")
+
+// ErrDeletionRunning is returned when the deletion job is asked to run while it already is.
+var ErrDeletionRunning = errors.New("deletion job already running")
+
+// ErrAnalysisRunning is returned when an analysis that is still running would be deleted.
+var ErrAnalysisRunning = errors.New("analysis is still running")
+
+// DeletedByRetention is who the analyses deleted by the deletion job are logged as deleted by.
+const DeletedByRetention = "retention job"
+
+// deletionBatchSize is how many analyses DeleteAnalyses reads and deletes at once.
+const deletionBatchSize = 100
+
+// deletionBatchDelay is how long DeleteAnalyses waits between batches, so the database is
+// not hammered while it deletes a large backlog.
+const deletionBatchDelay = time.Second
+
+// deletionState is the deletion job run in progress, if any, and the last one that finished.
+var deletionState = struct {
+	sync.Mutex
+	running bool
+	lastRun *types.DeletionRun
+}{}
+
+// IsDeletable returns whether analysisResult, one of a repository whose keep-th most recent
+// analysis started at threshold, can be deleted: it is older than every analysis kept.
+func IsDeletable(analysisResult types.Analysis, keep int, threshold time.Time) bool {
+	return keep == 0 || analysisResult.StartedAt.Before(threshold)
+}
+
+// keepThreshold returns when the keep-th most recent analysis of repositoryURL started, or
+// a zero time if it has fewer analyses than keep, so every one of them is kept.
+func keepThreshold(repositoryURL string, keep int) (time.Time, error) {
+	if keep == 0 {
+		return time.Time{}, nil
+	}
+	analysisQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	analyses, _, err := apiContext.APIConfiguration.DBInstance.FindPageDBAnalysis(analysisQuery, keep-1, 1)
+	if err != nil {
+		if isNotFound(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, err
+	}
+	if len(analyses) == 0 {
+		return time.Time{}, nil
+	}
+	return analyses[0].StartedAt, nil
+}
+
+// DeleteAnalyses deletes every analysis that finished before olderThan, in batches, unless it
+// is one of the keep most recent analyses of its repository. It returns how many it deleted.
+func DeleteAnalyses(olderThan time.Time, keep int) (int, error) {
+	analysisQuery := map[string]interface{}{
+		"status":     bson.M{"$ne": StatusRunning},
+		"finishedAt": bson.M{"$lt": olderThan},
+	}
+	thresholds := map[string]time.Time{}
+	deleted, kept := 0, 0
+	for {
+		// deleted analyses leave the query, so only the ones kept are skipped
+		analyses, err := apiContext.APIConfiguration.DBInstance.FindFinishedPageDBAnalysis(analysisQuery, kept, deletionBatchSize)
+		if err != nil {
+			if isNotFound(err) {
+				break
+			}
+			return deleted, err
+		}
+		if len(analyses) == 0 {
+			break
+		}
+		batch := []types.Analysis{}
+		for _, listed := range analyses {
+			threshold, ok := thresholds[listed.URL]
+			if !ok {
+				if threshold, err = keepThreshold(listed.URL, keep); err != nil {
+					return deleted, err
+				}
+				thresholds[listed.URL] = threshold
+			}
+			if !IsDeletable(listed, keep, threshold) {
+				kept++
+				continue
+			}
+			batch = append(batch, listed)
+		}
+		if len(batch) > 0 {
+			if err := removeAnalyses(batch, DeletedByRetention); err != nil {
+				return deleted, err
+			}
+			deleted += len(batch)
+		}
+		if len(analyses) < deletionBatchSize {
+			break
+		}
+		time.Sleep(deletionBatchDelay)
+	}
+	return deleted, nil
+}
+
+// DeleteAnalysis deletes the analysis of RID, logging deletedBy as who deleted it. It returns
+// ErrAnalysisRunning if the analysis is still running.
+func DeleteAnalysis(RID, deletedBy string) error {
+	analysisResult, err := FindAnalysis(RID)
+	if err != nil {
+		return err
+	}
+	if analysisResult.Status == StatusRunning {
+		return ErrAnalysisRunning
+	}
+	if err := removeAnalyses([]types.Analysis{analysisResult}, deletedBy); err != nil {
+		return err
+	}
+	refreshStats()
+	return nil
+}
+
+// removeAnalyses removes analyses, their archived copy, their share tokens and their timelines,
+// logging each one of them as deleted by deletedBy. No analysis is removed if an archived copy
+// could not be, so it is not left behind in the archive backend.
+func removeAnalyses(analyses []types.Analysis, deletedBy string) error {
+	if err := removeArchivedAnalyses(analyses); err != nil {
+		return err
+	}
+	RIDs := make([]string, 0, len(analyses))
+	for _, analysisResult := range analyses {
+		RIDs = append(RIDs, analysisResult.RID)
+	}
+	RIDQuery := map[string]interface{}{"RID": bson.M{"$in": RIDs}}
+	if err := apiContext.APIConfiguration.DBInstance.RemoveDBAnalyses(RIDQuery); err != nil {
+		return err
+	}
+	for _, analysisResult := range analyses {
+		log.Info("removeAnalyses", logInfoAnalysis, 46, analysisResult.RID, analysisResult.URL, deletedBy)
+	}
+	// a share token left behind gives access to nothing, so it can be left to expire
+	if err := apiContext.APIConfiguration.DBInstance.RemoveDBAnalysisShares(RIDQuery); err != nil && !isNotFound(err) {
+		log.Error("removeAnalyses", logInfoAnalysis, 2045, err)
+	}
+	if err := apiContext.APIConfiguration.DBInstance.RemoveDBAnalysisEvents(RIDQuery); err != nil && !isNotFound(err) {
+		log.Error("removeAnalyses", logInfoAnalysis, 2045, err)
+	}
+	return nil
+}
+
+// removeArchivedAnalyses removes the archived copy of the analyses that were archived.
+func removeArchivedAnalyses(analyses []types.Analysis) error {
+	var backend archive.Backend
+	for _, analysisResult := range analyses {
+		if !analysisResult.Archived || analysisResult.ArchiveKey == "" {
+			continue
+		}
+		if backend == nil {
+			var err error
+			if backend, err = archiveBackend(); err != nil {
+				return err
+			}
+		}
+		if err := backend.Delete(analysisResult.ArchiveKey); err != nil && err != archive.ErrObjectNotFound {
+			log.Error("removeAnalyses", logInfoAnalysis, 2052, analysisResult.RID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// refreshStats recomputes the cached statistics of analyses, if they are cached, so they stop
+// counting the ones that were deleted.
+func refreshStats() {
+	service := stats.NewService()
+	if service.Cache == nil {
+		return
+	}
+	if _, err := service.GetSummary(true); err != nil {
+		log.Error("refreshStats", logInfoAnalysis, 2023, err)
+	}
+}
+
+// RunDeletionJob deletes the analyses older than the maximum age and returns how it went. It
+// returns ErrDeletionRunning if it is already running.
+func RunDeletionJob() (types.DeletionRun, error) {
+	run := types.DeletionRun{StartedAt: time.Now()}
+	config := apiContext.APIConfiguration
+	if config.AnalysisMaxAge <= 0 {
+		return run, ErrDeletionDisabled
+	}
+	deletionState.Lock()
+	if deletionState.running {
+		deletionState.Unlock()
+		return run, ErrDeletionRunning
+	}
+	deletionState.running = true
+	deletionState.Unlock()
+
+	deleted, err := DeleteAnalyses(run.StartedAt.Add(-config.AnalysisMaxAge), config.AnalysisKeepPerRepository)
+	run.Deleted = deleted
+	if err != nil {
+		run.Error = err.Error()
+		log.Error("RunDeletionJob", logInfoAnalysis, 2045, err)
+	}
+	if deleted > 0 {
+		refreshStats()
+	}
+	run.FinishedAt = time.Now()
+	log.Info("RunDeletionJob", logInfoAnalysis, 47, run.Deleted)
+
+	deletionState.Lock()
+	deletionState.running = false
+	deletionState.lastRun = &run
+	deletionState.Unlock()
+	return run, nil
+}
+
+// NextDeletionRun returns when the deletion job runs next after now: at 1am UTC, after the
+// archive job.
+func NextDeletionRun(now time.Time) time.Time {
+	next := now.UTC().Truncate(24 * time.Hour).Add(time.Hour)
+	if !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// StartDeletionJob runs RunDeletionJob every night in background, if a maximum age of
+// analyses is configured.
+func StartDeletionJob() {
+	if apiContext.APIConfiguration.AnalysisMaxAge <= 0 {
+		return
+	}
+	go func() {
+		for {
+			time.Sleep(time.Until(NextDeletionRun(time.Now())))
+			if _, err := RunDeletionJob(); err != nil && err != ErrDeletionRunning {
+				log.Error("StartDeletionJob", logInfoAnalysis, 2045, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,50 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Deletion", func() {
+
+	threshold := time.Date(2020, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	Describe("IsDeletable", func() {
+		It("Should delete analyses started before the most recent ones kept", func() {
+			older := types.Analysis{RID: "older", StartedAt: threshold.Add(-time.Hour)}
+			Expect(analysis.IsDeletable(older, 10, threshold)).To(BeTrue())
+		})
+		It("Should keep the most recent ones", func() {
+			kept := types.Analysis{RID: "kept", StartedAt: threshold}
+			Expect(analysis.IsDeletable(kept, 10, threshold)).To(BeFalse())
+		})
+		It("Should keep every analysis of repositories with fewer analyses than kept", func() {
+			older := types.Analysis{RID: "older", StartedAt: threshold.Add(-time.Hour)}
+			Expect(analysis.IsDeletable(older, 10, time.Time{})).To(BeFalse())
+		})
+		It("Should delete every analysis if none is kept", func() {
+			kept := types.Analysis{RID: "kept", StartedAt: threshold}
+			Expect(analysis.IsDeletable(kept, 0, time.Time{})).To(BeTrue())
+		})
+	})
+
+	Describe("NextDeletionRun", func() {
+		It("Should return 1am UTC of the same day before it", func() {
+			now := time.Date(2020, 3, 10, 0, 30, 0, 0, time.UTC)
+			Expect(analysis.NextDeletionRun(now)).To(Equal(time.Date(2020, 3, 10, 1, 0, 0, 0, time.UTC)))
+		})
+		It("Should return 1am UTC of the next day after it", func() {
+			now := time.Date(2020, 3, 10, 1, 0, 0, 0, time.UTC)
+			Expect(analysis.NextDeletionRun(now)).To(Equal(time.Date(2020, 3, 11, 1, 0, 0, 0, time.UTC)))
+		})
+	})
+})
@@ -0,0 +1,91 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// ErrDiffRepositoryMismatch is returned when the analyses compared by DiffAnalyses
+// are not of the same repository.
+var ErrDiffRepositoryMismatch = errors.New("analyses are not of the same repository")
+
+// FindingFingerprint returns a fingerprint of finding that is stable across analyses:
+// it only depends on its rule, file and CVE, so it does not change when code around
+// it moves the finding to another line.
+func FindingFingerprint(finding types.UnifiedFinding) string {
+	cve := cveRegexp.FindString(finding.RuleID)
+	if cve == "" {
+		cve = cveRegexp.FindString(finding.Description)
+	}
+	sum := sha256.Sum256([]byte(strings.Join([]string{finding.RuleID, finding.File, cve}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// DiffAnalyses returns the findings of the analysis of headID that the analysis of baseID
+// did not have and the findings of baseID that headID does not have anymore.
+func DiffAnalyses(baseID, headID string) ([]types.UnifiedFinding, []types.UnifiedFinding, error) {
+	baseAnalysis, err := FindAnalysis(baseID)
+	if err != nil {
+		return nil, nil, err
+	}
+	headAnalysis, err := FindAnalysis(headID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if baseAnalysis.URL != headAnalysis.URL {
+		return nil, nil, ErrDiffRepositoryMismatch
+	}
+	newFindings, fixedFindings := DiffFindings(UnifyFindings(baseAnalysis.HuskyCIResults), UnifyFindings(headAnalysis.HuskyCIResults))
+	return newFindings, fixedFindings, nil
+}
+
+// DiffFindings returns the findings of head whose fingerprint is not in base and the
+// findings of base whose fingerprint is not in head. A fingerprint found more times
+// in head than in base has its extra findings reported as new, and the other way
+// around as fixed. Suppressed findings are never reported.
+func DiffFindings(base, head []types.UnifiedFinding) ([]types.UnifiedFinding, []types.UnifiedFinding) {
+	newFindings, fixedFindings, _ := CompareFindings(base, head)
+	return newFindings, fixedFindings
+}
+
+// CompareFindings returns the new and fixed findings of DiffFindings and the findings
+// of head that were matched by one of base, so they persist in head.
+func CompareFindings(base, head []types.UnifiedFinding) ([]types.UnifiedFinding, []types.UnifiedFinding, []types.UnifiedFinding) {
+	newFindings, persistentFindings := matchFindings(head, base)
+	fixedFindings, _ := matchFindings(base, head)
+	return newFindings, fixedFindings, persistentFindings
+}
+
+// matchFindings splits findings into the ones not matched by a finding of others with
+// the same fingerprint and the ones matched, keeping their order.
+func matchFindings(findings, others []types.UnifiedFinding) ([]types.UnifiedFinding, []types.UnifiedFinding) {
+	remaining := map[string]int{}
+	for _, other := range others {
+		if !other.Suppressed {
+			remaining[FindingFingerprint(other)]++
+		}
+	}
+	unmatched := []types.UnifiedFinding{}
+	matched := []types.UnifiedFinding{}
+	for _, finding := range findings {
+		if finding.Suppressed {
+			continue
+		}
+		fingerprint := FindingFingerprint(finding)
+		if remaining[fingerprint] > 0 {
+			remaining[fingerprint]--
+			matched = append(matched, finding)
+			continue
+		}
+		unmatched = append(unmatched, finding)
+	}
+	return unmatched, matched
+}
@@ -0,0 +1,111 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Diff", func() {
+
+	Describe("FindingFingerprint", func() {
+		finding := types.UnifiedFinding{Tool: "Bandit", RuleID: "B105", File: "app.py", Line: 3, Severity: "LOW", Description: "Possible hardcoded password"}
+
+		It("Should be deterministic", func() {
+			Expect(analysis.FindingFingerprint(finding)).To(Equal(analysis.FindingFingerprint(finding)))
+			Expect(analysis.FindingFingerprint(finding)).To(HaveLen(64))
+		})
+
+		It("Should not depend on the line or the severity of a finding", func() {
+			moved := finding
+			moved.Line = 42
+			moved.Severity = "MEDIUM"
+			Expect(analysis.FindingFingerprint(moved)).To(Equal(analysis.FindingFingerprint(finding)))
+		})
+
+		It("Should depend on its rule and file", func() {
+			otherRule := finding
+			otherRule.RuleID = "B106"
+			otherFile := finding
+			otherFile.File = "other.py"
+			Expect(analysis.FindingFingerprint(otherRule)).ToNot(Equal(analysis.FindingFingerprint(finding)))
+			Expect(analysis.FindingFingerprint(otherFile)).ToNot(Equal(analysis.FindingFingerprint(finding)))
+		})
+
+		It("Should depend on the CVE of its description", func() {
+			dependency := types.UnifiedFinding{Tool: "Safety", RuleID: "vulnerability", File: "requirements.txt", Description: "django: CVE-2019-19844"}
+			otherCVE := dependency
+			otherCVE.Description = "django: CVE-2020-7471"
+			Expect(analysis.FindingFingerprint(otherCVE)).ToNot(Equal(analysis.FindingFingerprint(dependency)))
+		})
+	})
+
+	Describe("DiffFindings", func() {
+		kept := types.UnifiedFinding{Tool: "GoSec", RuleID: "G104", File: "main.go", Line: 10, Severity: "LOW"}
+		fixed := types.UnifiedFinding{Tool: "GoSec", RuleID: "G101", File: "config.go", Line: 5, Severity: "HIGH"}
+		introduced := types.UnifiedFinding{Tool: "GoSec", RuleID: "G402", File: "client.go", Line: 20, Severity: "HIGH"}
+
+		Context("When findings were introduced and fixed", func() {
+			It("Should return them apart from the ones kept", func() {
+				movedKept := kept
+				movedKept.Line = 12
+				newFindings, fixedFindings := analysis.DiffFindings(
+					[]types.UnifiedFinding{kept, fixed},
+					[]types.UnifiedFinding{movedKept, introduced},
+				)
+				Expect(newFindings).To(Equal([]types.UnifiedFinding{introduced}))
+				Expect(fixedFindings).To(Equal([]types.UnifiedFinding{fixed}))
+			})
+		})
+
+		Context("When head has more findings of a fingerprint than base", func() {
+			It("Should only return the extra ones as new", func() {
+				again := kept
+				again.Line = 30
+				newFindings, fixedFindings := analysis.DiffFindings(
+					[]types.UnifiedFinding{kept},
+					[]types.UnifiedFinding{kept, again},
+				)
+				Expect(newFindings).To(Equal([]types.UnifiedFinding{again}))
+				Expect(fixedFindings).To(BeEmpty())
+			})
+		})
+
+		Context("When a finding is suppressed", func() {
+			It("Should not return it", func() {
+				suppressed := introduced
+				suppressed.Suppressed = true
+				newFindings, fixedFindings := analysis.DiffFindings(
+					[]types.UnifiedFinding{kept},
+					[]types.UnifiedFinding{kept, suppressed},
+				)
+				Expect(newFindings).To(BeEmpty())
+				Expect(fixedFindings).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("CompareFindings", func() {
+		kept := types.UnifiedFinding{Tool: "GoSec", RuleID: "G104", File: "main.go", Line: 10, Severity: "LOW"}
+		fixed := types.UnifiedFinding{Tool: "GoSec", RuleID: "G101", File: "config.go", Line: 5, Severity: "HIGH"}
+		introduced := types.UnifiedFinding{Tool: "GoSec", RuleID: "G402", File: "client.go", Line: 20, Severity: "HIGH"}
+
+		It("Should return the findings of head that base already had as persistent", func() {
+			movedKept := kept
+			movedKept.Line = 12
+			newFindings, fixedFindings, persistentFindings := analysis.CompareFindings(
+				[]types.UnifiedFinding{kept, fixed},
+				[]types.UnifiedFinding{movedKept, introduced},
+			)
+			Expect(newFindings).To(Equal([]types.UnifiedFinding{introduced}))
+			Expect(fixedFindings).To(Equal([]types.UnifiedFinding{fixed}))
+			Expect(persistentFindings).To(Equal([]types.UnifiedFinding{movedKept}))
+		})
+	})
+})
@@ -0,0 +1,26 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
+	goContext "golang.org/x/net/context"
+)
+
+// StartDockerEventMonitor watches the Docker event stream in background and fails the
+// analyses whose containers die, run out of memory or are removed while they still run,
+// with the reason of the event, such as "docker_event:die".
+func StartDockerEventMonitor() error {
+	monitor, err := huskydocker.NewDockerEventMonitor(func(event huskydocker.ContainerEvent) {
+		FailAnalysis(event.RID, errors.New(event.Reason()))
+	})
+	if err != nil {
+		return err
+	}
+	monitor.Start(goContext.Background())
+	return nil
+}
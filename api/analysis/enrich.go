@@ -0,0 +1,193 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"context"
+	"regexp"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/nvd"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const logActionEnrich = "EnrichCVEs"
+
+var cveRegexp = regexp.MustCompile(`CVE-\d{4}-\d{4,}`)
+
+// CVESource returns NVD data of a CVE.
+type CVESource interface {
+	FetchCVE(ctx context.Context, cve string) (types.NVDEntry, error)
+}
+
+// CVECache stores NVD data of CVEs already fetched.
+type CVECache interface {
+	FindCVE(cve string) (types.NVDEntry, error)
+	FindStaleCVEs(updatedBefore time.Time) ([]types.NVDEntry, error)
+	SaveCVE(entry types.NVDEntry) error
+}
+
+// CVEEnricher attaches CVSSv3 score, vector and NVD URL to vulnerabilities
+// that have a CVE. Cached data is used whenever it is newer than MaxAge and
+// NVD is never queried for longer than Timeout during an analysis.
+type CVEEnricher struct {
+	Cache   CVECache
+	Source  CVESource
+	Timeout time.Duration
+	MaxAge  time.Duration
+}
+
+// NewCVEEnricher returns a CVEEnricher that caches NVD data into MongoDB.
+func NewCVEEnricher() *CVEEnricher {
+	return &CVEEnricher{
+		Cache:   dbCVECache{},
+		Source:  nvd.NewClient(),
+		Timeout: 5 * time.Second,
+		MaxAge:  7 * 24 * time.Hour,
+	}
+}
+
+// Enrich attaches NVD data to every vulnerability of results that has a CVE.
+// It is best-effort: CVEs that could not be looked up are left untouched.
+func (e *CVEEnricher) Enrich(results *types.HuskyCIResults) {
+	ctx, cancel := context.WithTimeout(context.Background(), e.Timeout)
+	defer cancel()
+
+	entries := map[string]*types.NVDEntry{}
+	for _, output := range securityTestOutputs(results) {
+		for _, vulns := range [][]types.HuskyCIVulnerability{output.HighVulns, output.MediumVulns, output.LowVulns, output.NoSecVulns} {
+			for i := range vulns {
+				cve := findCVE(vulns[i])
+				if cve == "" {
+					continue
+				}
+				entry, ok := entries[cve]
+				if !ok {
+					entry = e.lookup(ctx, cve)
+					entries[cve] = entry
+				}
+				if entry != nil {
+					applyNVDEntry(&vulns[i], *entry)
+				}
+			}
+		}
+	}
+}
+
+// RefreshStale fetches again every cached CVE older than MaxAge.
+func (e *CVEEnricher) RefreshStale() error {
+	staleEntries, err := e.Cache.FindStaleCVEs(time.Now().Add(-e.MaxAge))
+	if err != nil {
+		return err
+	}
+	log.Info("RefreshStale", logInfoAnalysis, 26, len(staleEntries))
+	for _, staleEntry := range staleEntries {
+		entry, err := e.Source.FetchCVE(context.Background(), staleEntry.CVE)
+		if err != nil {
+			log.Warning("RefreshStale", logInfoAnalysis, 113, staleEntry.CVE, err)
+			continue
+		}
+		if err := e.Cache.SaveCVE(entry); err != nil {
+			log.Error("RefreshStale", logInfoAnalysis, 2019, staleEntry.CVE, err)
+		}
+	}
+	return nil
+}
+
+// StartNVDCacheRefresher refreshes stale NVD data every interval in background.
+func StartNVDCacheRefresher(interval time.Duration) {
+	enricher := NewCVEEnricher()
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := enricher.RefreshStale(); err != nil {
+				log.Error("StartNVDCacheRefresher", logInfoAnalysis, 2018, err)
+			}
+		}
+	}()
+}
+
+func (e *CVEEnricher) lookup(ctx context.Context, cve string) *types.NVDEntry {
+	cached, err := e.Cache.FindCVE(cve)
+	found := err == nil && cached.CVE != ""
+	if found && time.Since(cached.UpdatedAt) < e.MaxAge {
+		return &cached
+	}
+	if ctx.Err() == nil {
+		entry, err := e.Source.FetchCVE(ctx, cve)
+		if err == nil {
+			if err := e.Cache.SaveCVE(entry); err != nil {
+				log.Error(logActionEnrich, logInfoAnalysis, 2019, cve, err)
+			}
+			return &entry
+		}
+		log.Warning(logActionEnrich, logInfoAnalysis, 113, cve, err)
+	}
+	// outdated data is still better than none
+	if found {
+		return &cached
+	}
+	return nil
+}
+
+func findCVE(vuln types.HuskyCIVulnerability) string {
+	for _, field := range []string{vuln.RuleID, vuln.Type, vuln.Details} {
+		if cve := cveRegexp.FindString(field); cve != "" {
+			return cve
+		}
+	}
+	return ""
+}
+
+func applyNVDEntry(vuln *types.HuskyCIVulnerability, entry types.NVDEntry) {
+	if entry.CVSSv3Score > 0 {
+		vuln.CVSSScore = entry.CVSSv3Score
+	}
+	vuln.CVSSVector = entry.CVSSv3Vector
+	vuln.NVDURL = entry.URL
+}
+
+func securityTestOutputs(results *types.HuskyCIResults) []*types.HuskyCISecurityTestOutput {
+	outputs := []*types.HuskyCISecurityTestOutput{
+		&results.GoResults.HuskyCIGosecOutput,
+		&results.PythonResults.HuskyCIBanditOutput,
+		&results.PythonResults.HuskyCISafetyOutput,
+		&results.JavaScriptResults.HuskyCINpmAuditOutput,
+		&results.JavaScriptResults.HuskyCIYarnAuditOutput,
+		&results.JavaScriptResults.HuskyCIEslintOutput,
+		&results.JavaResults.HuskyCISpotBugsOutput,
+		&results.JavaResults.HuskyCIDependencyCheckOutput,
+		&results.RubyResults.HuskyCIBrakemanOutput,
+		&results.GenericResults.HuskyCIGitleaksOutput,
+		&results.GenericResults.HuskyCIDepConfusionOutput,
+		&results.GenericResults.HuskyCIHadolintOutput,
+	}
+	// a copy of a plugin output shares its vulnerabilities, so they are enriched in place
+	for _, name := range pluginNames(results.PluginResults) {
+		output := results.PluginResults[name]
+		outputs = append(outputs, &output)
+	}
+	return outputs
+}
+
+// dbCVECache stores NVD data using the configured DBInstance.
+type dbCVECache struct{}
+
+func (dbCVECache) FindCVE(cve string) (types.NVDEntry, error) {
+	return apiContext.APIConfiguration.DBInstance.FindOneDBNVDEntry(map[string]interface{}{"cve": cve})
+}
+
+func (dbCVECache) FindStaleCVEs(updatedBefore time.Time) ([]types.NVDEntry, error) {
+	return apiContext.APIConfiguration.DBInstance.FindAllDBNVDEntry(map[string]interface{}{"updatedAt": bson.M{"$lt": updatedBefore}})
+}
+
+func (dbCVECache) SaveCVE(entry types.NVDEntry) error {
+	_, err := apiContext.APIConfiguration.DBInstance.UpsertOneDBNVDEntry(map[string]interface{}{"cve": entry.CVE}, entry)
+	return err
+}
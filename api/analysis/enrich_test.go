@@ -0,0 +1,164 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type FakeCVECache struct {
+	entries map[string]types.NVDEntry
+	saved   []types.NVDEntry
+}
+
+func (f *FakeCVECache) FindCVE(cve string) (types.NVDEntry, error) {
+	entry, ok := f.entries[cve]
+	if !ok {
+		return types.NVDEntry{}, errors.New("not found")
+	}
+	return entry, nil
+}
+
+func (f *FakeCVECache) FindStaleCVEs(updatedBefore time.Time) ([]types.NVDEntry, error) {
+	stale := []types.NVDEntry{}
+	for _, entry := range f.entries {
+		if entry.UpdatedAt.Before(updatedBefore) {
+			stale = append(stale, entry)
+		}
+	}
+	return stale, nil
+}
+
+func (f *FakeCVECache) SaveCVE(entry types.NVDEntry) error {
+	f.saved = append(f.saved, entry)
+	return nil
+}
+
+type FakeCVESource struct {
+	entry   types.NVDEntry
+	err     error
+	delay   time.Duration
+	fetched []string
+}
+
+func (f *FakeCVESource) FetchCVE(ctx context.Context, cve string) (types.NVDEntry, error) {
+	f.fetched = append(f.fetched, cve)
+	select {
+	case <-time.After(f.delay):
+	case <-ctx.Done():
+		return types.NVDEntry{}, ctx.Err()
+	}
+	if f.err != nil {
+		return types.NVDEntry{}, f.err
+	}
+	entry := f.entry
+	entry.CVE = cve
+	return entry, nil
+}
+
+var _ = Describe("CVEEnricher", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	newResults := func() types.HuskyCIResults {
+		results := types.HuskyCIResults{}
+		results.JavaResults.HuskyCIDependencyCheckOutput.HighVulns = []types.HuskyCIVulnerability{
+			{SecurityTool: "DependencyCheck", RuleID: "CVE-2019-14379", CVSSScore: 7.5},
+		}
+		results.PythonResults.HuskyCISafetyOutput.MediumVulns = []types.HuskyCIVulnerability{
+			{SecurityTool: "Safety", Details: "Fixes CVE-2018-18074 in requests."},
+			{SecurityTool: "Safety", Details: "No CVE here."},
+		}
+		return results
+	}
+
+	Context("When the CVE is fresh in the cache", func() {
+		It("Should not query NVD", func() {
+			cache := &FakeCVECache{entries: map[string]types.NVDEntry{
+				"CVE-2019-14379": {CVE: "CVE-2019-14379", CVSSv3Score: 9.8, CVSSv3Vector: "CVSS:3.1/AV:N", URL: "https://nvd.nist.gov/vuln/detail/CVE-2019-14379", UpdatedAt: time.Now()},
+				"CVE-2018-18074": {CVE: "CVE-2018-18074", CVSSv3Score: 7.5, UpdatedAt: time.Now()},
+			}}
+			source := &FakeCVESource{}
+			enricher := analysis.CVEEnricher{Cache: cache, Source: source, Timeout: time.Second, MaxAge: time.Hour}
+			results := newResults()
+			enricher.Enrich(&results)
+
+			vuln := results.JavaResults.HuskyCIDependencyCheckOutput.HighVulns[0]
+			Expect(vuln.CVSSScore).To(Equal(9.8))
+			Expect(vuln.CVSSVector).To(Equal("CVSS:3.1/AV:N"))
+			Expect(vuln.NVDURL).To(Equal("https://nvd.nist.gov/vuln/detail/CVE-2019-14379"))
+			Expect(results.PythonResults.HuskyCISafetyOutput.MediumVulns[0].CVSSScore).To(Equal(7.5))
+			Expect(source.fetched).To(BeEmpty())
+		})
+	})
+
+	Context("When the CVE is not in the cache", func() {
+		It("Should query NVD and cache the result", func() {
+			cache := &FakeCVECache{entries: map[string]types.NVDEntry{}}
+			source := &FakeCVESource{entry: types.NVDEntry{CVSSv3Score: 8.1}}
+			enricher := analysis.CVEEnricher{Cache: cache, Source: source, Timeout: time.Second, MaxAge: time.Hour}
+			results := newResults()
+			enricher.Enrich(&results)
+
+			Expect(source.fetched).To(ConsistOf("CVE-2019-14379", "CVE-2018-18074"))
+			Expect(cache.saved).To(HaveLen(2))
+			Expect(results.JavaResults.HuskyCIDependencyCheckOutput.HighVulns[0].CVSSScore).To(Equal(8.1))
+		})
+	})
+
+	Context("When NVD is unreachable", func() {
+		It("Should keep vulnerabilities untouched and use stale cached data", func() {
+			cache := &FakeCVECache{entries: map[string]types.NVDEntry{
+				"CVE-2018-18074": {CVE: "CVE-2018-18074", CVSSv3Score: 7.5, UpdatedAt: time.Now().Add(-48 * time.Hour)},
+			}}
+			source := &FakeCVESource{err: errors.New("connection refused")}
+			enricher := analysis.CVEEnricher{Cache: cache, Source: source, Timeout: time.Second, MaxAge: time.Hour}
+			results := newResults()
+			enricher.Enrich(&results)
+
+			Expect(results.JavaResults.HuskyCIDependencyCheckOutput.HighVulns[0].CVSSScore).To(Equal(7.5))
+			Expect(results.JavaResults.HuskyCIDependencyCheckOutput.HighVulns[0].NVDURL).To(BeEmpty())
+			Expect(results.PythonResults.HuskyCISafetyOutput.MediumVulns[0].CVSSScore).To(Equal(7.5))
+		})
+	})
+
+	Context("When NVD is too slow", func() {
+		It("Should give up after the timeout", func() {
+			cache := &FakeCVECache{entries: map[string]types.NVDEntry{}}
+			source := &FakeCVESource{delay: time.Minute}
+			enricher := analysis.CVEEnricher{Cache: cache, Source: source, Timeout: 50 * time.Millisecond, MaxAge: time.Hour}
+			results := newResults()
+			start := time.Now()
+			enricher.Enrich(&results)
+
+			Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+			Expect(cache.saved).To(BeEmpty())
+		})
+	})
+
+	Describe("RefreshStale", func() {
+		It("Should fetch again only the stale CVEs", func() {
+			cache := &FakeCVECache{entries: map[string]types.NVDEntry{
+				"CVE-2019-14379": {CVE: "CVE-2019-14379", UpdatedAt: time.Now()},
+				"CVE-2018-18074": {CVE: "CVE-2018-18074", UpdatedAt: time.Now().Add(-48 * time.Hour)},
+			}}
+			source := &FakeCVESource{entry: types.NVDEntry{CVSSv3Score: 7.5}}
+			enricher := analysis.CVEEnricher{Cache: cache, Source: source, Timeout: time.Second, MaxAge: time.Hour}
+
+			Expect(enricher.RefreshStale()).To(BeNil())
+			Expect(source.fetched).To(Equal([]string{"CVE-2018-18074"}))
+			Expect(cache.saved).To(HaveLen(1))
+		})
+	})
+})
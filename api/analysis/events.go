@@ -0,0 +1,107 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"context"
+	"time"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+const (
+	// EventAnalysisStarted is emitted once the analysis is found running.
+	EventAnalysisStarted = "analysis.started"
+	// EventContainerFinished is emitted for each securityTest container that finished.
+	EventContainerFinished = "container.finished"
+	// EventAnalysisFinished is emitted when the analysis is no longer running.
+	EventAnalysisFinished = "analysis.finished"
+)
+
+// Event represents a change in the state of an analysis.
+type Event struct {
+	RID       string
+	Type      string
+	Status    string
+	Result    string
+	Container *types.Container
+	Time      time.Time
+}
+
+// WatchAnalysis polls the analysis of a given RID every interval and calls
+// emit for each new event until the analysis finishes, ctx is done or emit
+// returns an error.
+func WatchAnalysis(ctx context.Context, RID string, interval time.Duration, emit func(Event) error) error {
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	started := false
+	seenContainers := map[string]bool{}
+
+	for {
+		analysisResult, err := FindAnalysis(RID)
+		if err != nil {
+			return err
+		}
+
+		for _, event := range newEvents(analysisResult, &started, seenContainers) {
+			if err := emit(event); err != nil {
+				return err
+			}
+			if event.Type == EventAnalysisFinished {
+				return nil
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+func newEvents(analysisResult types.Analysis, started *bool, seenContainers map[string]bool) []Event {
+	events := []Event{}
+
+	if !*started {
+		*started = true
+		events = append(events, Event{
+			RID:    analysisResult.RID,
+			Type:   EventAnalysisStarted,
+			Status: analysisResult.Status,
+			Time:   analysisResult.StartedAt,
+		})
+	}
+
+	for i := range analysisResult.Containers {
+		container := analysisResult.Containers[i]
+		if container.CID == "" || seenContainers[container.CID] {
+			continue
+		}
+		seenContainers[container.CID] = true
+		events = append(events, Event{
+			RID:       analysisResult.RID,
+			Type:      EventContainerFinished,
+			Status:    container.CStatus,
+			Result:    container.CResult,
+			Container: &container,
+			Time:      container.FinishedAt,
+		})
+	}
+
+	if analysisResult.Status != "running" {
+		events = append(events, Event{
+			RID:    analysisResult.RID,
+			Type:   EventAnalysisFinished,
+			Status: analysisResult.Status,
+			Result: analysisResult.Result,
+			Time:   analysisResult.FinishedAt,
+		})
+	}
+
+	return events
+}
@@ -0,0 +1,67 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
+)
+
+// ResetComplianceMapping unloads the compliance mapping, so analysis_test
+// does not leak it between tests.
+func ResetComplianceMapping() {
+	complianceMapping = nil
+}
+
+// FinalizeRefs exposes finalizeRefs to analysis_test.
+func FinalizeRefs(containers []types.Container, refScans []*securitytest.RunAllInfo) ([]types.Container, []types.RefResult) {
+	return finalizeRefs(containers, refScans)
+}
+
+// ResetInFlight forgets the analyses in flight and lets new ones start after Shutdown.
+func ResetInFlight() {
+	inFlight = newInFlightAnalyses()
+}
+
+// BeginAnalysis exposes the tracking of an analysis in flight to analysis_test.
+func BeginAnalysis(RID string) (goContext.Context, error) {
+	return inFlight.begin(RID)
+}
+
+// EndAnalysis exposes the end of an analysis in flight to analysis_test.
+func EndAnalysis(RID string) {
+	inFlight.end(RID)
+}
+
+// FinishPRAnalysis exposes finishPRAnalysis to analysis_test.
+func FinishPRAnalysis(prAnalysis types.PRAnalysisResult, baseAnalysis, headAnalysis types.Analysis) types.PRAnalysisResult {
+	return finishPRAnalysis(prAnalysis, baseAnalysis, headAnalysis)
+}
+
+// FindAnalyzedCommit exposes findAnalyzedCommit to analysis_test.
+var FindAnalyzedCommit = findAnalyzedCommit
+
+// WaitPRAnalysis exposes waitPRAnalysis to analysis_test.
+var WaitPRAnalysis = waitPRAnalysis
+
+// RunWithRetry exposes runWithRetry to analysis_test.
+var RunWithRetry = runWithRetry
+
+// InterruptCause exposes why an analysis in flight was interrupted to analysis_test.
+func InterruptCause(RID string) error {
+	return inFlight.interruptCause(RID)
+}
+
+// StartQueuedAnalyses exposes startQueuedAnalyses to analysis_test.
+var StartQueuedAnalyses = startQueuedAnalyses
+
+// GoTracked exposes inFlight.goTracked to analysis_test.
+func GoTracked(work func(stopped <-chan struct{})) error {
+	return inFlight.goTracked(work)
+}
+
+// ApprovedIgnores exposes approvedIgnores to analysis_test.
+var ApprovedIgnores = approvedIgnores
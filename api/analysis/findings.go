@@ -0,0 +1,188 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// AggregateFindings returns all findings of an analysis, from every securityTool,
+// deduplicated and sorted by severity, file and line.
+func AggregateFindings(analysisID string) ([]types.UnifiedFinding, error) {
+	analysisResult, err := FindAnalysis(analysisID)
+	if err != nil {
+		return nil, err
+	}
+	return UnifyFindings(analysisResult.HuskyCIResults), nil
+}
+
+// UnifyFindings converts the parsed results of each securityTool into a
+// deduplicated and sorted slice of UnifiedFinding.
+func UnifyFindings(results types.HuskyCIResults) []types.UnifiedFinding {
+
+	toolOutputs := []struct {
+		tool   string
+		output types.HuskyCISecurityTestOutput
+	}{
+		{"GoSec", results.GoResults.HuskyCIGosecOutput},
+		{"Bandit", results.PythonResults.HuskyCIBanditOutput},
+		{"Safety", results.PythonResults.HuskyCISafetyOutput},
+		{"NpmAudit", results.JavaScriptResults.HuskyCINpmAuditOutput},
+		{"YarnAudit", results.JavaScriptResults.HuskyCIYarnAuditOutput},
+		{"ESLint", results.JavaScriptResults.HuskyCIEslintOutput},
+		{"SpotBugs", results.JavaResults.HuskyCISpotBugsOutput},
+		{"DependencyCheck", results.JavaResults.HuskyCIDependencyCheckOutput},
+		{"Brakeman", results.RubyResults.HuskyCIBrakemanOutput},
+		{"GitLeaks", results.GenericResults.HuskyCIGitleaksOutput},
+		{"DepConfusion", results.GenericResults.HuskyCIDepConfusionOutput},
+		{"Hadolint", results.GenericResults.HuskyCIHadolintOutput},
+	}
+	// securityTools of plugins are named as their securityTests
+	for _, name := range pluginNames(results.PluginResults) {
+		toolOutputs = append(toolOutputs, struct {
+			tool   string
+			output types.HuskyCISecurityTestOutput
+		}{name, results.PluginResults[name]})
+	}
+
+	findings := []types.UnifiedFinding{}
+	seen := map[string]bool{}
+
+	add := func(tool string, vulns []types.HuskyCIVulnerability, suppressed bool) {
+		for _, vuln := range vulns {
+			// gosec only reports how many issues were suppressed, not which ones.
+			if vuln.File == "" && vuln.Details == "" && vuln.Code == "" {
+				continue
+			}
+			finding := toUnifiedFinding(tool, vuln, suppressed)
+			key := findingKey(finding)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			findings = append(findings, finding)
+		}
+	}
+
+	for _, toolOutput := range toolOutputs {
+		add(toolOutput.tool, toolOutput.output.HighVulns, false)
+		add(toolOutput.tool, toolOutput.output.MediumVulns, false)
+		add(toolOutput.tool, toolOutput.output.LowVulns, false)
+		add(toolOutput.tool, toolOutput.output.NoSecVulns, true)
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool {
+		if severityRank(findings[i].Severity) != severityRank(findings[j].Severity) {
+			return severityRank(findings[i].Severity) > severityRank(findings[j].Severity)
+		}
+		if findings[i].File != findings[j].File {
+			return findings[i].File < findings[j].File
+		}
+		return findings[i].Line < findings[j].Line
+	})
+
+	return findings
+}
+
+// FilterFindings returns only the findings that match the given severity, tool
+// and file prefix. Empty filters match every finding.
+func FilterFindings(findings []types.UnifiedFinding, severity, tool, file string) []types.UnifiedFinding {
+	filtered := []types.UnifiedFinding{}
+	for _, finding := range findings {
+		if severity != "" && !strings.EqualFold(finding.Severity, severity) {
+			continue
+		}
+		if tool != "" && !strings.EqualFold(finding.Tool, tool) {
+			continue
+		}
+		if file != "" && !strings.HasPrefix(finding.File, file) {
+			continue
+		}
+		filtered = append(filtered, finding)
+	}
+	return filtered
+}
+
+// ListFindings returns the findings of every given analysis that match the given
+// severity, tool, file prefix and compliance requirement, along with their analysis.
+func ListFindings(analyses []types.Analysis, severity, tool, file, compliance string) ([]types.AnalysisFinding, error) {
+	analysisFindings := []types.AnalysisFinding{}
+	for _, analysisResult := range analyses {
+		findings := FilterFindings(UnifyFindings(analysisResult.HuskyCIResults), severity, tool, file)
+		findings, err := FilterByCompliance(findings, compliance)
+		if err != nil {
+			return nil, err
+		}
+		for _, finding := range findings {
+			analysisFindings = append(analysisFindings, types.AnalysisFinding{
+				RID:            analysisResult.RID,
+				URL:            analysisResult.URL,
+				Branch:         analysisResult.Branch,
+				UnifiedFinding: finding,
+			})
+		}
+	}
+	return analysisFindings, nil
+}
+
+func toUnifiedFinding(tool string, vuln types.HuskyCIVulnerability, suppressed bool) types.UnifiedFinding {
+	if vuln.SecurityTool != "" {
+		tool = vuln.SecurityTool
+	}
+	ruleID := vuln.RuleID
+	if ruleID == "" {
+		ruleID = vuln.Type
+	}
+	description := vuln.Details
+	if description == "" {
+		description = vuln.Type
+	}
+	line, _ := strconv.Atoi(vuln.Line)
+	return types.UnifiedFinding{
+		File:            vuln.File,
+		Line:            line,
+		Tool:            tool,
+		RuleID:          ruleID,
+		Severity:        strings.ToUpper(vuln.Severity),
+		CVSSScore:       vuln.CVSSScore,
+		CVSSVector:      vuln.CVSSVector,
+		URL:             vuln.NVDURL,
+		Description:     description,
+		Suppressed:      suppressed,
+		DependencyDepth: vuln.DependencyDepth,
+		Compliance:      MapCompliance(tool, vuln),
+	}
+}
+
+func findingKey(finding types.UnifiedFinding) string {
+	return fmt.Sprintf("%s|%s|%d|%s|%s", finding.Tool, finding.File, finding.Line, finding.RuleID, finding.Description)
+}
+
+func severityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "HIGH":
+		return 3
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
+
+// pluginNames returns the names of the securityTests of pluginResults, sorted.
+func pluginNames(pluginResults map[string]types.HuskyCISecurityTestOutput) []string {
+	names := []string{}
+	for name := range pluginResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,81 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Findings", func() {
+
+	gosecVuln := types.HuskyCIVulnerability{
+		SecurityTool: "GoSec",
+		Severity:     "MEDIUM",
+		File:         "/go/src/code/main.go",
+		Line:         "12",
+		RuleID:       "G104",
+		Details:      "Errors unhandled.",
+	}
+	banditVuln := types.HuskyCIVulnerability{
+		SecurityTool: "Bandit",
+		Severity:     "HIGH",
+		File:         "app.py",
+		Line:         "3",
+		RuleID:       "B602",
+		Details:      "subprocess call with shell=True",
+	}
+	results := types.HuskyCIResults{}
+	results.GoResults.HuskyCIGosecOutput.MediumVulns = []types.HuskyCIVulnerability{gosecVuln, gosecVuln}
+	results.GoResults.HuskyCIGosecOutput.NoSecVulns = []types.HuskyCIVulnerability{{}}
+	results.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{banditVuln}
+	results.PythonResults.HuskyCIBanditOutput.NoSecVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "Bandit", Severity: "NOSEC", File: "app.py", Line: "1", Details: "Consider possible security implications."},
+	}
+
+	Describe("UnifyFindings", func() {
+		findings := analysis.UnifyFindings(results)
+
+		Context("When the same vulnerability is reported twice", func() {
+			It("Should return it only once", func() {
+				Expect(findings).To(HaveLen(3))
+			})
+		})
+		Context("When findings have different severities", func() {
+			It("Should sort them by severity descending", func() {
+				Expect(findings[0].Tool).To(Equal("Bandit"))
+				Expect(findings[0].Severity).To(Equal("HIGH"))
+				Expect(findings[0].Line).To(Equal(3))
+				Expect(findings[1].RuleID).To(Equal("G104"))
+				Expect(findings[2].Suppressed).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("FilterFindings", func() {
+		findings := analysis.UnifyFindings(results)
+
+		Context("When no filter is given", func() {
+			It("Should return all findings", func() {
+				Expect(analysis.FilterFindings(findings, "", "", "")).To(HaveLen(3))
+			})
+		})
+		Context("When severity and tool filters are given", func() {
+			It("Should return only matching findings", func() {
+				filtered := analysis.FilterFindings(findings, "medium", "gosec", "")
+				Expect(filtered).To(HaveLen(1))
+				Expect(filtered[0].File).To(Equal("/go/src/code/main.go"))
+			})
+		})
+		Context("When a file filter is given", func() {
+			It("Should match findings by file prefix", func() {
+				Expect(analysis.FilterFindings(findings, "", "", "/go/src")).To(HaveLen(1))
+			})
+		})
+	})
+})
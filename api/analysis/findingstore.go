@@ -0,0 +1,161 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrFindingNotFound is returned when no finding has the given fingerprint.
+var ErrFindingNotFound = errors.New("finding not found")
+
+// backfillPageSize is how many analyses BackfillFindings reads at once.
+const backfillPageSize = 100
+
+// RepositoryFindingFingerprint returns the fingerprint a finding of repositoryURL is stored
+// with: the same finding reported by the same securityTool in every analysis of the
+// repository has the same one, see FindingFingerprint.
+func RepositoryFindingFingerprint(repositoryURL string, finding types.UnifiedFinding) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{repositoryURL, strings.ToLower(finding.Tool), FindingFingerprint(finding)}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindingUpdates returns the updates that record the findings of the analysis of RID, seen at
+// seenAt, by fingerprint. A finding reported more than once by the same analysis, such as in
+// two lines of a file, is only counted once. The finding itself is not stored, as the analysis
+// of its lastRID already holds it, see FindFinding.
+func FindingUpdates(RID, repositoryURL string, findings []types.UnifiedFinding, seenAt time.Time) map[string]map[string]interface{} {
+	updates := map[string]map[string]interface{}{}
+	for _, finding := range findings {
+		fingerprint := RepositoryFindingFingerprint(repositoryURL, finding)
+		if _, ok := updates[fingerprint]; ok {
+			continue
+		}
+		updates[fingerprint] = map[string]interface{}{
+			"$setOnInsert": bson.M{
+				"repositoryURL": repositoryURL,
+				"tool":          strings.ToLower(finding.Tool),
+				"contentHash":   FindingFingerprint(finding),
+				"firstSeenAt":   seenAt,
+				"firstRID":      RID,
+			},
+			"$set": bson.M{
+				"lastSeenAt": seenAt,
+				"lastRID":    RID,
+			},
+			"$inc": bson.M{"occurrenceCount": 1},
+		}
+	}
+	return updates
+}
+
+// RecordFindings stores the findings of the analysis of RID, seen at seenAt. Findings already
+// reported by previous analyses of the repository are not stored again: their occurrence
+// count and when they were last seen are updated instead. The analysis is then marked as
+// recorded, so BackfillFindings does not count its findings again.
+func RecordFindings(RID, repositoryURL string, findings []types.UnifiedFinding, seenAt time.Time) error {
+	for fingerprint, update := range FindingUpdates(RID, repositoryURL, findings, seenAt) {
+		findingQuery := map[string]interface{}{"fingerprint": fingerprint}
+		if err := apiContext.APIConfiguration.DBInstance.UpsertOneDBFinding(findingQuery, update); err != nil {
+			log.Error("RecordFindings", logInfoAnalysis, 2041, RID, err)
+			return err
+		}
+	}
+	analysisQuery := map[string]interface{}{"RID": RID}
+	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBAnalysis(analysisQuery, map[string]interface{}{"findingsRecorded": true}); err != nil {
+		log.Error("RecordFindings", logInfoAnalysis, 2041, RID, err)
+		return err
+	}
+	return nil
+}
+
+// FindFinding returns the finding of a given fingerprint, with when it was first and last
+// seen, as reported by the analysis it was last seen in. If it does not exist,
+// ErrFindingNotFound is returned.
+func FindFinding(fingerprint string) (types.Finding, error) {
+	finding, err := apiContext.APIConfiguration.DBInstance.FindOneDBFinding(map[string]interface{}{"fingerprint": fingerprint})
+	if err != nil {
+		if isNotFound(err) {
+			return finding, ErrFindingNotFound
+		}
+		log.Error("FindFinding", logInfoAnalysis, 1020, err)
+		return finding, err
+	}
+	lastAnalysis, err := FindAnalysis(finding.LastRID)
+	if err != nil {
+		// best-effort: its history is still returned once its last analysis is deleted
+		if err == ErrAnalysisNotFound {
+			return finding, nil
+		}
+		return finding, err
+	}
+	for _, unifiedFinding := range UnifyFindings(lastAnalysis.HuskyCIResults) {
+		if RepositoryFindingFingerprint(finding.RepositoryURL, unifiedFinding) == fingerprint {
+			finding.Finding = unifiedFinding
+			break
+		}
+	}
+	return finding, nil
+}
+
+// BackfillFindings records the findings of every analysis finished before it started whose
+// findings were not recorded yet, the oldest first, and returns how many analyses it recorded.
+// It can be run again, such as after it fails, as analyses are only recorded once.
+func BackfillFindings() (int, error) {
+	analysisQuery := map[string]interface{}{
+		"status":           StatusFinished,
+		"startedAt":        bson.M{"$lt": time.Now()},
+		"findingsRecorded": bson.M{"$ne": true},
+	}
+	_, total, err := apiContext.APIConfiguration.DBInstance.FindPageDBAnalysis(analysisQuery, 0, 1)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, nil
+		}
+		return 0, err
+	}
+	recorded := 0
+	// pages are the most recent first, so they are read from the last one, and the analyses
+	// recorded leave the query without moving the ones of the pages left
+	for end := total; end > 0; end -= backfillPageSize {
+		skip := end - backfillPageSize
+		if skip < 0 {
+			skip = 0
+		}
+		analyses, _, err := apiContext.APIConfiguration.DBInstance.FindPageDBAnalysis(analysisQuery, skip, end-skip)
+		if err != nil {
+			return recorded, err
+		}
+		for i := len(analyses) - 1; i >= 0; i-- {
+			// pages only have the fields needed to list analyses
+			analysisResult, err := FindAnalysis(analyses[i].RID)
+			if err != nil {
+				return recorded, err
+			}
+			// an analysis with errors did not run every securityTest, as in StartAnalysis
+			if analysisResult.ErrorFound != "" {
+				continue
+			}
+			seenAt := analysisResult.FinishedAt
+			if seenAt.IsZero() {
+				seenAt = analysisResult.StartedAt
+			}
+			if err := RecordFindings(analysisResult.RID, analysisResult.URL, UnifyFindings(analysisResult.HuskyCIResults), seenAt); err != nil {
+				return recorded, err
+			}
+			recorded++
+		}
+	}
+	return recorded, nil
+}
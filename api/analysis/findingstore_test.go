@@ -0,0 +1,137 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/db"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// findingsDB keeps a single finding and a single analysis in memory. Any other request panics.
+type findingsDB struct {
+	db.Requests
+	finding  types.Finding
+	analysis types.Analysis
+	updates  []map[string]interface{}
+}
+
+func (fDB *findingsDB) FindOneDBFinding(mapParams map[string]interface{}) (types.Finding, error) {
+	if mapParams["fingerprint"] != fDB.finding.Fingerprint {
+		return types.Finding{}, errors.New("No data found")
+	}
+	return fDB.finding, nil
+}
+
+func (fDB *findingsDB) UpsertOneDBFinding(mapParams, updateQuery map[string]interface{}) error {
+	return nil
+}
+
+func (fDB *findingsDB) FindOneDBAnalysis(mapParams map[string]interface{}) (types.Analysis, error) {
+	if mapParams["RID"] != fDB.analysis.RID {
+		return types.Analysis{}, errors.New("No data found")
+	}
+	return fDB.analysis, nil
+}
+
+func (fDB *findingsDB) UpdateOneDBAnalysis(mapParams map[string]interface{}, updatedAnalysis map[string]interface{}) error {
+	fDB.updates = append(fDB.updates, updatedAnalysis)
+	return nil
+}
+
+var _ = Describe("Finding store", func() {
+
+	finding := types.UnifiedFinding{File: "main.go", Line: 12, Tool: "GoSec", RuleID: "G104"}
+
+	Describe("RepositoryFindingFingerprint", func() {
+		It("Should not change when the finding moves to another line", func() {
+			moved := finding
+			moved.Line = 40
+			Expect(analysis.RepositoryFindingFingerprint("repo", moved)).To(Equal(analysis.RepositoryFindingFingerprint("repo", finding)))
+		})
+		It("Should change with the repository and the securityTool", func() {
+			fingerprint := analysis.RepositoryFindingFingerprint("repo", finding)
+			Expect(analysis.RepositoryFindingFingerprint("other", finding)).ToNot(Equal(fingerprint))
+			otherTool := finding
+			otherTool.Tool = "Semgrep"
+			Expect(analysis.RepositoryFindingFingerprint("repo", otherTool)).ToNot(Equal(fingerprint))
+		})
+	})
+
+	Describe("FindingUpdates", func() {
+		seenAt := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+
+		It("Should count a finding reported twice by the same analysis once", func() {
+			moved := finding
+			moved.Line = 40
+			updates := analysis.FindingUpdates("RID", "repo", []types.UnifiedFinding{finding, moved}, seenAt)
+			Expect(updates).To(HaveLen(1))
+			update := updates[analysis.RepositoryFindingFingerprint("repo", finding)]
+			Expect(update["$inc"]).To(Equal(bson.M{"occurrenceCount": 1}))
+			Expect(update["$set"]).To(Equal(bson.M{"lastSeenAt": seenAt, "lastRID": "RID"}))
+		})
+		It("Should only set when and where the finding was first seen once", func() {
+			update := analysis.FindingUpdates("RID", "repo", []types.UnifiedFinding{finding}, seenAt)[analysis.RepositoryFindingFingerprint("repo", finding)]
+			Expect(update["$setOnInsert"]).To(Equal(bson.M{
+				"repositoryURL": "repo",
+				"tool":          "gosec",
+				"contentHash":   analysis.FindingFingerprint(finding),
+				"firstSeenAt":   seenAt,
+				"firstRID":      "RID",
+			}))
+		})
+	})
+
+	Context("With a finding stored", func() {
+		var previousConfig *apiContext.APIConfig
+		var fakeDB *findingsDB
+		var fingerprint string
+
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+			vuln := types.HuskyCIVulnerability{Language: "Go", SecurityTool: "GoSec", Severity: "medium", File: "main.go", Line: "40", Code: "G104", Details: "Errors unhandled."}
+			lastAnalysis := types.Analysis{RID: "lastRID", URL: "repo"}
+			lastAnalysis.HuskyCIResults.GoResults.HuskyCIGosecOutput.MediumVulns = []types.HuskyCIVulnerability{vuln}
+			lastFinding := analysis.UnifyFindings(lastAnalysis.HuskyCIResults)[0]
+			fingerprint = analysis.RepositoryFindingFingerprint("repo", lastFinding)
+			fakeDB = &findingsDB{
+				finding:  types.Finding{Fingerprint: fingerprint, RepositoryURL: "repo", OccurrenceCount: 2, LastRID: "lastRID"},
+				analysis: lastAnalysis,
+			}
+			apiContext.APIConfiguration = &apiContext.APIConfig{DBInstance: fakeDB}
+		})
+
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+
+		It("Should return it as the analysis it was last seen in reported it", func() {
+			stored, err := analysis.FindFinding(fingerprint)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.OccurrenceCount).To(Equal(2))
+			Expect(stored.Finding.File).To(Equal("main.go"))
+			Expect(stored.Finding.Line).To(Equal(40))
+		})
+		It("Should still return its history once the analysis it was last seen in is deleted", func() {
+			fakeDB.analysis = types.Analysis{}
+			stored, err := analysis.FindFinding(fingerprint)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(stored.LastRID).To(Equal("lastRID"))
+			Expect(stored.Finding).To(Equal(types.UnifiedFinding{}))
+		})
+		It("Should mark the analysis its findings are recorded from", func() {
+			Expect(analysis.RecordFindings("lastRID", "repo", []types.UnifiedFinding{finding}, time.Now())).To(Succeed())
+			Expect(fakeDB.updates).To(Equal([]map[string]interface{}{{"findingsRecorded": true}}))
+		})
+	})
+})
@@ -0,0 +1,239 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// maxGitHubDescription is how long the description of a commit status can be.
+const maxGitHubDescription = 140
+
+// gitHubClient reports analyses to GitHub. It is built once from the configuration, so the
+// tokens of the installations of a GitHub App are shared by every analysis.
+var gitHubClient struct {
+	once   sync.Once
+	client *notifier.GitHubClient
+}
+
+// gitHubStarted holds, by RID, a channel closed once the started notification of an analysis
+// is done, so its finished one neither creates a second Check Run nor is overwritten by it.
+var gitHubStarted sync.Map
+
+// gitHubReporter returns the GitHubClient of the configuration, or nil if GitHub is not configured.
+func gitHubReporter() *notifier.GitHubClient {
+	gitHubClient.once.Do(func() {
+		config := apiContext.APIConfiguration.GitHubConfig
+		if config == nil {
+			return
+		}
+		client, err := notifier.NewGitHubClient(config.APIURL, config.Token, config.OwnerTokens, config.AppID, config.AppPrivateKey)
+		if err != nil {
+			log.Error("gitHubReporter", logInfoAnalysis, 2037, err)
+			return
+		}
+		gitHubClient.client = client
+	})
+	return gitHubClient.client
+}
+
+// GitHubStatusState returns the state of the commit status of an analysis with result.
+func GitHubStatusState(result string) string {
+	switch result {
+	case ResultPassed, ResultWarning:
+		return "success"
+	case ResultFailed:
+		return "failure"
+	default:
+		return "error"
+	}
+}
+
+// GitHubCheckConclusion returns the conclusion of the Check Run of an analysis with result.
+// Warnings do not block a pull request, but they are shown apart from passed analyses.
+func GitHubCheckConclusion(result string) string {
+	switch result {
+	case ResultPassed:
+		return "success"
+	case ResultWarning:
+		return "neutral"
+	default:
+		return "failure"
+	}
+}
+
+// GitHubCommitStatus returns the commit status of a finished analysis, linking to it if
+// publicURL is not empty.
+func GitHubCommitStatus(analysisResult types.Analysis, publicURL string) notifier.GitHubStatus {
+	return notifier.GitHubStatus{
+		State:       GitHubStatusState(analysisResult.Result),
+		TargetURL:   analysisLink(analysisResult.RID, publicURL),
+		Description: truncateDescription(AnalysisDescription(analysisResult), maxGitHubDescription),
+		Context:     notifier.GitHubContext,
+	}
+}
+
+// GitHubCheckRun returns the completed Check Run of a finished analysis, annotating up to
+// maxAnnotations of its unsuppressed findings that point to a file, the most severe first.
+func GitHubCheckRun(analysisResult types.Analysis, publicURL string, maxAnnotations int) notifier.GitHubCheckRun {
+	findings := []types.UnifiedFinding{}
+	for _, finding := range UnifyFindings(analysisResult.HuskyCIResults) {
+		if !finding.Suppressed && finding.File != "" {
+			findings = append(findings, finding)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return notificationSeverityRank(summarySeverity(findings[i])) > notificationSeverityRank(summarySeverity(findings[j]))
+	})
+	annotations := []notifier.GitHubAnnotation{}
+	for _, finding := range findings {
+		if len(annotations) == maxAnnotations {
+			break
+		}
+		line := finding.Line
+		if line <= 0 {
+			line = 1
+		}
+		annotations = append(annotations, notifier.GitHubAnnotation{
+			Path:            strings.TrimPrefix(finding.File, "./"),
+			StartLine:       line,
+			EndLine:         line,
+			AnnotationLevel: annotationLevel(summarySeverity(finding)),
+			Title:           strings.TrimSpace(finding.Tool + " " + finding.RuleID),
+			Message:         finding.Description,
+		})
+	}
+	summary := AnalysisDescription(analysisResult) + "."
+	if len(findings) > len(annotations) {
+		summary += fmt.Sprintf(" %d of %d findings are annotated.", len(annotations), len(findings))
+	}
+	finishedAt := analysisResult.FinishedAt
+	return notifier.GitHubCheckRun{
+		Name:        notifier.GitHubContext,
+		Status:      "completed",
+		Conclusion:  GitHubCheckConclusion(analysisResult.Result),
+		DetailsURL:  analysisLink(analysisResult.RID, publicURL),
+		CompletedAt: &finishedAt,
+		Output: &notifier.GitHubCheckOutput{
+			Title:       AnalysisDescription(analysisResult),
+			Summary:     summary,
+			Annotations: annotations,
+		},
+	}
+}
+
+func annotationLevel(severity string) string {
+	switch notificationSeverityRank(severity) {
+	case 4, 3:
+		return "failure"
+	case 2:
+		return "warning"
+	default:
+		return "notice"
+	}
+}
+
+func analysisLink(RID, publicURL string) string {
+	if publicURL == "" {
+		return ""
+	}
+	return publicURL + "/analysis/" + RID
+}
+
+// gitHubTarget returns the client, owner and name of the repository an analysis of repository
+// is reported to, or a nil client if it is not reported to GitHub. Only repositories of the
+// host of the configured API are, so no other host is mapped to a GitHub repository.
+func gitHubTarget(repository types.Repository) (*notifier.GitHubClient, string, string) {
+	gh := gitHubReporter()
+	if gh == nil || repository.CommitSHA == "" {
+		return nil, "", ""
+	}
+	host, owner, repo, err := util.ParseGitHubRepository(repository.URL)
+	if err != nil || host != gh.Host() {
+		return nil, "", ""
+	}
+	return gh, owner, repo
+}
+
+// notifyGitHubStarted reports the analysis of RID to GitHub as pending, in background,
+// if it analyzes a given commit of a GitHub repository.
+func notifyGitHubStarted(RID string, repository types.Repository) {
+	gh, owner, repo := gitHubTarget(repository)
+	if gh == nil {
+		return
+	}
+	started := make(chan struct{})
+	gitHubStarted.Store(RID, started)
+	publicURL := apiContext.APIConfiguration.PublicURL
+	gh.ReportAsync(func() error {
+		defer close(started)
+		if !gh.UsesChecks() {
+			return gh.SetCommitStatus(owner, repo, repository.CommitSHA, notifier.GitHubStatus{
+				State:       "pending",
+				TargetURL:   analysisLink(RID, publicURL),
+				Description: "huskyCI analysis is running",
+				Context:     notifier.GitHubContext,
+			})
+		}
+		ID, err := gh.CreateCheckRun(owner, repo, notifier.GitHubCheckRun{
+			Name:       notifier.GitHubContext,
+			HeadSHA:    repository.CommitSHA,
+			Status:     "in_progress",
+			DetailsURL: analysisLink(RID, publicURL),
+		})
+		if err != nil {
+			return err
+		}
+		// the Check Run is completed once the analysis finishes, even if it already did
+		analysisQuery := map[string]interface{}{"RID": RID}
+		return apiContext.APIConfiguration.DBInstance.UpdateOneDBAnalysisContainer(analysisQuery, bson.M{"githubCheckRunID": ID})
+	}, func(err error) {
+		log.Error("notifyGitHubStarted", logInfoAnalysis, 2037, RID, err)
+	})
+}
+
+// notifyGitHubFinished reports the finished analysis of RID to GitHub in background, if it
+// analyzed a given commit of a GitHub repository. Errors are only logged.
+func notifyGitHubFinished(RID string, repository types.Repository) {
+	gh, owner, repo := gitHubTarget(repository)
+	if gh == nil {
+		return
+	}
+	config := apiContext.APIConfiguration
+	gh.ReportAsync(func() error {
+		// the Check Run ID is only stored once the started notification is done
+		if started, ok := gitHubStarted.Load(RID); ok {
+			<-started.(chan struct{})
+			gitHubStarted.Delete(RID)
+		}
+		analysisResult, err := FindAnalysis(RID)
+		if err != nil {
+			return err
+		}
+		if !gh.UsesChecks() {
+			return gh.SetCommitStatus(owner, repo, repository.CommitSHA, GitHubCommitStatus(analysisResult, config.PublicURL))
+		}
+		checkRun := GitHubCheckRun(analysisResult, config.PublicURL, config.GitHubConfig.MaxAnnotations)
+		if analysisResult.GitHubCheckRunID != 0 {
+			return gh.UpdateCheckRun(owner, repo, analysisResult.GitHubCheckRunID, checkRun)
+		}
+		// the Check Run could not be created when the analysis started
+		checkRun.HeadSHA = repository.CommitSHA
+		_, err = gh.CreateCheckRun(owner, repo, checkRun)
+		return err
+	}, func(err error) {
+		log.Error("notifyGitHubFinished", logInfoAnalysis, 2037, RID, err)
+	})
+}
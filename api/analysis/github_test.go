@@ -0,0 +1,69 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitHub", func() {
+
+	results := types.HuskyCIResults{}
+	results.GoResults.HuskyCIGosecOutput.MediumVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "GoSec", Severity: "MEDIUM", File: "./main.go", Line: "12", RuleID: "G104", Details: "Errors unhandled."},
+	}
+	results.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "Bandit", Severity: "HIGH", File: "app.py", Line: "3", RuleID: "B602", Details: "subprocess call with shell=True"},
+	}
+	analysisResult := types.Analysis{
+		RID:            "RID",
+		Result:         analysis.ResultFailed,
+		Summary:        &types.SeveritySummary{High: 1, Medium: 1},
+		HuskyCIResults: results,
+	}
+
+	Describe("GitHubCommitStatus", func() {
+		It("Should describe the result and findings of the analysis", func() {
+			Expect(analysis.GitHubCommitStatus(analysisResult, "https://huskyci.example.com")).To(Equal(notifier.GitHubStatus{
+				State:       "failure",
+				TargetURL:   "https://huskyci.example.com/analysis/RID",
+				Description: "huskyCI analysis failed: 0 critical, 1 high, 1 medium and 0 low findings",
+				Context:     "huskyCI",
+			}))
+		})
+		It("Should report an analysis that could not finish as an error", func() {
+			errored := analysisResult
+			errored.Result = analysis.ResultError
+			status := analysis.GitHubCommitStatus(errored, "")
+			Expect(status.State).To(Equal("error"))
+			Expect(status.TargetURL).To(BeEmpty())
+		})
+	})
+
+	Describe("GitHubCheckRun", func() {
+		It("Should annotate findings, the most severe first", func() {
+			checkRun := analysis.GitHubCheckRun(analysisResult, "", 50)
+			Expect(checkRun.Status).To(Equal("completed"))
+			Expect(checkRun.Conclusion).To(Equal("failure"))
+			Expect(checkRun.Output.Annotations).To(Equal([]notifier.GitHubAnnotation{
+				{Path: "app.py", StartLine: 3, EndLine: 3, AnnotationLevel: "failure", Title: "Bandit B602", Message: "subprocess call with shell=True"},
+				{Path: "main.go", StartLine: 12, EndLine: 12, AnnotationLevel: "warning", Title: "GoSec G104", Message: "Errors unhandled."},
+			}))
+		})
+		It("Should annotate up to maxAnnotations findings", func() {
+			checkRun := analysis.GitHubCheckRun(analysisResult, "", 1)
+			Expect(checkRun.Output.Annotations).To(HaveLen(1))
+			Expect(checkRun.Output.Summary).To(HaveSuffix("1 of 2 findings are annotated."))
+		})
+		It("Should not block a pull request on warnings", func() {
+			Expect(analysis.GitHubCheckConclusion(analysis.ResultWarning)).To(Equal("neutral"))
+		})
+	})
+})
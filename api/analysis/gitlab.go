@@ -0,0 +1,208 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// GitLabMergeRequestKey is the metadata key of an analysis request with the IID of the
+// merge request it analyzes. Its pullRequestID is used if it is not set.
+const GitLabMergeRequestKey = "mergeRequestIID"
+
+// maxGitLabFindings is how many findings, the most severe first, a merge request note lists.
+const maxGitLabFindings = 10
+
+// GitLabMergeRequestIID returns the IID of the merge request repository is analyzed for,
+// or zero if it is not given.
+func GitLabMergeRequestIID(repository types.Repository) int {
+	IID := repository.Metadata[GitLabMergeRequestKey]
+	if IID == "" {
+		IID = repository.PullRequestID
+	}
+	number, err := strconv.Atoi(strings.TrimPrefix(IID, "!"))
+	if err != nil || number <= 0 {
+		return 0
+	}
+	return number
+}
+
+// GitLabStatusState returns the state of the commit status of an analysis with result.
+func GitLabStatusState(result string) string {
+	switch result {
+	case ResultPassed, ResultWarning:
+		return "success"
+	default:
+		return "failed"
+	}
+}
+
+// GitLabNote returns the merge request note of a finished analysis, with the severity
+// summary of its findings and the most severe ones.
+func GitLabNote(analysisResult types.Analysis, publicURL string) string {
+	findings := []types.UnifiedFinding{}
+	for _, finding := range UnifyFindings(analysisResult.HuskyCIResults) {
+		if !finding.Suppressed {
+			findings = append(findings, finding)
+		}
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return notificationSeverityRank(summarySeverity(findings[i])) > notificationSeverityRank(summarySeverity(findings[j]))
+	})
+
+	var note strings.Builder
+	note.WriteString(notifier.GitLabNoteMarker + "\n")
+	fmt.Fprintf(&note, "### %s\n\n", AnalysisDescription(types.Analysis{Result: analysisResult.Result}))
+	if summary := analysisResult.Summary; summary != nil {
+		note.WriteString("| Critical | High | Medium | Low |\n| --- | --- | --- | --- |\n")
+		fmt.Fprintf(&note, "| %d | %d | %d | %d |\n\n", summary.Critical, summary.High, summary.Medium, summary.Low)
+	}
+	if len(findings) > 0 {
+		note.WriteString("| Severity | Tool | Rule | Location | Description |\n| --- | --- | --- | --- | --- |\n")
+		for i, finding := range findings {
+			if i == maxGitLabFindings {
+				fmt.Fprintf(&note, "\n... and %d more findings.\n", len(findings)-maxGitLabFindings)
+				break
+			}
+			location := strings.TrimPrefix(finding.File, "./")
+			if location != "" && finding.Line > 0 {
+				location = fmt.Sprintf("%s:%d", location, finding.Line)
+			}
+			if location != "" {
+				location = "`" + location + "`"
+			}
+			fmt.Fprintf(&note, "| %s | %s | %s | %s | %s |\n", summarySeverity(finding), markdownCell(finding.Tool),
+				markdownCell(finding.RuleID), location, markdownCell(finding.Description))
+		}
+		note.WriteString("\n")
+	}
+	if publicURL != "" {
+		fmt.Fprintf(&note, "[View analysis %s](%s)", analysisResult.RID, analysisLink(analysisResult.RID, publicURL))
+	} else {
+		fmt.Fprintf(&note, "RID: %s", analysisResult.RID)
+	}
+	return note.String()
+}
+
+// markdownCell escapes text so it fits in a cell of a Markdown table.
+func markdownCell(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	return strings.ReplaceAll(text, "|", "\\|")
+}
+
+// gitLabTarget returns the client, project and merge request IID an analysis of repository
+// is reported to, or a nil client if it is not reported to GitLab.
+func gitLabTarget(repository types.Repository) (*notifier.GitLabClient, string, int) {
+	config := apiContext.APIConfiguration.GitLabConfig
+	if config == nil {
+		return nil, "", 0
+	}
+	gl := notifier.NewGitLabClient(config.URL, config.Token)
+	IID := GitLabMergeRequestIID(repository)
+	if gl == nil || (repository.CommitSHA == "" && IID == 0) {
+		return nil, "", 0
+	}
+	project, err := util.ParseGitLabProject(repository.URL)
+	if err != nil {
+		return nil, "", 0
+	}
+	return gl, project, IID
+}
+
+// gitLabEnabled returns whether the repository of repositoryURL opted in to be reported to GitLab.
+func gitLabEnabled(repositoryURL string) (bool, error) {
+	repository, err := apiContext.APIConfiguration.DBInstance.FindOneDBRepository(map[string]interface{}{"repositoryURL": repositoryURL})
+	if err != nil && !isNotFound(err) {
+		return false, err
+	}
+	return repository.GitLab, nil
+}
+
+// notifyGitLabStarted sets the commit of the analysis of RID as running in GitLab, in
+// background, if its repository opted in.
+func notifyGitLabStarted(RID string, repository types.Repository) {
+	gl, project, _ := gitLabTarget(repository)
+	if gl == nil || repository.CommitSHA == "" {
+		return
+	}
+	publicURL := apiContext.APIConfiguration.PublicURL
+	gl.ReportAsync(func() error {
+		if enabled, err := gitLabEnabled(repository.URL); !enabled {
+			return err
+		}
+		return gl.SetCommitStatus(project, repository.CommitSHA, notifier.GitLabStatus{
+			State:       "running",
+			Name:        notifier.GitLabStatusName,
+			TargetURL:   analysisLink(RID, publicURL),
+			Description: "huskyCI analysis is running",
+		})
+	}, func(err error) {
+		log.Error("notifyGitLabStarted", logInfoAnalysis, 2038, RID, err)
+	})
+}
+
+// notifyGitLabFinished reports the finished analysis of RID to GitLab in background, if its
+// repository opted in: its commit status is set and its merge request commented. Errors are
+// only logged.
+func notifyGitLabFinished(RID string, repository types.Repository) {
+	gl, project, IID := gitLabTarget(repository)
+	if gl == nil {
+		return
+	}
+	publicURL := apiContext.APIConfiguration.PublicURL
+	gl.ReportAsync(func() error {
+		if enabled, err := gitLabEnabled(repository.URL); !enabled {
+			return err
+		}
+		analysisResult, err := FindAnalysis(RID)
+		if err != nil {
+			return err
+		}
+		if repository.CommitSHA != "" {
+			err := gl.SetCommitStatus(project, repository.CommitSHA, notifier.GitLabStatus{
+				State:       GitLabStatusState(analysisResult.Result),
+				Name:        notifier.GitLabStatusName,
+				TargetURL:   analysisLink(RID, publicURL),
+				Description: AnalysisDescription(analysisResult),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		if IID == 0 {
+			return nil
+		}
+		return gl.UpsertMergeRequestNote(project, IID, GitLabNote(analysisResult, publicURL))
+	}, func(err error) {
+		log.Error("notifyGitLabFinished", logInfoAnalysis, 2038, RID, err)
+	})
+}
+
+// SetRepositoryGitLab sets whether analyses of a repository are reported to GitLab.
+func SetRepositoryGitLab(repositoryURL string, enabled bool) error {
+	repositoryQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	updateQuery := map[string]interface{}{"$set": bson.M{"gitlab": true}}
+	if !enabled {
+		updateQuery = map[string]interface{}{"$unset": bson.M{"gitlab": ""}}
+	}
+	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBRepository(repositoryQuery, updateQuery); err != nil {
+		if isNotFound(err) {
+			return ErrRepositoryNotFound
+		}
+		log.Error("SetRepositoryGitLab", logInfoAnalysis, 2039, repositoryURL, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitLab", func() {
+
+	results := types.HuskyCIResults{}
+	results.GoResults.HuskyCIGosecOutput.MediumVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "GoSec", Severity: "MEDIUM", File: "./main.go", Line: "12", RuleID: "G104", Details: "Errors | unhandled.\n"},
+	}
+	results.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "Bandit", Severity: "HIGH", File: "app.py", Line: "3", RuleID: "B602", Details: "subprocess call with shell=True"},
+	}
+	analysisResult := types.Analysis{
+		RID:            "RID",
+		Result:         analysis.ResultFailed,
+		Summary:        &types.SeveritySummary{High: 1, Medium: 1},
+		HuskyCIResults: results,
+	}
+
+	Describe("GitLabMergeRequestIID", func() {
+		It("Should prefer the mergeRequestIID metadata to the pullRequestID", func() {
+			repository := types.Repository{PullRequestID: "7", Metadata: map[string]string{"mergeRequestIID": "!42"}}
+			Expect(analysis.GitLabMergeRequestIID(repository)).To(Equal(42))
+			repository.Metadata = nil
+			Expect(analysis.GitLabMergeRequestIID(repository)).To(Equal(7))
+		})
+		It("Should return zero if the analysis is not of a merge request", func() {
+			Expect(analysis.GitLabMergeRequestIID(types.Repository{PullRequestID: "feature"})).To(BeZero())
+		})
+	})
+
+	Describe("GitLabStatusState", func() {
+		It("Should only fail the commit of a failed analysis or one that could not finish", func() {
+			Expect(analysis.GitLabStatusState(analysis.ResultWarning)).To(Equal("success"))
+			Expect(analysis.GitLabStatusState(analysis.ResultFailed)).To(Equal("failed"))
+			Expect(analysis.GitLabStatusState(analysis.ResultError)).To(Equal("failed"))
+		})
+	})
+
+	Describe("GitLabNote", func() {
+		It("Should summarize the analysis and list its findings, the most severe first", func() {
+			Expect(analysis.GitLabNote(analysisResult, "https://huskyci.example.com")).To(Equal("<!-- huskyCI -->\n" +
+				"### huskyCI analysis failed\n\n" +
+				"| Critical | High | Medium | Low |\n| --- | --- | --- | --- |\n| 0 | 1 | 1 | 0 |\n\n" +
+				"| Severity | Tool | Rule | Location | Description |\n| --- | --- | --- | --- | --- |\n" +
+				"| HIGH | Bandit | B602 | `app.py:3` | subprocess call with shell=True |\n" +
+				"| MEDIUM | GoSec | G104 | `main.go:12` | Errors \\| unhandled. |\n\n" +
+				"[View analysis RID](https://huskyci.example.com/analysis/RID)"))
+		})
+		It("Should give the RID if huskyCI has no public URL", func() {
+			passed := types.Analysis{RID: "RID", Result: analysis.ResultPassed}
+			Expect(analysis.GitLabNote(passed, "")).To(Equal("<!-- huskyCI -->\n### huskyCI analysis passed\n\nRID: RID"))
+		})
+	})
+})
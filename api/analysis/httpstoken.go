@@ -0,0 +1,41 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// httpsTokenEnabled returns whether the repository of repositoryURL opted in to be cloned with
+// HUSKYCI_API_GIT_HTTPS_TOKEN. It is cloned without it if that can not be told.
+func httpsTokenEnabled(repositoryURL string) bool {
+	repository, err := apiContext.APIConfiguration.DBInstance.FindOneDBRepository(map[string]interface{}{"repositoryURL": repositoryURL})
+	if err != nil {
+		if !isNotFound(err) {
+			log.Error("httpsTokenEnabled", logInfoAnalysis, 1013, err)
+		}
+		return false
+	}
+	return repository.HTTPSToken
+}
+
+// SetRepositoryHTTPSToken sets whether a repository is cloned with HUSKYCI_API_GIT_HTTPS_TOKEN.
+func SetRepositoryHTTPSToken(repositoryURL string, enabled bool) error {
+	repositoryQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	updateQuery := map[string]interface{}{"$set": bson.M{"httpsToken": true}}
+	if !enabled {
+		updateQuery = map[string]interface{}{"$unset": bson.M{"httpsToken": ""}}
+	}
+	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBRepository(repositoryQuery, updateQuery); err != nil {
+		if isNotFound(err) {
+			return ErrRepositoryNotFound
+		}
+		log.Error("SetRepositoryHTTPSToken", logInfoAnalysis, 2050, repositoryURL, err)
+		return err
+	}
+	return nil
+}
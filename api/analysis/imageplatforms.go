@@ -0,0 +1,62 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"strings"
+
+	"github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// ImagePlatformsReader reads the platforms an image is built for from its registry.
+type ImagePlatformsReader interface {
+	ImagePlatforms(image string) ([]string, error)
+}
+
+// SummarizeImagePlatforms returns the report of image, built for platforms, on the Docker host
+// of hostPlatform, such as linux/arm64. Variants, such as v8 of linux/arm64/v8, are not compared.
+func SummarizeImagePlatforms(image string, platforms []string, hostPlatform string) types.ImagePlatformReport {
+	report := types.ImagePlatformReport{Image: image, Platforms: platforms, MultiArch: len(platforms) > 1}
+	for _, platform := range platforms {
+		if platform == hostPlatform || strings.HasPrefix(platform, hostPlatform+"/") {
+			report.HostSupported = true
+		}
+	}
+	return report
+}
+
+// IsAMD64Only returns whether an image built for platforms is built for linux/amd64 only.
+func IsAMD64Only(platforms []string) bool {
+	return len(platforms) == 1 && platforms[0] == "linux/amd64"
+}
+
+// ValidateImagePlatforms reads, with reader, the platforms the image of every securityTest is
+// built for and reports which of them run natively on the Docker host.
+func ValidateImagePlatforms(reader ImagePlatformsReader) (types.ImagePlatformValidation, error) {
+	validation := types.ImagePlatformValidation{
+		HostPlatform: dockers.HostPlatform(),
+		Images:       []types.ImagePlatformReport{},
+		AMD64Only:    []string{},
+	}
+	images, err := securityTestImages()
+	if err != nil {
+		return validation, err
+	}
+	for _, image := range images {
+		platforms, err := reader.ImagePlatforms(image)
+		if err != nil {
+			log.Error("ValidateImagePlatforms", logInfoAnalysis, 3034, image, err)
+			validation.Images = append(validation.Images, types.ImagePlatformReport{Image: image, Error: err.Error()})
+			continue
+		}
+		validation.Images = append(validation.Images, SummarizeImagePlatforms(image, platforms, validation.HostPlatform))
+		if IsAMD64Only(platforms) {
+			validation.AMD64Only = append(validation.AMD64Only, image)
+		}
+	}
+	return validation, nil
+}
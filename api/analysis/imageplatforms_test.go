@@ -0,0 +1,42 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Image platforms", func() {
+
+	Describe("SummarizeImagePlatforms", func() {
+		It("Should report multi-arch images that run natively on the Docker host", func() {
+			platforms := []string{"linux/amd64", "linux/arm64/v8"}
+			Expect(analysis.SummarizeImagePlatforms("huskyci/gosec:2.1.0", platforms, "linux/arm64")).To(Equal(types.ImagePlatformReport{
+				Image:         "huskyci/gosec:2.1.0",
+				Platforms:     platforms,
+				MultiArch:     true,
+				HostSupported: true,
+			}))
+		})
+
+		It("Should report images built for another architecture only", func() {
+			report := analysis.SummarizeImagePlatforms("huskyci/bandit:1.0.0", []string{"linux/amd64"}, "linux/arm64")
+			Expect(report.MultiArch).To(BeFalse())
+			Expect(report.HostSupported).To(BeFalse())
+		})
+	})
+
+	Describe("IsAMD64Only", func() {
+		It("Should return whether images are built for linux/amd64 only", func() {
+			Expect(analysis.IsAMD64Only([]string{"linux/amd64"})).To(BeTrue())
+			Expect(analysis.IsAMD64Only([]string{"linux/amd64", "linux/arm64"})).To(BeFalse())
+			Expect(analysis.IsAMD64Only([]string{"linux/arm64"})).To(BeFalse())
+		})
+	})
+})
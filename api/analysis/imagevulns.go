@@ -0,0 +1,225 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
+)
+
+// imageVulnReportMaxAge is how long the report of an image is used by GateImage before the
+// image is scanned again.
+const imageVulnReportMaxAge = 24 * time.Hour
+
+// imageVulnRetryBackoff is how long GateImage waits before scanning again an image that could
+// not be scanned, so Trivy being down does not have it scanned on every run.
+const imageVulnRetryBackoff = time.Hour
+
+// imageVulnState is the last report of each image scanned, by image, and the scans running.
+var imageVulnState = struct {
+	sync.Mutex
+	reports  map[string]types.ImageVulnReport
+	scanning map[string]chan struct{}
+}{reports: map[string]types.ImageVulnReport{}, scanning: map[string]chan struct{}{}}
+
+// SummarizeImageVulns returns the report of image, counting vulnerabilities by severity.
+func SummarizeImageVulns(image string, vulnerabilities []dockers.TrivyVulnerability, scannedAt time.Time) types.ImageVulnReport {
+	report := types.ImageVulnReport{Image: image, ScannedAt: scannedAt}
+	for _, vulnerability := range vulnerabilities {
+		switch strings.ToUpper(vulnerability.Severity) {
+		case "CRITICAL":
+			report.Critical++
+		case "HIGH":
+			report.High++
+		case "MEDIUM":
+			report.Medium++
+		case "LOW":
+			report.Low++
+		default:
+			report.Unknown++
+		}
+	}
+	return report
+}
+
+// ImageVulnAlert returns the Slack message about the CRITICAL vulnerabilities of report and
+// whether there are any.
+func ImageVulnAlert(report types.ImageVulnReport) (string, bool) {
+	if report.Critical == 0 {
+		return "", false
+	}
+	text := fmt.Sprintf("huskyCI: Trivy found %d CRITICAL vulnerabilities in the securityTest image %s, which will not be run unless HUSKYCI_ALLOW_VULNERABLE_IMAGES is set.",
+		report.Critical, report.Image)
+	return text, true
+}
+
+// GetImageVulnReports returns the last report of each image scanned, sorted by image.
+func GetImageVulnReports() []types.ImageVulnReport {
+	imageVulnState.Lock()
+	defer imageVulnState.Unlock()
+	reports := make([]types.ImageVulnReport, 0, len(imageVulnState.reports))
+	for _, report := range imageVulnState.reports {
+		reports = append(reports, report)
+	}
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Image < reports[j].Image
+	})
+	return reports
+}
+
+// scanImage scans image with Trivy and keeps its report, alerting Slack if it has CRITICAL
+// vulnerabilities. An image being scanned already is not scanned twice: its report is waited
+// for instead.
+func scanImage(ctx goContext.Context, image string) types.ImageVulnReport {
+	imageVulnState.Lock()
+	if done, ok := imageVulnState.scanning[image]; ok {
+		imageVulnState.Unlock()
+		<-done
+		imageVulnState.Lock()
+		defer imageVulnState.Unlock()
+		return imageVulnState.reports[image]
+	}
+	done := make(chan struct{})
+	imageVulnState.scanning[image] = done
+	imageVulnState.Unlock()
+
+	scannedAt := time.Now()
+	vulnerabilities, err := dockers.ImageVulnScan(ctx, image)
+	report := SummarizeImageVulns(image, vulnerabilities, scannedAt)
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	imageVulnState.Lock()
+	imageVulnState.reports[image] = report
+	delete(imageVulnState.scanning, image)
+	imageVulnState.Unlock()
+	close(done)
+
+	notifyImageVulns(report)
+	return report
+}
+
+// notifyImageVulns alerts Slack if report has CRITICAL vulnerabilities.
+func notifyImageVulns(report types.ImageVulnReport) {
+	text, notify := ImageVulnAlert(report)
+	if !notify {
+		return
+	}
+	config := apiContext.APIConfiguration
+	slackNotifier := notifier.NewSlackNotifier(config.SlackWebhookURL)
+	if slackNotifier == nil {
+		return
+	}
+	slackNotifier.Channel = config.SlackChannel
+	slackNotifier.NotifyAsync(text, func(err error) {
+		log.Error("notifyImageVulns", logInfoAnalysis, 2024, err)
+	})
+}
+
+// NeedsImageVulnScan returns whether an image must be scanned again at now given report, its
+// last one if found: it was never scanned, it was more than a day ago or, if it could not
+// be, more than imageVulnRetryBackoff ago.
+func NeedsImageVulnScan(report types.ImageVulnReport, found bool, now time.Time) bool {
+	if !found {
+		return true
+	}
+	if report.Error != "" {
+		return now.Sub(report.ScannedAt) > imageVulnRetryBackoff
+	}
+	return now.Sub(report.ScannedAt) > imageVulnReportMaxAge
+}
+
+// GateImage returns dockers.ErrVulnerableImage if the last report of image, which is about to
+// run, has CRITICAL vulnerabilities, unless HUSKYCI_ALLOW_VULNERABLE_IMAGES is set. Images that
+// need to be scanned, see NeedsImageVulnScan, are scanned in background, as Trivy may take
+// minutes, and gated by their last report meanwhile. An image never scanned is let through
+// until its report is ready, so Trivy being slow or down does not stop every analysis.
+func GateImage(ctx goContext.Context, image string) error {
+	if apiContext.APIConfiguration.AllowVulnerableImages || strings.HasPrefix(image, dockers.TrivyImage+":") {
+		return nil
+	}
+	imageVulnState.Lock()
+	report, found := imageVulnState.reports[image]
+	_, scanning := imageVulnState.scanning[image]
+	imageVulnState.Unlock()
+	if !scanning && NeedsImageVulnScan(report, found, time.Now()) {
+		// not bound to ctx, as the report is kept for the next analyses
+		go scanImage(goContext.Background(), image)
+	}
+	if report.Critical > 0 {
+		log.Warning("GateImage", logInfoAnalysis, 133, image, report.Critical)
+		return dockers.ErrVulnerableImage
+	}
+	return nil
+}
+
+// RunImageVulnScan scans the image of every securityTest registered and returns their reports.
+func RunImageVulnScan(ctx goContext.Context) ([]types.ImageVulnReport, error) {
+	images, err := securityTestImages()
+	if err != nil {
+		return nil, err
+	}
+	reports := []types.ImageVulnReport{}
+	for _, image := range images {
+		reports = append(reports, scanImage(ctx, image))
+	}
+	log.Info("RunImageVulnScan", logInfoAnalysis, 45, len(reports))
+	return reports, nil
+}
+
+// securityTestImages returns the image of every securityTest registered, such as
+// huskyci/gosec:2.1.0, once each.
+func securityTestImages() ([]string, error) {
+	securityTests, err := apiContext.APIConfiguration.DBInstance.FindAllDBSecurityTest(map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	images := []string{}
+	listed := map[string]bool{}
+	for _, securityTest := range securityTests {
+		image := fmt.Sprintf("%s:%s", securityTest.Image, securityTest.ImageTag)
+		if securityTest.Image == "" || listed[image] {
+			continue
+		}
+		listed[image] = true
+		images = append(images, image)
+	}
+	return images, nil
+}
+
+// NextImageVulnScan returns when the images of securityTests are scanned next after now: at
+// 2am UTC, so the scan does not run along with the archive job.
+func NextImageVulnScan(now time.Time) time.Time {
+	next := now.UTC().Truncate(24 * time.Hour).Add(2 * time.Hour)
+	if !next.After(now) {
+		next = next.Add(24 * time.Hour)
+	}
+	return next
+}
+
+// StartImageVulnScanJob gates the images run on their vulnerabilities and scans the image of
+// every securityTest every night in background.
+func StartImageVulnScanJob() {
+	dockers.ImageGate = GateImage
+	go func() {
+		for {
+			time.Sleep(time.Until(NextImageVulnScan(time.Now())))
+			if _, err := RunImageVulnScan(goContext.Background()); err != nil {
+				log.Error("StartImageVulnScanJob", logInfoAnalysis, 2044, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,77 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Image vulnerabilities", func() {
+
+	scannedAt := time.Date(2020, 3, 10, 2, 0, 0, 0, time.UTC)
+
+	Describe("SummarizeImageVulns", func() {
+		It("Should count vulnerabilities by severity", func() {
+			vulnerabilities := []dockers.TrivyVulnerability{
+				{VulnerabilityID: "CVE-1", Severity: "CRITICAL"},
+				{VulnerabilityID: "CVE-2", Severity: "HIGH"},
+				{VulnerabilityID: "CVE-3", Severity: "HIGH"},
+				{VulnerabilityID: "CVE-4", Severity: "low"},
+				{VulnerabilityID: "CVE-5", Severity: "UNKNOWN"},
+			}
+			Expect(analysis.SummarizeImageVulns("huskyci/gosec:2.1.0", vulnerabilities, scannedAt)).To(Equal(types.ImageVulnReport{
+				Image:     "huskyci/gosec:2.1.0",
+				ScannedAt: scannedAt,
+				Critical:  1,
+				High:      2,
+				Low:       1,
+				Unknown:   1,
+			}))
+		})
+	})
+
+	Describe("ImageVulnAlert", func() {
+		It("Should only alert about images with CRITICAL vulnerabilities", func() {
+			_, notify := analysis.ImageVulnAlert(types.ImageVulnReport{Image: "huskyci/gosec:2.1.0", High: 3})
+			Expect(notify).To(BeFalse())
+			text, notify := analysis.ImageVulnAlert(types.ImageVulnReport{Image: "huskyci/gosec:2.1.0", Critical: 2})
+			Expect(notify).To(BeTrue())
+			Expect(text).To(ContainSubstring("2 CRITICAL vulnerabilities"))
+			Expect(text).To(ContainSubstring("huskyci/gosec:2.1.0"))
+		})
+	})
+
+	Describe("NeedsImageVulnScan", func() {
+		It("Should scan images never scanned or scanned more than a day ago", func() {
+			Expect(analysis.NeedsImageVulnScan(types.ImageVulnReport{}, false, scannedAt)).To(BeTrue())
+			report := types.ImageVulnReport{Image: "huskyci/gosec:2.1.0", ScannedAt: scannedAt}
+			Expect(analysis.NeedsImageVulnScan(report, true, scannedAt.Add(23*time.Hour))).To(BeFalse())
+			Expect(analysis.NeedsImageVulnScan(report, true, scannedAt.Add(25*time.Hour))).To(BeTrue())
+		})
+		It("Should back off before scanning again images that could not be scanned", func() {
+			report := types.ImageVulnReport{Image: "huskyci/gosec:2.1.0", ScannedAt: scannedAt, Error: "trivy could not scan the image"}
+			Expect(analysis.NeedsImageVulnScan(report, true, scannedAt.Add(time.Minute))).To(BeFalse())
+			Expect(analysis.NeedsImageVulnScan(report, true, scannedAt.Add(2*time.Hour))).To(BeTrue())
+		})
+	})
+
+	Describe("NextImageVulnScan", func() {
+		It("Should return 2am UTC of the same day before it", func() {
+			now := time.Date(2020, 3, 10, 1, 30, 0, 0, time.UTC)
+			Expect(analysis.NextImageVulnScan(now)).To(Equal(scannedAt))
+		})
+		It("Should return 2am UTC of the next day after it", func() {
+			now := time.Date(2020, 3, 10, 2, 0, 0, 0, time.UTC)
+			Expect(analysis.NextImageVulnScan(now)).To(Equal(time.Date(2020, 3, 11, 2, 0, 0, 0, time.UTC)))
+		})
+	})
+})
@@ -0,0 +1,73 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"sort"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// ErrIntegrityNotConfigured is returned when HUSKYCI_INTEGRITY_KEY is not set.
+var ErrIntegrityNotConfigured = errors.New("analysis integrity is not configured")
+
+// integrityPayload is what the Integrity of an analysis signs. Compliance and confidence
+// are left out of its findings, as they depend on the configuration rather than on the scan.
+type integrityPayload struct {
+	RID        string                 `json:"RID"`
+	URL        string                 `json:"repositoryURL"`
+	Result     string                 `json:"result"`
+	ErrorFound string                 `json:"errorFound"`
+	Findings   []types.UnifiedFinding `json:"findings"`
+}
+
+// ComputeIntegrity returns the hex-encoded HMAC-SHA256, using key, of the canonical JSON
+// of analysisResult: its identity, result and findings sorted by their key.
+func ComputeIntegrity(key string, analysisResult types.Analysis) string {
+	findings := UnifyFindings(analysisResult.HuskyCIResults)
+	for i := range findings {
+		findings[i].Compliance = types.ComplianceMapping{}
+		findings[i].ConfidenceScore = 0
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findingKey(findings[i]) < findingKey(findings[j])
+	})
+	payload, _ := json.Marshal(integrityPayload{
+		RID:        analysisResult.RID,
+		URL:        analysisResult.URL,
+		Result:     analysisResult.Result,
+		ErrorFound: analysisResult.ErrorFound,
+		Findings:   findings,
+	})
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write(payload)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// VerifyIntegrity returns whether the stored Integrity of analysisResult matches its
+// content, so it was not changed after it finished. An analysis without one is not valid.
+func VerifyIntegrity(analysisResult types.Analysis) bool {
+	key := apiContext.APIConfiguration.IntegrityKey
+	if key == "" || analysisResult.Integrity == "" {
+		return false
+	}
+	return hmac.Equal([]byte(analysisResult.Integrity), []byte(ComputeIntegrity(key, analysisResult)))
+}
+
+// analysisIntegrity returns the Integrity of a finished analysis or, if
+// HUSKYCI_INTEGRITY_KEY is not set, an empty string.
+func analysisIntegrity(analysisResult types.Analysis) string {
+	key := apiContext.APIConfiguration.IntegrityKey
+	if key == "" {
+		return ""
+	}
+	return ComputeIntegrity(key, analysisResult)
+}
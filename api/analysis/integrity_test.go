@@ -0,0 +1,120 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Integrity", func() {
+
+	key := "husky-integrity-key"
+	signedAnalysis := func() types.Analysis {
+		analysisResult := types.Analysis{
+			RID:    "6f9b3a1e-2c4d-4f5a-9b8c-7d6e5f4a3b2c",
+			URL:    "https://github.com/globocom/huskyCI.git",
+			Result: "failed",
+		}
+		analysisResult.HuskyCIResults.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{
+			{SecurityTool: "Bandit", Severity: "HIGH", File: "app.py", Line: "3", Details: "Use of eval", RuleID: "B307"},
+			{SecurityTool: "Bandit", Severity: "HIGH", File: "api.py", Line: "12", Details: "SQL injection", RuleID: "B608"},
+		}
+		analysisResult.HuskyCIResults.GoResults.HuskyCIGosecOutput.LowVulns = []types.HuskyCIVulnerability{
+			{SecurityTool: "GoSec", Severity: "LOW", File: "main.go", Line: "7", Details: "Errors unhandled", RuleID: "G104"},
+		}
+		analysisResult.Integrity = analysis.ComputeIntegrity(key, analysisResult)
+		return analysisResult
+	}
+
+	var previousConfig *apiContext.APIConfig
+	BeforeEach(func() {
+		previousConfig = apiContext.APIConfiguration
+		apiContext.APIConfiguration = &apiContext.APIConfig{IntegrityKey: key}
+	})
+	AfterEach(func() {
+		apiContext.APIConfiguration = previousConfig
+	})
+
+	Describe("ComputeIntegrity", func() {
+		Context("When findings are stored in another order", func() {
+			It("Should return the same integrity", func() {
+				analysisResult := signedAnalysis()
+				highVulns := analysisResult.HuskyCIResults.PythonResults.HuskyCIBanditOutput.HighVulns
+				highVulns[0], highVulns[1] = highVulns[1], highVulns[0]
+				Expect(analysis.ComputeIntegrity(key, analysisResult)).To(Equal(analysisResult.Integrity))
+			})
+		})
+		Context("When it is computed with another key", func() {
+			It("Should return another integrity", func() {
+				analysisResult := signedAnalysis()
+				Expect(analysis.ComputeIntegrity("another-key", analysisResult)).ToNot(Equal(analysisResult.Integrity))
+			})
+		})
+	})
+
+	Describe("VerifyIntegrity", func() {
+		Context("When the analysis was not changed", func() {
+			It("Should return true", func() {
+				Expect(analysis.VerifyIntegrity(signedAnalysis())).To(BeTrue())
+			})
+		})
+		Context("When any signed field was changed", func() {
+			It("Should return false", func() {
+				mutations := map[string]func(analysisResult *types.Analysis){
+					"RID":        func(analysisResult *types.Analysis) { analysisResult.RID = "another" },
+					"URL":        func(analysisResult *types.Analysis) { analysisResult.URL = "https://github.com/globocom/other.git" },
+					"result":     func(analysisResult *types.Analysis) { analysisResult.Result = "passed" },
+					"errorFound": func(analysisResult *types.Analysis) { analysisResult.ErrorFound = "error clonning" },
+					"severity": func(analysisResult *types.Analysis) {
+						analysisResult.HuskyCIResults.PythonResults.HuskyCIBanditOutput.HighVulns[0].Severity = "LOW"
+					},
+					"file": func(analysisResult *types.Analysis) {
+						analysisResult.HuskyCIResults.PythonResults.HuskyCIBanditOutput.HighVulns[0].File = "other.py"
+					},
+					"line": func(analysisResult *types.Analysis) {
+						analysisResult.HuskyCIResults.GoResults.HuskyCIGosecOutput.LowVulns[0].Line = "8"
+					},
+					"details": func(analysisResult *types.Analysis) {
+						analysisResult.HuskyCIResults.GoResults.HuskyCIGosecOutput.LowVulns[0].Details = "nothing"
+					},
+					"removed finding": func(analysisResult *types.Analysis) {
+						analysisResult.HuskyCIResults.GoResults.HuskyCIGosecOutput.LowVulns = nil
+					},
+					"suppressed finding": func(analysisResult *types.Analysis) {
+						gosecOutput := &analysisResult.HuskyCIResults.GoResults.HuskyCIGosecOutput
+						gosecOutput.NoSecVulns = gosecOutput.LowVulns
+						gosecOutput.LowVulns = nil
+					},
+				}
+				for field, mutate := range mutations {
+					analysisResult := signedAnalysis()
+					mutate(&analysisResult)
+					Expect(analysis.VerifyIntegrity(analysisResult)).To(BeFalse(), field)
+				}
+			})
+		})
+		Context("When the integrity itself was changed or removed", func() {
+			It("Should return false", func() {
+				analysisResult := signedAnalysis()
+				analysisResult.Integrity = analysis.ComputeIntegrity("forged-key", analysisResult)
+				Expect(analysis.VerifyIntegrity(analysisResult)).To(BeFalse())
+				analysisResult.Integrity = ""
+				Expect(analysis.VerifyIntegrity(analysisResult)).To(BeFalse())
+			})
+		})
+		Context("When HUSKYCI_INTEGRITY_KEY is not set", func() {
+			It("Should return false", func() {
+				analysisResult := signedAnalysis()
+				apiContext.APIConfiguration = &apiContext.APIConfig{}
+				Expect(analysis.VerifyIntegrity(analysisResult)).To(BeFalse())
+			})
+		})
+	})
+})
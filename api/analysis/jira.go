@@ -0,0 +1,90 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// jiraIssueTimeout is how long opening the JIRA issue of a single finding may take.
+const jiraIssueTimeout = 30 * time.Second
+
+// JIRAIssueFindings returns the findings of repositoryURL that are opened as JIRA issues: the
+// ones not suppressed of at least minSeverity, each one once. Their severity is the one they
+// are summarized with, so a finding with a critical CVSS score is a CRITICAL one.
+func JIRAIssueFindings(repositoryURL string, findings []types.UnifiedFinding, minSeverity string) []types.Finding {
+	issueFindings := []types.Finding{}
+	seen := map[string]bool{}
+	for _, finding := range findings {
+		severity := summarySeverity(finding)
+		if finding.Suppressed || notificationSeverityRank(severity) < notificationSeverityRank(minSeverity) {
+			continue
+		}
+		fingerprint := RepositoryFindingFingerprint(repositoryURL, finding)
+		if seen[fingerprint] {
+			continue
+		}
+		seen[fingerprint] = true
+		finding.Severity = severity
+		issueFindings = append(issueFindings, types.Finding{
+			Fingerprint:   fingerprint,
+			RepositoryURL: repositoryURL,
+			Tool:          strings.ToLower(finding.Tool),
+			Finding:       finding,
+		})
+	}
+	return issueFindings
+}
+
+// openJIRAIssues opens, in background, a JIRA issue for each finding of the analysis of RID
+// of at least HUSKYCI_JIRA_MIN_SEVERITY that has none yet, and links its key to the finding.
+// Findings must already be recorded. Errors are only logged and the findings left are opened
+// by the next analysis of the repository.
+func openJIRAIssues(RID, repositoryURL string, findings []types.UnifiedFinding) {
+	config := apiContext.APIConfiguration.JIRAConfig
+	if config == nil {
+		return
+	}
+	jn := notifier.NewJIRANotifier(config.URL, config.Project, config.Email, config.Token, config.Username, config.Password)
+	if jn == nil {
+		return
+	}
+	issueFindings := JIRAIssueFindings(repositoryURL, findings, config.MinSeverity)
+	if len(issueFindings) == 0 {
+		return
+	}
+	jn.ReportAsync(func() error {
+		for _, finding := range issueFindings {
+			findingQuery := map[string]interface{}{"fingerprint": finding.Fingerprint}
+			stored, err := apiContext.APIConfiguration.DBInstance.FindOneDBFinding(findingQuery)
+			if err == nil && stored.JIRAIssueKey != "" {
+				continue
+			}
+			// an issue still open in JIRA is found again by CreateIssue, so none is duplicated
+			ctx, cancel := goContext.WithTimeout(goContext.Background(), jiraIssueTimeout)
+			key, err := jn.CreateIssue(ctx, finding)
+			cancel()
+			if err != nil {
+				return err
+			}
+			updateQuery := map[string]interface{}{"$set": bson.M{"jiraIssueKey": key}}
+			if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBFinding(findingQuery, updateQuery); err != nil {
+				return err
+			}
+			log.Info("openJIRAIssues", logInfoAnalysis, 53, RID, key, finding.Fingerprint)
+		}
+		return nil
+	}, func(err error) {
+		log.Error("openJIRAIssues", logInfoAnalysis, 2049, RID, err)
+	})
+}
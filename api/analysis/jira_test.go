@@ -0,0 +1,46 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JIRAIssueFindings", func() {
+
+	repositoryURL := "https://github.com/globocom/huskyCI.git"
+	high := types.UnifiedFinding{Tool: "Bandit", Severity: "high", File: "app.py", Line: 3, RuleID: "B602"}
+	medium := types.UnifiedFinding{Tool: "GoSec", Severity: "MEDIUM", File: "main.go", Line: 12, RuleID: "G104"}
+
+	It("Should only open findings of at least the minimum severity, fingerprinted as they are recorded", func() {
+		issueFindings := analysis.JIRAIssueFindings(repositoryURL, []types.UnifiedFinding{high, medium}, "HIGH")
+		Expect(issueFindings).To(HaveLen(1))
+		Expect(issueFindings[0].Fingerprint).To(Equal(analysis.RepositoryFindingFingerprint(repositoryURL, high)))
+		Expect(issueFindings[0].RepositoryURL).To(Equal(repositoryURL))
+		Expect(issueFindings[0].Tool).To(Equal("bandit"))
+		Expect(issueFindings[0].Finding.Severity).To(Equal("HIGH"))
+	})
+
+	It("Should open a finding with a critical CVSS score as a CRITICAL one", func() {
+		critical := medium
+		critical.CVSSScore = 9.8
+		issueFindings := analysis.JIRAIssueFindings(repositoryURL, []types.UnifiedFinding{critical}, "CRITICAL")
+		Expect(issueFindings).To(HaveLen(1))
+		Expect(issueFindings[0].Finding.Severity).To(Equal("CRITICAL"))
+	})
+
+	It("Should leave out suppressed findings and open each finding once", func() {
+		suppressed := high
+		suppressed.RuleID = "B105"
+		suppressed.Suppressed = true
+		issueFindings := analysis.JIRAIssueFindings(repositoryURL, []types.UnifiedFinding{high, high, suppressed}, "LOW")
+		Expect(issueFindings).To(HaveLen(1))
+		Expect(issueFindings[0].Finding.RuleID).To(Equal("B602"))
+	})
+})
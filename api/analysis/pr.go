@@ -0,0 +1,247 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/google/uuid"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const logActionNewPRAnalysis = "NewPRAnalysis"
+
+var (
+	// ErrPRAnalysisNotFound is returned when no pull request analysis has the given ID.
+	ErrPRAnalysisNotFound = errors.New("pull request analysis not found")
+	// ErrPRSameBranch is returned when the head and base branches of a pull request are the same.
+	ErrPRSameBranch = errors.New("head and base branches must be different")
+)
+
+// prAnalysisPollInterval is how often a pull request analysis checks if its head and base
+// analyses finished.
+var prAnalysisPollInterval = 5 * time.Second
+
+// prAnalysisStartGrace is how long a pull request analysis waits, besides the analysis
+// timeout, for its head and base analyses to be stored as finished.
+const prAnalysisStartGrace = time.Minute
+
+// reusableResults are the results of the analyses whose findings can be compared: the ones
+// that ran every securityTest.
+var reusableResults = []string{ResultPassed, ResultFailed, ResultWarning}
+
+// NewPRAnalysis starts the analyses of the head and base branches of a pull request and,
+// in background, compares their findings once both finish. If baseCommit was already
+// analyzed, its analysis is reused instead of starting a new one. ID identifies the pull
+// request analysis, which is stored with status running until the comparison is done.
+func NewPRAnalysis(ID string, request types.PRAnalysisRequest) (types.PRAnalysisResult, error) {
+	if request.HeadBranch == request.BaseBranch {
+		return types.PRAnalysisResult{}, ErrPRSameBranch
+	}
+	prAnalysis := types.PRAnalysisResult{
+		ID:         ID,
+		URL:        request.URL,
+		HeadBranch: request.HeadBranch,
+		BaseBranch: request.BaseBranch,
+		HeadCommit: request.HeadCommit,
+		BaseCommit: request.BaseCommit,
+		HeadRID:    uuid.New().String(),
+		Status:     StatusRunning,
+		StartedAt:  time.Now(),
+	}
+
+	baseAnalysis, found, err := findAnalyzedCommit(request.URL, request.BaseCommit)
+	if err != nil {
+		return types.PRAnalysisResult{}, err
+	}
+	if found {
+		prAnalysis.BaseRID = baseAnalysis.RID
+		prAnalysis.BaseReused = true
+	} else {
+		prAnalysis.BaseRID = uuid.New().String()
+		baseRepository := types.Repository{URL: request.URL, Branch: request.BaseBranch, CommitSHA: request.BaseCommit}
+		if err := NewAnalysis(prAnalysis.BaseRID, baseRepository); err != nil {
+			return types.PRAnalysisResult{}, err
+		}
+	}
+	headRepository := types.Repository{URL: request.URL, Branch: request.HeadBranch, CommitSHA: request.HeadCommit}
+	if err := NewAnalysis(prAnalysis.HeadRID, headRepository); err != nil {
+		return types.PRAnalysisResult{}, err
+	}
+
+	if err := apiContext.APIConfiguration.DBInstance.InsertDBPRAnalysis(prAnalysis); err != nil {
+		log.Error(logActionNewPRAnalysis, logInfoAnalysis, 2033, ID, err)
+		return types.PRAnalysisResult{}, err
+	}
+	log.Info(logActionNewPRAnalysis, logInfoAnalysis, 41, ID)
+
+	deadline := prAnalysis.StartedAt.Add(apiContext.APIConfiguration.AnalysisTimeout + prAnalysisStartGrace)
+	err = inFlight.goTracked(func(stopped <-chan struct{}) {
+		waitPRAnalysis(stopped, prAnalysis, deadline)
+	})
+	if err != nil {
+		storeInterruptedPRAnalysis(prAnalysis)
+		return types.PRAnalysisResult{}, err
+	}
+	return prAnalysis, nil
+}
+
+// FindPRAnalysis returns the pull request analysis of a given ID. If it does not
+// exist, ErrPRAnalysisNotFound is returned.
+func FindPRAnalysis(ID string) (types.PRAnalysisResult, error) {
+	prAnalysis, err := apiContext.APIConfiguration.DBInstance.FindOneDBPRAnalysis(map[string]interface{}{"id": ID})
+	if err != nil {
+		if isNotFound(err) {
+			return prAnalysis, ErrPRAnalysisNotFound
+		}
+		log.Error("FindPRAnalysis", logInfoAnalysis, 1020, err)
+		return prAnalysis, err
+	}
+	return prAnalysis, nil
+}
+
+// findAnalyzedCommit returns the most recent finished analysis of commit, given either by
+// its full SHA or by the one of the analysis request. Only analyses that passed, failed or
+// warned are returned: an analysis with an error did not run every securityTest.
+func findAnalyzedCommit(repositoryURL, commit string) (types.Analysis, bool, error) {
+	if commit == "" {
+		return types.Analysis{}, false, nil
+	}
+	for _, commitField := range []string{"commit", "commitSHA"} {
+		analysisQuery := map[string]interface{}{
+			"repositoryURL": repositoryURL,
+			commitField:     commit,
+			"status":        StatusFinished,
+			"result":        bson.M{"$in": reusableResults},
+			"errorFound":    bson.M{"$in": []interface{}{"", nil}},
+		}
+		analysisResult, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysis(analysisQuery)
+		if err == nil {
+			return analysisResult, true, nil
+		}
+		if !isNotFound(err) {
+			log.Error(logActionNewPRAnalysis, logInfoAnalysis, 1009, err)
+			return types.Analysis{}, false, err
+		}
+	}
+	return types.Analysis{}, false, nil
+}
+
+// waitPRAnalysis polls the head and base analyses of prAnalysis until both stop running,
+// or until deadline, and stores their comparison. It stores prAnalysis as interrupted once
+// stopped is closed, as its head and base analyses are interrupted too.
+func waitPRAnalysis(stopped <-chan struct{}, prAnalysis types.PRAnalysisResult, deadline time.Time) {
+	var baseAnalysis, headAnalysis types.Analysis
+	for {
+		var baseErr, headErr error
+		baseAnalysis, baseErr = FindAnalysis(prAnalysis.BaseRID)
+		headAnalysis, headErr = FindAnalysis(prAnalysis.HeadRID)
+		// an analysis just started may not be stored yet
+		if baseErr == nil && headErr == nil && baseAnalysis.Status != StatusRunning && headAnalysis.Status != StatusRunning {
+			break
+		}
+		if time.Now().After(deadline) {
+			log.Warning(logActionNewPRAnalysis, logInfoAnalysis, 129, prAnalysis.ID)
+			prAnalysis.Status = StatusTimeout
+			prAnalysis.ErrorFound = "head and base analyses did not finish in time"
+			prAnalysis.FinishedAt = time.Now()
+			storePRAnalysis(prAnalysis)
+			return
+		}
+		select {
+		case <-stopped:
+			storeInterruptedPRAnalysis(prAnalysis)
+			return
+		case <-time.After(prAnalysisPollInterval):
+		}
+	}
+	storePRAnalysis(finishPRAnalysis(prAnalysis, baseAnalysis, headAnalysis))
+}
+
+// storeInterruptedPRAnalysis stores prAnalysis as interrupted, as huskyCI shut down before
+// its head and base analyses could be compared.
+func storeInterruptedPRAnalysis(prAnalysis types.PRAnalysisResult) {
+	prAnalysis.Status = StatusInterrupted
+	prAnalysis.ErrorFound = ErrShuttingDown.Error()
+	prAnalysis.FinishedAt = time.Now()
+	storePRAnalysis(prAnalysis)
+}
+
+// finishPRAnalysis compares the findings of the finished head and base analyses of
+// prAnalysis. If either did not finish successfully, their findings are not compared.
+func finishPRAnalysis(prAnalysis types.PRAnalysisResult, baseAnalysis, headAnalysis types.Analysis) types.PRAnalysisResult {
+	prAnalysis.FinishedAt = time.Now()
+	for _, analysisResult := range []types.Analysis{headAnalysis, baseAnalysis} {
+		if analysisResult.Status != StatusFinished || analysisResult.ErrorFound != "" {
+			prAnalysis.Status = StatusErrorRunning
+			prAnalysis.ErrorFound = fmt.Sprintf("analysis %s of branch %s did not finish: %s %s", analysisResult.RID, analysisResult.Branch, analysisResult.Status, analysisResult.ErrorFound)
+			return prAnalysis
+		}
+	}
+	newFindings, resolvedFindings, persistentFindings := CompareFindings(UnifyFindings(baseAnalysis.HuskyCIResults), UnifyFindings(headAnalysis.HuskyCIResults))
+	prAnalysis.Status = StatusFinished
+	prAnalysis.NewFindings = SortByConfidence(newFindings)
+	prAnalysis.ResolvedFindings = resolvedFindings
+	prAnalysis.PersistentFindings = persistentFindings
+	prAnalysis.NewSummary = Summarize(newFindings)
+	review := BuildPRReview(prAnalysis)
+	prAnalysis.Review = &review
+	return prAnalysis
+}
+
+func storePRAnalysis(prAnalysis types.PRAnalysisResult) {
+	updatedPRAnalysis := map[string]interface{}{
+		"status":             prAnalysis.Status,
+		"errorFound":         prAnalysis.ErrorFound,
+		"newFindings":        prAnalysis.NewFindings,
+		"resolvedFindings":   prAnalysis.ResolvedFindings,
+		"persistentFindings": prAnalysis.PersistentFindings,
+		"newSummary":         prAnalysis.NewSummary,
+		"review":             prAnalysis.Review,
+		"finishedAt":         prAnalysis.FinishedAt,
+	}
+	err := apiContext.APIConfiguration.DBInstance.UpdateOneDBPRAnalysis(map[string]interface{}{"id": prAnalysis.ID}, updatedPRAnalysis)
+	if err != nil {
+		log.Error(logActionNewPRAnalysis, logInfoAnalysis, 2033, prAnalysis.ID, err)
+	}
+}
+
+// BuildPRReview returns a review of a pull request analysis that summarizes its findings and
+// comments each new finding on its line. New findings without a file and line are only
+// counted in the body, as GitHub and GitLab can not comment them inline.
+func BuildPRReview(prAnalysis types.PRAnalysisResult) types.PRReview {
+	review := types.PRReview{Comments: []types.PRReviewComment{}}
+	var body strings.Builder
+	if len(prAnalysis.NewFindings) == 0 {
+		body.WriteString("huskyCI found no new security issues.")
+	} else {
+		fmt.Fprintf(&body, "huskyCI found %d new security issue(s)", len(prAnalysis.NewFindings))
+		if summary := prAnalysis.NewSummary; summary != nil {
+			fmt.Fprintf(&body, ": %d critical, %d high, %d medium, %d low and %d info", summary.Critical, summary.High, summary.Medium, summary.Low, summary.Info)
+		}
+		body.WriteString(".")
+	}
+	fmt.Fprintf(&body, " %d issue(s) were resolved and %d persist from %s.", len(prAnalysis.ResolvedFindings), len(prAnalysis.PersistentFindings), prAnalysis.BaseBranch)
+
+	for _, finding := range prAnalysis.NewFindings {
+		if finding.File == "" || finding.Line <= 0 {
+			continue
+		}
+		comment := fmt.Sprintf("**%s** %s", finding.Severity, finding.Tool)
+		if finding.RuleID != "" {
+			comment += " " + finding.RuleID
+		}
+		comment += ": " + finding.Description
+		review.Comments = append(review.Comments, types.PRReviewComment{Path: finding.File, Line: finding.Line, Body: comment})
+	}
+	review.Body = body.String()
+	return review
+}
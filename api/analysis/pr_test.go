@@ -0,0 +1,161 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/db"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// prAnalysesDB keeps the queries of analyses and the updates of pull request analyses, and
+// returns analysis if it is set. Any other request panics.
+type prAnalysesDB struct {
+	db.Requests
+	analysis  *types.Analysis
+	queries   []map[string]interface{}
+	prUpdates []map[string]interface{}
+}
+
+func (fDB *prAnalysesDB) FindOneDBAnalysis(mapParams map[string]interface{}) (types.Analysis, error) {
+	fDB.queries = append(fDB.queries, mapParams)
+	if fDB.analysis == nil {
+		return types.Analysis{}, errors.New("No data found")
+	}
+	return *fDB.analysis, nil
+}
+
+func (fDB *prAnalysesDB) UpdateOneDBPRAnalysis(mapParams map[string]interface{}, updatedPRAnalysis map[string]interface{}) error {
+	fDB.prUpdates = append(fDB.prUpdates, updatedPRAnalysis)
+	return nil
+}
+
+var _ = Describe("PRAnalysis", func() {
+
+	kept := types.HuskyCIVulnerability{SecurityTool: "GoSec", Severity: "LOW", File: "main.go", Line: "10", Details: "Errors unhandled", RuleID: "G104"}
+	fixed := types.HuskyCIVulnerability{SecurityTool: "GoSec", Severity: "HIGH", File: "config.go", Line: "5", Details: "Hardcoded credentials", RuleID: "G101"}
+	introduced := types.HuskyCIVulnerability{SecurityTool: "GoSec", Severity: "HIGH", File: "client.go", Line: "20", Details: "TLS InsecureSkipVerify set true", RuleID: "G402"}
+
+	goAnalysis := func(RID, branch string, vulns ...types.HuskyCIVulnerability) types.Analysis {
+		return types.Analysis{
+			RID:    RID,
+			Branch: branch,
+			Status: analysis.StatusFinished,
+			HuskyCIResults: types.HuskyCIResults{
+				GoResults: types.GoResults{
+					HuskyCIGosecOutput: types.HuskyCISecurityTestOutput{LowVulns: vulns},
+				},
+			},
+		}
+	}
+	prAnalysis := types.PRAnalysisResult{ID: "PR", HeadBranch: "feature", BaseBranch: "master", HeadRID: "head", BaseRID: "base", Status: analysis.StatusRunning}
+
+	Describe("FinishPRAnalysis", func() {
+
+		Context("When both analyses finished", func() {
+			result := analysis.FinishPRAnalysis(prAnalysis, goAnalysis("base", "master", kept, fixed), goAnalysis("head", "feature", kept, introduced))
+
+			It("Should return the new, resolved and persistent findings of head", func() {
+				Expect(result.Status).To(Equal(analysis.StatusFinished))
+				Expect(result.ErrorFound).To(BeEmpty())
+				Expect(result.NewFindings).To(HaveLen(1))
+				Expect(result.NewFindings[0].RuleID).To(Equal("G402"))
+				Expect(result.ResolvedFindings).To(HaveLen(1))
+				Expect(result.ResolvedFindings[0].RuleID).To(Equal("G101"))
+				Expect(result.PersistentFindings).To(HaveLen(1))
+				Expect(result.PersistentFindings[0].RuleID).To(Equal("G104"))
+				Expect(result.NewSummary.High).To(Equal(1))
+				Expect(result.FinishedAt.IsZero()).To(BeFalse())
+			})
+
+			It("Should comment the new findings on their lines", func() {
+				Expect(result.Review).ToNot(BeNil())
+				Expect(result.Review.Comments).To(Equal([]types.PRReviewComment{
+					{Path: "client.go", Line: 20, Body: "**HIGH** GoSec G402: TLS InsecureSkipVerify set true"},
+				}))
+				Expect(result.Review.Body).To(ContainSubstring("1 new security issue(s)"))
+				Expect(result.Review.Body).To(ContainSubstring("1 issue(s) were resolved and 1 persist from master"))
+			})
+		})
+
+		Context("When an analysis did not finish", func() {
+			It("Should not compare their findings", func() {
+				headAnalysis := goAnalysis("head", "feature", introduced)
+				headAnalysis.Status = analysis.StatusInterrupted
+				result := analysis.FinishPRAnalysis(prAnalysis, goAnalysis("base", "master"), headAnalysis)
+				Expect(result.Status).To(Equal(analysis.StatusErrorRunning))
+				Expect(result.ErrorFound).To(ContainSubstring("analysis head of branch feature did not finish: interrupted"))
+				Expect(result.NewFindings).To(BeEmpty())
+				Expect(result.Review).To(BeNil())
+			})
+		})
+	})
+
+	Describe("BuildPRReview", func() {
+		It("Should not comment new findings without a line", func() {
+			review := analysis.BuildPRReview(types.PRAnalysisResult{
+				BaseBranch:  "master",
+				NewFindings: []types.UnifiedFinding{{Tool: "Safety", File: "requirements.txt", Severity: "HIGH", Description: "django: CVE-2019-19844"}},
+			})
+			Expect(review.Comments).To(BeEmpty())
+			Expect(review.Body).To(ContainSubstring("1 new security issue(s)"))
+		})
+
+		It("Should state when no issue was introduced", func() {
+			review := analysis.BuildPRReview(types.PRAnalysisResult{BaseBranch: "master"})
+			Expect(review.Body).To(HavePrefix("huskyCI found no new security issues."))
+		})
+	})
+
+	Context("With a database", func() {
+		var previousConfig *apiContext.APIConfig
+		var fakeDB *prAnalysesDB
+
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+			fakeDB = &prAnalysesDB{}
+			apiContext.APIConfiguration = &apiContext.APIConfig{DBInstance: fakeDB}
+		})
+
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+
+		Describe("FindAnalyzedCommit", func() {
+			It("Should only reuse analyses that passed, failed or warned without errors", func() {
+				_, found, err := analysis.FindAnalyzedCommit("repo", "9fceb02d0ae598e95dc970b74767f19372d61af8")
+				Expect(err).ToNot(HaveOccurred())
+				Expect(found).To(BeFalse())
+				Expect(fakeDB.queries).To(HaveLen(2))
+				for _, query := range fakeDB.queries {
+					Expect(query).To(HaveKeyWithValue("result", bson.M{"$in": []string{"passed", "failed", "warning"}}))
+					Expect(query).To(HaveKeyWithValue("errorFound", bson.M{"$in": []interface{}{"", nil}}))
+				}
+			})
+		})
+
+		Describe("WaitPRAnalysis", func() {
+			It("Should store it as interrupted once huskyCI shuts down", func() {
+				running := goAnalysis("head", "feature")
+				running.Status = analysis.StatusRunning
+				fakeDB.analysis = &running
+				stopped := make(chan struct{})
+				close(stopped)
+				analysis.WaitPRAnalysis(stopped, prAnalysis, time.Now().Add(time.Hour))
+				Expect(fakeDB.prUpdates).To(HaveLen(1))
+				Expect(fakeDB.prUpdates[0]["status"]).To(Equal(analysis.StatusInterrupted))
+				Expect(fakeDB.prUpdates[0]["errorFound"]).To(Equal(analysis.ErrShuttingDown.Error()))
+			})
+		})
+	})
+})
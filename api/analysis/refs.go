@@ -0,0 +1,40 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// finalizeRefs computes the result of every other ref scanned by an analysis and returns
+// its containers along with the ones of its Branch, which are given first.
+func finalizeRefs(containers []types.Container, refScans []*securitytest.RunAllInfo) ([]types.Container, []types.RefResult) {
+	allContainers := append([]types.Container{}, containers...)
+	refResults := []types.RefResult{}
+	for _, refScan := range refScans {
+		refResults = append(refResults, refResult(refScan))
+		allContainers = append(allContainers, refScan.Containers...)
+	}
+	return allContainers, refResults
+}
+
+// refResult finalizes the results of a ref as the ones of an analysis.
+func refResult(refScan *securitytest.RunAllInfo) types.RefResult {
+	FinalizeResults(refScan)
+	// best-effort: unknown CVEs are stored as they are
+	NewCVEEnricher().Enrich(&refScan.HuskyCIResults)
+	errorString := ""
+	if refScan.ErrorFound != nil {
+		errorString = refScan.ErrorFound.Error()
+	}
+	return types.RefResult{
+		Ref:            refScan.Ref,
+		Result:         refScan.FinalResult,
+		ErrorFound:     errorString,
+		Codes:          refScan.Codes,
+		HuskyCIResults: refScan.HuskyCIResults,
+	}
+}
@@ -0,0 +1,52 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Refs", func() {
+
+	Describe("FinalizeRefs", func() {
+		Context("When other refs were scanned along with the branch", func() {
+			branchContainers := []types.Container{{CResult: "passed", Ref: "feature"}}
+			baseScan := &securitytest.RunAllInfo{
+				Ref:        "main",
+				Containers: []types.Container{{CResult: "failed", Ref: "main"}},
+				Codes:      []types.Code{{Language: "Go"}},
+			}
+			baseScan.HuskyCIResults.GoResults.HuskyCIGosecOutput.HighVulns = []types.HuskyCIVulnerability{{Details: "G101"}}
+			containers, refResults := analysis.FinalizeRefs(branchContainers, []*securitytest.RunAllInfo{baseScan})
+
+			It("Should return the containers of the branch first, followed by the ones of each ref", func() {
+				Expect(containers).To(HaveLen(2))
+				Expect(containers[0].Ref).To(Equal("feature"))
+				Expect(containers[1].Ref).To(Equal("main"))
+			})
+
+			It("Should return the result and findings of each ref", func() {
+				Expect(refResults).To(HaveLen(1))
+				Expect(refResults[0].Ref).To(Equal("main"))
+				Expect(refResults[0].Result).To(Equal(analysis.ResultFailed))
+				Expect(refResults[0].Codes).To(Equal(baseScan.Codes))
+				Expect(refResults[0].HuskyCIResults.GoResults.HuskyCIGosecOutput.HighVulns).To(HaveLen(1))
+			})
+		})
+
+		Context("When no other ref was scanned", func() {
+			It("Should return the containers of the branch and no ref results", func() {
+				containers, refResults := analysis.FinalizeRefs([]types.Container{{CResult: "passed"}}, nil)
+				Expect(containers).To(HaveLen(1))
+				Expect(refResults).To(BeEmpty())
+			})
+		})
+	})
+})
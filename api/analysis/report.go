@@ -0,0 +1,61 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// BuildReport returns analysisResult along with the status of each of its securityTests and
+// its normalized findings. Findings ignored by the request were never reported by their
+// securityTools, so they are not in it. The raw output of each container, which can be
+// large, is only kept if includeRawOutput is true.
+func BuildReport(analysisResult types.Analysis, includeRawOutput bool) types.AnalysisReport {
+	containers := make([]types.Container, len(analysisResult.Containers))
+	copy(containers, analysisResult.Containers)
+	securityTests := []types.SecurityTestReport{}
+	for i, container := range containers {
+		if !includeRawOutput {
+			containers[i].COutput = ""
+		}
+		securityTests = append(securityTests, types.SecurityTestReport{
+			Name:       container.SecurityTest.Name,
+			Status:     container.CStatus,
+			Result:     container.CResult,
+			Info:       container.CInfo,
+			StartedAt:  container.StartedAt,
+			FinishedAt: container.FinishedAt,
+			DurationMs: container.DurationMs,
+			Ref:        container.Ref,
+			Subpath:    container.Subpath,
+			Cached:     container.Cached,
+		})
+	}
+	analysisResult.Containers = containers
+	// once finished, the raw output of each container is the one to look at
+	if analysisResult.Status != StatusRunning {
+		analysisResult.OutputTails = nil
+	}
+
+	findings := UnifyFindings(analysisResult.HuskyCIResults)
+	// analyses finished before the summary was stored still have one
+	if analysisResult.Summary == nil {
+		analysisResult.Summary = Summarize(findings)
+	}
+
+	var durationMs int64
+	if !analysisResult.FinishedAt.IsZero() && analysisResult.FinishedAt.After(analysisResult.StartedAt) {
+		durationMs = analysisResult.FinishedAt.Sub(analysisResult.StartedAt).Nanoseconds() / int64(time.Millisecond)
+	}
+
+	return types.AnalysisReport{
+		Analysis:      analysisResult,
+		DurationMs:    durationMs,
+		SecurityTests: securityTests,
+		Findings:      findings,
+	}
+}
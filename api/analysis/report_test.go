@@ -0,0 +1,105 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BuildReport", func() {
+
+	startedAt := time.Date(2020, 5, 1, 10, 0, 0, 0, time.UTC)
+	analysisResult := types.Analysis{
+		RID:        "RID",
+		Status:     "finished",
+		Result:     "failed",
+		StartedAt:  startedAt,
+		FinishedAt: startedAt.Add(90 * time.Second),
+		Containers: []types.Container{
+			{
+				SecurityTest: types.SecurityTest{Name: "bandit"},
+				CStatus:      "finished",
+				CResult:      "failed",
+				COutput:      `{"results": []}`,
+				StartedAt:    startedAt,
+				FinishedAt:   startedAt.Add(time.Minute),
+				DurationMs:   60000,
+			},
+		},
+		HuskyCIResults: types.HuskyCIResults{
+			PythonResults: types.PythonResults{
+				HuskyCIBanditOutput: types.HuskyCISecurityTestOutput{
+					HighVulns: []types.HuskyCIVulnerability{
+						{SecurityTool: "Bandit", Severity: "high", File: "app.py", Line: "3", Details: "Use of exec", RuleID: "B102"},
+					},
+				},
+			},
+		},
+	}
+
+	Context("When the raw output is not included", func() {
+		report := analysis.BuildReport(analysisResult, false)
+
+		It("Should omit the output of every container", func() {
+			Expect(report.Containers[0].COutput).To(BeEmpty())
+			Expect(analysisResult.Containers[0].COutput).ToNot(BeEmpty())
+		})
+
+		It("Should return the status and timings of each securityTest", func() {
+			Expect(report.SecurityTests).To(Equal([]types.SecurityTestReport{
+				{
+					Name:       "bandit",
+					Status:     "finished",
+					Result:     "failed",
+					StartedAt:  startedAt,
+					FinishedAt: startedAt.Add(time.Minute),
+					DurationMs: 60000,
+				},
+			}))
+			Expect(report.DurationMs).To(Equal(int64(90000)))
+		})
+
+		It("Should return the normalized findings and their summary", func() {
+			Expect(report.Findings).To(HaveLen(1))
+			Expect(report.Findings[0].Tool).To(Equal("Bandit"))
+			Expect(report.Findings[0].Severity).To(Equal("HIGH"))
+			Expect(report.Findings[0].Line).To(Equal(3))
+			Expect(report.Findings[0].RuleID).To(Equal("B102"))
+			Expect(report.Summary.High).To(Equal(1))
+		})
+	})
+
+	Context("When the raw output is included", func() {
+		It("Should keep the output of every container", func() {
+			report := analysis.BuildReport(analysisResult, true)
+			Expect(report.Containers[0].COutput).To(Equal(`{"results": []}`))
+		})
+	})
+
+	Context("When the analysis is running", func() {
+		It("Should return the tail of the output of each container", func() {
+			running := analysisResult
+			running.Status = "running"
+			running.OutputTails = map[string][]string{"gosec": {"[gosec] Checking package: main"}}
+			report := analysis.BuildReport(running, false)
+			Expect(report.OutputTails).To(Equal(running.OutputTails))
+		})
+	})
+
+	Context("When the analysis is finished", func() {
+		It("Should not return the tail of the output of any container", func() {
+			finished := analysisResult
+			finished.OutputTails = map[string][]string{"gosec": {"[gosec] Checking package: main"}}
+			report := analysis.BuildReport(finished, false)
+			Expect(report.OutputTails).To(BeNil())
+		})
+	})
+})
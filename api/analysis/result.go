@@ -0,0 +1,215 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// Results of an analysis and of each of its containers.
+const (
+	ResultPassed  = "passed"
+	ResultWarning = "warning"
+	ResultFailed  = "failed"
+	ResultError   = "error"
+	// ResultTimedOut is only set to containers that did not finish before their timeout.
+	ResultTimedOut = "timedout"
+)
+
+// Status of an analysis.
+const (
+	StatusRunning      = "running"
+	StatusFinished     = "finished"
+	StatusErrorRunning = "error running"
+	// StatusTimeout is set to analyses whose securityTests were canceled by the analysis timeout.
+	StatusTimeout = "timeout"
+	// StatusCanceled is only set to containers canceled by the analysis timeout.
+	StatusCanceled = "canceled"
+	// StatusInterrupted is set to analyses that were still running when huskyCI shut down.
+	StatusInterrupted = "interrupted"
+)
+
+// InfrastructureError prefixes the analysis error when securityTests could not
+// produce a result, such as when their output could not be parsed.
+const InfrastructureError = "infrastructure error: securityTests could not be completed"
+
+// ErrAnalysisDeadline is set to analyses that did not report a result before their deadline.
+var ErrAnalysisDeadline = errors.New("analysis did not report a result before its deadline")
+
+// ErrAnalysisTimeout is set to analyses that did not finish before their timeout.
+var ErrAnalysisTimeout = errors.New("analysis did not finish before its timeout")
+
+// ComputeResult returns the overall result of an analysis given the result of each of
+// its containers, using the precedence error > failed > warning > passed. A container
+// that timed out or without a result never reported, so it is an error as well. The names of the
+// securityTests that resulted in error are also returned.
+func ComputeResult(containers []types.Container) (string, []string) {
+	erroredSecurityTests := []string{}
+	failed, warning := false, false
+	// npm audit or yarn audit always warns that the other lock file was not found,
+	// so a single JavaScript warning does not change the result.
+	jsWarningFlag := false
+
+	for _, container := range containers {
+		switch container.CResult {
+		case ResultError, ResultTimedOut, "":
+			erroredSecurityTests = append(erroredSecurityTests, container.SecurityTest.Name)
+		case ResultFailed:
+			failed = true
+		case ResultWarning:
+			if container.SecurityTest.Language == "JavaScript" && !jsWarningFlag {
+				jsWarningFlag = true
+				continue
+			}
+			warning = true
+		}
+	}
+
+	switch {
+	case len(erroredSecurityTests) > 0:
+		return ResultError, erroredSecurityTests
+	case failed:
+		return ResultFailed, erroredSecurityTests
+	case warning:
+		return ResultWarning, erroredSecurityTests
+	}
+	return ResultPassed, erroredSecurityTests
+}
+
+// FinalizeResults sets the status, result and error of an analysis once all of its
+// containers have finished, running or not. An analysis with containers canceled by
+// its timeout keeps the results of the other ones, but its status is timeout.
+func FinalizeResults(results *securitytest.RunAllInfo) {
+	if results.ErrorFound == ErrAnalysisInterrupted {
+		results.Status = StatusInterrupted
+		results.FinalResult = ResultError
+		return
+	}
+	if results.ErrorFound != nil && results.ErrorFound != ErrAnalysisTimeout {
+		results.Status = StatusErrorRunning
+		results.FinalResult = ResultError
+		return
+	}
+	if results.ErrorFound == ErrAnalysisTimeout || hasCanceledContainers(results.Containers) {
+		results.ErrorFound = ErrAnalysisTimeout
+		results.Status = StatusTimeout
+		results.FinalResult = ResultError
+		return
+	}
+	result, erroredSecurityTests := ComputeResult(results.Containers)
+	results.FinalResult = result
+	if result == ResultError {
+		// containers that could not complete make the whole analysis an
+		// infrastructure error, which is not the same as vulnerabilities found.
+		results.ErrorFound = fmt.Errorf("%s: %s", InfrastructureError, strings.Join(erroredSecurityTests, ", "))
+		results.Status = StatusErrorRunning
+		return
+	}
+	results.Status = StatusFinished
+}
+
+func hasCanceledContainers(containers []types.Container) bool {
+	for _, container := range containers {
+		if container.CStatus == StatusCanceled {
+			return true
+		}
+	}
+	return false
+}
+
+// FinalizeStaleAnalyses sets an error result to every analysis that is still running
+// after the given deadline, as its containers will never report, and sends the notifications
+// of its result, so its callback and commit status do not wait for it forever.
+func FinalizeStaleAnalyses(deadline time.Duration) error {
+	staleQuery := map[string]interface{}{
+		"status":    StatusRunning,
+		"startedAt": bson.M{"$lt": time.Now().Add(-deadline)},
+	}
+	staleAnalyses, err := apiContext.APIConfiguration.DBInstance.FindAllDBAnalysis(staleQuery)
+	if err != nil {
+		if isNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	for _, staleAnalysis := range staleAnalyses {
+		log.Warning("FinalizeStaleAnalyses", logInfoAnalysis, 115, staleAnalysis.RID)
+		updateAnalysisQuery := bson.M{
+			"status":     StatusErrorRunning,
+			"result":     ResultError,
+			"errorFound": ErrAnalysisDeadline.Error(),
+			"finishedAt": time.Now(),
+		}
+		if err := updateRunningAnalysis(staleAnalysis.RID, updateAnalysisQuery); err != nil {
+			log.Error("FinalizeStaleAnalyses", logInfoAnalysis, 2020, staleAnalysis.RID, err)
+			continue
+		}
+		metrics.AnalysesFinished.WithLabelValues(StatusErrorRunning, ResultError).Inc()
+		repository := RetryRepository(staleAnalysis)
+		// the commit status reported as started is the one of the request, not the commit scanned
+		repository.CommitSHA = staleAnalysis.CommitSHA
+		notifyFinished(staleAnalysis.RID, repository)
+	}
+	return nil
+}
+
+// StartStaleAnalysesFinalizer runs FinalizeStaleAnalyses every minute in background.
+func StartStaleAnalysesFinalizer(deadline time.Duration) {
+	go func() {
+		ticker := time.NewTicker(time.Minute)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := FinalizeStaleAnalyses(deadline); err != nil {
+				log.Error("StartStaleAnalysesFinalizer", logInfoAnalysis, 2020, err)
+			}
+		}
+	}()
+}
+
+// updateRunningAnalysis updates an analysis in a single operation that only applies
+// while it is running, so a late finalizer can not overwrite an earlier result.
+func updateRunningAnalysis(RID string, updateAnalysisQuery map[string]interface{}) error {
+	analysisQuery := map[string]interface{}{"RID": RID, "status": StatusRunning}
+	return apiContext.APIConfiguration.DBInstance.UpdateOneDBAnalysisContainer(analysisQuery, updateAnalysisQuery)
+}
+
+// AnalysisDescription returns a one-line description of a finished analysis, with the
+// severity summary of its findings, as reported to the platform its repository is hosted on.
+func AnalysisDescription(analysisResult types.Analysis) string {
+	var description string
+	switch analysisResult.Result {
+	case ResultPassed:
+		description = "huskyCI analysis passed"
+	case ResultWarning:
+		description = "huskyCI analysis passed with warnings"
+	case ResultFailed:
+		description = "huskyCI analysis failed"
+	default:
+		return "huskyCI could not finish the analysis"
+	}
+	if summary := analysisResult.Summary; summary != nil {
+		description += fmt.Sprintf(": %d critical, %d high, %d medium and %d low findings", summary.Critical, summary.High, summary.Medium, summary.Low)
+	}
+	return description
+}
+
+// truncateDescription cuts description to maxLength bytes, as platforms limit how long the
+// description of a commit status can be.
+func truncateDescription(description string, maxLength int) string {
+	if len(description) > maxLength {
+		return description[:maxLength]
+	}
+	return description
+}
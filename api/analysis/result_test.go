@@ -0,0 +1,160 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"errors"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Result", func() {
+
+	Describe("ComputeResult", func() {
+		Context("When containers have error, failed and warning results", func() {
+			It("Should return error and the securityTests that resulted in error", func() {
+				containers := []types.Container{
+					{CResult: "warning", SecurityTest: types.SecurityTest{Name: "bandit"}},
+					{CResult: "failed", SecurityTest: types.SecurityTest{Name: "safety"}},
+					{CResult: "error", SecurityTest: types.SecurityTest{Name: "gosec"}},
+				}
+				result, erroredSecurityTests := analysis.ComputeResult(containers)
+				Expect(result).To(Equal(analysis.ResultError))
+				Expect(erroredSecurityTests).To(Equal([]string{"gosec"}))
+			})
+		})
+		Context("When containers have failed and warning results", func() {
+			It("Should return failed", func() {
+				containers := []types.Container{
+					{CResult: "warning"},
+					{CResult: "failed"},
+					{CResult: "passed"},
+				}
+				result, _ := analysis.ComputeResult(containers)
+				Expect(result).To(Equal(analysis.ResultFailed))
+			})
+		})
+		Context("When a container never reported a result", func() {
+			It("Should return error", func() {
+				containers := []types.Container{
+					{CResult: "passed"},
+					{CResult: "", SecurityTest: types.SecurityTest{Name: "gitleaks"}},
+				}
+				result, erroredSecurityTests := analysis.ComputeResult(containers)
+				Expect(result).To(Equal(analysis.ResultError))
+				Expect(erroredSecurityTests).To(Equal([]string{"gitleaks"}))
+			})
+		})
+		Context("When a container timed out", func() {
+			It("Should return error", func() {
+				containers := []types.Container{
+					{CResult: "failed", SecurityTest: types.SecurityTest{Name: "bandit"}},
+					{CResult: "timedout", SecurityTest: types.SecurityTest{Name: "brakeman"}},
+				}
+				result, erroredSecurityTests := analysis.ComputeResult(containers)
+				Expect(result).To(Equal(analysis.ResultError))
+				Expect(erroredSecurityTests).To(Equal([]string{"brakeman"}))
+			})
+		})
+		Context("When a single JavaScript container has a warning", func() {
+			It("Should return passed", func() {
+				containers := []types.Container{
+					{CResult: "warning", SecurityTest: types.SecurityTest{Name: "npmaudit", Language: "JavaScript"}},
+					{CResult: "passed", SecurityTest: types.SecurityTest{Name: "yarnaudit", Language: "JavaScript"}},
+				}
+				result, _ := analysis.ComputeResult(containers)
+				Expect(result).To(Equal(analysis.ResultPassed))
+			})
+		})
+		Context("When both JavaScript containers have a warning", func() {
+			It("Should return warning", func() {
+				containers := []types.Container{
+					{CResult: "warning", SecurityTest: types.SecurityTest{Name: "npmaudit", Language: "JavaScript"}},
+					{CResult: "warning", SecurityTest: types.SecurityTest{Name: "yarnaudit", Language: "JavaScript"}},
+				}
+				result, _ := analysis.ComputeResult(containers)
+				Expect(result).To(Equal(analysis.ResultWarning))
+			})
+		})
+	})
+
+	Describe("FinalizeResults", func() {
+		Context("When a container result is error", func() {
+			It("Should set an infrastructure error instead of failed", func() {
+				results := securitytest.RunAllInfo{
+					Containers: []types.Container{
+						{CResult: "failed", SecurityTest: types.SecurityTest{Name: "bandit"}},
+						{CResult: "error", SecurityTest: types.SecurityTest{Name: "gosec"}},
+					},
+				}
+				analysis.FinalizeResults(&results)
+				Expect(results.FinalResult).To(Equal("error"))
+				Expect(results.Status).To(Equal("error running"))
+				Expect(results.ErrorFound.Error()).To(HavePrefix(analysis.InfrastructureError))
+				Expect(results.ErrorFound.Error()).To(ContainSubstring("gosec"))
+			})
+		})
+		Context("When a container was canceled by the analysis timeout", func() {
+			It("Should set the timeout status and keep the other containers", func() {
+				results := securitytest.RunAllInfo{
+					Containers: []types.Container{
+						{CResult: "failed", CStatus: "finished", SecurityTest: types.SecurityTest{Name: "bandit"}},
+						{CResult: "timedout", CStatus: "canceled", SecurityTest: types.SecurityTest{Name: "gosec"}},
+					},
+				}
+				analysis.FinalizeResults(&results)
+				Expect(results.FinalResult).To(Equal("error"))
+				Expect(results.Status).To(Equal("timeout"))
+				Expect(results.ErrorFound).To(Equal(analysis.ErrAnalysisTimeout))
+				Expect(results.Containers).To(HaveLen(2))
+			})
+		})
+		Context("When the analysis was interrupted by a shutdown", func() {
+			It("Should set the interrupted status", func() {
+				results := securitytest.RunAllInfo{
+					Containers: []types.Container{
+						{CResult: "failed", CStatus: "finished", SecurityTest: types.SecurityTest{Name: "bandit"}},
+						{CResult: "timedout", CStatus: "canceled", SecurityTest: types.SecurityTest{Name: "gosec"}},
+					},
+				}
+				results.SetAnalysisError(analysis.ErrAnalysisInterrupted)
+				analysis.FinalizeResults(&results)
+				Expect(results.FinalResult).To(Equal("error"))
+				Expect(results.Status).To(Equal("interrupted"))
+				Expect(results.ErrorFound).To(Equal(analysis.ErrAnalysisInterrupted))
+			})
+		})
+		Context("When a container result is failed", func() {
+			It("Should set the final result as failed", func() {
+				results := securitytest.RunAllInfo{
+					Containers: []types.Container{
+						{CResult: "passed"},
+						{CResult: "failed"},
+					},
+				}
+				analysis.FinalizeResults(&results)
+				Expect(results.FinalResult).To(Equal("failed"))
+				Expect(results.Status).To(Equal("finished"))
+				Expect(results.ErrorFound).To(BeNil())
+			})
+		})
+		Context("When the analysis could not run", func() {
+			It("Should keep its error and set the final result as error", func() {
+				results := securitytest.RunAllInfo{
+					ErrorFound: errors.New("could not clone repository"),
+				}
+				analysis.FinalizeResults(&results)
+				Expect(results.FinalResult).To(Equal("error"))
+				Expect(results.Status).To(Equal("error running"))
+				Expect(results.ErrorFound.Error()).To(Equal("could not clone repository"))
+			})
+		})
+	})
+})
@@ -0,0 +1,65 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// analysisRetryBackoff is how long an analysis that could not run waits before running again
+// for the first time. It doubles after each retry.
+var analysisRetryBackoff = 10 * time.Second
+
+// scanRefWithRetry runs scanRef again, with fresh results, while it fails with an error that
+// is transient according to policy. It returns whether every securityTest could run and how
+// many attempts it took. Each retry is stored in the running analysis and counted by metrics.
+func scanRefWithRetry(ctx goContext.Context, RID string, repository types.Repository, results *securitytest.RunAllInfo, policy types.RetryPolicy) (bool, int) {
+	ok := false
+	attempts, _ := runWithRetry(ctx, policy, analysisRetryBackoff, func() error {
+		*results = securitytest.RunAllInfo{
+			ScanType:            ScanTypeFull,
+			Subpaths:            results.Subpaths,
+			Ref:                 results.Ref,
+			OnContainerFinished: results.OnContainerFinished,
+			OnOutput:            results.OnOutput,
+		}
+		ok = scanRef(ctx, RID, repository, results)
+		return results.ErrorFound
+	}, func(attempts int, err error) {
+		log.Warning(logActionStart, logInfoAnalysis, 130, RID, attempts, err)
+		metrics.AnalysisRetries.Inc()
+		if err := updateRunningAnalysis(RID, bson.M{"retryCount": attempts}); err != nil {
+			log.Error(logActionStart, logInfoAnalysis, 2011, err)
+		}
+	})
+	return ok, attempts
+}
+
+// runWithRetry calls run up to policy.MaxAttempts times while it fails with an error policy
+// retries, waiting backoff before the second attempt and twice as long before each next one.
+// onRetry is called with how many attempts failed so far and the last error before each
+// retry. It returns how many attempts it took and the error of the last one, if any.
+func runWithRetry(ctx goContext.Context, policy types.RetryPolicy, backoff time.Duration, run func() error, onRetry func(attempts int, err error)) (int, error) {
+	for attempts := 1; ; attempts++ {
+		err := run()
+		if err == nil || attempts >= policy.MaxAttempts || ctx.Err() != nil || !policy.Retryable(err) {
+			return attempts, err
+		}
+		select {
+		case <-ctx.Done():
+			return attempts, err
+		case <-time.After(backoff):
+		}
+		onRetry(attempts, err)
+		backoff *= 2
+	}
+}
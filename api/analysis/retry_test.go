@@ -0,0 +1,78 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RunWithRetry", func() {
+
+	policy := types.RetryPolicy{MaxAttempts: 3, RetryableErrors: []string{"connection refused"}}
+	transientErr := errors.New("could not create container: dial tcp 10.0.0.1:2376: Connection Refused")
+	retried := []int{}
+	onRetry := func(attempts int, err error) {
+		retried = append(retried, attempts)
+	}
+
+	BeforeEach(func() {
+		retried = []int{}
+	})
+
+	Context("When an attempt fails with a transient error", func() {
+		It("Should run again until it succeeds", func() {
+			errs := []error{transientErr, nil}
+			attempts, err := analysis.RunWithRetry(goContext.Background(), policy, time.Millisecond, func() error {
+				err := errs[0]
+				errs = errs[1:]
+				return err
+			}, onRetry)
+			Expect(err).To(BeNil())
+			Expect(attempts).To(Equal(2))
+			Expect(retried).To(Equal([]int{1}))
+		})
+		It("Should give up after MaxAttempts and return the last error", func() {
+			attempts, err := analysis.RunWithRetry(goContext.Background(), policy, time.Millisecond, func() error {
+				return transientErr
+			}, onRetry)
+			Expect(err).To(Equal(transientErr))
+			Expect(attempts).To(Equal(3))
+			Expect(retried).To(Equal([]int{1, 2}))
+		})
+	})
+
+	Context("When an attempt fails with an error that is not transient", func() {
+		It("Should fail right away", func() {
+			notFoundErr := errors.New("could not pull image: manifest for huskyci/gosec:v9 not found")
+			attempts, err := analysis.RunWithRetry(goContext.Background(), policy, time.Millisecond, func() error {
+				return notFoundErr
+			}, onRetry)
+			Expect(err).To(Equal(notFoundErr))
+			Expect(attempts).To(Equal(1))
+			Expect(retried).To(BeEmpty())
+		})
+	})
+
+	Context("When the analysis is done before its retry", func() {
+		It("Should not run again", func() {
+			ctx, cancel := goContext.WithCancel(goContext.Background())
+			attempts, err := analysis.RunWithRetry(ctx, policy, time.Hour, func() error {
+				cancel()
+				return transientErr
+			}, onRetry)
+			Expect(err).To(Equal(transientErr))
+			Expect(attempts).To(Equal(1))
+			Expect(retried).To(BeEmpty())
+		})
+	})
+})
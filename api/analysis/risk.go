@@ -0,0 +1,171 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// The risk score of a repository goes from 0 to 100 and is computed as:
+//
+//	min(100, sum(CVSS * severityWeight * ageFactor) * (1 + suppressionRate) / riskNormalization)
+//
+// of its unsuppressed findings, where:
+//   - CVSS is the CVSS score of the finding or, if it has none, the one of its severity
+//     (9.5 critical, 7.5 high, 5 medium, 2.5 low and 0.5 otherwise).
+//   - severityWeight is 4 for critical, 3 for high, 2 for medium, 1 for low and 0.5 otherwise.
+//   - ageFactor grows linearly from 1, for findings of a tool that had none the previous
+//     day, to 2, for findings of a tool that has had some for riskMaxAge days or more.
+//   - suppressionRate is the share of findings that were suppressed, as suppressing
+//     findings hides risk instead of fixing it.
+//
+// A single high finding scores 4.5 and about 22 of them reach 100.
+const (
+	riskNormalization = 5.0
+	riskMaxScore      = 100.0
+	riskMaxAge        = 90 * 24 * time.Hour
+)
+
+// Sort orders of ListRepositoryRisks.
+const (
+	RiskSortRiskScore = "riskScore"
+	RiskSortURL       = "repositoryURL"
+)
+
+// ErrInvalidRiskSort is returned when repositories are sorted by an unknown field or order.
+var ErrInvalidRiskSort = errors.New("sort must be riskScore or repositoryURL and order must be asc or desc")
+
+var severityCVSS = map[string]float64{"CRITICAL": 9.5, "HIGH": 7.5, "MEDIUM": 5, "LOW": 2.5}
+var severityWeight = map[string]float64{"CRITICAL": 4, "HIGH": 3, "MEDIUM": 2, "LOW": 1}
+
+// ComputeRiskScore returns the risk score of a repository given the findings of its
+// last analysis and its daily snapshots, which tell how long each tool has reported findings.
+func ComputeRiskScore(findings []types.UnifiedFinding, history []types.VulnerabilityTrend) float64 {
+	if len(findings) == 0 {
+		return 0
+	}
+	ages := findingAges(history)
+	sum, suppressed := 0.0, 0
+	for _, finding := range findings {
+		if finding.Suppressed {
+			suppressed++
+			continue
+		}
+		severity := summarySeverity(finding)
+		cvss := finding.CVSSScore
+		if cvss <= 0 {
+			cvss = riskValue(severityCVSS, severity, 0.5)
+		}
+		ageFactor := 1 + math.Min(float64(ages[strings.ToLower(finding.Tool)])/float64(riskMaxAge), 1)
+		sum += cvss * riskValue(severityWeight, severity, 0.5) * ageFactor
+	}
+	suppressionRate := float64(suppressed) / float64(len(findings))
+	score := sum * (1 + suppressionRate) / riskNormalization
+	return math.Round(math.Min(score, riskMaxScore)*100) / 100
+}
+
+// findingAges returns, for each tool, how long it has reported findings without a
+// day of none until the latest day of history.
+func findingAges(history []types.VulnerabilityTrend) map[string]time.Duration {
+	byTool := map[string][]types.VulnerabilityTrend{}
+	var latest time.Time
+	for _, snapshot := range history {
+		byTool[snapshot.Tool] = append(byTool[snapshot.Tool], snapshot)
+		if snapshot.Date.After(latest) {
+			latest = snapshot.Date
+		}
+	}
+	ages := map[string]time.Duration{}
+	for tool, snapshots := range byTool {
+		sort.Slice(snapshots, func(i, j int) bool { return snapshots[i].Date.After(snapshots[j].Date) })
+		var since time.Time
+		for _, snapshot := range snapshots {
+			if snapshot.TotalCount == 0 {
+				break
+			}
+			since = snapshot.Date
+		}
+		if !since.IsZero() {
+			ages[strings.ToLower(tool)] = latest.Sub(since)
+		}
+	}
+	return ages
+}
+
+func riskValue(values map[string]float64, severity string, otherwise float64) float64 {
+	if value, ok := values[severity]; ok {
+		return value
+	}
+	return otherwise
+}
+
+// repositoryRiskScore computes the risk score of a repository after one of its analyses
+// finished with findings, along with the history of its findings.
+func repositoryRiskScore(repositoryURL string, findings []types.UnifiedFinding) float64 {
+	since := time.Now().UTC().Add(-riskMaxAge)
+	trendQuery := map[string]interface{}{"repository": repositoryURL, "date": bson.M{"$gte": since}}
+	history, err := apiContext.APIConfiguration.DBInstance.FindAllDBVulnerabilityTrend(trendQuery)
+	if err != nil && !isNotFound(err) {
+		// best-effort: findings are scored as if they were new
+		log.Error("recordRiskScore", logInfoAnalysis, 2027, repositoryURL, err)
+	}
+	return ComputeRiskScore(findings, history)
+}
+
+// recordRiskScore stores riskScore in the repository, once the analysis it was computed
+// from is stored with it.
+func recordRiskScore(repositoryURL string, riskScore float64) {
+	repositoryQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	updateQuery := map[string]interface{}{"$set": bson.M{"riskScore": riskScore}}
+	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBRepository(repositoryQuery, updateQuery); err != nil {
+		log.Error("recordRiskScore", logInfoAnalysis, 2030, repositoryURL, err)
+	}
+}
+
+// ListRepositoryRisks returns every repository with the risk score of its last finished
+// analysis, sorted by sortBy, riskScore by default, in order, desc by default.
+func ListRepositoryRisks(sortBy, order string) ([]types.Repository, error) {
+	if sortBy == "" {
+		sortBy = RiskSortRiskScore
+	}
+	if order == "" {
+		order = "desc"
+	}
+	if (sortBy != RiskSortRiskScore && sortBy != RiskSortURL) || (order != "asc" && order != "desc") {
+		return nil, ErrInvalidRiskSort
+	}
+	repositoryQuery := map[string]interface{}{"repositoryURL": bson.M{"$exists": true}}
+	repositories, err := apiContext.APIConfiguration.DBInstance.FindAllDBRepository(repositoryQuery)
+	if err != nil && !isNotFound(err) {
+		log.Error("ListRepositoryRisks", logInfoAnalysis, 1047, err)
+		return nil, err
+	}
+	SortRepositories(repositories, sortBy, order == "desc")
+	return repositories, nil
+}
+
+// SortRepositories sorts repositories by riskScore or repositoryURL. Ties of
+// riskScore are sorted by repositoryURL, so the ranking is stable.
+func SortRepositories(repositories []types.Repository, sortBy string, descending bool) {
+	sort.SliceStable(repositories, func(i, j int) bool {
+		first, second := repositories[i], repositories[j]
+		if sortBy == RiskSortRiskScore && first.RiskScore != second.RiskScore {
+			return (first.RiskScore < second.RiskScore) != descending
+		}
+		if sortBy == RiskSortURL && descending {
+			return first.URL > second.URL
+		}
+		return first.URL < second.URL
+	})
+}
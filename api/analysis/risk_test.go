@@ -0,0 +1,104 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Risk", func() {
+
+	today := time.Date(2020, time.March, 30, 0, 0, 0, 0, time.UTC)
+	highFinding := types.UnifiedFinding{Tool: "Bandit", Severity: "HIGH", File: "app.py"}
+
+	Describe("ComputeRiskScore", func() {
+		Context("When there are no findings", func() {
+			It("Should return 0", func() {
+				Expect(analysis.ComputeRiskScore(nil, nil)).To(Equal(0.0))
+			})
+		})
+		Context("When a new finding has no CVSS score", func() {
+			It("Should use the CVSS score of its severity", func() {
+				Expect(analysis.ComputeRiskScore([]types.UnifiedFinding{highFinding}, nil)).To(Equal(4.5))
+			})
+		})
+		Context("When a finding has a CVSS score", func() {
+			It("Should weigh it by the severity of its CVSS score", func() {
+				findings := []types.UnifiedFinding{{Tool: "DependencyCheck", Severity: "HIGH", CVSSScore: 9.8}}
+				Expect(analysis.ComputeRiskScore(findings, nil)).To(Equal(7.84))
+			})
+		})
+		Context("When its tool has reported findings for a while", func() {
+			It("Should weigh it up to twice as much after 90 days", func() {
+				history := []types.VulnerabilityTrend{
+					{Tool: "bandit", Date: today.AddDate(0, 0, -90), TotalCount: 1},
+					{Tool: "bandit", Date: today, TotalCount: 1},
+				}
+				Expect(analysis.ComputeRiskScore([]types.UnifiedFinding{highFinding}, history)).To(Equal(9.0))
+				history[0].Date = today.AddDate(0, 0, -45)
+				Expect(analysis.ComputeRiskScore([]types.UnifiedFinding{highFinding}, history)).To(Equal(6.75))
+			})
+			It("Should only count since the last day without findings", func() {
+				history := []types.VulnerabilityTrend{
+					{Tool: "bandit", Date: today.AddDate(0, 0, -90), TotalCount: 1},
+					{Tool: "bandit", Date: today.AddDate(0, 0, -10), TotalCount: 0},
+					{Tool: "bandit", Date: today, TotalCount: 1},
+					{Tool: "gosec", Date: today.AddDate(0, 0, -90), TotalCount: 1},
+				}
+				Expect(analysis.ComputeRiskScore([]types.UnifiedFinding{highFinding}, history)).To(Equal(4.5))
+			})
+		})
+		Context("When findings were suppressed", func() {
+			It("Should raise the score by the suppression rate", func() {
+				findings := []types.UnifiedFinding{highFinding, {Tool: "Bandit", Severity: "NOSEC", Suppressed: true}}
+				Expect(analysis.ComputeRiskScore(findings, nil)).To(Equal(6.75))
+			})
+		})
+		Context("When there are many findings", func() {
+			It("Should cap the score at 100", func() {
+				findings := []types.UnifiedFinding{}
+				for i := 0; i < 30; i++ {
+					findings = append(findings, highFinding)
+				}
+				Expect(analysis.ComputeRiskScore(findings, nil)).To(Equal(100.0))
+			})
+		})
+	})
+
+	Describe("SortRepositories", func() {
+		repositories := func() []types.Repository {
+			return []types.Repository{
+				{URL: "https://github.com/b.git", RiskScore: 10},
+				{URL: "https://github.com/c.git", RiskScore: 50},
+				{URL: "https://github.com/a.git", RiskScore: 10},
+			}
+		}
+		urls := func(repositories []types.Repository) []string {
+			sorted := []string{}
+			for _, repository := range repositories {
+				sorted = append(sorted, repository.URL)
+			}
+			return sorted
+		}
+		It("Should rank by riskScore and then by repositoryURL", func() {
+			ranked := repositories()
+			analysis.SortRepositories(ranked, analysis.RiskSortRiskScore, true)
+			Expect(urls(ranked)).To(Equal([]string{"https://github.com/c.git", "https://github.com/a.git", "https://github.com/b.git"}))
+			analysis.SortRepositories(ranked, analysis.RiskSortRiskScore, false)
+			Expect(urls(ranked)).To(Equal([]string{"https://github.com/a.git", "https://github.com/b.git", "https://github.com/c.git"}))
+		})
+		It("Should sort by repositoryURL", func() {
+			ranked := repositories()
+			analysis.SortRepositories(ranked, analysis.RiskSortURL, true)
+			Expect(urls(ranked)).To(Equal([]string{"https://github.com/c.git", "https://github.com/b.git", "https://github.com/a.git"}))
+		})
+	})
+})
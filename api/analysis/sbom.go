@@ -0,0 +1,275 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/google/uuid"
+)
+
+// CycloneDXSpecVersion is the version of the CycloneDX specification of the bills of materials.
+const CycloneDXSpecVersion = "1.4"
+
+// sbomSecurityTool is a securityTest that finds vulnerable dependencies, along with the
+// package URL type of the dependencies it finds. Dependency-Check reports package URLs itself.
+type sbomSecurityTool struct {
+	output   func(results types.HuskyCIResults) types.HuskyCISecurityTestOutput
+	purlType string
+}
+
+var sbomSecurityTools = []sbomSecurityTool{
+	{func(results types.HuskyCIResults) types.HuskyCISecurityTestOutput {
+		return results.JavaScriptResults.HuskyCINpmAuditOutput
+	}, "npm"},
+	{func(results types.HuskyCIResults) types.HuskyCISecurityTestOutput {
+		return results.JavaScriptResults.HuskyCIYarnAuditOutput
+	}, "npm"},
+	{func(results types.HuskyCIResults) types.HuskyCISecurityTestOutput {
+		return results.PythonResults.HuskyCISafetyOutput
+	}, "pypi"},
+	{func(results types.HuskyCIResults) types.HuskyCISecurityTestOutput {
+		return results.JavaResults.HuskyCIDependencyCheckOutput
+	}, ""},
+}
+
+// CycloneDXReport returns the CycloneDX bill of materials of the analysis of analysisID as
+// JSON, see BuildCycloneDX. If it does not exist, ErrAnalysisNotFound is returned.
+func CycloneDXReport(analysisID string) ([]byte, error) {
+	analysisResult, err := FindAnalysis(analysisID)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(BuildCycloneDX(analysisResult, apiContext.APIConfiguration.Version), "", "  ")
+}
+
+// BuildCycloneDX returns the bill of materials of the dependencies of analysisResult found by
+// npm audit, yarn audit, Safety and Dependency-Check, made by huskyCI of huskyCIVersion. A
+// dependency found by more than one of them is listed once, with every known vulnerability
+// attached. Vulnerabilities ignored or suppressed are left out, but not their dependency.
+func BuildCycloneDX(analysisResult types.Analysis, huskyCIVersion string) types.CycloneDXBOM {
+	components := map[string]*types.CycloneDXComponent{}
+	vulnerabilities := map[string]*types.CycloneDXVulnerability{}
+	for _, securityTool := range sbomSecurityTools {
+		output := securityTool.output(analysisResult.HuskyCIResults)
+		for _, vuln := range output.NoSecVulns {
+			addSBOMComponent(components, securityTool.purlType, vuln)
+		}
+		for _, vulns := range [][]types.HuskyCIVulnerability{output.LowVulns, output.MediumVulns, output.HighVulns} {
+			for _, vuln := range vulns {
+				if component := addSBOMComponent(components, securityTool.purlType, vuln); component != nil {
+					addSBOMVulnerability(vulnerabilities, component.BOMRef, vuln)
+				}
+			}
+		}
+	}
+
+	bom := types.CycloneDXBOM{
+		BOMFormat:   "CycloneDX",
+		SpecVersion: CycloneDXSpecVersion,
+		// the same analysis always has the same bill of materials
+		SerialNumber: "urn:uuid:" + uuid.NewSHA1(uuid.NameSpaceURL, []byte(analysisResult.URL+"#"+analysisResult.RID)).String(),
+		Version:      1,
+		Metadata: types.CycloneDXMetadata{
+			Tools:     []types.CycloneDXTool{{Vendor: "Globo.com", Name: "huskyCI", Version: huskyCIVersion}},
+			Component: sbomRepositoryComponent(analysisResult),
+		},
+		Components:      []types.CycloneDXComponent{},
+		Vulnerabilities: []types.CycloneDXVulnerability{},
+	}
+	if !analysisResult.FinishedAt.IsZero() {
+		bom.Metadata.Timestamp = analysisResult.FinishedAt.UTC().Format(time.RFC3339)
+	}
+	for _, component := range components {
+		bom.Components = append(bom.Components, *component)
+	}
+	sort.Slice(bom.Components, func(i, j int) bool {
+		return bom.Components[i].BOMRef < bom.Components[j].BOMRef
+	})
+	for _, vulnerability := range vulnerabilities {
+		sort.Slice(vulnerability.Affects, func(i, j int) bool {
+			return vulnerability.Affects[i].Ref < vulnerability.Affects[j].Ref
+		})
+		bom.Vulnerabilities = append(bom.Vulnerabilities, *vulnerability)
+	}
+	sort.Slice(bom.Vulnerabilities, func(i, j int) bool {
+		if bom.Vulnerabilities[i].ID != bom.Vulnerabilities[j].ID {
+			return bom.Vulnerabilities[i].ID < bom.Vulnerabilities[j].ID
+		}
+		return bom.Vulnerabilities[i].Affects[0].Ref < bom.Vulnerabilities[j].Affects[0].Ref
+	})
+	return bom
+}
+
+// sbomRepositoryComponent returns the component the bill of materials of analysisResult is of:
+// its repository, at the commit scanned if it is known.
+func sbomRepositoryComponent(analysisResult types.Analysis) types.CycloneDXComponent {
+	version := analysisResult.Commit
+	if version == "" {
+		version = analysisResult.CommitSHA
+	}
+	if version == "" {
+		version = analysisResult.Branch
+	}
+	return types.CycloneDXComponent{Type: "application", BOMRef: analysisResult.URL, Name: analysisResult.URL, Version: version}
+}
+
+// addSBOMComponent adds the dependency vuln was found in to components, unless it is there
+// already, and returns it. Findings that are not of a dependency, such as a lock file missing,
+// have none.
+func addSBOMComponent(components map[string]*types.CycloneDXComponent, purlType string, vuln types.HuskyCIVulnerability) *types.CycloneDXComponent {
+	component, ok := sbomComponent(purlType, vuln)
+	if !ok {
+		return nil
+	}
+	property := types.CycloneDXProperty{Name: "huskyci:securityTool", Value: vuln.SecurityTool}
+	if existing, ok := components[component.BOMRef]; ok {
+		for _, p := range existing.Properties {
+			if p == property {
+				return existing
+			}
+		}
+		existing.Properties = append(existing.Properties, property)
+		return existing
+	}
+	component.Properties = []types.CycloneDXProperty{property}
+	components[component.BOMRef] = &component
+	return &component
+}
+
+// sbomComponent returns the dependency vuln was found in. npm and yarn audit report its name
+// and version apart, Safety both together and Dependency-Check its package URL.
+func sbomComponent(purlType string, vuln types.HuskyCIVulnerability) (types.CycloneDXComponent, bool) {
+	component := types.CycloneDXComponent{Type: "library"}
+	switch purlType {
+	case "npm":
+		component.Name, component.Version = vuln.Code, vuln.Version
+	case "pypi":
+		fields := strings.Fields(vuln.Code)
+		if len(fields) > 0 {
+			component.Name = strings.ToLower(fields[0])
+		}
+		if len(fields) > 1 {
+			component.Version = fields[1]
+		}
+	default:
+		if !strings.HasPrefix(vuln.Code, "pkg:") {
+			// a file Dependency-Check could not identify
+			component.Name = vuln.Code
+			component.BOMRef = vuln.Code
+			return component, component.Name != ""
+		}
+		component.PURL = vuln.Code
+		component.Group, component.Name, component.Version = parsePURL(vuln.Code)
+		component.BOMRef = component.PURL
+		return component, component.Name != ""
+	}
+	if component.Name == "" {
+		return component, false
+	}
+	// the scope of npm packages, such as @babel/core, is the namespace of their package URL
+	name := strings.Replace(url.PathEscape(component.Name), "%2F", "/", 1)
+	if strings.HasPrefix(name, "@") {
+		name = "%40" + name[1:]
+	}
+	component.PURL = "pkg:" + purlType + "/" + name
+	if component.Version != "" {
+		component.PURL += "@" + url.PathEscape(component.Version)
+	}
+	component.BOMRef = component.PURL
+	return component, true
+}
+
+// parsePURL returns the namespace, name and version of a package URL such as
+// pkg:maven/org.apache.commons/commons-text@1.9.
+func parsePURL(purl string) (string, string, string) {
+	path := strings.TrimPrefix(purl, "pkg:")
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	version := ""
+	if i := strings.LastIndex(path, "@"); i > 0 {
+		path, version = path[:i], path[i+1:]
+	}
+	// the first segment is the type of package, such as maven
+	segments := strings.Split(path, "/")
+	if len(segments) < 2 {
+		return "", "", ""
+	}
+	name := segments[len(segments)-1]
+	namespace := strings.Join(segments[1:len(segments)-1], "/")
+	unescape := func(s string) string {
+		if unescaped, err := url.PathUnescape(s); err == nil {
+			return unescaped
+		}
+		return s
+	}
+	return unescape(namespace), unescape(name), unescape(version)
+}
+
+// addSBOMVulnerability attaches vuln to the component of ref. The same vulnerability found by
+// more than one securityTool, or in more than one component, is listed once.
+func addSBOMVulnerability(vulnerabilities map[string]*types.CycloneDXVulnerability, ref string, vuln types.HuskyCIVulnerability) {
+	id := cveRegexp.FindString(vuln.RuleID)
+	if id == "" {
+		id = cveRegexp.FindString(vuln.Details)
+	}
+	if id == "" {
+		id = vuln.RuleID
+	}
+	// without an ID, vulnerabilities of different components can not be told apart
+	key := id
+	if key == "" {
+		key = ref + "\x00" + vuln.Details
+	}
+	vulnerability, ok := vulnerabilities[key]
+	if !ok {
+		vulnerability = &types.CycloneDXVulnerability{ID: id, Description: vuln.Details, Affects: []types.CycloneDXAffects{}}
+		if vuln.NVDURL != "" {
+			vulnerability.Source = &types.CycloneDXSource{Name: "NVD", URL: vuln.NVDURL}
+		}
+		vulnerability.Ratings = []types.CycloneDXRating{sbomRating(vuln)}
+		vulnerabilities[key] = vulnerability
+	} else if rating := sbomRating(vuln); sbomRatingRank(rating) > sbomRatingRank(vulnerability.Ratings[0]) {
+		vulnerability.Ratings = []types.CycloneDXRating{rating}
+	}
+	for _, affects := range vulnerability.Affects {
+		if affects.Ref == ref {
+			return
+		}
+	}
+	vulnerability.Affects = append(vulnerability.Affects, types.CycloneDXAffects{Ref: ref})
+}
+
+// sbomRatingRank ranks rating by its severity and then its CVSS score.
+func sbomRatingRank(rating types.CycloneDXRating) float64 {
+	return float64(notificationSeverityRank(rating.Severity))*10 + rating.Score
+}
+
+// sbomRating returns the rating of vuln: its severity, CRITICAL if its CVSS score is, and
+// its CVSSv3 score and vector if it has them.
+func sbomRating(vuln types.HuskyCIVulnerability) types.CycloneDXRating {
+	rating := types.CycloneDXRating{Severity: strings.ToLower(vuln.Severity)}
+	if vuln.CVSSScore >= criticalCVSSScore {
+		rating.Severity = "critical"
+	}
+	switch rating.Severity {
+	case "critical", "high", "medium", "low":
+	default:
+		rating.Severity = "unknown"
+	}
+	if vuln.CVSSScore > 0 {
+		rating.Score = vuln.CVSSScore
+		rating.Vector = vuln.CVSSVector
+		rating.Method = "CVSSv3"
+	}
+	return rating
+}
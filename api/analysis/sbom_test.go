@@ -0,0 +1,112 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("BuildCycloneDX", func() {
+
+	results := types.HuskyCIResults{}
+	results.JavaScriptResults.HuskyCINpmAuditOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "NpmAudit", Severity: "high", Code: "lodash", Version: "4.17.15", Details: "Prototype Pollution in lodash (CVE-2020-8203)", CVSSScore: 7.4, CVSSVector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:H/A:H", NVDURL: "https://nvd.nist.gov/vuln/detail/CVE-2020-8203"},
+		{SecurityTool: "NpmAudit", Severity: "high", Code: "@babel/traverse", Version: "7.22.0", Details: "Arbitrary code execution"},
+	}
+	results.JavaScriptResults.HuskyCIYarnAuditOutput.MediumVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "YarnAudit", Severity: "medium", Code: "lodash", Version: "4.17.15", Details: "Prototype Pollution in lodash (CVE-2020-8203)"},
+	}
+	results.JavaScriptResults.HuskyCIYarnAuditOutput.LowVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "YarnAudit", Severity: "low", Details: "It looks like your project doesn't have a yarn.lock file."},
+	}
+	results.PythonResults.HuskyCISafetyOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "Safety", Severity: "high", Code: "Django 2.2.1", RuleID: "38765", Details: "Django 2.2.1 has a SQL injection."},
+	}
+	results.PythonResults.HuskyCISafetyOutput.NoSecVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "Safety", Severity: "high", Code: "requests 2.19.0", RuleID: "36546", Details: "Ignored vulnerability."},
+	}
+	results.JavaResults.HuskyCIDependencyCheckOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "DependencyCheck", Severity: "HIGH", Code: "pkg:maven/org.apache.commons/commons-text@1.9", RuleID: "CVE-2022-42889", CVSSScore: 9.8},
+	}
+	// code analysis findings are not of dependencies
+	results.GoResults.HuskyCIGosecOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "GoSec", Severity: "HIGH", Code: "exec.Command(cmd)", RuleID: "G204"},
+	}
+	analysisResult := types.Analysis{
+		RID:            "RID",
+		URL:            "https://github.com/globocom/huskyCI.git",
+		Branch:         "master",
+		Commit:         "3f2a9c",
+		FinishedAt:     time.Date(2020, 5, 4, 10, 0, 0, 0, time.UTC),
+		HuskyCIResults: results,
+	}
+
+	bom := analysis.BuildCycloneDX(analysisResult, "1.2.0")
+
+	It("Should describe the analysis as a CycloneDX 1.4 bill of materials", func() {
+		Expect(bom.BOMFormat).To(Equal("CycloneDX"))
+		Expect(bom.SpecVersion).To(Equal("1.4"))
+		Expect(bom.SerialNumber).To(HavePrefix("urn:uuid:"))
+		Expect(analysis.BuildCycloneDX(analysisResult, "1.2.0").SerialNumber).To(Equal(bom.SerialNumber))
+		Expect(bom.Metadata.Timestamp).To(Equal("2020-05-04T10:00:00Z"))
+		Expect(bom.Metadata.Tools).To(Equal([]types.CycloneDXTool{{Vendor: "Globo.com", Name: "huskyCI", Version: "1.2.0"}}))
+		Expect(bom.Metadata.Component.Name).To(Equal("https://github.com/globocom/huskyCI.git"))
+		Expect(bom.Metadata.Component.Version).To(Equal("3f2a9c"))
+	})
+
+	It("Should list each dependency once, along with every securityTool that found it", func() {
+		refs := []string{}
+		for _, component := range bom.Components {
+			refs = append(refs, component.BOMRef)
+		}
+		Expect(refs).To(Equal([]string{
+			"pkg:maven/org.apache.commons/commons-text@1.9",
+			"pkg:npm/%40babel/traverse@7.22.0",
+			"pkg:npm/lodash@4.17.15",
+			"pkg:pypi/django@2.2.1",
+			"pkg:pypi/requests@2.19.0",
+		}))
+		Expect(bom.Components[0].Group).To(Equal("org.apache.commons"))
+		Expect(bom.Components[0].Name).To(Equal("commons-text"))
+		Expect(bom.Components[0].Version).To(Equal("1.9"))
+		Expect(bom.Components[2].Properties).To(Equal([]types.CycloneDXProperty{
+			{Name: "huskyci:securityTool", Value: "NpmAudit"},
+			{Name: "huskyci:securityTool", Value: "YarnAudit"},
+		}))
+	})
+
+	It("Should attach the known vulnerabilities of each dependency, but not the ignored ones", func() {
+		ids := []string{}
+		for _, vulnerability := range bom.Vulnerabilities {
+			ids = append(ids, vulnerability.ID)
+		}
+		Expect(ids).To(Equal([]string{"", "38765", "CVE-2020-8203", "CVE-2022-42889"}))
+		Expect(bom.Vulnerabilities[0].Affects).To(Equal([]types.CycloneDXAffects{{Ref: "pkg:npm/%40babel/traverse@7.22.0"}}))
+		Expect(bom.Vulnerabilities[1].Affects).To(Equal([]types.CycloneDXAffects{{Ref: "pkg:pypi/django@2.2.1"}}))
+	})
+
+	It("Should rate vulnerabilities by the most severe securityTool that found them", func() {
+		lodash := bom.Vulnerabilities[2]
+		Expect(lodash.Affects).To(Equal([]types.CycloneDXAffects{{Ref: "pkg:npm/lodash@4.17.15"}}))
+		Expect(lodash.Source).To(Equal(&types.CycloneDXSource{Name: "NVD", URL: "https://nvd.nist.gov/vuln/detail/CVE-2020-8203"}))
+		Expect(lodash.Ratings).To(Equal([]types.CycloneDXRating{
+			{Score: 7.4, Severity: "high", Method: "CVSSv3", Vector: "CVSS:3.1/AV:N/AC:H/PR:N/UI:N/S:U/C:N/I:H/A:H"},
+		}))
+		Expect(bom.Vulnerabilities[3].Ratings[0].Severity).To(Equal("critical"))
+	})
+
+	It("Should be empty if no dependency was found", func() {
+		empty := analysis.BuildCycloneDX(types.Analysis{RID: "RID", URL: "https://github.com/globocom/huskyCI.git", Branch: "master"}, "1.2.0")
+		Expect(empty.Components).To(BeEmpty())
+		Expect(empty.Vulnerabilities).To(BeEmpty())
+		Expect(empty.Metadata.Component.Version).To(Equal("master"))
+	})
+})
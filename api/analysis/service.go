@@ -0,0 +1,208 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	mgo "gopkg.in/mgo.v2"
+)
+
+const logActionNewAnalysis = "NewAnalysis"
+const logActionFindAnalysis = "FindAnalysis"
+
+var (
+	// ErrAnalysisNotFound is returned when no analysis matches the given query.
+	ErrAnalysisNotFound = errors.New("analysis not found")
+	// ErrAnalysisAlreadyRunning is returned when a repository and branch already have a running analysis.
+	ErrAnalysisAlreadyRunning = errors.New("an analysis is already in place for this URL and branch")
+	// ErrInvalidResultFilter is returned when analyses are listed by an unknown result.
+	ErrInvalidResultFilter = errors.New("result must be passed, warning, failed or error")
+	// ErrRetryTarball is returned when an analysis of a tarball would run again, as the tarball is not kept.
+	ErrRetryTarball = errors.New("analyses of tarballs can not be retried, as their tarball is not kept")
+)
+
+// NewAnalysis registers the repository if needed and starts a new analysis
+// in background. Both REST and gRPC APIs rely on it after validating inputs.
+func NewAnalysis(RID string, repository types.Repository) error {
+
+	// huskyCI waits for the analyses it started before shutting down
+	interruptCtx, err := inFlight.begin(RID)
+	if err != nil {
+		log.Warning(logActionNewAnalysis, logInfoAnalysis, 127, repository.URL)
+		return err
+	}
+	started := false
+	defer func() {
+		if !started {
+			inFlight.end(RID)
+		}
+	}()
+
+	// step-01: is this repository already in MongoDB?
+	repositoryQuery := map[string]interface{}{"repositoryURL": repository.URL}
+	_, err = apiContext.APIConfiguration.DBInstance.FindOneDBRepository(repositoryQuery)
+	if err != nil {
+		if !isNotFound(err) {
+			log.Error(logActionNewAnalysis, logInfoAnalysis, 1013, err)
+			return err
+		}
+		// step-01-o1: repository not found! insert it into MongoDB
+		repository.CreatedAt = time.Now()
+		if err := apiContext.APIConfiguration.DBInstance.InsertDBRepository(repository); err != nil {
+			log.Error(logActionNewAnalysis, logInfoAnalysis, 1010, err)
+			return err
+		}
+	} else {
+		// step-02: repository found! does it have a running status analysis?
+		analysisQuery := map[string]interface{}{"repositoryURL": repository.URL, "repositoryBranch": repository.Branch}
+		analysisResult, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysis(analysisQuery)
+		if err != nil {
+			if !isNotFound(err) {
+				log.Error(logActionNewAnalysis, logInfoAnalysis, 1009, err)
+				return err
+			}
+		} else if analysisResult.Status == "running" {
+			log.Warning(logActionNewAnalysis, logInfoAnalysis, 104, analysisResult.URL)
+			return ErrAnalysisAlreadyRunning
+		}
+	}
+
+	// step-03: lets start this analysis!
+	log.Info(logActionNewAnalysis, logInfoAnalysis, 16, repository.Branch, repository.URL)
+	started = true
+	go func() {
+		defer inFlight.end(RID)
+		StartAnalysis(interruptCtx, RID, repository)
+	}()
+	return nil
+}
+
+// RetryAnalysis runs the finished analysis of RID again as a new analysis of newRID, with the
+// same parameters, linked back to it by its retryOf. It returns ErrAnalysisRunning if the
+// analysis is still running and ErrRetryTarball if it scanned a tarball.
+func RetryAnalysis(RID, newRID string) error {
+	analysisResult, err := FindAnalysis(RID)
+	if err != nil {
+		return err
+	}
+	if analysisResult.Status == StatusRunning {
+		return ErrAnalysisRunning
+	}
+	if analysisResult.SourceType == SourceTypeTarball {
+		return ErrRetryTarball
+	}
+	return NewAnalysis(newRID, RetryRepository(analysisResult))
+}
+
+// RetryRepository returns the request of analysisResult as it was made, so it can run again.
+// The commit scanned is pinned, so the same code is scanned even if its branch moved. The
+// values of its extra environment variables are not stored, as they may be secrets, so they
+// are not set again.
+func RetryRepository(analysisResult types.Analysis) types.Repository {
+	repository := types.Repository{
+		URL:               analysisResult.URL,
+		Branch:            analysisResult.Branch,
+		IncrementalScan:   analysisResult.ScanType == ScanTypeIncremental,
+		BaseCommit:        analysisResult.BaseCommit,
+		Subpaths:          analysisResult.Subpaths,
+		ExcludedPaths:     analysisResult.ExcludedPaths,
+		Ignores:           analysisResult.Ignored,
+		GitleaksAllowlist: analysisResult.GitleaksAllowlist,
+		NpmAuditFailOn:    analysisResult.NpmAuditFailOn,
+		Refs:              analysisResult.Refs,
+		CommitSHA:         analysisResult.CommitSHA,
+		CommitAuthor:      analysisResult.CommitAuthor,
+		PullRequestID:     analysisResult.PullRequestID,
+		Metadata:          analysisResult.Metadata,
+		CallbackURL:       analysisResult.CallbackURL,
+		RetryOf:           analysisResult.RID,
+	}
+	if repository.CommitSHA == "" {
+		repository.CommitSHA = analysisResult.Commit
+	}
+	return repository
+}
+
+// FindAnalysis returns the analysis of a given RID. If it does not
+// exist, ErrAnalysisNotFound is returned.
+func FindAnalysis(RID string) (types.Analysis, error) {
+	analysisQuery := map[string]interface{}{"RID": RID}
+	analysisResult, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysis(analysisQuery)
+	if err != nil {
+		if isNotFound(err) {
+			log.Warning(logActionFindAnalysis, logInfoAnalysis, 106, RID)
+			return analysisResult, ErrAnalysisNotFound
+		}
+		log.Error(logActionFindAnalysis, logInfoAnalysis, 1020, err)
+		return analysisResult, err
+	}
+	return analysisResult, nil
+}
+
+// ListAnalyses returns all analyses of a given repository URL. If
+// repositoryBranch is not empty, only analyses of it are returned.
+func ListAnalyses(repositoryURL, repositoryBranch string) ([]types.Analysis, error) {
+	analysisQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	if repositoryBranch != "" {
+		analysisQuery["repositoryBranch"] = repositoryBranch
+	}
+	analyses, err := apiContext.APIConfiguration.DBInstance.FindAllDBAnalysis(analysisQuery)
+	if err != nil {
+		if isNotFound(err) {
+			return []types.Analysis{}, nil
+		}
+		log.Error("ListAnalyses", logInfoAnalysis, 1020, err)
+		return nil, err
+	}
+	return analyses, nil
+}
+
+// ListAnalysesPage returns the summary of a page of perPage analyses of a given repository
+// URL, the most recent first, and how many analyses it has. If repositoryBranch or result
+// are not empty, only analyses of that branch or with that result are listed. A repository
+// without analyses has none, so it is not an error.
+func ListAnalysesPage(repositoryURL, repositoryBranch, result string, page, perPage int) ([]types.AnalysisListItem, int, error) {
+	analysisQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	if repositoryBranch != "" {
+		analysisQuery["repositoryBranch"] = repositoryBranch
+	}
+	if result != "" {
+		if result != ResultPassed && result != ResultWarning && result != ResultFailed && result != ResultError {
+			return nil, 0, ErrInvalidResultFilter
+		}
+		analysisQuery["result"] = result
+	}
+	analyses, total, err := apiContext.APIConfiguration.DBInstance.FindPageDBAnalysis(analysisQuery, (page-1)*perPage, perPage)
+	if err != nil {
+		if isNotFound(err) {
+			return []types.AnalysisListItem{}, 0, nil
+		}
+		log.Error("ListAnalysesPage", logInfoAnalysis, 1020, err)
+		return nil, 0, err
+	}
+	items := []types.AnalysisListItem{}
+	for _, analysisResult := range analyses {
+		items = append(items, types.AnalysisListItem{
+			RID:        analysisResult.RID,
+			Branch:     analysisResult.Branch,
+			Commit:     analysisResult.Commit,
+			Status:     analysisResult.Status,
+			Result:     analysisResult.Result,
+			StartedAt:  analysisResult.StartedAt,
+			FinishedAt: analysisResult.FinishedAt,
+			Summary:    analysisResult.Summary,
+		})
+	}
+	return items, total, nil
+}
+
+func isNotFound(err error) bool {
+	return err == mgo.ErrNotFound || err.Error() == "No data found"
+}
@@ -0,0 +1,63 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ListAnalysesPage", func() {
+
+	Context("When analyses are listed by an unknown result", func() {
+		It("Should return ErrInvalidResultFilter without querying them", func() {
+			analyses, total, err := analysis.ListAnalysesPage("https://github.com/globocom/huskyCI.git", "", "unknown", 1, 20)
+			Expect(err).To(Equal(analysis.ErrInvalidResultFilter))
+			Expect(analyses).To(BeNil())
+			Expect(total).To(Equal(0))
+		})
+	})
+})
+
+var _ = Describe("RetryRepository", func() {
+
+	original := types.Analysis{
+		RID:           "original",
+		URL:           "https://github.com/globocom/huskyCI.git",
+		Branch:        "master",
+		Status:        analysis.StatusErrorRunning,
+		ScanType:      analysis.ScanTypeIncremental,
+		BaseCommit:    "0123456789abcdef0123456789abcdef01234567",
+		ExcludedPaths: []string{"vendor/"},
+		Commit:        "89abcdef0123456789abcdef0123456789abcdef",
+		Metadata:      map[string]string{"job": "1234"},
+		ExtraEnvKeys:  []string{"NVD_API_KEY"},
+	}
+
+	It("Should request the analysis again, linked back to it", func() {
+		repository := analysis.RetryRepository(original)
+		Expect(repository.URL).To(Equal(original.URL))
+		Expect(repository.Branch).To(Equal(original.Branch))
+		Expect(repository.IncrementalScan).To(BeTrue())
+		Expect(repository.BaseCommit).To(Equal(original.BaseCommit))
+		Expect(repository.ExcludedPaths).To(Equal(original.ExcludedPaths))
+		Expect(repository.Metadata).To(Equal(original.Metadata))
+		Expect(repository.RetryOf).To(Equal("original"))
+		Expect(repository.ExtraEnv).To(BeEmpty())
+	})
+
+	It("Should pin the commit scanned, so the same code is scanned again", func() {
+		Expect(analysis.RetryRepository(original).CommitSHA).To(Equal(original.Commit))
+	})
+
+	It("Should keep the commit the analysis was requested for", func() {
+		requested := original
+		requested.CommitSHA = "fedcba9876543210fedcba9876543210fedcba98"
+		Expect(analysis.RetryRepository(requested).CommitSHA).To(Equal(requested.CommitSHA))
+	})
+})
@@ -0,0 +1,177 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// DefaultShareTTL is how long a shared analysis can be accessed if no TTL is given.
+const DefaultShareTTL = 7 * 24 * time.Hour
+
+// MaxShareTTL is the longest an analysis can be shared for.
+const MaxShareTTL = 30 * 24 * time.Hour
+
+var (
+	// ErrShareNotConfigured is returned when HUSKYCI_SHARE_SECRET is not set.
+	ErrShareNotConfigured = errors.New("analysis sharing is not configured")
+	// ErrInvalidShareTTL is returned when a share would not expire within MaxShareTTL.
+	ErrInvalidShareTTL = errors.New("share TTL must be positive and up to 30 days")
+	// ErrInvalidShareToken is returned when a share token was not signed by huskyCI.
+	ErrInvalidShareToken = errors.New("invalid share token")
+	// ErrShareExpired is returned when a share token is past its expiry.
+	ErrShareExpired = errors.New("share token expired")
+	// ErrShareNotFound is returned when a share token was revoked or cleaned up.
+	ErrShareNotFound = errors.New("share token not found")
+)
+
+// NewShareToken returns a token for RID that expires at expiresAt. It is the
+// base64url-encoded RID and expiry followed by their HMAC-SHA256 using secret.
+func NewShareToken(secret, RID string, expiresAt time.Time) string {
+	payload := fmt.Sprintf("%s.%d", RID, expiresAt.Unix())
+	return fmt.Sprintf("%s.%s", base64.RawURLEncoding.EncodeToString([]byte(payload)), base64.RawURLEncoding.EncodeToString(signSharePayload(secret, payload)))
+}
+
+// ParseShareToken returns the RID and the expiry of a token signed with secret.
+// A token is parsed even if it is expired, so it can be told apart from a forged one.
+func ParseShareToken(secret, token string) (string, time.Time, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 2 {
+		return "", time.Time{}, ErrInvalidShareToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return "", time.Time{}, ErrInvalidShareToken
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil || !hmac.Equal(signature, signSharePayload(secret, string(payload))) {
+		return "", time.Time{}, ErrInvalidShareToken
+	}
+	separator := strings.LastIndex(string(payload), ".")
+	if separator < 0 {
+		return "", time.Time{}, ErrInvalidShareToken
+	}
+	expiry, err := strconv.ParseInt(string(payload[separator+1:]), 10, 64)
+	if err != nil {
+		return "", time.Time{}, ErrInvalidShareToken
+	}
+	return string(payload[:separator]), time.Unix(expiry, 0), nil
+}
+
+func signSharePayload(secret, payload string) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// ShareAnalysis issues a token that gives read-only access to an analysis for ttl.
+// Issued tokens are stored, so they can be revoked and cleaned up once expired.
+func ShareAnalysis(RID string, ttl time.Duration) (types.AnalysisShare, error) {
+	secret := apiContext.APIConfiguration.ShareSecret
+	if secret == "" {
+		return types.AnalysisShare{}, ErrShareNotConfigured
+	}
+	if ttl <= 0 || ttl > MaxShareTTL {
+		return types.AnalysisShare{}, ErrInvalidShareTTL
+	}
+	if _, err := FindAnalysis(RID); err != nil {
+		return types.AnalysisShare{}, err
+	}
+	now := time.Now()
+	// tokens only carry seconds, so the stored expiry must not be more precise
+	expiresAt := now.Add(ttl).Truncate(time.Second)
+	analysisShare := types.AnalysisShare{
+		Token:     NewShareToken(secret, RID, expiresAt),
+		RID:       RID,
+		CreatedAt: now,
+		ExpiresAt: expiresAt,
+	}
+	if err := apiContext.APIConfiguration.DBInstance.InsertDBAnalysisShare(analysisShare); err != nil {
+		log.Error("ShareAnalysis", logInfoAnalysis, 2028, RID, err)
+		return types.AnalysisShare{}, err
+	}
+	log.Info("ShareAnalysis", logInfoAnalysis, 122, RID, expiresAt)
+	return analysisShare, nil
+}
+
+// FindSharedAnalysis returns the read-only summary of the analysis of a given share token.
+func FindSharedAnalysis(token string) (types.SharedAnalysis, error) {
+	secret := apiContext.APIConfiguration.ShareSecret
+	if secret == "" {
+		return types.SharedAnalysis{}, ErrShareNotConfigured
+	}
+	RID, expiresAt, err := ParseShareToken(secret, token)
+	if err != nil {
+		return types.SharedAnalysis{}, err
+	}
+	if !time.Now().Before(expiresAt) {
+		return types.SharedAnalysis{}, ErrShareExpired
+	}
+	if _, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysisShare(map[string]interface{}{"token": token}); err != nil {
+		if isNotFound(err) {
+			return types.SharedAnalysis{}, ErrShareNotFound
+		}
+		return types.SharedAnalysis{}, err
+	}
+	analysisResult, err := FindAnalysis(RID)
+	if err != nil {
+		return types.SharedAnalysis{}, err
+	}
+	findings := SortByConfidence(UnifyFindings(analysisResult.HuskyCIResults))
+	return types.SharedAnalysis{
+		RID:        analysisResult.RID,
+		URL:        analysisResult.URL,
+		Branch:     analysisResult.Branch,
+		Status:     analysisResult.Status,
+		Result:     analysisResult.Result,
+		StartedAt:  analysisResult.StartedAt,
+		FinishedAt: analysisResult.FinishedAt,
+		Summary:    analysisResult.Summary,
+		Findings:   findings,
+		Compliance: SummarizeCompliance(findings),
+		ExpiresAt:  expiresAt,
+	}, nil
+}
+
+// RevokeAnalysisShares revokes every share token issued for a given analysis.
+func RevokeAnalysisShares(RID string) error {
+	err := apiContext.APIConfiguration.DBInstance.RemoveDBAnalysisShares(map[string]interface{}{"RID": RID})
+	if err != nil {
+		log.Error("RevokeAnalysisShares", logInfoAnalysis, 2029, RID, err)
+	}
+	return err
+}
+
+// RemoveExpiredShares removes every share token that is past its expiry.
+func RemoveExpiredShares() error {
+	return apiContext.APIConfiguration.DBInstance.RemoveDBAnalysisShares(map[string]interface{}{
+		"expiresAt": bson.M{"$lt": time.Now()},
+	})
+}
+
+// StartExpiredSharesCleaner runs RemoveExpiredShares every interval in background.
+func StartExpiredSharesCleaner(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if err := RemoveExpiredShares(); err != nil {
+				log.Error("StartExpiredSharesCleaner", logInfoAnalysis, 2029, err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,57 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Share", func() {
+
+	secret := "husky-share-secret"
+	RID := "6f9b3a1e-2c4d-4f5a-9b8c-7d6e5f4a3b2c"
+	expiresAt := time.Unix(1585580645, 0)
+
+	Describe("ParseShareToken", func() {
+		Context("When the token was signed with the same secret", func() {
+			It("Should return its RID and expiry", func() {
+				token := analysis.NewShareToken(secret, RID, expiresAt)
+				Expect(token).ToNot(ContainSubstring("="))
+				parsedRID, parsedExpiresAt, err := analysis.ParseShareToken(secret, token)
+				Expect(err).To(BeNil())
+				Expect(parsedRID).To(Equal(RID))
+				Expect(parsedExpiresAt.Equal(expiresAt)).To(BeTrue())
+			})
+		})
+		Context("When the token was signed with another secret", func() {
+			It("Should return ErrInvalidShareToken", func() {
+				token := analysis.NewShareToken("another-secret", RID, expiresAt)
+				_, _, err := analysis.ParseShareToken(secret, token)
+				Expect(err).To(Equal(analysis.ErrInvalidShareToken))
+			})
+		})
+		Context("When the expiry of the token was changed", func() {
+			It("Should return ErrInvalidShareToken", func() {
+				token := analysis.NewShareToken(secret, RID, expiresAt)
+				forgedToken := analysis.NewShareToken(secret, RID, expiresAt.Add(24*time.Hour))
+				tampered := strings.Split(forgedToken, ".")[0] + "." + strings.Split(token, ".")[1]
+				_, _, err := analysis.ParseShareToken(secret, tampered)
+				Expect(err).To(Equal(analysis.ErrInvalidShareToken))
+			})
+		})
+		Context("When the token is malformed", func() {
+			It("Should return ErrInvalidShareToken", func() {
+				_, _, err := analysis.ParseShareToken(secret, "not-a-token")
+				Expect(err).To(Equal(analysis.ErrInvalidShareToken))
+			})
+		})
+	})
+})
@@ -0,0 +1,164 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/log"
+	goContext "golang.org/x/net/context"
+)
+
+// ErrShuttingDown is returned instead of starting an analysis once Shutdown was called.
+var ErrShuttingDown = errors.New("huskyCI is shutting down, try again later")
+
+// ErrAnalysisInterrupted is set to analyses that were still running when huskyCI shut down.
+var ErrAnalysisInterrupted = errors.New("analysis was interrupted as huskyCI shut down")
+
+// interruptGracePeriod is how long interrupted analyses have to stop and remove their
+// containers and store their status once Shutdown cancels them.
+const interruptGracePeriod = 30 * time.Second
+
+// inFlightAnalyses are the analyses started by NewAnalysis that did not finish yet, along
+// with the background work that follows them, such as the one of bulk analysis jobs.
+type inFlightAnalyses struct {
+	mutex        sync.Mutex
+	shuttingDown bool
+	interrupts   map[string]goContext.CancelFunc
+	// failures are why analyses were interrupted before huskyCI shut down, see FailAnalysis.
+	failures map[string]error
+	// stopped is closed once Shutdown is called, so background work stops.
+	stopped chan struct{}
+	wg      sync.WaitGroup
+}
+
+var inFlight = newInFlightAnalyses()
+
+func newInFlightAnalyses() *inFlightAnalyses {
+	return &inFlightAnalyses{interrupts: map[string]goContext.CancelFunc{}, failures: map[string]error{}, stopped: make(chan struct{})}
+}
+
+// begin tracks the analysis of RID and returns the context Shutdown cancels to interrupt
+// it, or ErrShuttingDown if no analysis can start anymore.
+func (analyses *inFlightAnalyses) begin(RID string) (goContext.Context, error) {
+	analyses.mutex.Lock()
+	defer analyses.mutex.Unlock()
+	if analyses.shuttingDown {
+		return nil, ErrShuttingDown
+	}
+	ctx, interrupt := goContext.WithCancel(goContext.Background())
+	analyses.interrupts[RID] = interrupt
+	analyses.wg.Add(1)
+	return ctx, nil
+}
+
+// goTracked runs work in background, waited for by Shutdown, or returns ErrShuttingDown if
+// huskyCI is shutting down. work must return soon after stopped is closed.
+func (analyses *inFlightAnalyses) goTracked(work func(stopped <-chan struct{})) error {
+	analyses.mutex.Lock()
+	defer analyses.mutex.Unlock()
+	if analyses.shuttingDown {
+		return ErrShuttingDown
+	}
+	analyses.wg.Add(1)
+	go func() {
+		defer analyses.wg.Done()
+		work(analyses.stopped)
+	}()
+	return nil
+}
+
+// end stops tracking the analysis of RID once it is stored as finished.
+func (analyses *inFlightAnalyses) end(RID string) {
+	analyses.mutex.Lock()
+	defer analyses.mutex.Unlock()
+	if interrupt, ok := analyses.interrupts[RID]; ok {
+		interrupt()
+		delete(analyses.interrupts, RID)
+		delete(analyses.failures, RID)
+		analyses.wg.Done()
+	}
+}
+
+// fail interrupts the analysis of RID with err and returns whether it was in flight.
+func (analyses *inFlightAnalyses) fail(RID string, err error) bool {
+	analyses.mutex.Lock()
+	defer analyses.mutex.Unlock()
+	interrupt, ok := analyses.interrupts[RID]
+	if !ok {
+		return false
+	}
+	if _, failed := analyses.failures[RID]; !failed {
+		analyses.failures[RID] = err
+	}
+	interrupt()
+	return true
+}
+
+// interruptCause returns why the analysis of RID was interrupted: the error it failed with
+// or, if none, ErrAnalysisInterrupted.
+func (analyses *inFlightAnalyses) interruptCause(RID string) error {
+	analyses.mutex.Lock()
+	defer analyses.mutex.Unlock()
+	if err, ok := analyses.failures[RID]; ok {
+		return err
+	}
+	return ErrAnalysisInterrupted
+}
+
+// interruptAll cancels every analysis still running and returns how many they were.
+func (analyses *inFlightAnalyses) interruptAll() int {
+	analyses.mutex.Lock()
+	defer analyses.mutex.Unlock()
+	for _, interrupt := range analyses.interrupts {
+		interrupt()
+	}
+	return len(analyses.interrupts)
+}
+
+// wait returns whether every analysis finished before timeout.
+func (analyses *inFlightAnalyses) wait(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		analyses.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Shutdown makes NewAnalysis return ErrShuttingDown, stops background work such as the one of
+// bulk analysis jobs and waits up to timeout for the analyses already running to finish. The ones still running are then interrupted: their containers
+// are stopped and removed and they are stored with StatusInterrupted. It returns how many
+// analyses were interrupted.
+func Shutdown(timeout time.Duration) int {
+	inFlight.mutex.Lock()
+	if !inFlight.shuttingDown {
+		inFlight.shuttingDown = true
+		close(inFlight.stopped)
+	}
+	inFlight.mutex.Unlock()
+
+	if inFlight.wait(timeout) {
+		return 0
+	}
+	interrupted := inFlight.interruptAll()
+	if !inFlight.wait(interruptGracePeriod) {
+		log.Warning("Shutdown", logInfoAnalysis, 126, interruptGracePeriod)
+	}
+	return interrupted
+}
+
+// FailAnalysis interrupts the analysis of RID, if it is running, so its containers are stopped
+// and removed and it is stored as an error with err. It returns whether it was running.
+func FailAnalysis(RID string, err error) bool {
+	return inFlight.fail(RID, err)
+}
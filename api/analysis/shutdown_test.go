@@ -0,0 +1,84 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Shutdown", func() {
+
+	AfterEach(func() {
+		analysis.ResetInFlight()
+	})
+
+	Context("When no analysis is running", func() {
+		It("Should return no interrupted analysis and refuse new ones", func() {
+			Expect(analysis.Shutdown(time.Second)).To(Equal(0))
+			_, err := analysis.BeginAnalysis("RID")
+			Expect(err).To(Equal(analysis.ErrShuttingDown))
+		})
+	})
+
+	Context("When an analysis finishes before the timeout", func() {
+		It("Should wait for it without interrupting it", func() {
+			interruptCtx, err := analysis.BeginAnalysis("RID")
+			Expect(err).To(BeNil())
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				analysis.EndAnalysis("RID")
+			}()
+			Expect(analysis.Shutdown(5 * time.Second)).To(Equal(0))
+			Expect(interruptCtx.Err()).ToNot(BeNil())
+		})
+	})
+
+	Context("When an analysis is still running after the timeout", func() {
+		It("Should interrupt it and wait for it to end", func() {
+			interruptCtx, err := analysis.BeginAnalysis("RID")
+			Expect(err).To(BeNil())
+			go func() {
+				<-interruptCtx.Done()
+				analysis.EndAnalysis("RID")
+			}()
+			Expect(analysis.Shutdown(10 * time.Millisecond)).To(Equal(1))
+		})
+	})
+
+	Context("When background work is running", func() {
+		It("Should stop it and wait for it to return", func() {
+			returned := make(chan struct{})
+			Expect(analysis.GoTracked(func(stopped <-chan struct{}) {
+				<-stopped
+				close(returned)
+			})).To(Succeed())
+			Expect(analysis.Shutdown(5 * time.Second)).To(Equal(0))
+			Expect(returned).To(BeClosed())
+		})
+		It("Should refuse to start it once shutting down", func() {
+			Expect(analysis.Shutdown(time.Second)).To(Equal(0))
+			Expect(analysis.GoTracked(func(stopped <-chan struct{}) {})).To(Equal(analysis.ErrShuttingDown))
+		})
+	})
+
+	Context("When an analysis fails while it runs", func() {
+		It("Should interrupt it with the error it failed with", func() {
+			interruptCtx, err := analysis.BeginAnalysis("RID")
+			Expect(err).To(BeNil())
+			Expect(analysis.FailAnalysis("RID", errors.New("docker_event:oom"))).To(BeTrue())
+			Expect(interruptCtx.Err()).ToNot(BeNil())
+			Expect(analysis.InterruptCause("RID")).To(MatchError("docker_event:oom"))
+		})
+		It("Should not fail an analysis that is not running", func() {
+			Expect(analysis.FailAnalysis("RID", errors.New("docker_event:die"))).To(BeFalse())
+			Expect(analysis.InterruptCause("RID")).To(Equal(analysis.ErrAnalysisInterrupted))
+		})
+	})
+})
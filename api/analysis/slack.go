@@ -0,0 +1,126 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// ErrRepositoryNotFound is returned when a repository was never analyzed.
+var ErrRepositoryNotFound = errors.New("repository not found")
+
+// maxSlackFindings is how many findings, the most severe first, a Slack message lists.
+const maxSlackFindings = 5
+
+// SlackMessage returns the Slack message of a failed analysis, with its most severe findings
+// and a link to it if publicURL is set, and whether it must be notified: only failed analyses
+// with a finding of at least minSeverity are.
+func SlackMessage(analysisResult types.Analysis, minSeverity, publicURL string) (string, bool) {
+	if analysisResult.Result != ResultFailed || minSeverity == "" {
+		return "", false
+	}
+	findings := []types.UnifiedFinding{}
+	for _, finding := range UnifyFindings(analysisResult.HuskyCIResults) {
+		if !finding.Suppressed && notificationSeverityRank(summarySeverity(finding)) >= notificationSeverityRank(minSeverity) {
+			findings = append(findings, finding)
+		}
+	}
+	if len(findings) == 0 {
+		return "", false
+	}
+	sort.SliceStable(findings, func(i, j int) bool {
+		return notificationSeverityRank(summarySeverity(findings[i])) > notificationSeverityRank(summarySeverity(findings[j]))
+	})
+
+	var message strings.Builder
+	fmt.Fprintf(&message, "huskyCI analysis failed for %s (%s)", analysisResult.URL, analysisResult.Branch)
+	if summary := Summarize(findings); summary != nil {
+		fmt.Fprintf(&message, ": %d critical, %d high, %d medium and %d low findings", summary.Critical, summary.High, summary.Medium, summary.Low)
+	}
+	message.WriteString(".")
+	for i, finding := range findings {
+		if i == maxSlackFindings {
+			fmt.Fprintf(&message, "\n... and %d more", len(findings)-maxSlackFindings)
+			break
+		}
+		location := finding.File
+		if finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.File, finding.Line)
+		}
+		fmt.Fprintf(&message, "\n• *%s* %s %s %s: %s", summarySeverity(finding), finding.Tool, finding.RuleID, location, finding.Description)
+	}
+	if publicURL != "" {
+		fmt.Fprintf(&message, "\n<%s/analysis/%s|View analysis %s>", publicURL, analysisResult.RID, analysisResult.RID)
+	} else {
+		fmt.Fprintf(&message, "\nRID: %s", analysisResult.RID)
+	}
+	return message.String(), true
+}
+
+func notificationSeverityRank(severity string) int {
+	if strings.EqualFold(severity, "CRITICAL") {
+		return 4
+	}
+	return severityRank(severity)
+}
+
+// notifySlack posts the analysis of RID to Slack in background if it failed with findings
+// of at least HUSKYCI_API_SLACK_MIN_SEVERITY. Errors are only logged, so Slack being down
+// never fails nor delays an analysis.
+func notifySlack(RID, repositoryURL string) {
+	config := apiContext.APIConfiguration
+	slackNotifier := notifier.NewSlackNotifier(config.SlackWebhookURL)
+	if slackNotifier == nil || config.SlackMinSeverity == "" {
+		return
+	}
+	analysisResult, err := FindAnalysis(RID)
+	if err != nil {
+		log.Error("notifySlack", logInfoAnalysis, 2024, err)
+		return
+	}
+	text, notify := SlackMessage(analysisResult, config.SlackMinSeverity, config.PublicURL)
+	if !notify {
+		return
+	}
+	slackNotifier.Channel = config.SlackChannel
+	repository, err := config.DBInstance.FindOneDBRepository(map[string]interface{}{"repositoryURL": repositoryURL})
+	if err != nil && !isNotFound(err) {
+		// best-effort: the default channel is notified instead
+		log.Error("notifySlack", logInfoAnalysis, 1013, err)
+	}
+	if repository.SlackChannel != "" {
+		slackNotifier.Channel = repository.SlackChannel
+	}
+	slackNotifier.NotifyAsync(text, func(err error) {
+		log.Error("notifySlack", logInfoAnalysis, 2024, err)
+	})
+}
+
+// SetRepositorySlackChannel sets the Slack channel failed analyses of a repository are
+// notified to. An empty channel restores the one of HUSKYCI_API_SLACK_CHANNEL.
+func SetRepositorySlackChannel(repositoryURL, channel string) error {
+	repositoryQuery := map[string]interface{}{"repositoryURL": repositoryURL}
+	updateQuery := map[string]interface{}{"$set": bson.M{"slackChannel": channel}}
+	if channel == "" {
+		updateQuery = map[string]interface{}{"$unset": bson.M{"slackChannel": ""}}
+	}
+	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBRepository(repositoryQuery, updateQuery); err != nil {
+		if isNotFound(err) {
+			return ErrRepositoryNotFound
+		}
+		log.Error("SetRepositorySlackChannel", logInfoAnalysis, 2036, repositoryURL, err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,64 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SlackMessage", func() {
+
+	results := types.HuskyCIResults{}
+	results.GoResults.HuskyCIGosecOutput.MediumVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "GoSec", Severity: "MEDIUM", File: "main.go", Line: "12", RuleID: "G104", Details: "Errors unhandled."},
+	}
+	results.PythonResults.HuskyCIBanditOutput.HighVulns = []types.HuskyCIVulnerability{
+		{SecurityTool: "Bandit", Severity: "HIGH", File: "app.py", Line: "3", RuleID: "B602", Details: "subprocess call with shell=True"},
+	}
+	analysisResult := types.Analysis{
+		RID:            "RID",
+		URL:            "https://github.com/globocom/huskyCI.git",
+		Branch:         "master",
+		Result:         analysis.ResultFailed,
+		HuskyCIResults: results,
+	}
+
+	Context("When a failed analysis has findings of at least the minimum severity", func() {
+		It("Should list them, the most severe first, and link to the analysis", func() {
+			message, notify := analysis.SlackMessage(analysisResult, "MEDIUM", "https://huskyci.example.com")
+			Expect(notify).To(BeTrue())
+			Expect(message).To(Equal("huskyCI analysis failed for https://github.com/globocom/huskyCI.git (master): 0 critical, 1 high, 1 medium and 0 low findings.\n" +
+				"• *HIGH* Bandit B602 app.py:3: subprocess call with shell=True\n" +
+				"• *MEDIUM* GoSec G104 main.go:12: Errors unhandled.\n" +
+				"<https://huskyci.example.com/analysis/RID|View analysis RID>"))
+		})
+		It("Should leave out findings below the minimum severity", func() {
+			message, notify := analysis.SlackMessage(analysisResult, "HIGH", "")
+			Expect(notify).To(BeTrue())
+			Expect(message).ToNot(ContainSubstring("G104"))
+			Expect(message).To(HaveSuffix("\nRID: RID"))
+		})
+	})
+
+	Context("When no finding has the minimum severity", func() {
+		It("Should not notify", func() {
+			_, notify := analysis.SlackMessage(analysisResult, "CRITICAL", "")
+			Expect(notify).To(BeFalse())
+		})
+	})
+
+	Context("When the analysis passed", func() {
+		It("Should not notify", func() {
+			passed := analysisResult
+			passed.Result = analysis.ResultPassed
+			_, notify := analysis.SlackMessage(passed, "LOW", "")
+			Expect(notify).To(BeFalse())
+		})
+	})
+})
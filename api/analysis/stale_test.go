@@ -0,0 +1,105 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/db"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// staleAnalysesDB keeps a single analysis in memory. Any other request panics.
+type staleAnalysesDB struct {
+	db.Requests
+	mutex    sync.Mutex
+	analysis types.Analysis
+}
+
+func (fDB *staleAnalysesDB) FindAllDBAnalysis(mapParams map[string]interface{}) ([]types.Analysis, error) {
+	fDB.mutex.Lock()
+	defer fDB.mutex.Unlock()
+	return []types.Analysis{fDB.analysis}, nil
+}
+
+func (fDB *staleAnalysesDB) FindOneDBAnalysis(mapParams map[string]interface{}) (types.Analysis, error) {
+	fDB.mutex.Lock()
+	defer fDB.mutex.Unlock()
+	return fDB.analysis, nil
+}
+
+func (fDB *staleAnalysesDB) UpdateOneDBAnalysisContainer(mapParams, updateQuery map[string]interface{}) error {
+	fDB.mutex.Lock()
+	defer fDB.mutex.Unlock()
+	fDB.analysis.Status = updateQuery["status"].(string)
+	fDB.analysis.Result = updateQuery["result"].(string)
+	fDB.analysis.ErrorFound = updateQuery["errorFound"].(string)
+	return nil
+}
+
+func (fDB *staleAnalysesDB) UpdateOneDBAnalysis(mapParams map[string]interface{}, updatedAnalysis map[string]interface{}) error {
+	return nil
+}
+
+var _ = Describe("FinalizeStaleAnalyses", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	var previousConfig *apiContext.APIConfig
+
+	BeforeEach(func() {
+		previousConfig = apiContext.APIConfiguration
+	})
+
+	AfterEach(func() {
+		apiContext.APIConfiguration = previousConfig
+	})
+
+	Context("When an analysis with a callback URL is still running after the deadline", func() {
+		It("Should store it with an error and post its result to its callback URL", func() {
+			callbacks := make(chan types.AnalysisCallback, 1)
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				callback := types.AnalysisCallback{}
+				Expect(json.NewDecoder(r.Body).Decode(&callback)).To(Succeed())
+				callbacks <- callback
+			}))
+			defer server.Close()
+
+			fakeDB := &staleAnalysesDB{analysis: types.Analysis{
+				RID:         "RID",
+				URL:         "https://github.com/globocom/huskyCI.git",
+				Branch:      "master",
+				Status:      analysis.StatusRunning,
+				StartedAt:   time.Now().Add(-time.Hour),
+				CallbackURL: server.URL,
+			}}
+			apiContext.APIConfiguration = &apiContext.APIConfig{
+				DBInstance:          fakeDB,
+				CallbackAllowlist:   []string{"127.0.0.1"},
+				CallbackMaxAttempts: 1,
+			}
+
+			Expect(analysis.FinalizeStaleAnalyses(time.Minute)).To(Succeed())
+			Expect(notifier.Flush(5 * time.Second)).To(BeTrue())
+
+			var callback types.AnalysisCallback
+			Eventually(callbacks).Should(Receive(&callback))
+			Expect(callback.RID).To(Equal("RID"))
+			Expect(callback.Status).To(Equal(analysis.StatusErrorRunning))
+			Expect(callback.Result).To(Equal(analysis.ResultError))
+		})
+	})
+})
@@ -0,0 +1,57 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// criticalCVSSScore is the lowest CVSSv3 score of a critical finding.
+const criticalCVSSScore = 9.0
+
+// Summarize counts findings by severity and by securityTool, so clients do not
+// need to walk every finding. Suppressed findings are only counted as ignored.
+func Summarize(findings []types.UnifiedFinding) *types.SeveritySummary {
+	summary := &types.SeveritySummary{ByTool: map[string]int{}}
+	files := map[string]bool{}
+
+	for _, finding := range findings {
+		if finding.Suppressed {
+			summary.Ignored++
+			continue
+		}
+		switch summarySeverity(finding) {
+		case "CRITICAL":
+			summary.Critical++
+		case "HIGH":
+			summary.High++
+		case "MEDIUM":
+			summary.Medium++
+		case "LOW":
+			summary.Low++
+		default:
+			summary.Info++
+		}
+		summary.ByTool[finding.Tool]++
+		if finding.File != "" {
+			files[finding.File] = true
+		}
+		if strings.EqualFold(finding.Tool, "GitLeaks") {
+			summary.SecretsFound = true
+		}
+	}
+
+	summary.FilesAffected = len(files)
+	return summary
+}
+
+func summarySeverity(finding types.UnifiedFinding) string {
+	if finding.CVSSScore >= criticalCVSSScore {
+		return "CRITICAL"
+	}
+	return strings.ToUpper(finding.Severity)
+}
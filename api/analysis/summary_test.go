@@ -0,0 +1,58 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Summary", func() {
+
+	Context("When an analysis has findings of many securityTools", func() {
+		findings := []types.UnifiedFinding{
+			{Tool: "DependencyCheck", Severity: "HIGH", CVSSScore: 9.8, File: "lib/jackson.jar"},
+			{Tool: "Bandit", Severity: "HIGH", File: "app.py", Line: 3},
+			{Tool: "Bandit", Severity: "MEDIUM", File: "app.py", Line: 10},
+			{Tool: "GoSec", Severity: "LOW", File: "main.go"},
+			{Tool: "GitLeaks", Severity: "", File: "config.yml"},
+			{Tool: "Bandit", Severity: "NOSEC", File: "other.py", Suppressed: true},
+		}
+		summary := analysis.Summarize(findings)
+
+		It("Should count findings by severity", func() {
+			Expect(summary.Critical).To(Equal(1))
+			Expect(summary.High).To(Equal(1))
+			Expect(summary.Medium).To(Equal(1))
+			Expect(summary.Low).To(Equal(1))
+			Expect(summary.Info).To(Equal(1))
+		})
+
+		It("Should count findings by securityTool", func() {
+			Expect(summary.ByTool).To(Equal(map[string]int{"DependencyCheck": 1, "Bandit": 2, "GoSec": 1, "GitLeaks": 1}))
+		})
+
+		It("Should count suppressed findings only as ignored", func() {
+			Expect(summary.Ignored).To(Equal(1))
+			Expect(summary.FilesAffected).To(Equal(4))
+		})
+
+		It("Should state that secrets were found", func() {
+			Expect(summary.SecretsFound).To(BeTrue())
+		})
+	})
+
+	Context("When an analysis has no findings", func() {
+		It("Should return a zeroed summary", func() {
+			summary := analysis.Summarize([]types.UnifiedFinding{})
+			Expect(summary.High).To(Equal(0))
+			Expect(summary.SecretsFound).To(BeFalse())
+			Expect(summary.ByTool).To(BeEmpty())
+		})
+	})
+})
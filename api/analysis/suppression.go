@@ -0,0 +1,328 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+const logActionSuppression = "Suppression"
+
+// Status of a suppression request. Only approved ones suppress their finding.
+const (
+	SuppressionPending  = "pending"
+	SuppressionApproved = "approved"
+	SuppressionRejected = "rejected"
+)
+
+// Actions of the steps of the approval chain of a suppression request. A reviewer approves
+// or rejects a pending request.
+const (
+	SuppressionActionRequest = "request"
+	SuppressionActionApprove = "approve"
+	SuppressionActionReject  = "reject"
+)
+
+var (
+	// ErrSuppressionRequester is returned when a suppression request does not say who requested it.
+	ErrSuppressionRequester = errors.New("requestedBy is required")
+	// ErrSuppressionReason is returned when a suppression request does not say why the finding is a false positive.
+	ErrSuppressionReason = errors.New("reason is required")
+	// ErrSuppressionPending is returned when a finding already has a pending suppression request.
+	ErrSuppressionPending = errors.New("finding already has a pending suppression request")
+	// ErrSuppressionNotFound is returned when the finding has no suppression request of the given ID.
+	ErrSuppressionNotFound = errors.New("suppression request not found")
+	// ErrSuppressionReviewed is returned when a suppression request that is not pending is reviewed.
+	ErrSuppressionReviewed = errors.New("suppression request was already reviewed")
+	// ErrSuppressionAction is returned when a review neither approves nor rejects a suppression request.
+	ErrSuppressionAction = errors.New("action must be approve or reject")
+	// ErrSuppressionSelfReview is returned when the requester of a suppression request reviews it.
+	ErrSuppressionSelfReview = errors.New("a suppression request can not be reviewed by its requester")
+)
+
+func init() {
+	securitytest.SuppressionFingerprint = suppressionFingerprint
+}
+
+// suppressionFingerprint returns the fingerprint of a finding of securityTestName in the
+// repository of repositoryURL, the same one it is recorded with by RecordFindings.
+func suppressionFingerprint(repositoryURL, securityTestName string, vuln types.HuskyCIVulnerability) string {
+	return RepositoryFindingFingerprint(repositoryURL, toUnifiedFinding(securityTestName, vuln, false))
+}
+
+// NewSuppressionRequest returns the pending request, of requestID, to suppress finding as
+// asked by request, made at now. It must say who requested it and why.
+func NewSuppressionRequest(requestID string, finding types.Finding, request types.SuppressionRequest, now time.Time) (types.SuppressionRequest, error) {
+	requestedBy := strings.TrimSpace(request.RequestedBy)
+	if requestedBy == "" {
+		return types.SuppressionRequest{}, ErrSuppressionRequester
+	}
+	reason := strings.TrimSpace(request.Reason)
+	if reason == "" {
+		return types.SuppressionRequest{}, ErrSuppressionReason
+	}
+	return types.SuppressionRequest{
+		RequestID:     requestID,
+		Fingerprint:   finding.Fingerprint,
+		RepositoryURL: finding.RepositoryURL,
+		Status:        SuppressionPending,
+		RequestedBy:   requestedBy,
+		Reason:        reason,
+		Evidence:      strings.TrimSpace(request.Evidence),
+		RequestedAt:   now,
+		Chain:         []types.SuppressionStep{{Action: SuppressionActionRequest, By: requestedBy, Comment: reason, At: now}},
+	}, nil
+}
+
+// ReviewSuppressionStep returns the step of the approval chain of suppression reviewed by
+// reviewer at now, as asked by review. Only pending requests can be reviewed, and not by
+// whoever requested them.
+func ReviewSuppressionStep(suppression types.SuppressionRequest, review types.SuppressionReview, reviewer string, now time.Time) (types.SuppressionStep, error) {
+	action := strings.ToLower(strings.TrimSpace(review.Action))
+	if action != SuppressionActionApprove && action != SuppressionActionReject {
+		return types.SuppressionStep{}, ErrSuppressionAction
+	}
+	if suppression.Status != SuppressionPending {
+		return types.SuppressionStep{}, ErrSuppressionReviewed
+	}
+	if strings.EqualFold(strings.TrimSpace(reviewer), suppression.RequestedBy) {
+		return types.SuppressionStep{}, ErrSuppressionSelfReview
+	}
+	return types.SuppressionStep{Action: action, By: reviewer, Comment: strings.TrimSpace(review.Comment), At: now}, nil
+}
+
+// RequestSuppression stores a pending request, of requestID, to suppress finding as a false
+// positive and notifies its reviewers. Until one of them approves it, the finding is reported
+// and fails analyses as before.
+func RequestSuppression(requestID string, finding types.Finding, request types.SuppressionRequest) (types.SuppressionRequest, error) {
+	suppression, err := NewSuppressionRequest(requestID, finding, request, time.Now())
+	if err != nil {
+		return suppression, err
+	}
+	if err := storeSuppressionRequest(suppression, finding); err != nil {
+		return types.SuppressionRequest{}, err
+	}
+	return suppression, nil
+}
+
+// storeSuppressionRequest stores suppression, of finding, unless the finding already has a
+// pending one, and notifies its reviewers.
+func storeSuppressionRequest(suppression types.SuppressionRequest, finding types.Finding) error {
+	pendingQuery := map[string]interface{}{"fingerprint": finding.Fingerprint, "status": SuppressionPending}
+	if _, err := apiContext.APIConfiguration.DBInstance.FindOneDBSuppressionRequest(pendingQuery); err == nil {
+		return ErrSuppressionPending
+	} else if !isNotFound(err) {
+		log.Error(logActionSuppression, logInfoAnalysis, 2048, suppression.RequestID, err)
+		return err
+	}
+	if err := apiContext.APIConfiguration.DBInstance.InsertDBSuppressionRequest(suppression); err != nil {
+		log.Error(logActionSuppression, logInfoAnalysis, 2048, suppression.RequestID, err)
+		return err
+	}
+	log.Info(logActionSuppression, logInfoAnalysis, 51, suppression.RequestID, finding.Fingerprint, suppression.RequestedBy)
+	notifySuppressionReviewers(suppression, finding)
+	return nil
+}
+
+// IgnoreFingerprint returns the fingerprint the suppression request of ignore, of the
+// repository of repositoryURL, is made for, as an ignore is not a recorded finding.
+func IgnoreFingerprint(repositoryURL string, ignore types.Ignore) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{repositoryURL, "ignore", strings.ToLower(ignore.SecurityTest), ignore.ID}, "\x00")))
+	return hex.EncodeToString(sum[:])
+}
+
+// approvedIgnores returns, as they were approved, the ignores of the analysis of RID of
+// repositoryURL whose suppression request was approved and has not expired at now. An ignore
+// never requested before is requested on behalf of the analysis, so it is only respected once
+// a reviewer approves it. If they can not be read, none is, so findings are not hidden.
+func approvedIgnores(RID, repositoryURL string, ignores []types.Ignore, now time.Time) []types.Ignore {
+	approved := []types.Ignore{}
+	for i, ignore := range ignores {
+		fingerprint := IgnoreFingerprint(repositoryURL, ignore)
+		suppressions, err := apiContext.APIConfiguration.DBInstance.FindAllDBSuppressionRequests(map[string]interface{}{"fingerprint": fingerprint})
+		if err != nil && !isNotFound(err) {
+			log.Error(logActionSuppression, logInfoAnalysis, 1020, err)
+			continue
+		}
+		if len(suppressions) == 0 {
+			requestIgnore(fmt.Sprintf("%s-%d", RID, i+1), RID, repositoryURL, fingerprint, ignore, now)
+			continue
+		}
+		for _, suppression := range suppressions {
+			if suppression.Status == SuppressionApproved && suppression.Ignore != nil && suppression.Ignore.IsActive(now) {
+				approved = append(approved, *suppression.Ignore)
+				break
+			}
+		}
+	}
+	return approved
+}
+
+// requestIgnore stores a pending request, of requestID, to ignore, of fingerprint, as asked by
+// the analysis of RID of repositoryURL at now. Errors are only logged, as the ignore is not
+// respected until it is requested again.
+func requestIgnore(requestID, RID, repositoryURL, fingerprint string, ignore types.Ignore, now time.Time) {
+	finding := types.Finding{
+		Fingerprint:   fingerprint,
+		RepositoryURL: repositoryURL,
+		Tool:          ignore.SecurityTest,
+	}
+	request := types.SuppressionRequest{RequestedBy: fmt.Sprintf("analysis %s", RID), Reason: ignore.Reason}
+	suppression, err := NewSuppressionRequest(requestID, finding, request, now)
+	if err != nil {
+		log.Error(logActionSuppression, logInfoAnalysis, 2048, requestID, err)
+		return
+	}
+	suppression.Ignore = &ignore
+	// an error is already logged, and a pending request is the one just stored by another analysis
+	_ = storeSuppressionRequest(suppression, finding)
+}
+
+// ReviewSuppression approves or rejects, as asked by review, the pending suppression request
+// of requestID of the finding of fingerprint on behalf of reviewer. The step is appended to its
+// approval chain. Approved suppressions are respected by the next analyses of the repository.
+func ReviewSuppression(fingerprint, requestID, reviewer string, review types.SuppressionReview) (types.SuppressionRequest, error) {
+	suppressionQuery := map[string]interface{}{"requestID": requestID, "fingerprint": fingerprint}
+	suppression, err := apiContext.APIConfiguration.DBInstance.FindOneDBSuppressionRequest(suppressionQuery)
+	if err != nil {
+		if isNotFound(err) {
+			return suppression, ErrSuppressionNotFound
+		}
+		log.Error(logActionSuppression, logInfoAnalysis, 2048, requestID, err)
+		return suppression, err
+	}
+	step, err := ReviewSuppressionStep(suppression, review, reviewer, time.Now())
+	if err != nil {
+		return suppression, err
+	}
+	status := SuppressionApproved
+	if step.Action == SuppressionActionReject {
+		status = SuppressionRejected
+	}
+	updateQuery := map[string]interface{}{
+		"$set":  bson.M{"status": status, "reviewedBy": step.By, "reviewedAt": step.At},
+		"$push": bson.M{"chain": step},
+	}
+	// a request reviewed by someone else in the meantime is not pending anymore
+	pendingQuery := map[string]interface{}{"requestID": requestID, "status": SuppressionPending}
+	if err := apiContext.APIConfiguration.DBInstance.UpdateOneDBSuppressionRequest(pendingQuery, updateQuery); err != nil {
+		if isNotFound(err) {
+			return suppression, ErrSuppressionReviewed
+		}
+		log.Error(logActionSuppression, logInfoAnalysis, 2048, requestID, err)
+		return suppression, err
+	}
+	log.Info(logActionSuppression, logInfoAnalysis, 52, requestID, step.Action, step.By)
+	suppression.Status = status
+	suppression.ReviewedBy = step.By
+	suppression.ReviewedAt = step.At
+	suppression.Chain = append(suppression.Chain, step)
+	return suppression, nil
+}
+
+// PendingSuppressions returns the suppression requests waiting for a review, the oldest first.
+func PendingSuppressions() ([]types.SuppressionRequest, error) {
+	suppressions, err := apiContext.APIConfiguration.DBInstance.FindAllDBSuppressionRequests(map[string]interface{}{"status": SuppressionPending})
+	if err != nil {
+		if isNotFound(err) {
+			return []types.SuppressionRequest{}, nil
+		}
+		log.Error(logActionSuppression, logInfoAnalysis, 1020, err)
+		return nil, err
+	}
+	sort.SliceStable(suppressions, func(i, j int) bool {
+		return suppressions[i].RequestedAt.Before(suppressions[j].RequestedAt)
+	})
+	return suppressions, nil
+}
+
+// approvedSuppressions returns the sorted fingerprints of the findings of repositoryURL whose
+// suppression was approved. If they can not be read, none is, so findings are not hidden.
+func approvedSuppressions(repositoryURL string) []string {
+	suppressionQuery := map[string]interface{}{"repositoryURL": repositoryURL, "status": SuppressionApproved}
+	suppressions, err := apiContext.APIConfiguration.DBInstance.FindAllDBSuppressionRequests(suppressionQuery)
+	if err != nil {
+		if !isNotFound(err) {
+			log.Error(logActionSuppression, logInfoAnalysis, 1020, err)
+		}
+		return nil
+	}
+	fingerprints := []string{}
+	for _, suppression := range suppressions {
+		fingerprints = append(fingerprints, suppression.Fingerprint)
+	}
+	sort.Strings(fingerprints)
+	return fingerprints
+}
+
+// SuppressionMessage returns the Slack message asking reviewers to approve or reject
+// suppression of finding, with a link to the review queue if publicURL is set.
+func SuppressionMessage(suppression types.SuppressionRequest, finding types.Finding, publicURL string) string {
+	var message strings.Builder
+	if suppression.Ignore != nil {
+		fmt.Fprintf(&message, "%s asks to ignore %s %s in %s", suppression.RequestedBy, suppression.Ignore.SecurityTest, suppression.Ignore.ID, suppression.RepositoryURL)
+		if !suppression.Ignore.ExpiresAt.IsZero() {
+			fmt.Fprintf(&message, " until %s", suppression.Ignore.ExpiresAt.Format(time.RFC3339))
+		}
+	} else {
+		fmt.Fprintf(&message, "%s asks to suppress a %s finding of %s as a false positive", suppression.RequestedBy, finding.Tool, suppression.RepositoryURL)
+	}
+	if finding.Finding.RuleID != "" {
+		location := finding.Finding.File
+		if finding.Finding.Line > 0 {
+			location = fmt.Sprintf("%s:%d", finding.Finding.File, finding.Finding.Line)
+		}
+		fmt.Fprintf(&message, ": *%s* %s %s", summarySeverity(finding.Finding), finding.Finding.RuleID, location)
+	}
+	fmt.Fprintf(&message, ".\nReason: %s", suppression.Reason)
+	if suppression.Evidence != "" {
+		fmt.Fprintf(&message, "\nEvidence: %s", suppression.Evidence)
+	}
+	if publicURL != "" {
+		fmt.Fprintf(&message, "\n<%s/suppressions/pending|Review pending suppressions>", publicURL)
+	} else {
+		fmt.Fprintf(&message, "\nRequest: %s", suppression.RequestID)
+	}
+	return message.String()
+}
+
+// notifySuppressionReviewers posts suppression of finding, in background, to the default Slack
+// channel, followed by admins, and to the one of its repository, followed by its team. Errors
+// are only logged, as reviewers also find it in the review queue.
+func notifySuppressionReviewers(suppression types.SuppressionRequest, finding types.Finding) {
+	config := apiContext.APIConfiguration
+	if notifier.NewSlackNotifier(config.SlackWebhookURL) == nil {
+		return
+	}
+	channels := []string{config.SlackChannel}
+	repository, err := config.DBInstance.FindOneDBRepository(map[string]interface{}{"repositoryURL": suppression.RepositoryURL})
+	if err != nil && !isNotFound(err) {
+		log.Error("notifySuppressionReviewers", logInfoAnalysis, 1013, err)
+	}
+	if repository.SlackChannel != "" && repository.SlackChannel != config.SlackChannel {
+		channels = append(channels, repository.SlackChannel)
+	}
+	text := SuppressionMessage(suppression, finding, config.PublicURL)
+	for _, channel := range channels {
+		slackNotifier := notifier.NewSlackNotifier(config.SlackWebhookURL)
+		slackNotifier.Channel = channel
+		slackNotifier.NotifyAsync(text, func(err error) {
+			log.Error("notifySuppressionReviewers", logInfoAnalysis, 2024, err)
+		})
+	}
+}
@@ -0,0 +1,199 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/db"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// suppressionsDB keeps suppression requests in memory. Any other request panics.
+type suppressionsDB struct {
+	db.Requests
+	mutex        sync.Mutex
+	suppressions []types.SuppressionRequest
+}
+
+func (fDB *suppressionsDB) FindAllDBSuppressionRequests(mapParams map[string]interface{}) ([]types.SuppressionRequest, error) {
+	fDB.mutex.Lock()
+	defer fDB.mutex.Unlock()
+	suppressions := []types.SuppressionRequest{}
+	for _, suppression := range fDB.suppressions {
+		if suppression.Fingerprint == mapParams["fingerprint"] {
+			suppressions = append(suppressions, suppression)
+		}
+	}
+	return suppressions, nil
+}
+
+func (fDB *suppressionsDB) FindOneDBSuppressionRequest(mapParams map[string]interface{}) (types.SuppressionRequest, error) {
+	suppressions, _ := fDB.FindAllDBSuppressionRequests(mapParams)
+	for _, suppression := range suppressions {
+		if suppression.Status == mapParams["status"] {
+			return suppression, nil
+		}
+	}
+	return types.SuppressionRequest{}, errors.New("No data found")
+}
+
+func (fDB *suppressionsDB) InsertDBSuppressionRequest(suppression types.SuppressionRequest) error {
+	fDB.mutex.Lock()
+	defer fDB.mutex.Unlock()
+	fDB.suppressions = append(fDB.suppressions, suppression)
+	return nil
+}
+
+var _ = Describe("Suppression", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	finding := types.Finding{
+		Fingerprint:   "0b5c3e7f",
+		RepositoryURL: "https://github.com/globocom/huskyCI.git",
+		Tool:          "gosec",
+		Finding:       types.UnifiedFinding{File: "api/util/util.go", Line: 42, Tool: "GoSec", RuleID: "G104", Severity: "MEDIUM"},
+	}
+
+	Describe("NewSuppressionRequest", func() {
+		It("Should return a pending request whose chain starts with it", func() {
+			request := types.SuppressionRequest{RequestedBy: " alice ", Reason: "the error can not happen", Evidence: "https://github.com/globocom/huskyCI/pull/1"}
+			suppression, err := analysis.NewSuppressionRequest("req-1", finding, request, now)
+			Expect(err).To(BeNil())
+			Expect(suppression.Status).To(Equal(analysis.SuppressionPending))
+			Expect(suppression.Fingerprint).To(Equal(finding.Fingerprint))
+			Expect(suppression.RepositoryURL).To(Equal(finding.RepositoryURL))
+			Expect(suppression.RequestedBy).To(Equal("alice"))
+			Expect(suppression.Chain).To(Equal([]types.SuppressionStep{{Action: analysis.SuppressionActionRequest, By: "alice", Comment: "the error can not happen", At: now}}))
+		})
+
+		It("Should require who requests it", func() {
+			_, err := analysis.NewSuppressionRequest("req-1", finding, types.SuppressionRequest{Reason: "false positive"}, now)
+			Expect(err).To(Equal(analysis.ErrSuppressionRequester))
+		})
+
+		It("Should require a reason", func() {
+			_, err := analysis.NewSuppressionRequest("req-1", finding, types.SuppressionRequest{RequestedBy: "alice", Reason: "  "}, now)
+			Expect(err).To(Equal(analysis.ErrSuppressionReason))
+		})
+	})
+
+	Describe("ReviewSuppressionStep", func() {
+		pending := types.SuppressionRequest{RequestID: "req-1", Status: analysis.SuppressionPending, RequestedBy: "alice"}
+
+		It("Should approve a pending request", func() {
+			step, err := analysis.ReviewSuppressionStep(pending, types.SuppressionReview{Action: "Approve", Comment: "checked"}, "huskyCIUser", now)
+			Expect(err).To(BeNil())
+			Expect(step).To(Equal(types.SuppressionStep{Action: analysis.SuppressionActionApprove, By: "huskyCIUser", Comment: "checked", At: now}))
+		})
+
+		It("Should reject a pending request", func() {
+			step, err := analysis.ReviewSuppressionStep(pending, types.SuppressionReview{Action: "reject"}, "huskyCIUser", now)
+			Expect(err).To(BeNil())
+			Expect(step.Action).To(Equal(analysis.SuppressionActionReject))
+		})
+
+		It("Should refuse other actions", func() {
+			_, err := analysis.ReviewSuppressionStep(pending, types.SuppressionReview{Action: "ignore"}, "huskyCIUser", now)
+			Expect(err).To(Equal(analysis.ErrSuppressionAction))
+		})
+
+		It("Should refuse requests already reviewed", func() {
+			approved := pending
+			approved.Status = analysis.SuppressionApproved
+			_, err := analysis.ReviewSuppressionStep(approved, types.SuppressionReview{Action: "reject"}, "huskyCIUser", now)
+			Expect(err).To(Equal(analysis.ErrSuppressionReviewed))
+		})
+
+		It("Should refuse a review by its requester", func() {
+			_, err := analysis.ReviewSuppressionStep(pending, types.SuppressionReview{Action: "approve"}, "Alice", now)
+			Expect(err).To(Equal(analysis.ErrSuppressionSelfReview))
+		})
+	})
+
+	Describe("SuppressionMessage", func() {
+		suppression := types.SuppressionRequest{RequestID: "req-1", RepositoryURL: finding.RepositoryURL, RequestedBy: "alice", Reason: "the error can not happen"}
+
+		It("Should link to the review queue", func() {
+			Expect(analysis.SuppressionMessage(suppression, finding, "https://huskyci.example.com")).To(Equal(
+				"alice asks to suppress a gosec finding of https://github.com/globocom/huskyCI.git as a false positive: *MEDIUM* G104 api/util/util.go:42.\n" +
+					"Reason: the error can not happen\n" +
+					"<https://huskyci.example.com/suppressions/pending|Review pending suppressions>"))
+		})
+
+		It("Should name the request if huskyCI has no public URL", func() {
+			Expect(analysis.SuppressionMessage(suppression, finding, "")).To(HaveSuffix("\nRequest: req-1"))
+		})
+	})
+
+	Describe("ApprovedIgnores", func() {
+		repositoryURL := "https://github.com/globocom/huskyCI.git"
+		ignore := types.Ignore{SecurityTest: "safety", ID: "38414", Reason: "no fix released"}
+		var (
+			previousConfig *apiContext.APIConfig
+			fakeDB         *suppressionsDB
+		)
+
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+			fakeDB = &suppressionsDB{}
+			apiContext.APIConfiguration = &apiContext.APIConfig{DBInstance: fakeDB}
+		})
+
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+
+		It("Should request an ignore never requested before instead of respecting it", func() {
+			Expect(analysis.ApprovedIgnores("rid-1", repositoryURL, []types.Ignore{ignore}, now)).To(BeEmpty())
+			Expect(fakeDB.suppressions).To(HaveLen(1))
+			Expect(fakeDB.suppressions[0].Fingerprint).To(Equal(analysis.IgnoreFingerprint(repositoryURL, ignore)))
+			Expect(fakeDB.suppressions[0].Status).To(Equal(analysis.SuppressionPending))
+			Expect(fakeDB.suppressions[0].RequestedBy).To(Equal("analysis rid-1"))
+			Expect(fakeDB.suppressions[0].Ignore).To(Equal(&ignore))
+		})
+
+		It("Should not request again an ignore pending or rejected", func() {
+			analysis.ApprovedIgnores("rid-1", repositoryURL, []types.Ignore{ignore}, now)
+			Expect(analysis.ApprovedIgnores("rid-2", repositoryURL, []types.Ignore{ignore}, now)).To(BeEmpty())
+			fakeDB.suppressions[0].Status = analysis.SuppressionRejected
+			Expect(analysis.ApprovedIgnores("rid-3", repositoryURL, []types.Ignore{ignore}, now)).To(BeEmpty())
+			Expect(fakeDB.suppressions).To(HaveLen(1))
+		})
+
+		It("Should respect an approved ignore as it was approved until it expires", func() {
+			analysis.ApprovedIgnores("rid-1", repositoryURL, []types.Ignore{ignore}, now)
+			fakeDB.suppressions[0].Status = analysis.SuppressionApproved
+			extended := ignore
+			extended.ExpiresAt = now.Add(24 * time.Hour)
+			Expect(analysis.ApprovedIgnores("rid-2", repositoryURL, []types.Ignore{extended}, now)).To(Equal([]types.Ignore{ignore}))
+			fakeDB.suppressions[0].Ignore.ExpiresAt = now
+			Expect(analysis.ApprovedIgnores("rid-3", repositoryURL, []types.Ignore{ignore}, now)).To(BeEmpty())
+		})
+	})
+
+	Describe("SuppressionFingerprint", func() {
+		It("Should fingerprint a finding of a securityTest as it is recorded", func() {
+			repositoryURL := "https://github.com/globocom/huskyCI.git"
+			vuln := types.HuskyCIVulnerability{Language: "Python", SecurityTool: "Safety", Severity: "high", RuleID: "38414", Details: "SQL injection", File: "requirements.txt"}
+			results := types.HuskyCIResults{}
+			results.PythonResults.HuskyCISafetyOutput.HighVulns = []types.HuskyCIVulnerability{vuln}
+			recorded := analysis.UnifyFindings(results)
+			Expect(recorded).To(HaveLen(1))
+			Expect(securitytest.SuppressionFingerprint(repositoryURL, "safety", vuln)).To(Equal(analysis.RepositoryFindingFingerprint(repositoryURL, recorded[0])))
+		})
+	})
+})
@@ -0,0 +1,137 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// improvementWindow is how far back ImprovementDelta compares findings.
+const improvementWindow = 30 * 24 * time.Hour
+
+// BuildTrends returns the snapshot of each securityTest of containers, and of each
+// securityTool that reported findings, of repositoryURL at the day of date. Tools are
+// named as their securityTests and suppressed findings are not counted.
+func BuildTrends(repositoryURL string, date time.Time, containers []types.Container, findings []types.UnifiedFinding) []types.VulnerabilityTrend {
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	trendsByTool := map[string]*types.VulnerabilityTrend{}
+	tools := []string{}
+	trendOf := func(tool string) *types.VulnerabilityTrend {
+		trend, ok := trendsByTool[tool]
+		if !ok {
+			trend = &types.VulnerabilityTrend{RepositoryURL: repositoryURL, Date: day, Tool: tool}
+			trendsByTool[tool] = trend
+			tools = append(tools, tool)
+		}
+		return trend
+	}
+
+	// securityTests without findings still have a snapshot, so a tool drops to zero
+	for _, container := range containers {
+		if container.SecurityTest.Name != "" && container.SecurityTest.Name != "gitauthors" {
+			trendOf(container.SecurityTest.Name)
+		}
+	}
+
+	for _, finding := range findings {
+		if finding.Suppressed {
+			continue
+		}
+		trend := trendOf(strings.ToLower(finding.Tool))
+		switch summarySeverity(finding) {
+		case "CRITICAL", "HIGH":
+			trend.HighCount++
+		case "MEDIUM":
+			trend.MediumCount++
+		case "LOW":
+			trend.LowCount++
+		}
+		trend.TotalCount++
+	}
+
+	sort.Strings(tools)
+	trends := []types.VulnerabilityTrend{}
+	for _, tool := range tools {
+		trends = append(trends, *trendsByTool[tool])
+	}
+	return trends
+}
+
+// RecordTrends upserts today's snapshots of a repository after one of its analyses finished.
+func RecordTrends(repositoryURL string, containers []types.Container, findings []types.UnifiedFinding) {
+	for _, trend := range BuildTrends(repositoryURL, time.Now().UTC(), containers, findings) {
+		trendQuery := map[string]interface{}{"repository": trend.RepositoryURL, "date": trend.Date, "tool": trend.Tool}
+		if _, err := apiContext.APIConfiguration.DBInstance.UpsertOneDBVulnerabilityTrend(trendQuery, trend); err != nil {
+			log.Error("RecordTrends", logInfoAnalysis, 2026, repositoryURL, err)
+		}
+	}
+}
+
+// GetRepositoryTrend returns the snapshots of the last days of a repository, only
+// of tool if it is not empty, sorted by date and tool.
+func GetRepositoryTrend(repositoryURL string, days int, tool string) (types.RepositoryTrend, error) {
+	now := time.Now().UTC()
+	since := now.AddDate(0, 0, -days)
+	if window := now.Add(-improvementWindow); window.Before(since) {
+		since = window
+	}
+	trendQuery := map[string]interface{}{"repository": repositoryURL, "date": bson.M{"$gte": since}}
+	if tool != "" {
+		trendQuery["tool"] = tool
+	}
+	snapshots, err := apiContext.APIConfiguration.DBInstance.FindAllDBVulnerabilityTrend(trendQuery)
+	if err != nil && !isNotFound(err) {
+		return types.RepositoryTrend{}, err
+	}
+	sort.SliceStable(snapshots, func(i, j int) bool {
+		if !snapshots[i].Date.Equal(snapshots[j].Date) {
+			return snapshots[i].Date.Before(snapshots[j].Date)
+		}
+		return snapshots[i].Tool < snapshots[j].Tool
+	})
+
+	trend := []types.VulnerabilityTrend{}
+	for _, snapshot := range snapshots {
+		if !snapshot.Date.Before(now.AddDate(0, 0, -days)) {
+			trend = append(trend, snapshot)
+		}
+	}
+	return types.RepositoryTrend{
+		RepositoryURL:    repositoryURL,
+		Days:             days,
+		Tool:             tool,
+		ImprovementDelta: ImprovementDelta(snapshots, now),
+		Trend:            trend,
+	}, nil
+}
+
+// ImprovementDelta returns how many fewer findings the latest day of snapshots has
+// than the earliest day within the last 30 days of now. snapshots must be sorted by date.
+func ImprovementDelta(snapshots []types.VulnerabilityTrend, now time.Time) int {
+	window := now.Add(-improvementWindow)
+	totals := map[time.Time]int{}
+	var first, last time.Time
+	for _, snapshot := range snapshots {
+		if snapshot.Date.Before(window) {
+			continue
+		}
+		if first.IsZero() {
+			first = snapshot.Date
+		}
+		last = snapshot.Date
+		totals[snapshot.Date] += snapshot.TotalCount
+	}
+	if first.IsZero() {
+		return 0
+	}
+	return totals[first] - totals[last]
+}
@@ -0,0 +1,76 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package analysis_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Trend", func() {
+
+	repositoryURL := "https://github.com/globocom/huskyCI.git"
+	now := time.Date(2020, time.March, 30, 15, 4, 5, 0, time.UTC)
+	today := time.Date(2020, time.March, 30, 0, 0, 0, 0, time.UTC)
+
+	Describe("BuildTrends", func() {
+		Context("When securityTests ran with and without findings", func() {
+			containers := []types.Container{
+				{SecurityTest: types.SecurityTest{Name: "bandit"}},
+				{SecurityTest: types.SecurityTest{Name: "gosec"}},
+				{SecurityTest: types.SecurityTest{Name: "gitauthors"}},
+			}
+			findings := []types.UnifiedFinding{
+				{Tool: "Bandit", Severity: "HIGH", File: "app.py"},
+				{Tool: "Bandit", Severity: "MEDIUM", File: "app.py", Line: 2},
+				{Tool: "DependencyCheck", Severity: "HIGH", CVSSScore: 9.8},
+				{Tool: "Bandit", Severity: "NOSEC", Suppressed: true},
+			}
+			trends := analysis.BuildTrends(repositoryURL, now, containers, findings)
+
+			It("Should return a daily snapshot of each tool named as its securityTest", func() {
+				Expect(trends).To(Equal([]types.VulnerabilityTrend{
+					{RepositoryURL: repositoryURL, Date: today, Tool: "bandit", HighCount: 1, MediumCount: 1, TotalCount: 2},
+					{RepositoryURL: repositoryURL, Date: today, Tool: "dependencycheck", HighCount: 1, TotalCount: 1},
+					{RepositoryURL: repositoryURL, Date: today, Tool: "gosec"},
+				}))
+			})
+		})
+	})
+
+	Describe("ImprovementDelta", func() {
+		Context("When a repository has fewer findings than 30 days ago", func() {
+			It("Should return how many fewer findings it has", func() {
+				snapshots := []types.VulnerabilityTrend{
+					{Date: today.AddDate(0, 0, -40), Tool: "bandit", TotalCount: 50},
+					{Date: today.AddDate(0, 0, -20), Tool: "bandit", TotalCount: 8},
+					{Date: today.AddDate(0, 0, -20), Tool: "gosec", TotalCount: 2},
+					{Date: today, Tool: "bandit", TotalCount: 3},
+					{Date: today, Tool: "gosec", TotalCount: 1},
+				}
+				Expect(analysis.ImprovementDelta(snapshots, now)).To(Equal(6))
+			})
+		})
+		Context("When a repository has more findings than 30 days ago", func() {
+			It("Should return a negative delta", func() {
+				snapshots := []types.VulnerabilityTrend{
+					{Date: today.AddDate(0, 0, -10), Tool: "bandit", TotalCount: 1},
+					{Date: today, Tool: "bandit", TotalCount: 4},
+				}
+				Expect(analysis.ImprovementDelta(snapshots, now)).To(Equal(-3))
+			})
+		})
+		Context("When a repository has no snapshots in the last 30 days", func() {
+			It("Should return zero", func() {
+				Expect(analysis.ImprovementDelta([]types.VulnerabilityTrend{}, now)).To(Equal(0))
+			})
+		})
+	})
+})
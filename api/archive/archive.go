@@ -0,0 +1,132 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package archive
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrObjectNotFound is returned when a Backend has no object with the given key.
+var ErrObjectNotFound = errors.New("archived object not found")
+
+// Backends huskyCI archives analyses to, as set by HUSKYCI_ARCHIVE_BACKEND.
+const (
+	BackendS3   = "s3"
+	BackendGCS  = "gcs"
+	BackendFile = "file"
+)
+
+// Backend stores archived analyses by key, such as "analyses/<RID>.json.gz".
+type Backend interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error)
+	// Delete deletes the object of key, returning ErrObjectNotFound if there is none.
+	Delete(key string) error
+}
+
+// Config configures the Backend of NewBackend. Bucket and the HMAC keys AccessKey and
+// SecretKey are the ones of S3 or, through its XML API, of Google Cloud Storage. Endpoint,
+// if set, is the one of an S3-compatible store, such as MinIO. Dir is where the file
+// backend writes to, such as a mounted volume.
+type Config struct {
+	Backend   string
+	Bucket    string
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Dir       string
+}
+
+// NewBackend returns the Backend of config, or nil if config.Backend is empty so callers
+// can tell that archiving is not configured.
+func NewBackend(config Config) (Backend, error) {
+	switch config.Backend {
+	case "":
+		return nil, nil
+	case BackendS3, BackendGCS:
+		if config.Bucket == "" || config.AccessKey == "" || config.SecretKey == "" {
+			return nil, fmt.Errorf("%s archive backend requires a bucket and HMAC keys", config.Backend)
+		}
+		endpoint, region := config.Endpoint, config.Region
+		if endpoint == "" && config.Backend == BackendGCS {
+			endpoint = "https://storage.googleapis.com"
+		}
+		if endpoint == "" {
+			endpoint = "https://s3.amazonaws.com"
+			if region != "" && region != defaultS3Region {
+				endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", region)
+			}
+		}
+		if region == "" {
+			region = defaultS3Region
+		}
+		return NewS3Backend(endpoint, region, config.Bucket, config.AccessKey, config.SecretKey), nil
+	case BackendFile:
+		if config.Dir == "" {
+			return nil, errors.New("file archive backend requires a directory")
+		}
+		return &FileBackend{Dir: config.Dir}, nil
+	default:
+		return nil, fmt.Errorf("unknown archive backend %s", config.Backend)
+	}
+}
+
+// FileBackend archives objects as files of Dir.
+type FileBackend struct {
+	Dir string
+}
+
+// Put writes data to the file of key, creating its directories.
+func (fb *FileBackend) Put(key string, data []byte) error {
+	path, err := fb.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0640)
+}
+
+// Get reads the file of key.
+func (fb *FileBackend) Get(key string) ([]byte, error) {
+	path, err := fb.path(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, ErrObjectNotFound
+	}
+	return data, err
+}
+
+// Delete removes the file of key.
+func (fb *FileBackend) Delete(key string) error {
+	path, err := fb.path(key)
+	if err != nil {
+		return err
+	}
+	err = os.Remove(path)
+	if os.IsNotExist(err) {
+		return ErrObjectNotFound
+	}
+	return err
+}
+
+// path returns the file of key, which can not be outside of Dir.
+func (fb *FileBackend) path(key string) (string, error) {
+	cleaned := filepath.Clean("/" + key)
+	if cleaned == "/" || strings.Contains(key, "..") {
+		return "", fmt.Errorf("invalid archive key %s", key)
+	}
+	return filepath.Join(fb.Dir, cleaned), nil
+}
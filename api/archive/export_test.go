@@ -0,0 +1,8 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package archive
+
+// SignRequest exposes signRequest to archive_test.
+var SignRequest = signRequest
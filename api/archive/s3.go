@@ -0,0 +1,149 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package archive
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// defaultS3Region is the region requests are signed for if none is configured. Google Cloud
+// Storage accepts any region.
+const defaultS3Region = "us-east-1"
+
+// amzDateFormat is the format of the X-Amz-Date header.
+const amzDateFormat = "20060102T150405Z"
+
+// S3Backend archives objects in a bucket of S3 or of a store with an S3-compatible API,
+// such as Google Cloud Storage, signing its requests with AWS Signature Version 4.
+type S3Backend struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	Client    *http.Client
+}
+
+// NewS3Backend returns an S3Backend of bucket at endpoint, such as https://s3.amazonaws.com.
+func NewS3Backend(endpoint, region, bucket, accessKey, secretKey string) *S3Backend {
+	return &S3Backend{
+		Endpoint:  strings.TrimSuffix(endpoint, "/"),
+		Region:    region,
+		Bucket:    bucket,
+		AccessKey: accessKey,
+		SecretKey: secretKey,
+		Client:    &http.Client{Timeout: time.Minute, Transport: util.ProxiedTransport()},
+	}
+}
+
+// Put uploads data as the object of key.
+func (sb *S3Backend) Put(key string, data []byte) error {
+	_, err := sb.do(http.MethodPut, key, data)
+	return err
+}
+
+// Get downloads the object of key.
+func (sb *S3Backend) Get(key string) ([]byte, error) {
+	return sb.do(http.MethodGet, key, nil)
+}
+
+// Delete deletes the object of key. S3 does not tell whether it existed.
+func (sb *S3Backend) Delete(key string) error {
+	_, err := sb.do(http.MethodDelete, key, nil)
+	return err
+}
+
+func (sb *S3Backend) do(method, key string, body []byte) ([]byte, error) {
+	// buckets are addressed by path, as their names may not be valid hostnames
+	objectURL := fmt.Sprintf("%s/%s/%s", sb.Endpoint, url.PathEscape(sb.Bucket), escapeKey(key))
+	req, err := http.NewRequest(method, objectURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	signRequest(req, body, sb.AccessKey, sb.SecretKey, sb.Region, time.Now())
+	resp, err := sb.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrObjectNotFound
+	}
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return nil, fmt.Errorf("archive backend returned status code %d for %s %s", resp.StatusCode, method, key)
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+// escapeKey escapes each segment of key, keeping its slashes.
+func escapeKey(key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// signRequest signs req, whose body is body, for the s3 service of region at now with AWS
+// Signature Version 4, see https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html.
+// Every header already set in req is signed.
+func signRequest(req *http.Request, body []byte, accessKey, secretKey, region string, now time.Time) {
+	payloadHash := sha256.Sum256(body)
+	amzDate := now.UTC().Format(amzDateFormat)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(payloadHash[:]))
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(payloadHash[:]),
+	}, "\n")
+	canonicalRequestHash := sha256.Sum256([]byte(canonicalRequest))
+	scope := strings.Join([]string{amzDate[:8], region, "s3", "aws4_request"}, "/")
+	stringToSign := strings.Join([]string{"AWS4-HMAC-SHA256", amzDate, scope, hex.EncodeToString(canonicalRequestHash[:])}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), amzDate[:8])
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s,SignedHeaders=%s,Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package cache
+
+import (
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// ErrCacheMiss is returned when a key is not cached.
+var ErrCacheMiss = errors.New("key not found in cache")
+
+// Cache stores values for a limited time.
+type Cache interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte, ttl time.Duration) error
+}
+
+// Instance is the cache shared by the API. It is nil if Redis is not configured.
+var Instance Cache
+
+// RedisCache implements Cache using Redis.
+type RedisCache struct {
+	client *redis.Client
+}
+
+// NewRedisCache connects to Redis and returns a RedisCache.
+func NewRedisCache(address, password string, db int) (*RedisCache, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     address,
+		Password: password,
+		DB:       db,
+	})
+	if err := client.Ping().Err(); err != nil {
+		client.Close()
+		return nil, err
+	}
+	return &RedisCache{client: client}, nil
+}
+
+// Get returns the value of a key or ErrCacheMiss if it is not cached.
+func (rC *RedisCache) Get(key string) ([]byte, error) {
+	value, err := rC.client.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	}
+	return value, err
+}
+
+// Ping returns an error if Redis can not be reached.
+func (rC *RedisCache) Ping() error {
+	return rC.client.Ping().Err()
+}
+
+// Set caches the value of a key for ttl.
+func (rC *RedisCache) Set(key string, value []byte, ttl time.Duration) error {
+	return rC.client.Set(key, value, ttl).Err()
+}
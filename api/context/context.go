@@ -42,15 +42,45 @@ type DBConfig struct {
 	ConnMaxLifetime time.Duration
 }
 
+// RedisConfig represents Redis configuration.
+type RedisConfig struct {
+	Address  string
+	Password string
+	DB       int
+}
+
 // DockerHostsConfig represents Docker Hosts configuration.
 type DockerHostsConfig struct {
 	Address         string
 	DockerAPIPort   int
 	PathCertificate string
+	CAPath          string
 	Host            string
 	TLSVerify       int
+	MaxContainers   int
+	ClientPoolSize  int
+	PullInterval    time.Duration
+	PullTimeout     time.Duration
+	// MaxImageAge, if set, is how old a cached image can be before it is pulled again.
+	MaxImageAge time.Duration
+	// RequestTimeout is how long a single call to the Docker API can take, so a wedged
+	// daemon fails it instead of hanging its goroutine.
+	RequestTimeout time.Duration
 }
 
+// DefaultDockerClientPoolSize is how many idle Docker API clients are kept by default.
+const DefaultDockerClientPoolSize = 10
+
+// DefaultDockerPullInterval is how long, on average, huskyCI waits by default between
+// attempts to pull an image that is not loaded yet.
+const DefaultDockerPullInterval = 15 * time.Second
+
+// DefaultDockerPullTimeout is how long huskyCI tries to pull an image by default.
+const DefaultDockerPullTimeout = 15 * time.Minute
+
+// DefaultDockerRequestTimeout is how long a single call to the Docker API can take by default.
+const DefaultDockerRequestTimeout = 30 * time.Second
+
 // GraylogConfig represents Graylog configuration.
 type GraylogConfig struct {
 	Address        string
@@ -60,28 +90,160 @@ type GraylogConfig struct {
 	DevelopmentEnv bool
 }
 
+// GitHubConfig represents the configuration of the GitHub integration, which reports
+// analyses of a commit as its commit status or, if AppID is set, as a Check Run.
+type GitHubConfig struct {
+	APIURL         string
+	Token          string
+	OwnerTokens    map[string]string
+	AppID          int
+	AppPrivateKey  string
+	MaxAnnotations int
+}
+
+// GitLabConfig represents the configuration of the GitLab integration, which reports analyses
+// of repositories that opt in as the pipeline status of their commit and a merge request note.
+type GitLabConfig struct {
+	URL   string
+	Token string
+}
+
+// BitbucketConfig represents the configuration of the Bitbucket integration, which reports
+// analyses of a commit as its build status in Bitbucket Cloud or, if URL is set, Server.
+type BitbucketConfig struct {
+	URL         string
+	Username    string
+	AppPassword string
+	Token       string
+}
+
+// JIRAConfig represents the configuration of the JIRA integration, which opens a Bug in Project
+// for each new finding of at least MinSeverity. It authenticates to JIRA Cloud with the API
+// Token of Email or, if Username is set, to JIRA Server with a session of Username.
+type JIRAConfig struct {
+	URL         string
+	Project     string
+	Email       string
+	Token       string
+	Username    string
+	Password    string
+	MinSeverity string
+}
+
+// ArchiveConfig represents where the raw output of analyses older than the retention is
+// archived to: Backend is "s3", "gcs" or "file", and archiving is disabled if it is empty.
+// Google Cloud Storage is reached through its S3-compatible API with HMAC keys.
+type ArchiveConfig struct {
+	Backend   string
+	Bucket    string
+	Endpoint  string
+	Region    string
+	AccessKey string
+	SecretKey string
+	Path      string
+}
+
+// MaxGitHubAnnotations is how many annotations GitHub accepts in a single Check Run update.
+const MaxGitHubAnnotations = 50
+
 // APIConfig represents API configuration.
 type APIConfig struct {
-	Port                   int
-	Version                string
-	ReleaseDate            string
-	AllowOriginValue       string
-	UseTLS                 bool
-	GitPrivateSSHKey       string
-	GraylogConfig          *GraylogConfig
-	DBConfig               *DBConfig
-	DockerHostsConfig      *DockerHostsConfig
-	EnrySecurityTest       *types.SecurityTest
-	GitAuthorsSecurityTest *types.SecurityTest
-	GosecSecurityTest      *types.SecurityTest
-	BanditSecurityTest     *types.SecurityTest
-	BrakemanSecurityTest   *types.SecurityTest
-	NpmAuditSecurityTest   *types.SecurityTest
-	YarnAuditSecurityTest  *types.SecurityTest
-	SpotBugsSecurityTest   *types.SecurityTest
-	GitleaksSecurityTest   *types.SecurityTest
-	SafetySecurityTest     *types.SecurityTest
-	DBInstance             db.Requests
+	Port                        int
+	GRPCPort                    int
+	Version                     string
+	ReleaseDate                 string
+	Commit                      string
+	AllowOriginValue            string
+	UseTLS                      bool
+	GitPrivateSSHKey            string
+	GraylogConfig               *GraylogConfig
+	DBConfig                    *DBConfig
+	RedisConfig                 *RedisConfig
+	DockerHostsConfig           *DockerHostsConfig
+	EnrySecurityTest            *types.SecurityTest
+	GitAuthorsSecurityTest      *types.SecurityTest
+	GosecSecurityTest           *types.SecurityTest
+	BanditSecurityTest          *types.SecurityTest
+	BrakemanSecurityTest        *types.SecurityTest
+	NpmAuditSecurityTest        *types.SecurityTest
+	YarnAuditSecurityTest       *types.SecurityTest
+	SpotBugsSecurityTest        *types.SecurityTest
+	GitleaksSecurityTest        *types.SecurityTest
+	SafetySecurityTest          *types.SecurityTest
+	DependencyCheckSecurityTest *types.SecurityTest
+	DepConfusionSecurityTest    *types.SecurityTest
+	EslintSecurityTest          *types.SecurityTest
+	HadolintSecurityTest        *types.SecurityTest
+	GitDiffSecurityTest         *types.SecurityTest
+	DependencyCheckFailSeverity string
+	GenericFailSeverity         string
+	CorrelateStrategy           string
+	AnalysisDeadline            time.Duration
+	AnalysisTimeout             time.Duration
+	ShutdownTimeout             time.Duration
+	SecurityTestParallelism     int
+	LanguageToolMapping         map[string][]string
+	SLA                         map[string]time.Duration
+	SlackWebhookURL             string
+	SlackChannel                string
+	SlackMinSeverity            string
+	ExcludedPaths               []string
+	ShareSecret                 string
+	PluginDir                   string
+	CustomSecurityTestImages    []string
+	AllowedEnvKeys              []string
+	EnforceImmutableTags        bool
+	IntegrityKey                string
+	AdvisoryDBURLs              map[string]string
+	GitleaksProtectedTags       []string
+	InternalPackagePrefixes     []string
+	ScanCache                   bool
+	PassedOutputMaxSize         int
+	OutputSecrets               []string
+	ESLintFailOnWarnings        bool
+	// GitHTTPSTokenHosts are the hosts HUSKYCI_API_GIT_HTTPS_TOKEN is sent to when cloning a
+	// repository that opted in to it.
+	GitHTTPSTokenHosts []string
+	// SuppressionReviewers are the API users, such as team leads, that review suppression
+	// requests along with admin.
+	SuppressionReviewers []string
+	// CallbackAllowlist, CallbackSecret and CallbackMaxAttempts configure the callbacks of analyses.
+	CallbackAllowlist   []string
+	CallbackSecret      string
+	CallbackMaxAttempts int
+	// PublicURL is the URL huskyCI is reached at, such as in the links of callbacks.
+	PublicURL  string
+	DBInstance db.Requests
+	// AnalysisRetryPolicy is how analyses that could not run due to a transient error are retried.
+	AnalysisRetryPolicy types.RetryPolicy
+	// GitHubConfig configures how analyses of a commit are reported to GitHub.
+	GitHubConfig *GitHubConfig
+	// GitLabConfig configures how analyses of a commit or merge request are reported to GitLab.
+	GitLabConfig *GitLabConfig
+	// BitbucketConfig configures how analyses of a commit are reported to Bitbucket.
+	BitbucketConfig *BitbucketConfig
+	// JIRAConfig configures how findings are opened as JIRA issues.
+	JIRAConfig *JIRAConfig
+	// AnalysisRetention is how long after they finish analyses keep their raw output in the
+	// database before it is archived.
+	AnalysisRetention time.Duration
+	// MinConfidence is the lowest confidence of a finding that fails an analysis, by securityTest.
+	MinConfidence map[string]string
+	// HadolintFailLevel is the lowest level of a Hadolint finding that fails an analysis.
+	HadolintFailLevel string
+	// AllowVulnerableImages lets images of securityTests with CRITICAL vulnerabilities be pulled.
+	AllowVulnerableImages bool
+	// RateLimitBurst is how many analyses a client can request in a minute. 0 is unlimited.
+	RateLimitBurst int
+	// RateLimitSustained is how many analyses a client can request in an hour. 0 is unlimited.
+	RateLimitSustained int
+	// AnalysisMaxAge is how long after they finish analyses are deleted. 0 never deletes them.
+	AnalysisMaxAge time.Duration
+	// AnalysisKeepPerRepository is how many of the most recent analyses of each repository
+	// are never deleted, however old they are.
+	AnalysisKeepPerRepository int
+	// ArchiveConfig configures where the raw output of analyses is archived to.
+	ArchiveConfig *ArchiveConfig
 }
 
 // DefaultConfig is the struct that stores the caller for testing.
@@ -105,26 +267,80 @@ func (dF DefaultConfig) GetAPIConfig() (*APIConfig, error) {
 func (dF DefaultConfig) SetOnceConfig() {
 	onceConfig.Do(func() {
 		APIConfiguration = &APIConfig{
-			Port:                   dF.GetAPIPort(),
-			Version:                dF.GetAPIVersion(),
-			ReleaseDate:            dF.GetAPIReleaseDate(),
-			AllowOriginValue:       dF.GetAllowOriginValue(),
-			UseTLS:                 dF.GetAPIUseTLS(),
-			GitPrivateSSHKey:       dF.getGitPrivateSSHKey(),
-			GraylogConfig:          dF.getGraylogConfig(),
-			DBConfig:               dF.getDBConfig(),
-			DockerHostsConfig:      dF.getDockerHostsConfig(),
-			EnrySecurityTest:       dF.getSecurityTestConfig("enry"),
-			GitAuthorsSecurityTest: dF.getSecurityTestConfig("gitauthors"),
-			GosecSecurityTest:      dF.getSecurityTestConfig("gosec"),
-			BanditSecurityTest:     dF.getSecurityTestConfig("bandit"),
-			BrakemanSecurityTest:   dF.getSecurityTestConfig("brakeman"),
-			NpmAuditSecurityTest:   dF.getSecurityTestConfig("npmaudit"),
-			YarnAuditSecurityTest:  dF.getSecurityTestConfig("yarnaudit"),
-			SpotBugsSecurityTest:   dF.getSecurityTestConfig("spotbugs"),
-			GitleaksSecurityTest:   dF.getSecurityTestConfig("gitleaks"),
-			SafetySecurityTest:     dF.getSecurityTestConfig("safety"),
-			DBInstance:             dF.GetDB(),
+			Port:                        dF.GetAPIPort(),
+			GRPCPort:                    dF.GetGRPCPort(),
+			Version:                     dF.GetAPIVersion(),
+			ReleaseDate:                 dF.GetAPIReleaseDate(),
+			Commit:                      dF.GetAPICommit(),
+			AllowOriginValue:            dF.GetAllowOriginValue(),
+			UseTLS:                      dF.GetAPIUseTLS(),
+			GitPrivateSSHKey:            dF.getGitPrivateSSHKey(),
+			GraylogConfig:               dF.getGraylogConfig(),
+			DBConfig:                    dF.getDBConfig(),
+			RedisConfig:                 dF.getRedisConfig(),
+			DockerHostsConfig:           dF.getDockerHostsConfig(),
+			EnrySecurityTest:            dF.getSecurityTestConfig("enry"),
+			GitAuthorsSecurityTest:      dF.getSecurityTestConfig("gitauthors"),
+			GosecSecurityTest:           dF.getSecurityTestConfig("gosec"),
+			BanditSecurityTest:          dF.getSecurityTestConfig("bandit"),
+			BrakemanSecurityTest:        dF.getSecurityTestConfig("brakeman"),
+			NpmAuditSecurityTest:        dF.getSecurityTestConfig("npmaudit"),
+			YarnAuditSecurityTest:       dF.getSecurityTestConfig("yarnaudit"),
+			SpotBugsSecurityTest:        dF.getSecurityTestConfig("spotbugs"),
+			GitleaksSecurityTest:        dF.getSecurityTestConfig("gitleaks"),
+			SafetySecurityTest:          dF.getSecurityTestConfig("safety"),
+			DependencyCheckSecurityTest: dF.getSecurityTestConfig("dependencycheck"),
+			DepConfusionSecurityTest:    dF.getSecurityTestConfig("depconfusion"),
+			EslintSecurityTest:          dF.getSecurityTestConfig("eslint"),
+			HadolintSecurityTest:        dF.getSecurityTestConfig("hadolint"),
+			GitDiffSecurityTest:         dF.getSecurityTestConfig("gitdiff"),
+			DependencyCheckFailSeverity: dF.GetDependencyCheckFailSeverity(),
+			GenericFailSeverity:         dF.GetGenericFailSeverity(),
+			CorrelateStrategy:           dF.GetCorrelateStrategy(),
+			AnalysisDeadline:            dF.GetAnalysisDeadline(),
+			AnalysisTimeout:             dF.GetAnalysisTimeout(),
+			ShutdownTimeout:             dF.GetShutdownTimeout(),
+			SecurityTestParallelism:     dF.GetSecurityTestParallelism(),
+			LanguageToolMapping:         dF.getLanguageToolMapping(),
+			SLA:                         dF.getSLA(),
+			SlackWebhookURL:             dF.GetSlackWebhookURL(),
+			SlackChannel:                dF.GetSlackChannel(),
+			SlackMinSeverity:            dF.GetSlackMinSeverity(),
+			ExcludedPaths:               dF.getExcludedPaths(),
+			ShareSecret:                 dF.GetShareSecret(),
+			PluginDir:                   dF.GetPluginDir(),
+			CustomSecurityTestImages:    dF.getCustomSecurityTestImages(),
+			AllowedEnvKeys:              dF.GetAllowedEnvKeys(),
+			EnforceImmutableTags:        dF.GetEnforceImmutableTags(),
+			IntegrityKey:                dF.GetIntegrityKey(),
+			AdvisoryDBURLs:              dF.GetAdvisoryDBURLs(),
+			GitleaksProtectedTags:       dF.getGitleaksProtectedTags(),
+			InternalPackagePrefixes:     dF.getInternalPackagePrefixes(),
+			ScanCache:                   dF.GetScanCache(),
+			PassedOutputMaxSize:         dF.GetPassedOutputMaxSize(),
+			OutputSecrets:               dF.GetOutputSecrets(),
+			ESLintFailOnWarnings:        dF.GetESLintFailOnWarnings(),
+			GitHTTPSTokenHosts:          dF.GetGitHTTPSTokenHosts(),
+			SuppressionReviewers:        dF.GetSuppressionReviewers(),
+			CallbackAllowlist:           dF.GetCallbackAllowlist(),
+			CallbackSecret:              dF.GetCallbackSecret(),
+			CallbackMaxAttempts:         dF.GetCallbackMaxAttempts(),
+			PublicURL:                   dF.GetPublicURL(),
+			DBInstance:                  dF.GetDB(),
+			AnalysisRetryPolicy:         dF.GetAnalysisRetryPolicy(),
+			GitHubConfig:                dF.getGitHubConfig(),
+			GitLabConfig:                dF.getGitLabConfig(),
+			BitbucketConfig:             dF.getBitbucketConfig(),
+			JIRAConfig:                  dF.getJIRAConfig(),
+			AnalysisRetention:           dF.GetAnalysisRetention(),
+			MinConfidence:               dF.GetMinConfidence(),
+			HadolintFailLevel:           dF.GetHadolintFailLevel(),
+			AllowVulnerableImages:       dF.GetAllowVulnerableImages(),
+			RateLimitBurst:              dF.GetRateLimit("HUSKYCI_API_RATE_LIMIT_BURST"),
+			RateLimitSustained:          dF.GetRateLimit("HUSKYCI_API_RATE_LIMIT_SUSTAINED"),
+			AnalysisMaxAge:              dF.GetAnalysisMaxAge(),
+			AnalysisKeepPerRepository:   dF.GetAnalysisKeepPerRepository(),
+			ArchiveConfig:               dF.getArchiveConfig(),
 		}
 	})
 }
@@ -141,14 +357,43 @@ func (dF DefaultConfig) GetAPIPort() int {
 	return apiPort
 }
 
-// GetAPIVersion returns current API version
+// GetGRPCPort will return the port number
+// where HuskyCI gRPC server will be listening to.
+// If HUSKYCI_GRPC_PORT is not set, it will
+// return the default 50051 port.
+func (dF DefaultConfig) GetGRPCPort() int {
+	grpcPort, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_GRPC_PORT"))
+	if err != nil {
+		grpcPort = 50051
+	}
+	return grpcPort
+}
+
+// Build information of huskyCI, set when it is built with -ldflags such as
+// -X github.com/globocom/huskyCI/api/context.version=v0.14.0. Builds without
+// them, such as go run, are dev ones.
+var (
+	version   = "dev"
+	commit    = "dev"
+	buildDate = "dev"
+)
+
+// GetAPIVersion returns current API version, a semantic version such as 0.14.0,
+// or dev if it was not set at build time.
 func (dF DefaultConfig) GetAPIVersion() string {
-	return "0.13.0"
+	return strings.TrimPrefix(version, "v")
 }
 
-// GetAPIReleaseDate returns current API release date
+// GetAPIReleaseDate returns when the API was built, or dev if it was not set
+// at build time.
 func (dF DefaultConfig) GetAPIReleaseDate() string {
-	return "2020-02-28"
+	return buildDate
+}
+
+// GetAPICommit returns the git commit the API was built from, or dev if it was
+// not set at build time.
+func (dF DefaultConfig) GetAPICommit() string {
+	return commit
 }
 
 // GetAllowOriginValue returns the allow origin value
@@ -198,6 +443,167 @@ func (dF DefaultConfig) GetGraylogIsDev() bool {
 	return true
 }
 
+func (dF DefaultConfig) getGitHubConfig() *GitHubConfig {
+	appID, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITHUB_APP_ID"))
+	if err != nil {
+		appID = 0
+	}
+	return &GitHubConfig{
+		APIURL:         dF.GetGitHubAPIURL(),
+		Token:          dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITHUB_TOKEN"),
+		OwnerTokens:    dF.GetGitHubOwnerTokens(),
+		AppID:          appID,
+		AppPrivateKey:  dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITHUB_APP_PRIVATE_KEY"),
+		MaxAnnotations: dF.GetGitHubMaxAnnotations(),
+	}
+}
+
+// GetGitHubAPIURL returns the URL of the GitHub API, which is the one
+// of a GitHub Enterprise Server if HUSKYCI_API_GITHUB_API_URL is set.
+func (dF DefaultConfig) GetGitHubAPIURL() string {
+	apiURL := strings.TrimSuffix(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITHUB_API_URL"), "/")
+	if apiURL == "" {
+		return "https://api.github.com"
+	}
+	return apiURL
+}
+
+// GetGitHubOwnerTokens returns the token of each GitHub installation, by
+// the owner of its repositories. It depends on HUSKYCI_API_GITHUB_TOKENS, a
+// comma separated list of owner=token, and HUSKYCI_API_GITHUB_TOKEN is used
+// for the owners it does not list.
+func (dF DefaultConfig) GetGitHubOwnerTokens() map[string]string {
+	ownerTokens := map[string]string{}
+	for _, entry := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITHUB_TOKENS"), ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		owner, token := strings.ToLower(strings.TrimSpace(parts[0])), strings.TrimSpace(parts[1])
+		if owner != "" && token != "" {
+			ownerTokens[owner] = token
+		}
+	}
+	return ownerTokens
+}
+
+// GetGitHubMaxAnnotations returns how many findings of an analysis are
+// annotated in its Check Run. It depends on HUSKYCI_API_GITHUB_MAX_ANNOTATIONS
+// and is 50, the most GitHub accepts, by default.
+func (dF DefaultConfig) GetGitHubMaxAnnotations() int {
+	maxAnnotations, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITHUB_MAX_ANNOTATIONS"))
+	if err != nil || maxAnnotations < 0 || maxAnnotations > MaxGitHubAnnotations {
+		return MaxGitHubAnnotations
+	}
+	return maxAnnotations
+}
+
+// getGitLabConfig depends on HUSKYCI_API_GITLAB_URL, the URL of a GitLab instance
+// such as https://gitlab.example.com, and HUSKYCI_API_GITLAB_TOKEN, a token allowed
+// to set commit statuses and comment merge requests.
+func (dF DefaultConfig) getGitLabConfig() *GitLabConfig {
+	return &GitLabConfig{
+		URL:   strings.TrimSuffix(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITLAB_URL"), "/"),
+		Token: dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GITLAB_TOKEN"),
+	}
+}
+
+// GetDockerMaxImageAge returns how old, since it was created, a cached
+// image can be before huskyCI pulls it again. It depends on
+// HUSKYCI_DOCKERAPI_MAX_IMAGE_AGE, in seconds, and images are never
+// pulled again if it is not set.
+func (dF DefaultConfig) GetDockerMaxImageAge() time.Duration {
+	maxAge, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_MAX_IMAGE_AGE"))
+	if err != nil || maxAge <= 0 {
+		return 0
+	}
+	return time.Duration(maxAge) * time.Second
+}
+
+// getBitbucketConfig depends on HUSKYCI_API_BITBUCKET_URL, the URL of a Bitbucket
+// Server, or Bitbucket Cloud if it is not set, and either HUSKYCI_API_BITBUCKET_USERNAME
+// and HUSKYCI_API_BITBUCKET_APP_PASSWORD or HUSKYCI_API_BITBUCKET_TOKEN.
+func (dF DefaultConfig) getBitbucketConfig() *BitbucketConfig {
+	return &BitbucketConfig{
+		URL:         strings.TrimSuffix(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_BITBUCKET_URL"), "/"),
+		Username:    dF.Caller.GetEnvironmentVariable("HUSKYCI_API_BITBUCKET_USERNAME"),
+		AppPassword: dF.Caller.GetEnvironmentVariable("HUSKYCI_API_BITBUCKET_APP_PASSWORD"),
+		Token:       dF.Caller.GetEnvironmentVariable("HUSKYCI_API_BITBUCKET_TOKEN"),
+	}
+}
+
+// getJIRAConfig depends on HUSKYCI_JIRA_URL, HUSKYCI_JIRA_PROJECT, the key of the
+// project issues are created in, and either HUSKYCI_JIRA_EMAIL and HUSKYCI_JIRA_TOKEN
+// for JIRA Cloud or HUSKYCI_JIRA_USERNAME and HUSKYCI_JIRA_PASSWORD for JIRA Server.
+// HUSKYCI_JIRA_MIN_SEVERITY is the lowest severity of a finding opened as an issue,
+// and HIGH if it is not set.
+func (dF DefaultConfig) getJIRAConfig() *JIRAConfig {
+	minSeverity := strings.ToUpper(dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_MIN_SEVERITY"))
+	switch minSeverity {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW":
+	default:
+		minSeverity = "HIGH"
+	}
+	return &JIRAConfig{
+		URL:         strings.TrimSuffix(dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_URL"), "/"),
+		Project:     dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_PROJECT"),
+		Email:       dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_EMAIL"),
+		Token:       dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_TOKEN"),
+		Username:    dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_USERNAME"),
+		Password:    dF.Caller.GetEnvironmentVariable("HUSKYCI_JIRA_PASSWORD"),
+		MinSeverity: minSeverity,
+	}
+}
+
+// GetAnalysisRetention returns how long after they finish analyses keep
+// their raw output in the database. It depends on
+// HUSKYCI_ANALYSIS_RETENTION_DAYS and is 90 days if it is not set.
+func (dF DefaultConfig) GetAnalysisRetention() time.Duration {
+	days, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_ANALYSIS_RETENTION_DAYS"))
+	if err != nil || days <= 0 {
+		days = 90
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetAnalysisMaxAge returns how long after they finish analyses are
+// deleted. It depends on HUSKYCI_ANALYSIS_MAX_AGE_DAYS and is 0, so
+// analyses are never deleted, if it is not set.
+func (dF DefaultConfig) GetAnalysisMaxAge() time.Duration {
+	days, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_ANALYSIS_MAX_AGE_DAYS"))
+	if err != nil || days <= 0 {
+		return 0
+	}
+	return time.Duration(days) * 24 * time.Hour
+}
+
+// GetAnalysisKeepPerRepository returns how many of the most recent
+// analyses of each repository are kept however old they are. It
+// depends on HUSKYCI_ANALYSIS_KEEP_PER_REPOSITORY and is 10 by default.
+func (dF DefaultConfig) GetAnalysisKeepPerRepository() int {
+	keep, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_ANALYSIS_KEEP_PER_REPOSITORY"))
+	if err != nil || keep < 0 {
+		return 10
+	}
+	return keep
+}
+
+// getArchiveConfig depends on HUSKYCI_ARCHIVE_BACKEND and, for the s3 and gcs
+// backends, HUSKYCI_ARCHIVE_BUCKET, HUSKYCI_ARCHIVE_ACCESS_KEY and
+// HUSKYCI_ARCHIVE_SECRET_KEY, with the optional HUSKYCI_ARCHIVE_ENDPOINT and
+// HUSKYCI_ARCHIVE_REGION, or, for the file backend, HUSKYCI_ARCHIVE_PATH.
+func (dF DefaultConfig) getArchiveConfig() *ArchiveConfig {
+	return &ArchiveConfig{
+		Backend:   strings.ToLower(dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_BACKEND")),
+		Bucket:    dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_BUCKET"),
+		Endpoint:  strings.TrimSuffix(dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_ENDPOINT"), "/"),
+		Region:    dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_REGION"),
+		AccessKey: dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_ACCESS_KEY"),
+		SecretKey: dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_SECRET_KEY"),
+		Path:      dF.Caller.GetEnvironmentVariable("HUSKYCI_ARCHIVE_PATH"),
+	}
+}
+
 func (dF DefaultConfig) getDBConfig() *DBConfig {
 	return &DBConfig{
 		Address:         dF.Caller.GetEnvironmentVariable("HUSKYCI_DATABASE_DB_ADDR"),
@@ -213,6 +619,26 @@ func (dF DefaultConfig) getDBConfig() *DBConfig {
 	}
 }
 
+// getRedisConfig returns Redis configuration. Redis is optional:
+// if HUSKYCI_REDIS_ADDR is not set, nothing is cached.
+func (dF DefaultConfig) getRedisConfig() *RedisConfig {
+	return &RedisConfig{
+		Address:  dF.Caller.GetEnvironmentVariable("HUSKYCI_REDIS_ADDR"),
+		Password: dF.Caller.GetEnvironmentVariable("HUSKYCI_REDIS_PASSWORD"),
+		DB:       dF.GetRedisDB(),
+	}
+}
+
+// GetRedisDB returns the Redis database number. It
+// depends on HUSKYCI_REDIS_DB and defaults to 0.
+func (dF DefaultConfig) GetRedisDB() int {
+	redisDB, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_REDIS_DB"))
+	if err != nil || redisDB < 0 {
+		return 0
+	}
+	return redisDB
+}
+
 // GetMaxOpenConns returns the maximum number
 // of DB opened connections. It depends on an env
 // called HUSKYCI_DATABASE_DB_MAX_OPEN_CONNS.
@@ -250,7 +676,7 @@ func (dF DefaultConfig) GetConnMaxLifetime() time.Duration {
 	return time.Hour * time.Duration(connMaxLifetime)
 }
 
-//GetDBPort returns the port where DB
+// GetDBPort returns the port where DB
 // will be listening to. It depends on an env
 // called HUSKYCI_DATABASE_DB_PORT.
 func (dF DefaultConfig) GetDBPort() int {
@@ -289,15 +715,31 @@ func (dF DefaultConfig) getDockerHostsConfig() *DockerHostsConfig {
 	dockerHostsAddressesEnv := dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_ADDR")
 	dockerHostsAddresses := strings.Split(dockerHostsAddressesEnv, " ")
 	dockerHostsPathCertificates := dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_CERT_PATH")
+	// an extra CA bundle, such as the one of a corporate CA, trusted besides the system ones
+	dockerHostsCAPath := dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_CA_PATH")
 	return &DockerHostsConfig{
 		Address:         dockerHostsAddresses[0],
 		DockerAPIPort:   dockerAPIPort,
 		PathCertificate: dockerHostsPathCertificates,
+		CAPath:          dockerHostsCAPath,
 		Host:            fmt.Sprintf("%s:%d", dockerHostsAddresses[0], dockerAPIPort),
 		TLSVerify:       dF.GetDockerAPITLSVerify(),
+		MaxContainers:   dF.GetDockerAPIMaxContainers(),
+		ClientPoolSize:  dF.GetDockerClientPoolSize(),
+		PullInterval:    dF.GetDockerPullInterval(),
+		PullTimeout:     dF.GetDockerPullTimeout(),
+		MaxImageAge:     dF.GetDockerMaxImageAge(),
+		RequestTimeout:  dF.GetDockerRequestTimeout(),
 	}
 }
 
+// GetDockerHostsAddresses returns the address of every Docker
+// host, as listed, separated by spaces, in HUSKYCI_DOCKERAPI_ADDR.
+// Analyses only run in the first one.
+func (dF DefaultConfig) GetDockerHostsAddresses() []string {
+	return strings.Fields(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_ADDR"))
+}
+
 // GetDockerAPIPort will return the port number
 // where Docker API will be listening to. This
 // depends on HUSKYCI_DOCKERAPI_PORT.
@@ -322,6 +764,479 @@ func (dF DefaultConfig) GetDockerAPITLSVerify() int {
 	return 1
 }
 
+// GetDockerAPIMaxContainers returns how many containers
+// huskyCI runs at the same time in each Docker host. It
+// depends on HUSKYCI_DOCKERAPI_MAX_CONTAINERS and defaults
+// to 10. If it is 0, the limit is sized from the number of
+// CPUs of the Docker host.
+func (dF DefaultConfig) GetDockerAPIMaxContainers() int {
+	maxContainers, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_MAX_CONTAINERS"))
+	if err != nil || maxContainers < 0 {
+		return 10
+	}
+	return maxContainers
+}
+
+// GetDockerClientPoolSize returns how many idle Docker API
+// clients are kept to be reused by the next containers. It
+// depends on HUSKYCI_DOCKER_CLIENT_POOL_SIZE and defaults to 10.
+func (dF DefaultConfig) GetDockerClientPoolSize() int {
+	poolSize, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKER_CLIENT_POOL_SIZE"))
+	if err != nil || poolSize <= 0 {
+		return DefaultDockerClientPoolSize
+	}
+	return poolSize
+}
+
+// GetDockerPullInterval returns how long, on average, huskyCI waits
+// between attempts to pull an image. It depends on
+// HUSKYCI_DOCKERAPI_PULL_INTERVAL, in seconds, and defaults to 15.
+func (dF DefaultConfig) GetDockerPullInterval() time.Duration {
+	interval, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_PULL_INTERVAL"))
+	if err != nil || interval <= 0 {
+		return DefaultDockerPullInterval
+	}
+	return time.Duration(interval) * time.Second
+}
+
+// GetDockerPullTimeout returns how long huskyCI tries to pull an
+// image before giving up. It depends on HUSKYCI_DOCKERAPI_PULL_TIMEOUT,
+// in seconds, and defaults to 900.
+func (dF DefaultConfig) GetDockerPullTimeout() time.Duration {
+	timeout, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_PULL_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return DefaultDockerPullTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// GetDockerRequestTimeout returns how long a single call to the
+// Docker API, such as creating a container, can take before it
+// fails. It depends on HUSKYCI_DOCKERAPI_REQUEST_TIMEOUT, in
+// seconds, and defaults to 30.
+func (dF DefaultConfig) GetDockerRequestTimeout() time.Duration {
+	timeout, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_DOCKERAPI_REQUEST_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return DefaultDockerRequestTimeout
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// GetDependencyCheckFailSeverity returns the lowest severity
+// (LOW, MEDIUM or HIGH) of a Dependency-Check vulnerability that
+// fails an analysis. Less severe ones are reported as warnings.
+// It depends on HUSKYCI_DEPENDENCYCHECK_FAIL_SEVERITY and
+// defaults to MEDIUM, as it happens to every other securityTest.
+func (dF DefaultConfig) GetDependencyCheckFailSeverity() string {
+	severity := strings.ToUpper(dF.Caller.GetEnvironmentVariable("HUSKYCI_DEPENDENCYCHECK_FAIL_SEVERITY"))
+	switch severity {
+	case "LOW", "MEDIUM", "HIGH":
+		return severity
+	}
+	return "MEDIUM"
+}
+
+// GetGenericFailSeverity returns the lowest severity (LOW, MEDIUM
+// or HIGH) of a finding of a custom securityTest parsed by the generic
+// parser that fails an analysis. Less severe ones are reported as warnings.
+// It depends on HUSKYCI_GENERIC_FAIL_SEVERITY and defaults to MEDIUM.
+func (dF DefaultConfig) GetGenericFailSeverity() string {
+	severity := strings.ToUpper(dF.Caller.GetEnvironmentVariable("HUSKYCI_GENERIC_FAIL_SEVERITY"))
+	switch severity {
+	case "LOW", "MEDIUM", "HIGH":
+		return severity
+	}
+	return "MEDIUM"
+}
+
+// GetHadolintFailLevel returns the lowest level (error, warning,
+// info or style) of a Hadolint finding that fails an analysis.
+// Less severe ones are reported as warnings. It depends on
+// HUSKYCI_HADOLINT_FAIL_LEVEL and defaults to error.
+func (dF DefaultConfig) GetHadolintFailLevel() string {
+	level := strings.ToLower(dF.Caller.GetEnvironmentVariable("HUSKYCI_HADOLINT_FAIL_LEVEL"))
+	switch level {
+	case "error", "warning", "info", "style":
+		return level
+	}
+	return "error"
+}
+
+// GetCorrelateStrategy returns how findings of different securityTools
+// are correlated: "exact" matches only the same line, while "fuzzy"
+// also matches findings up to three lines apart.
+// It depends on HUSKYCI_CORRELATE_STRATEGY and defaults to fuzzy.
+func (dF DefaultConfig) GetCorrelateStrategy() string {
+	strategy := strings.ToLower(dF.Caller.GetEnvironmentVariable("HUSKYCI_CORRELATE_STRATEGY"))
+	if strategy == "exact" {
+		return strategy
+	}
+	return "fuzzy"
+}
+
+// GetAnalysisDeadline returns how long an analysis can run
+// before it is finalized with an error result, as some of its
+// containers will never report. It depends on
+// HUSKYCI_API_ANALYSIS_DEADLINE, in seconds, and defaults to 2 hours.
+func (dF DefaultConfig) GetAnalysisDeadline() time.Duration {
+	deadline, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_ANALYSIS_DEADLINE"))
+	if err != nil || deadline <= 0 {
+		return 2 * time.Hour
+	}
+	return time.Duration(deadline) * time.Second
+}
+
+// GetAnalysisTimeout returns how long an analysis can run before its remaining
+// securityTests are canceled and it is finalized with a timeout status. It depends on
+// HUSKYCI_API_ANALYSIS_TIMEOUT, in seconds, defaults to 1 hour and is never
+// longer than the analysis deadline.
+func (dF DefaultConfig) GetAnalysisTimeout() time.Duration {
+	analysisTimeout := time.Hour
+	timeout, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_ANALYSIS_TIMEOUT"))
+	if err == nil && timeout > 0 {
+		analysisTimeout = time.Duration(timeout) * time.Second
+	}
+	if deadline := dF.GetAnalysisDeadline(); analysisTimeout > deadline {
+		return deadline
+	}
+	return analysisTimeout
+}
+
+// defaultRetryableErrors are the errors of a Docker API that is briefly unavailable.
+var defaultRetryableErrors = []string{
+	"connection refused",
+	"connection reset by peer",
+	"context deadline exceeded",
+	"i/o timeout",
+	"TLS handshake timeout",
+}
+
+// GetAnalysisRetryPolicy returns how an analysis that could not run
+// due to a transient error is retried. It runs up to
+// HUSKYCI_API_ANALYSIS_MAX_ATTEMPTS times, 2 by default, while its error
+// contains one of HUSKYCI_API_ANALYSIS_RETRYABLE_ERRORS, a comma-separated
+// list that defaults to the errors of an unavailable Docker API.
+func (dF DefaultConfig) GetAnalysisRetryPolicy() types.RetryPolicy {
+	maxAttempts, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_ANALYSIS_MAX_ATTEMPTS"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 2
+	}
+	retryableErrors := []string{}
+	for _, retryableError := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_ANALYSIS_RETRYABLE_ERRORS"), ",") {
+		if retryableError = strings.TrimSpace(retryableError); retryableError != "" {
+			retryableErrors = append(retryableErrors, retryableError)
+		}
+	}
+	if len(retryableErrors) == 0 {
+		retryableErrors = defaultRetryableErrors
+	}
+	return types.RetryPolicy{MaxAttempts: maxAttempts, RetryableErrors: retryableErrors}
+}
+
+// GetShutdownTimeout returns how long huskyCI waits for the analyses
+// it is running to finish once it is asked to stop, before it interrupts
+// them. It depends on HUSKYCI_API_SHUTDOWN_TIMEOUT, in seconds, and
+// defaults to 1 minute.
+func (dF DefaultConfig) GetShutdownTimeout() time.Duration {
+	timeout, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SHUTDOWN_TIMEOUT"))
+	if err != nil || timeout <= 0 {
+		return time.Minute
+	}
+	return time.Duration(timeout) * time.Second
+}
+
+// GetPassedOutputMaxSize returns how many bytes of the output of a
+// passed container are stored, as the ones that failed keep more of it
+// for triage. It depends on HUSKYCI_API_PASSED_OUTPUT_MAX_SIZE and
+// defaults to 4096.
+func (dF DefaultConfig) GetPassedOutputMaxSize() int {
+	maxSize, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_PASSED_OUTPUT_MAX_SIZE"))
+	if err != nil || maxSize <= 0 {
+		return 4096
+	}
+	return maxSize
+}
+
+// GetSecurityTestParallelism returns how many securityTests of a single analysis
+// can run at the same time. It depends on HUSKYCI_API_SECURITYTEST_PARALLELISM
+// and defaults to 4.
+func (dF DefaultConfig) GetSecurityTestParallelism() int {
+	parallelism, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SECURITYTEST_PARALLELISM"))
+	if err != nil || parallelism <= 0 {
+		return 4
+	}
+	return parallelism
+}
+
+// getLanguageToolMapping returns the securityTests that run for each
+// language, as set in languageToolMapping of config.yaml. Languages are
+// lower-cased, as they are read by Viper.
+func (dF DefaultConfig) getLanguageToolMapping() map[string][]string {
+	languageToolMapping := map[string][]string{}
+	for language, securityTests := range dF.Caller.GetStringMapStringSliceFromConfigFile("languageToolMapping") {
+		languageToolMapping[strings.ToLower(language)] = securityTests
+	}
+	return languageToolMapping
+}
+
+// getSLA returns how long each securityTest is expected to take, as set in sla
+// of config.yaml using Go durations such as "5m". Invalid durations are ignored.
+func (dF DefaultConfig) getSLA() map[string]time.Duration {
+	sla := map[string]time.Duration{}
+	for securityTestName, value := range dF.Caller.GetStringMapStringFromConfigFile("sla") {
+		duration, err := time.ParseDuration(value)
+		if err != nil || duration <= 0 {
+			continue
+		}
+		sla[strings.ToLower(securityTestName)] = duration
+	}
+	return sla
+}
+
+// GetSlackWebhookURL returns the Slack incoming webhook used to send
+// alerts. It depends on HUSKYCI_API_SLACK_WEBHOOK_URL and Slack
+// alerts are not sent if it is not set.
+func (dF DefaultConfig) GetSlackWebhookURL() string {
+	return dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SLACK_WEBHOOK_URL")
+}
+
+// GetSlackChannel returns the Slack channel failed analyses are notified to, instead of
+// the default one of the webhook. It depends on HUSKYCI_API_SLACK_CHANNEL.
+func (dF DefaultConfig) GetSlackChannel() string {
+	return dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SLACK_CHANNEL")
+}
+
+// GetSlackMinSeverity returns the lowest severity of a finding that makes a failed analysis
+// be notified on Slack: CRITICAL, HIGH, MEDIUM or LOW. It depends on
+// HUSKYCI_API_SLACK_MIN_SEVERITY and failed analyses are not notified if it is not set.
+func (dF DefaultConfig) GetSlackMinSeverity() string {
+	minSeverity := strings.ToUpper(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SLACK_MIN_SEVERITY"))
+	switch minSeverity {
+	case "CRITICAL", "HIGH", "MEDIUM", "LOW":
+		return minSeverity
+	}
+	return ""
+}
+
+// GetShareSecret returns the key used to sign the URLs of shared analyses.
+// It depends on HUSKYCI_SHARE_SECRET and analyses can not be shared if it is not set.
+func (dF DefaultConfig) GetShareSecret() string {
+	return dF.Caller.GetEnvironmentVariable("HUSKYCI_SHARE_SECRET")
+}
+
+// GetIntegrityKey returns the key used to sign finished analyses. It depends on
+// HUSKYCI_INTEGRITY_KEY and analyses are not signed if it is not set.
+func (dF DefaultConfig) GetIntegrityKey() string {
+	return dF.Caller.GetEnvironmentVariable("HUSKYCI_INTEGRITY_KEY")
+}
+
+// GetAdvisoryDBURLs returns the mirror of the advisory database of each securityTest that
+// uses one instead of its public database. It depends on HUSKYCI_ADVISORY_DB_URLS, a comma
+// separated list of securityTest=URL, and entries without a securityTest or URL are ignored.
+func (dF DefaultConfig) GetAdvisoryDBURLs() map[string]string {
+	advisoryDBURLs := map[string]string{}
+	for _, entry := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_ADVISORY_DB_URLS"), ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		securityTestName, URL := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		if securityTestName != "" && URL != "" {
+			advisoryDBURLs[securityTestName] = URL
+		}
+	}
+	return advisoryDBURLs
+}
+
+// GetMinConfidence returns the lowest confidence (LOW, MEDIUM or HIGH) of a finding
+// that fails an analysis, for each securityTest whose findings have one, such as gosec,
+// bandit, brakeman and spotbugs. Findings of less confidence are reported as warnings,
+// whatever their severity. It depends on HUSKYCI_MIN_CONFIDENCE, a comma separated list
+// of securityTest=confidence, and invalid entries are ignored.
+func (dF DefaultConfig) GetMinConfidence() map[string]string {
+	minConfidence := map[string]string{}
+	for _, entry := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_MIN_CONFIDENCE"), ",") {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		securityTestName, confidence := strings.TrimSpace(parts[0]), strings.ToUpper(strings.TrimSpace(parts[1]))
+		switch confidence {
+		case "LOW", "MEDIUM", "HIGH":
+			if securityTestName != "" {
+				minConfidence[securityTestName] = confidence
+			}
+		}
+	}
+	return minConfidence
+}
+
+// GetPluginDir returns the directory whose .so files are loaded as parser plugins.
+// It depends on HUSKYCI_PLUGIN_DIR and no plugin is loaded if it is not set.
+func (dF DefaultConfig) GetPluginDir() string {
+	return dF.Caller.GetEnvironmentVariable("HUSKYCI_PLUGIN_DIR")
+}
+
+// GetAllowedEnvKeys returns the environment variables an analysis request may set in
+// its containers. It depends on HUSKYCI_ALLOWED_ENV_KEYS, a comma separated list, and
+// no environment variable can be set if it is not set.
+func (dF DefaultConfig) GetAllowedEnvKeys() []string {
+	allowedEnvKeys := []string{}
+	for _, key := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_ALLOWED_ENV_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			allowedEnvKeys = append(allowedEnvKeys, key)
+		}
+	}
+	return allowedEnvKeys
+}
+
+// GetOutputSecrets returns the secrets redacted from the output of containers
+// before it is stored: the private SSH key and the HTTPS token of git, and the
+// values of the environment variables listed in HUSKYCI_API_SECRET_ENV_KEYS, a
+// comma separated list, such as the password of a registry.
+func (dF DefaultConfig) GetOutputSecrets() []string {
+	secretEnvKeys := []string{"HUSKYCI_API_GIT_PRIVATE_SSH_KEY", "HUSKYCI_API_GIT_HTTPS_TOKEN"}
+	for _, key := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SECRET_ENV_KEYS"), ",") {
+		if key = strings.TrimSpace(key); key != "" {
+			secretEnvKeys = append(secretEnvKeys, key)
+		}
+	}
+	secrets := []string{}
+	for _, key := range secretEnvKeys {
+		if secret := dF.Caller.GetEnvironmentVariable(key); secret != "" {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets
+}
+
+// GetGitHTTPSTokenHosts returns the hosts, such as "github.com", HUSKYCI_API_GIT_HTTPS_TOKEN
+// is sent to. It depends on HUSKYCI_API_GIT_HTTPS_TOKEN_HOSTS, a comma separated list, and the
+// token is not sent to any host if it is not set.
+func (dF DefaultConfig) GetGitHTTPSTokenHosts() []string {
+	tokenHosts := []string{}
+	for _, host := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_GIT_HTTPS_TOKEN_HOSTS"), ",") {
+		if host = strings.TrimSpace(host); host != "" {
+			tokenHosts = append(tokenHosts, host)
+		}
+	}
+	return tokenHosts
+}
+
+// GetSuppressionReviewers returns the usernames of the API users, such as team leads, that
+// approve or reject suppression requests along with admin. It depends on
+// HUSKYCI_API_SUPPRESSION_REVIEWERS, a comma separated list, and only admin reviews them if
+// it is not set.
+func (dF DefaultConfig) GetSuppressionReviewers() []string {
+	reviewers := []string{}
+	for _, reviewer := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_SUPPRESSION_REVIEWERS"), ",") {
+		if reviewer = strings.TrimSpace(reviewer); reviewer != "" {
+			reviewers = append(reviewers, reviewer)
+		}
+	}
+	return reviewers
+}
+
+// GetCallbackAllowlist returns the hosts and CIDRs the callbacks of analyses can be posted
+// to. It depends on HUSKYCI_API_CALLBACK_ALLOWLIST, a comma separated list, and analysis
+// requests with a callback URL are refused if it is not set.
+func (dF DefaultConfig) GetCallbackAllowlist() []string {
+	callbackAllowlist := []string{}
+	for _, entry := range strings.Split(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_CALLBACK_ALLOWLIST"), ",") {
+		if entry = strings.TrimSpace(entry); entry != "" {
+			callbackAllowlist = append(callbackAllowlist, entry)
+		}
+	}
+	return callbackAllowlist
+}
+
+// GetCallbackSecret returns the key used to sign the callbacks of analyses. It depends
+// on HUSKYCI_API_CALLBACK_SECRET and callbacks are not signed if it is not set.
+func (dF DefaultConfig) GetCallbackSecret() string {
+	return dF.Caller.GetEnvironmentVariable("HUSKYCI_API_CALLBACK_SECRET")
+}
+
+// GetCallbackMaxAttempts returns how many times the callback of an analysis is posted
+// before giving up. It depends on HUSKYCI_API_CALLBACK_MAX_ATTEMPTS and is 5 by default.
+func (dF DefaultConfig) GetCallbackMaxAttempts() int {
+	maxAttempts, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_CALLBACK_MAX_ATTEMPTS"))
+	if err != nil || maxAttempts <= 0 {
+		maxAttempts = 5
+	}
+	return maxAttempts
+}
+
+// GetRateLimit returns how many analyses a client, by access token or IP, can request
+// in the window of the rate limit set by envVar: HUSKYCI_API_RATE_LIMIT_BURST for a
+// minute or HUSKYCI_API_RATE_LIMIT_SUSTAINED for an hour. It is 0, unlimited, by default.
+func (dF DefaultConfig) GetRateLimit(envVar string) int {
+	limit, err := dF.Caller.ConvertStrToInt(dF.Caller.GetEnvironmentVariable(envVar))
+	if err != nil || limit < 0 {
+		return 0
+	}
+	return limit
+}
+
+// GetPublicURL returns the URL huskyCI is reached at, without a trailing slash. It depends
+// on HUSKYCI_API_PUBLIC_URL and callbacks do not link to their analysis if it is not set.
+func (dF DefaultConfig) GetPublicURL() string {
+	return strings.TrimSuffix(dF.Caller.GetEnvironmentVariable("HUSKYCI_API_PUBLIC_URL"), "/")
+}
+
+// GetEnforceImmutableTags returns whether securityTest images must not be run by a
+// mutable tag, such as latest or stable. It depends on HUSKYCI_ENFORCE_IMMUTABLE_TAGS.
+func (dF DefaultConfig) GetEnforceImmutableTags() bool {
+	option := dF.Caller.GetEnvironmentVariable("HUSKYCI_ENFORCE_IMMUTABLE_TAGS")
+	return strings.EqualFold(option, "true") || option == "1"
+}
+
+// GetAllowVulnerableImages returns whether images of securityTests with CRITICAL
+// vulnerabilities found by Trivy can still be pulled. It depends on
+// HUSKYCI_ALLOW_VULNERABLE_IMAGES.
+func (dF DefaultConfig) GetAllowVulnerableImages() bool {
+	option := dF.Caller.GetEnvironmentVariable("HUSKYCI_ALLOW_VULNERABLE_IMAGES")
+	return strings.EqualFold(option, "true") || option == "1"
+}
+
+// GetESLintFailOnWarnings returns whether the warnings of ESLint fail an analysis
+// as its errors do. It depends on HUSKYCI_ESLINT_FAIL_ON_WARNINGS.
+func (dF DefaultConfig) GetESLintFailOnWarnings() bool {
+	option := dF.Caller.GetEnvironmentVariable("HUSKYCI_ESLINT_FAIL_ON_WARNINGS")
+	return strings.EqualFold(option, "true") || option == "1"
+}
+
+// GetScanCache returns whether securityTests reuse the findings of a previous analysis of
+// the same commit by the same image instead of scanning it again. It depends on HUSKYCI_SCAN_CACHE.
+func (dF DefaultConfig) GetScanCache() bool {
+	option := dF.Caller.GetEnvironmentVariable("HUSKYCI_SCAN_CACHE")
+	return strings.EqualFold(option, "true") || option == "1"
+}
+
+// getExcludedPaths returns the paths, or file globs, whose findings are not
+// reported by any securityTest unless a repository sets its own, as set in
+// excludedPaths of config.yaml.
+func (dF DefaultConfig) getExcludedPaths() []string {
+	return dF.Caller.GetStringSliceFromConfigFile("excludedPaths")
+}
+
+// getGitleaksProtectedTags returns the tags of the gitleaks rules, such as private keys,
+// an analysis request can not allowlist, as set in gitleaksProtectedTags of config.yaml.
+func (dF DefaultConfig) getGitleaksProtectedTags() []string {
+	return dF.Caller.GetStringSliceFromConfigFile("gitleaksProtectedTags")
+}
+
+// getInternalPackagePrefixes returns the npm scopes, such as "@example/", and name prefixes of
+// internal packages checked against public registries, as set in internalPackagePrefixes of config.yaml.
+func (dF DefaultConfig) getInternalPackagePrefixes() []string {
+	return dF.Caller.GetStringSliceFromConfigFile("internalPackagePrefixes")
+}
+
+// getCustomSecurityTestImages returns the images, or image prefixes ending in "/",
+// custom securityTests can run, as set in customSecurityTestImages of config.yaml.
+func (dF DefaultConfig) getCustomSecurityTestImages() []string {
+	return dF.Caller.GetStringSliceFromConfigFile("customSecurityTestImages")
+}
+
 func (dF DefaultConfig) getSecurityTestConfig(securityTestName string) *types.SecurityTest {
 	return &types.SecurityTest{
 		Name:             dF.Caller.GetStringFromConfigFile(fmt.Sprintf("%s.name", securityTestName)),
@@ -332,6 +1247,11 @@ func (dF DefaultConfig) getSecurityTestConfig(securityTestName string) *types.Se
 		Language:         dF.Caller.GetStringFromConfigFile(fmt.Sprintf("%s.language", securityTestName)),
 		Default:          dF.Caller.GetBoolFromConfigFile(fmt.Sprintf("%s.default", securityTestName)),
 		TimeOutInSeconds: dF.Caller.GetIntFromConfigFile(fmt.Sprintf("%s.timeOutInSeconds", securityTestName)),
+		Dedup:            dF.Caller.GetBoolFromConfigFile(fmt.Sprintf("%s.dedup", securityTestName)),
+		User:             dF.Caller.GetStringFromConfigFile(fmt.Sprintf("%s.user", securityTestName)),
+		BlockingMode:     dF.Caller.GetStringFromConfigFile(fmt.Sprintf("%s.blockingMode", securityTestName)),
+		ExpectedVersion:  dF.Caller.GetStringFromConfigFile(fmt.Sprintf("%s.expectedVersion", securityTestName)),
+		DependsOn:        dF.Caller.GetStringSliceFromConfigFile(fmt.Sprintf("%s.dependsOn", securityTestName)),
 	}
 }
 
@@ -20,6 +20,9 @@ type FakeCaller struct {
 	expectedStringFromConfig     string
 	expectedBoolFromConfig       bool
 	expectedIntFromConfig        int
+	expectedStringMapFromConfig  map[string][]string
+	expectedSLAFromConfig        map[string]string
+	expectedSliceFromConfig      []string
 }
 
 func (fC *FakeCaller) ConvertStrToInt(str string) (int, error) {
@@ -46,6 +49,18 @@ func (fC *FakeCaller) GetIntFromConfigFile(value string) int {
 	return fC.expectedIntFromConfig
 }
 
+func (fC *FakeCaller) GetStringMapStringSliceFromConfigFile(value string) map[string][]string {
+	return fC.expectedStringMapFromConfig
+}
+
+func (fC *FakeCaller) GetStringMapStringFromConfigFile(value string) map[string]string {
+	return fC.expectedSLAFromConfig
+}
+
+func (fC *FakeCaller) GetStringSliceFromConfigFile(value string) []string {
+	return fC.expectedSliceFromConfig
+}
+
 func (fC *FakeCaller) GetTimeDurationInSeconds(duration int) time.Duration {
 	return time.Duration(duration) * time.Second
 }
@@ -53,171 +68,1076 @@ func (fC *FakeCaller) GetTimeDurationInSeconds(duration int) time.Duration {
 var _ = Describe("Context", func() {
 	Describe("GetAPIPort", func() {
 		Context("When ConvertStrToInt returns an error", func() {
-			It("Should return the expected 8888 port", func() {
+			It("Should return the expected 8888 port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAPIPort()).To(Equal(8888))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid port", func() {
+			It("Should return the expected port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         1234,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAPIPort()).To(Equal(fakeCaller.expectedIntegerValue))
+			})
+		})
+	})
+	Describe("GetGRPCPort", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the expected 50051 port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGRPCPort()).To(Equal(50051))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid port", func() {
+			It("Should return the expected port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         1234,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGRPCPort()).To(Equal(fakeCaller.expectedIntegerValue))
+			})
+		})
+	})
+	Describe("GetAPIUseTLS", func() {
+		Context("When GetEnvironmentVariable returns a valid option", func() {
+			It("Should return a true boolean", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "True",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAPIUseTLS()).To(BeTrue())
+			})
+		})
+		Context("When GetEnvironmentVariable returns a not valid option", func() {
+			It("Should return a true boolean", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "Invalid",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAPIUseTLS()).To(BeFalse())
+			})
+		})
+	})
+	Describe("GetGrayLogIsDev", func() {
+		Context("When GetEnvironmentVariable returns valid option", func() {
+			It("Should return a false boolean", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "False",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGraylogIsDev()).To(BeFalse())
+			})
+		})
+		Context("When GetEnvironmentVariable returns invalid option", func() {
+			It("Should return a false boolean", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGraylogIsDev()).To(BeTrue())
+			})
+		})
+	})
+	Describe("GetDBPort", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return 27017 port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBPort()).To(Equal(27017))
+			})
+		})
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return 27017 port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         2222,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBPort()).To(Equal(fakeCaller.expectedIntegerValue))
+			})
+		})
+	})
+	Describe("GetDBTimeout", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return 60s of duration", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBTimeout()).To(Equal(time.Duration(60) * time.Second))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid timeout", func() {
+			It("Should return the expected duration", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         200,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBTimeout()).To(Equal(time.Duration(fakeCaller.expectedIntegerValue) * time.Second))
+			})
+		})
+	})
+	Describe("GetDBPoolLimit", func() {
+		Context("When ConvertStr returns an error", func() {
+			It("Should return a pool of connections with a size 1000", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         124,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBPoolLimit()).To(Equal(1000))
+			})
+		})
+		Context("When ConvertStr returns an negative value", func() {
+			It("Should return a pool of connections with a size 1000", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         -24,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBPoolLimit()).To(Equal(1000))
+			})
+		})
+		Context("When ConvertStr returns a valid value", func() {
+			It("Should return a pool of connections with the expected size", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         50,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDBPoolLimit()).To(Equal(fakeCaller.expectedIntegerValue))
+			})
+		})
+	})
+	Describe("GetDockerAPIPort", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return 2376 port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPIPort()).To(Equal(2376))
+			})
+		})
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return 2376 port", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         25000,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPIPort()).To(Equal(fakeCaller.expectedIntegerValue))
+			})
+		})
+	})
+	Describe("GetDockerHostsAddresses", func() {
+		Context("When HUSKYCI_DOCKERAPI_ADDR lists many Docker hosts", func() {
+			It("Should return each one of them", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: " dockerapi  dockerapi-2 ",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerHostsAddresses()).To(Equal([]string{"dockerapi", "dockerapi-2"}))
+			})
+		})
+	})
+	Describe("GetDockerClientPoolSize", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 10 clients", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerClientPoolSize()).To(Equal(10))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         4,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerClientPoolSize()).To(Equal(4))
+			})
+		})
+	})
+	Describe("GetDockerPullInterval", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 15 seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerPullInterval()).To(Equal(15 * time.Second))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it in seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         30,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerPullInterval()).To(Equal(30 * time.Second))
+			})
+		})
+	})
+	Describe("GetDockerMaxImageAge", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return zero so images are never pulled again", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerMaxImageAge()).To(BeZero())
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it in seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         86400,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerMaxImageAge()).To(Equal(24 * time.Hour))
+			})
+		})
+	})
+	Describe("GetAnalysisRetention", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 90 days", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisRetention()).To(Equal(90 * 24 * time.Hour))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it in days", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         30,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisRetention()).To(Equal(30 * 24 * time.Hour))
+			})
+		})
+	})
+	Describe("GetAnalysisMaxAge", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return 0, so analyses are never deleted", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisMaxAge()).To(Equal(time.Duration(0)))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it in days", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         365,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisMaxAge()).To(Equal(365 * 24 * time.Hour))
+			})
+		})
+	})
+	Describe("GetAnalysisKeepPerRepository", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 10", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisKeepPerRepository()).To(Equal(10))
+			})
+		})
+		Context("When ConvertStrToInt returns 0", func() {
+			It("Should return 0, so no analysis is kept", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisKeepPerRepository()).To(Equal(0))
+			})
+		})
+	})
+	Describe("GetDockerPullTimeout", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 15 minutes", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerPullTimeout()).To(Equal(15 * time.Minute))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it in seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         600,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerPullTimeout()).To(Equal(10 * time.Minute))
+			})
+		})
+	})
+	Describe("GetDockerRequestTimeout", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 30 seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerRequestTimeout()).To(Equal(30 * time.Second))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid value", func() {
+			It("Should return it in seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         5,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerRequestTimeout()).To(Equal(5 * time.Second))
+			})
+		})
+	})
+	Describe("GetDockerAPIMaxContainers", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 10 containers", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPIMaxContainers()).To(Equal(10))
+			})
+		})
+		Context("When ConvertStrToInt returns a negative number", func() {
+			It("Should return the default 10 containers", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         -1,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPIMaxContainers()).To(Equal(10))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         4,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPIMaxContainers()).To(Equal(4))
+			})
+		})
+	})
+	Describe("GetDockerAPITLSVerify", func() {
+		Context("When GetEnvironmentVariable returns a valid value", func() {
+			It("Should return 0", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "False",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPITLSVerify()).To(Equal(0))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an invalid value", func() {
+			It("Should return 1", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "Invalid",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDockerAPITLSVerify()).To(Equal(1))
+			})
+		})
+	})
+	Describe("GetDependencyCheckFailSeverity", func() {
+		Context("When GetEnvironmentVariable returns a valid severity", func() {
+			It("Should return it in upper case", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "high",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDependencyCheckFailSeverity()).To(Equal("HIGH"))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an invalid severity", func() {
+			It("Should return the default MEDIUM severity", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "critical",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetDependencyCheckFailSeverity()).To(Equal("MEDIUM"))
+			})
+		})
+	})
+	Describe("GetGenericFailSeverity", func() {
+		Context("When GetEnvironmentVariable returns a valid severity", func() {
+			It("Should return it in upper case", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "low",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGenericFailSeverity()).To(Equal("LOW"))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an invalid severity", func() {
+			It("Should return the default MEDIUM severity", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "nosec",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGenericFailSeverity()).To(Equal("MEDIUM"))
+			})
+		})
+	})
+	Describe("GetAdvisoryDBURLs", func() {
+		Context("When GetEnvironmentVariable returns a list of securityTest=URL", func() {
+			It("Should return the URL of each securityTest", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "npmaudit=https://npm.example.com, safety = https://safety.example.com/db?token=a=b,invalid,gosec=",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAdvisoryDBURLs()).To(Equal(map[string]string{
+					"npmaudit": "https://npm.example.com",
+					"safety":   "https://safety.example.com/db?token=a=b",
+				}))
+			})
+		})
+	})
+	Describe("GetHadolintFailLevel", func() {
+		Context("When GetEnvironmentVariable returns a valid level", func() {
+			It("Should return it in lower case", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "Warning",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetHadolintFailLevel()).To(Equal("warning"))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an invalid level", func() {
+			It("Should return error", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "critical",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetHadolintFailLevel()).To(Equal("error"))
+			})
+		})
+	})
+	Describe("GetMinConfidence", func() {
+		Context("When GetEnvironmentVariable returns a list of securityTest=confidence", func() {
+			It("Should return the valid confidence of each securityTest", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "gosec=medium, brakeman = HIGH,bandit=certain,invalid,=LOW",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetMinConfidence()).To(Equal(map[string]string{
+					"gosec":    "MEDIUM",
+					"brakeman": "HIGH",
+				}))
+			})
+		})
+	})
+	Describe("GetScanCache", func() {
+		Context("When GetEnvironmentVariable returns true", func() {
+			It("Should return true", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "true",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetScanCache()).To(BeTrue())
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return false", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetScanCache()).To(BeFalse())
+			})
+		})
+	})
+	Describe("GetEnforceImmutableTags", func() {
+		Context("When GetEnvironmentVariable returns true", func() {
+			It("Should return true", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "true",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetEnforceImmutableTags()).To(BeTrue())
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return false", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetEnforceImmutableTags()).To(BeFalse())
+			})
+		})
+	})
+	Describe("GetAllowVulnerableImages", func() {
+		Context("When GetEnvironmentVariable returns true", func() {
+			It("Should return true", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "true",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAllowVulnerableImages()).To(BeTrue())
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return false", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAllowVulnerableImages()).To(BeFalse())
+			})
+		})
+	})
+	Describe("GetESLintFailOnWarnings", func() {
+		Context("When GetEnvironmentVariable returns true", func() {
+			It("Should return true", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "true",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetESLintFailOnWarnings()).To(BeTrue())
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return false", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetESLintFailOnWarnings()).To(BeFalse())
+			})
+		})
+	})
+	Describe("GetAllowedEnvKeys", func() {
+		Context("When GetEnvironmentVariable returns a comma separated list", func() {
+			It("Should return each one of its keys", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "SEMGREP_APP_TOKEN, NVD_API_KEY,",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAllowedEnvKeys()).To(Equal([]string{"SEMGREP_APP_TOKEN", "NVD_API_KEY"}))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return no key", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAllowedEnvKeys()).To(BeEmpty())
+			})
+		})
+	})
+	Describe("GetSlackMinSeverity", func() {
+		Context("When GetEnvironmentVariable returns a severity", func() {
+			It("Should return it in upper case", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "high",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetSlackMinSeverity()).To(Equal("HIGH"))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an unknown severity", func() {
+			It("Should return an empty string", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "urgent",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetSlackMinSeverity()).To(BeEmpty())
+			})
+		})
+	})
+	Describe("GetGitHTTPSTokenHosts", func() {
+		Context("When GetEnvironmentVariable returns a comma separated list", func() {
+			It("Should return each one of its hosts", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "github.com, gitlab.example.com,",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGitHTTPSTokenHosts()).To(Equal([]string{"github.com", "gitlab.example.com"}))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return no host", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGitHTTPSTokenHosts()).To(BeEmpty())
+			})
+		})
+	})
+	Describe("GetSuppressionReviewers", func() {
+		Context("When GetEnvironmentVariable returns a comma separated list", func() {
+			It("Should return each one of its usernames", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "alice, bob,",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetSuppressionReviewers()).To(Equal([]string{"alice", "bob"}))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return no reviewer", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetSuppressionReviewers()).To(BeEmpty())
+			})
+		})
+	})
+	Describe("GetCallbackAllowlist", func() {
+		Context("When GetEnvironmentVariable returns a comma separated list", func() {
+			It("Should return each one of its hosts and CIDRs", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "ci.example.com, 10.0.0.0/8,",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetCallbackAllowlist()).To(Equal([]string{"ci.example.com", "10.0.0.0/8"}))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an empty string", func() {
+			It("Should return no host", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetCallbackAllowlist()).To(BeEmpty())
+			})
+		})
+	})
+	Describe("GetCallbackMaxAttempts", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 5", func() {
 				fakeCaller := FakeCaller{
 					expectedIntegerValue:         0,
-					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetAPIPort()).To(Equal(8888))
+				Expect(config.GetCallbackMaxAttempts()).To(Equal(5))
 			})
 		})
-		Context("When ConvertStrToInt returns a valid port", func() {
-			It("Should return the expected port", func() {
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         1234,
+					expectedIntegerValue:         3,
 					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetAPIPort()).To(Equal(fakeCaller.expectedIntegerValue))
+				Expect(config.GetCallbackMaxAttempts()).To(Equal(3))
 			})
 		})
 	})
-	Describe("GetAPIUseTLS", func() {
-		Context("When GetEnvironmentVariable returns a valid option", func() {
-			It("Should return a true boolean", func() {
+	Describe("GetGitHubOwnerTokens", func() {
+		Context("When tokens are set by owner", func() {
+			It("Should map each owner, in lower case, to its token", func() {
 				fakeCaller := FakeCaller{
-					expectedEnvVar: "True",
+					expectedEnvVar: "Globocom=token1, other = token2,invalid,=token3",
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetAPIUseTLS()).To(BeTrue())
+				Expect(config.GetGitHubOwnerTokens()).To(Equal(map[string]string{"globocom": "token1", "other": "token2"}))
 			})
 		})
-		Context("When GetEnvironmentVariable returns a not valid option", func() {
-			It("Should return a true boolean", func() {
+	})
+	Describe("GetGitHubMaxAnnotations", func() {
+		Context("When ConvertStrToInt returns a value GitHub accepts", func() {
+			It("Should return it", func() {
 				fakeCaller := FakeCaller{
-					expectedEnvVar: "Invalid",
+					expectedIntegerValue:         10,
+					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetAPIUseTLS()).To(BeFalse())
+				Expect(config.GetGitHubMaxAnnotations()).To(Equal(10))
+			})
+		})
+		Context("When ConvertStrToInt returns more than GitHub accepts", func() {
+			It("Should return 50", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         100,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetGitHubMaxAnnotations()).To(Equal(50))
 			})
 		})
 	})
-	Describe("GetGrayLogIsDev", func() {
-		Context("When GetEnvironmentVariable returns valid option", func() {
-			It("Should return a false boolean", func() {
+	Describe("GetAnalysisRetryPolicy", func() {
+		Context("When neither max attempts nor retryable errors are set", func() {
+			It("Should retry errors of an unavailable Docker API once", func() {
 				fakeCaller := FakeCaller{
-					expectedEnvVar: "False",
+					expectedEnvVar:               "",
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetGraylogIsDev()).To(BeFalse())
+				policy := config.GetAnalysisRetryPolicy()
+				Expect(policy.MaxAttempts).To(Equal(2))
+				Expect(policy.RetryableErrors).To(ContainElement("connection refused"))
+				Expect(policy.RetryableErrors).To(ContainElement("context deadline exceeded"))
 			})
 		})
-		Context("When GetEnvironmentVariable returns invalid option", func() {
-			It("Should return a false boolean", func() {
+		Context("When retryable errors are set", func() {
+			It("Should split them by commas", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar:               "connection refused, EOF ,",
+					expectedIntegerValue:         3,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetAnalysisRetryPolicy()).To(Equal(types.RetryPolicy{
+					MaxAttempts:     3,
+					RetryableErrors: []string{"connection refused", "EOF"},
+				}))
+			})
+		})
+	})
+	Describe("GetOutputSecrets", func() {
+		Context("When no secret is set", func() {
+			It("Should return no secret", func() {
 				fakeCaller := FakeCaller{
 					expectedEnvVar: "",
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetGraylogIsDev()).To(BeTrue())
+				Expect(config.GetOutputSecrets()).To(BeEmpty())
+			})
+		})
+		Context("When GetEnvironmentVariable returns a secret", func() {
+			It("Should return it for git and each key of HUSKYCI_API_SECRET_ENV_KEYS", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "REGISTRY_PASSWORD",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetOutputSecrets()).To(Equal([]string{"REGISTRY_PASSWORD", "REGISTRY_PASSWORD", "REGISTRY_PASSWORD"}))
 			})
 		})
 	})
-	Describe("GetDBPort", func() {
+	Describe("GetCorrelateStrategy", func() {
+		Context("When GetEnvironmentVariable returns exact", func() {
+			It("Should return exact", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "EXACT",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetCorrelateStrategy()).To(Equal("exact"))
+			})
+		})
+		Context("When GetEnvironmentVariable returns an unknown strategy", func() {
+			It("Should return the default fuzzy strategy", func() {
+				fakeCaller := FakeCaller{
+					expectedEnvVar: "",
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetCorrelateStrategy()).To(Equal("fuzzy"))
+			})
+		})
+	})
+	Describe("GetRedisDB", func() {
 		Context("When ConvertStrToInt returns an error", func() {
-			It("Should return 27017 port", func() {
+			It("Should return the default 0 database", func() {
 				fakeCaller := FakeCaller{
 					expectedIntegerValue:         0,
-					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBPort()).To(Equal(27017))
+				Expect(config.GetRedisDB()).To(Equal(0))
 			})
 		})
-		Context("When ConvertStrToInt returns an error", func() {
-			It("Should return 27017 port", func() {
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         2222,
+					expectedIntegerValue:         2,
 					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBPort()).To(Equal(fakeCaller.expectedIntegerValue))
+				Expect(config.GetRedisDB()).To(Equal(2))
 			})
 		})
 	})
-	Describe("GetDBTimeout", func() {
+	Describe("GetAnalysisDeadline", func() {
 		Context("When ConvertStrToInt returns an error", func() {
-			It("Should return 60s of duration", func() {
+			It("Should return the default 2 hours", func() {
 				fakeCaller := FakeCaller{
 					expectedIntegerValue:         0,
-					expectedConvertStrToIntError: errors.New("Failed converting string to integer"),
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBTimeout()).To(Equal(time.Duration(60) * time.Second))
+				Expect(config.GetAnalysisDeadline()).To(Equal(2 * time.Hour))
 			})
 		})
-		Context("When ConvertStrToInt returns a valid timeout", func() {
-			It("Should return the expected duration", func() {
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it in seconds", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         200,
+					expectedIntegerValue:         600,
 					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBTimeout()).To(Equal(time.Duration(fakeCaller.expectedIntegerValue) * time.Second))
+				Expect(config.GetAnalysisDeadline()).To(Equal(10 * time.Minute))
 			})
 		})
 	})
-	Describe("GetDBPoolLimit", func() {
-		Context("When ConvertStr returns an error", func() {
-			It("Should return a pool of connections with a size 1000", func() {
+	Describe("GetAnalysisTimeout", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 1 hour", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         124,
+					expectedIntegerValue:         0,
 					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBPoolLimit()).To(Equal(1000))
+				Expect(config.GetAnalysisTimeout()).To(Equal(time.Hour))
 			})
 		})
-		Context("When ConvertStr returns an negative value", func() {
-			It("Should return a pool of connections with a size 1000", func() {
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it in seconds, as it is not longer than the analysis deadline", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         -24,
+					expectedIntegerValue:         600,
 					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBPoolLimit()).To(Equal(1000))
+				Expect(config.GetAnalysisTimeout()).To(Equal(10 * time.Minute))
 			})
 		})
-		Context("When ConvertStr returns a valid value", func() {
-			It("Should return a pool of connections with the expected size", func() {
+	})
+	Describe("GetShutdownTimeout", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 1 minute", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         50,
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetShutdownTimeout()).To(Equal(time.Minute))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it in seconds", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         120,
 					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDBPoolLimit()).To(Equal(fakeCaller.expectedIntegerValue))
+				Expect(config.GetShutdownTimeout()).To(Equal(2 * time.Minute))
 			})
 		})
 	})
-	Describe("GetDockerAPIPort", func() {
+	Describe("GetPassedOutputMaxSize", func() {
 		Context("When ConvertStrToInt returns an error", func() {
-			It("Should return 2376 port", func() {
+			It("Should return the default 4096", func() {
 				fakeCaller := FakeCaller{
 					expectedIntegerValue:         0,
 					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
@@ -225,43 +1145,83 @@ var _ = Describe("Context", func() {
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDockerAPIPort()).To(Equal(2376))
+				Expect(config.GetPassedOutputMaxSize()).To(Equal(4096))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         1024,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetPassedOutputMaxSize()).To(Equal(1024))
 			})
 		})
+	})
+	Describe("GetRateLimit", func() {
 		Context("When ConvertStrToInt returns an error", func() {
-			It("Should return 2376 port", func() {
+			It("Should return 0, unlimited", func() {
 				fakeCaller := FakeCaller{
-					expectedIntegerValue:         25000,
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetRateLimit("HUSKYCI_API_RATE_LIMIT_BURST")).To(Equal(0))
+			})
+		})
+		Context("When ConvertStrToInt returns a valid number", func() {
+			It("Should return it", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         30,
 					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDockerAPIPort()).To(Equal(fakeCaller.expectedIntegerValue))
+				Expect(config.GetRateLimit("HUSKYCI_API_RATE_LIMIT_BURST")).To(Equal(30))
 			})
 		})
 	})
-	Describe("GetDockerAPITLSVerify", func() {
-		Context("When GetEnvironmentVariable returns a valid value", func() {
-			It("Should return 0", func() {
+	Describe("GetSecurityTestParallelism", func() {
+		Context("When ConvertStrToInt returns an error", func() {
+			It("Should return the default 4", func() {
 				fakeCaller := FakeCaller{
-					expectedEnvVar: "False",
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: errors.New("Error during the convertion from string to integer"),
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDockerAPITLSVerify()).To(Equal(0))
+				Expect(config.GetSecurityTestParallelism()).To(Equal(4))
 			})
 		})
-		Context("When GetEnvironmentVariable returns an invalid value", func() {
-			It("Should return 1", func() {
+		Context("When ConvertStrToInt returns a number that is not positive", func() {
+			It("Should return the default 4", func() {
 				fakeCaller := FakeCaller{
-					expectedEnvVar: "Invalid",
+					expectedIntegerValue:         0,
+					expectedConvertStrToIntError: nil,
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
 				}
-				Expect(config.GetDockerAPITLSVerify()).To(Equal(1))
+				Expect(config.GetSecurityTestParallelism()).To(Equal(4))
+			})
+		})
+		Context("When ConvertStrToInt returns a positive number", func() {
+			It("Should return it", func() {
+				fakeCaller := FakeCaller{
+					expectedIntegerValue:         2,
+					expectedConvertStrToIntError: nil,
+				}
+				config := DefaultConfig{
+					Caller: &fakeCaller,
+				}
+				Expect(config.GetSecurityTestParallelism()).To(Equal(2))
 			})
 		})
 	})
@@ -289,6 +1249,9 @@ var _ = Describe("Context", func() {
 					expectedStringFromConfig:     "teste",
 					expectedBoolFromConfig:       true,
 					expectedIntFromConfig:        1234,
+					expectedStringMapFromConfig:  map[string][]string{"Python": {"bandit"}},
+					expectedSLAFromConfig:        map[string]string{"Bandit": "5m", "gosec": "invalid"},
+					expectedSliceFromConfig:      []string{"vendor", "*.pb.go"},
 				}
 				config := DefaultConfig{
 					Caller: &fakeCaller,
@@ -296,8 +1259,10 @@ var _ = Describe("Context", func() {
 				apiConfig, err := config.GetAPIConfig()
 				expectedConfig := &APIConfig{
 					Port:             fakeCaller.expectedIntegerValue,
-					Version:          "0.13.0",
-					ReleaseDate:      "2020-02-28",
+					GRPCPort:         fakeCaller.expectedIntegerValue,
+					Version:          "dev",
+					ReleaseDate:      "dev",
+					Commit:           "dev",
 					AllowOriginValue: fakeCaller.expectedEnvVar,
 					UseTLS:           true,
 					GitPrivateSSHKey: fakeCaller.expectedEnvVar,
@@ -320,12 +1285,24 @@ var _ = Describe("Context", func() {
 						MaxIdleConns:    fakeCaller.expectedIntegerValue,
 						ConnMaxLifetime: time.Duration(fakeCaller.expectedIntegerValue) * time.Hour,
 					},
+					RedisConfig: &RedisConfig{
+						Address:  fakeCaller.expectedEnvVar,
+						Password: fakeCaller.expectedEnvVar,
+						DB:       fakeCaller.expectedIntegerValue,
+					},
 					DockerHostsConfig: &DockerHostsConfig{
 						Address:         "1",
 						DockerAPIPort:   fakeCaller.expectedIntegerValue,
 						PathCertificate: fakeCaller.expectedEnvVar,
+						CAPath:          fakeCaller.expectedEnvVar,
 						Host:            "1:1234",
 						TLSVerify:       1,
+						MaxContainers:   fakeCaller.expectedIntegerValue,
+						ClientPoolSize:  fakeCaller.expectedIntegerValue,
+						PullInterval:    time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
+						PullTimeout:     time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
+						MaxImageAge:     time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
+						RequestTimeout:  time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
 					},
 					EnrySecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -336,6 +1313,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					GitAuthorsSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -346,6 +1328,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					GosecSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -356,6 +1343,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					BanditSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -366,6 +1358,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					BrakemanSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -376,6 +1373,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					NpmAuditSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -386,6 +1388,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					YarnAuditSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -396,6 +1403,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					SafetySecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -406,6 +1418,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					GitleaksSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -416,6 +1433,11 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
 					},
 					SpotBugsSecurityTest: &types.SecurityTest{
 						Name:             fakeCaller.expectedStringFromConfig,
@@ -426,8 +1448,168 @@ var _ = Describe("Context", func() {
 						Language:         fakeCaller.expectedStringFromConfig,
 						Default:          fakeCaller.expectedBoolFromConfig,
 						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
+					},
+					DependencyCheckSecurityTest: &types.SecurityTest{
+						Name:             fakeCaller.expectedStringFromConfig,
+						Image:            fakeCaller.expectedStringFromConfig,
+						ImageTag:         fakeCaller.expectedStringFromConfig,
+						Cmd:              fakeCaller.expectedStringFromConfig,
+						Type:             fakeCaller.expectedStringFromConfig,
+						Language:         fakeCaller.expectedStringFromConfig,
+						Default:          fakeCaller.expectedBoolFromConfig,
+						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
+					},
+					DepConfusionSecurityTest: &types.SecurityTest{
+						Name:             fakeCaller.expectedStringFromConfig,
+						Image:            fakeCaller.expectedStringFromConfig,
+						ImageTag:         fakeCaller.expectedStringFromConfig,
+						Cmd:              fakeCaller.expectedStringFromConfig,
+						Type:             fakeCaller.expectedStringFromConfig,
+						Language:         fakeCaller.expectedStringFromConfig,
+						Default:          fakeCaller.expectedBoolFromConfig,
+						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
+					},
+					EslintSecurityTest: &types.SecurityTest{
+						Name:             fakeCaller.expectedStringFromConfig,
+						Image:            fakeCaller.expectedStringFromConfig,
+						ImageTag:         fakeCaller.expectedStringFromConfig,
+						Cmd:              fakeCaller.expectedStringFromConfig,
+						Type:             fakeCaller.expectedStringFromConfig,
+						Language:         fakeCaller.expectedStringFromConfig,
+						Default:          fakeCaller.expectedBoolFromConfig,
+						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
+					},
+					HadolintSecurityTest: &types.SecurityTest{
+						Name:             fakeCaller.expectedStringFromConfig,
+						Image:            fakeCaller.expectedStringFromConfig,
+						ImageTag:         fakeCaller.expectedStringFromConfig,
+						Cmd:              fakeCaller.expectedStringFromConfig,
+						Type:             fakeCaller.expectedStringFromConfig,
+						Language:         fakeCaller.expectedStringFromConfig,
+						Default:          fakeCaller.expectedBoolFromConfig,
+						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
+					},
+					GitDiffSecurityTest: &types.SecurityTest{
+						Name:             fakeCaller.expectedStringFromConfig,
+						Image:            fakeCaller.expectedStringFromConfig,
+						ImageTag:         fakeCaller.expectedStringFromConfig,
+						Cmd:              fakeCaller.expectedStringFromConfig,
+						Type:             fakeCaller.expectedStringFromConfig,
+						Language:         fakeCaller.expectedStringFromConfig,
+						Default:          fakeCaller.expectedBoolFromConfig,
+						TimeOutInSeconds: fakeCaller.expectedIntFromConfig,
+						Dedup:            fakeCaller.expectedBoolFromConfig,
+						User:             fakeCaller.expectedStringFromConfig,
+						BlockingMode:     fakeCaller.expectedStringFromConfig,
+						ExpectedVersion:  fakeCaller.expectedStringFromConfig,
+						DependsOn:        fakeCaller.expectedSliceFromConfig,
+					},
+					DependencyCheckFailSeverity: "MEDIUM",
+					GenericFailSeverity:         "MEDIUM",
+					CorrelateStrategy:           "fuzzy",
+					AnalysisDeadline:            time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
+					AnalysisTimeout:             time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
+					ShutdownTimeout:             time.Duration(fakeCaller.expectedIntegerValue) * time.Second,
+					SecurityTestParallelism:     fakeCaller.expectedIntegerValue,
+					LanguageToolMapping:         map[string][]string{"python": {"bandit"}},
+					SLA:                         map[string]time.Duration{"bandit": 5 * time.Minute},
+					SlackWebhookURL:             fakeCaller.expectedEnvVar,
+					SlackChannel:                fakeCaller.expectedEnvVar,
+					SlackMinSeverity:            "",
+					ExcludedPaths:               fakeCaller.expectedSliceFromConfig,
+					ShareSecret:                 fakeCaller.expectedEnvVar,
+					PluginDir:                   fakeCaller.expectedEnvVar,
+					CustomSecurityTestImages:    fakeCaller.expectedSliceFromConfig,
+					AllowedEnvKeys:              []string{fakeCaller.expectedEnvVar},
+					EnforceImmutableTags:        true,
+					IntegrityKey:                fakeCaller.expectedEnvVar,
+					AdvisoryDBURLs:              map[string]string{},
+					GitleaksProtectedTags:       fakeCaller.expectedSliceFromConfig,
+					InternalPackagePrefixes:     fakeCaller.expectedSliceFromConfig,
+					ScanCache:                   true,
+					PassedOutputMaxSize:         fakeCaller.expectedIntegerValue,
+					OutputSecrets:               []string{fakeCaller.expectedEnvVar, fakeCaller.expectedEnvVar, fakeCaller.expectedEnvVar},
+					ESLintFailOnWarnings:        true,
+					GitHTTPSTokenHosts:          []string{fakeCaller.expectedEnvVar},
+					SuppressionReviewers:        []string{fakeCaller.expectedEnvVar},
+					CallbackAllowlist:           []string{fakeCaller.expectedEnvVar},
+					CallbackSecret:              fakeCaller.expectedEnvVar,
+					CallbackMaxAttempts:         fakeCaller.expectedIntegerValue,
+					PublicURL:                   fakeCaller.expectedEnvVar,
+					DBInstance:                  &db.MongoRequests{},
+					AnalysisRetryPolicy: types.RetryPolicy{
+						MaxAttempts:     fakeCaller.expectedIntegerValue,
+						RetryableErrors: []string{fakeCaller.expectedEnvVar},
+					},
+					GitHubConfig: &GitHubConfig{
+						APIURL:         fakeCaller.expectedEnvVar,
+						Token:          fakeCaller.expectedEnvVar,
+						OwnerTokens:    map[string]string{},
+						AppID:          fakeCaller.expectedIntegerValue,
+						AppPrivateKey:  fakeCaller.expectedEnvVar,
+						MaxAnnotations: MaxGitHubAnnotations,
+					},
+					GitLabConfig: &GitLabConfig{
+						URL:   fakeCaller.expectedEnvVar,
+						Token: fakeCaller.expectedEnvVar,
+					},
+					BitbucketConfig: &BitbucketConfig{
+						URL:         fakeCaller.expectedEnvVar,
+						Username:    fakeCaller.expectedEnvVar,
+						AppPassword: fakeCaller.expectedEnvVar,
+						Token:       fakeCaller.expectedEnvVar,
+					},
+					JIRAConfig: &JIRAConfig{
+						URL:         fakeCaller.expectedEnvVar,
+						Project:     fakeCaller.expectedEnvVar,
+						Email:       fakeCaller.expectedEnvVar,
+						Token:       fakeCaller.expectedEnvVar,
+						Username:    fakeCaller.expectedEnvVar,
+						Password:    fakeCaller.expectedEnvVar,
+						MinSeverity: "HIGH",
+					},
+					AnalysisRetention:         time.Duration(fakeCaller.expectedIntegerValue) * 24 * time.Hour,
+					MinConfidence:             map[string]string{},
+					HadolintFailLevel:         "error",
+					AllowVulnerableImages:     true,
+					RateLimitBurst:            fakeCaller.expectedIntegerValue,
+					RateLimitSustained:        fakeCaller.expectedIntegerValue,
+					AnalysisMaxAge:            time.Duration(fakeCaller.expectedIntegerValue) * 24 * time.Hour,
+					AnalysisKeepPerRepository: fakeCaller.expectedIntegerValue,
+					ArchiveConfig: &ArchiveConfig{
+						Backend:   fakeCaller.expectedEnvVar,
+						Bucket:    fakeCaller.expectedEnvVar,
+						Endpoint:  fakeCaller.expectedEnvVar,
+						Region:    fakeCaller.expectedEnvVar,
+						AccessKey: fakeCaller.expectedEnvVar,
+						SecretKey: fakeCaller.expectedEnvVar,
+						Path:      fakeCaller.expectedEnvVar,
 					},
-					DBInstance: &db.MongoRequests{},
 				}
 				Expect(apiConfig).To(Equal(expectedConfig))
 				Expect(err).To(BeNil())
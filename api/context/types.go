@@ -48,12 +48,30 @@ func (eC *ExternalCalls) GetIntFromConfigFile(value string) int {
 	return viper.GetInt(value)
 }
 
+// GetStringMapStringSliceFromConfigFile returns a map of string slices from a config file.
+func (eC *ExternalCalls) GetStringMapStringSliceFromConfigFile(value string) map[string][]string {
+	return viper.GetStringMapStringSlice(value)
+}
+
+// GetStringMapStringFromConfigFile returns a map of strings from a config file.
+func (eC *ExternalCalls) GetStringMapStringFromConfigFile(value string) map[string]string {
+	return viper.GetStringMapString(value)
+}
+
+// GetStringSliceFromConfigFile returns a slice of strings from a config file.
+func (eC *ExternalCalls) GetStringSliceFromConfigFile(value string) []string {
+	return viper.GetStringSlice(value)
+}
+
 // CallerInterface is the interface that stores all external call functions.
 type CallerInterface interface {
 	SetConfigFile(configName, configPath string) error
 	GetStringFromConfigFile(value string) string
 	GetBoolFromConfigFile(value string) bool
 	GetIntFromConfigFile(value string) int
+	GetStringMapStringSliceFromConfigFile(value string) map[string][]string
+	GetStringMapStringFromConfigFile(value string) map[string]string
+	GetStringSliceFromConfigFile(value string) []string
 	GetEnvironmentVariable(envName string) string
 	ConvertStrToInt(str string) (int, error)
 	GetTimeDurationInSeconds(duration int) time.Duration
@@ -0,0 +1,369 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"time"
+
+	mongoHuskyCI "github.com/globocom/huskyCI/api/db/mongo"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// GetAnalysisStats returns aggregated statistics of all analyses, computed by
+// a single aggregation pipeline.
+func (mR *MongoRequests) GetAnalysisStats() (types.AnalysisStats, error) {
+	now := time.Now()
+	analysisStats := types.AnalysisStats{}
+	err := mongoHuskyCI.Conn.AggregateOne(analysisStatsQuery(now), mongoHuskyCI.AnalysisCollection, &analysisStats)
+	analysisStats.GeneratedAt = now
+	return analysisStats, err
+}
+
+// analysisStatsQuery returns an aggregation that computes every statistic in its
+// own $facet and then reshapes them into an AnalysisStats document.
+func analysisStatsQuery(now time.Time) []bson.M {
+	return []bson.M{
+		bson.M{
+			"$facet": bson.M{
+				"last24Hours":           countSinceStages(now.Add(-24 * time.Hour)),
+				"last7Days":             countSinceStages(now.AddDate(0, 0, -7)),
+				"last30Days":            countSinceStages(now.AddDate(0, 0, -30)),
+				"tools":                 toolStatsStages(),
+				"topFailedRepositories": topFailedRepositoriesStages(10),
+				"cveSeverities":         cveSeveritiesStages(),
+				"findingsTrend":         findingsTrendStages(now.AddDate(0, 0, -30)),
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"totals": bson.M{
+					"last24Hours": firstCount("$last24Hours.count"),
+					"last7Days":   firstCount("$last7Days.count"),
+					"last30Days":  firstCount("$last30Days.count"),
+				},
+				"tools":                 1,
+				"topFailedRepositories": 1,
+				"cveSeverities":         1,
+				"findingsTrend":         1,
+			},
+		},
+	}
+}
+
+func countSinceStages(since time.Time) []bson.M {
+	return []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"startedAt": bson.M{
+					"$gte": since,
+				},
+			},
+		},
+		bson.M{
+			"$count": "count",
+		},
+	}
+}
+
+func firstCount(field string) bson.M {
+	return bson.M{
+		"$ifNull": []interface{}{
+			bson.M{"$arrayElemAt": []interface{}{field, 0}},
+			0,
+		},
+	}
+}
+
+// toolStatsStages computes, per securityTest, the rate of containers that passed
+// or only warned and their mean duration.
+func toolStatsStages() []bson.M {
+	return []bson.M{
+		bson.M{
+			"$unwind": "$containers",
+		},
+		bson.M{
+			"$match": bson.M{
+				"containers.cResult": bson.M{
+					"$in": []string{"passed", "warning", "failed", "error"},
+				},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": "$containers.securityTest.name",
+				"total": bson.M{
+					"$sum": 1,
+				},
+				"passed": bson.M{
+					"$sum": bson.M{
+						"$cond": []interface{}{
+							bson.M{"$in": []interface{}{"$containers.cResult", []string{"passed", "warning"}}},
+							1,
+							0,
+						},
+					},
+				},
+				"meanDuration": bson.M{
+					"$avg": bson.M{
+						"$subtract": []string{"$containers.finishedAt", "$containers.startedAt"},
+					},
+				},
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"_id":          0,
+				"securityTest": "$_id",
+				"total":        1,
+				"passRate": bson.M{
+					"$divide": []string{"$passed", "$total"},
+				},
+				"meanDurationSeconds": bson.M{
+					"$divide": []interface{}{"$meanDuration", 1000},
+				},
+			},
+		},
+		bson.M{
+			"$sort": bson.M{
+				"securityTest": 1,
+			},
+		},
+	}
+}
+
+func topFailedRepositoriesStages(limit int) []bson.M {
+	return []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"result": "failed",
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": "$repositoryURL",
+				"failures": bson.M{
+					"$sum": 1,
+				},
+			},
+		},
+		bson.M{
+			"$sort": bson.D{
+				{Name: "failures", Value: -1},
+				{Name: "_id", Value: 1},
+			},
+		},
+		bson.M{
+			"$limit": limit,
+		},
+		bson.M{
+			"$project": bson.M{
+				"_id":           0,
+				"repositoryURL": "$_id",
+				"failures":      1,
+			},
+		},
+	}
+}
+
+// vulnerabilitiesStages unwinds huskyciresults so each document holds a single
+// vulnerability in "vulns.v" and its severity list name in "vulns.k".
+func vulnerabilitiesStages() []bson.M {
+	kept := func(extra bson.M) bson.M {
+		project := bson.M{
+			"repositoryURL":    1,
+			"repositoryBranch": 1,
+			"startedAt":        1,
+		}
+		for k, v := range extra {
+			project[k] = v
+		}
+		return bson.M{"$project": project}
+	}
+	return []bson.M{
+		kept(bson.M{"languages": bson.M{"$objectToArray": "$huskyciresults"}}),
+		bson.M{"$unwind": "$languages"},
+		kept(bson.M{"outputs": bson.M{"$objectToArray": "$languages.v"}}),
+		bson.M{"$unwind": "$outputs"},
+		kept(bson.M{"vulns": bson.M{"$objectToArray": "$outputs.v"}}),
+		bson.M{"$unwind": "$vulns"},
+		bson.M{"$unwind": "$vulns.v"},
+		bson.M{
+			"$match": bson.M{
+				"vulns.k": bson.M{
+					"$ne": "nosecvulns",
+				},
+			},
+		},
+	}
+}
+
+func cveSeveritiesStages() []bson.M {
+	return append(vulnerabilitiesStages(),
+		bson.M{
+			"$match": bson.M{
+				"$or": []bson.M{
+					bson.M{"vulns.v.ruleid": bson.RegEx{Pattern: "^CVE-"}},
+					bson.M{"vulns.v.details": bson.RegEx{Pattern: `CVE-\d{4}-\d+`}},
+				},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": bson.M{
+					"$toUpper": "$vulns.v.severity",
+				},
+				"count": bson.M{
+					"$sum": 1,
+				},
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"_id":      0,
+				"severity": "$_id",
+				"count":    1,
+			},
+		},
+		bson.M{
+			"$sort": bson.M{
+				"severity": 1,
+			},
+		},
+	)
+}
+
+// findingsTrendStages counts, per day, findings first reported by a repository
+// branch and findings that were last reported before its latest analysis.
+func findingsTrendStages(since time.Time) []bson.M {
+	stages := []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"startedAt": bson.M{
+					"$gte": since,
+				},
+			},
+		},
+	}
+	stages = append(stages, vulnerabilitiesStages()...)
+	return append(stages,
+		bson.M{
+			"$group": bson.M{
+				"_id": bson.M{
+					"repositoryURL":    "$repositoryURL",
+					"repositoryBranch": "$repositoryBranch",
+					"securityTool":     "$vulns.v.securitytool",
+					"file":             "$vulns.v.file",
+					"details":          "$vulns.v.details",
+				},
+				"firstSeen": bson.M{"$min": "$startedAt"},
+				"lastSeen":  bson.M{"$max": "$startedAt"},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": bson.M{
+					"repositoryURL":    "$_id.repositoryURL",
+					"repositoryBranch": "$_id.repositoryBranch",
+				},
+				"findings": bson.M{
+					"$push": bson.M{
+						"firstSeen": "$firstSeen",
+						"lastSeen":  "$lastSeen",
+					},
+				},
+			},
+		},
+		bson.M{
+			"$lookup": bson.M{
+				"from": mongoHuskyCI.AnalysisCollection,
+				"let": bson.M{
+					"url":    "$_id.repositoryURL",
+					"branch": "$_id.repositoryBranch",
+				},
+				"pipeline": []bson.M{
+					bson.M{
+						"$match": bson.M{
+							"$expr": bson.M{
+								"$and": []bson.M{
+									bson.M{"$eq": []string{"$repositoryURL", "$$url"}},
+									bson.M{"$eq": []string{"$repositoryBranch", "$$branch"}},
+								},
+							},
+						},
+					},
+					bson.M{
+						"$group": bson.M{
+							"_id":          nil,
+							"lastAnalysis": bson.M{"$max": "$startedAt"},
+						},
+					},
+				},
+				"as": "latest",
+			},
+		},
+		bson.M{"$unwind": "$latest"},
+		bson.M{"$unwind": "$findings"},
+		bson.M{
+			"$project": bson.M{
+				"events": bson.M{
+					"$concatArrays": []interface{}{
+						[]bson.M{
+							bson.M{"type": "new", "date": dayOf("$findings.firstSeen")},
+						},
+						bson.M{
+							"$cond": []interface{}{
+								bson.M{"$lt": []string{"$findings.lastSeen", "$latest.lastAnalysis"}},
+								[]bson.M{
+									bson.M{"type": "resolved", "date": dayOf("$findings.lastSeen")},
+								},
+								[]bson.M{},
+							},
+						},
+					},
+				},
+			},
+		},
+		bson.M{"$unwind": "$events"},
+		bson.M{
+			"$group": bson.M{
+				"_id": "$events.date",
+				"new": bson.M{
+					"$sum": bson.M{
+						"$cond": []interface{}{bson.M{"$eq": []string{"$events.type", "new"}}, 1, 0},
+					},
+				},
+				"resolved": bson.M{
+					"$sum": bson.M{
+						"$cond": []interface{}{bson.M{"$eq": []string{"$events.type", "resolved"}}, 1, 0},
+					},
+				},
+			},
+		},
+		bson.M{
+			"$sort": bson.M{
+				"_id": 1,
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"_id":      0,
+				"date":     "$_id",
+				"new":      1,
+				"resolved": 1,
+			},
+		},
+	)
+}
+
+func dayOf(field string) bson.M {
+	return bson.M{
+		"$dateFromParts": bson.M{
+			"year":  bson.M{"$year": field},
+			"month": bson.M{"$month": field},
+			"day":   bson.M{"$dayOfMonth": field},
+		},
+	}
+}
@@ -5,10 +5,13 @@
 package db
 
 import (
+	"errors"
+	"reflect"
 	"time"
 
 	mongoHuskyCI "github.com/globocom/huskyCI/api/db/mongo"
 	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2"
 	"gopkg.in/mgo.v2/bson"
 )
 
@@ -131,6 +134,85 @@ func (mR *MongoRequests) FindAllDBAnalysis(mapParams map[string]interface{}) ([]
 	return analysisResponse, err
 }
 
+// analysisPageSelectors are the fields of the analyses of a page: the ones needed to list
+// them, and to delete their archived copy.
+var analysisPageSelectors = []string{"RID", "repositoryURL", "repositoryBranch", "commit", "status", "result", "startedAt", "finishedAt", "summary", "riskScore", "archived", "archiveKey"}
+
+// FindPageDBAnalysis returns up to limit analyses of a given query, the most recent first,
+// skipping the first skip ones, and how many analyses match it. Only the fields needed to
+// list analyses are returned.
+func (mR *MongoRequests) FindPageDBAnalysis(mapParams map[string]interface{}, skip, limit int) ([]types.Analysis, int, error) {
+	analysisQuery := []bson.M{}
+	for k, v := range mapParams {
+		analysisQuery = append(analysisQuery, bson.M{k: v})
+	}
+	analysisFinalQuery := bson.M{"$and": analysisQuery}
+	analysisResponse := []types.Analysis{}
+	total, err := mongoHuskyCI.Conn.SearchPage(analysisFinalQuery, analysisPageSelectors, []string{"-startedAt"}, skip, limit, mongoHuskyCI.AnalysisCollection, &analysisResponse)
+	return analysisResponse, total, err
+}
+
+// FindFinishedPageDBAnalysis returns up to limit analyses of a given query, the first to
+// finish first, skipping the first skip ones. It pages through finished analyses, such as
+// the ones older than the retention, along the finishedAt index. Only the fields needed to
+// list analyses are returned.
+func (mR *MongoRequests) FindFinishedPageDBAnalysis(mapParams map[string]interface{}, skip, limit int) ([]types.Analysis, error) {
+	analysisQuery := []bson.M{}
+	for k, v := range mapParams {
+		analysisQuery = append(analysisQuery, bson.M{k: v})
+	}
+	analysisFinalQuery := bson.M{"$and": analysisQuery}
+	analysisResponse := []types.Analysis{}
+	_, err := mongoHuskyCI.Conn.SearchPage(analysisFinalQuery, analysisPageSelectors, []string{"finishedAt"}, skip, limit, mongoHuskyCI.AnalysisCollection, &analysisResponse)
+	return analysisResponse, err
+}
+
+// analysisFindingsSelectors are the fields of the analyses read by ForEachDBAnalysis: the ones
+// needed to list their findings.
+var analysisFindingsSelectors = []string{"RID", "repositoryURL", "repositoryBranch", "finishedAt", "huskyciresults"}
+
+// ForEachDBAnalysis calls each with the analyses of a given query, one at a time, as they are
+// read from a cursor, so they are never all held in memory. It stops at the first error of
+// each. Only the fields needed to list their findings are returned.
+func (mR *MongoRequests) ForEachDBAnalysis(mapParams map[string]interface{}, each func(types.Analysis) error) error {
+	analysisQuery := []bson.M{}
+	for k, v := range mapParams {
+		analysisQuery = append(analysisQuery, bson.M{k: v})
+	}
+	analysisFinalQuery := bson.M{"$and": analysisQuery}
+	analysisResponse := types.Analysis{}
+	return mongoHuskyCI.Conn.SearchEach(analysisFinalQuery, analysisFindingsSelectors, mongoHuskyCI.AnalysisCollection, &analysisResponse, func() error {
+		err := each(analysisResponse)
+		// fields missing from the next document must not be kept from this one
+		analysisResponse = types.Analysis{}
+		return err
+	})
+}
+
+// FindOneDBNVDEntry checks if a given CVE is present into NVDCollection.
+func (mR *MongoRequests) FindOneDBNVDEntry(mapParams map[string]interface{}) (types.NVDEntry, error) {
+	nvdEntryResponse := types.NVDEntry{}
+	nvdEntryQuery := []bson.M{}
+	for k, v := range mapParams {
+		nvdEntryQuery = append(nvdEntryQuery, bson.M{k: v})
+	}
+	nvdEntryFinalQuery := bson.M{"$and": nvdEntryQuery}
+	err := mongoHuskyCI.Conn.SearchOne(nvdEntryFinalQuery, nil, mongoHuskyCI.NVDCollection, &nvdEntryResponse)
+	return nvdEntryResponse, err
+}
+
+// FindAllDBNVDEntry returns all CVEs of a given query present into NVDCollection.
+func (mR *MongoRequests) FindAllDBNVDEntry(mapParams map[string]interface{}) ([]types.NVDEntry, error) {
+	nvdEntryQuery := []bson.M{}
+	for k, v := range mapParams {
+		nvdEntryQuery = append(nvdEntryQuery, bson.M{k: v})
+	}
+	nvdEntryFinalQuery := bson.M{"$and": nvdEntryQuery}
+	nvdEntryResponse := []types.NVDEntry{}
+	err := mongoHuskyCI.Conn.Search(nvdEntryFinalQuery, nil, mongoHuskyCI.NVDCollection, &nvdEntryResponse)
+	return nvdEntryResponse, err
+}
+
 // InsertDBRepository inserts a new repository into RepositoryCollection.
 func (mR *MongoRequests) InsertDBRepository(repository types.Repository) error {
 	newRepository := bson.M{
@@ -167,6 +249,29 @@ func (mR *MongoRequests) InsertDBAnalysis(analysis types.Analysis) error {
 		"containers":       analysis.Containers,
 		"startedAt":        analysis.StartedAt,
 	}
+	// fields set by the analysis request are omitempty, so they are only stored if set
+	requestFields := bson.M{
+		"scanType":          analysis.ScanType,
+		"baseCommit":        analysis.BaseCommit,
+		"subpaths":          analysis.Subpaths,
+		"excludedPaths":     analysis.ExcludedPaths,
+		"sourceType":        analysis.SourceType,
+		"extraEnvKeys":      analysis.ExtraEnvKeys,
+		"ignored":           analysis.Ignored,
+		"gitleaksAllowlist": analysis.GitleaksAllowlist,
+		"npmAuditFailOn":    analysis.NpmAuditFailOn,
+		"refs":              analysis.Refs,
+		"commitSHA":         analysis.CommitSHA,
+		"commitAuthor":      analysis.CommitAuthor,
+		"pullRequestID":     analysis.PullRequestID,
+		"metadata":          analysis.Metadata,
+		"callbackURL":       analysis.CallbackURL,
+	}
+	for field, value := range requestFields {
+		if !reflect.ValueOf(value).IsZero() {
+			newAnalysis[field] = value
+		}
+	}
 	err := mongoHuskyCI.Conn.Insert(newAnalysis, mongoHuskyCI.AnalysisCollection)
 	return err
 }
@@ -194,6 +299,10 @@ func (mR *MongoRequests) InsertDBAccessToken(accessToken types.DBToken) error {
 		"createdAt":     accessToken.CreatedAt,
 		"salt":          accessToken.Salt,
 		"uuid":          accessToken.UUID,
+		"prefix":        accessToken.Prefix,
+	}
+	if !accessToken.ExpiresAt.IsZero() {
+		newAccessToken["expiresAt"] = accessToken.ExpiresAt
 	}
 	err := mongoHuskyCI.Conn.Insert(newAccessToken, mongoHuskyCI.AccessTokenCollection)
 	return err
@@ -270,3 +379,283 @@ func (mR *MongoRequests) UpdateOneDBAccessToken(mapParams map[string]interface{}
 	err := mongoHuskyCI.Conn.Update(aTokenFinalQuery, updatedAccessToken, mongoHuskyCI.AccessTokenCollection)
 	return err
 }
+
+// UpsertOneDBNVDEntry checks if a given CVE is present into NVDCollection and update it.
+func (mR *MongoRequests) UpsertOneDBNVDEntry(mapParams map[string]interface{}, updatedNVDEntry types.NVDEntry) (interface{}, error) {
+	nvdEntryQuery := []bson.M{}
+	for k, v := range mapParams {
+		nvdEntryQuery = append(nvdEntryQuery, bson.M{k: v})
+	}
+	nvdEntryFinalQuery := bson.M{"$and": nvdEntryQuery}
+	changeInfo, err := mongoHuskyCI.Conn.Upsert(nvdEntryFinalQuery, updatedNVDEntry, mongoHuskyCI.NVDCollection)
+	return changeInfo, err
+}
+
+// FindAllDBVulnerabilityTrend returns all daily snapshots of a given query present into VulnerabilityTrendCollection.
+func (mR *MongoRequests) FindAllDBVulnerabilityTrend(mapParams map[string]interface{}) ([]types.VulnerabilityTrend, error) {
+	trendQuery := []bson.M{}
+	for k, v := range mapParams {
+		trendQuery = append(trendQuery, bson.M{k: v})
+	}
+	trendFinalQuery := bson.M{"$and": trendQuery}
+	trendResponse := []types.VulnerabilityTrend{}
+	err := mongoHuskyCI.Conn.Search(trendFinalQuery, nil, mongoHuskyCI.VulnerabilityTrendCollection, &trendResponse)
+	return trendResponse, err
+}
+
+// UpsertOneDBVulnerabilityTrend checks if a given daily snapshot is present into VulnerabilityTrendCollection and update it.
+func (mR *MongoRequests) UpsertOneDBVulnerabilityTrend(mapParams map[string]interface{}, updatedTrend types.VulnerabilityTrend) (interface{}, error) {
+	trendQuery := []bson.M{}
+	for k, v := range mapParams {
+		trendQuery = append(trendQuery, bson.M{k: v})
+	}
+	trendFinalQuery := bson.M{"$and": trendQuery}
+	changeInfo, err := mongoHuskyCI.Conn.Upsert(trendFinalQuery, updatedTrend, mongoHuskyCI.VulnerabilityTrendCollection)
+	return changeInfo, err
+}
+
+// FindOneDBFinding checks if a given finding is present into FindingCollection.
+func (mR *MongoRequests) FindOneDBFinding(mapParams map[string]interface{}) (types.Finding, error) {
+	findingQuery := []bson.M{}
+	for k, v := range mapParams {
+		findingQuery = append(findingQuery, bson.M{k: v})
+	}
+	findingFinalQuery := bson.M{"$and": findingQuery}
+	findingResponse := types.Finding{}
+	err := mongoHuskyCI.Conn.SearchOne(findingFinalQuery, nil, mongoHuskyCI.FindingCollection, &findingResponse)
+	return findingResponse, err
+}
+
+// UpsertOneDBFinding updates a given finding of FindingCollection with updateQuery, inserting it if it is not there yet.
+func (mR *MongoRequests) UpsertOneDBFinding(mapParams, updateQuery map[string]interface{}) error {
+	findingQuery := []bson.M{}
+	for k, v := range mapParams {
+		findingQuery = append(findingQuery, bson.M{k: v})
+	}
+	findingFinalQuery := bson.M{"$and": findingQuery}
+	_, err := mongoHuskyCI.Conn.Upsert(findingFinalQuery, updateQuery, mongoHuskyCI.FindingCollection)
+	return err
+}
+
+// UpdateOneDBFinding checks if a given finding is present into FindingCollection and update it.
+func (mR *MongoRequests) UpdateOneDBFinding(mapParams, updateQuery map[string]interface{}) error {
+	findingQuery := []bson.M{}
+	for k, v := range mapParams {
+		findingQuery = append(findingQuery, bson.M{k: v})
+	}
+	findingFinalQuery := bson.M{"$and": findingQuery}
+	return mongoHuskyCI.Conn.Update(findingFinalQuery, updateQuery, mongoHuskyCI.FindingCollection)
+}
+
+// IncrementDBRateLimit adds requests of key to its window of RateLimitCollection starting at
+// windowStart, inserting it if it is not there yet, and returns how many requests it has.
+func (mR *MongoRequests) IncrementDBRateLimit(key string, requests int, windowStart, expiresAt time.Time) (int, error) {
+	windowQuery := bson.M{"key": key, "windowStart": windowStart}
+	change := mgo.Change{
+		Update:    bson.M{"$inc": bson.M{"count": requests}, "$setOnInsert": bson.M{"expiresAt": expiresAt}},
+		Upsert:    true,
+		ReturnNew: true,
+	}
+	window := struct {
+		Count int `bson:"count"`
+	}{}
+	_, err := mongoHuskyCI.Conn.Apply(windowQuery, change, mongoHuskyCI.RateLimitCollection, &window)
+	if mgo.IsDup(err) {
+		// another replica inserted the window first, so it can now be incremented
+		_, err = mongoHuskyCI.Conn.Apply(windowQuery, change, mongoHuskyCI.RateLimitCollection, &window)
+	}
+	return window.Count, err
+}
+
+// InsertDBAnalysisShare inserts a new share token into AnalysisShareCollection.
+func (mR *MongoRequests) InsertDBAnalysisShare(analysisShare types.AnalysisShare) error {
+	return mongoHuskyCI.Conn.Insert(analysisShare, mongoHuskyCI.AnalysisShareCollection)
+}
+
+// FindOneDBAnalysisShare checks if a given share token is present into AnalysisShareCollection.
+func (mR *MongoRequests) FindOneDBAnalysisShare(mapParams map[string]interface{}) (types.AnalysisShare, error) {
+	shareQuery := []bson.M{}
+	for k, v := range mapParams {
+		shareQuery = append(shareQuery, bson.M{k: v})
+	}
+	shareFinalQuery := bson.M{"$and": shareQuery}
+	shareResponse := types.AnalysisShare{}
+	err := mongoHuskyCI.Conn.SearchOne(shareFinalQuery, nil, mongoHuskyCI.AnalysisShareCollection, &shareResponse)
+	return shareResponse, err
+}
+
+// RemoveDBAnalysisShares removes every share token of a given query from AnalysisShareCollection.
+func (mR *MongoRequests) RemoveDBAnalysisShares(mapParams map[string]interface{}) error {
+	shareQuery := []bson.M{}
+	for k, v := range mapParams {
+		shareQuery = append(shareQuery, bson.M{k: v})
+	}
+	shareFinalQuery := bson.M{"$and": shareQuery}
+	return mongoHuskyCI.Conn.RemoveAll(shareFinalQuery, mongoHuskyCI.AnalysisShareCollection)
+}
+
+// RemoveDBAnalyses removes every analysis of a given query from AnalysisCollection.
+func (mR *MongoRequests) RemoveDBAnalyses(mapParams map[string]interface{}) error {
+	analysisQuery := []bson.M{}
+	for k, v := range mapParams {
+		analysisQuery = append(analysisQuery, bson.M{k: v})
+	}
+	analysisFinalQuery := bson.M{"$and": analysisQuery}
+	return mongoHuskyCI.Conn.RemoveAll(analysisFinalQuery, mongoHuskyCI.AnalysisCollection)
+}
+
+// InsertDBPRAnalysis inserts a new pull request analysis into PRAnalysisCollection.
+func (mR *MongoRequests) InsertDBPRAnalysis(prAnalysis types.PRAnalysisResult) error {
+	return mongoHuskyCI.Conn.Insert(prAnalysis, mongoHuskyCI.PRAnalysisCollection)
+}
+
+// FindOneDBPRAnalysis checks if a given pull request analysis is present into PRAnalysisCollection.
+func (mR *MongoRequests) FindOneDBPRAnalysis(mapParams map[string]interface{}) (types.PRAnalysisResult, error) {
+	prAnalysisQuery := []bson.M{}
+	for k, v := range mapParams {
+		prAnalysisQuery = append(prAnalysisQuery, bson.M{k: v})
+	}
+	prAnalysisFinalQuery := bson.M{"$and": prAnalysisQuery}
+	prAnalysisResponse := types.PRAnalysisResult{}
+	err := mongoHuskyCI.Conn.SearchOne(prAnalysisFinalQuery, nil, mongoHuskyCI.PRAnalysisCollection, &prAnalysisResponse)
+	return prAnalysisResponse, err
+}
+
+// UpdateOneDBPRAnalysis checks if a given pull request analysis is present into PRAnalysisCollection and update it.
+func (mR *MongoRequests) UpdateOneDBPRAnalysis(mapParams map[string]interface{}, updatedPRAnalysis map[string]interface{}) error {
+	updatedQuery := bson.M{
+		"$set": updatedPRAnalysis,
+	}
+	prAnalysisQuery := []bson.M{}
+	for k, v := range mapParams {
+		prAnalysisQuery = append(prAnalysisQuery, bson.M{k: v})
+	}
+	prAnalysisFinalQuery := bson.M{"$and": prAnalysisQuery}
+	return mongoHuskyCI.Conn.Update(prAnalysisFinalQuery, updatedQuery, mongoHuskyCI.PRAnalysisCollection)
+}
+
+// InsertDBAnalysisEvent inserts a new event of an analysis into AnalysisEventCollection.
+func (mR *MongoRequests) InsertDBAnalysisEvent(event types.AnalysisEvent) error {
+	return mongoHuskyCI.Conn.Insert(event, mongoHuskyCI.AnalysisEventCollection)
+}
+
+// FindAllDBAnalysisEvents returns every event of a given query from AnalysisEventCollection.
+func (mR *MongoRequests) FindAllDBAnalysisEvents(mapParams map[string]interface{}) ([]types.AnalysisEvent, error) {
+	eventQuery := []bson.M{}
+	for k, v := range mapParams {
+		eventQuery = append(eventQuery, bson.M{k: v})
+	}
+	eventFinalQuery := bson.M{"$and": eventQuery}
+	eventResponse := []types.AnalysisEvent{}
+	err := mongoHuskyCI.Conn.Search(eventFinalQuery, nil, mongoHuskyCI.AnalysisEventCollection, &eventResponse)
+	return eventResponse, err
+}
+
+// RemoveDBAnalysisEvents removes every event of a given query from AnalysisEventCollection.
+func (mR *MongoRequests) RemoveDBAnalysisEvents(mapParams map[string]interface{}) error {
+	eventQuery := []bson.M{}
+	for k, v := range mapParams {
+		eventQuery = append(eventQuery, bson.M{k: v})
+	}
+	eventFinalQuery := bson.M{"$and": eventQuery}
+	return mongoHuskyCI.Conn.RemoveAll(eventFinalQuery, mongoHuskyCI.AnalysisEventCollection)
+}
+
+// InsertDBBulkAnalysis inserts a new bulk analysis job into BulkAnalysisCollection.
+func (mR *MongoRequests) InsertDBBulkAnalysis(bulkJob types.BulkAnalysisJob) error {
+	return mongoHuskyCI.Conn.Insert(bulkJob, mongoHuskyCI.BulkAnalysisCollection)
+}
+
+// FindOneDBBulkAnalysis checks if a given bulk analysis job is present into BulkAnalysisCollection.
+func (mR *MongoRequests) FindOneDBBulkAnalysis(mapParams map[string]interface{}) (types.BulkAnalysisJob, error) {
+	bulkJobQuery := []bson.M{}
+	for k, v := range mapParams {
+		bulkJobQuery = append(bulkJobQuery, bson.M{k: v})
+	}
+	bulkJobFinalQuery := bson.M{"$and": bulkJobQuery}
+	bulkJobResponse := types.BulkAnalysisJob{}
+	err := mongoHuskyCI.Conn.SearchOne(bulkJobFinalQuery, nil, mongoHuskyCI.BulkAnalysisCollection, &bulkJobResponse)
+	return bulkJobResponse, err
+}
+
+// FindAllDBBulkAnalysis returns all bulk analysis jobs of BulkAnalysisCollection matching mapParams.
+func (mR *MongoRequests) FindAllDBBulkAnalysis(mapParams map[string]interface{}) ([]types.BulkAnalysisJob, error) {
+	bulkJobQuery := []bson.M{}
+	for k, v := range mapParams {
+		bulkJobQuery = append(bulkJobQuery, bson.M{k: v})
+	}
+	bulkJobFinalQuery := bson.M{"$and": bulkJobQuery}
+	bulkJobResponse := []types.BulkAnalysisJob{}
+	err := mongoHuskyCI.Conn.Search(bulkJobFinalQuery, nil, mongoHuskyCI.BulkAnalysisCollection, &bulkJobResponse)
+	return bulkJobResponse, err
+}
+
+// UpdateOneDBBulkAnalysis checks if a given bulk analysis job is present into BulkAnalysisCollection and update it.
+func (mR *MongoRequests) UpdateOneDBBulkAnalysis(mapParams map[string]interface{}, updatedBulkJob map[string]interface{}) error {
+	updatedQuery := bson.M{
+		"$set": updatedBulkJob,
+	}
+	bulkJobQuery := []bson.M{}
+	for k, v := range mapParams {
+		bulkJobQuery = append(bulkJobQuery, bson.M{k: v})
+	}
+	bulkJobFinalQuery := bson.M{"$and": bulkJobQuery}
+	return mongoHuskyCI.Conn.Update(bulkJobFinalQuery, updatedQuery, mongoHuskyCI.BulkAnalysisCollection)
+}
+
+// InsertDBSuppressionRequest inserts a new suppression request into SuppressionRequestCollection.
+func (mR *MongoRequests) InsertDBSuppressionRequest(suppression types.SuppressionRequest) error {
+	return mongoHuskyCI.Conn.Insert(suppression, mongoHuskyCI.SuppressionRequestCollection)
+}
+
+// FindOneDBSuppressionRequest checks if a given suppression request is present into SuppressionRequestCollection.
+func (mR *MongoRequests) FindOneDBSuppressionRequest(mapParams map[string]interface{}) (types.SuppressionRequest, error) {
+	suppressionQuery := []bson.M{}
+	for k, v := range mapParams {
+		suppressionQuery = append(suppressionQuery, bson.M{k: v})
+	}
+	suppressionFinalQuery := bson.M{"$and": suppressionQuery}
+	suppressionResponse := types.SuppressionRequest{}
+	err := mongoHuskyCI.Conn.SearchOne(suppressionFinalQuery, nil, mongoHuskyCI.SuppressionRequestCollection, &suppressionResponse)
+	return suppressionResponse, err
+}
+
+// FindAllDBSuppressionRequests returns every suppression request of a given query from SuppressionRequestCollection.
+func (mR *MongoRequests) FindAllDBSuppressionRequests(mapParams map[string]interface{}) ([]types.SuppressionRequest, error) {
+	suppressionQuery := []bson.M{}
+	for k, v := range mapParams {
+		suppressionQuery = append(suppressionQuery, bson.M{k: v})
+	}
+	suppressionFinalQuery := bson.M{"$and": suppressionQuery}
+	suppressionResponse := []types.SuppressionRequest{}
+	err := mongoHuskyCI.Conn.Search(suppressionFinalQuery, nil, mongoHuskyCI.SuppressionRequestCollection, &suppressionResponse)
+	return suppressionResponse, err
+}
+
+// UpdateOneDBSuppressionRequest checks if a given suppression request is present into SuppressionRequestCollection and update it.
+func (mR *MongoRequests) UpdateOneDBSuppressionRequest(mapParams, updateQuery map[string]interface{}) error {
+	suppressionQuery := []bson.M{}
+	for k, v := range mapParams {
+		suppressionQuery = append(suppressionQuery, bson.M{k: v})
+	}
+	suppressionFinalQuery := bson.M{"$and": suppressionQuery}
+	return mongoHuskyCI.Conn.Update(suppressionFinalQuery, updateQuery, mongoHuskyCI.SuppressionRequestCollection)
+}
+
+// RemoveDBSecurityTest removes every securityTest of a given query from SecurityTestCollection.
+func (mR *MongoRequests) RemoveDBSecurityTest(mapParams map[string]interface{}) error {
+	securityTestQuery := []bson.M{}
+	for k, v := range mapParams {
+		securityTestQuery = append(securityTestQuery, bson.M{k: v})
+	}
+	securityTestFinalQuery := bson.M{"$and": securityTestQuery}
+	return mongoHuskyCI.Conn.RemoveAll(securityTestFinalQuery, mongoHuskyCI.SecurityTestCollection)
+}
+
+// HealthCheckDB returns an error if AnalysisCollection can not be reached.
+func (mR *MongoRequests) HealthCheckDB() error {
+	if mongoHuskyCI.Conn == nil {
+		return errors.New("not connected to MongoDB")
+	}
+	return mongoHuskyCI.Conn.Ping(mongoHuskyCI.AnalysisCollection)
+}
@@ -23,6 +23,23 @@ var (
 	AnalysisCollection     = "analysis"
 	UserCollection         = "user"
 	AccessTokenCollection  = "accessToken"
+	NVDCollection          = "nvd"
+	// VulnerabilityTrendCollection holds daily snapshots of findings per repository and tool.
+	VulnerabilityTrendCollection = "vulnerabilityTrend"
+	// AnalysisShareCollection holds the share tokens issued for analyses and their expiry.
+	AnalysisShareCollection = "analysisShare"
+	// PRAnalysisCollection holds the comparisons of the head and base analyses of pull requests.
+	PRAnalysisCollection = "prAnalysis"
+	// FindingCollection holds the findings of each repository, stored once however many analyses report them.
+	FindingCollection = "finding"
+	// AnalysisEventCollection holds the timeline of each container of analyses.
+	AnalysisEventCollection = "analysisEvent"
+	// BulkAnalysisCollection holds the bulk analysis jobs and the analysis of each of their repositories.
+	BulkAnalysisCollection = "bulkAnalysis"
+	// SuppressionRequestCollection holds the requests to suppress findings and their approval chain.
+	SuppressionRequestCollection = "suppressionRequest"
+	// RateLimitCollection holds how many analyses each client requested in each rate limit window.
+	RateLimitCollection = "rateLimit"
 )
 
 // DB is the struct that represents mongo session.
@@ -72,11 +89,60 @@ func Connect(address, dbName, username, password string, poolLimit, port int, ti
 	}
 
 	Conn = &DB{Session: session}
+	ensureIndexes(session)
 	go autoReconnect()
 
 	return nil
 }
 
+// collectionIndexes are the indexes of each collection whose queries need them, such as
+// the ones listing the analyses of a repository sorted by when they started.
+var collectionIndexes = map[string][]mgo.Index{
+	AnalysisCollection: {
+		{Key: []string{"repositoryURL", "-startedAt"}, Background: true},
+		{Key: []string{"repositoryURL", "repositoryBranch", "-startedAt"}, Background: true},
+		// the retention and archive jobs page through the analyses that finished the longest ago
+		{Key: []string{"finishedAt"}, Background: true},
+		// cached scans are found by the cache key of one of their containers
+		{Key: []string{"containers.cacheKey"}, Background: true},
+	},
+	PRAnalysisCollection: {
+		{Key: []string{"id"}, Unique: true, Background: true},
+	},
+	AnalysisEventCollection: {
+		{Key: []string{"RID", "time"}, Background: true},
+	},
+	BulkAnalysisCollection: {
+		{Key: []string{"jobID"}, Unique: true, Background: true},
+	},
+	SuppressionRequestCollection: {
+		{Key: []string{"requestID"}, Unique: true, Background: true},
+		{Key: []string{"fingerprint", "status"}, Background: true},
+		{Key: []string{"repositoryURL", "status"}, Background: true},
+	},
+	FindingCollection: {
+		{Key: []string{"fingerprint"}, Unique: true, Background: true},
+		{Key: []string{"repositoryURL", "-lastSeenAt"}, Background: true},
+	},
+	RateLimitCollection: {
+		{Key: []string{"key", "windowStart"}, Unique: true, Background: true},
+		// windows are removed by MongoDB once they expire
+		{Key: []string{"expiresAt"}, ExpireAfter: time.Second, Background: true},
+	},
+}
+
+// ensureIndexes creates the indexes of collectionIndexes that do not exist yet. Queries
+// still work without them, so an index that can not be created is only logged.
+func ensureIndexes(session *mgo.Session) {
+	for collection, indexes := range collectionIndexes {
+		for _, index := range indexes {
+			if err := session.DB("").C(collection).EnsureIndex(index); err != nil {
+				log.Error(logActionConnect, logInfoMongo, 2032, collection, err)
+			}
+		}
+	}
+}
+
 // autoReconnect checks mongo's connection each second and, if an error is found, reconect to it.
 func autoReconnect() {
 	log.Info(logActionReconnect, logInfoMongo, 22)
@@ -123,6 +189,15 @@ func (db *DB) UpdateAll(query, updateQuery interface{}, collection string) error
 	return err
 }
 
+// RemoveAll removes all documents that match the query.
+func (db *DB) RemoveAll(query bson.M, collection string) error {
+	session := db.Session.Clone()
+	c := session.DB("").C(collection)
+	defer session.Close()
+	_, err := c.RemoveAll(query)
+	return err
+}
+
 // Search searchs all documents that match the query. If selectors are present, the return will be only the chosen fields.
 func (db *DB) Search(query bson.M, selectors []string, collection string, obj interface{}) error {
 	session := db.Session.Clone()
@@ -145,6 +220,54 @@ func (db *DB) Search(query bson.M, selectors []string, collection string, obj in
 	return err
 }
 
+// SearchPage searches for the documents that match query sorted by sortFields, skipping the
+// first skip ones and returning up to limit of them, and returns how many match query.
+func (db *DB) SearchPage(query bson.M, selectors []string, sortFields []string, skip, limit int, collection string, obj interface{}) (int, error) {
+	session := db.Session.Clone()
+	defer session.Close()
+	c := session.DB("").C(collection)
+
+	total, err := c.Find(query).Count()
+	if err != nil {
+		return 0, err
+	}
+	find := c.Find(query).Sort(sortFields...).Skip(skip).Limit(limit)
+	if selectors != nil {
+		selector := bson.M{}
+		for _, v := range selectors {
+			selector[v] = 1
+		}
+		find = find.Select(selector)
+	}
+	return total, find.All(obj)
+}
+
+// SearchEach decodes the documents that match query into obj one at a time, as they are read
+// from a cursor, calling each after every one, and stops at the first error of each. If
+// selectors are present, only the chosen fields are decoded.
+func (db *DB) SearchEach(query bson.M, selectors []string, collection string, obj interface{}, each func() error) error {
+	session := db.Session.Clone()
+	defer session.Close()
+	c := session.DB("").C(collection)
+
+	find := c.Find(query)
+	if selectors != nil {
+		selector := bson.M{}
+		for _, v := range selectors {
+			selector[v] = 1
+		}
+		find = find.Select(selector)
+	}
+	iter := find.Iter()
+	for iter.Next(obj) {
+		if err := each(); err != nil {
+			iter.Close()
+			return err
+		}
+	}
+	return iter.Close()
+}
+
 // Aggregation prepares a pipeline to aggregate.
 func (db *DB) Aggregation(aggregation []bson.M, collection string) (interface{}, error) {
 	session := db.Session.Clone()
@@ -159,6 +282,22 @@ func (db *DB) Aggregation(aggregation []bson.M, collection string) (interface{},
 	return resp, err
 }
 
+// AggregateOne runs an aggregation and unmarshals its first result into obj.
+func (db *DB) AggregateOne(aggregation []bson.M, collection string, obj interface{}) error {
+	session := db.Session.Clone()
+	defer session.Close()
+	c := session.DB("").C(collection)
+	return c.Pipe(aggregation).AllowDiskUse().One(obj)
+}
+
+// AggregateAll runs an aggregation and unmarshals all of its results into obj.
+func (db *DB) AggregateAll(aggregation []bson.M, collection string, obj interface{}) error {
+	session := db.Session.Clone()
+	defer session.Close()
+	c := session.DB("").C(collection)
+	return c.Pipe(aggregation).AllowDiskUse().All(obj)
+}
+
 // SearchOne searchs for the first element that matchs with the given query.
 func (db *DB) SearchOne(query bson.M, selectors []string, collection string, obj interface{}) error {
 	session := db.Session.Clone()
@@ -181,6 +320,26 @@ func (db *DB) SearchOne(query bson.M, selectors []string, collection string, obj
 	return err
 }
 
+// Ping checks the connection with a round-trip to a collection.
+func (db *DB) Ping(collection string) error {
+	session := db.Session.Clone()
+	defer session.Close()
+	if err := session.Ping(); err != nil {
+		return err
+	}
+	_, err := session.DB("").C(collection).Find(nil).Limit(1).Count()
+	return err
+}
+
+// Apply runs change on the document that matches the given query, atomically, and
+// unmarshals the document before or, if change.ReturnNew is set, after it into obj.
+func (db *DB) Apply(query bson.M, change mgo.Change, collection string, obj interface{}) (*mgo.ChangeInfo, error) {
+	session := db.Session.Clone()
+	defer session.Close()
+	c := session.DB("").C(collection)
+	return c.Find(query).Apply(change, obj)
+}
+
 // Upsert inserts a document or update it if it already exists.
 func (db *DB) Upsert(query bson.M, obj interface{}, collection string) (*mgo.ChangeInfo, error) {
 	session := db.Session.Clone()
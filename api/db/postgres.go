@@ -3,6 +3,7 @@ package db
 import (
 	"errors"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
@@ -132,7 +133,7 @@ func (pR *PostgresRequests) FindAllDBAnalysis(
 
 // InsertDBRepository inserts a new repository into repository table.
 func (pR *PostgresRequests) InsertDBRepository(repository types.Repository) error {
-	if (types.Repository{}) == repository {
+	if reflect.DeepEqual(types.Repository{}, repository) {
 		return errors.New("Empty repository data")
 	}
 	repositoryMap := map[string]interface{}{
@@ -153,7 +154,7 @@ func (pR *PostgresRequests) InsertDBRepository(repository types.Repository) erro
 
 // InsertDBSecurityTest inserts a new securityTest into securityTest table.
 func (pR *PostgresRequests) InsertDBSecurityTest(securityTest types.SecurityTest) error {
-	if (types.SecurityTest{}) == securityTest {
+	if reflect.DeepEqual(types.SecurityTest{}, securityTest) {
 		return errors.New("Empty SecurityTest data")
 	}
 	securityTestMap := map[string]interface{}{
@@ -189,6 +190,8 @@ func (pR *PostgresRequests) InsertDBAnalysis(analysis types.Analysis) error {
 		"repositoryBranch": analysis.Branch,
 		"status":           analysis.Status,
 		"startedAt":        analysis.StartedAt,
+		"scanType":         analysis.ScanType,
+		"baseCommit":       analysis.BaseCommit,
 	}
 	analysisMap, err := pR.ConfigureAnalysisData(analysisMap)
 	if err != nil {
@@ -282,7 +285,7 @@ func (pR *PostgresRequests) UpdateOneDBRepository(
 // and update it. If not, it will insert a new entry.
 func (pR *PostgresRequests) UpsertOneDBSecurityTest(
 	mapParams map[string]interface{}, updatedSecurityTest types.SecurityTest) (interface{}, error) {
-	if (types.SecurityTest{}) == updatedSecurityTest {
+	if reflect.DeepEqual(types.SecurityTest{}, updatedSecurityTest) {
 		return nil, errors.New("Empty fields to be updated")
 	}
 	if len(mapParams) == 0 {
@@ -427,6 +430,196 @@ func (pR *PostgresRequests) GetMetricByType(
 	return nil, errors.New("Function not supported yet in postgres")
 }
 
+// GetAnalysisStats returns aggregated statistics of all analyses
+func (pR *PostgresRequests) GetAnalysisStats() (types.AnalysisStats, error) {
+	return types.AnalysisStats{}, errors.New("Function not supported yet in postgres")
+}
+
+// GetSLAReport returns the percentiles of the scan duration of each securityTest
+func (pR *PostgresRequests) GetSLAReport(since time.Time) ([]types.SLAReport, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// FindOneDBNVDEntry returns NVD data of a CVE
+func (pR *PostgresRequests) FindOneDBNVDEntry(
+	mapParams map[string]interface{}) (types.NVDEntry, error) {
+	return types.NVDEntry{}, errors.New("Function not supported yet in postgres")
+}
+
+// FindAllDBNVDEntry returns NVD data of all CVEs found
+func (pR *PostgresRequests) FindAllDBNVDEntry(
+	mapParams map[string]interface{}) ([]types.NVDEntry, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// UpsertOneDBNVDEntry inserts or updates NVD data of a CVE
+func (pR *PostgresRequests) UpsertOneDBNVDEntry(
+	mapParams map[string]interface{}, updatedNVDEntry types.NVDEntry) (interface{}, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// FindPageDBAnalysis returns a page of the analyses of a given query, the most recent first
+func (pR *PostgresRequests) FindPageDBAnalysis(
+	mapParams map[string]interface{}, skip, limit int) ([]types.Analysis, int, error) {
+	return nil, 0, errors.New("Function not supported yet in postgres")
+}
+
+// FindFinishedPageDBAnalysis returns a page of the analyses of a given query, the first to finish first
+func (pR *PostgresRequests) FindFinishedPageDBAnalysis(
+	mapParams map[string]interface{}, skip, limit int) ([]types.Analysis, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// ForEachDBAnalysis calls each with the analyses of a given query, one at a time
+func (pR *PostgresRequests) ForEachDBAnalysis(
+	mapParams map[string]interface{}, each func(types.Analysis) error) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// FindAllDBVulnerabilityTrend returns daily snapshots of findings of a repository
+func (pR *PostgresRequests) FindAllDBVulnerabilityTrend(
+	mapParams map[string]interface{}) ([]types.VulnerabilityTrend, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// UpsertOneDBVulnerabilityTrend inserts or updates a daily snapshot of findings of a repository
+func (pR *PostgresRequests) UpsertOneDBVulnerabilityTrend(
+	mapParams map[string]interface{}, updatedTrend types.VulnerabilityTrend) (interface{}, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// FindOneDBFinding returns a finding of a repository tracked across its analyses
+func (pR *PostgresRequests) FindOneDBFinding(mapParams map[string]interface{}) (types.Finding, error) {
+	return types.Finding{}, errors.New("Function not supported yet in postgres")
+}
+
+// UpsertOneDBFinding inserts or updates a finding of a repository tracked across its analyses
+func (pR *PostgresRequests) UpsertOneDBFinding(mapParams, updateQuery map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// UpdateOneDBFinding updates a finding of a repository tracked across its analyses
+func (pR *PostgresRequests) UpdateOneDBFinding(mapParams, updateQuery map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// IncrementDBRateLimit adds requests of key to its rate limit window and returns how many it has
+func (pR *PostgresRequests) IncrementDBRateLimit(key string, requests int, windowStart, expiresAt time.Time) (int, error) {
+	return 0, errors.New("Function not supported yet in postgres")
+}
+
+// InsertDBAnalysisShare inserts a new share token of an analysis
+func (pR *PostgresRequests) InsertDBAnalysisShare(analysisShare types.AnalysisShare) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// FindOneDBAnalysisShare returns a share token of an analysis
+func (pR *PostgresRequests) FindOneDBAnalysisShare(
+	mapParams map[string]interface{}) (types.AnalysisShare, error) {
+	return types.AnalysisShare{}, errors.New("Function not supported yet in postgres")
+}
+
+// RemoveDBAnalysisShares removes share tokens of analyses
+func (pR *PostgresRequests) RemoveDBAnalysisShares(mapParams map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// RemoveDBAnalyses removes analyses
+func (pR *PostgresRequests) RemoveDBAnalyses(mapParams map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// InsertDBPRAnalysis inserts a new pull request analysis
+func (pR *PostgresRequests) InsertDBPRAnalysis(prAnalysis types.PRAnalysisResult) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// FindOneDBPRAnalysis returns a pull request analysis
+func (pR *PostgresRequests) FindOneDBPRAnalysis(
+	mapParams map[string]interface{}) (types.PRAnalysisResult, error) {
+	return types.PRAnalysisResult{}, errors.New("Function not supported yet in postgres")
+}
+
+// UpdateOneDBPRAnalysis updates a pull request analysis
+func (pR *PostgresRequests) UpdateOneDBPRAnalysis(mapParams map[string]interface{},
+	updatedPRAnalysis map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// InsertDBAnalysisEvent inserts a new event of an analysis
+func (pR *PostgresRequests) InsertDBAnalysisEvent(event types.AnalysisEvent) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// FindAllDBAnalysisEvents returns the events of analyses
+func (pR *PostgresRequests) FindAllDBAnalysisEvents(
+	mapParams map[string]interface{}) ([]types.AnalysisEvent, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// RemoveDBAnalysisEvents removes events of analyses
+func (pR *PostgresRequests) RemoveDBAnalysisEvents(mapParams map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// InsertDBBulkAnalysis inserts a new bulk analysis job
+func (pR *PostgresRequests) InsertDBBulkAnalysis(bulkJob types.BulkAnalysisJob) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// FindOneDBBulkAnalysis returns a bulk analysis job
+func (pR *PostgresRequests) FindOneDBBulkAnalysis(
+	mapParams map[string]interface{}) (types.BulkAnalysisJob, error) {
+	return types.BulkAnalysisJob{}, errors.New("Function not supported yet in postgres")
+}
+
+// FindAllDBBulkAnalysis returns bulk analysis jobs
+func (pR *PostgresRequests) FindAllDBBulkAnalysis(
+	mapParams map[string]interface{}) ([]types.BulkAnalysisJob, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// UpdateOneDBBulkAnalysis updates a bulk analysis job
+func (pR *PostgresRequests) UpdateOneDBBulkAnalysis(mapParams map[string]interface{},
+	updatedBulkJob map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// InsertDBSuppressionRequest inserts a new suppression request
+func (pR *PostgresRequests) InsertDBSuppressionRequest(suppression types.SuppressionRequest) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// FindOneDBSuppressionRequest returns a suppression request
+func (pR *PostgresRequests) FindOneDBSuppressionRequest(
+	mapParams map[string]interface{}) (types.SuppressionRequest, error) {
+	return types.SuppressionRequest{}, errors.New("Function not supported yet in postgres")
+}
+
+// FindAllDBSuppressionRequests returns suppression requests
+func (pR *PostgresRequests) FindAllDBSuppressionRequests(
+	mapParams map[string]interface{}) ([]types.SuppressionRequest, error) {
+	return nil, errors.New("Function not supported yet in postgres")
+}
+
+// UpdateOneDBSuppressionRequest updates a suppression request
+func (pR *PostgresRequests) UpdateOneDBSuppressionRequest(mapParams,
+	updateQuery map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// RemoveDBSecurityTest removes securityTests
+func (pR *PostgresRequests) RemoveDBSecurityTest(mapParams map[string]interface{}) error {
+	return errors.New("Function not supported yet in postgres")
+}
+
+// HealthCheckDB returns an error if analysis table can not be reached.
+func (pR *PostgresRequests) HealthCheckDB() error {
+	analysisResponse := []types.Analysis{}
+	return pR.DataRetriever.RetrieveFromDB(
+		`SELECT "RID" FROM "analysis" LIMIT 1`, &analysisResponse, []string{})
+}
+
 // ConfigureUpdateQuery will receive a partial update query and mount the final query with
 // all data to be set and the search parameters related to the row to be changed.
 func ConfigureUpdateQuery(
@@ -578,5 +771,12 @@ func (pR *PostgresRequests) ConfigureAnalysisData(
 		}
 		updatedAnalysis["codes"] = codeJSON
 	}
+	if summary, ok := updatedAnalysis["summary"].(*types.SeveritySummary); ok {
+		summaryJSON, err := pR.JSONHandler.Marshal(summary)
+		if err != nil {
+			return updatedAnalysis, err
+		}
+		updatedAnalysis["summary"] = summaryJSON
+	}
 	return updatedAnalysis, nil
 }
@@ -1321,4 +1321,26 @@ var _ = Describe("Postgres", func() {
 			})
 		})
 	})
+	Describe("HealthCheckDB", func() {
+		Context("When RetrieveFromDB returns an error", func() {
+			It("Should return the same error", func() {
+				fakeRetriever := FakeRetriever{
+					expectedRetrieveError: errors.New("Failed to retrieve data"),
+				}
+				postgres := PostgresRequests{
+					DataRetriever: &fakeRetriever,
+				}
+				Expect(postgres.HealthCheckDB()).To(Equal(fakeRetriever.expectedRetrieveError))
+			})
+		})
+		Context("When RetrieveFromDB returns a nil error", func() {
+			It("Should return a nil error", func() {
+				fakeRetriever := FakeRetriever{}
+				postgres := PostgresRequests{
+					DataRetriever: &fakeRetriever,
+				}
+				Expect(postgres.HealthCheckDB()).To(BeNil())
+			})
+		})
+	})
 })
@@ -0,0 +1,84 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package db
+
+import (
+	"time"
+
+	mongoHuskyCI "github.com/globocom/huskyCI/api/db/mongo"
+	"github.com/globocom/huskyCI/api/types"
+	"gopkg.in/mgo.v2/bson"
+)
+
+// GetSLAReport returns the p50, p95 and p99 scan duration of each securityTest
+// run by analyses started since the given time.
+func (mR *MongoRequests) GetSLAReport(since time.Time) ([]types.SLAReport, error) {
+	slaReport := []types.SLAReport{}
+	err := mongoHuskyCI.Conn.AggregateAll(slaReportQuery(since), mongoHuskyCI.AnalysisCollection, &slaReport)
+	return slaReport, err
+}
+
+// slaReportQuery returns an aggregation that computes the percentiles of the
+// durationMs stored in each container. It needs MongoDB 7.0 or later for $percentile.
+func slaReportQuery(since time.Time) []bson.M {
+	return []bson.M{
+		bson.M{
+			"$match": bson.M{
+				"startedAt": bson.M{
+					"$gte": since,
+				},
+			},
+		},
+		bson.M{
+			"$unwind": "$containers",
+		},
+		bson.M{
+			"$match": bson.M{
+				"containers.durationMs": bson.M{
+					"$gt": 0,
+				},
+			},
+		},
+		bson.M{
+			"$group": bson.M{
+				"_id": "$containers.securityTest.name",
+				"total": bson.M{
+					"$sum": 1,
+				},
+				"percentiles": bson.M{
+					"$percentile": bson.M{
+						"input":  "$containers.durationMs",
+						"p":      []float64{0.5, 0.95, 0.99},
+						"method": "approximate",
+					},
+				},
+			},
+		},
+		bson.M{
+			"$project": bson.M{
+				"_id":          0,
+				"securityTest": "$_id",
+				"total":        1,
+				"p50Seconds":   percentileInSeconds(0),
+				"p95Seconds":   percentileInSeconds(1),
+				"p99Seconds":   percentileInSeconds(2),
+			},
+		},
+		bson.M{
+			"$sort": bson.M{
+				"securityTest": 1,
+			},
+		},
+	}
+}
+
+func percentileInSeconds(index int) bson.M {
+	return bson.M{
+		"$divide": []interface{}{
+			bson.M{"$arrayElemAt": []interface{}{"$percentiles", index}},
+			1000,
+		},
+	}
+}
@@ -22,6 +22,11 @@ type Requests interface {
 	FindAllDBRepository(mapParams map[string]interface{}) ([]types.Repository, error)
 	FindAllDBSecurityTest(mapParams map[string]interface{}) ([]types.SecurityTest, error)
 	FindAllDBAnalysis(mapParams map[string]interface{}) ([]types.Analysis, error)
+	FindPageDBAnalysis(mapParams map[string]interface{}, skip, limit int) ([]types.Analysis, int, error)
+	FindFinishedPageDBAnalysis(mapParams map[string]interface{}, skip, limit int) ([]types.Analysis, error)
+	ForEachDBAnalysis(mapParams map[string]interface{}, each func(types.Analysis) error) error
+	FindOneDBNVDEntry(mapParams map[string]interface{}) (types.NVDEntry, error)
+	FindAllDBNVDEntry(mapParams map[string]interface{}) ([]types.NVDEntry, error)
 	InsertDBRepository(repository types.Repository) error
 	InsertDBSecurityTest(securityTest types.SecurityTest) error
 	InsertDBAnalysis(analysis types.Analysis) error
@@ -29,11 +34,40 @@ type Requests interface {
 	InsertDBAccessToken(accessToken types.DBToken) error
 	UpdateOneDBRepository(mapParams, updateQuery map[string]interface{}) error
 	UpsertOneDBSecurityTest(mapParams map[string]interface{}, updatedSecurityTest types.SecurityTest) (interface{}, error)
+	RemoveDBSecurityTest(mapParams map[string]interface{}) error
 	UpdateOneDBAnalysis(mapParams map[string]interface{}, updatedAnalysis map[string]interface{}) error
 	UpdateOneDBUser(mapParams map[string]interface{}, updatedUser types.User) error
 	UpdateOneDBAnalysisContainer(mapParams, updateQuery map[string]interface{}) error
 	UpdateOneDBAccessToken(mapParams map[string]interface{}, updatedAccessToken types.DBToken) error
+	UpsertOneDBNVDEntry(mapParams map[string]interface{}, updatedNVDEntry types.NVDEntry) (interface{}, error)
+	FindAllDBVulnerabilityTrend(mapParams map[string]interface{}) ([]types.VulnerabilityTrend, error)
+	UpsertOneDBVulnerabilityTrend(mapParams map[string]interface{}, updatedTrend types.VulnerabilityTrend) (interface{}, error)
+	FindOneDBFinding(mapParams map[string]interface{}) (types.Finding, error)
+	UpsertOneDBFinding(mapParams, updateQuery map[string]interface{}) error
+	UpdateOneDBFinding(mapParams, updateQuery map[string]interface{}) error
+	InsertDBAnalysisShare(analysisShare types.AnalysisShare) error
+	FindOneDBAnalysisShare(mapParams map[string]interface{}) (types.AnalysisShare, error)
+	RemoveDBAnalysisShares(mapParams map[string]interface{}) error
+	RemoveDBAnalyses(mapParams map[string]interface{}) error
+	InsertDBPRAnalysis(prAnalysis types.PRAnalysisResult) error
+	FindOneDBPRAnalysis(mapParams map[string]interface{}) (types.PRAnalysisResult, error)
+	UpdateOneDBPRAnalysis(mapParams map[string]interface{}, updatedPRAnalysis map[string]interface{}) error
+	InsertDBBulkAnalysis(bulkJob types.BulkAnalysisJob) error
+	InsertDBAnalysisEvent(event types.AnalysisEvent) error
+	FindAllDBAnalysisEvents(mapParams map[string]interface{}) ([]types.AnalysisEvent, error)
+	RemoveDBAnalysisEvents(mapParams map[string]interface{}) error
+	FindOneDBBulkAnalysis(mapParams map[string]interface{}) (types.BulkAnalysisJob, error)
+	FindAllDBBulkAnalysis(mapParams map[string]interface{}) ([]types.BulkAnalysisJob, error)
+	UpdateOneDBBulkAnalysis(mapParams map[string]interface{}, updatedBulkJob map[string]interface{}) error
+	InsertDBSuppressionRequest(suppression types.SuppressionRequest) error
+	FindOneDBSuppressionRequest(mapParams map[string]interface{}) (types.SuppressionRequest, error)
+	FindAllDBSuppressionRequests(mapParams map[string]interface{}) ([]types.SuppressionRequest, error)
+	UpdateOneDBSuppressionRequest(mapParams, updateQuery map[string]interface{}) error
 	GetMetricByType(metricType string, queryStringParams map[string][]string) (interface{}, error)
+	GetAnalysisStats() (types.AnalysisStats, error)
+	GetSLAReport(since time.Time) ([]types.SLAReport, error)
+	IncrementDBRateLimit(key string, requests int, windowStart, expiresAt time.Time) (int, error)
+	HealthCheckDB() error
 }
 
 // MongoRequests implements Requests
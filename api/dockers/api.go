@@ -5,10 +5,17 @@
 package dockers
 
 import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"io/ioutil"
-	"os"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	dockerTypes "github.com/docker/docker/api/types"
 	"github.com/docker/docker/api/types/container"
@@ -21,8 +28,20 @@ import (
 
 // Docker is the docker struct
 type Docker struct {
-	CID    string `json:"Id"`
-	client *client.Client
+	CID           string `json:"Id"`
+	client        *client.Client
+	host          string
+	maxContainers int
+	// secrets are redacted from the output of its containers.
+	secrets []string
+	// pullInterval is how long, on average, it waits between attempts to pull an image
+	// and pullTimeout how long it tries to.
+	pullInterval time.Duration
+	pullTimeout  time.Duration
+	// maxImageAge, if set, is how old an image can be before it is pulled again.
+	maxImageAge time.Duration
+	// requestTimeout is how long a single call to the Docker API can take.
+	requestTimeout time.Duration
 }
 
 // CreateContainerPayload is a struct that represents all data needed to create a container.
@@ -35,54 +54,91 @@ type CreateContainerPayload struct {
 const logActionNew = "NewDocker"
 const logInfoAPI = "DOCKERAPI"
 
-// NewDocker returns a new docker.
+// NewDocker returns a new docker with a client acquired from the pool of its Docker host.
+// It must be released by Release once it is not used anymore.
 func NewDocker() (*Docker, error) {
 	configAPI, err := context.DefaultConf.GetAPIConfig()
 	if err != nil {
 		log.Error(logActionNew, logInfoAPI, 3026, err)
 		return nil, err
 	}
-	dockerHost := fmt.Sprintf("https://%s", configAPI.DockerHostsConfig.Host)
 
-	// env vars needed by docker/docker library to create a NewEnvClient:
-	err = os.Setenv("DOCKER_HOST", dockerHost)
-	if err != nil {
-		log.Error(logActionNew, logInfoAPI, 3001, err)
-		return nil, err
-	}
-
-	err = os.Setenv("DOCKER_CERT_PATH", configAPI.DockerHostsConfig.PathCertificate)
-	if err != nil {
-		log.Error(logActionNew, logInfoAPI, 3019, err)
-		return nil, err
-	}
-
-	tlsVerify := strconv.Itoa(configAPI.DockerHostsConfig.TLSVerify)
-	err = os.Setenv("DOCKER_TLS_VERIFY", tlsVerify)
-	if err != nil {
-		log.Error(logActionNew, logInfoAPI, 3020, err)
-		return nil, err
-	}
-
-	client, err := client.NewEnvClient()
+	client, err := dockerClients.Acquire(*configAPI.DockerHostsConfig)
 	if err != nil {
 		log.Error(logActionNew, logInfoAPI, 3002, err)
 		return nil, err
 	}
 	docker := &Docker{
-		client: client,
+		client:        client,
+		host:          configAPI.DockerHostsConfig.Host,
+		maxContainers: configAPI.DockerHostsConfig.MaxContainers,
+		secrets:       configAPI.OutputSecrets,
+		pullInterval:  configAPI.DockerHostsConfig.PullInterval,
+		pullTimeout:   configAPI.DockerHostsConfig.PullTimeout,
+		maxImageAge:   configAPI.DockerHostsConfig.MaxImageAge,
+		// a wedged daemon must fail each call instead of hanging the analysis
+		requestTimeout: configAPI.DockerHostsConfig.RequestTimeout,
 	}
 	return docker, nil
 }
 
-// CreateContainer creates a new container and return its CID and an error
-func (d Docker) CreateContainer(image, cmd string) (string, error) {
-	ctx := goContext.Background()
-	resp, err := d.client.ContainerCreate(ctx, &container.Config{
-		Image: image,
-		Tty:   true,
-		Cmd:   []string{"/bin/sh", "-c", cmd},
-	}, nil, nil, "")
+// Release returns the client of d to the pool of its Docker host.
+func (d *Docker) Release() {
+	dockerClients.Release(d.client)
+	d.client = nil
+}
+
+// requestTimeout returns timeout, the configured one of a single call to the Docker API, or
+// the default one if it is not set.
+func requestTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return context.DefaultDockerRequestTimeout
+	}
+	return timeout
+}
+
+// minCopyBytesPerSecond is the slowest a file is expected to be copied into a container at,
+// so copying a large file, such as a 100 MB tarball, is given more than the request timeout.
+const minCopyBytesPerSecond = 1 << 20
+
+// copyTimeout returns how long copying size bytes into a container can take: the request
+// timeout plus one second per minCopyBytesPerSecond.
+func copyTimeout(timeout time.Duration, size int) time.Duration {
+	return requestTimeout(timeout) + time.Duration(size)*time.Second/minCopyBytesPerSecond
+}
+
+// requestContext returns the context of a single call to the Docker API, done once parent
+// is or the request timeout of d expires, and the function that releases it.
+func (d Docker) requestContext(parent goContext.Context) (goContext.Context, goContext.CancelFunc) {
+	return goContext.WithTimeout(parent, requestTimeout(d.requestTimeout))
+}
+
+// CreateContainer creates a new container and return its CID and an error.
+// The container runs as user, a "uid:gid", or as the image's user if it is empty, and
+// env, a list of KEY=value, is merged into the environment variables of its image.
+// It is labeled with labels, such as LabelAnalysisID. If volume is set, the volume of that
+// name, created if it does not exist yet, is mounted at SharedVolumePath.
+// It fails once ctx is done.
+func (d Docker) CreateContainer(ctx goContext.Context, image, cmd, user string, env []string, labels map[string]string, volume string) (string, error) {
+	ctx, cancel := d.requestContext(ctx)
+	defer cancel()
+	var hostConfig *container.HostConfig
+	if volume != "" {
+		hostConfig = &container.HostConfig{Binds: []string{volume + ":" + SharedVolumePath}}
+	}
+	var resp container.ContainerCreateCreatedBody
+	err := dockerBreaker.Execute(func() error {
+		var err error
+		resp, err = d.client.ContainerCreate(ctx, &container.Config{
+			Image:  image,
+			Tty:    true,
+			Cmd:    []string{"/bin/sh", "-c", cmd},
+			User:   user,
+			Env:    env,
+			Labels: labels,
+		}, hostConfig, nil, "")
+		return err
+	})
 
 	if err != nil {
 		log.Error("CreateContainer", logInfoAPI, 3005, err)
@@ -91,16 +147,81 @@ func (d Docker) CreateContainer(image, cmd string) (string, error) {
 	return resp.ID, nil
 }
 
-// StartContainer starts a container and returns its error.
-func (d Docker) StartContainer() error {
-	ctx := goContext.Background()
-	return d.client.ContainerStart(ctx, d.CID, dockerTypes.ContainerStartOptions{})
+// CopyToContainer writes content as the file dstPath, an absolute path, of a created
+// container. The file is sent in a tar archive extracted at the container's root, so
+// Docker also creates the directories of dstPath that do not exist. The copy is given more
+// than the request timeout the larger content is, see copyTimeout.
+func (d Docker) CopyToContainer(ctx goContext.Context, dstPath string, content []byte) error {
+	archive, err := fileArchive(dstPath, content)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := goContext.WithTimeout(ctx, copyTimeout(d.requestTimeout, len(content)))
+	defer cancel()
+	return d.client.CopyToContainer(ctx, d.CID, "/", archive, dockerTypes.CopyToContainerOptions{})
 }
 
-// WaitContainer returns when container finishes executing cmd.
-func (d Docker) WaitContainer(timeOutInSeconds int) error {
-	ctx := goContext.Background()
+// fileArchive returns a tar archive with only content as the file dstPath relative to /.
+// Its directories are not in the archive, so the existing ones keep their permissions.
+func fileArchive(dstPath string, content []byte) (io.Reader, error) {
+	if !path.IsAbs(dstPath) {
+		return nil, fmt.Errorf("destination path must be absolute: %s", dstPath)
+	}
+	name := strings.TrimPrefix(path.Clean(dstPath), "/")
+	if name == "" {
+		return nil, fmt.Errorf("destination path must be a file: %s", dstPath)
+	}
+	var archive bytes.Buffer
+	tarWriter := tar.NewWriter(&archive)
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(content)),
+		ModTime: time.Now(),
+	}
+	if err := tarWriter.WriteHeader(header); err != nil {
+		return nil, err
+	}
+	if _, err := tarWriter.Write(content); err != nil {
+		return nil, err
+	}
+	if err := tarWriter.Close(); err != nil {
+		return nil, err
+	}
+	return &archive, nil
+}
+
+// StartContainer starts a container and returns its error. It fails once ctx is done.
+func (d Docker) StartContainer(ctx goContext.Context) error {
+	ctx, cancel := d.requestContext(ctx)
+	defer cancel()
+	return dockerBreaker.Execute(func() error {
+		return d.client.ContainerStart(ctx, d.CID, dockerTypes.ContainerStartOptions{})
+	})
+}
+
+// ErrContainerTimeout is returned when a container does not finish before its timeout.
+var ErrContainerTimeout = errors.New("container did not finish before its timeout")
+
+// ErrContainerCanceled is returned when the context of a container is done before it finishes.
+var ErrContainerCanceled = errors.New("container was canceled before it finished")
+
+// WaitContainer returns when container finishes executing cmd, with ErrContainerCanceled once
+// parent is done or, if timeOutInSeconds is greater than zero, with ErrContainerTimeout once it expires.
+func (d Docker) WaitContainer(parent goContext.Context, timeOutInSeconds int) error {
+	ctx := parent
+	if timeOutInSeconds > 0 {
+		var cancel goContext.CancelFunc
+		ctx, cancel = goContext.WithTimeout(parent, time.Duration(timeOutInSeconds)*time.Second)
+		defer cancel()
+	}
 	statusCode, err := d.client.ContainerWait(ctx, d.CID)
+	if parent.Err() != nil {
+		return ErrContainerCanceled
+	}
+	if ctx.Err() == goContext.DeadlineExceeded {
+		return ErrContainerTimeout
+	}
 
 	if statusCode != 0 {
 		return fmt.Errorf("Error in POST to wait the container with statusCode %d", statusCode)
@@ -111,7 +232,8 @@ func (d Docker) WaitContainer(timeOutInSeconds int) error {
 
 // StopContainer stops an active container by it's CID
 func (d Docker) StopContainer() error {
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	err := d.client.ContainerStop(ctx, d.CID, nil)
 	if err != nil {
 		log.Error("StopContainer", logInfoAPI, 3022, err)
@@ -121,7 +243,8 @@ func (d Docker) StopContainer() error {
 
 // RemoveContainer removes a container by it's CID
 func (d Docker) RemoveContainer() error {
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	err := d.client.ContainerRemove(ctx, d.CID, dockerTypes.ContainerRemoveOptions{})
 	if err != nil {
 		log.Error("RemoveContainer", logInfoAPI, 3023, err)
@@ -129,10 +252,28 @@ func (d Docker) RemoveContainer() error {
 	return err
 }
 
+// RemoveVolume removes the volume of a given name, even if no container ever mounted it.
+func (d Docker) RemoveVolume(name string) error {
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
+	err := dockerBreaker.Execute(func() error {
+		err := d.client.VolumeRemove(ctx, name, true)
+		if client.IsErrNotFound(err) {
+			return nil
+		}
+		return err
+	})
+	if err != nil {
+		log.Error("RemoveVolume", logInfoAPI, 3035, name, err)
+	}
+	return err
+}
+
 // ListStoppedContainers returns a Docker type list with CIDs of stopped containers
 func (d Docker) ListStoppedContainers() ([]Docker, error) {
 
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	dockerFilters := filters.NewArgs()
 	dockerFilters.Add("status", "exited")
 	options := dockerTypes.ContainerListOptions{
@@ -150,8 +291,9 @@ func (d Docker) ListStoppedContainers() ([]Docker, error) {
 	var dockerList []Docker
 	for _, c := range containerList {
 		docker := Docker{
-			CID:    c.ID,
-			client: d.client,
+			CID:            c.ID,
+			client:         d.client,
+			requestTimeout: d.requestTimeout,
 		}
 		dockerList = append(dockerList, docker)
 	}
@@ -182,7 +324,8 @@ func (d Docker) DieContainers() error {
 
 // ReadOutput returns STDOUT of a given containerID.
 func (d Docker) ReadOutput() (string, error) {
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	out, err := d.client.ContainerLogs(ctx, d.CID, dockerTypes.ContainerLogsOptions{ShowStdout: true})
 	if err != nil {
 		log.Error("ReadOutput", logInfoAPI, 3006, err)
@@ -194,12 +337,76 @@ func (d Docker) ReadOutput() (string, error) {
 		log.Error("ReadOutput", logInfoAPI, 3007, err)
 		return "", err
 	}
-	return string(body), err
+	return SanitizeOutput(string(body), d.secrets), err
+}
+
+// ReadOutputTail returns, at best effort, the last maxBytes of STDOUT of a given containerID,
+// such as of a container killed on its timeout. It asks Docker for the last maxBytes lines,
+// as each one has at least a byte, and then keeps only their last maxBytes.
+func (d Docker) ReadOutputTail(ctx goContext.Context, maxBytes int) (string, error) {
+	if maxBytes <= 0 {
+		return "", nil
+	}
+	ctx, cancel := d.requestContext(ctx)
+	defer cancel()
+	out, err := d.client.ContainerLogs(ctx, d.CID, dockerTypes.ContainerLogsOptions{ShowStdout: true, Tail: strconv.Itoa(maxBytes)})
+	if err != nil {
+		log.Error("ReadOutputTail", logInfoAPI, 3006, err)
+		return "", err
+	}
+	defer out.Close()
+
+	body, err := ioutil.ReadAll(out)
+	if err != nil {
+		log.Error("ReadOutputTail", logInfoAPI, 3007, err)
+	}
+	if len(body) > maxBytes {
+		body = body[len(body)-maxBytes:]
+	}
+	return SanitizeOutput(string(body), d.secrets), err
+}
+
+// FollowOutput streams STDOUT of a given containerID line by line to onLine
+// as the container writes it. It returns when the container stops writing
+// or when ctx is canceled, in which case ctx.Err() is returned.
+func (d Docker) FollowOutput(ctx goContext.Context, onLine func(line string)) error {
+	out, err := d.client.ContainerLogs(ctx, d.CID, dockerTypes.ContainerLogsOptions{ShowStdout: true, Follow: true})
+	if err != nil {
+		log.Error("FollowOutput", logInfoAPI, 3006, err)
+		return err
+	}
+	defer out.Close()
+
+	// closing out unblocks the scanner below once ctx is canceled
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			out.Close()
+		case <-done:
+		}
+	}()
+
+	scanner := bufio.NewScanner(out)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		onLine(SanitizeOutput(scanner.Text(), d.secrets))
+	}
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if err := scanner.Err(); err != nil {
+		log.Error("FollowOutput", logInfoAPI, 3028, err)
+		return err
+	}
+	return nil
 }
 
 // ReadOutputStderr returns STDERR of a given containerID.
 func (d Docker) ReadOutputStderr() (string, error) {
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	out, err := d.client.ContainerLogs(ctx, d.CID, dockerTypes.ContainerLogsOptions{ShowStderr: true})
 	if err != nil {
 		log.Error("ReadOutputStderr", logInfoAPI, 3006, err)
@@ -211,56 +418,164 @@ func (d Docker) ReadOutputStderr() (string, error) {
 		log.Error("ReadOutputStderr", logInfoAPI, 3008, err)
 		return "", err
 	}
-	return string(body), err
+	return SanitizeOutput(string(body), d.secrets), err
 }
 
-// PullImage pulls an image, like docker pull.
+// PullImage pulls an image, like docker pull. It returns once Docker starts pulling it, while
+// the progress of the pull is read in background until it finishes.
 func (d Docker) PullImage(image string) error {
-	ctx := goContext.Background()
-	_, err := d.client.ImagePull(ctx, image, dockerTypes.ImagePullOptions{})
-	if err != nil {
+	// only the request is bound to the request timeout: the pull goes on, as long as it
+	// takes, while its progress is read
+	ctx, cancel := goContext.WithCancel(goContext.Background())
+	requestTimer := time.AfterFunc(requestTimeout(d.requestTimeout), cancel)
+	var progress io.ReadCloser
+	err := dockerBreaker.Execute(func() error {
+		var err error
+		progress, err = d.client.ImagePull(ctx, image, dockerTypes.ImagePullOptions{})
+		return err
+	})
+	if !requestTimer.Stop() || err != nil {
+		cancel()
+		if progress != nil {
+			progress.Close()
+		}
+		if err == nil {
+			err = goContext.DeadlineExceeded
+		}
 		log.Error("PullImage", logInfoAPI, 3009, err)
+		return err
 	}
-	return err
+	go func() {
+		io.Copy(ioutil.Discard, progress)
+		progress.Close()
+		cancel()
+	}()
+	return nil
 }
 
-// ImageIsLoaded returns a bool if a a docker image is loaded or not.
-func (d Docker) ImageIsLoaded(image string) bool {
+// ImageIsLoaded returns a bool if a a docker image is loaded or not. If maxAge is set, an
+// image created longer than maxAge ago is not loaded, so a moving tag such as latest is pulled again.
+// An error is returned if the images of the Docker host can not be listed, such as when it is wedged.
+func (d Docker) ImageIsLoaded(image string, maxAge time.Duration) (bool, error) {
 	args := filters.NewArgs()
 	args.Add("reference", image)
 	options := dockerTypes.ImageListOptions{Filters: args}
 
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	result, err := d.client.ImageList(ctx, options)
 	if err != nil {
 		log.Error("ImageIsLoaded", logInfoAPI, 3010, err)
-		panic(err)
+		return false, err
 	}
 
-	return len(result) != 0
+	return imageIsFresh(result, maxAge, time.Now()), nil
+}
+
+// imageIsFresh returns whether one of images was created less than maxAge before now, or
+// whether there is any image if maxAge is not set.
+func imageIsFresh(images []dockerTypes.ImageSummary, maxAge time.Duration, now time.Time) bool {
+	for _, image := range images {
+		if maxAge <= 0 || now.Sub(time.Unix(image.Created, 0)) < maxAge {
+			return true
+		}
+	}
+	return false
+}
+
+// ImageDigest returns the repo digest of a loaded image, such as "huskyci/bandit@sha256:...",
+// or its ID if it was built locally and has no repo digest.
+func (d Docker) ImageDigest(image string) (string, error) {
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
+	imageInspect, _, err := d.client.ImageInspectWithRaw(ctx, image)
+	if err != nil {
+		return "", err
+	}
+	if len(imageInspect.RepoDigests) > 0 {
+		return imageInspect.RepoDigests[0], nil
+	}
+	return imageInspect.ID, nil
 }
 
 // ListImages returns docker images, like docker image ls.
 func (d Docker) ListImages() ([]dockerTypes.ImageSummary, error) {
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	return d.client.ImageList(ctx, dockerTypes.ImageListOptions{})
 }
 
 // RemoveImage removes an image.
 func (d Docker) RemoveImage(imageID string) ([]dockerTypes.ImageDelete, error) {
-	ctx := goContext.Background()
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
 	return d.client.ImageRemove(ctx, imageID, dockerTypes.ImageRemoveOptions{Force: true})
 }
 
 // HealthCheckDockerAPI returns true if a 200 status code is received from dockerAddress or false otherwise.
+// It uses a dedicated client, so it does not wait for nor take one of the pool.
 func HealthCheckDockerAPI() error {
-	d, err := NewDocker()
+	configAPI, err := context.DefaultConf.GetAPIConfig()
+	if err != nil {
+		log.Error("HealthCheckDockerAPI", logInfoAPI, 3011, err)
+		return err
+	}
+	dockerClient, err := dedicatedHealthCheckClient(*configAPI.DockerHostsConfig)
 	if err != nil {
 		log.Error("HealthCheckDockerAPI", logInfoAPI, 3011, err)
 		return err
 	}
 
-	ctx := goContext.Background()
-	_, err = d.client.Ping(ctx)
+	ctx, cancel := goContext.WithTimeout(goContext.Background(), requestTimeout(configAPI.DockerHostsConfig.RequestTimeout))
+	defer cancel()
+	_, err = dockerClient.Ping(ctx)
+	return err
+}
+
+// DockerHosts returns every configured Docker host, as its host:port, the one analyses run
+// in first.
+func DockerHosts() ([]string, error) {
+	configAPI, err := context.DefaultConf.GetAPIConfig()
+	if err != nil {
+		return nil, err
+	}
+	hosts := []string{configAPI.DockerHostsConfig.Host}
+	for _, address := range context.DefaultConf.GetDockerHostsAddresses() {
+		host := fmt.Sprintf("%s:%d", address, configAPI.DockerHostsConfig.DockerAPIPort)
+		if host != configAPI.DockerHostsConfig.Host {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts, nil
+}
+
+// HealthCheckDockerHost works like HealthCheckDockerAPI, but for the Docker host at host, one
+// of DockerHosts. A Docker host analyses do not run in is reached with a client of its own.
+func HealthCheckDockerHost(host string) error {
+	configAPI, err := context.DefaultConf.GetAPIConfig()
+	if err != nil {
+		log.Error("HealthCheckDockerHost", logInfoAPI, 3011, err)
+		return err
+	}
+	if host == configAPI.DockerHostsConfig.Host {
+		return HealthCheckDockerAPI()
+	}
+	hostsConfig := *configAPI.DockerHostsConfig
+	hostsConfig.Host = host
+	transport, err := newDockerTransport(hostsConfig)
+	if err != nil {
+		log.Error("HealthCheckDockerHost", logInfoAPI, 3011, err)
+		return err
+	}
+	defer transport.CloseIdleConnections()
+	dockerClient, err := newDockerClient(fmt.Sprintf("https://%s", host), transport)
+	if err != nil {
+		log.Error("HealthCheckDockerHost", logInfoAPI, 3011, err)
+		return err
+	}
+
+	ctx, cancel := goContext.WithTimeout(goContext.Background(), requestTimeout(hostsConfig.RequestTimeout))
+	defer cancel()
+	_, err = dockerClient.Ping(ctx)
 	return err
 }
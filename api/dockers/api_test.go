@@ -0,0 +1,82 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Docker API requests", func() {
+
+	var (
+		wedged    chan struct{}
+		daemon    *httptest.Server
+		apiClient *client.Client
+	)
+
+	BeforeEach(func() {
+		wedged = make(chan struct{})
+		// a wedged daemon accepts requests but never answers them
+		daemon = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-wedged
+		}))
+		var err error
+		apiClient, err = client.NewClient("tcp://"+strings.TrimPrefix(daemon.URL, "http://"), "1.25", daemon.Client(), nil)
+		Expect(err).To(BeNil())
+	})
+
+	AfterEach(func() {
+		close(wedged)
+		daemon.Close()
+	})
+
+	Context("When the daemon does not answer a call", func() {
+		It("Should fail it once the request timeout expires", func() {
+			d := dockers.NewTestDocker(apiClient, 50*time.Millisecond)
+			d.CID = "wedged"
+			done := make(chan error, 1)
+			go func() { done <- d.StopContainer() }()
+			Eventually(done, time.Second).Should(Receive(HaveOccurred()))
+		})
+		It("Should fail listing images too", func() {
+			d := dockers.NewTestDocker(apiClient, 50*time.Millisecond)
+			done := make(chan error, 1)
+			go func() {
+				_, err := d.ListImages()
+				done <- err
+			}()
+			Eventually(done, time.Second).Should(Receive(HaveOccurred()))
+		})
+		It("Should return an error checking if an image is loaded instead of panicking", func() {
+			d := dockers.NewTestDocker(apiClient, 50*time.Millisecond)
+			done := make(chan error, 1)
+			go func() {
+				defer GinkgoRecover()
+				loaded, err := d.ImageIsLoaded("huskyci/gosec:v1", 0)
+				Expect(loaded).To(BeFalse())
+				done <- err
+			}()
+			Eventually(done, time.Second).Should(Receive(HaveOccurred()))
+		})
+	})
+
+	Describe("CopyTimeout", func() {
+		It("Should be the request timeout for small files", func() {
+			Expect(dockers.CopyTimeout(30*time.Second, 1024)).To(BeNumerically("~", 30*time.Second, time.Millisecond))
+		})
+		It("Should grow with the size of the file", func() {
+			Expect(dockers.CopyTimeout(30*time.Second, 100<<20)).To(Equal(130 * time.Second))
+		})
+	})
+})
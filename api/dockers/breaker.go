@@ -0,0 +1,179 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+	"github.com/globocom/huskyCI/api/log"
+	goContext "golang.org/x/net/context"
+)
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState string
+
+const (
+	// CircuitClosed lets every call through, counting its failures.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen refuses every call until its CooldownPeriod ends.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a single probe call through to decide whether to close again.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+const (
+	defaultConsecutiveFailures = 5
+	defaultFailureWindow       = 30 * time.Second
+	defaultCooldownPeriod      = 60 * time.Second
+)
+
+// ErrCircuitOpen is returned instead of calling the Docker API while its circuit is open.
+var ErrCircuitOpen = errors.New("docker API circuit is open, it failed too many times")
+
+// CircuitBreaker stops calling the Docker API once it fails ConsecutiveFailures times in a
+// row within Window, so a degraded Docker daemon is not flooded by retries. It is open for
+// CooldownPeriod and then half-open, when a single probe call decides whether it closes.
+type CircuitBreaker struct {
+	ConsecutiveFailures int
+	Window              time.Duration
+	CooldownPeriod      time.Duration
+
+	mutex        sync.Mutex
+	state        CircuitState
+	failures     int
+	firstFailure time.Time
+	openedAt     time.Time
+	probing      bool
+	now          func() time.Time
+}
+
+// dockerBreaker wraps the Docker API calls of huskyCI.
+var dockerBreaker = NewCircuitBreaker()
+
+// NewCircuitBreaker returns a closed CircuitBreaker that opens after 5 failures
+// within 30 seconds for 60 seconds.
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{
+		ConsecutiveFailures: defaultConsecutiveFailures,
+		Window:              defaultFailureWindow,
+		CooldownPeriod:      defaultCooldownPeriod,
+		state:               CircuitClosed,
+		now:                 time.Now,
+	}
+}
+
+// DockerCircuitState returns the state of the circuit of the Docker API.
+func DockerCircuitState() CircuitState {
+	return dockerBreaker.State()
+}
+
+// State returns the state of cb, which is half-open once its CooldownPeriod ends.
+func (cb *CircuitBreaker) State() CircuitState {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.refreshState()
+	return cb.state
+}
+
+// Execute calls fn unless cb is open, or half-open with its probe still running, in
+// which case ErrCircuitOpen is returned. The error of fn is counted as a failure only if it
+// is a failure of the Docker daemon or of the connection to it, see isDaemonFailure.
+func (cb *CircuitBreaker) Execute(fn func() error) error {
+	if !cb.allow() {
+		return ErrCircuitOpen
+	}
+	err := fn()
+	cb.record(err)
+	return err
+}
+
+func (cb *CircuitBreaker) allow() bool {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	cb.refreshState()
+	switch cb.state {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if cb.probing {
+			return false
+		}
+		cb.probing = true
+	}
+	return true
+}
+
+// isCallCanceled returns whether err is from a call canceled or timed out by its caller, which
+// tells nothing about the Docker daemon.
+func isCallCanceled(err error) bool {
+	return errors.Is(err, goContext.Canceled) || errors.Is(err, goContext.DeadlineExceeded)
+}
+
+// isDaemonFailure returns whether err is a failure of the Docker daemon or of the connection
+// to it, and not of the call: a missing image, container or volume is answered by a healthy
+// daemon.
+func isDaemonFailure(err error) bool {
+	return err != nil && !isCallCanceled(err) && !client.IsErrNotFound(err)
+}
+
+func (cb *CircuitBreaker) record(err error) {
+	cb.mutex.Lock()
+	defer cb.mutex.Unlock()
+	now := cb.now()
+	if isCallCanceled(err) {
+		// neither a failure nor a success: a canceled probe lets the next call probe instead
+		if cb.state == CircuitHalfOpen {
+			cb.probing = false
+		}
+		return
+	}
+	failed := isDaemonFailure(err)
+	if cb.state == CircuitHalfOpen {
+		cb.probing = false
+		if failed {
+			cb.open(now)
+		} else {
+			cb.close()
+		}
+		return
+	}
+	if !failed {
+		cb.failures = 0
+		return
+	}
+	if cb.failures == 0 || now.Sub(cb.firstFailure) > cb.Window {
+		cb.failures = 0
+		cb.firstFailure = now
+	}
+	cb.failures++
+	if cb.failures >= cb.ConsecutiveFailures {
+		cb.open(now)
+	}
+}
+
+// refreshState moves an open cb to half-open once its CooldownPeriod ends.
+func (cb *CircuitBreaker) refreshState() {
+	if cb.state == CircuitOpen && cb.now().Sub(cb.openedAt) >= cb.CooldownPeriod {
+		cb.state = CircuitHalfOpen
+		cb.probing = false
+	}
+}
+
+func (cb *CircuitBreaker) open(now time.Time) {
+	if cb.state != CircuitOpen {
+		log.Warning("CircuitBreaker", logInfoAPI, 125, cb.failures, cb.CooldownPeriod)
+	}
+	cb.state = CircuitOpen
+	cb.openedAt = now
+	cb.failures = 0
+}
+
+func (cb *CircuitBreaker) close() {
+	cb.state = CircuitClosed
+	cb.failures = 0
+}
@@ -0,0 +1,137 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"errors"
+	"time"
+
+	goContext "golang.org/x/net/context"
+
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CircuitBreaker", func() {
+
+	errDocker := errors.New("docker daemon is degraded")
+	failing := func() error { return errDocker }
+	succeeding := func() error { return nil }
+
+	var breaker *dockers.CircuitBreaker
+	var now time.Time
+	BeforeEach(func() {
+		now = time.Date(2020, 3, 1, 12, 0, 0, 0, time.UTC)
+		breaker = dockers.NewCircuitBreaker()
+		breaker.SetNow(func() time.Time { return now })
+	})
+
+	failTimes := func(times int) {
+		for i := 0; i < times; i++ {
+			Expect(breaker.Execute(failing)).To(Equal(errDocker))
+		}
+	}
+
+	Context("When calls fail fewer times than ConsecutiveFailures", func() {
+		It("Should stay closed", func() {
+			failTimes(4)
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+		})
+	})
+
+	Context("When a call succeeds between failures", func() {
+		It("Should count the failures from zero again", func() {
+			failTimes(4)
+			Expect(breaker.Execute(succeeding)).To(Succeed())
+			failTimes(4)
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+		})
+	})
+
+	Context("When calls are canceled or time out", func() {
+		It("Should not count them as failures", func() {
+			failTimes(4)
+			for _, err := range []error{goContext.Canceled, goContext.DeadlineExceeded} {
+				callErr := err
+				Expect(breaker.Execute(func() error { return callErr })).To(Equal(callErr))
+			}
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+			failTimes(1)
+			Expect(breaker.State()).To(Equal(dockers.CircuitOpen))
+		})
+	})
+
+	Context("When a call fails because its object is not found", func() {
+		It("Should count it as a success of the daemon", func() {
+			failTimes(4)
+			Expect(breaker.Execute(func() error { return notFoundError{} })).To(HaveOccurred())
+			failTimes(4)
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+		})
+	})
+
+	Context("When failures are further apart than Window", func() {
+		It("Should stay closed", func() {
+			failTimes(4)
+			now = now.Add(31 * time.Second)
+			failTimes(1)
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+		})
+	})
+
+	Context("When calls fail ConsecutiveFailures times within Window", func() {
+		It("Should open and refuse calls without running them", func() {
+			failTimes(5)
+			Expect(breaker.State()).To(Equal(dockers.CircuitOpen))
+			called := false
+			err := breaker.Execute(func() error {
+				called = true
+				return nil
+			})
+			Expect(err).To(Equal(dockers.ErrCircuitOpen))
+			Expect(called).To(BeFalse())
+		})
+	})
+
+	Context("When CooldownPeriod ends", func() {
+		BeforeEach(func() {
+			failTimes(5)
+			now = now.Add(60 * time.Second)
+		})
+		It("Should be half-open and let a single probe through", func() {
+			Expect(breaker.State()).To(Equal(dockers.CircuitHalfOpen))
+			err := breaker.Execute(func() error {
+				Expect(breaker.Execute(succeeding)).To(Equal(dockers.ErrCircuitOpen))
+				return nil
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+		It("Should close if the probe succeeds", func() {
+			Expect(breaker.Execute(succeeding)).To(Succeed())
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+		})
+		It("Should let another probe through if the probe is canceled", func() {
+			Expect(breaker.Execute(func() error { return goContext.Canceled })).To(Equal(goContext.Canceled))
+			Expect(breaker.State()).To(Equal(dockers.CircuitHalfOpen))
+			Expect(breaker.Execute(succeeding)).To(Succeed())
+			Expect(breaker.State()).To(Equal(dockers.CircuitClosed))
+		})
+		It("Should open again for another CooldownPeriod if the probe fails", func() {
+			Expect(breaker.Execute(failing)).To(Equal(errDocker))
+			Expect(breaker.State()).To(Equal(dockers.CircuitOpen))
+			now = now.Add(59 * time.Second)
+			Expect(breaker.State()).To(Equal(dockers.CircuitOpen))
+			now = now.Add(time.Second)
+			Expect(breaker.State()).To(Equal(dockers.CircuitHalfOpen))
+		})
+	})
+})
+
+type notFoundError struct{}
+
+func (notFoundError) Error() string  { return "Error: No such container: 4f8c1b2e" }
+func (notFoundError) NotFound() bool { return true }
@@ -0,0 +1,170 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"crypto/tls"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/go-connections/tlsconfig"
+	"github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// DockerClientManager keeps up to HUSKYCI_DOCKER_CLIENT_POOL_SIZE idle Docker API clients of a Docker host. Its
+// clients share a single *http.Transport, so connections and TLS sessions are reused
+// across containers instead of being opened by a new client for each one of them.
+type DockerClientManager struct {
+	mutex       sync.Mutex
+	idle        chan *client.Client
+	transport   *http.Transport
+	host        string
+	hostsConfig context.DockerHostsConfig
+}
+
+// dockerClients is the DockerClientManager of every Docker of huskyCI but the one of
+// HealthCheckDockerAPI, so a busy pool does not make the API look unhealthy.
+var dockerClients = &DockerClientManager{}
+
+var (
+	healthCheckClientMutex sync.Mutex
+	healthCheckClient      *client.Client
+	healthCheckHostsConfig context.DockerHostsConfig
+)
+
+// Acquire returns an idle client of the Docker host of hostsConfig or, if there is none,
+// a new one. Every client acquired must be returned by Release once it is not used anymore.
+func (manager *DockerClientManager) Acquire(hostsConfig context.DockerHostsConfig) (*client.Client, error) {
+	manager.mutex.Lock()
+	if manager.idle == nil || manager.hostsConfig != hostsConfig {
+		// the configuration changed, so clients of the former Docker host are dropped
+		transport, err := newDockerTransport(hostsConfig)
+		if err != nil {
+			manager.mutex.Unlock()
+			return nil, err
+		}
+		if manager.transport != nil {
+			manager.transport.CloseIdleConnections()
+		}
+		manager.idle = make(chan *client.Client, clientPoolSize(hostsConfig))
+		manager.transport = transport
+		manager.host = fmt.Sprintf("https://%s", hostsConfig.Host)
+		manager.hostsConfig = hostsConfig
+	}
+	idle, transport, host := manager.idle, manager.transport, manager.host
+	manager.mutex.Unlock()
+
+	select {
+	case dockerClient := <-idle:
+		return dockerClient, nil
+	default:
+		return newDockerClient(host, transport)
+	}
+}
+
+// Release returns dockerClient to the pool or, if it is already full, drops it.
+func (manager *DockerClientManager) Release(dockerClient *client.Client) {
+	if dockerClient == nil {
+		return
+	}
+	manager.mutex.Lock()
+	idle := manager.idle
+	manager.mutex.Unlock()
+	select {
+	case idle <- dockerClient:
+	default:
+	}
+}
+
+// dedicatedHealthCheckClient returns the client HealthCheckDockerAPI uses, which has its
+// own transport and is never part of the pool.
+func dedicatedHealthCheckClient(hostsConfig context.DockerHostsConfig) (*client.Client, error) {
+	healthCheckClientMutex.Lock()
+	defer healthCheckClientMutex.Unlock()
+	if healthCheckClient != nil && healthCheckHostsConfig == hostsConfig {
+		return healthCheckClient, nil
+	}
+	transport, err := newDockerTransport(hostsConfig)
+	if err != nil {
+		return nil, err
+	}
+	dockerClient, err := newDockerClient(fmt.Sprintf("https://%s", hostsConfig.Host), transport)
+	if err != nil {
+		return nil, err
+	}
+	healthCheckClient, healthCheckHostsConfig = dockerClient, hostsConfig
+	return healthCheckClient, nil
+}
+
+func clientPoolSize(hostsConfig context.DockerHostsConfig) int {
+	if hostsConfig.ClientPoolSize <= 0 {
+		return context.DefaultDockerClientPoolSize
+	}
+	return hostsConfig.ClientPoolSize
+}
+
+// newDockerTransport returns the transport of the clients of a Docker host, authenticated
+// by the certificates of its PathCertificate, as NewEnvClient of docker/docker does. It
+// goes through HUSKYCI_HTTP_PROXY unless the Docker host is in HUSKYCI_NO_PROXY.
+func newDockerTransport(hostsConfig context.DockerHostsConfig) (*http.Transport, error) {
+	transport := &http.Transport{
+		Proxy:               util.ProxyFunc(),
+		MaxIdleConnsPerHost: clientPoolSize(hostsConfig),
+	}
+	if hostsConfig.PathCertificate != "" || hostsConfig.CAPath != "" {
+		tlsConfig, err := newDockerTLSConfig(hostsConfig)
+		if err != nil {
+			return nil, err
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+	return transport, nil
+}
+
+// newDockerTLSConfig returns the TLS configuration of a Docker host: the client certificate
+// and CA of its PathCertificate, if set, and the CA bundle of its CAPath, which is trusted
+// along with the system CAs, such as when the Docker host is signed by a corporate CA.
+func newDockerTLSConfig(hostsConfig context.DockerHostsConfig) (*tls.Config, error) {
+	options := tlsconfig.Options{}
+	if hostsConfig.PathCertificate != "" {
+		options.CAFile = filepath.Join(hostsConfig.PathCertificate, "ca.pem")
+		options.CertFile = filepath.Join(hostsConfig.PathCertificate, "cert.pem")
+		options.KeyFile = filepath.Join(hostsConfig.PathCertificate, "key.pem")
+	}
+	tlsConfig, err := tlsconfig.Client(options)
+	if err != nil {
+		return nil, err
+	}
+	if hostsConfig.CAPath == "" {
+		return tlsConfig, nil
+	}
+	if tlsConfig.RootCAs == nil {
+		if tlsConfig.RootCAs, err = tlsconfig.SystemCertPool(); err != nil {
+			return nil, err
+		}
+	}
+	bundle, err := ioutil.ReadFile(hostsConfig.CAPath)
+	if err != nil {
+		return nil, err
+	}
+	if !tlsConfig.RootCAs.AppendCertsFromPEM(bundle) {
+		return nil, fmt.Errorf("no CA certificate found in %s", hostsConfig.CAPath)
+	}
+	return tlsConfig, nil
+}
+
+func newDockerClient(host string, transport *http.Transport) (*client.Client, error) {
+	version := os.Getenv("DOCKER_API_VERSION")
+	if version == "" {
+		version = client.DefaultVersion
+	}
+	return client.NewClient(host, version, &http.Client{Transport: transport}, nil)
+}
@@ -0,0 +1,141 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DockerClientManager", func() {
+
+	hostsConfig := context.DockerHostsConfig{Host: "dockerapi:2376", ClientPoolSize: 1}
+	var manager *dockers.DockerClientManager
+	BeforeEach(func() {
+		manager = &dockers.DockerClientManager{}
+	})
+
+	Context("When a client was released", func() {
+		It("Should be acquired again instead of a new one", func() {
+			dockerClient, err := manager.Acquire(hostsConfig)
+			Expect(err).ToNot(HaveOccurred())
+			manager.Release(dockerClient)
+			Expect(manager.Acquire(hostsConfig)).To(BeIdenticalTo(dockerClient))
+		})
+	})
+
+	Context("When every idle client is in use", func() {
+		It("Should return a new one", func() {
+			dockerClient, err := manager.Acquire(hostsConfig)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(manager.Acquire(hostsConfig)).ToNot(BeIdenticalTo(dockerClient))
+		})
+	})
+
+	Context("When more clients are released than its pool size", func() {
+		It("Should keep only up to its pool size", func() {
+			first, _ := manager.Acquire(hostsConfig)
+			second, _ := manager.Acquire(hostsConfig)
+			manager.Release(first)
+			manager.Release(second)
+			Expect(manager.Acquire(hostsConfig)).To(BeIdenticalTo(first))
+			Expect(manager.Acquire(hostsConfig)).ToNot(BeIdenticalTo(second))
+		})
+	})
+
+	Context("When the Docker host changes", func() {
+		It("Should not return clients of the former one", func() {
+			dockerClient, _ := manager.Acquire(hostsConfig)
+			manager.Release(dockerClient)
+			otherHostsConfig := context.DockerHostsConfig{Host: "otherdockerapi:2376", ClientPoolSize: 1}
+			Expect(manager.Acquire(otherHostsConfig)).ToNot(BeIdenticalTo(dockerClient))
+		})
+	})
+})
+
+var _ = Describe("NewDockerTransport", func() {
+
+	var dir string
+	BeforeEach(func() {
+		var err error
+		dir, err = ioutil.TempDir("", "huskyci-docker-ca")
+		Expect(err).ToNot(HaveOccurred())
+	})
+	AfterEach(func() {
+		os.RemoveAll(dir)
+	})
+
+	newCA := func() *x509.Certificate {
+		key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		Expect(err).ToNot(HaveOccurred())
+		template := &x509.Certificate{
+			SerialNumber:          big.NewInt(1),
+			Subject:               pkix.Name{CommonName: "Corporate CA"},
+			NotBefore:             time.Now().Add(-time.Hour),
+			NotAfter:              time.Now().Add(time.Hour),
+			IsCA:                  true,
+			BasicConstraintsValid: true,
+			KeyUsage:              x509.KeyUsageCertSign,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		Expect(err).ToNot(HaveOccurred())
+		ca, err := x509.ParseCertificate(der)
+		Expect(err).ToNot(HaveOccurred())
+		return ca
+	}
+
+	Context("When neither a certificate path nor a CA path is set", func() {
+		It("Should not configure TLS", func() {
+			transport, err := dockers.NewDockerTransport(context.DockerHostsConfig{Host: "dockerapi:2376"})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transport.TLSClientConfig).To(BeNil())
+		})
+	})
+
+	Context("When a CA path is set", func() {
+		It("Should trust its CA", func() {
+			ca := newCA()
+			caPath := filepath.Join(dir, "corporate-ca.pem")
+			Expect(ioutil.WriteFile(caPath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ca.Raw}), 0600)).To(Succeed())
+
+			transport, err := dockers.NewDockerTransport(context.DockerHostsConfig{Host: "dockerapi:2376", CAPath: caPath})
+			Expect(err).ToNot(HaveOccurred())
+			Expect(transport.TLSClientConfig).ToNot(BeNil())
+			_, err = ca.Verify(x509.VerifyOptions{Roots: transport.TLSClientConfig.RootCAs})
+			Expect(err).ToNot(HaveOccurred())
+		})
+	})
+
+	Context("When the CA path has no certificate", func() {
+		It("Should return an error", func() {
+			caPath := filepath.Join(dir, "empty.pem")
+			Expect(ioutil.WriteFile(caPath, []byte("not a certificate"), 0600)).To(Succeed())
+			_, err := dockers.NewDockerTransport(context.DockerHostsConfig{Host: "dockerapi:2376", CAPath: caPath})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Context("When the CA path does not exist", func() {
+		It("Should return an error", func() {
+			_, err := dockers.NewDockerTransport(context.DockerHostsConfig{Host: "dockerapi:2376", CAPath: filepath.Join(dir, "missing.pem")})
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
@@ -0,0 +1,20 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"testing"
+
+	"github.com/globocom/huskyCI/api/log"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+func TestDockers(t *testing.T) {
+	log.InitLog(true, "", "", "log_test", "log_test")
+	RegisterFailHandler(Fail)
+	RunSpecs(t, "Dockers Suite")
+}
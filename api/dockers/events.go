@@ -0,0 +1,188 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/api/types/filters"
+	"github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	goContext "golang.org/x/net/context"
+)
+
+// LabelAnalysisID labels the containers of an analysis with its RID, so DockerEventMonitor
+// can tell which analysis a Docker event is about.
+const LabelAnalysisID = "huskyci.analysis_id"
+
+// LabelSecurityTest labels the containers of an analysis with the name of their securityTest,
+// so the events of its timeline tell which securityTest they are about.
+const LabelSecurityTest = "huskyci.security_test"
+
+// Actions of the container events DockerEventMonitor watches.
+const (
+	EventDie     = "die"
+	EventOOM     = "oom"
+	EventDestroy = "destroy"
+)
+
+// defaultDieGracePeriod is how long WaitContainer has to notice that a container died
+// before its death is reported as unexpected.
+const defaultDieGracePeriod = 30 * time.Second
+
+// eventsReconnectDelay is how long DockerEventMonitor waits to subscribe again once the
+// Docker event stream fails.
+const eventsReconnectDelay = 5 * time.Second
+
+// ContainerEvent is a Docker event about the container CID of the analysis of RID.
+type ContainerEvent struct {
+	CID    string
+	RID    string
+	Action string
+}
+
+// Reason returns why the analysis of the event failed, such as "docker_event:oom".
+func (event ContainerEvent) Reason() string {
+	return fmt.Sprintf("docker_event:%s", event.Action)
+}
+
+// runningContainers are the containers started by DockerRunWithProgress that it is still
+// waiting for, by CID, with the RID of their analysis.
+var runningContainers = struct {
+	sync.Mutex
+	containers map[string]string
+}{containers: map[string]string{}}
+
+// trackContainer marks the container CID of the analysis of RID as running.
+func trackContainer(CID, RID string) {
+	if RID == "" {
+		return
+	}
+	runningContainers.Lock()
+	defer runningContainers.Unlock()
+	runningContainers.containers[CID] = RID
+}
+
+// untrackContainer marks the container CID as in a terminal state and returns whether it
+// was running, so the end of a container is only handled once.
+func untrackContainer(CID string) bool {
+	runningContainers.Lock()
+	defer runningContainers.Unlock()
+	_, running := runningContainers.containers[CID]
+	delete(runningContainers.containers, CID)
+	return running
+}
+
+// eventSource streams Docker events, such as a Docker client.
+type eventSource interface {
+	Events(ctx goContext.Context, options dockerTypes.EventsOptions) (<-chan events.Message, <-chan error)
+}
+
+// DockerEventMonitor watches the Docker event stream for the containers of analyses that die,
+// are killed for running out of memory or are removed while huskyCI still waits for them,
+// such as by the Docker daemon, and calls OnUnexpectedExit with the event.
+type DockerEventMonitor struct {
+	source eventSource
+	// DieGracePeriod is how long WaitContainer has to notice a container died, as every
+	// container dies once it finishes.
+	DieGracePeriod   time.Duration
+	OnUnexpectedExit func(ContainerEvent)
+}
+
+// NewDockerEventMonitor returns a DockerEventMonitor of the Docker host of huskyCI that calls
+// onUnexpectedExit. The event stream is long-lived, so its client is never part of the pool.
+func NewDockerEventMonitor(onUnexpectedExit func(ContainerEvent)) (*DockerEventMonitor, error) {
+	configAPI, err := context.DefaultConf.GetAPIConfig()
+	if err != nil {
+		return nil, err
+	}
+	transport, err := newDockerTransport(*configAPI.DockerHostsConfig)
+	if err != nil {
+		return nil, err
+	}
+	dockerClient, err := newDockerClient(fmt.Sprintf("https://%s", configAPI.DockerHostsConfig.Host), transport)
+	if err != nil {
+		return nil, err
+	}
+	return &DockerEventMonitor{source: dockerClient, DieGracePeriod: defaultDieGracePeriod, OnUnexpectedExit: onUnexpectedExit}, nil
+}
+
+// Start runs the monitor in background until ctx is done.
+func (monitor *DockerEventMonitor) Start(ctx goContext.Context) {
+	go monitor.Run(ctx)
+}
+
+// Run watches the Docker event stream until ctx is done, subscribing again whenever it fails.
+func (monitor *DockerEventMonitor) Run(ctx goContext.Context) {
+	args := filters.NewArgs()
+	args.Add("type", events.ContainerEventType)
+	args.Add("label", LabelAnalysisID)
+	for _, action := range []string{EventDie, EventOOM, EventDestroy} {
+		args.Add("event", action)
+	}
+	for {
+		messages, errs := monitor.source.Events(ctx, dockerTypes.EventsOptions{Filters: args})
+		err := monitor.consume(ctx, messages, errs)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Error("DockerEventMonitor", logInfoAPI, 3032, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(eventsReconnectDelay):
+		}
+	}
+}
+
+// consume handles messages until the stream returns an error.
+func (monitor *DockerEventMonitor) consume(ctx goContext.Context, messages <-chan events.Message, errs <-chan error) error {
+	for {
+		select {
+		case message := <-messages:
+			monitor.Handle(message)
+		case err := <-errs:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// Handle reports message if it is about a container huskyCI still waits for. Its death is
+// only reported if WaitContainer does not notice it within DieGracePeriod, while running out
+// of memory or being removed is always unexpected.
+func (monitor *DockerEventMonitor) Handle(message events.Message) {
+	if message.Type != events.ContainerEventType {
+		return
+	}
+	event := ContainerEvent{CID: message.Actor.ID, RID: message.Actor.Attributes[LabelAnalysisID], Action: message.Action}
+	if event.RID == "" {
+		return
+	}
+	switch event.Action {
+	case EventOOM, EventDestroy:
+		monitor.report(event)
+	case EventDie:
+		time.AfterFunc(monitor.DieGracePeriod, func() {
+			monitor.report(event)
+		})
+	}
+}
+
+// report calls OnUnexpectedExit with event unless its container already reached a terminal state.
+func (monitor *DockerEventMonitor) report(event ContainerEvent) {
+	if !untrackContainer(event.CID) {
+		return
+	}
+	log.Warning("DockerEventMonitor", logInfoAPI, 132, event.RID, event.Reason())
+	if monitor.OnUnexpectedExit != nil {
+		monitor.OnUnexpectedExit(event)
+	}
+}
@@ -0,0 +1,103 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/globocom/huskyCI/api/dockers"
+	goContext "golang.org/x/net/context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("DockerEventMonitor", func() {
+
+	var (
+		reported chan dockers.ContainerEvent
+		monitor  *dockers.DockerEventMonitor
+	)
+
+	containerEvent := func(CID, RID, action string) events.Message {
+		return events.Message{
+			Type:   events.ContainerEventType,
+			Action: action,
+			Actor:  events.Actor{ID: CID, Attributes: map[string]string{dockers.LabelAnalysisID: RID}},
+		}
+	}
+
+	BeforeEach(func() {
+		reported = make(chan dockers.ContainerEvent, 10)
+		monitor = dockers.NewEventMonitor(nil, 20*time.Millisecond, func(event dockers.ContainerEvent) {
+			reported <- event
+		})
+	})
+
+	AfterEach(func() {
+		dockers.UntrackContainer("CID")
+	})
+
+	Context("When a running container runs out of memory", func() {
+		It("Should report it right away with its reason", func() {
+			dockers.TrackContainer("CID", "RID")
+			monitor.Handle(containerEvent("CID", "RID", dockers.EventOOM))
+			Expect(reported).To(Receive(Equal(dockers.ContainerEvent{CID: "CID", RID: "RID", Action: dockers.EventOOM})))
+			Expect(dockers.ContainerEvent{Action: dockers.EventOOM}.Reason()).To(Equal("docker_event:oom"))
+		})
+	})
+
+	Context("When a running container dies and WaitContainer does not notice it", func() {
+		It("Should report it once its grace period is over", func() {
+			dockers.TrackContainer("CID", "RID")
+			monitor.Handle(containerEvent("CID", "RID", dockers.EventDie))
+			Consistently(reported, 10*time.Millisecond).ShouldNot(Receive())
+			Eventually(reported).Should(Receive(Equal(dockers.ContainerEvent{CID: "CID", RID: "RID", Action: dockers.EventDie})))
+		})
+	})
+
+	Context("When a container dies as it finishes", func() {
+		It("Should not report it", func() {
+			dockers.TrackContainer("CID", "RID")
+			monitor.Handle(containerEvent("CID", "RID", dockers.EventDie))
+			Expect(dockers.UntrackContainer("CID")).To(BeTrue())
+			Consistently(reported, 50*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Context("When a container is not waited for", func() {
+		It("Should not report its events", func() {
+			monitor.Handle(containerEvent("CID", "RID", dockers.EventDestroy))
+			monitor.Handle(containerEvent("CID", "", dockers.EventOOM))
+			Consistently(reported, 50*time.Millisecond).ShouldNot(Receive())
+		})
+	})
+
+	Context("When it runs", func() {
+		It("Should subscribe to the container events of analyses and handle them", func() {
+			messages := make(chan events.Message, 1)
+			subscribed := make(chan dockerTypes.EventsOptions, 1)
+			monitor = dockers.NewEventMonitor(func(ctx goContext.Context, options dockerTypes.EventsOptions) (<-chan events.Message, <-chan error) {
+				subscribed <- options
+				return messages, make(chan error)
+			}, time.Second, func(event dockers.ContainerEvent) {
+				reported <- event
+			})
+			ctx, cancel := goContext.WithCancel(goContext.Background())
+			defer cancel()
+			dockers.TrackContainer("CID", "RID")
+			monitor.Start(ctx)
+
+			var options dockerTypes.EventsOptions
+			Eventually(subscribed).Should(Receive(&options))
+			Expect(options.Filters.Get("label")).To(ConsistOf(dockers.LabelAnalysisID))
+			Expect(options.Filters.Get("event")).To(ConsistOf(dockers.EventDie, dockers.EventOOM, dockers.EventDestroy))
+			messages <- containerEvent("CID", "RID", dockers.EventDestroy)
+			Eventually(reported).Should(Receive(Equal(dockers.ContainerEvent{CID: "CID", RID: "RID", Action: dockers.EventDestroy})))
+		})
+	})
+})
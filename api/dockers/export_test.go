@@ -0,0 +1,68 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/events"
+	"github.com/docker/docker/client"
+	goContext "golang.org/x/net/context"
+)
+
+// SetNow replaces the clock of cb in dockers_test.
+func (cb *CircuitBreaker) SetNow(now func() time.Time) {
+	cb.now = now
+}
+
+// NewDockerTransport exposes newDockerTransport to dockers_test.
+var NewDockerTransport = newDockerTransport
+
+// PullRetryDelay exposes pullRetryDelay to dockers_test.
+var PullRetryDelay = pullRetryDelay
+
+// PullImage exposes pullImage to dockers_test.
+func PullImage(d interface {
+	ImageIsLoaded(image string, maxAge time.Duration) (bool, error)
+	PullImage(image string) error
+}, canonicalURL, image string, maxAge, interval, timeout time.Duration) error {
+	return pullImage(d, canonicalURL, image, maxAge, interval, timeout)
+}
+
+// ImageIsFresh exposes imageIsFresh to dockers_test.
+var ImageIsFresh = imageIsFresh
+
+// CopyTimeout exposes copyTimeout to dockers_test.
+var CopyTimeout = copyTimeout
+
+// NewLogReader exposes newLogReader to dockers_test.
+var NewLogReader = newLogReader
+
+// RecordFailure exposes recordFailure to dockers_test.
+var RecordFailure = recordFailure
+
+// TrackContainer exposes trackContainer to dockers_test.
+var TrackContainer = trackContainer
+
+// UntrackContainer exposes untrackContainer to dockers_test.
+var UntrackContainer = untrackContainer
+
+// NewEventMonitor returns a DockerEventMonitor of the events of source to dockers_test.
+func NewEventMonitor(source func(ctx goContext.Context, options dockerTypes.EventsOptions) (<-chan events.Message, <-chan error), dieGracePeriod time.Duration, onUnexpectedExit func(ContainerEvent)) *DockerEventMonitor {
+	return &DockerEventMonitor{source: eventSourceFunc(source), DieGracePeriod: dieGracePeriod, OnUnexpectedExit: onUnexpectedExit}
+}
+
+type eventSourceFunc func(ctx goContext.Context, options dockerTypes.EventsOptions) (<-chan events.Message, <-chan error)
+
+func (source eventSourceFunc) Events(ctx goContext.Context, options dockerTypes.EventsOptions) (<-chan events.Message, <-chan error) {
+	return source(ctx, options)
+}
+
+// NewTestDocker returns a Docker of client whose calls to the Docker API take at most
+// requestTimeout to dockers_test.
+func NewTestDocker(client *client.Client, requestTimeout time.Duration) *Docker {
+	return &Docker{client: client, requestTimeout: requestTimeout}
+}
@@ -7,11 +7,19 @@ package dockers
 import (
 	"errors"
 	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
 	"time"
 
 	"regexp"
 
+	"github.com/globocom/huskyCI/api/context"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/globocom/huskyCI/api/timeline"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
 )
 
 const logActionRun = "DockerRun"
@@ -20,6 +28,45 @@ const logActionPull = "pullImage"
 
 const urlRegexp = `([\w\-_]+(?:(?:\.[\w\-_]+)+))([\w\-\.,@?^=%&amp;:/~\+#]*[\w\-\@?^=%&amp;/~\+#])?`
 
+// outputTailMaxBytes is how much of the output of a container killed on its timeout is kept,
+// so it shows where its securityTest got stuck.
+const outputTailMaxBytes = 4096
+
+// outputTailTimeout bounds reading that output, as the Docker host may be overloaded.
+const outputTailTimeout = 10 * time.Second
+
+// mutableTags are moved to new images by their maintainers, so the image of a
+// securityTest using one of them may change silently.
+var mutableTags = map[string]bool{"": true, "latest": true, "stable": true}
+
+// ErrMutableTag is returned when an image would be run by a mutable tag, such as latest,
+// while HUSKYCI_ENFORCE_IMMUTABLE_TAGS is set.
+var ErrMutableTag = errors.New("image tag is mutable, such as latest or stable")
+
+// InfraError is returned when a container could not run because of the Docker host, such as
+// when its image could not be pulled or it could not be created or started. It is not caused by
+// the code scanned, so running the container again may succeed.
+type InfraError struct {
+	Step string
+	Err  error
+}
+
+func (e *InfraError) Error() string {
+	return fmt.Sprintf("could not %s: %v", e.Step, e.Err)
+}
+
+// IsInfraError returns whether err is an InfraError.
+func IsInfraError(err error) bool {
+	_, ok := err.(*InfraError)
+	return ok
+}
+
+// IsMutableTag returns whether imageTag may point to a different image over time. An
+// empty tag is latest, as Docker pulls it by default.
+func IsMutableTag(imageTag string) bool {
+	return mutableTags[strings.ToLower(imageTag)]
+}
+
 func configureImagePath(image, tag string) (string, string) {
 	fullContainerImage := fmt.Sprintf("%s:%s", image, tag)
 	regex := regexp.MustCompile(urlRegexp)
@@ -33,78 +80,303 @@ func configureImagePath(image, tag string) (string, string) {
 	return canonicalURL, fullContainerImage
 }
 
+// SharedVolumePath is where the volume shared by the containers of an analysis that depend
+// on each other is mounted, such as to scan what a build wrote. Containers read it from
+// the HUSKYCI_SHARED_DIR environment variable.
+const SharedVolumePath = "/huskyci/shared"
+
+// SharedVolumeEnv is the environment variable set to SharedVolumePath in containers that
+// mount a shared volume.
+const SharedVolumeEnv = "HUSKYCI_SHARED_DIR"
+
+// SharedVolumeName returns the name of the volume shared by the containers of the analysis
+// of RID, see SharedVolumePath.
+func SharedVolumeName(RID string) string {
+	return "huskyci-shared-" + RID
+}
+
+// RemoveSharedVolume removes the volume of name once no container of its analysis uses it.
+func RemoveSharedVolume(name string) error {
+	d, err := NewDocker()
+	if err != nil {
+		return err
+	}
+	defer d.Release()
+	return d.RemoveVolume(name)
+}
+
 // DockerRun starts a new container and returns its output and an error.
 func DockerRun(image, imageTag, cmd string, timeOutInSeconds int) (string, string, error) {
+	return DockerRunWithProgress(goContext.Background(), image, imageTag, cmd, "", nil, nil, "", nil, timeOutInSeconds, nil)
+}
+
+// ImageDigest returns the digest of image:imageTag as loaded in the Docker host.
+func ImageDigest(image, imageTag string) (string, error) {
+	d, err := NewDocker()
+	if err != nil {
+		return "", err
+	}
+	defer d.Release()
+	_, fullContainerImage := configureImagePath(image, imageTag)
+	digest, err := d.ImageDigest(fullContainerImage)
+	if err != nil {
+		log.Error("ImageDigest", logInfoHuskyDocker, 3030, fullContainerImage, err)
+	}
+	return digest, err
+}
+
+// DockerRunWithProgress works like DockerRun but runs cmd as user, if it is not
+// empty, and, if onLine is not nil, it also follows the container's STDOUT and
+// calls onLine for each line while the container is still running. Once ctx is
+// done, the container is stopped, or not even created, and ErrContainerCanceled is returned.
+// A container that times out is stopped too, but the last bytes of its output are returned
+// with ErrContainerTimeout.
+// Each one of files, such as a scanner config, is copied into the container before it starts
+// and env, a list of KEY=value, is added to the environment variables of its image.
+// If volume is set, that volume is mounted at SharedVolumePath, see SharedVolumeName.
+// The container is labeled with labels: if they have a LabelAnalysisID, DockerEventMonitor
+// reports it if it exits while still being waited for, and each step it goes through is
+// recorded in the timeline of its analysis.
+func DockerRunWithProgress(ctx goContext.Context, image, imageTag, cmd, user string, env []string, files []types.ContainerFile, volume string, labels map[string]string, timeOutInSeconds int, onLine func(line string)) (string, string, error) {
+
+	if ctx.Err() != nil {
+		return "", "", ErrContainerCanceled
+	}
+	if context.APIConfiguration != nil && context.APIConfiguration.EnforceImmutableTags && IsMutableTag(imageTag) {
+		log.Error(logActionRun, logInfoHuskyDocker, 3031, image, imageTag)
+		return "", "", ErrMutableTag
+	}
 
 	// step 1: create a new docker API client
 	d, err := NewDocker()
 	if err != nil {
-		return "", "", err
+		recordFailure("connect", err)
+		return "", "", &InfraError{Step: "connect to the Docker API", Err: err}
+	}
+	defer d.Release()
+
+	record := func(eventType, CID string, err error) {
+		timeline.Record(labels[LabelAnalysisID], CID, labels[LabelSecurityTest], eventType, err)
 	}
 
 	canonicalURL, fullContainerImage := configureImagePath(image, imageTag)
-	// step 2: pull image if it is not there yet
-	if !d.ImageIsLoaded(fullContainerImage) {
-		if err := pullImage(d, canonicalURL, fullContainerImage); err != nil {
+	// step 2: pull image if it is not there yet or it is older than maxImageAge
+	loaded, err := d.ImageIsLoaded(fullContainerImage, d.maxImageAge)
+	if err != nil {
+		recordFailure("pull", err)
+		infraErr := &InfraError{Step: "list images", Err: err}
+		record(timeline.EventPulled, "", infraErr)
+		return "", "", infraErr
+	}
+	if ImageGate != nil {
+		if err := ImageGate(ctx, fullContainerImage); err != nil {
+			record(timeline.EventPulled, "", err)
 			return "", "", err
 		}
 	}
+	if !loaded {
+		if err := pullImage(d, canonicalURL, fullContainerImage, d.maxImageAge, d.pullInterval, d.pullTimeout); err != nil {
+			recordFailure("pull", err)
+			infraErr, ok := err.(*InfraError)
+			if !ok {
+				infraErr = &InfraError{Step: "pull image", Err: err}
+			}
+			record(timeline.EventPulled, "", infraErr)
+			return "", "", infraErr
+		}
+		record(timeline.EventPulled, "", nil)
+		warnEmulatedImage(d, fullContainerImage)
+	}
 
-	// step 3: create a new container given an image and it's cmd
-	CID, err := d.CreateContainer(fullContainerImage, cmd)
+	// step 3: wait for a free slot in the Docker host and create a new container given an image and it's cmd
+	releaseSlot, err := acquireContainerSlot(ctx, d)
 	if err != nil {
 		return "", "", err
 	}
+	defer releaseSlot()
+	CID, err := d.CreateContainer(ctx, fullContainerImage, cmd, user, env, labels, volume)
+	if err != nil {
+		recordFailure("create", err)
+		infraErr := &InfraError{Step: "create container", Err: err}
+		record(timeline.EventCreated, "", infraErr)
+		return "", "", infraErr
+	}
 	d.CID = CID
+	record(timeline.EventCreated, CID, nil)
+
+	// step 3.1: copy files the cmd needs, such as the code if it can not be cloned
+	for _, file := range files {
+		if err := d.CopyToContainer(ctx, file.Path, file.Content); err != nil {
+			log.Error(logActionRun, logInfoHuskyDocker, 3029, file.Path, err)
+			recordFailure("copy", err)
+			d.RemoveContainer()
+			infraErr := &InfraError{Step: "copy files to container", Err: err}
+			record(timeline.EventStarted, CID, infraErr)
+			return "", "", infraErr
+		}
+	}
 
 	// step 4: start container
-	if err := d.StartContainer(); err != nil {
+	if err := d.StartContainer(ctx); err != nil {
 		log.Error(logActionRun, logInfoHuskyDocker, 3015, err)
-		return "", "", err
+		recordFailure("start", err)
+		d.RemoveContainer()
+		infraErr := &InfraError{Step: "start container", Err: err}
+		record(timeline.EventStarted, CID, infraErr)
+		return "", "", infraErr
 	}
 	log.Info(logActionRun, logInfoHuskyDocker, 32, fullContainerImage, d.CID)
+	record(timeline.EventStarted, CID, nil)
+
+	// step 4.1: follow container's output while it runs
+	stopFollowing := followOutput(d, onLine)
 
 	// step 5: wait container finish
-	if err := d.WaitContainer(timeOutInSeconds); err != nil {
+	trackContainer(d.CID, labels[LabelAnalysisID])
+	err = d.WaitContainer(ctx, timeOutInSeconds)
+	untrackContainer(d.CID)
+	stopFollowing()
+	if err == ErrContainerTimeout || err == ErrContainerCanceled {
+		// a hanging securityTest must not hold its slot in the Docker host
+		if err == ErrContainerTimeout {
+			log.Warning(logActionRun, logInfoHuskyDocker, 117, fullContainerImage, d.CID, timeOutInSeconds)
+			recordFailure("wait", err)
+		} else {
+			log.Warning(logActionRun, logInfoHuskyDocker, 120, fullContainerImage, d.CID)
+		}
+		d.StopContainer()
+		cOutputTail := ""
+		if err == ErrContainerTimeout {
+			cOutputTail = readOutputTail(d)
+		}
+		d.RemoveContainer()
+		record(timeline.EventFinished, CID, err)
+		return CID, cOutputTail, err
+	}
+	if err != nil {
 		log.Error(logActionRun, logInfoHuskyDocker, 3016, err)
-		return "", "", err
+		recordFailure("wait", err)
+		d.StopContainer()
+		d.RemoveContainer()
+		infraErr := &InfraError{Step: "wait container", Err: err}
+		record(timeline.EventFinished, CID, infraErr)
+		return "", "", infraErr
 	}
 
 	// step 6: read container's output when it finishes
 	cOutput, err := d.ReadOutput()
 	if err != nil {
-		return "", "", err
+		recordFailure("read", err)
+		d.RemoveContainer()
+		infraErr := &InfraError{Step: "read container output", Err: err}
+		record(timeline.EventFinished, CID, infraErr)
+		return "", "", infraErr
 	}
 	log.Info(logActionRun, logInfoHuskyDocker, 34, fullContainerImage, d.CID)
+	record(timeline.EventFinished, CID, nil)
 
 	// step 7: remove container from docker API
 	if err := d.RemoveContainer(); err != nil {
 		log.Error(logActionRun, logInfoHuskyDocker, 3027, err)
+		recordFailure("remove", err)
 		return "", "", err
 	}
 
 	return CID, cOutput, nil
 }
 
-func pullImage(d *Docker, canonicalURL, image string) error {
-	timeout := time.After(15 * time.Minute)
-	retryTick := time.NewTicker(15 * time.Second)
+// readOutputTail returns the last outputTailMaxBytes of d's output or, if they
+// can not be read, an empty string.
+func readOutputTail(d *Docker) string {
+	ctx, cancel := goContext.WithTimeout(goContext.Background(), outputTailTimeout)
+	defer cancel()
+	cOutputTail, _ := d.ReadOutputTail(ctx, outputTailMaxBytes)
+	return cOutputTail
+}
+
+// followOutput starts following d's output in background and returns
+// a function that stops it and waits for the goroutine to return.
+func followOutput(d *Docker, onLine func(line string)) func() {
+	if onLine == nil {
+		return func() {}
+	}
+	ctx, cancel := goContext.WithCancel(goContext.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if err := d.FollowOutput(ctx, onLine); err != nil && err != goContext.Canceled {
+			log.Error(logActionRun, logInfoHuskyDocker, 3028, err)
+		}
+	}()
+	return func() {
+		// give the stream a chance to reach EOF before forcing it to stop
+		select {
+		case <-done:
+		case <-time.After(5 * time.Second):
+		}
+		cancel()
+		<-done
+	}
+}
+
+// imagePuller pulls images into a Docker host, such as a Docker.
+type imagePuller interface {
+	ImageIsLoaded(image string, maxAge time.Duration) (bool, error)
+	PullImage(image string) error
+}
+
+// pullImage pulls image and waits for it to be loaded. It tries right away and then again
+// after a random delay around interval, so analyses started together do not hit the
+// registry in lockstep, until timeout. An image older than maxAge is only pulled once,
+// as the registry may have no newer one. An InfraError is returned if the images of the
+// Docker host can not be listed.
+func pullImage(d imagePuller, canonicalURL, image string, maxAge, interval, timeout time.Duration) error {
+	if interval <= 0 {
+		interval = context.DefaultDockerPullInterval
+	}
+	if timeout <= 0 {
+		timeout = context.DefaultDockerPullTimeout
+	}
+	deadline := time.After(timeout)
+	startedAt := time.Now()
 	for {
+		log.Info(logActionPull, logInfoHuskyDocker, 31, image)
+		loaded, err := d.ImageIsLoaded(image, maxAge)
+		if err != nil {
+			metrics.ImagePullDuration.WithLabelValues("failure").Observe(time.Since(startedAt).Seconds())
+			return &InfraError{Step: "list images", Err: err}
+		}
+		if loaded {
+			log.Info(logActionPull, logInfoHuskyDocker, 35, image)
+			metrics.ImagePullDuration.WithLabelValues("success").Observe(time.Since(startedAt).Seconds())
+			return nil
+		}
+		if err := d.PullImage(canonicalURL); err != nil {
+			log.Error(logActionPull, logInfoHuskyDocker, 3013, err)
+			metrics.ImagePullDuration.WithLabelValues("failure").Observe(time.Since(startedAt).Seconds())
+			return err
+		}
+		maxAge = 0
 		select {
-		case <-timeout:
+		case <-deadline:
 			timeOutErr := errors.New("timeout")
 			log.Error(logActionPull, logInfoHuskyDocker, 3013, timeOutErr)
+			metrics.ImagePullDuration.WithLabelValues("failure").Observe(time.Since(startedAt).Seconds())
 			return timeOutErr
-		case <-retryTick.C:
-			log.Info(logActionPull, logInfoHuskyDocker, 31, image)
-			if d.ImageIsLoaded(image) {
-				log.Info(logActionPull, logInfoHuskyDocker, 35, image)
-				return nil
-			}
-			if err := d.PullImage(canonicalURL); err != nil {
-				log.Error(logActionPull, logInfoHuskyDocker, 3013, err)
-				return err
-			}
+		case <-time.After(pullRetryDelay(interval)):
 		}
 	}
 }
+
+// pullJitter is seeded so huskyCI instances started together do not pull in lockstep either.
+var pullJitter = struct {
+	sync.Mutex
+	*rand.Rand
+}{Rand: rand.New(rand.NewSource(time.Now().UnixNano()))}
+
+// pullRetryDelay returns a random delay between half and one and a half times interval.
+func pullRetryDelay(interval time.Duration) time.Duration {
+	pullJitter.Lock()
+	defer pullJitter.Unlock()
+	return interval/2 + time.Duration(pullJitter.Int63n(int64(interval)))
+}
@@ -0,0 +1,151 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"errors"
+	"sync/atomic"
+	"time"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("IsInfraError", func() {
+
+	Context("When a container could not be created", func() {
+		It("Should return true", func() {
+			err := &dockers.InfraError{Step: "create container", Err: errors.New("connection reset by peer")}
+			Expect(dockers.IsInfraError(err)).To(BeTrue())
+			Expect(err.Error()).To(Equal("could not create container: connection reset by peer"))
+		})
+	})
+
+	Context("When a container timed out or was canceled", func() {
+		It("Should return false", func() {
+			Expect(dockers.IsInfraError(dockers.ErrContainerTimeout)).To(BeFalse())
+			Expect(dockers.IsInfraError(dockers.ErrContainerCanceled)).To(BeFalse())
+			Expect(dockers.IsInfraError(dockers.ErrMutableTag)).To(BeFalse())
+			Expect(dockers.IsInfraError(nil)).To(BeFalse())
+		})
+	})
+})
+
+var _ = Describe("PullRetryDelay", func() {
+
+	It("Should spread retries between half and one and a half times the interval", func() {
+		delays := map[time.Duration]bool{}
+		for i := 0; i < 100; i++ {
+			delay := dockers.PullRetryDelay(10 * time.Second)
+			Expect(delay).To(BeNumerically(">=", 5*time.Second))
+			Expect(delay).To(BeNumerically("<", 15*time.Second))
+			delays[delay] = true
+		}
+		Expect(len(delays)).To(BeNumerically(">", 1))
+	})
+})
+
+// fakeImagePuller loads its image after it is pulled pullsToLoad times. If stale is set,
+// the image is older than any maxAge. listErr is returned when its images are listed. pulls
+// is read by tests while PullImage pulls in background, so it is only accessed atomically.
+type fakeImagePuller struct {
+	pulls       int32
+	pullsToLoad int32
+	pullErr     error
+	listErr     error
+	stale       bool
+}
+
+func (f *fakeImagePuller) ImageIsLoaded(image string, maxAge time.Duration) (bool, error) {
+	if f.listErr != nil {
+		return false, f.listErr
+	}
+	return f.Pulls() >= f.pullsToLoad && (maxAge == 0 || !f.stale), nil
+}
+
+func (f *fakeImagePuller) PullImage(image string) error {
+	atomic.AddInt32(&f.pulls, 1)
+	return f.pullErr
+}
+
+func (f *fakeImagePuller) Pulls() int32 {
+	return atomic.LoadInt32(&f.pulls)
+}
+
+var _ = Describe("PullImage", func() {
+
+	Context("When the image is missing and the registry is healthy", func() {
+		It("Should pull it before the first retry tick", func() {
+			puller := &fakeImagePuller{pullsToLoad: 1}
+			done := make(chan error, 1)
+			go func() {
+				done <- dockers.PullImage(puller, "docker.io/huskyci/gosec:v1", "huskyci/gosec:v1", 0, 20*time.Millisecond, time.Minute)
+			}()
+			// the first tick is at least half of the interval away
+			Eventually(puller.Pulls, 5*time.Millisecond, time.Millisecond).Should(Equal(int32(1)))
+			Eventually(done).Should(Receive(BeNil()))
+		})
+	})
+
+	Context("When the image is already loaded", func() {
+		It("Should not pull it", func() {
+			puller := &fakeImagePuller{}
+			Expect(dockers.PullImage(puller, "docker.io/huskyci/gosec:v1", "huskyci/gosec:v1", 0, time.Hour, time.Hour)).To(BeNil())
+			Expect(puller.Pulls()).To(BeZero())
+		})
+	})
+
+	Context("When the image is older than maxAge", func() {
+		It("Should pull it only once, as the registry may have no newer one", func() {
+			puller := &fakeImagePuller{stale: true}
+			done := make(chan error, 1)
+			go func() {
+				done <- dockers.PullImage(puller, "docker.io/huskyci/gosec:latest", "huskyci/gosec:latest", time.Hour, time.Millisecond, time.Minute)
+			}()
+			Eventually(done).Should(Receive(BeNil()))
+			Expect(puller.Pulls()).To(Equal(int32(1)))
+		})
+	})
+
+	Context("When the images of the Docker host can not be listed", func() {
+		It("Should return an InfraError so the analysis is retried", func() {
+			puller := &fakeImagePuller{listErr: errors.New("context deadline exceeded")}
+			err := dockers.PullImage(puller, "docker.io/huskyci/gosec:v1", "huskyci/gosec:v1", 0, time.Hour, time.Hour)
+			Expect(dockers.IsInfraError(err)).To(BeTrue())
+			Expect(err).To(MatchError("could not list images: context deadline exceeded"))
+			Expect(puller.Pulls()).To(BeZero())
+		})
+	})
+
+	Context("When the registry refuses the pull", func() {
+		It("Should return its error right away", func() {
+			puller := &fakeImagePuller{pullsToLoad: 1, pullErr: errors.New("toomanyrequests")}
+			Expect(dockers.PullImage(puller, "docker.io/huskyci/gosec:v1", "huskyci/gosec:v1", 0, time.Hour, time.Hour)).To(MatchError("toomanyrequests"))
+		})
+	})
+})
+
+var _ = Describe("ImageIsFresh", func() {
+
+	now := time.Date(2020, 6, 1, 12, 0, 0, 0, time.UTC)
+	images := []dockerTypes.ImageSummary{{Created: now.Add(-48 * time.Hour).Unix()}}
+
+	Context("When maxAge is not set", func() {
+		It("Should return whether the image is cached", func() {
+			Expect(dockers.ImageIsFresh(images, 0, now)).To(BeTrue())
+			Expect(dockers.ImageIsFresh(nil, 0, now)).To(BeFalse())
+		})
+	})
+
+	Context("When maxAge is set", func() {
+		It("Should return whether the image was created less than maxAge ago", func() {
+			Expect(dockers.ImageIsFresh(images, 72*time.Hour, now)).To(BeTrue())
+			Expect(dockers.ImageIsFresh(images, 24*time.Hour, now)).To(BeFalse())
+		})
+	})
+})
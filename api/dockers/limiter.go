@@ -0,0 +1,71 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"sync"
+
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
+	goContext "golang.org/x/net/context"
+)
+
+// defaultMaxContainers is used when the CPU count of a Docker host is unknown.
+const defaultMaxContainers = 10
+
+// containerSlots holds a semaphore per Docker host that limits
+// how many containers huskyCI runs there at the same time.
+var (
+	containerSlotsMutex sync.Mutex
+	containerSlots      = map[string]chan struct{}{}
+)
+
+// acquireContainerSlot blocks until d's Docker host runs fewer containers than its limit
+// and returns a function that releases the slot taken. If ctx is done while waiting
+// for a slot, ErrContainerCanceled is returned instead.
+func acquireContainerSlot(ctx goContext.Context, d *Docker) (func(), error) {
+	slots := hostContainerSlots(d)
+	select {
+	case slots <- struct{}{}:
+	default:
+		log.Info(logActionRun, logInfoHuskyDocker, 37, d.host)
+		queueDepth := metrics.ContainerQueueDepth.WithLabelValues(d.host)
+		queueDepth.Inc()
+		defer queueDepth.Dec()
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			return nil, ErrContainerCanceled
+		}
+	}
+	return func() {
+		<-slots
+	}, nil
+}
+
+func hostContainerSlots(d *Docker) chan struct{} {
+	containerSlotsMutex.Lock()
+	defer containerSlotsMutex.Unlock()
+	if slots, ok := containerSlots[d.host]; ok {
+		return slots
+	}
+	size := d.maxContainers
+	if size == 0 {
+		size = d.cpuCount()
+	}
+	slots := make(chan struct{}, size)
+	containerSlots[d.host] = slots
+	return slots
+}
+
+func (d Docker) cpuCount() int {
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
+	info, err := d.client.Info(ctx)
+	if err != nil || info.NCPU < 1 {
+		return defaultMaxContainers
+	}
+	return info.NCPU
+}
@@ -0,0 +1,118 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+
+	dockerTypes "github.com/docker/docker/api/types"
+	"github.com/globocom/huskyCI/api/log"
+	goContext "golang.org/x/net/context"
+)
+
+// logHeaderSize is the size of the header of each frame of the logs of a container without
+// a TTY: the stream it was written to, three zero bytes and the size of the frame.
+const logHeaderSize = 8
+
+// Logs returns the logs of its container, as selected by opts such as ShowStdout or Follow,
+// without buffering them, so large outputs can be streamed to a client. They are
+// demultiplexed if the container has no TTY and, if it has secrets, redacted line by line.
+// The caller must close them, which stops reading them.
+func (d Docker) Logs(ctx goContext.Context, opts dockerTypes.ContainerLogsOptions) (io.ReadCloser, error) {
+	// only the inspection is bound to the request timeout, as the logs may be followed
+	inspectCtx, cancel := d.requestContext(ctx)
+	containerJSON, err := d.client.ContainerInspect(inspectCtx, d.CID)
+	cancel()
+	if err != nil {
+		log.Error("Logs", logInfoAPI, 3006, err)
+		return nil, err
+	}
+	out, err := d.client.ContainerLogs(ctx, d.CID, opts)
+	if err != nil {
+		log.Error("Logs", logInfoAPI, 3006, err)
+		return nil, err
+	}
+	multiplexed := containerJSON.Config == nil || !containerJSON.Config.Tty
+	return newLogReader(out, multiplexed, d.secrets), nil
+}
+
+// logReader reads the logs of a container until it is closed.
+type logReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (lr *logReader) Close() error {
+	var err error
+	for _, closer := range lr.closers {
+		if closeErr := closer.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// newLogReader returns the logs of src, demultiplexed if multiplexed is set and with secrets
+// redacted line by line. Closing it closes src.
+func newLogReader(src io.ReadCloser, multiplexed bool, secrets []string) io.ReadCloser {
+	var logs io.Reader = src
+	if multiplexed {
+		logs = &demuxReader{src: src}
+	}
+	if len(secrets) == 0 {
+		return &logReader{Reader: logs, closers: []io.Closer{src}}
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		lines := bufio.NewReader(logs)
+		for {
+			line, err := lines.ReadString('\n')
+			if line != "" {
+				if _, writeErr := io.WriteString(pw, SanitizeOutput(line, secrets)); writeErr != nil {
+					return
+				}
+			}
+			if err != nil {
+				if err == io.EOF {
+					err = nil
+				}
+				pw.CloseWithError(err)
+				return
+			}
+		}
+	}()
+	// closing src unblocks the reads of the goroutine above
+	return &logReader{Reader: pr, closers: []io.Closer{pr, src}}
+}
+
+// demuxReader reads the payload of the frames of the logs of a container without a TTY.
+// Frames of stdout and stderr are read in the order they were written.
+type demuxReader struct {
+	src io.Reader
+	// remaining is how much of the payload of the current frame is left to read.
+	remaining uint32
+}
+
+func (dr *demuxReader) Read(p []byte) (int, error) {
+	for dr.remaining == 0 {
+		header := make([]byte, logHeaderSize)
+		// a stream that ends between frames ends with io.EOF
+		if _, err := io.ReadFull(dr.src, header); err != nil {
+			return 0, err
+		}
+		dr.remaining = binary.BigEndian.Uint32(header[4:])
+	}
+	if uint32(len(p)) > dr.remaining {
+		p = p[:dr.remaining]
+	}
+	n, err := dr.src.Read(p)
+	dr.remaining -= uint32(n)
+	if err == io.EOF && dr.remaining > 0 {
+		err = io.ErrUnexpectedEOF
+	}
+	return n, err
+}
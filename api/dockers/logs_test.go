@@ -0,0 +1,74 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"io/ioutil"
+
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// logFrame returns payload as a frame of the logs of a container without a TTY.
+func logFrame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+// closeRecorder records whether it was closed.
+type closeRecorder struct {
+	io.Reader
+	closed bool
+}
+
+func (cr *closeRecorder) Close() error {
+	cr.closed = true
+	return nil
+}
+
+var _ = Describe("NewLogReader", func() {
+
+	Context("When the container has no TTY", func() {
+		It("Should return the payload of its frames in order", func() {
+			stream := bytes.Join([][]byte{logFrame(1, "Run started\n"), logFrame(2, "warning: "), logFrame(1, "done\n")}, nil)
+			src := &closeRecorder{Reader: bytes.NewReader(stream)}
+			logs := dockers.NewLogReader(src, true, nil)
+			Expect(ioutil.ReadAll(logs)).To(Equal([]byte("Run started\nwarning: done\n")))
+			Expect(logs.Close()).To(Succeed())
+			Expect(src.closed).To(BeTrue())
+		})
+		It("Should return io.ErrUnexpectedEOF if a frame is cut", func() {
+			stream := logFrame(1, "Run started\n")
+			src := &closeRecorder{Reader: bytes.NewReader(stream[:len(stream)-3])}
+			_, err := ioutil.ReadAll(dockers.NewLogReader(src, true, nil))
+			Expect(err).To(Equal(io.ErrUnexpectedEOF))
+		})
+	})
+
+	Context("When the container has a TTY", func() {
+		It("Should return its logs as they are", func() {
+			src := &closeRecorder{Reader: bytes.NewBufferString("Run started\ndone")}
+			Expect(ioutil.ReadAll(dockers.NewLogReader(src, false, nil))).To(Equal([]byte("Run started\ndone")))
+		})
+	})
+
+	Context("When the container has secrets", func() {
+		It("Should redact them line by line", func() {
+			stream := bytes.Join([][]byte{logFrame(1, "token=s3cr3t-"), logFrame(1, "value\nok\n"), logFrame(1, "last s3cr3t-value")}, nil)
+			src := &closeRecorder{Reader: bytes.NewReader(stream)}
+			logs := dockers.NewLogReader(src, true, []string{"s3cr3t-value"})
+			Expect(ioutil.ReadAll(logs)).To(Equal([]byte("token=[REDACTED]\nok\nlast [REDACTED]")))
+			Expect(logs.Close()).To(Succeed())
+			Expect(src.closed).To(BeTrue())
+		})
+	})
+})
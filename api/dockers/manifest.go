@@ -0,0 +1,277 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// Media types of the manifests read by ImagePlatforms. Lists and indexes have a manifest for
+// each platform of a multi-arch image.
+const (
+	mediaTypeManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+	mediaTypeOCIIndex     = "application/vnd.oci.image.index.v1+json"
+	mediaTypeManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeOCIManifest  = "application/vnd.oci.image.manifest.v1+json"
+)
+
+// dockerHubRegistry is the registry of images whose name has no registry, such as huskyci/gosec.
+const dockerHubRegistry = "registry-1.docker.io"
+
+// ImageReference is an image split into the registry it is pulled from, its repository and
+// its tag or digest.
+type ImageReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+}
+
+// ParseImageReference returns the reference of image, such as huskyci/gosec:2.1.0, as Docker
+// reads it: images with no registry are pulled from Docker Hub and with no tag, by latest.
+func ParseImageReference(image string) ImageReference {
+	name, tag := image, "latest"
+	if at := strings.Index(image, "@"); at >= 0 {
+		name, tag = image[:at], image[at+1:]
+	} else if colon := strings.LastIndex(image, ":"); colon > strings.LastIndex(image, "/") {
+		name, tag = image[:colon], image[colon+1:]
+	}
+	registry := dockerHubRegistry
+	if parts := strings.SplitN(name, "/", 2); len(parts) == 2 && (strings.ContainsAny(parts[0], ".:") || parts[0] == "localhost") {
+		registry, name = parts[0], parts[1]
+	}
+	if registry == "docker.io" || registry == "index.docker.io" {
+		registry = dockerHubRegistry
+	}
+	if registry == dockerHubRegistry && !strings.Contains(name, "/") {
+		name = "library/" + name
+	}
+	return ImageReference{Registry: registry, Repository: name, Tag: tag}
+}
+
+// imageManifest holds the fields of a manifest, or of a list of them, ImagePlatforms reads.
+type imageManifest struct {
+	MediaType string `json:"mediaType"`
+	Manifests []struct {
+		Platform imagePlatform `json:"platform"`
+	} `json:"manifests"`
+	Config struct {
+		Digest string `json:"digest"`
+	} `json:"config"`
+	// Architecture is only set by manifests of schema 1.
+	Architecture string `json:"architecture"`
+}
+
+// imagePlatform is the platform of an image, as in a manifest list or an image config.
+type imagePlatform struct {
+	OS           string `json:"os"`
+	Architecture string `json:"architecture"`
+	Variant      string `json:"variant"`
+}
+
+func (platform imagePlatform) String() string {
+	name := platform.OS + "/" + platform.Architecture
+	if platform.Variant != "" {
+		name += "/" + platform.Variant
+	}
+	return name
+}
+
+// ManifestPlatforms returns the platforms of a manifest list, or of an index, of mediaType. A
+// single manifest has no platforms, so the digest of its config, which has it, is returned.
+func ManifestPlatforms(mediaType string, body []byte) ([]string, string, error) {
+	manifest := imageManifest{}
+	if err := json.Unmarshal(body, &manifest); err != nil {
+		return nil, "", err
+	}
+	if mediaType == "" || mediaType == "application/json" {
+		mediaType = manifest.MediaType
+	}
+	switch {
+	case mediaType == mediaTypeManifestList || mediaType == mediaTypeOCIIndex || len(manifest.Manifests) > 0:
+		platforms := []string{}
+		for _, listed := range manifest.Manifests {
+			// attestations of images built by buildx are listed as unknown/unknown
+			if listed.Platform.OS == "" || listed.Platform.OS == "unknown" {
+				continue
+			}
+			platforms = append(platforms, listed.Platform.String())
+		}
+		return platforms, "", nil
+	case manifest.Config.Digest != "":
+		return nil, manifest.Config.Digest, nil
+	case manifest.Architecture != "":
+		return []string{imagePlatform{OS: "linux", Architecture: manifest.Architecture}.String()}, "", nil
+	}
+	return nil, "", fmt.Errorf("unknown manifest media type %s", mediaType)
+}
+
+// ConfigPlatform returns the platform of an image config.
+func ConfigPlatform(body []byte) (string, error) {
+	platform := imagePlatform{}
+	if err := json.Unmarshal(body, &platform); err != nil {
+		return "", err
+	}
+	if platform.Architecture == "" {
+		return "", fmt.Errorf("image config has no architecture")
+	}
+	if platform.OS == "" {
+		platform.OS = "linux"
+	}
+	return platform.String(), nil
+}
+
+// ManifestClient reads the manifests of images from their registries, anonymously.
+type ManifestClient struct {
+	Client *http.Client
+	// Scheme registries are reached with, https unless they are test servers.
+	Scheme string
+}
+
+// NewManifestClient returns a ManifestClient that reaches registries through the proxy of huskyCI.
+func NewManifestClient() *ManifestClient {
+	return &ManifestClient{
+		Client: &http.Client{Timeout: 30 * time.Second, Transport: util.ProxiedTransport()},
+		Scheme: "https",
+	}
+}
+
+// ImagePlatforms returns the platforms image, such as huskyci/gosec:2.1.0, is built for, such
+// as linux/amd64 and linux/arm64/v8, as its registry lists them.
+func (mc *ManifestClient) ImagePlatforms(image string) ([]string, error) {
+	ref := ParseImageReference(image)
+	token := ""
+	accept := strings.Join([]string{mediaTypeManifestList, mediaTypeOCIIndex, mediaTypeManifest, mediaTypeOCIManifest}, ", ")
+	body, mediaType, err := mc.get(ref, "manifests/"+ref.Tag, accept, &token)
+	if err != nil {
+		return nil, err
+	}
+	platforms, configDigest, err := ManifestPlatforms(mediaType, body)
+	if err != nil || configDigest == "" {
+		return platforms, err
+	}
+	configBody, _, err := mc.get(ref, "blobs/"+configDigest, "", &token)
+	if err != nil {
+		return nil, err
+	}
+	platform, err := ConfigPlatform(configBody)
+	if err != nil {
+		return nil, err
+	}
+	return []string{platform}, nil
+}
+
+// get returns the body and the media type of the object at path of the repository of ref. If
+// the registry asks for a bearer token, an anonymous one is requested and kept in token.
+func (mc *ManifestClient) get(ref ImageReference, path, accept string, token *string) ([]byte, string, error) {
+	objectURL := fmt.Sprintf("%s://%s/v2/%s/%s", mc.Scheme, ref.Registry, ref.Repository, path)
+	for attempt := 0; attempt < 2; attempt++ {
+		req, err := http.NewRequest(http.MethodGet, objectURL, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		if accept != "" {
+			req.Header.Set("Accept", accept)
+		}
+		if *token != "" {
+			req.Header.Set("Authorization", "Bearer "+*token)
+		}
+		resp, err := mc.Client.Do(req)
+		if err != nil {
+			return nil, "", err
+		}
+		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, "", err
+		}
+		if resp.StatusCode == http.StatusUnauthorized && *token == "" {
+			if *token, err = mc.anonymousToken(resp.Header.Get("WWW-Authenticate")); err != nil {
+				return nil, "", err
+			}
+			continue
+		}
+		if resp.StatusCode != http.StatusOK {
+			return nil, "", fmt.Errorf("registry returned status code %d for %s", resp.StatusCode, objectURL)
+		}
+		return body, resp.Header.Get("Content-Type"), nil
+	}
+	return nil, "", fmt.Errorf("registry refused the token for %s", objectURL)
+}
+
+// anonymousToken requests a pull token from the realm of the bearer challenge of a registry,
+// such as: Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="...".
+func (mc *ManifestClient) anonymousToken(challenge string) (string, error) {
+	params := ParseBearerChallenge(challenge)
+	realm := params["realm"]
+	if realm == "" {
+		return "", fmt.Errorf("registry asked for an unsupported authentication: %s", challenge)
+	}
+	query := url.Values{}
+	for _, param := range []string{"service", "scope"} {
+		if params[param] != "" {
+			query.Set(param, params[param])
+		}
+	}
+	resp, err := mc.Client.Get(realm + "?" + query.Encode())
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("registry token service returned status code %d", resp.StatusCode)
+	}
+	tokenResponse := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&tokenResponse); err != nil {
+		return "", err
+	}
+	if tokenResponse.Token != "" {
+		return tokenResponse.Token, nil
+	}
+	return tokenResponse.AccessToken, nil
+}
+
+// ParseBearerChallenge returns the parameters of the bearer challenge of a WWW-Authenticate
+// header, or none if it is not one.
+func ParseBearerChallenge(challenge string) map[string]string {
+	params := map[string]string{}
+	if !strings.HasPrefix(strings.ToLower(challenge), "bearer ") {
+		return params
+	}
+	rest := strings.TrimSpace(challenge[len("bearer "):])
+	for rest != "" {
+		eq := strings.Index(rest, "=")
+		if eq < 0 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(rest[:eq]))
+		rest = strings.TrimSpace(rest[eq+1:])
+		value := ""
+		if strings.HasPrefix(rest, `"`) {
+			end := strings.Index(rest[1:], `"`)
+			if end < 0 {
+				break
+			}
+			value, rest = rest[1:end+1], rest[end+2:]
+		} else if comma := strings.Index(rest, ","); comma >= 0 {
+			value, rest = rest[:comma], rest[comma:]
+		} else {
+			value, rest = rest, ""
+		}
+		params[key] = value
+		rest = strings.TrimPrefix(strings.TrimSpace(rest), ",")
+	}
+	return params
+}
@@ -0,0 +1,132 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseImageReference", func() {
+
+	It("Should pull images with no registry from Docker Hub", func() {
+		Expect(dockers.ParseImageReference("huskyci/gosec:2.1.0")).To(Equal(dockers.ImageReference{Registry: "registry-1.docker.io", Repository: "huskyci/gosec", Tag: "2.1.0"}))
+	})
+
+	It("Should pull official images from the library of Docker Hub by latest", func() {
+		Expect(dockers.ParseImageReference("docker.io/alpine")).To(Equal(dockers.ImageReference{Registry: "registry-1.docker.io", Repository: "library/alpine", Tag: "latest"}))
+	})
+
+	It("Should keep the registry and port of other registries", func() {
+		Expect(dockers.ParseImageReference("localhost:5000/huskyci/gosec:2.1.0")).To(Equal(dockers.ImageReference{Registry: "localhost:5000", Repository: "huskyci/gosec", Tag: "2.1.0"}))
+	})
+
+	It("Should read digests as tags", func() {
+		Expect(dockers.ParseImageReference("ghcr.io/huskyci/gosec@sha256:abc").Tag).To(Equal("sha256:abc"))
+	})
+})
+
+var _ = Describe("ManifestPlatforms", func() {
+
+	Context("When the manifest is a list", func() {
+		It("Should return its platforms, skipping attestations", func() {
+			list := `{"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"platform":{"os":"linux","architecture":"amd64"}},{"platform":{"os":"linux","architecture":"arm64","variant":"v8"}},{"platform":{"os":"unknown","architecture":"unknown"}}]}`
+			platforms, configDigest, err := dockers.ManifestPlatforms("", []byte(list))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platforms).To(Equal([]string{"linux/amd64", "linux/arm64/v8"}))
+			Expect(configDigest).To(BeEmpty())
+		})
+	})
+
+	Context("When the manifest is of a single platform", func() {
+		It("Should return the digest of its config", func() {
+			manifest := `{"schemaVersion":2,"config":{"digest":"sha256:abc"}}`
+			platforms, configDigest, err := dockers.ManifestPlatforms("application/vnd.docker.distribution.manifest.v2+json", []byte(manifest))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(platforms).To(BeEmpty())
+			Expect(configDigest).To(Equal("sha256:abc"))
+		})
+	})
+
+	Context("When the manifest is unknown", func() {
+		It("Should return an error", func() {
+			_, _, err := dockers.ManifestPlatforms("", []byte(`{}`))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("ParseBearerChallenge", func() {
+
+	It("Should return the parameters of the challenge", func() {
+		challenge := `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:huskyci/gosec:pull"`
+		Expect(dockers.ParseBearerChallenge(challenge)).To(Equal(map[string]string{
+			"realm":   "https://auth.docker.io/token",
+			"service": "registry.docker.io",
+			"scope":   "repository:huskyci/gosec:pull",
+		}))
+	})
+
+	It("Should return no parameters for other challenges", func() {
+		Expect(dockers.ParseBearerChallenge(`Basic realm="registry"`)).To(BeEmpty())
+	})
+})
+
+var _ = Describe("NormalizeArchitecture", func() {
+
+	It("Should return the names of architectures in manifests", func() {
+		Expect(dockers.NormalizeArchitecture("x86_64")).To(Equal("amd64"))
+		Expect(dockers.NormalizeArchitecture("aarch64")).To(Equal("arm64"))
+		Expect(dockers.NormalizeArchitecture("arm64")).To(Equal("arm64"))
+	})
+})
+
+var _ = Describe("ImagePlatforms", func() {
+
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		mux := http.NewServeMux()
+		mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+			Expect(r.URL.Query().Get("scope")).To(Equal("repository:huskyci/gosec:pull"))
+			w.Write([]byte(`{"token":"anonymous"}`))
+		})
+		mux.HandleFunc("/v2/huskyci/gosec/manifests/2.1.0", func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") != "Bearer anonymous" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",scope="repository:huskyci/gosec:pull"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+			w.Write([]byte(`{"config":{"digest":"sha256:abc"}}`))
+		})
+		mux.HandleFunc("/v2/huskyci/gosec/blobs/sha256:abc", func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(`{"os":"linux","architecture":"amd64"}`))
+		})
+		server = httptest.NewServer(mux)
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	It("Should read the platform of a single manifest from its config, with an anonymous token", func() {
+		client := &dockers.ManifestClient{Client: server.Client(), Scheme: "http"}
+		image := strings.TrimPrefix(server.URL, "http://") + "/huskyci/gosec:2.1.0"
+		Expect(client.ImagePlatforms(image)).To(Equal([]string{"linux/amd64"}))
+	})
+
+	It("Should return an error for images the registry does not have", func() {
+		client := &dockers.ManifestClient{Client: server.Client(), Scheme: "http"}
+		_, err := client.ImagePlatforms(strings.TrimPrefix(server.URL, "http://") + "/huskyci/bandit:1.0.0")
+		Expect(err).To(HaveOccurred())
+	})
+})
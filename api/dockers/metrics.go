@@ -0,0 +1,60 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"net"
+	"strings"
+
+	"github.com/docker/docker/client"
+	"github.com/globocom/huskyCI/api/metrics"
+	goContext "golang.org/x/net/context"
+)
+
+// Classes of the errors of containers, as counted by metrics.ContainerFailures.
+const (
+	ErrorClassTimeout    = "timeout"
+	ErrorClassConnection = "connection"
+	ErrorClassNotFound   = "not_found"
+	ErrorClassOther      = "other"
+)
+
+// ErrorClass returns the class err is counted as when a container fails, so failures can be
+// told apart without a label per error message.
+func ErrorClass(err error) string {
+	if infraErr, ok := err.(*InfraError); ok {
+		err = infraErr.Err
+	}
+	if err == ErrContainerTimeout || err == goContext.DeadlineExceeded {
+		return ErrorClassTimeout
+	}
+	if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+		return ErrorClassTimeout
+	}
+	if err == ErrCircuitOpen || client.IsErrConnectionFailed(err) {
+		return ErrorClassConnection
+	}
+	if _, ok := err.(net.Error); ok {
+		return ErrorClassConnection
+	}
+	if client.IsErrNotFound(err) {
+		return ErrorClassNotFound
+	}
+	message := strings.ToLower(err.Error())
+	switch {
+	case strings.Contains(message, "timeout"):
+		return ErrorClassTimeout
+	case strings.Contains(message, "connection refused"), strings.Contains(message, "cannot connect"):
+		return ErrorClassConnection
+	case strings.Contains(message, "not found"), strings.Contains(message, "no such"):
+		return ErrorClassNotFound
+	}
+	return ErrorClassOther
+}
+
+// recordFailure counts a container that failed in phase, such as pull or create, with err.
+func recordFailure(phase string, err error) {
+	metrics.ContainerFailures.WithLabelValues(phase, ErrorClass(err)).Inc()
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"errors"
+
+	"github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ErrorClass", func() {
+
+	It("Should classify a container that timed out", func() {
+		Expect(dockers.ErrorClass(dockers.ErrContainerTimeout)).To(Equal(dockers.ErrorClassTimeout))
+	})
+
+	It("Should classify the error of an infrastructure step by its cause", func() {
+		err := &dockers.InfraError{Step: "connect to the Docker API", Err: dockers.ErrCircuitOpen}
+		Expect(dockers.ErrorClass(err)).To(Equal(dockers.ErrorClassConnection))
+	})
+
+	It("Should classify an image that does not exist", func() {
+		err := errors.New("Error: image huskyci/missing:latest not found")
+		Expect(dockers.ErrorClass(err)).To(Equal(dockers.ErrorClassNotFound))
+	})
+
+	It("Should classify any other error as other", func() {
+		Expect(dockers.ErrorClass(errors.New("no space left on device"))).To(Equal(dockers.ErrorClassOther))
+	})
+})
+
+var _ = Describe("RecordFailure", func() {
+
+	It("Should count the failure by phase and class in the registry", func() {
+		failures := metrics.ContainerFailures.WithLabelValues("pull", dockers.ErrorClassTimeout)
+		before := testutil.ToFloat64(failures)
+		dockers.RecordFailure("pull", errors.New("timeout"))
+		Expect(testutil.ToFloat64(failures)).To(Equal(before + 1))
+		Expect(testutil.CollectAndCount(metrics.ContainerFailures)).To(BeNumerically(">=", 1))
+	})
+})
@@ -0,0 +1,96 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"runtime"
+	"strings"
+	"sync"
+
+	"github.com/globocom/huskyCI/api/log"
+	goContext "golang.org/x/net/context"
+)
+
+// architectures are the names of architectures as reported by uname, such as the Docker
+// daemon does, by their name in image manifests.
+var architectures = map[string]string{
+	"x86_64":  "amd64",
+	"aarch64": "arm64",
+	"armv7l":  "arm",
+	"i386":    "386",
+	"i686":    "386",
+}
+
+// hostPlatform is the platform of the Docker host, such as linux/arm64, once it is detected.
+var hostPlatform = struct {
+	sync.Mutex
+	platform string
+}{}
+
+// NormalizeArchitecture returns the name of architecture in image manifests, such as arm64
+// for aarch64.
+func NormalizeArchitecture(architecture string) string {
+	architecture = strings.ToLower(architecture)
+	if normalized, ok := architectures[architecture]; ok {
+		return normalized
+	}
+	return architecture
+}
+
+// DetectHostPlatform returns the platform of the Docker host, such as linux/arm64, and keeps it
+// for HostPlatform. If the Docker host can not be asked, the architecture huskyCI runs on is
+// assumed, as they usually are the same.
+func DetectHostPlatform() string {
+	platform := "linux/" + runtime.GOARCH
+	if d, err := NewDocker(); err == nil {
+		ctx, cancel := d.requestContext(goContext.Background())
+		info, err := d.client.Info(ctx)
+		cancel()
+		d.Release()
+		if err == nil && info.Architecture != "" {
+			osType := info.OSType
+			if osType == "" {
+				osType = "linux"
+			}
+			platform = osType + "/" + NormalizeArchitecture(info.Architecture)
+		}
+	}
+	hostPlatform.Lock()
+	hostPlatform.platform = platform
+	hostPlatform.Unlock()
+	log.Info("DetectHostPlatform", logInfoAPI, 48, platform)
+	return platform
+}
+
+// HostPlatform returns the platform of the Docker host found by DetectHostPlatform, or the one
+// huskyCI runs on if it was not detected yet.
+func HostPlatform() string {
+	hostPlatform.Lock()
+	defer hostPlatform.Unlock()
+	if hostPlatform.platform == "" {
+		return "linux/" + runtime.GOARCH
+	}
+	return hostPlatform.platform
+}
+
+// HostArchitecture returns the architecture of the platform of the Docker host, such as arm64.
+func HostArchitecture() string {
+	platform := HostPlatform()
+	return platform[strings.LastIndex(platform, "/")+1:]
+}
+
+// warnEmulatedImage logs a warning if image, just pulled, was built for another architecture
+// than the one of the Docker host, as it runs under emulation, many times slower.
+func warnEmulatedImage(d *Docker, image string) {
+	ctx, cancel := d.requestContext(goContext.Background())
+	defer cancel()
+	imageInspect, _, err := d.client.ImageInspectWithRaw(ctx, image)
+	if err != nil || imageInspect.Architecture == "" {
+		return
+	}
+	if architecture := NormalizeArchitecture(imageInspect.Architecture); architecture != HostArchitecture() {
+		log.Warning("warnEmulatedImage", logInfoAPI, 135, image, architecture, HostPlatform())
+	}
+}
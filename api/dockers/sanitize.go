@@ -0,0 +1,53 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"sort"
+	"strings"
+)
+
+// redactedSecret replaces secrets in the output of containers.
+const redactedSecret = "[REDACTED]"
+
+// minRedactedLineSize is the minimum size of a line of a multi-line secret redacted on
+// its own, so lines such as "-----END RSA PRIVATE KEY-----" of a key are not redacted
+// wherever they appear, unlike the base64 ones between them.
+const minRedactedLineSize = 32
+
+// SanitizeOutput replaces every occurrence of secrets in output with [REDACTED], such as
+// the private SSH key echoed by a cmd run with set -x. A multi-line secret is also
+// redacted when its line breaks are printed as CRLF or escaped as in JSON, and each of
+// its long lines is redacted on its own in case the secret is printed in parts.
+func SanitizeOutput(output string, secrets []string) string {
+	variants := []string{}
+	for _, secret := range secrets {
+		secret = strings.TrimSpace(secret)
+		if secret == "" {
+			continue
+		}
+		variants = append(variants, secret)
+		if !strings.Contains(secret, "\n") {
+			continue
+		}
+		variants = append(variants,
+			strings.Replace(secret, "\n", "\r\n", -1),
+			strings.Replace(secret, "\n", `\n`, -1),
+		)
+		for _, line := range strings.Split(secret, "\n") {
+			if line = strings.TrimSpace(line); len(line) >= minRedactedLineSize {
+				variants = append(variants, line)
+			}
+		}
+	}
+	// a whole secret is redacted before its lines, so no part of it is left around them
+	sort.SliceStable(variants, func(i, j int) bool {
+		return len(variants[i]) > len(variants[j])
+	})
+	for _, variant := range variants {
+		output = strings.Replace(output, variant, redactedSecret, -1)
+	}
+	return output
+}
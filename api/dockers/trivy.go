@@ -0,0 +1,97 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/util"
+	goContext "golang.org/x/net/context"
+)
+
+// Image and tag of Trivy, which ImageVulnScan runs to scan images for vulnerabilities.
+const (
+	TrivyImage    = "huskyci/trivy"
+	TrivyImageTag = "0.16.0"
+)
+
+// trivyTimeOutInSeconds is how long ImageVulnScan waits for Trivy, which downloads its
+// vulnerability database on every run.
+const trivyTimeOutInSeconds = 600
+
+// trivyErrorOutput is printed by the cmd of ImageVulnScan if Trivy fails.
+const trivyErrorOutput = "ERROR_RUNNING_TRIVY"
+
+// ErrVulnerableImage is returned when an image with CRITICAL vulnerabilities would be pulled
+// while HUSKYCI_ALLOW_VULNERABLE_IMAGES is not set.
+var ErrVulnerableImage = errors.New("image has CRITICAL vulnerabilities")
+
+// ImageGate, if set, is called with the image DockerRunWithProgress is about to run, whether
+// it is loaded already or not. The image is not pulled and its container is not run if it
+// returns an error.
+var ImageGate func(ctx goContext.Context, image string) error
+
+// TrivyVulnerability is a vulnerability Trivy found in a package of an image.
+type TrivyVulnerability struct {
+	VulnerabilityID  string `json:"VulnerabilityID"`
+	PkgName          string `json:"PkgName"`
+	InstalledVersion string `json:"InstalledVersion"`
+	FixedVersion     string `json:"FixedVersion"`
+	Severity         string `json:"Severity"`
+	Title            string `json:"Title"`
+}
+
+// trivyResult is the result of a target of an image, such as its OS packages.
+type trivyResult struct {
+	Target          string               `json:"Target"`
+	Vulnerabilities []TrivyVulnerability `json:"Vulnerabilities"`
+}
+
+// ParseTrivyOutput returns the vulnerabilities of every target of a Trivy JSON report. Both the
+// current report, an object with Results, and the list of results of older versions are read.
+func ParseTrivyOutput(output string) ([]TrivyVulnerability, error) {
+	output = strings.TrimSpace(output)
+	if strings.Contains(output, trivyErrorOutput) {
+		return nil, errors.New("trivy could not scan the image")
+	}
+	var results []trivyResult
+	if strings.HasPrefix(output, "[") {
+		if err := json.Unmarshal([]byte(output), &results); err != nil {
+			return nil, err
+		}
+	} else {
+		report := struct {
+			Results []trivyResult `json:"Results"`
+		}{}
+		if err := json.Unmarshal([]byte(output), &report); err != nil {
+			return nil, err
+		}
+		results = report.Results
+	}
+	vulnerabilities := []TrivyVulnerability{}
+	for _, result := range results {
+		vulnerabilities = append(vulnerabilities, result.Vulnerabilities...)
+	}
+	return vulnerabilities, nil
+}
+
+// ImageVulnScan runs Trivy in image mode against imageRef, such as huskyci/gosec:2.1.0, and
+// returns the vulnerabilities it found. Trivy pulls imageRef from its registry itself.
+func ImageVulnScan(ctx goContext.Context, imageRef string) ([]TrivyVulnerability, error) {
+	cmd := fmt.Sprintf("trivy image --quiet --no-progress --format json %s 2> /dev/null || echo '%s'", util.ShellQuote(imageRef), trivyErrorOutput)
+	_, cOutput, err := DockerRunWithProgress(ctx, TrivyImage, TrivyImageTag, cmd, "", nil, nil, "", nil, trivyTimeOutInSeconds, nil)
+	if err == nil {
+		var vulnerabilities []TrivyVulnerability
+		if vulnerabilities, err = ParseTrivyOutput(cOutput); err == nil {
+			return vulnerabilities, nil
+		}
+	}
+	log.Error("ImageVulnScan", logInfoHuskyDocker, 3033, imageRef, err)
+	return nil, err
+}
@@ -0,0 +1,48 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package dockers_test
+
+import (
+	"github.com/globocom/huskyCI/api/dockers"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ParseTrivyOutput", func() {
+
+	openssl := dockers.TrivyVulnerability{VulnerabilityID: "CVE-2021-3450", PkgName: "libssl1.1", InstalledVersion: "1.1.1i-r0", FixedVersion: "1.1.1k-r0", Severity: "CRITICAL"}
+
+	Context("When Trivy reports an object with Results", func() {
+		It("Should return the vulnerabilities of every target", func() {
+			output := `{"SchemaVersion":2,"Results":[{"Target":"huskyci/gosec:2.1.0 (alpine 3.11)","Vulnerabilities":[{"VulnerabilityID":"CVE-2021-3450","PkgName":"libssl1.1","InstalledVersion":"1.1.1i-r0","FixedVersion":"1.1.1k-r0","Severity":"CRITICAL"}]},{"Target":"go.sum","Vulnerabilities":[{"VulnerabilityID":"CVE-2020-29652","PkgName":"golang.org/x/crypto","Severity":"HIGH"}]}]}`
+			vulnerabilities, err := dockers.ParseTrivyOutput(output)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(vulnerabilities).To(HaveLen(2))
+			Expect(vulnerabilities[0]).To(Equal(openssl))
+			Expect(vulnerabilities[1].Severity).To(Equal("HIGH"))
+		})
+	})
+
+	Context("When an older Trivy reports a list of results", func() {
+		It("Should return their vulnerabilities", func() {
+			output := `[{"Target":"huskyci/gosec:2.1.0 (alpine 3.11)","Vulnerabilities":[{"VulnerabilityID":"CVE-2021-3450","PkgName":"libssl1.1","InstalledVersion":"1.1.1i-r0","FixedVersion":"1.1.1k-r0","Severity":"CRITICAL"}]}]`
+			Expect(dockers.ParseTrivyOutput(output)).To(Equal([]dockers.TrivyVulnerability{openssl}))
+		})
+	})
+
+	Context("When the image has no vulnerabilities", func() {
+		It("Should return none", func() {
+			Expect(dockers.ParseTrivyOutput(`{"Results":[{"Target":"huskyci/gosec:2.1.0","Vulnerabilities":null}]}`)).To(BeEmpty())
+		})
+	})
+
+	Context("When Trivy could not run", func() {
+		It("Should return an error", func() {
+			_, err := dockers.ParseTrivyOutput("ERROR_RUNNING_TRIVY\n")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
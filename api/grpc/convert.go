@@ -0,0 +1,124 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpc
+
+import (
+	"sort"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/grpc/huskycipb"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/golang/protobuf/ptypes"
+	"github.com/golang/protobuf/ptypes/timestamp"
+)
+
+func toTimestamp(t time.Time) *timestamp.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := ptypes.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+func toAnalysis(analysisResult types.Analysis) *huskycipb.Analysis {
+	containers := []*huskycipb.Container{}
+	for _, container := range analysisResult.Containers {
+		containers = append(containers, toContainer(container))
+	}
+	return &huskycipb.Analysis{
+		AnalysisId:       analysisResult.RID,
+		RepositoryUrl:    analysisResult.URL,
+		RepositoryBranch: analysisResult.Branch,
+		CommitAuthors:    analysisResult.CommitAuthors,
+		Status:           analysisResult.Status,
+		Result:           analysisResult.Result,
+		ErrorFound:       analysisResult.ErrorFound,
+		Containers:       containers,
+		StartedAt:        toTimestamp(analysisResult.StartedAt),
+		FinishedAt:       toTimestamp(analysisResult.FinishedAt),
+		Vulnerabilities:  toVulnerabilities(analysisResult.HuskyCIResults),
+	}
+}
+
+func toContainer(container types.Container) *huskycipb.Container {
+	return &huskycipb.Container{
+		ContainerId:  container.CID,
+		SecurityTest: container.SecurityTest.Name,
+		Status:       container.CStatus,
+		Result:       container.CResult,
+		Info:         container.CInfo,
+		StartedAt:    toTimestamp(container.StartedAt),
+		FinishedAt:   toTimestamp(container.FinishedAt),
+	}
+}
+
+func toVulnerabilities(results types.HuskyCIResults) []*huskycipb.Vulnerability {
+	outputs := []types.HuskyCISecurityTestOutput{
+		results.GoResults.HuskyCIGosecOutput,
+		results.PythonResults.HuskyCIBanditOutput,
+		results.PythonResults.HuskyCISafetyOutput,
+		results.JavaScriptResults.HuskyCINpmAuditOutput,
+		results.JavaScriptResults.HuskyCIYarnAuditOutput,
+		results.JavaScriptResults.HuskyCIEslintOutput,
+		results.JavaResults.HuskyCISpotBugsOutput,
+		results.JavaResults.HuskyCIDependencyCheckOutput,
+		results.RubyResults.HuskyCIBrakemanOutput,
+		results.GenericResults.HuskyCIGitleaksOutput,
+		results.GenericResults.HuskyCIDepConfusionOutput,
+		results.GenericResults.HuskyCIHadolintOutput,
+	}
+	pluginNames := []string{}
+	for name := range results.PluginResults {
+		pluginNames = append(pluginNames, name)
+	}
+	sort.Strings(pluginNames)
+	for _, name := range pluginNames {
+		outputs = append(outputs, results.PluginResults[name])
+	}
+	vulnerabilities := []*huskycipb.Vulnerability{}
+	for _, output := range outputs {
+		for _, vulns := range [][]types.HuskyCIVulnerability{output.HighVulns, output.MediumVulns, output.LowVulns, output.NoSecVulns} {
+			for _, vuln := range vulns {
+				vulnerabilities = append(vulnerabilities, toVulnerability(vuln))
+			}
+		}
+	}
+	return vulnerabilities
+}
+
+func toVulnerability(vuln types.HuskyCIVulnerability) *huskycipb.Vulnerability {
+	return &huskycipb.Vulnerability{
+		Language:        vuln.Language,
+		SecurityTool:    vuln.SecurityTool,
+		Severity:        vuln.Severity,
+		Confidence:      vuln.Confidence,
+		File:            vuln.File,
+		Line:            vuln.Line,
+		Code:            vuln.Code,
+		Details:         vuln.Details,
+		Type:            vuln.Type,
+		VulnerableBelow: vuln.VunerableBelow,
+		Version:         vuln.Version,
+		Occurrences:     int32(vuln.Occurrences),
+	}
+}
+
+func toEvent(event analysis.Event) *huskycipb.AnalysisEvent {
+	analysisEvent := &huskycipb.AnalysisEvent{
+		AnalysisId: event.RID,
+		Type:       event.Type,
+		Status:     event.Status,
+		Result:     event.Result,
+		Time:       toTimestamp(event.Time),
+	}
+	if event.Container != nil {
+		analysisEvent.Container = toContainer(*event.Container)
+	}
+	return analysisEvent
+}
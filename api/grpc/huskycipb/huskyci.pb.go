@@ -0,0 +1,984 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// source: huskyci.proto
+
+package huskycipb
+
+import (
+	context "context"
+	fmt "fmt"
+	proto "github.com/golang/protobuf/proto"
+	timestamp "github.com/golang/protobuf/ptypes/timestamp"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+	math "math"
+)
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ = proto.Marshal
+var _ = fmt.Errorf
+var _ = math.Inf
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the proto package it is being compiled against.
+// A compilation error at this line likely means your copy of the
+// proto package needs to be updated.
+const _ = proto.ProtoPackageIsVersion3 // please upgrade the proto package
+
+type SubmitAnalysisRequest struct {
+	RepositoryUrl        string   `protobuf:"bytes,1,opt,name=repository_url,json=repositoryUrl,proto3" json:"repository_url,omitempty"`
+	RepositoryBranch     string   `protobuf:"bytes,2,opt,name=repository_branch,json=repositoryBranch,proto3" json:"repository_branch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubmitAnalysisRequest) Reset()         { *m = SubmitAnalysisRequest{} }
+func (m *SubmitAnalysisRequest) String() string { return proto.CompactTextString(m) }
+func (*SubmitAnalysisRequest) ProtoMessage()    {}
+func (*SubmitAnalysisRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{0}
+}
+
+func (m *SubmitAnalysisRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubmitAnalysisRequest.Unmarshal(m, b)
+}
+func (m *SubmitAnalysisRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubmitAnalysisRequest.Marshal(b, m, deterministic)
+}
+func (m *SubmitAnalysisRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubmitAnalysisRequest.Merge(m, src)
+}
+func (m *SubmitAnalysisRequest) XXX_Size() int {
+	return xxx_messageInfo_SubmitAnalysisRequest.Size(m)
+}
+func (m *SubmitAnalysisRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubmitAnalysisRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubmitAnalysisRequest proto.InternalMessageInfo
+
+func (m *SubmitAnalysisRequest) GetRepositoryUrl() string {
+	if m != nil {
+		return m.RepositoryUrl
+	}
+	return ""
+}
+
+func (m *SubmitAnalysisRequest) GetRepositoryBranch() string {
+	if m != nil {
+		return m.RepositoryBranch
+	}
+	return ""
+}
+
+type SubmitAnalysisResponse struct {
+	AnalysisId           string   `protobuf:"bytes,1,opt,name=analysis_id,json=analysisId,proto3" json:"analysis_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubmitAnalysisResponse) Reset()         { *m = SubmitAnalysisResponse{} }
+func (m *SubmitAnalysisResponse) String() string { return proto.CompactTextString(m) }
+func (*SubmitAnalysisResponse) ProtoMessage()    {}
+func (*SubmitAnalysisResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{1}
+}
+
+func (m *SubmitAnalysisResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_SubmitAnalysisResponse.Unmarshal(m, b)
+}
+func (m *SubmitAnalysisResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_SubmitAnalysisResponse.Marshal(b, m, deterministic)
+}
+func (m *SubmitAnalysisResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubmitAnalysisResponse.Merge(m, src)
+}
+func (m *SubmitAnalysisResponse) XXX_Size() int {
+	return xxx_messageInfo_SubmitAnalysisResponse.Size(m)
+}
+func (m *SubmitAnalysisResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubmitAnalysisResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubmitAnalysisResponse proto.InternalMessageInfo
+
+func (m *SubmitAnalysisResponse) GetAnalysisId() string {
+	if m != nil {
+		return m.AnalysisId
+	}
+	return ""
+}
+
+type GetAnalysisRequest struct {
+	AnalysisId           string   `protobuf:"bytes,1,opt,name=analysis_id,json=analysisId,proto3" json:"analysis_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *GetAnalysisRequest) Reset()         { *m = GetAnalysisRequest{} }
+func (m *GetAnalysisRequest) String() string { return proto.CompactTextString(m) }
+func (*GetAnalysisRequest) ProtoMessage()    {}
+func (*GetAnalysisRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{2}
+}
+
+func (m *GetAnalysisRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_GetAnalysisRequest.Unmarshal(m, b)
+}
+func (m *GetAnalysisRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_GetAnalysisRequest.Marshal(b, m, deterministic)
+}
+func (m *GetAnalysisRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_GetAnalysisRequest.Merge(m, src)
+}
+func (m *GetAnalysisRequest) XXX_Size() int {
+	return xxx_messageInfo_GetAnalysisRequest.Size(m)
+}
+func (m *GetAnalysisRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_GetAnalysisRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_GetAnalysisRequest proto.InternalMessageInfo
+
+func (m *GetAnalysisRequest) GetAnalysisId() string {
+	if m != nil {
+		return m.AnalysisId
+	}
+	return ""
+}
+
+type ListAnalysesRequest struct {
+	RepositoryUrl        string   `protobuf:"bytes,1,opt,name=repository_url,json=repositoryUrl,proto3" json:"repository_url,omitempty"`
+	RepositoryBranch     string   `protobuf:"bytes,2,opt,name=repository_branch,json=repositoryBranch,proto3" json:"repository_branch,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *ListAnalysesRequest) Reset()         { *m = ListAnalysesRequest{} }
+func (m *ListAnalysesRequest) String() string { return proto.CompactTextString(m) }
+func (*ListAnalysesRequest) ProtoMessage()    {}
+func (*ListAnalysesRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{3}
+}
+
+func (m *ListAnalysesRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAnalysesRequest.Unmarshal(m, b)
+}
+func (m *ListAnalysesRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAnalysesRequest.Marshal(b, m, deterministic)
+}
+func (m *ListAnalysesRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAnalysesRequest.Merge(m, src)
+}
+func (m *ListAnalysesRequest) XXX_Size() int {
+	return xxx_messageInfo_ListAnalysesRequest.Size(m)
+}
+func (m *ListAnalysesRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAnalysesRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAnalysesRequest proto.InternalMessageInfo
+
+func (m *ListAnalysesRequest) GetRepositoryUrl() string {
+	if m != nil {
+		return m.RepositoryUrl
+	}
+	return ""
+}
+
+func (m *ListAnalysesRequest) GetRepositoryBranch() string {
+	if m != nil {
+		return m.RepositoryBranch
+	}
+	return ""
+}
+
+type ListAnalysesResponse struct {
+	Analyses             []*Analysis `protobuf:"bytes,1,rep,name=analyses,proto3" json:"analyses,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}    `json:"-"`
+	XXX_unrecognized     []byte      `json:"-"`
+	XXX_sizecache        int32       `json:"-"`
+}
+
+func (m *ListAnalysesResponse) Reset()         { *m = ListAnalysesResponse{} }
+func (m *ListAnalysesResponse) String() string { return proto.CompactTextString(m) }
+func (*ListAnalysesResponse) ProtoMessage()    {}
+func (*ListAnalysesResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{4}
+}
+
+func (m *ListAnalysesResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_ListAnalysesResponse.Unmarshal(m, b)
+}
+func (m *ListAnalysesResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_ListAnalysesResponse.Marshal(b, m, deterministic)
+}
+func (m *ListAnalysesResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ListAnalysesResponse.Merge(m, src)
+}
+func (m *ListAnalysesResponse) XXX_Size() int {
+	return xxx_messageInfo_ListAnalysesResponse.Size(m)
+}
+func (m *ListAnalysesResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_ListAnalysesResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ListAnalysesResponse proto.InternalMessageInfo
+
+func (m *ListAnalysesResponse) GetAnalyses() []*Analysis {
+	if m != nil {
+		return m.Analyses
+	}
+	return nil
+}
+
+type StreamAnalysisEventsRequest struct {
+	AnalysisId           string   `protobuf:"bytes,1,opt,name=analysis_id,json=analysisId,proto3" json:"analysis_id,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StreamAnalysisEventsRequest) Reset()         { *m = StreamAnalysisEventsRequest{} }
+func (m *StreamAnalysisEventsRequest) String() string { return proto.CompactTextString(m) }
+func (*StreamAnalysisEventsRequest) ProtoMessage()    {}
+func (*StreamAnalysisEventsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{5}
+}
+
+func (m *StreamAnalysisEventsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamAnalysisEventsRequest.Unmarshal(m, b)
+}
+func (m *StreamAnalysisEventsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamAnalysisEventsRequest.Marshal(b, m, deterministic)
+}
+func (m *StreamAnalysisEventsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamAnalysisEventsRequest.Merge(m, src)
+}
+func (m *StreamAnalysisEventsRequest) XXX_Size() int {
+	return xxx_messageInfo_StreamAnalysisEventsRequest.Size(m)
+}
+func (m *StreamAnalysisEventsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamAnalysisEventsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamAnalysisEventsRequest proto.InternalMessageInfo
+
+func (m *StreamAnalysisEventsRequest) GetAnalysisId() string {
+	if m != nil {
+		return m.AnalysisId
+	}
+	return ""
+}
+
+type Analysis struct {
+	AnalysisId           string               `protobuf:"bytes,1,opt,name=analysis_id,json=analysisId,proto3" json:"analysis_id,omitempty"`
+	RepositoryUrl        string               `protobuf:"bytes,2,opt,name=repository_url,json=repositoryUrl,proto3" json:"repository_url,omitempty"`
+	RepositoryBranch     string               `protobuf:"bytes,3,opt,name=repository_branch,json=repositoryBranch,proto3" json:"repository_branch,omitempty"`
+	CommitAuthors        []string             `protobuf:"bytes,4,rep,name=commit_authors,json=commitAuthors,proto3" json:"commit_authors,omitempty"`
+	Status               string               `protobuf:"bytes,5,opt,name=status,proto3" json:"status,omitempty"`
+	Result               string               `protobuf:"bytes,6,opt,name=result,proto3" json:"result,omitempty"`
+	ErrorFound           string               `protobuf:"bytes,7,opt,name=error_found,json=errorFound,proto3" json:"error_found,omitempty"`
+	Containers           []*Container         `protobuf:"bytes,8,rep,name=containers,proto3" json:"containers,omitempty"`
+	StartedAt            *timestamp.Timestamp `protobuf:"bytes,9,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt           *timestamp.Timestamp `protobuf:"bytes,10,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	Vulnerabilities      []*Vulnerability     `protobuf:"bytes,11,rep,name=vulnerabilities,proto3" json:"vulnerabilities,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Analysis) Reset()         { *m = Analysis{} }
+func (m *Analysis) String() string { return proto.CompactTextString(m) }
+func (*Analysis) ProtoMessage()    {}
+func (*Analysis) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{6}
+}
+
+func (m *Analysis) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Analysis.Unmarshal(m, b)
+}
+func (m *Analysis) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Analysis.Marshal(b, m, deterministic)
+}
+func (m *Analysis) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Analysis.Merge(m, src)
+}
+func (m *Analysis) XXX_Size() int {
+	return xxx_messageInfo_Analysis.Size(m)
+}
+func (m *Analysis) XXX_DiscardUnknown() {
+	xxx_messageInfo_Analysis.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Analysis proto.InternalMessageInfo
+
+func (m *Analysis) GetAnalysisId() string {
+	if m != nil {
+		return m.AnalysisId
+	}
+	return ""
+}
+
+func (m *Analysis) GetRepositoryUrl() string {
+	if m != nil {
+		return m.RepositoryUrl
+	}
+	return ""
+}
+
+func (m *Analysis) GetRepositoryBranch() string {
+	if m != nil {
+		return m.RepositoryBranch
+	}
+	return ""
+}
+
+func (m *Analysis) GetCommitAuthors() []string {
+	if m != nil {
+		return m.CommitAuthors
+	}
+	return nil
+}
+
+func (m *Analysis) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *Analysis) GetResult() string {
+	if m != nil {
+		return m.Result
+	}
+	return ""
+}
+
+func (m *Analysis) GetErrorFound() string {
+	if m != nil {
+		return m.ErrorFound
+	}
+	return ""
+}
+
+func (m *Analysis) GetContainers() []*Container {
+	if m != nil {
+		return m.Containers
+	}
+	return nil
+}
+
+func (m *Analysis) GetStartedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedAt
+	}
+	return nil
+}
+
+func (m *Analysis) GetFinishedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.FinishedAt
+	}
+	return nil
+}
+
+func (m *Analysis) GetVulnerabilities() []*Vulnerability {
+	if m != nil {
+		return m.Vulnerabilities
+	}
+	return nil
+}
+
+type Container struct {
+	ContainerId          string               `protobuf:"bytes,1,opt,name=container_id,json=containerId,proto3" json:"container_id,omitempty"`
+	SecurityTest         string               `protobuf:"bytes,2,opt,name=security_test,json=securityTest,proto3" json:"security_test,omitempty"`
+	Status               string               `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Result               string               `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	Info                 string               `protobuf:"bytes,5,opt,name=info,proto3" json:"info,omitempty"`
+	StartedAt            *timestamp.Timestamp `protobuf:"bytes,6,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	FinishedAt           *timestamp.Timestamp `protobuf:"bytes,7,opt,name=finished_at,json=finishedAt,proto3" json:"finished_at,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *Container) Reset()         { *m = Container{} }
+func (m *Container) String() string { return proto.CompactTextString(m) }
+func (*Container) ProtoMessage()    {}
+func (*Container) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{7}
+}
+
+func (m *Container) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Container.Unmarshal(m, b)
+}
+func (m *Container) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Container.Marshal(b, m, deterministic)
+}
+func (m *Container) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Container.Merge(m, src)
+}
+func (m *Container) XXX_Size() int {
+	return xxx_messageInfo_Container.Size(m)
+}
+func (m *Container) XXX_DiscardUnknown() {
+	xxx_messageInfo_Container.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Container proto.InternalMessageInfo
+
+func (m *Container) GetContainerId() string {
+	if m != nil {
+		return m.ContainerId
+	}
+	return ""
+}
+
+func (m *Container) GetSecurityTest() string {
+	if m != nil {
+		return m.SecurityTest
+	}
+	return ""
+}
+
+func (m *Container) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *Container) GetResult() string {
+	if m != nil {
+		return m.Result
+	}
+	return ""
+}
+
+func (m *Container) GetInfo() string {
+	if m != nil {
+		return m.Info
+	}
+	return ""
+}
+
+func (m *Container) GetStartedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.StartedAt
+	}
+	return nil
+}
+
+func (m *Container) GetFinishedAt() *timestamp.Timestamp {
+	if m != nil {
+		return m.FinishedAt
+	}
+	return nil
+}
+
+type Vulnerability struct {
+	Language             string   `protobuf:"bytes,1,opt,name=language,proto3" json:"language,omitempty"`
+	SecurityTool         string   `protobuf:"bytes,2,opt,name=security_tool,json=securityTool,proto3" json:"security_tool,omitempty"`
+	Severity             string   `protobuf:"bytes,3,opt,name=severity,proto3" json:"severity,omitempty"`
+	Confidence           string   `protobuf:"bytes,4,opt,name=confidence,proto3" json:"confidence,omitempty"`
+	File                 string   `protobuf:"bytes,5,opt,name=file,proto3" json:"file,omitempty"`
+	Line                 string   `protobuf:"bytes,6,opt,name=line,proto3" json:"line,omitempty"`
+	Code                 string   `protobuf:"bytes,7,opt,name=code,proto3" json:"code,omitempty"`
+	Details              string   `protobuf:"bytes,8,opt,name=details,proto3" json:"details,omitempty"`
+	Type                 string   `protobuf:"bytes,9,opt,name=type,proto3" json:"type,omitempty"`
+	VulnerableBelow      string   `protobuf:"bytes,10,opt,name=vulnerable_below,json=vulnerableBelow,proto3" json:"vulnerable_below,omitempty"`
+	Version              string   `protobuf:"bytes,11,opt,name=version,proto3" json:"version,omitempty"`
+	Occurrences          int32    `protobuf:"varint,12,opt,name=occurrences,proto3" json:"occurrences,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *Vulnerability) Reset()         { *m = Vulnerability{} }
+func (m *Vulnerability) String() string { return proto.CompactTextString(m) }
+func (*Vulnerability) ProtoMessage()    {}
+func (*Vulnerability) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{8}
+}
+
+func (m *Vulnerability) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_Vulnerability.Unmarshal(m, b)
+}
+func (m *Vulnerability) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_Vulnerability.Marshal(b, m, deterministic)
+}
+func (m *Vulnerability) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Vulnerability.Merge(m, src)
+}
+func (m *Vulnerability) XXX_Size() int {
+	return xxx_messageInfo_Vulnerability.Size(m)
+}
+func (m *Vulnerability) XXX_DiscardUnknown() {
+	xxx_messageInfo_Vulnerability.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_Vulnerability proto.InternalMessageInfo
+
+func (m *Vulnerability) GetLanguage() string {
+	if m != nil {
+		return m.Language
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetSecurityTool() string {
+	if m != nil {
+		return m.SecurityTool
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetSeverity() string {
+	if m != nil {
+		return m.Severity
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetConfidence() string {
+	if m != nil {
+		return m.Confidence
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetFile() string {
+	if m != nil {
+		return m.File
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetLine() string {
+	if m != nil {
+		return m.Line
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetCode() string {
+	if m != nil {
+		return m.Code
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetDetails() string {
+	if m != nil {
+		return m.Details
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetVulnerableBelow() string {
+	if m != nil {
+		return m.VulnerableBelow
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetVersion() string {
+	if m != nil {
+		return m.Version
+	}
+	return ""
+}
+
+func (m *Vulnerability) GetOccurrences() int32 {
+	if m != nil {
+		return m.Occurrences
+	}
+	return 0
+}
+
+type AnalysisEvent struct {
+	AnalysisId           string               `protobuf:"bytes,1,opt,name=analysis_id,json=analysisId,proto3" json:"analysis_id,omitempty"`
+	Type                 string               `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Status               string               `protobuf:"bytes,3,opt,name=status,proto3" json:"status,omitempty"`
+	Result               string               `protobuf:"bytes,4,opt,name=result,proto3" json:"result,omitempty"`
+	Container            *Container           `protobuf:"bytes,5,opt,name=container,proto3" json:"container,omitempty"`
+	Time                 *timestamp.Timestamp `protobuf:"bytes,6,opt,name=time,proto3" json:"time,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}             `json:"-"`
+	XXX_unrecognized     []byte               `json:"-"`
+	XXX_sizecache        int32                `json:"-"`
+}
+
+func (m *AnalysisEvent) Reset()         { *m = AnalysisEvent{} }
+func (m *AnalysisEvent) String() string { return proto.CompactTextString(m) }
+func (*AnalysisEvent) ProtoMessage()    {}
+func (*AnalysisEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_f0d4aa8d9554dacc, []int{9}
+}
+
+func (m *AnalysisEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_AnalysisEvent.Unmarshal(m, b)
+}
+func (m *AnalysisEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_AnalysisEvent.Marshal(b, m, deterministic)
+}
+func (m *AnalysisEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_AnalysisEvent.Merge(m, src)
+}
+func (m *AnalysisEvent) XXX_Size() int {
+	return xxx_messageInfo_AnalysisEvent.Size(m)
+}
+func (m *AnalysisEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_AnalysisEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_AnalysisEvent proto.InternalMessageInfo
+
+func (m *AnalysisEvent) GetAnalysisId() string {
+	if m != nil {
+		return m.AnalysisId
+	}
+	return ""
+}
+
+func (m *AnalysisEvent) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *AnalysisEvent) GetStatus() string {
+	if m != nil {
+		return m.Status
+	}
+	return ""
+}
+
+func (m *AnalysisEvent) GetResult() string {
+	if m != nil {
+		return m.Result
+	}
+	return ""
+}
+
+func (m *AnalysisEvent) GetContainer() *Container {
+	if m != nil {
+		return m.Container
+	}
+	return nil
+}
+
+func (m *AnalysisEvent) GetTime() *timestamp.Timestamp {
+	if m != nil {
+		return m.Time
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*SubmitAnalysisRequest)(nil), "huskycipb.SubmitAnalysisRequest")
+	proto.RegisterType((*SubmitAnalysisResponse)(nil), "huskycipb.SubmitAnalysisResponse")
+	proto.RegisterType((*GetAnalysisRequest)(nil), "huskycipb.GetAnalysisRequest")
+	proto.RegisterType((*ListAnalysesRequest)(nil), "huskycipb.ListAnalysesRequest")
+	proto.RegisterType((*ListAnalysesResponse)(nil), "huskycipb.ListAnalysesResponse")
+	proto.RegisterType((*StreamAnalysisEventsRequest)(nil), "huskycipb.StreamAnalysisEventsRequest")
+	proto.RegisterType((*Analysis)(nil), "huskycipb.Analysis")
+	proto.RegisterType((*Container)(nil), "huskycipb.Container")
+	proto.RegisterType((*Vulnerability)(nil), "huskycipb.Vulnerability")
+	proto.RegisterType((*AnalysisEvent)(nil), "huskycipb.AnalysisEvent")
+}
+
+func init() {
+	proto.RegisterFile("huskyci.proto", fileDescriptor_f0d4aa8d9554dacc)
+}
+
+var fileDescriptor_f0d4aa8d9554dacc = []byte{
+	// 810 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0x51, 0x8f, 0xdb, 0x44,
+	0x10, 0x56, 0x92, 0xeb, 0x5d, 0x3c, 0x4e, 0x4a, 0xd9, 0x1e, 0x95, 0x15, 0x44, 0x2f, 0x0d, 0x02,
+	0x05, 0x21, 0x39, 0x55, 0x80, 0x87, 0x0a, 0x09, 0x29, 0xa9, 0xa0, 0x9c, 0x84, 0x84, 0x94, 0xb6,
+	0x3c, 0xf4, 0x25, 0x5a, 0xdb, 0x93, 0x64, 0xd5, 0x8d, 0xd7, 0xec, 0xae, 0x83, 0xf2, 0x2b, 0xf8,
+	0x6b, 0x3c, 0xf2, 0xc2, 0x0f, 0xe0, 0x5f, 0xa0, 0x5d, 0xaf, 0x1d, 0xe7, 0x2e, 0x21, 0x77, 0x42,
+	0xbc, 0xed, 0x7c, 0xfb, 0xcd, 0x78, 0xe6, 0x9b, 0x59, 0x0f, 0x74, 0x57, 0xb9, 0x7a, 0xbf, 0x8d,
+	0x59, 0x98, 0x49, 0xa1, 0x05, 0xf1, 0x9c, 0x99, 0x45, 0xbd, 0xab, 0xa5, 0x10, 0x4b, 0x8e, 0x23,
+	0x7b, 0x11, 0xe5, 0x8b, 0x91, 0x66, 0x6b, 0x54, 0x9a, 0xae, 0xb3, 0x82, 0x3b, 0x78, 0x0f, 0x1f,
+	0xbd, 0xce, 0xa3, 0x35, 0xd3, 0x93, 0x94, 0xf2, 0xad, 0x62, 0x6a, 0x86, 0xbf, 0xe6, 0xa8, 0x34,
+	0xf9, 0x0c, 0x1e, 0x4a, 0xcc, 0x84, 0x62, 0x5a, 0xc8, 0xed, 0x3c, 0x97, 0x3c, 0x68, 0xf4, 0x1b,
+	0x43, 0x6f, 0xd6, 0xdd, 0xa1, 0x6f, 0x25, 0x27, 0x5f, 0xc2, 0x87, 0x35, 0x5a, 0x24, 0x69, 0x1a,
+	0xaf, 0x82, 0xa6, 0x65, 0x3e, 0xda, 0x5d, 0x4c, 0x2d, 0x3e, 0x78, 0x01, 0x4f, 0x6e, 0x7e, 0x4c,
+	0x65, 0x22, 0x55, 0x48, 0xae, 0xc0, 0xa7, 0x0e, 0x9b, 0xb3, 0xc4, 0x7d, 0x0a, 0x4a, 0xe8, 0x3a,
+	0x19, 0x7c, 0x03, 0xe4, 0x15, 0xde, 0x4a, 0xf2, 0xa4, 0x1b, 0x83, 0xc7, 0x3f, 0x31, 0xe5, 0xfc,
+	0xf0, 0x7f, 0x2d, 0xee, 0x15, 0x5c, 0xee, 0x7f, 0xca, 0x95, 0x36, 0x82, 0x36, 0x75, 0x58, 0xd0,
+	0xe8, 0xb7, 0x86, 0xfe, 0xf8, 0x71, 0x58, 0x35, 0x28, 0xac, 0x2a, 0xaa, 0x48, 0x83, 0xef, 0xe0,
+	0xe3, 0xd7, 0x5a, 0x22, 0x5d, 0x97, 0x77, 0xdf, 0x6f, 0x30, 0xd5, 0x77, 0xaf, 0xf9, 0xef, 0x16,
+	0xb4, 0x4b, 0xd7, 0x93, 0xec, 0x03, 0x52, 0x34, 0xef, 0x2c, 0x45, 0xeb, 0xb0, 0x14, 0x26, 0x66,
+	0x2c, 0xd6, 0x6b, 0xa6, 0xe7, 0x34, 0xd7, 0x2b, 0x21, 0x55, 0x70, 0xd6, 0x6f, 0x99, 0x98, 0x05,
+	0x3a, 0x29, 0x40, 0xf2, 0x04, 0xce, 0x95, 0xa6, 0x3a, 0x57, 0xc1, 0x03, 0x1b, 0xc8, 0x59, 0x06,
+	0x97, 0xa8, 0x72, 0xae, 0x83, 0xf3, 0x02, 0x2f, 0x2c, 0x53, 0x0b, 0x4a, 0x29, 0xe4, 0x7c, 0x21,
+	0xf2, 0x34, 0x09, 0x2e, 0x8a, 0x5a, 0x2c, 0xf4, 0x83, 0x41, 0xc8, 0xd7, 0x00, 0xb1, 0x48, 0x35,
+	0x65, 0x29, 0x4a, 0x15, 0xb4, 0xad, 0xd8, 0x97, 0x35, 0xb1, 0x5f, 0x96, 0x97, 0xb3, 0x1a, 0x8f,
+	0xbc, 0x00, 0x50, 0x9a, 0x4a, 0x8d, 0xc9, 0x9c, 0xea, 0xc0, 0xeb, 0x37, 0x86, 0xfe, 0xb8, 0x17,
+	0x16, 0x0f, 0x27, 0x2c, 0x1f, 0x4e, 0xf8, 0xa6, 0x7c, 0x38, 0x33, 0xcf, 0xb1, 0x27, 0x9a, 0x7c,
+	0x0b, 0xfe, 0x82, 0xa5, 0x4c, 0xad, 0x0a, 0x5f, 0x38, 0xe9, 0x0b, 0x25, 0x7d, 0xa2, 0xc9, 0x14,
+	0x3e, 0xd8, 0xe4, 0x3c, 0x45, 0x49, 0x23, 0xc6, 0x99, 0x66, 0xa8, 0x02, 0xdf, 0xa6, 0x1c, 0xd4,
+	0x52, 0xfe, 0xa5, 0xc6, 0xd8, 0xce, 0x6e, 0x3a, 0x0c, 0x7e, 0x6f, 0x82, 0x57, 0x55, 0x45, 0x9e,
+	0x41, 0xa7, 0xaa, 0x6b, 0xd7, 0x6d, 0xbf, 0xc2, 0xae, 0x13, 0xf2, 0x29, 0x74, 0x15, 0xc6, 0xb9,
+	0x64, 0x7a, 0x3b, 0xd7, 0xa8, 0xb4, 0xeb, 0x76, 0xa7, 0x04, 0xdf, 0x98, 0x11, 0xdb, 0x35, 0xa6,
+	0x75, 0xa4, 0x31, 0x67, 0x7b, 0x8d, 0x21, 0x70, 0xc6, 0xd2, 0x85, 0x70, 0x6d, 0xb4, 0xe7, 0x1b,
+	0xaa, 0x9e, 0xff, 0x07, 0x55, 0x2f, 0xee, 0xa3, 0xea, 0xe0, 0xaf, 0x26, 0x74, 0xf7, 0x44, 0x23,
+	0x3d, 0x68, 0x73, 0x9a, 0x2e, 0x73, 0xba, 0x44, 0xa7, 0x48, 0x65, 0xef, 0xcb, 0x21, 0x04, 0xbf,
+	0x25, 0x87, 0x10, 0xdc, 0x04, 0x50, 0xb8, 0x41, 0x63, 0x3b, 0x41, 0x2a, 0x9b, 0x3c, 0xb5, 0x23,
+	0xb7, 0x60, 0x09, 0xa6, 0x31, 0x3a, 0x59, 0x6a, 0x88, 0x91, 0x66, 0xc1, 0x38, 0x96, 0xd2, 0x98,
+	0xb3, 0xc1, 0x38, 0x4b, 0xd1, 0x4d, 0xb7, 0x3d, 0x1b, 0x2c, 0x16, 0x09, 0xba, 0xa1, 0xb6, 0x67,
+	0x12, 0xc0, 0x45, 0x82, 0x9a, 0x32, 0x6e, 0x66, 0xd9, 0xc0, 0xa5, 0x69, 0xd8, 0x7a, 0x9b, 0xa1,
+	0x1d, 0x56, 0x6f, 0x66, 0xcf, 0xe4, 0x0b, 0x78, 0x54, 0x4e, 0x07, 0xc7, 0x79, 0x84, 0x5c, 0xfc,
+	0x66, 0x07, 0xd2, 0xdb, 0x4d, 0x0d, 0xc7, 0xa9, 0x81, 0x4d, 0xe0, 0x0d, 0x4a, 0xc5, 0x44, 0x1a,
+	0xf8, 0x45, 0x60, 0x67, 0x92, 0x3e, 0xf8, 0x22, 0x8e, 0x73, 0x29, 0x4d, 0xf2, 0x2a, 0xe8, 0xf4,
+	0x1b, 0xc3, 0x07, 0xb3, 0x3a, 0x34, 0xf8, 0xb3, 0x01, 0xdd, 0xbd, 0x1f, 0xd3, 0xe9, 0x5f, 0x4c,
+	0x99, 0x6d, 0xb3, 0x96, 0xed, 0x7d, 0x47, 0x6c, 0x0c, 0x5e, 0x35, 0xc6, 0x56, 0xcc, 0x63, 0x2f,
+	0x7b, 0x47, 0x23, 0x21, 0x9c, 0x99, 0x75, 0x77, 0x87, 0xe1, 0xb3, 0xbc, 0xf1, 0x1f, 0x4d, 0xb8,
+	0xf8, 0xd1, 0x84, 0x7c, 0x79, 0x4d, 0xde, 0xc2, 0xc3, 0xfd, 0x55, 0x45, 0xfa, 0xb5, 0xcf, 0x1d,
+	0x5c, 0x99, 0xbd, 0x67, 0xff, 0xc2, 0x70, 0xcb, 0x60, 0x02, 0x7e, 0x6d, 0x8d, 0x91, 0x4f, 0x6a,
+	0x1e, 0xb7, 0xd7, 0x5b, 0xef, 0xd0, 0xa2, 0x20, 0x3f, 0x43, 0xa7, 0xbe, 0x67, 0xc8, 0xd3, 0x1a,
+	0xe9, 0xc0, 0xae, 0xeb, 0x5d, 0x1d, 0xbd, 0x77, 0x39, 0xbd, 0x83, 0xcb, 0x43, 0xfb, 0x86, 0x7c,
+	0x5e, 0x2f, 0xe7, 0xf8, 0x42, 0xea, 0x05, 0x07, 0xb2, 0xb4, 0x8c, 0xe7, 0x8d, 0xe9, 0xf3, 0x77,
+	0xe1, 0x92, 0xe9, 0x55, 0x1e, 0x85, 0xb1, 0x58, 0x8f, 0x96, 0x5c, 0x44, 0xc2, 0x1c, 0x56, 0x85,
+	0xca, 0x23, 0x9a, 0xb1, 0xd1, 0x52, 0x66, 0xf1, 0xa8, 0x8a, 0x10, 0x9d, 0xdb, 0xf6, 0x7c, 0xf5,
+	0xcf, 0x00, 0xf7, 0x68, 0x1a, 0x88, 0xd4, 0x08, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConnInterface
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion6
+
+// HuskyCIClient is the client API for HuskyCI service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type HuskyCIClient interface {
+	// SubmitAnalysis starts a new analysis and returns its ID right away.
+	SubmitAnalysis(ctx context.Context, in *SubmitAnalysisRequest, opts ...grpc.CallOption) (*SubmitAnalysisResponse, error)
+	// GetAnalysis returns an analysis given its ID.
+	GetAnalysis(ctx context.Context, in *GetAnalysisRequest, opts ...grpc.CallOption) (*Analysis, error)
+	// ListAnalyses returns all analyses of a given repository.
+	ListAnalyses(ctx context.Context, in *ListAnalysesRequest, opts ...grpc.CallOption) (*ListAnalysesResponse, error)
+	// StreamAnalysisEvents emits events until the analysis has finished.
+	StreamAnalysisEvents(ctx context.Context, in *StreamAnalysisEventsRequest, opts ...grpc.CallOption) (HuskyCI_StreamAnalysisEventsClient, error)
+}
+
+type huskyCIClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewHuskyCIClient(cc grpc.ClientConnInterface) HuskyCIClient {
+	return &huskyCIClient{cc}
+}
+
+func (c *huskyCIClient) SubmitAnalysis(ctx context.Context, in *SubmitAnalysisRequest, opts ...grpc.CallOption) (*SubmitAnalysisResponse, error) {
+	out := new(SubmitAnalysisResponse)
+	err := c.cc.Invoke(ctx, "/huskycipb.HuskyCI/SubmitAnalysis", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *huskyCIClient) GetAnalysis(ctx context.Context, in *GetAnalysisRequest, opts ...grpc.CallOption) (*Analysis, error) {
+	out := new(Analysis)
+	err := c.cc.Invoke(ctx, "/huskycipb.HuskyCI/GetAnalysis", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *huskyCIClient) ListAnalyses(ctx context.Context, in *ListAnalysesRequest, opts ...grpc.CallOption) (*ListAnalysesResponse, error) {
+	out := new(ListAnalysesResponse)
+	err := c.cc.Invoke(ctx, "/huskycipb.HuskyCI/ListAnalyses", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *huskyCIClient) StreamAnalysisEvents(ctx context.Context, in *StreamAnalysisEventsRequest, opts ...grpc.CallOption) (HuskyCI_StreamAnalysisEventsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_HuskyCI_serviceDesc.Streams[0], "/huskycipb.HuskyCI/StreamAnalysisEvents", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &huskyCIStreamAnalysisEventsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type HuskyCI_StreamAnalysisEventsClient interface {
+	Recv() (*AnalysisEvent, error)
+	grpc.ClientStream
+}
+
+type huskyCIStreamAnalysisEventsClient struct {
+	grpc.ClientStream
+}
+
+func (x *huskyCIStreamAnalysisEventsClient) Recv() (*AnalysisEvent, error) {
+	m := new(AnalysisEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// HuskyCIServer is the server API for HuskyCI service.
+type HuskyCIServer interface {
+	// SubmitAnalysis starts a new analysis and returns its ID right away.
+	SubmitAnalysis(context.Context, *SubmitAnalysisRequest) (*SubmitAnalysisResponse, error)
+	// GetAnalysis returns an analysis given its ID.
+	GetAnalysis(context.Context, *GetAnalysisRequest) (*Analysis, error)
+	// ListAnalyses returns all analyses of a given repository.
+	ListAnalyses(context.Context, *ListAnalysesRequest) (*ListAnalysesResponse, error)
+	// StreamAnalysisEvents emits events until the analysis has finished.
+	StreamAnalysisEvents(*StreamAnalysisEventsRequest, HuskyCI_StreamAnalysisEventsServer) error
+}
+
+// UnimplementedHuskyCIServer can be embedded to have forward compatible implementations.
+type UnimplementedHuskyCIServer struct {
+}
+
+func (*UnimplementedHuskyCIServer) SubmitAnalysis(ctx context.Context, req *SubmitAnalysisRequest) (*SubmitAnalysisResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SubmitAnalysis not implemented")
+}
+func (*UnimplementedHuskyCIServer) GetAnalysis(ctx context.Context, req *GetAnalysisRequest) (*Analysis, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetAnalysis not implemented")
+}
+func (*UnimplementedHuskyCIServer) ListAnalyses(ctx context.Context, req *ListAnalysesRequest) (*ListAnalysesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAnalyses not implemented")
+}
+func (*UnimplementedHuskyCIServer) StreamAnalysisEvents(req *StreamAnalysisEventsRequest, srv HuskyCI_StreamAnalysisEventsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamAnalysisEvents not implemented")
+}
+
+func RegisterHuskyCIServer(s *grpc.Server, srv HuskyCIServer) {
+	s.RegisterService(&_HuskyCI_serviceDesc, srv)
+}
+
+func _HuskyCI_SubmitAnalysis_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SubmitAnalysisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HuskyCIServer).SubmitAnalysis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/huskycipb.HuskyCI/SubmitAnalysis",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HuskyCIServer).SubmitAnalysis(ctx, req.(*SubmitAnalysisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HuskyCI_GetAnalysis_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetAnalysisRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HuskyCIServer).GetAnalysis(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/huskycipb.HuskyCI/GetAnalysis",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HuskyCIServer).GetAnalysis(ctx, req.(*GetAnalysisRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HuskyCI_ListAnalyses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAnalysesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(HuskyCIServer).ListAnalyses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/huskycipb.HuskyCI/ListAnalyses",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(HuskyCIServer).ListAnalyses(ctx, req.(*ListAnalysesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _HuskyCI_StreamAnalysisEvents_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamAnalysisEventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(HuskyCIServer).StreamAnalysisEvents(m, &huskyCIStreamAnalysisEventsServer{stream})
+}
+
+type HuskyCI_StreamAnalysisEventsServer interface {
+	Send(*AnalysisEvent) error
+	grpc.ServerStream
+}
+
+type huskyCIStreamAnalysisEventsServer struct {
+	grpc.ServerStream
+}
+
+func (x *huskyCIStreamAnalysisEventsServer) Send(m *AnalysisEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+var _HuskyCI_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "huskycipb.HuskyCI",
+	HandlerType: (*HuskyCIServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "SubmitAnalysis",
+			Handler:    _HuskyCI_SubmitAnalysis_Handler,
+		},
+		{
+			MethodName: "GetAnalysis",
+			Handler:    _HuskyCI_GetAnalysis_Handler,
+		},
+		{
+			MethodName: "ListAnalyses",
+			Handler:    _HuskyCI_ListAnalyses_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamAnalysisEvents",
+			Handler:       _HuskyCI_StreamAnalysisEvents_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "huskyci.proto",
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpc
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/globocom/huskyCI/api/ratelimit"
+	goGrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// submitAnalysisMethod is the full name of SubmitAnalysis, the method analyses are requested with.
+const submitAnalysisMethod = "/huskycipb.HuskyCI/SubmitAnalysis"
+
+// RateLimitAnalyses returns a unary interceptor that counts each SubmitAnalysis call against
+// the same limits as the analyses requested to the REST API, see ratelimit.AllowAnalysis. A
+// call of a client that exceeded them is refused with ResourceExhausted and retry-after header
+// metadata. The limit closest to being exceeded is sent in the x-ratelimit-limit,
+// x-ratelimit-remaining and x-ratelimit-reset header metadata.
+func RateLimitAnalyses(store ratelimit.Store, identifier ratelimit.TokenIdentifier) goGrpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *goGrpc.UnaryServerInfo, handler goGrpc.UnaryHandler) (interface{}, error) {
+		if info.FullMethod != submitAnalysisMethod {
+			return handler(ctx, req)
+		}
+		state, enforced := ratelimit.AllowAnalysis(store, identifier, getToken(ctx), peerAddress(ctx), time.Now())
+		if !enforced {
+			return handler(ctx, req)
+		}
+		header := metadata.Pairs(
+			"x-ratelimit-limit", strconv.Itoa(state.Limit),
+			"x-ratelimit-remaining", strconv.Itoa(state.Remaining),
+			"x-ratelimit-reset", strconv.FormatInt(state.Reset.Unix(), 10),
+		)
+		if state.Exceeded {
+			header.Set("retry-after", strconv.Itoa(int(state.RetryAfter.Round(time.Second)/time.Second)))
+		}
+		// best-effort: the limit is enforced even if the metadata can not be sent
+		_ = goGrpc.SetHeader(ctx, header)
+		if state.Exceeded {
+			return nil, status.Error(codes.ResourceExhausted, "too many analyses requested")
+		}
+		return handler(ctx, req)
+	}
+}
+
+// peerAddress returns the IP of the client of ctx, the gRPC counterpart of its real IP.
+func peerAddress(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return ""
+	}
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		return p.Addr.String()
+	}
+	return host
+}
@@ -0,0 +1,139 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpc_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	huskyGrpc "github.com/globocom/huskyCI/api/grpc"
+	"github.com/globocom/huskyCI/api/types"
+	goGrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeRateLimitStore counts requests in memory, by key and window.
+type fakeRateLimitStore struct {
+	counts map[string]int
+}
+
+func (fS *fakeRateLimitStore) Increment(key string, requests int, windowStart, expiresAt time.Time) (int, error) {
+	fS.counts[key] += requests
+	return fS.counts[key], nil
+}
+
+// fakeTokenIdentifier knows a single token.
+type fakeTokenIdentifier struct {
+	token       string
+	accessToken types.DBToken
+}
+
+func (fI *fakeTokenIdentifier) Identify(rcvToken string) (types.DBToken, error) {
+	if rcvToken != fI.token {
+		return types.DBToken{}, errors.New("token not found")
+	}
+	return fI.accessToken, nil
+}
+
+// fakeServerStream keeps the header metadata set by an interceptor.
+type fakeServerStream struct {
+	header metadata.MD
+}
+
+func (fS *fakeServerStream) Method() string { return "" }
+
+func (fS *fakeServerStream) SetHeader(md metadata.MD) error {
+	fS.header = metadata.Join(fS.header, md)
+	return nil
+}
+
+func (fS *fakeServerStream) SendHeader(md metadata.MD) error { return fS.SetHeader(md) }
+
+func (fS *fakeServerStream) SetTrailer(md metadata.MD) error { return nil }
+
+var _ = Describe("RateLimitAnalyses", func() {
+
+	var previousConfig *apiContext.APIConfig
+	var store *fakeRateLimitStore
+	var interceptor goGrpc.UnaryServerInterceptor
+	submitInfo := &goGrpc.UnaryServerInfo{FullMethod: "/huskycipb.HuskyCI/SubmitAnalysis"}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return "submitted", nil
+	}
+	clientCtx := func(stream *fakeServerStream, md metadata.MD) context.Context {
+		ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 4242}})
+		ctx = metadata.NewIncomingContext(ctx, md)
+		return goGrpc.NewContextWithServerTransportStream(ctx, stream)
+	}
+
+	BeforeEach(func() {
+		previousConfig = apiContext.APIConfiguration
+		apiContext.APIConfiguration = &apiContext.APIConfig{RateLimitBurst: 1}
+		store = &fakeRateLimitStore{counts: map[string]int{}}
+		identifier := &fakeTokenIdentifier{
+			token:       "valid-token",
+			accessToken: types.DBToken{UUID: "token-uuid", RateLimitBurst: 2},
+		}
+		interceptor = huskyGrpc.RateLimitAnalyses(store, identifier)
+	})
+
+	AfterEach(func() {
+		apiContext.APIConfiguration = previousConfig
+	})
+
+	Context("When a client without a token exceeds the limit", func() {
+		It("Should refuse its call with ResourceExhausted and retry-after metadata", func() {
+			stream := &fakeServerStream{}
+			resp, err := interceptor(clientCtx(stream, metadata.MD{}), nil, submitInfo, handler)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(resp).To(Equal("submitted"))
+			Expect(stream.header.Get("x-ratelimit-remaining")).To(Equal([]string{"0"}))
+
+			stream = &fakeServerStream{}
+			_, err = interceptor(clientCtx(stream, metadata.MD{}), nil, submitInfo, handler)
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+			Expect(stream.header.Get("retry-after")).To(HaveLen(1))
+			for key := range store.counts {
+				Expect(strings.HasPrefix(key, "ip:10.0.0.1:")).To(BeTrue())
+			}
+		})
+	})
+
+	Context("When a client sends a token with its own limit", func() {
+		It("Should count its calls by token with its limit", func() {
+			md := metadata.Pairs("husky-token", "valid-token")
+			for i := 0; i < 2; i++ {
+				_, err := interceptor(clientCtx(&fakeServerStream{}, md), nil, submitInfo, handler)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			_, err := interceptor(clientCtx(&fakeServerStream{}, md), nil, submitInfo, handler)
+			Expect(status.Code(err)).To(Equal(codes.ResourceExhausted))
+			for key := range store.counts {
+				Expect(strings.HasPrefix(key, "token:token-uuid:")).To(BeTrue())
+			}
+		})
+	})
+
+	Context("When another method is called", func() {
+		It("Should not count it", func() {
+			info := &goGrpc.UnaryServerInfo{FullMethod: "/huskycipb.HuskyCI/GetAnalysis"}
+			for i := 0; i < 3; i++ {
+				_, err := interceptor(clientCtx(&fakeServerStream{}, metadata.MD{}), nil, info, handler)
+				Expect(err).ToNot(HaveOccurred())
+			}
+			Expect(store.counts).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,213 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/auth"
+	"github.com/globocom/huskyCI/api/grpc/huskycipb"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/ratelimit"
+	"github.com/globocom/huskyCI/api/token"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+	"github.com/google/uuid"
+	goGrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const logInfoGRPC = "GRPC"
+
+// tokenMetadataKey is the gRPC counterpart of the Husky-Token header.
+const tokenMetadataKey = "husky-token"
+
+// authorizationMetadataKey is the gRPC counterpart of the Authorization header.
+const authorizationMetadataKey = "authorization"
+
+// Authorizer checks if a token can access a given repository URL.
+type Authorizer interface {
+	HasAuthorization(attemptToken, repositoryURL string) bool
+}
+
+// Server implements huskycipb.HuskyCIServer on top of the analysis
+// package, the same service layer used by the REST handlers.
+type Server struct {
+	huskycipb.UnimplementedHuskyCIServer
+	Authorizer     Authorizer
+	EventsInterval time.Duration
+}
+
+// NewServer returns a Server that validates tokens the same way the REST API does.
+func NewServer() *Server {
+	return &Server{
+		Authorizer: &token.TValidator{
+			TokenVerifier: newTokenHandler(),
+		},
+		EventsInterval: 5 * time.Second,
+	}
+}
+
+// newTokenHandler returns a token.THandler of the tokens stored in the database.
+func newTokenHandler() *token.THandler {
+	return &token.THandler{
+		External: &token.TCaller{},
+		HashGen:  &auth.Pbkdf2Caller{},
+	}
+}
+
+// Start listens on the given port and serves huskyCI gRPC API. If useTLS
+// is true, the same certificate and key files of the REST API are used.
+func Start(port int, useTLS bool) error {
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	// analyses requested through either API are counted together
+	opts := []goGrpc.ServerOption{goGrpc.UnaryInterceptor(RateLimitAnalyses(ratelimit.DBStore{}, newTokenHandler()))}
+	if useTLS {
+		creds, err := credentials.NewServerTLSFromFile(util.CertFile, util.KeyFile)
+		if err != nil {
+			return err
+		}
+		opts = append(opts, goGrpc.Creds(creds))
+	}
+	grpcServer := goGrpc.NewServer(opts...)
+	huskycipb.RegisterHuskyCIServer(grpcServer, NewServer())
+	log.Info("Start", logInfoGRPC, 25, port)
+	return grpcServer.Serve(listener)
+}
+
+// SubmitAnalysis starts a new analysis and returns its RID without waiting for it.
+func (s *Server) SubmitAnalysis(ctx context.Context, req *huskycipb.SubmitAnalysisRequest) (*huskycipb.SubmitAnalysisResponse, error) {
+	RID := uuid.New().String()
+	if !s.Authorizer.HasAuthorization(getToken(ctx), req.GetRepositoryUrl()) {
+		log.Error("SubmitAnalysis", logInfoGRPC, 1027, RID)
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	sanitizedRepoURL, err := util.CheckMaliciousRepoURL(req.GetRepositoryUrl())
+	if err != nil {
+		log.Error("SubmitAnalysis", logInfoGRPC, 1016, req.GetRepositoryUrl())
+		return nil, status.Error(codes.InvalidArgument, "invalid repository URL")
+	}
+	if err := util.CheckValidRepoBranch(req.GetRepositoryBranch()); err != nil {
+		log.Error("SubmitAnalysis", logInfoGRPC, 1017, req.GetRepositoryBranch())
+		return nil, status.Error(codes.InvalidArgument, "invalid repository branch")
+	}
+	repository := types.Repository{
+		URL:    sanitizedRepoURL,
+		Branch: req.GetRepositoryBranch(),
+	}
+	if err := analysis.NewAnalysis(RID, repository); err != nil {
+		if err == analysis.ErrAnalysisAlreadyRunning {
+			return nil, status.Error(codes.AlreadyExists, err.Error())
+		}
+		if err == analysis.ErrShuttingDown {
+			return nil, status.Error(codes.Unavailable, err.Error())
+		}
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	return &huskycipb.SubmitAnalysisResponse{AnalysisId: RID}, nil
+}
+
+// GetAnalysis returns the analysis of a given RID.
+func (s *Server) GetAnalysis(ctx context.Context, req *huskycipb.GetAnalysisRequest) (*huskycipb.Analysis, error) {
+	analysisResult, err := s.findAuthorizedAnalysis(ctx, req.GetAnalysisId())
+	if err != nil {
+		return nil, err
+	}
+	return toAnalysis(analysisResult), nil
+}
+
+// ListAnalyses returns all analyses of a given repository URL and, optionally, branch.
+func (s *Server) ListAnalyses(ctx context.Context, req *huskycipb.ListAnalysesRequest) (*huskycipb.ListAnalysesResponse, error) {
+	if !s.Authorizer.HasAuthorization(getToken(ctx), req.GetRepositoryUrl()) {
+		log.Error("ListAnalyses", logInfoGRPC, 1027, req.GetRepositoryUrl())
+		return nil, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	sanitizedRepoURL, err := util.CheckMaliciousRepoURL(req.GetRepositoryUrl())
+	if err != nil {
+		log.Error("ListAnalyses", logInfoGRPC, 1016, req.GetRepositoryUrl())
+		return nil, status.Error(codes.InvalidArgument, "invalid repository URL")
+	}
+	if err := util.CheckValidRepoBranch(req.GetRepositoryBranch()); err != nil {
+		log.Error("ListAnalyses", logInfoGRPC, 1017, req.GetRepositoryBranch())
+		return nil, status.Error(codes.InvalidArgument, "invalid repository branch")
+	}
+	analyses, err := analysis.ListAnalyses(sanitizedRepoURL, req.GetRepositoryBranch())
+	if err != nil {
+		return nil, status.Error(codes.Internal, "internal error")
+	}
+	response := &huskycipb.ListAnalysesResponse{}
+	for _, analysisResult := range analyses {
+		response.Analyses = append(response.Analyses, toAnalysis(analysisResult))
+	}
+	return response, nil
+}
+
+// StreamAnalysisEvents sends analysis events until the analysis has finished
+// or the client cancels the stream.
+func (s *Server) StreamAnalysisEvents(req *huskycipb.StreamAnalysisEventsRequest, stream huskycipb.HuskyCI_StreamAnalysisEventsServer) error {
+	if _, err := s.findAuthorizedAnalysis(stream.Context(), req.GetAnalysisId()); err != nil {
+		return err
+	}
+	err := analysis.WatchAnalysis(stream.Context(), req.GetAnalysisId(), s.EventsInterval, func(event analysis.Event) error {
+		return stream.Send(toEvent(event))
+	})
+	if err != nil {
+		if err == context.Canceled || err == context.DeadlineExceeded {
+			return status.FromContextError(err).Err()
+		}
+		if _, ok := status.FromError(err); ok {
+			return err
+		}
+		return status.Error(codes.Internal, "internal error")
+	}
+	return nil
+}
+
+func (s *Server) findAuthorizedAnalysis(ctx context.Context, RID string) (types.Analysis, error) {
+	if err := util.CheckValidRID(RID); err != nil {
+		log.Warning("GetAnalysis", logInfoGRPC, 107, RID)
+		return types.Analysis{}, status.Error(codes.InvalidArgument, "invalid RID")
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !s.Authorizer.HasAuthorization(getToken(ctx), analysisResult.URL) {
+		log.Error("GetAnalysis", logInfoGRPC, 1027, RID)
+		return analysisResult, status.Error(codes.PermissionDenied, "permission denied")
+	}
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			return analysisResult, status.Error(codes.NotFound, "analysis not found")
+		}
+		return analysisResult, status.Error(codes.Internal, "internal error")
+	}
+	return analysisResult, nil
+}
+
+// getToken returns the access token of ctx, sent as a bearer token in the authorization
+// metadata or, by clients that predate it, in the husky-token metadata.
+func getToken(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if authorization := md.Get(authorizationMetadataKey); len(authorization) > 0 && strings.HasPrefix(authorization[0], "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization[0], "Bearer "))
+	}
+	values := md.Get(tokenMetadataKey)
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
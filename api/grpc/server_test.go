@@ -0,0 +1,113 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package grpc_test
+
+import (
+	"context"
+
+	huskyGrpc "github.com/globocom/huskyCI/api/grpc"
+	"github.com/globocom/huskyCI/api/grpc/huskycipb"
+	"github.com/globocom/huskyCI/api/log"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type FakeAuthorizer struct {
+	expectedToken string
+}
+
+func (fA *FakeAuthorizer) HasAuthorization(attemptToken, repositoryURL string) bool {
+	return attemptToken == fA.expectedToken
+}
+
+var _ = Describe("Server", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	server := huskyGrpc.Server{
+		Authorizer: &FakeAuthorizer{expectedToken: "valid-token"},
+	}
+	authorizedCtx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("husky-token", "valid-token"))
+
+	Describe("SubmitAnalysis", func() {
+		Context("When husky-token metadata is missing", func() {
+			It("Should return a PermissionDenied error", func() {
+				req := &huskycipb.SubmitAnalysisRequest{
+					RepositoryUrl:    "https://github.com/globocom/huskyCI.git",
+					RepositoryBranch: "master",
+				}
+				_, err := server.SubmitAnalysis(context.Background(), req)
+				Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+			})
+		})
+		Context("When the token is sent as a bearer token in authorization metadata", func() {
+			It("Should authorize it as husky-token metadata", func() {
+				ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer valid-token"))
+				req := &huskycipb.SubmitAnalysisRequest{
+					RepositoryUrl:    "http://globo.com",
+					RepositoryBranch: "master",
+				}
+				_, err := server.SubmitAnalysis(ctx, req)
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+		})
+		Context("When authorization metadata is not a bearer token", func() {
+			It("Should return a PermissionDenied error", func() {
+				ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Basic valid-token"))
+				req := &huskycipb.SubmitAnalysisRequest{
+					RepositoryUrl:    "https://github.com/globocom/huskyCI.git",
+					RepositoryBranch: "master",
+				}
+				_, err := server.SubmitAnalysis(ctx, req)
+				Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+			})
+		})
+		Context("When repository URL is invalid", func() {
+			It("Should return an InvalidArgument error", func() {
+				req := &huskycipb.SubmitAnalysisRequest{
+					RepositoryUrl:    "http://globo.com",
+					RepositoryBranch: "master",
+				}
+				_, err := server.SubmitAnalysis(authorizedCtx, req)
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+		})
+		Context("When repository branch is invalid", func() {
+			It("Should return an InvalidArgument error", func() {
+				req := &huskycipb.SubmitAnalysisRequest{
+					RepositoryUrl:    "https://github.com/globocom/huskyCI.git",
+					RepositoryBranch: " [bra nch] ",
+				}
+				_, err := server.SubmitAnalysis(authorizedCtx, req)
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+		})
+	})
+
+	Describe("GetAnalysis", func() {
+		Context("When analysis ID is invalid", func() {
+			It("Should return an InvalidArgument error", func() {
+				req := &huskycipb.GetAnalysisRequest{AnalysisId: "*"}
+				_, err := server.GetAnalysis(authorizedCtx, req)
+				Expect(status.Code(err)).To(Equal(codes.InvalidArgument))
+			})
+		})
+	})
+
+	Describe("ListAnalyses", func() {
+		Context("When husky-token metadata is not valid", func() {
+			It("Should return a PermissionDenied error", func() {
+				ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("husky-token", "invalid"))
+				req := &huskycipb.ListAnalysesRequest{RepositoryUrl: "https://github.com/globocom/huskyCI.git"}
+				_, err := server.ListAnalyses(ctx, req)
+				Expect(status.Code(err)).To(Equal(codes.PermissionDenied))
+			})
+		})
+	})
+})
@@ -8,7 +8,7 @@ package log
 var MsgCode = map[int]string{
 
 	// HuskyCI API infos
-	11: "Starting HuskyCI.",
+	11: "Starting HuskyCI. Version, commit, build date and Go version: ",
 	12: "Environment variables set properly.",
 	13: "Docker API is up and running.",
 	14: "Connection with MongoDB succeed.",
@@ -19,6 +19,11 @@ var MsgCode = map[int]string{
 	19: "SecurityTest upserted in MondoDB: ",
 	20: "Default User found in MongoDB.",
 	24: "URL received to generate a new token: ",
+	25: "Starting huskyCI gRPC server on port: ",
+	26: "Refreshing stale NVD cache entries: ",
+	27: "Connection with Redis succeed.",
+	28: "Loaded the parser plugin of the following securityTest: ",
+	29: "Custom securityTest removed from MongoDB: ",
 
 	// HuskyCI API warnings
 	101: "Analysis started: ",
@@ -32,6 +37,29 @@ var MsgCode = map[int]string{
 	110: "The following repository is already in MongoDB: ",
 	111: "Invalid user input for time range query string parameter: ",
 	112: "Invalid user input for metric type: ",
+	113: "Could not enrich the following CVE with NVD data: ",
+	114: "Could not list changed files, running a full scan: ",
+	115: "Analysis did not report a result before its deadline: ",
+	116: "Could not use cached analysis stats: ",
+	117: "Container did not finish before its timeout in seconds and was removed: ",
+	118: "Retrying securityTest that could not run due to an infrastructure error: ",
+	119: "securityTest took longer than its SLA: ",
+	120: "Container was stopped and removed as its analysis exceeded its timeout: ",
+	121: "Analysis exceeded its timeout and its remaining securityTests were canceled: ",
+	122: "Analysis was shared until: ",
+	123: "securityTest ran a different version than the expected one: ",
+	124: "securityTest image uses a mutable tag, pin it to a version: ",
+	125: "Docker API circuit opened after consecutive failures, refusing calls for: ",
+	126: "Interrupted analyses did not store their status within: ",
+	127: "Refused a new analysis as huskyCI is shutting down: ",
+	128: "Pending notifications were not delivered within: ",
+	129: "Pull request analysis timed out waiting for its head and base analyses: ",
+	130: "Retrying analysis that could not run due to a transient error: ",
+	131: "Refused a custom securityTest with invalid dependencies: ",
+	132: "A container of the following analysis exited unexpectedly: ",
+	133: "Refused to pull the following image with CRITICAL vulnerabilities: ",
+	134: "Rate limited analyses requested by the following client: ",
+	135: "The following image was built for another architecture than the Docker host and runs under emulation: ",
 
 	// HuskyCI API errors
 	1001: "Error(s) found when starting HuskyCI API: ",
@@ -73,6 +101,33 @@ var MsgCode = map[int]string{
 	1037: "Internal error running Yarnaudit: ",
 	1038: "Could not Unmarshall the following gitleaksOutput: ",
 	1039: "Could not Unmarshall the following spotbugsOutput: ",
+	1040: "Could not start huskyCI gRPC server: ",
+	1041: "Could not Unmarshall the following dependencyCheckOutput: ",
+	1042: "Received an invalid base commit: ",
+	1043: "Received an invalid subpath: ",
+	1044: "Received an invalid excluded path: ",
+	1045: "Received an invalid tarball: ",
+	1046: "Could not load the compliance mapping: ",
+	1047: "MongoDB message in FindAllDBRepository: ",
+	1048: "Could not load parser plugins: ",
+	1049: "Received an invalid env: ",
+	1050: "Received an invalid ignore: ",
+	1051: "Received an invalid gitleaks allowlist: ",
+	1052: "Received an invalid npm audit fail on: ",
+	1053: "Received invalid refs: ",
+	1054: "Could not Unmarshall the following depConfusionOutput: ",
+	1055: "Could not stop the REST API gracefully: ",
+	1056: "Received an invalid commit SHA: ",
+	1057: "Received invalid requester metadata: ",
+	1058: "Could not Unmarshall the following eslintOutput: ",
+	1059: "Received an invalid pull request analysis: ",
+	1060: "Received an invalid callback URL: ",
+	1061: "securityTests have invalid dependencies: ",
+	1062: "Could not Unmarshall the following hadolintOutput: ",
+	1063: "Could not count the analyses requested by the following client: ",
+	1064: "Could not validate the platforms of the images of securityTests: ",
+	1065: "Received an invalid bulk analysis request: ",
+	1066: "Received an invalid suppression request: ",
 
 	// MongoDB infos
 	21: "Connecting to MongoDB.",
@@ -104,6 +159,41 @@ var MsgCode = map[int]string{
 	2015: "Could not create a new repository: ",
 	2016: "Could not create a new securityTest: ",
 	2017: "Error running the MongoDB aggregation for the following metric: ",
+	2018: "Could not refresh NVD cache: ",
+	2019: "Could not cache NVD data of the following CVE: ",
+	2020: "Could not finalize analysis: ",
+	2021: "Database Healthcheck failed: ",
+	2022: "Could not connect to Redis: ",
+	2023: "Error running the MongoDB aggregation for the analysis stats: ",
+	2024: "Could not send Slack alert: ",
+	2025: "Error running the MongoDB aggregation for the SLA report: ",
+	2026: "Could not record the vulnerability trend of the following repository: ",
+	2027: "Could not get the vulnerability trend of the following repository: ",
+	2028: "Could not share the following analysis: ",
+	2029: "Could not remove analysis shares: ",
+	2030: "Could not store the risk score of the following repository: ",
+	2031: "Could not store the following custom securityTest: ",
+	2032: "Could not create the indexes of the following collection: ",
+	2033: "Could not store the following pull request analysis: ",
+	2034: "Could not deliver the callback of the following analysis: ",
+	2035: "Could not renew the Vault token: ",
+	2036: "Could not update the Slack channel of the following repository: ",
+	2037: "Could not report the analysis to GitHub: ",
+	2038: "Could not report the analysis to GitLab: ",
+	2039: "Could not update the GitLab integration of the following repository: ",
+	2040: "Could not report the analysis to Bitbucket: ",
+	2041: "Could not record the findings of the following analysis: ",
+	2042: "Could not archive the following analysis: ",
+	2043: "Could not retrieve the following archived analysis: ",
+	2044: "Could not scan the images of securityTests for vulnerabilities: ",
+	2045: "Could not delete analyses: ",
+	2046: "Could not store the following bulk analysis job: ",
+	2047: "Could not record the following analysis event: ",
+	2048: "Could not store the following suppression request: ",
+	2049: "Could not open the JIRA issues of the following analysis: ",
+	2050: "Could not update whether the HTTPS token clones the following repository: ",
+	2051: "Could not resume the bulk analysis jobs left running: ",
+	2052: "Could not delete the archived copy of the following analysis: ",
 
 	// Docker API info
 	31: "Waiting pull image...",
@@ -112,6 +202,24 @@ var MsgCode = map[int]string{
 	34: "Container finished successfully: ",
 	35: "Container image has been pulled successfully: ",
 	36: "Container cOutput read sucessfully for CID: ",
+	37: "Max concurrent containers reached. Waiting for a free slot in Docker host: ",
+	38: "Reused the cached findings of the following securityTest from the analysis: ",
+	39: "Shutting down huskyCI after receiving the signal: ",
+	40: "huskyCI stopped. Analyses interrupted: ",
+	41: "Pull request analysis started: ",
+	42: "Delivered the callback of the following analysis: ",
+	43: "Vault token will be renewed before it expires in: ",
+	44: "Archive job finished. Analyses archived: ",
+	45: "Image vulnerability scan finished. Images scanned: ",
+	46: "Deleted analysis. RID, repository and deleted by: ",
+	47: "Deletion job finished. Analyses deleted: ",
+	48: "Docker host platform detected: ",
+	49: "Bulk analysis job started. Job and repositories: ",
+	50: "Bulk analysis job finished: ",
+	51: "Finding suppression requested. Request, finding and requested by: ",
+	52: "Finding suppression reviewed. Request, action and reviewed by: ",
+	53: "JIRA issue opened. Analysis, issue and finding: ",
+	54: "Bulk analysis job resumed: ",
 
 	// Docker API warning
 	301: "",
@@ -142,6 +250,14 @@ var MsgCode = map[int]string{
 	3025: "Could not update listed containers: ",
 	3026: "Could not initialize default configurations: ",
 	3027: "Could not remove container via huskyCI: ",
+	3028: "Could not follow container's output: ",
+	3029: "Could not copy the following file into the container: ",
+	3030: "Could not inspect the digest of the following image: ",
+	3031: "Refused to run the following image by a mutable tag: ",
+	3032: "Could not watch the Docker event stream: ",
+	3033: "Could not scan the following image for vulnerabilities: ",
+	3034: "Could not read the platforms of the following image from its registry: ",
+	3035: "Could not remove the following shared volume: ",
 
 	// Util package errors
 	4001: "Could not read certificate file: ",
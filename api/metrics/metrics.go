@@ -0,0 +1,102 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package metrics holds the Prometheus metrics of huskyCI, served at /metrics:
+//
+//	huskyci_analyses_started_total                         analyses started
+//	huskyci_analyses_finished_total{status,result}         analyses finished
+//	huskyci_securitytest_duration_seconds{securitytest}    container duration of each securityTest
+//	huskyci_container_failures_total{phase,class}          containers failed by phase and error class
+//	huskyci_container_queue_depth{host}                    containers waiting for a Docker host slot
+//	huskyci_image_pull_duration_seconds{result}            image pulls, result is success or failure
+//	huskyci_component_up{component}                        health of mongodb and docker at scrape time
+//	huskyci_analysis_retries_total                         analyses run again after a transient error
+//	huskyci_sla_violations_total{tool}                     containers slower than their SLA
+//	huskyci_high_confidence_findings                       correlated findings of the last analysis
+//	huskyci_bulk_job_size                                  repositories of each bulk analysis job
+//
+// The phase of a container failure is one of connect, pull, create, copy, start, wait, read
+// and remove, and its class one of timeout, connection, not_found and other. Containers
+// canceled along with their analysis are not failures. No metric is labeled by repository:
+// findings and risk scores of each repository are only served to whoever can read them.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// HighConfidenceFindings is the number of correlated groups reported by two
+// or more securityTools in the last finished analysis.
+var HighConfidenceFindings = promauto.NewGauge(prometheus.GaugeOpts{
+	Name: "huskyci_high_confidence_findings",
+	Help: "Number of findings reported by two or more securityTools in the last finished analysis.",
+})
+
+// SLAViolations is the number of containers that took longer than the SLA of their securityTest.
+var SLAViolations = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "huskyci_sla_violations_total",
+	Help: "Number of containers that took longer than the SLA of their securityTest.",
+}, []string{"tool"})
+
+// AnalysisRetries is the number of times an analysis ran again after a transient error.
+var AnalysisRetries = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "huskyci_analysis_retries_total",
+	Help: "Number of times an analysis ran again after a transient error.",
+})
+
+// AnalysesStarted is the number of analyses started.
+var AnalysesStarted = promauto.NewCounter(prometheus.CounterOpts{
+	Name: "huskyci_analyses_started_total",
+	Help: "Number of analyses started.",
+})
+
+// AnalysesFinished is the number of analyses finished by their status, such as finished or
+// timeout, and their result, such as passed or failed.
+var AnalysesFinished = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "huskyci_analyses_finished_total",
+	Help: "Number of analyses finished by status and result.",
+}, []string{"status", "result"})
+
+// SecurityTestDuration is how long the container of each securityTest took, in seconds.
+var SecurityTestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "huskyci_securitytest_duration_seconds",
+	Help:    "How long the container of each securityTest took, in seconds.",
+	Buckets: prometheus.ExponentialBuckets(1, 2, 12),
+}, []string{"securitytest"})
+
+// ContainerFailures is the number of containers that failed in a phase of their run, such as
+// pull or create, by the class of their error, such as timeout or connection.
+var ContainerFailures = promauto.NewCounterVec(prometheus.CounterOpts{
+	Name: "huskyci_container_failures_total",
+	Help: "Number of containers that failed by phase of their run and class of error.",
+}, []string{"phase", "class"})
+
+// ContainerQueueDepth is the number of containers waiting for a free slot in each Docker host.
+var ContainerQueueDepth = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "huskyci_container_queue_depth",
+	Help: "Number of containers waiting for a free slot in each Docker host.",
+}, []string{"host"})
+
+// ImagePullDuration is how long pulling an image took until it was loaded, in seconds, by
+// whether it succeeded.
+var ImagePullDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+	Name:    "huskyci_image_pull_duration_seconds",
+	Help:    "How long pulling an image took until it was loaded, in seconds.",
+	Buckets: prometheus.ExponentialBuckets(1, 2, 11),
+}, []string{"result"})
+
+// ComponentUp is 1 if a component huskyCI depends on, mongodb or docker, was healthy when
+// metrics were last scraped and 0 otherwise.
+var ComponentUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+	Name: "huskyci_component_up",
+	Help: "Whether a component huskyCI depends on was healthy when metrics were scraped.",
+}, []string{"component"})
+
+// BulkJobSize is how many repositories each bulk analysis job accepted has.
+var BulkJobSize = promauto.NewHistogram(prometheus.HistogramOpts{
+	Name:    "huskyci_bulk_job_size",
+	Help:    "How many repositories each bulk analysis job accepted has.",
+	Buckets: []float64{1, 5, 10, 25, 50, 100},
+})
@@ -0,0 +1,79 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// MaxPendingNotifications is how many notifications sent in background can wait to be
+// delivered before the queue of notifications is reported as unhealthy.
+const MaxPendingNotifications = 100
+
+// pending are the notifications sent in background that were not delivered yet.
+var (
+	pending      sync.WaitGroup
+	pendingCount int64
+)
+
+// goPending runs deliver in background, counted as pending until it returns.
+func goPending(deliver func()) {
+	pending.Add(1)
+	atomic.AddInt64(&pendingCount, 1)
+	go func() {
+		defer pending.Done()
+		defer atomic.AddInt64(&pendingCount, -1)
+		deliver()
+	}()
+}
+
+// reportAsync calls report in background. onError is called if it fails.
+func reportAsync(report func() error, onError func(error)) {
+	goPending(func() {
+		if err := report(); err != nil && onError != nil {
+			onError(err)
+		}
+	})
+}
+
+// retry calls send until it succeeds, it can not be retried or it was called maxAttempts
+// times. send returns how long to wait before calling it again: zero if the server did not
+// tell, so backoff is waited and then doubled, or a negative duration if it can not be retried.
+func retry(maxAttempts int, backoff time.Duration, send func() (time.Duration, error)) error {
+	for attempts := 1; ; attempts++ {
+		wait, err := send()
+		if err == nil || wait < 0 || attempts >= maxAttempts {
+			return err
+		}
+		if wait == 0 {
+			wait = backoff
+			backoff *= 2
+		}
+		time.Sleep(wait)
+	}
+}
+
+// Flush waits up to timeout for the notifications sent in background to be delivered and
+// returns whether all of them were.
+func Flush(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		pending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Pending returns how many notifications sent in background were not delivered yet.
+func Pending() int {
+	return int(atomic.LoadInt64(&pendingCount))
+}
@@ -0,0 +1,142 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// BitbucketStatusKey is the key huskyCI sets build statuses with.
+const BitbucketStatusKey = "huskyCI"
+
+// BitbucketCloudAPIURL is the URL of the API of Bitbucket Cloud.
+const BitbucketCloudAPIURL = "https://api.bitbucket.org"
+
+// BitbucketCloudHost is the host Bitbucket Cloud repositories are cloned from.
+const BitbucketCloudHost = "bitbucket.org"
+
+// bitbucketMaxAttempts is how many times a request failed by Bitbucket is sent.
+const bitbucketMaxAttempts = 5
+
+// BitbucketStatus is a build status, see
+// https://developer.atlassian.com/cloud/bitbucket/rest/api-group-commit-statuses/.
+// Bitbucket Server takes the same fields.
+type BitbucketStatus struct {
+	Key         string `json:"key"`
+	State       string `json:"state"`
+	URL         string `json:"url"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// BitbucketClient sets build statuses in Bitbucket Cloud or, if Server is set, in the
+// Bitbucket Server at URL. It authenticates with the app password of Username if it is
+// set, or with Token otherwise.
+type BitbucketClient struct {
+	URL         string
+	Server      bool
+	Username    string
+	Password    string
+	Token       string
+	MaxAttempts int
+	Backoff     time.Duration
+	Client      *http.Client
+}
+
+// NewBitbucketClient returns a BitbucketClient of the Bitbucket Server at serverURL, or
+// of Bitbucket Cloud if it is empty. It returns nil if neither an app password nor a
+// token is given, so callers can tell that Bitbucket is not configured.
+func NewBitbucketClient(serverURL, username, appPassword, token string) *BitbucketClient {
+	if (username == "" || appPassword == "") && token == "" {
+		return nil
+	}
+	bb := &BitbucketClient{
+		URL:         BitbucketCloudAPIURL,
+		Username:    username,
+		Password:    appPassword,
+		Token:       token,
+		MaxAttempts: bitbucketMaxAttempts,
+		Backoff:     time.Second,
+		Client:      &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+	}
+	if serverURL != "" {
+		bb.URL, bb.Server = strings.TrimSuffix(serverURL, "/"), true
+	}
+	return bb
+}
+
+// Host returns the host repositories reported by it are cloned from.
+func (bb *BitbucketClient) Host() string {
+	if !bb.Server {
+		return BitbucketCloudHost
+	}
+	serverURL, err := url.Parse(bb.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.ToLower(serverURL.Hostname())
+}
+
+// SetBuildStatus sets status to the commit sha of the repository slug of owner, a
+// workspace of Bitbucket Cloud or a project key of Bitbucket Server.
+func (bb *BitbucketClient) SetBuildStatus(owner, slug, sha string, status BitbucketStatus) error {
+	path := fmt.Sprintf("/2.0/repositories/%s/%s/commit/%s/statuses/build", url.PathEscape(owner), url.PathEscape(slug), url.PathEscape(sha))
+	if bb.Server {
+		path = fmt.Sprintf("/rest/api/1.0/projects/%s/repos/%s/commits/%s/builds", url.PathEscape(owner), url.PathEscape(slug), url.PathEscape(sha))
+	}
+	body, err := json.Marshal(status)
+	if err != nil {
+		return err
+	}
+	return retry(bb.MaxAttempts, bb.Backoff, func() (time.Duration, error) {
+		return bb.send(path, body)
+	})
+}
+
+// ReportAsync calls report without waiting for it. onError is called if it fails.
+// Flush waits for these reports.
+func (bb *BitbucketClient) ReportAsync(report func() error, onError func(error)) {
+	reportAsync(report, onError)
+}
+
+// send returns how long to wait before retrying a failed request: zero if Bitbucket did not
+// tell, or a negative duration if it can not be retried.
+func (bb *BitbucketClient) send(path string, body []byte) (time.Duration, error) {
+	req, err := http.NewRequest(http.MethodPost, bb.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if bb.Username != "" && bb.Password != "" {
+		req.SetBasicAuth(bb.Username, bb.Password)
+	} else {
+		req.Header.Set("Authorization", "Bearer "+bb.Token)
+	}
+	resp, err := bb.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return 0, nil
+	}
+	err = fmt.Errorf("Bitbucket returned status code %d for %s", resp.StatusCode, path)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return rateLimitWait(resp.Header), err
+	case resp.StatusCode >= 500:
+		return 0, err
+	default:
+		return -1, err
+	}
+}
@@ -0,0 +1,122 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// CallbackSignatureHeader holds "sha256=" followed by the hex-encoded HMAC-SHA256 of the
+// body of a callback, so its receiver can tell it was sent by huskyCI.
+const CallbackSignatureHeader = "X-HuskyCI-Signature"
+
+// callbackBackoff is how long a callback waits before its second attempt, doubled after each one.
+const callbackBackoff = time.Second
+
+// Callback posts a JSON payload to the callback URL of an analysis.
+type Callback struct {
+	URL         string
+	Secret      string
+	Allowlist   []string
+	MaxAttempts int
+	Backoff     time.Duration
+	Client      *http.Client
+}
+
+// NewCallback returns a Callback for callbackURL, signed with secret if it is not empty,
+// that is posted up to maxAttempts times as long as its host is in allowlist.
+func NewCallback(callbackURL, secret string, allowlist []string, maxAttempts int) *Callback {
+	return &Callback{
+		URL:         callbackURL,
+		Secret:      secret,
+		Allowlist:   allowlist,
+		MaxAttempts: maxAttempts,
+		Backoff:     callbackBackoff,
+		// its transport is the one of each delivery, see util.CallbackTransport
+		Client: &http.Client{
+			Timeout: 10 * time.Second,
+			// a redirect could lead to a host out of the allowlist
+			CheckRedirect: func(*http.Request, []*http.Request) error {
+				return http.ErrUseLastResponse
+			},
+		},
+	}
+}
+
+// Deliver posts payload to the callback URL and returns how many attempts it took. Attempts
+// that fail to connect or get a 5xx response are retried with exponential backoff, while
+// any other response that is not 2xx is not retried.
+func (cb *Callback) Deliver(payload interface{}) (int, error) {
+	// the host may resolve to another IP since the callback URL was accepted, and again
+	// between this check and each attempt, so the IP each attempt dials is checked too
+	if err := util.CheckValidCallbackURL(cb.URL, cb.Allowlist); err != nil {
+		return 0, err
+	}
+	transport, err := util.CallbackTransport(cb.URL, cb.Allowlist)
+	if err != nil {
+		return 0, err
+	}
+	defer transport.CloseIdleConnections()
+	client := *cb.Client
+	client.Transport = transport
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+	backoff := cb.Backoff
+	attempts := 0
+	for {
+		attempts++
+		retry, err := cb.post(&client, body)
+		if err == nil || !retry || attempts >= cb.MaxAttempts {
+			return attempts, err
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+// post returns whether a failed post of body with client can be retried.
+func (cb *Callback) post(client *http.Client, body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, cb.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if cb.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(cb.Secret))
+		mac.Write(body)
+		req.Header.Set(CallbackSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+	return resp.StatusCode >= 500, fmt.Errorf("callback returned status code %d", resp.StatusCode)
+}
+
+// DeliverAsync delivers payload without waiting for it and then calls onDone with how many
+// attempts it took and its error, if any. Flush waits for these deliveries.
+func (cb *Callback) DeliverAsync(payload interface{}, onDone func(int, error)) {
+	goPending(func() {
+		attempts, err := cb.Deliver(payload)
+		if onDone != nil {
+			onDone(attempts, err)
+		}
+	})
+}
@@ -0,0 +1,326 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// GitHubContext is the name huskyCI reports commit statuses and Check Runs with.
+const GitHubContext = "huskyCI"
+
+// gitHubMaxAttempts is how many times a request rate limited or failed by GitHub is sent.
+const gitHubMaxAttempts = 5
+
+// gitHubBackoff is how long a request waits before its second attempt, doubled after each
+// one, when GitHub does not tell when to retry it.
+const gitHubBackoff = time.Second
+
+// gitHubMaxRetryWait bounds how long a rate limited request waits for its rate limit to reset.
+const gitHubMaxRetryWait = time.Minute
+
+// GitHubStatus is a commit status, see https://docs.github.com/rest/commits/statuses.
+type GitHubStatus struct {
+	State       string `json:"state"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+	Context     string `json:"context"`
+}
+
+// GitHubCheckRun is a Check Run, see https://docs.github.com/rest/checks/runs.
+type GitHubCheckRun struct {
+	Name        string             `json:"name,omitempty"`
+	HeadSHA     string             `json:"head_sha,omitempty"`
+	Status      string             `json:"status,omitempty"`
+	Conclusion  string             `json:"conclusion,omitempty"`
+	DetailsURL  string             `json:"details_url,omitempty"`
+	CompletedAt *time.Time         `json:"completed_at,omitempty"`
+	Output      *GitHubCheckOutput `json:"output,omitempty"`
+}
+
+// GitHubCheckOutput is the summary of a Check Run and its annotations.
+type GitHubCheckOutput struct {
+	Title       string             `json:"title"`
+	Summary     string             `json:"summary"`
+	Annotations []GitHubAnnotation `json:"annotations,omitempty"`
+}
+
+// GitHubAnnotation points a finding out at a line of a file of the commit checked.
+type GitHubAnnotation struct {
+	Path            string `json:"path"`
+	StartLine       int    `json:"start_line"`
+	EndLine         int    `json:"end_line"`
+	AnnotationLevel string `json:"annotation_level"`
+	Title           string `json:"title,omitempty"`
+	Message         string `json:"message"`
+}
+
+// GitHubClient reports analyses to the GitHub API. It authenticates as a GitHub App if
+// AppID is set, which is required to create Check Runs, or with the token of the owner
+// of each repository otherwise, falling back to Token.
+type GitHubClient struct {
+	APIURL      string
+	Token       string
+	OwnerTokens map[string]string
+	AppID       int
+	AppKey      *rsa.PrivateKey
+	MaxAttempts int
+	Backoff     time.Duration
+	Client      *http.Client
+	// installationTokens caches the tokens of the installations of the GitHub App, by owner.
+	installationTokens map[string]gitHubToken
+	mutex              sync.Mutex
+}
+
+type gitHubToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewGitHubClient returns a GitHubClient of the GitHub API at apiURL, or nil if neither
+// a token nor a GitHub App is configured so callers can tell that GitHub is not. An
+// error is returned if appPrivateKey is not a PEM-encoded RSA key.
+func NewGitHubClient(apiURL, token string, ownerTokens map[string]string, appID int, appPrivateKey string) (*GitHubClient, error) {
+	if token == "" && len(ownerTokens) == 0 && appID == 0 {
+		return nil, nil
+	}
+	gh := &GitHubClient{
+		APIURL:             strings.TrimSuffix(apiURL, "/"),
+		Token:              token,
+		OwnerTokens:        ownerTokens,
+		MaxAttempts:        gitHubMaxAttempts,
+		Backoff:            gitHubBackoff,
+		Client:             &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+		installationTokens: map[string]gitHubToken{},
+	}
+	if appID != 0 {
+		key, err := parseRSAPrivateKey(appPrivateKey)
+		if err != nil {
+			return nil, err
+		}
+		gh.AppID, gh.AppKey = appID, key
+	}
+	return gh, nil
+}
+
+func parseRSAPrivateKey(pemKey string) (*rsa.PrivateKey, error) {
+	block, _ := pem.Decode([]byte(pemKey))
+	if block == nil {
+		return nil, errors.New("GitHub App private key is not PEM-encoded")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	rsaKey, ok := key.(*rsa.PrivateKey)
+	if !ok {
+		return nil, errors.New("GitHub App private key is not an RSA key")
+	}
+	return rsaKey, nil
+}
+
+// GitHubCloudHost is the host of the repositories of GitHub, whose API is at api.github.com.
+const GitHubCloudHost = "github.com"
+
+// Host returns the host repositories reported by it are cloned from: github.com for the API
+// of GitHub and the host of the API for GitHub Enterprise Server.
+func (gh *GitHubClient) Host() string {
+	apiURL, err := url.Parse(gh.APIURL)
+	if err != nil {
+		return ""
+	}
+	host := strings.ToLower(apiURL.Hostname())
+	if host == "api."+GitHubCloudHost {
+		return GitHubCloudHost
+	}
+	return host
+}
+
+// UsesChecks returns whether it reports analyses as Check Runs instead of commit statuses.
+func (gh *GitHubClient) UsesChecks() bool {
+	return gh.AppID != 0
+}
+
+// SetCommitStatus sets status to the commit sha of owner/repo.
+func (gh *GitHubClient) SetCommitStatus(owner, repo, sha string, status GitHubStatus) error {
+	path := fmt.Sprintf("/repos/%s/%s/statuses/%s", owner, repo, url.PathEscape(sha))
+	return gh.do(owner, repo, http.MethodPost, path, status, nil)
+}
+
+// CreateCheckRun creates checkRun in owner/repo and returns its ID.
+func (gh *GitHubClient) CreateCheckRun(owner, repo string, checkRun GitHubCheckRun) (int64, error) {
+	response := struct {
+		ID int64 `json:"id"`
+	}{}
+	err := gh.do(owner, repo, http.MethodPost, fmt.Sprintf("/repos/%s/%s/check-runs", owner, repo), checkRun, &response)
+	return response.ID, err
+}
+
+// UpdateCheckRun updates the Check Run of owner/repo with the given ID to checkRun.
+func (gh *GitHubClient) UpdateCheckRun(owner, repo string, ID int64, checkRun GitHubCheckRun) error {
+	return gh.do(owner, repo, http.MethodPatch, fmt.Sprintf("/repos/%s/%s/check-runs/%d", owner, repo, ID), checkRun, nil)
+}
+
+// ReportAsync calls report without waiting for it. onError is called if it fails.
+// Flush waits for these reports.
+func (gh *GitHubClient) ReportAsync(report func() error, onError func(error)) {
+	reportAsync(report, onError)
+}
+
+// token returns the token huskyCI authenticates with to report to owner/repo.
+func (gh *GitHubClient) token(owner, repo string) (string, error) {
+	if gh.AppID == 0 {
+		if token, ok := gh.OwnerTokens[strings.ToLower(owner)]; ok {
+			return token, nil
+		}
+		return gh.Token, nil
+	}
+	gh.mutex.Lock()
+	defer gh.mutex.Unlock()
+	cached, ok := gh.installationTokens[strings.ToLower(owner)]
+	// tokens are renewed a bit before they expire so none expires during a request
+	if ok && time.Now().Add(time.Minute).Before(cached.ExpiresAt) {
+		return cached.Token, nil
+	}
+	appToken, err := gh.appToken()
+	if err != nil {
+		return "", err
+	}
+	installation := struct {
+		ID int64 `json:"id"`
+	}{}
+	if err := gh.request(appToken, http.MethodGet, fmt.Sprintf("/repos/%s/%s/installation", owner, repo), nil, &installation); err != nil {
+		return "", err
+	}
+	installationToken := gitHubToken{}
+	path := fmt.Sprintf("/app/installations/%d/access_tokens", installation.ID)
+	if err := gh.request(appToken, http.MethodPost, path, nil, &installationToken); err != nil {
+		return "", err
+	}
+	gh.installationTokens[strings.ToLower(owner)] = installationToken
+	return installationToken.Token, nil
+}
+
+// appToken returns a JWT, signed by the private key of the GitHub App, that is valid for
+// a few minutes.
+func (gh *GitHubClient) appToken() (string, error) {
+	now := time.Now()
+	header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT"})
+	claims, _ := json.Marshal(map[string]interface{}{
+		// the clock of GitHub may be a bit behind
+		"iat": now.Add(-time.Minute).Unix(),
+		"exp": now.Add(9 * time.Minute).Unix(),
+		"iss": strconv.Itoa(gh.AppID),
+	})
+	unsigned := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(claims)
+	digest := sha256.Sum256([]byte(unsigned))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, gh.AppKey, crypto.SHA256, digest[:])
+	if err != nil {
+		return "", err
+	}
+	return unsigned + "." + base64.RawURLEncoding.EncodeToString(signature), nil
+}
+
+func (gh *GitHubClient) do(owner, repo, method, path string, payload, response interface{}) error {
+	token, err := gh.token(owner, repo)
+	if err != nil {
+		return err
+	}
+	if token == "" {
+		return fmt.Errorf("no GitHub token for %s", owner)
+	}
+	return gh.request(token, method, path, payload, response)
+}
+
+// request sends payload to the GitHub API, authenticated by token, and decodes its response
+// into response if it is not nil. Requests that fail to connect, are rate limited or get a
+// 5xx response are sent again, after the rate limit resets or with exponential backoff.
+func (gh *GitHubClient) request(token, method, path string, payload, response interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	return retry(gh.MaxAttempts, gh.Backoff, func() (time.Duration, error) {
+		return gh.send(token, method, path, body, response)
+	})
+}
+
+// send returns how long to wait before retrying a failed request: zero if GitHub did not
+// tell, or a negative duration if it can not be retried.
+func (gh *GitHubClient) send(token, method, path string, body []byte, response interface{}) (time.Duration, error) {
+	req, err := http.NewRequest(method, gh.APIURL+path, bytes.NewReader(body))
+	if err != nil {
+		return -1, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := gh.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if response == nil {
+			return 0, nil
+		}
+		return -1, json.NewDecoder(resp.Body).Decode(response)
+	}
+	err = fmt.Errorf("GitHub returned status code %d for %s %s", resp.StatusCode, method, path)
+	rateLimited := resp.StatusCode == http.StatusTooManyRequests ||
+		(resp.StatusCode == http.StatusForbidden && resp.Header.Get("X-RateLimit-Remaining") == "0")
+	switch {
+	case rateLimited:
+		return rateLimitWait(resp.Header), err
+	case resp.StatusCode >= 500:
+		return 0, err
+	default:
+		return -1, err
+	}
+}
+
+// rateLimitWait returns how long GitHub asks a rate limited request to wait, bounded by
+// gitHubMaxRetryWait, or zero if it does not tell.
+func rateLimitWait(header http.Header) time.Duration {
+	var wait time.Duration
+	if seconds, err := strconv.Atoi(header.Get("Retry-After")); err == nil {
+		wait = time.Duration(seconds) * time.Second
+	} else if reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+		wait = time.Until(time.Unix(reset, 0))
+	}
+	if wait <= 0 {
+		return 0
+	}
+	if wait > gitHubMaxRetryWait {
+		return gitHubMaxRetryWait
+	}
+	return wait
+}
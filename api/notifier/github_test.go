@@ -0,0 +1,29 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier_test
+
+import (
+	"github.com/globocom/huskyCI/api/notifier"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitHubClient", func() {
+
+	Describe("Host", func() {
+		It("Should be github.com for the API of GitHub", func() {
+			gh, err := notifier.NewGitHubClient("https://api.github.com", "token", nil, 0, "")
+			Expect(err).To(BeNil())
+			Expect(gh.Host()).To(Equal("github.com"))
+		})
+
+		It("Should be the host of the API of GitHub Enterprise Server", func() {
+			gh, err := notifier.NewGitHubClient("https://GitHub.example.com/api/v3/", "token", nil, 0, "")
+			Expect(err).To(BeNil())
+			Expect(gh.Host()).To(Equal("github.example.com"))
+		})
+	})
+})
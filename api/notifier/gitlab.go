@@ -0,0 +1,176 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// GitLabNoteMarker starts the merge request note of huskyCI, so it is updated by the next
+// analyses instead of a new note being posted each time.
+const GitLabNoteMarker = "<!-- huskyCI -->"
+
+// GitLabStatusName is the name huskyCI sets commit statuses with.
+const GitLabStatusName = "huskyCI"
+
+// gitLabMaxAttempts is how many times a request rate limited or failed by GitLab is sent.
+const gitLabMaxAttempts = 5
+
+// GitLabStatus is a commit status, see https://docs.gitlab.com/ee/api/commits.html#set-the-pipeline-status-of-a-commit.
+type GitLabStatus struct {
+	State       string `json:"state"`
+	Name        string `json:"name"`
+	TargetURL   string `json:"target_url,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// GitLabClient reports analyses to the API of a GitLab instance.
+type GitLabClient struct {
+	URL         string
+	Token       string
+	MaxAttempts int
+	Backoff     time.Duration
+	Client      *http.Client
+}
+
+// NewGitLabClient returns a GitLabClient of the GitLab at gitLabURL, or nil if either
+// gitLabURL or token is empty so callers can tell that GitLab is not configured.
+func NewGitLabClient(gitLabURL, token string) *GitLabClient {
+	if gitLabURL == "" || token == "" {
+		return nil
+	}
+	return &GitLabClient{
+		URL:         strings.TrimSuffix(gitLabURL, "/"),
+		Token:       token,
+		MaxAttempts: gitLabMaxAttempts,
+		Backoff:     time.Second,
+		Client:      &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+	}
+}
+
+// SetCommitStatus sets status to the commit sha of project, given by its path.
+func (gl *GitLabClient) SetCommitStatus(project, sha string, status GitLabStatus) error {
+	path := fmt.Sprintf("/projects/%s/statuses/%s", url.PathEscape(project), url.PathEscape(sha))
+	return gl.request(http.MethodPost, path, status, nil)
+}
+
+// UpsertMergeRequestNote comments the merge request of project with the given IID with
+// body, which must start with GitLabNoteMarker, editing the note of a previous analysis if
+// there is one.
+func (gl *GitLabClient) UpsertMergeRequestNote(project string, IID int, body string) error {
+	notesPath := fmt.Sprintf("/projects/%s/merge_requests/%d/notes", url.PathEscape(project), IID)
+	note := map[string]string{"body": body}
+	ID, err := gl.findNote(notesPath)
+	if err != nil {
+		return err
+	}
+	if ID == 0 {
+		return gl.request(http.MethodPost, notesPath, note, nil)
+	}
+	return gl.request(http.MethodPut, fmt.Sprintf("%s/%d", notesPath, ID), note, nil)
+}
+
+// findNote returns the ID of the first note of notesPath that starts with GitLabNoteMarker,
+// or zero if there is none.
+func (gl *GitLabClient) findNote(notesPath string) (int64, error) {
+	for page := 1; page > 0; {
+		notes := []struct {
+			ID     int64  `json:"id"`
+			Body   string `json:"body"`
+			System bool   `json:"system"`
+		}{}
+		path := fmt.Sprintf("%s?sort=asc&order_by=created_at&per_page=100&page=%d", notesPath, page)
+		nextPage, err := gl.requestPage(path, &notes)
+		if err != nil {
+			return 0, err
+		}
+		for _, note := range notes {
+			if !note.System && strings.HasPrefix(note.Body, GitLabNoteMarker) {
+				return note.ID, nil
+			}
+		}
+		page = nextPage
+	}
+	return 0, nil
+}
+
+// ReportAsync calls report without waiting for it. onError is called if it fails.
+// Flush waits for these reports.
+func (gl *GitLabClient) ReportAsync(report func() error, onError func(error)) {
+	reportAsync(report, onError)
+}
+
+// requestPage gets a page of a list into response and returns the number of the next one,
+// or zero if it is the last.
+func (gl *GitLabClient) requestPage(path string, response interface{}) (int, error) {
+	var nextPage int
+	err := retry(gl.MaxAttempts, gl.Backoff, func() (time.Duration, error) {
+		header, wait, err := gl.send(http.MethodGet, path, nil, response)
+		if err == nil {
+			nextPage, _ = strconv.Atoi(header.Get("X-Next-Page"))
+		}
+		return wait, err
+	})
+	return nextPage, err
+}
+
+// request sends payload to the GitLab API and decodes its response into response if it is
+// not nil. Requests that fail to connect, are rate limited or get a 5xx response are sent
+// again, once GitLab allows it or with exponential backoff.
+func (gl *GitLabClient) request(method, path string, payload, response interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	return retry(gl.MaxAttempts, gl.Backoff, func() (time.Duration, error) {
+		_, wait, err := gl.send(method, path, body, response)
+		return wait, err
+	})
+}
+
+// send returns the header of the response and how long to wait before retrying a failed
+// request: zero if GitLab did not tell, or a negative duration if it can not be retried.
+func (gl *GitLabClient) send(method, path string, body []byte, response interface{}) (http.Header, time.Duration, error) {
+	req, err := http.NewRequest(method, gl.URL+"/api/v4"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, -1, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", gl.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := gl.Client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		if response == nil {
+			return resp.Header, 0, nil
+		}
+		return resp.Header, -1, json.NewDecoder(resp.Body).Decode(response)
+	}
+	err = fmt.Errorf("GitLab returned status code %d for %s %s", resp.StatusCode, method, path)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return resp.Header, rateLimitWait(resp.Header), err
+	case resp.StatusCode >= 500:
+		return resp.Header, 0, err
+	default:
+		return resp.Header, -1, err
+	}
+}
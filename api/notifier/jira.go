@@ -0,0 +1,252 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+	goContext "golang.org/x/net/context"
+)
+
+// JIRAIssueType is the type of the issues huskyCI creates.
+const JIRAIssueType = "Bug"
+
+// jiraPriorities are the JIRA priorities of issues by the severity of their finding.
+var jiraPriorities = map[string]string{
+	"CRITICAL": "Highest",
+	"HIGH":     "High",
+	"MEDIUM":   "Medium",
+	"LOW":      "Low",
+}
+
+// JIRANotifier creates JIRA issues for findings in Project of the JIRA at URL. It authenticates
+// to JIRA Cloud with the API token of Email or, if Username is set, to JIRA Server with a
+// session of Username, which is opened again once it expires.
+type JIRANotifier struct {
+	URL      string
+	Project  string
+	Email    string
+	Token    string
+	Username string
+	Password string
+	Client   *http.Client
+
+	mutex   sync.Mutex
+	session *http.Cookie
+}
+
+// NewJIRANotifier returns a JIRANotifier of project in the JIRA at jiraURL, or nil if
+// either is empty or there are no credentials, so callers can tell that JIRA is not configured.
+func NewJIRANotifier(jiraURL, project, email, token, username, password string) *JIRANotifier {
+	if jiraURL == "" || project == "" || (token == "" && username == "") {
+		return nil
+	}
+	return &JIRANotifier{
+		URL:      strings.TrimSuffix(jiraURL, "/"),
+		Project:  project,
+		Email:    email,
+		Token:    token,
+		Username: username,
+		Password: password,
+		Client:   &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+	}
+}
+
+// JIRAPriority returns the JIRA priority of an issue of a finding of severity, such as High.
+func JIRAPriority(severity string) string {
+	if priority, ok := jiraPriorities[strings.ToUpper(severity)]; ok {
+		return priority
+	}
+	return jiraPriorities["MEDIUM"]
+}
+
+// JIRASummary returns the summary of the issue of finding. It ends with the fingerprint of
+// finding, so the issue is found again instead of a duplicate being created.
+func JIRASummary(finding types.Finding) string {
+	location := finding.Finding.File
+	if location != "" && finding.Finding.Line > 0 {
+		location = fmt.Sprintf("%s:%d", location, finding.Finding.Line)
+	}
+	return fmt.Sprintf("[huskyCI] %s %s in %s (%s)", finding.Finding.Tool, finding.Finding.RuleID, location, finding.Fingerprint)
+}
+
+// JIRADescription returns the description of the issue of finding.
+func JIRADescription(finding types.Finding) string {
+	var description strings.Builder
+	fmt.Fprintf(&description, "huskyCI found a %s finding in %s.\n\n", strings.ToUpper(finding.Finding.Severity), finding.RepositoryURL)
+	fmt.Fprintf(&description, "*Tool:* %s\n*Rule:* %s\n", finding.Finding.Tool, finding.Finding.RuleID)
+	if finding.Finding.File != "" {
+		fmt.Fprintf(&description, "*File:* %s", finding.Finding.File)
+		if finding.Finding.Line > 0 {
+			fmt.Fprintf(&description, ":%d", finding.Finding.Line)
+		}
+		description.WriteString("\n")
+	}
+	if finding.Finding.CVSSScore > 0 {
+		fmt.Fprintf(&description, "*CVSS:* %.1f %s\n", finding.Finding.CVSSScore, finding.Finding.CVSSVector)
+	}
+	if finding.Finding.URL != "" {
+		fmt.Fprintf(&description, "*Reference:* %s\n", finding.Finding.URL)
+	}
+	fmt.Fprintf(&description, "\n%s\n\n*Fingerprint:* %s", finding.Finding.Description, finding.Fingerprint)
+	return description.String()
+}
+
+// CreateIssue creates a Bug in Project for finding and returns its key, such as SEC-42. If
+// an issue of finding is still open, its key is returned instead of a duplicate being created.
+func (jn *JIRANotifier) CreateIssue(ctx goContext.Context, finding types.Finding) (string, error) {
+	key, err := jn.findOpenIssue(ctx, finding.Fingerprint)
+	if err != nil || key != "" {
+		return key, err
+	}
+	issue := map[string]interface{}{
+		"fields": map[string]interface{}{
+			"project":     map[string]string{"key": jn.Project},
+			"issuetype":   map[string]string{"name": JIRAIssueType},
+			"priority":    map[string]string{"name": JIRAPriority(finding.Finding.Severity)},
+			"summary":     JIRASummary(finding),
+			"description": JIRADescription(finding),
+		},
+	}
+	created := struct {
+		Key string `json:"key"`
+	}{}
+	if err := jn.request(ctx, http.MethodPost, "/rest/api/2/issue", issue, &created); err != nil {
+		return "", err
+	}
+	return created.Key, nil
+}
+
+// ReportAsync calls report without waiting for it. onError is called if it fails.
+// Flush waits for these reports.
+func (jn *JIRANotifier) ReportAsync(report func() error, onError func(error)) {
+	reportAsync(report, onError)
+}
+
+// findOpenIssue returns the key of an issue of Project not done yet whose summary has
+// fingerprint, or an empty string if there is none.
+func (jn *JIRANotifier) findOpenIssue(ctx goContext.Context, fingerprint string) (string, error) {
+	jql := fmt.Sprintf(`project = "%s" AND summary ~ "%s" AND statusCategory != Done`, jn.Project, fingerprint)
+	query := url.Values{"jql": {jql}, "fields": {"summary"}, "maxResults": {"10"}}
+	found := struct {
+		Issues []struct {
+			Key    string `json:"key"`
+			Fields struct {
+				Summary string `json:"summary"`
+			} `json:"fields"`
+		} `json:"issues"`
+	}{}
+	if err := jn.request(ctx, http.MethodGet, "/rest/api/2/search?"+query.Encode(), nil, &found); err != nil {
+		return "", err
+	}
+	// the text search of JIRA is fuzzy, so the fingerprint must be in the summary as is
+	for _, issue := range found.Issues {
+		if strings.Contains(issue.Fields.Summary, fingerprint) {
+			return issue.Key, nil
+		}
+	}
+	return "", nil
+}
+
+// request sends payload to the JIRA API and decodes its response into response. A request
+// refused as the session of JIRA Server expired is sent again with a new one.
+func (jn *JIRANotifier) request(ctx goContext.Context, method, path string, payload, response interface{}) error {
+	var body []byte
+	if payload != nil {
+		var err error
+		if body, err = json.Marshal(payload); err != nil {
+			return err
+		}
+	}
+	statusCode, err := jn.send(ctx, method, path, body, response)
+	if statusCode == http.StatusUnauthorized && jn.Username != "" {
+		jn.mutex.Lock()
+		jn.session = nil
+		jn.mutex.Unlock()
+		_, err = jn.send(ctx, method, path, body, response)
+	}
+	return err
+}
+
+func (jn *JIRANotifier) send(ctx goContext.Context, method, path string, body []byte, response interface{}) (int, error) {
+	req, err := http.NewRequest(method, jn.URL+path, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if jn.Username != "" {
+		session, err := jn.serverSession(ctx)
+		if err != nil {
+			return 0, err
+		}
+		req.AddCookie(session)
+	} else {
+		req.SetBasicAuth(jn.Email, jn.Token)
+	}
+	resp, err := jn.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("JIRA returned status code %d for %s %s", resp.StatusCode, method, strings.SplitN(path, "?", 2)[0])
+	}
+	if response == nil {
+		return resp.StatusCode, nil
+	}
+	return resp.StatusCode, json.NewDecoder(resp.Body).Decode(response)
+}
+
+// serverSession returns the session cookie of Username in JIRA Server, opening a session
+// if there is none yet.
+func (jn *JIRANotifier) serverSession(ctx goContext.Context) (*http.Cookie, error) {
+	jn.mutex.Lock()
+	defer jn.mutex.Unlock()
+	if jn.session != nil {
+		return jn.session, nil
+	}
+	body, err := json.Marshal(map[string]string{"username": jn.Username, "password": jn.Password})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, jn.URL+"/rest/auth/1/session", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := jn.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("JIRA returned status code %d opening a session", resp.StatusCode)
+	}
+	login := struct {
+		Session struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"session"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&login); err != nil {
+		return nil, err
+	}
+	jn.session = &http.Cookie{Name: login.Session.Name, Value: login.Session.Value}
+	return jn.session, nil
+}
@@ -0,0 +1,171 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+	goContext "golang.org/x/net/context"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// jiraRequest is a request received by a fake JIRA API.
+type jiraRequest struct {
+	Method        string
+	Path          string
+	JQL           string
+	Authorization string
+	Session       string
+	Issue         map[string]map[string]interface{}
+}
+
+var _ = Describe("JIRANotifier", func() {
+
+	var (
+		server   *httptest.Server
+		requests []jiraRequest
+		// openIssues are the issues the search of the fake JIRA finds, by key
+		openIssues map[string]string
+		// sessions are how many sessions were opened, and expired makes the next request fail as unauthorized
+		sessions int
+		expired  bool
+	)
+
+	finding := types.Finding{
+		Fingerprint:   "3f2a9c",
+		RepositoryURL: "https://github.com/globocom/huskyCI.git",
+		Tool:          "bandit",
+		Finding: types.UnifiedFinding{
+			Tool:        "Bandit",
+			Severity:    "CRITICAL",
+			File:        "app.py",
+			Line:        3,
+			RuleID:      "B602",
+			Description: "subprocess call with shell=True",
+		},
+	}
+
+	BeforeEach(func() {
+		requests, openIssues, sessions, expired = nil, map[string]string{}, 0, false
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/rest/auth/1/session" {
+				sessions++
+				w.Write([]byte(`{"session":{"name":"JSESSIONID","value":"session"}}`))
+				return
+			}
+			request := jiraRequest{Method: r.Method, Path: r.URL.Path, JQL: r.URL.Query().Get("jql"), Authorization: r.Header.Get("Authorization")}
+			if cookie, err := r.Cookie("JSESSIONID"); err == nil {
+				request.Session = cookie.Value
+			}
+			if r.Method == http.MethodPost {
+				Expect(json.NewDecoder(r.Body).Decode(&request.Issue)).To(Succeed())
+			}
+			requests = append(requests, request)
+			if expired {
+				expired = false
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			switch r.URL.Path {
+			case "/rest/api/2/search":
+				issues := []map[string]interface{}{}
+				for key, summary := range openIssues {
+					issues = append(issues, map[string]interface{}{"key": key, "fields": map[string]string{"summary": summary}})
+				}
+				Expect(json.NewEncoder(w).Encode(map[string]interface{}{"issues": issues})).To(Succeed())
+			case "/rest/api/2/issue":
+				w.WriteHeader(http.StatusCreated)
+				w.Write([]byte(`{"id":"10000","key":"SEC-1"}`))
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Context("When it is not configured", func() {
+		It("Should be nil", func() {
+			Expect(notifier.NewJIRANotifier("", "SEC", "", "token", "", "")).To(BeNil())
+			Expect(notifier.NewJIRANotifier(server.URL, "", "", "token", "", "")).To(BeNil())
+			Expect(notifier.NewJIRANotifier(server.URL, "SEC", "", "", "", "")).To(BeNil())
+		})
+	})
+
+	Context("When the finding has no open issue", func() {
+		It("Should create a Bug with the fingerprint in its summary, authenticated with the API token", func() {
+			jn := notifier.NewJIRANotifier(server.URL+"/", "SEC", "bot@example.com", "token", "", "")
+			key, err := jn.CreateIssue(goContext.Background(), finding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(Equal("SEC-1"))
+			Expect(requests).To(HaveLen(2))
+			Expect(requests[0].Path).To(Equal("/rest/api/2/search"))
+			Expect(requests[0].JQL).To(Equal(`project = "SEC" AND summary ~ "3f2a9c" AND statusCategory != Done`))
+			Expect(requests[1].Authorization).To(Equal("Basic Ym90QGV4YW1wbGUuY29tOnRva2Vu"))
+			fields := requests[1].Issue["fields"]
+			Expect(fields["project"]).To(Equal(map[string]interface{}{"key": "SEC"}))
+			Expect(fields["issuetype"]).To(Equal(map[string]interface{}{"name": "Bug"}))
+			Expect(fields["priority"]).To(Equal(map[string]interface{}{"name": "Highest"}))
+			Expect(fields["summary"]).To(Equal("[huskyCI] Bandit B602 in app.py:3 (3f2a9c)"))
+			Expect(fields["description"]).To(ContainSubstring("subprocess call with shell=True"))
+		})
+	})
+
+	Context("When the finding already has an open issue", func() {
+		It("Should return its key instead of creating a duplicate", func() {
+			openIssues["SEC-7"] = notifier.JIRASummary(finding)
+			jn := notifier.NewJIRANotifier(server.URL, "SEC", "bot@example.com", "token", "", "")
+			key, err := jn.CreateIssue(goContext.Background(), finding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(Equal("SEC-7"))
+			Expect(requests).To(HaveLen(1))
+		})
+		It("Should not take an issue the fuzzy search found for another fingerprint", func() {
+			openIssues["SEC-8"] = "[huskyCI] Bandit B602 in app.py:3 (3f2a9d)"
+			jn := notifier.NewJIRANotifier(server.URL, "SEC", "bot@example.com", "token", "", "")
+			key, err := jn.CreateIssue(goContext.Background(), finding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(key).To(Equal("SEC-1"))
+		})
+	})
+
+	Context("When it authenticates to JIRA Server", func() {
+		It("Should reuse its session and open a new one once it expires", func() {
+			jn := notifier.NewJIRANotifier(server.URL, "SEC", "", "", "huskyci", "secret")
+			_, err := jn.CreateIssue(goContext.Background(), finding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sessions).To(Equal(1))
+			expired = true
+			_, err = jn.CreateIssue(goContext.Background(), finding)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(sessions).To(Equal(2))
+			for _, request := range requests {
+				Expect(request.Session).To(Equal("session"))
+				Expect(request.Authorization).To(BeEmpty())
+			}
+		})
+	})
+
+	Context("When JIRA refuses the issue", func() {
+		It("Should return an error", func() {
+			expired = true
+			jn := notifier.NewJIRANotifier(server.URL, "SEC", "bot@example.com", "token", "", "")
+			_, err := jn.CreateIssue(goContext.Background(), finding)
+			Expect(err).To(MatchError(ContainSubstring("status code 401")))
+		})
+	})
+
+	It("Should map the severity of findings to JIRA priorities", func() {
+		Expect(notifier.JIRAPriority("high")).To(Equal("High"))
+		Expect(notifier.JIRAPriority("LOW")).To(Equal("Low"))
+		Expect(notifier.JIRAPriority("")).To(Equal("Medium"))
+	})
+})
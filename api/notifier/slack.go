@@ -0,0 +1,62 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package notifier
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// SlackNotifier sends messages to a Slack channel using an incoming webhook. Messages
+// are sent to Channel if it is set, or to the default channel of the webhook otherwise.
+type SlackNotifier struct {
+	WebhookURL string
+	Channel    string
+	Client     *http.Client
+}
+
+// NewSlackNotifier returns a SlackNotifier for webhookURL, or nil if it is empty
+// so callers can tell that Slack is not configured.
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	if webhookURL == "" {
+		return nil
+	}
+	return &SlackNotifier{
+		WebhookURL: webhookURL,
+		Client:     &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+	}
+}
+
+// Notify posts text to the Slack webhook.
+func (s *SlackNotifier) Notify(text string) error {
+	message := map[string]string{"text": text}
+	if s.Channel != "" {
+		message["channel"] = s.Channel
+	}
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	resp, err := s.Client.Post(s.WebhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("slack webhook returned status code %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// NotifyAsync posts text to the Slack webhook without waiting for it. onError
+// is called if it could not be delivered. Flush waits for these deliveries.
+func (s *SlackNotifier) NotifyAsync(text string, onError func(error)) {
+	reportAsync(func() error { return s.Notify(text) }, onError)
+}
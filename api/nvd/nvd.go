@@ -0,0 +1,93 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nvd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// DefaultBaseURL is the NVD REST API used to fetch a single CVE.
+const DefaultBaseURL = "https://services.nvd.nist.gov/rest/json/cve/1.0"
+
+// DetailURL is the NVD page of a CVE.
+const DetailURL = "https://nvd.nist.gov/vuln/detail/%s"
+
+// ErrCVENotFound is returned when NVD does not know a given CVE.
+var ErrCVENotFound = errors.New("CVE not found in NVD")
+
+// Client fetches CVE data from NVD.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+}
+
+// cveResponse is the part of NVD response used by huskyCI.
+type cveResponse struct {
+	Result struct {
+		CVEItems []struct {
+			Impact struct {
+				BaseMetricV3 struct {
+					CVSSV3 struct {
+						VectorString string  `json:"vectorString"`
+						BaseScore    float64 `json:"baseScore"`
+					} `json:"cvssV3"`
+				} `json:"baseMetricV3"`
+			} `json:"impact"`
+		} `json:"CVE_Items"`
+	} `json:"result"`
+}
+
+// NewClient returns a Client of the public NVD API.
+func NewClient() *Client {
+	return &Client{
+		BaseURL:    DefaultBaseURL,
+		HTTPClient: &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+	}
+}
+
+// FetchCVE returns CVSSv3 base score and vector of a given CVE.
+func (c *Client) FetchCVE(ctx context.Context, cve string) (types.NVDEntry, error) {
+	req, err := http.NewRequest(http.MethodGet, fmt.Sprintf("%s/%s", c.BaseURL, cve), nil)
+	if err != nil {
+		return types.NVDEntry{}, err
+	}
+	resp, err := c.HTTPClient.Do(req.WithContext(ctx))
+	if err != nil {
+		return types.NVDEntry{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return types.NVDEntry{}, ErrCVENotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return types.NVDEntry{}, fmt.Errorf("NVD returned status code %d", resp.StatusCode)
+	}
+
+	nvdResponse := cveResponse{}
+	if err := json.NewDecoder(resp.Body).Decode(&nvdResponse); err != nil {
+		return types.NVDEntry{}, err
+	}
+	if len(nvdResponse.Result.CVEItems) == 0 {
+		return types.NVDEntry{}, ErrCVENotFound
+	}
+
+	cvssV3 := nvdResponse.Result.CVEItems[0].Impact.BaseMetricV3.CVSSV3
+	return types.NVDEntry{
+		CVE:          cve,
+		CVSSv3Score:  cvssV3.BaseScore,
+		CVSSv3Vector: cvssV3.VectorString,
+		URL:          fmt.Sprintf(DetailURL, cve),
+		UpdatedAt:    time.Now(),
+	}, nil
+}
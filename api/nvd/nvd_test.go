@@ -0,0 +1,68 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package nvd_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+
+	"github.com/globocom/huskyCI/api/nvd"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Nvd", func() {
+
+	var server *httptest.Server
+
+	BeforeEach(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/CVE-2019-14379":
+				w.Write([]byte(`{"result": {"CVE_Items": [{"impact": {"baseMetricV3": {"cvssV3": {"vectorString": "CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H", "baseScore": 9.8}}}}]}}`))
+			case "/CVE-2019-0000":
+				w.WriteHeader(http.StatusNotFound)
+			default:
+				w.WriteHeader(http.StatusInternalServerError)
+			}
+		}))
+	})
+
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("FetchCVE", func() {
+		Context("When NVD knows the CVE", func() {
+			It("Should return its CVSSv3 score, vector and URL", func() {
+				client := nvd.NewClient()
+				client.BaseURL = server.URL
+				entry, err := client.FetchCVE(context.Background(), "CVE-2019-14379")
+				Expect(err).To(BeNil())
+				Expect(entry.CVSSv3Score).To(Equal(9.8))
+				Expect(entry.CVSSv3Vector).To(Equal("CVSS:3.1/AV:N/AC:L/PR:N/UI:N/S:U/C:H/I:H/A:H"))
+				Expect(entry.URL).To(Equal("https://nvd.nist.gov/vuln/detail/CVE-2019-14379"))
+			})
+		})
+		Context("When NVD does not know the CVE", func() {
+			It("Should return ErrCVENotFound", func() {
+				client := nvd.NewClient()
+				client.BaseURL = server.URL
+				_, err := client.FetchCVE(context.Background(), "CVE-2019-0000")
+				Expect(err).To(Equal(nvd.ErrCVENotFound))
+			})
+		})
+		Context("When NVD returns an error", func() {
+			It("Should return an error", func() {
+				client := nvd.NewClient()
+				client.BaseURL = server.URL
+				_, err := client.FetchCVE(context.Background(), "CVE-2019-1111")
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
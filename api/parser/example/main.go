@@ -0,0 +1,71 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Command example is a huskyCI parser plugin of a securityTool named "examplescanner"
+// that prints its issues as {"issues": [{"file", "line", "severity", "rule", "message"}]}.
+//
+// Plugins must be built with the same Go version and dependencies as huskyCI API and,
+// as they need cgo, huskyCI API must be built with CGO_ENABLED=1 as well:
+//
+//	go build -buildmode=plugin -o $HUSKYCI_PLUGIN_DIR/examplescanner.so ./api/parser/example
+//
+// Its securityTest is inserted in the securityTest collection as any other one, such as:
+//
+//	{"name": "examplescanner", "image": "example/scanner", "imageTag": "1.0.0",
+//	 "cmd": "%GIT_CLONE% && examplescanner --json code", "type": "Generic", "default": true,
+//	 "timeOutSeconds": 360}
+package main
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/parser"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+type exampleOutput struct {
+	Issues []struct {
+		File     string `json:"file"`
+		Line     int    `json:"line"`
+		Severity string `json:"severity"`
+		Rule     string `json:"rule"`
+		Message  string `json:"message"`
+	} `json:"issues"`
+}
+
+type exampleParser struct{}
+
+func (exampleParser) Name() string {
+	return "examplescanner"
+}
+
+func (exampleParser) Parse(cOutput string) ([]types.HuskyCIVulnerability, error) {
+	output := exampleOutput{}
+	if err := json.Unmarshal([]byte(cOutput), &output); err != nil {
+		return nil, err
+	}
+	vulns := []types.HuskyCIVulnerability{}
+	for _, issue := range output.Issues {
+		vulns = append(vulns, types.HuskyCIVulnerability{
+			SecurityTool: "ExampleScanner",
+			Severity:     strings.ToUpper(issue.Severity),
+			RuleID:       issue.Rule,
+			Details:      issue.Message,
+			File:         issue.File,
+			Line:         strconv.Itoa(issue.Line),
+		})
+	}
+	return vulns, nil
+}
+
+func (exampleParser) ToolImage() parser.Image {
+	return parser.Image{Name: "example/scanner", Tag: "1.0.0"}
+}
+
+// Parser is looked up by huskyCI when the plugin is loaded.
+var Parser parser.Parser = exampleParser{}
+
+func main() {}
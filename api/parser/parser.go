@@ -0,0 +1,106 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package parser lets huskyCI parse the output of securityTools it does not ship
+// with, so organizations can scan with their internal tools without forking it.
+//
+// A Parser turns the output of the cmd of a securityTest into findings. Every
+// securityTool of huskyCI is a Parser registered in Registry when the securitytest
+// package is initialized, and more of them are loaded from Go plugins at startup.
+//
+// The contract of a Parser is:
+//   - Name is the name of the securityTest it parses, as stored in the securityTest
+//     collection, and is unique among every Parser.
+//   - Parse receives the whole output of the securityTest cmd, without the
+//     HUSKYCI_TOOL_VERSION line, and returns its findings. It must not keep
+//     cOutput, as parsers are called concurrently by every running analysis.
+//     An error marks the container as one that could not be parsed, not the
+//     whole analysis as an error.
+//   - Each finding has a Severity of HIGH, MEDIUM, LOW or NOSEC, the last one
+//     for findings suppressed in the code. Findings of other severities are dropped.
+//   - ToolImage is the image that runs the securityTool, used when its securityTest
+//     does not set one. Built-in parsers return an empty Image, as config.yaml sets theirs.
+//
+// The securityTest of a plugin must still be inserted in the securityTest collection,
+// as it holds the cmd, language and type that decide when it runs. See the example
+// directory for a plugin and how it is built.
+package parser
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// Image is a docker image and its tag.
+type Image struct {
+	Name string
+	Tag  string
+}
+
+// Parser parses the output of a securityTool into findings.
+type Parser interface {
+	Name() string
+	Parse(cOutput string) ([]types.HuskyCIVulnerability, error)
+	ToolImage() Image
+}
+
+var (
+	// ErrParserWithoutName is returned when a Parser with an empty Name is registered.
+	ErrParserWithoutName = errors.New("parser must have a name")
+	// ErrParserAlreadyRegistered is returned when a Parser of the same Name was registered before.
+	ErrParserAlreadyRegistered = errors.New("a parser with this name is already registered")
+)
+
+// PluginRegistry holds a Parser for each securityTest by its name.
+type PluginRegistry struct {
+	mutex   sync.RWMutex
+	parsers map[string]Parser
+}
+
+// Registry holds the built-in parsers and the ones loaded from plugins.
+var Registry = NewPluginRegistry()
+
+// NewPluginRegistry returns an empty PluginRegistry.
+func NewPluginRegistry() *PluginRegistry {
+	return &PluginRegistry{parsers: map[string]Parser{}}
+}
+
+// Register adds parser to the registry. A Parser can not replace one of the same name,
+// so a plugin can not take over a built-in securityTool.
+func (registry *PluginRegistry) Register(parser Parser) error {
+	name := parser.Name()
+	if name == "" {
+		return ErrParserWithoutName
+	}
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	if _, ok := registry.parsers[name]; ok {
+		return ErrParserAlreadyRegistered
+	}
+	registry.parsers[name] = parser
+	return nil
+}
+
+// Get returns the Parser of the securityTest name, if any was registered.
+func (registry *PluginRegistry) Get(name string) (Parser, bool) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	parser, ok := registry.parsers[name]
+	return parser, ok
+}
+
+// Names returns the name of every registered Parser, sorted.
+func (registry *PluginRegistry) Names() []string {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+	names := []string{}
+	for name := range registry.parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,91 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/globocom/huskyCI/api/parser"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type fakeParser struct {
+	name string
+}
+
+func (fake fakeParser) Name() string {
+	return fake.name
+}
+
+func (fake fakeParser) Parse(cOutput string) ([]types.HuskyCIVulnerability, error) {
+	return nil, nil
+}
+
+func (fake fakeParser) ToolImage() parser.Image {
+	return parser.Image{Name: "huskyci/" + fake.name, Tag: "latest"}
+}
+
+var _ = Describe("Parser", func() {
+
+	Describe("PluginRegistry", func() {
+		Context("When a parser is registered", func() {
+			It("Should get it by its name", func() {
+				registry := parser.NewPluginRegistry()
+				Expect(registry.Register(fakeParser{name: "internalscanner"})).To(Succeed())
+				registered, ok := registry.Get("internalscanner")
+				Expect(ok).To(BeTrue())
+				Expect(registered.ToolImage()).To(Equal(parser.Image{Name: "huskyci/internalscanner", Tag: "latest"}))
+				Expect(registry.Names()).To(Equal([]string{"internalscanner"}))
+			})
+		})
+		Context("When no parser of a name is registered", func() {
+			It("Should not get any", func() {
+				_, ok := parser.NewPluginRegistry().Get("internalscanner")
+				Expect(ok).To(BeFalse())
+			})
+		})
+		Context("When a parser of the same name is registered", func() {
+			It("Should not replace the first one", func() {
+				registry := parser.NewPluginRegistry()
+				Expect(registry.Register(fakeParser{name: "bandit"})).To(Succeed())
+				Expect(registry.Register(fakeParser{name: "bandit"})).To(Equal(parser.ErrParserAlreadyRegistered))
+			})
+		})
+		Context("When a parser has no name", func() {
+			It("Should return an error", func() {
+				Expect(parser.NewPluginRegistry().Register(fakeParser{})).To(Equal(parser.ErrParserWithoutName))
+			})
+		})
+	})
+
+	Describe("LoadPlugins", func() {
+		Context("When the directory has no plugins", func() {
+			It("Should load none", func() {
+				dir, err := ioutil.TempDir("", "huskyci-plugins")
+				Expect(err).To(BeNil())
+				defer os.RemoveAll(dir)
+				Expect(ioutil.WriteFile(filepath.Join(dir, "README"), []byte("not a plugin"), 0644)).To(Succeed())
+				loaded, err := parser.NewPluginRegistry().LoadPlugins(dir)
+				Expect(err).To(BeNil())
+				Expect(loaded).To(BeEmpty())
+			})
+		})
+		Context("When a .so file is not a plugin", func() {
+			It("Should return an error", func() {
+				dir, err := ioutil.TempDir("", "huskyci-plugins")
+				Expect(err).To(BeNil())
+				defer os.RemoveAll(dir)
+				Expect(ioutil.WriteFile(filepath.Join(dir, "scanner.so"), []byte("not a plugin"), 0644)).To(Succeed())
+				_, err = parser.NewPluginRegistry().LoadPlugins(dir)
+				Expect(err).ToNot(BeNil())
+			})
+		})
+	})
+})
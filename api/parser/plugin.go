@@ -0,0 +1,57 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package parser
+
+import (
+	"fmt"
+	"path/filepath"
+	"plugin"
+)
+
+// PluginSymbol is the exported variable of a plugin that holds its Parser.
+const PluginSymbol = "Parser"
+
+// LoadPlugins registers the Parser of every .so file in dir and returns the names of
+// the ones loaded. Plugins are loaded until one of them fails, whose error is returned.
+func (registry *PluginRegistry) LoadPlugins(dir string) ([]string, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.so"))
+	if err != nil {
+		return nil, err
+	}
+	loaded := []string{}
+	for _, path := range paths {
+		parser, err := openPlugin(path)
+		if err != nil {
+			return loaded, err
+		}
+		if err := registry.Register(parser); err != nil {
+			return loaded, fmt.Errorf("%s: %v", path, err)
+		}
+		loaded = append(loaded, parser.Name())
+	}
+	return loaded, nil
+}
+
+// openPlugin returns the Parser of the plugin at path, which is declared either as
+// a Parser variable or as a variable of a type that implements Parser.
+func openPlugin(path string) (Parser, error) {
+	loadedPlugin, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	symbol, err := loadedPlugin.Lookup(PluginSymbol)
+	if err != nil {
+		return nil, err
+	}
+	switch parser := symbol.(type) {
+	case *Parser:
+		if *parser != nil {
+			return *parser, nil
+		}
+	case Parser:
+		return parser, nil
+	}
+	return nil, fmt.Errorf("%s: %s does not implement parser.Parser", path, PluginSymbol)
+}
@@ -0,0 +1,70 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"fmt"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// TokenIdentifier returns the access token a client sent, such as token.THandler.
+type TokenIdentifier interface {
+	Identify(rcvToken string) (types.DBToken, error)
+}
+
+// DBStore counts the analyses requested by each client in the database, so every replica of
+// the API enforces the same limits, whichever of its REST or gRPC APIs they are requested to.
+type DBStore struct{}
+
+// Increment adds requests of key to its window in the database.
+func (DBStore) Increment(key string, requests int, windowStart, expiresAt time.Time) (int, error) {
+	return apiContext.APIConfiguration.DBInstance.IncrementDBRateLimit(key, requests, windowStart, expiresAt)
+}
+
+// AnalysisClient returns the key the analyses requested with rcvToken from addr are counted
+// with and their limits: the ones of the access token, if it is a valid one, or the ones of
+// addr otherwise.
+func AnalysisClient(identifier TokenIdentifier, rcvToken, addr string) (string, Limits) {
+	limits := Limits{
+		Burst:     apiContext.APIConfiguration.RateLimitBurst,
+		Sustained: apiContext.APIConfiguration.RateLimitSustained,
+	}
+	if rcvToken != "" {
+		if accessToken, err := identifier.Identify(rcvToken); err == nil {
+			override := Limits{Burst: accessToken.RateLimitBurst, Sustained: accessToken.RateLimitSustained}
+			return fmt.Sprintf("token:%s", accessToken.UUID), limits.Override(override)
+		}
+	}
+	return fmt.Sprintf("ip:%s", addr), limits
+}
+
+// AllowAnalysis counts an analysis requested with rcvToken from addr in store and returns the
+// resulting State. It returns false if no limit is enforced or the analysis could not be
+// counted, so the database being down does not stop analyses, and the request is let through.
+func AllowAnalysis(store Store, identifier TokenIdentifier, rcvToken, addr string, now time.Time) (State, bool) {
+	return AllowAnalyses(store, identifier, rcvToken, addr, 1, now)
+}
+
+// AllowAnalyses counts analyses requested at once, such as the ones of a bulk analysis, as
+// AllowAnalysis counts a single one.
+func AllowAnalyses(store Store, identifier TokenIdentifier, rcvToken, addr string, analyses int, now time.Time) (State, bool) {
+	key, limits := AnalysisClient(identifier, rcvToken, addr)
+	if !limits.Enabled() {
+		return State{}, false
+	}
+	state, err := AllowN(store, key, limits, analyses, now)
+	if err != nil {
+		log.Error("RateLimitAnalyses", "RATELIMIT", 1063, key, err)
+		return State{}, false
+	}
+	if state.Exceeded {
+		log.Warning("RateLimitAnalyses", "RATELIMIT", 134, key)
+	}
+	return state, true
+}
@@ -0,0 +1,105 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit
+
+import (
+	"time"
+)
+
+// Windows the burst and sustained limits are counted in.
+const (
+	BurstWindow     = time.Minute
+	SustainedWindow = time.Hour
+)
+
+// Limits are how many requests a client can make in a BurstWindow and in a SustainedWindow.
+// A limit of 0 is not enforced.
+type Limits struct {
+	Burst     int
+	Sustained int
+}
+
+// Enabled returns whether any of limits is enforced.
+func (limits Limits) Enabled() bool {
+	return limits.Burst > 0 || limits.Sustained > 0
+}
+
+// Override returns limits with each limit of override that is set replacing its own.
+func (limits Limits) Override(override Limits) Limits {
+	if override.Burst > 0 {
+		limits.Burst = override.Burst
+	}
+	if override.Sustained > 0 {
+		limits.Sustained = override.Sustained
+	}
+	return limits
+}
+
+// Store counts the requests of each key in a window. Increment must be atomic, so every
+// replica of the API sharing a Store sees the same counts.
+type Store interface {
+	// Increment adds requests of key to the window starting at windowStart and returns how
+	// many requests of key it has. The window can be forgotten after expiresAt.
+	Increment(key string, requests int, windowStart, expiresAt time.Time) (int, error)
+}
+
+// State is the limit closest to being exceeded by a client after its request: how many
+// requests it allows, how many are left and when its window resets. If Exceeded is set, the
+// request must be refused and the client can try again after RetryAfter.
+type State struct {
+	Limit      int
+	Remaining  int
+	Reset      time.Time
+	Exceeded   bool
+	RetryAfter time.Duration
+}
+
+// Allow counts a request of key made at now against limits and returns the resulting State.
+func Allow(store Store, key string, limits Limits, now time.Time) (State, error) {
+	return AllowN(store, key, limits, 1, now)
+}
+
+// AllowN counts requests of key made at once at now against limits, such as the analyses of
+// a bulk analysis, and returns the resulting State.
+func AllowN(store Store, key string, limits Limits, requests int, now time.Time) (State, error) {
+	state := State{Remaining: -1}
+	windows := []struct {
+		limit  int
+		length time.Duration
+	}{{limits.Burst, BurstWindow}, {limits.Sustained, SustainedWindow}}
+	for _, window := range windows {
+		if window.limit <= 0 {
+			continue
+		}
+		windowStart := now.Truncate(window.length)
+		reset := windowStart.Add(window.length)
+		count, err := store.Increment(windowKey(key, window.length), requests, windowStart, reset)
+		if err != nil {
+			return State{}, err
+		}
+		remaining := window.limit - count
+		if remaining < 0 {
+			remaining = 0
+		}
+		exceeded := count > window.limit
+		// an exceeded limit always wins, the longest one if both are exceeded
+		if (exceeded && (!state.Exceeded || reset.After(state.Reset))) ||
+			(!exceeded && !state.Exceeded && (state.Remaining < 0 || remaining < state.Remaining)) {
+			state = State{Limit: window.limit, Remaining: remaining, Reset: reset, Exceeded: exceeded}
+		}
+	}
+	if state.Exceeded {
+		state.RetryAfter = state.Reset.Sub(now)
+	}
+	if state.Remaining < 0 {
+		state.Remaining = 0
+	}
+	return state, nil
+}
+
+// windowKey returns the key of the window of length of key.
+func windowKey(key string, length time.Duration) string {
+	return key + ":" + length.String()
+}
@@ -0,0 +1,110 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package ratelimit_test
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/globocom/huskyCI/api/ratelimit"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// fakeStore counts requests in memory.
+type fakeStore struct {
+	counts map[string]int
+	err    error
+}
+
+func (fS *fakeStore) Increment(key string, requests int, windowStart, expiresAt time.Time) (int, error) {
+	if fS.err != nil {
+		return 0, fS.err
+	}
+	windowKey := fmt.Sprintf("%s@%d", key, windowStart.Unix())
+	fS.counts[windowKey] += requests
+	return fS.counts[windowKey], nil
+}
+
+var _ = Describe("Allow", func() {
+
+	var store *fakeStore
+	now := time.Date(2020, 3, 10, 12, 30, 15, 0, time.UTC)
+
+	BeforeEach(func() {
+		store = &fakeStore{counts: map[string]int{}}
+	})
+
+	Context("When the client is within its limits", func() {
+		It("Should return the limit closest to being exceeded", func() {
+			state, err := ratelimit.Allow(store, "token:1", ratelimit.Limits{Burst: 5, Sustained: 3}, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state).To(Equal(ratelimit.State{Limit: 3, Remaining: 2, Reset: time.Date(2020, 3, 10, 13, 0, 0, 0, time.UTC)}))
+		})
+	})
+
+	Context("When the client exceeds its burst limit", func() {
+		It("Should refuse the request until the next minute", func() {
+			limits := ratelimit.Limits{Burst: 2, Sustained: 100}
+			for i := 0; i < 2; i++ {
+				state, _ := ratelimit.Allow(store, "ip:10.0.0.1", limits, now)
+				Expect(state.Exceeded).To(BeFalse())
+			}
+			state, err := ratelimit.Allow(store, "ip:10.0.0.1", limits, now)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(state.Exceeded).To(BeTrue())
+			Expect(state.Remaining).To(Equal(0))
+			Expect(state.RetryAfter).To(Equal(45 * time.Second))
+
+			state, _ = ratelimit.Allow(store, "ip:10.0.0.1", limits, now.Add(time.Minute))
+			Expect(state.Exceeded).To(BeFalse())
+		})
+		It("Should not limit other clients", func() {
+			limits := ratelimit.Limits{Burst: 1}
+			ratelimit.Allow(store, "ip:10.0.0.1", limits, now)
+			state, _ := ratelimit.Allow(store, "ip:10.0.0.2", limits, now)
+			Expect(state.Exceeded).To(BeFalse())
+		})
+	})
+
+	Context("When the client exceeds its sustained limit", func() {
+		It("Should refuse the request until the next hour", func() {
+			limits := ratelimit.Limits{Burst: 10, Sustained: 1}
+			ratelimit.Allow(store, "token:1", limits, now)
+			state, _ := ratelimit.Allow(store, "token:1", limits, now)
+			Expect(state.Exceeded).To(BeTrue())
+			Expect(state.Limit).To(Equal(1))
+			Expect(state.RetryAfter).To(Equal(29*time.Minute + 45*time.Second))
+		})
+	})
+
+	Context("When the client requests many at once", func() {
+		It("Should count each one of them", func() {
+			limits := ratelimit.Limits{Burst: 5}
+			state, _ := ratelimit.AllowN(store, "token:1", limits, 4, now)
+			Expect(state.Exceeded).To(BeFalse())
+			Expect(state.Remaining).To(Equal(1))
+			state, _ = ratelimit.AllowN(store, "token:1", limits, 2, now)
+			Expect(state.Exceeded).To(BeTrue())
+		})
+	})
+
+	Context("When the store fails", func() {
+		It("Should return its error", func() {
+			store.err = errors.New("connection refused")
+			_, err := ratelimit.Allow(store, "token:1", ratelimit.Limits{Burst: 1}, now)
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
+
+var _ = Describe("Limits", func() {
+	It("Should only override the limits that are set", func() {
+		limits := ratelimit.Limits{Burst: 10, Sustained: 100}
+		Expect(limits.Override(ratelimit.Limits{Sustained: 1000})).To(Equal(ratelimit.Limits{Burst: 10, Sustained: 1000}))
+	})
+})
@@ -5,18 +5,23 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/globocom/huskyCI/api/analysis"
 	"github.com/globocom/huskyCI/api/auth"
 	apiContext "github.com/globocom/huskyCI/api/context"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/timeline"
 	"github.com/globocom/huskyCI/api/token"
 	"github.com/globocom/huskyCI/api/types"
 	"github.com/globocom/huskyCI/api/util"
 	"github.com/labstack/echo"
-	mgo "gopkg.in/mgo.v2"
 )
 
 var (
@@ -36,42 +41,209 @@ func init() {
 }
 
 const logActionReceiveRequest = "ReceiveRequest"
+const logActionReceiveTarballRequest = "ReceiveTarballRequest"
 const logActionGetAnalysis = "GetAnalysis"
+const logActionVerifyAnalysis = "VerifyAnalysis"
+const logActionGetAnalysisFindings = "GetAnalysisFindings"
+const logActionGetFindings = "GetFindings"
+const logActionDiffAnalyses = "DiffAnalyses"
+const logActionListAnalyses = "ListAnalyses"
+const logActionExportAnalysis = "ExportAnalysis"
+const logActionExportFindings = "ExportFindings"
 const logInfoAnalysis = "ANALYSIS"
 
-// GetAnalysis returns the status of a given analysis given a RID.
+const defaultFindingsPageSize = 50
+const maxFindingsPageSize = 500
+const defaultAnalysesPerPage = 20
+const maxAnalysesPerPage = 100
+
+const defaultExportDays = 30
+
+const maxTarballSize = 100 << 20
+
+// GetAnalysis returns the status of a given analysis given a RID, along with the status of
+// each of its securityTests and its normalized findings. The raw output of its securityTests
+// is only returned if the includeRawOutput query string param is true, restored from the
+// archive if the analysis was archived.
 func GetAnalysis(c echo.Context) error {
 
 	RID := c.Param("id")
-	attemptToken := c.Request().Header.Get("Husky-Token")
+	attemptToken := requestToken(c)
 	if err := util.CheckMaliciousRID(RID, c); err != nil {
 		return err
 	}
-	analysisQuery := map[string]interface{}{"RID": RID}
-	analysisResult, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysis(analysisQuery)
+	includeRawOutput := false
+	if rawIncludeRawOutput := c.QueryParam("includeRawOutput"); rawIncludeRawOutput != "" {
+		parsed, err := strconv.ParseBool(rawIncludeRawOutput)
+		if err != nil {
+			reply := map[string]interface{}{"success": false, "error": "invalid includeRawOutput"}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		includeRawOutput = parsed
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
 	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
 		log.Error(logActionGetAnalysis, logInfoAnalysis, 1027, RID)
 		reply := map[string]interface{}{"success": false, "error": "permission denied"}
 		return c.JSON(http.StatusUnauthorized, reply)
 	}
 	if err != nil {
-		if err == mgo.ErrNotFound || err.Error() == "No data found" {
-			log.Warning(logActionGetAnalysis, logInfoAnalysis, 106, RID)
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	if includeRawOutput && analysisResult.Archived {
+		// the raw output of archived analyses is no longer in the database
+		if analysisResult, err = analysis.RetrieveArchivedAnalysis(analysisResult); err != nil {
+			reply := map[string]interface{}{"success": false, "error": "internal error"}
+			return c.JSON(http.StatusInternalServerError, reply)
+		}
+	}
+	return c.JSON(http.StatusOK, analysis.BuildReport(analysisResult, includeRawOutput))
+}
+
+// GetAnalysisTimeline returns the events of each container of a given analysis, the oldest
+// first, such as when it was created or when its output was parsed, along with their errors.
+func GetAnalysisTimeline(c echo.Context) error {
+
+	RID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error("GetAnalysisTimeline", logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	events, err := timeline.Events(RID)
+	if err != nil {
+		log.Error("GetAnalysisTimeline", logInfoAnalysis, 1020, err)
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, events)
+}
+
+// RetryAnalysis runs a given finished analysis again, such as one that failed due to an
+// infrastructure error, with the same parameters. The RID of the new analysis is returned
+// so it can be polled.
+func RetryAnalysis(c echo.Context) error {
+
+	RID := c.Param("id")
+	newRID := c.Response().Header().Get(echo.HeaderXRequestID)
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error("RetryAnalysis", logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err == nil {
+		err = analysis.RetryAnalysis(RID, newRID)
+	}
+	if err != nil {
+		switch err {
+		case analysis.ErrAnalysisNotFound:
 			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
 			return c.JSON(http.StatusNotFound, reply)
+		case analysis.ErrAnalysisRunning, analysis.ErrAnalysisAlreadyRunning:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusConflict, reply)
+		case analysis.ErrRetryTarball:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		case analysis.ErrShuttingDown:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusServiceUnavailable, reply)
 		}
-		log.Error(logActionGetAnalysis, logInfoAnalysis, 1020, err)
 		reply := map[string]interface{}{"success": false, "error": "internal error"}
 		return c.JSON(http.StatusInternalServerError, reply)
 	}
-	return c.JSON(http.StatusOK, analysisResult)
+	reply := map[string]interface{}{"success": true, "error": "", "RID": newRID, "retryOf": RID}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// DeleteAnalysis removes a given finished analysis, logging which admin removed it. Only
+// admin can remove it.
+func DeleteAnalysis(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can delete analyses"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	RID := c.Param("id")
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	username, _, _ := c.Request().BasicAuth()
+	deletedBy := fmt.Sprintf("%s from %s", username, c.RealIP())
+	if err := analysis.DeleteAnalysis(RID, deletedBy); err != nil {
+		switch err {
+		case analysis.ErrAnalysisNotFound:
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		case analysis.ErrAnalysisRunning:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusConflict, reply)
+		}
+		log.Error("DeleteAnalysis", logInfoAnalysis, 2045, err)
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "error": ""})
+}
+
+// VerifyAnalysis returns whether a given finished analysis still matches the integrity
+// signed when it finished, so it was not changed in the database since then.
+func VerifyAnalysis(c echo.Context) error {
+
+	RID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error(logActionVerifyAnalysis, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	if apiContext.APIConfiguration.IntegrityKey == "" {
+		reply := map[string]interface{}{"success": false, "error": analysis.ErrIntegrityNotConfigured.Error()}
+		return c.JSON(http.StatusServiceUnavailable, reply)
+	}
+	reply := map[string]interface{}{"valid": analysis.VerifyIntegrity(analysisResult), "verifiedAt": time.Now()}
+	return c.JSON(http.StatusOK, reply)
 }
 
 // ReceiveRequest receives the request and performs several checks before starting a new analysis.
 func ReceiveRequest(c echo.Context) error {
 
 	RID := c.Response().Header().Get(echo.HeaderXRequestID)
-	attemptToken := c.Request().Header.Get("Husky-Token")
+	attemptToken := requestToken(c)
 
 	// step-00: is this a valid JSON?
 	repository := types.Repository{}
@@ -92,52 +264,463 @@ func ReceiveRequest(c echo.Context) error {
 		return err
 	}
 	repository.URL = sanitizedRepoURL
+	if err := util.CheckValidExtraEnv(repository.ExtraEnv, apiContext.APIConfiguration.AllowedEnvKeys); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1049, err)
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if repository.CallbackURL != "" {
+		if err := util.CheckValidCallbackURL(repository.CallbackURL, apiContext.APIConfiguration.CallbackAllowlist); err != nil {
+			log.Error(logActionReceiveRequest, logInfoAnalysis, 1060, err)
+			reply := map[string]interface{}{"success": false, "error": "invalid callback URL"}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+	}
 
-	// step-02: is this repository already in MongoDB?
-	repositoryQuery := map[string]interface{}{"repositoryURL": repository.URL}
-	_, err = apiContext.APIConfiguration.DBInstance.FindOneDBRepository(repositoryQuery)
-	if err != nil {
-		if err == mgo.ErrNotFound || err.Error() == "No data found" {
-			// step-02-o1: repository not found! insert it into MongoDB
-			repository.CreatedAt = time.Now()
-			err = apiContext.APIConfiguration.DBInstance.InsertDBRepository(repository)
-			if err != nil {
-				log.Error(logActionReceiveRequest, logInfoAnalysis, 1010, err)
-				reply := map[string]interface{}{"success": false, "error": "internal error"}
-				return c.JSON(http.StatusInternalServerError, reply)
-			}
-		} else {
-			// step-02-o2: another error searching for repositoryQuery
-			log.Error(logActionReceiveRequest, logInfoAnalysis, 1013, err)
-			reply := map[string]interface{}{"success": false, "error": "internal error"}
-			return c.JSON(http.StatusInternalServerError, reply)
+	// step-02: register the repository and start the analysis in background
+	if err := analysis.NewAnalysis(RID, repository); err != nil {
+		if err == analysis.ErrAnalysisAlreadyRunning {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusConflict, reply)
 		}
-	} else { // err == nil
-		// step-03: repository found! does it have a running status analysis?
-		analysisQuery := map[string]interface{}{"repositoryURL": repository.URL, "repositoryBranch": repository.Branch}
-		analysisResult, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysis(analysisQuery)
-		if err != nil {
-			if err == mgo.ErrNotFound || err.Error() == "No data found" {
-				// nice! we can start this analysis!
-			} else {
-				// step-03-err: another error searching for analysisQuery
-				log.Error(logActionReceiveRequest, logInfoAnalysis, 1009, err)
-				reply := map[string]interface{}{"success": false, "error": "internal error"}
-				return c.JSON(http.StatusInternalServerError, reply)
-			}
-		} else { // err == nil
-			// step 03-a: Ops, this analysis is already running!
-			if analysisResult.Status == "running" {
-				log.Warning(logActionReceiveRequest, logInfoAnalysis, 104, analysisResult.URL)
-				reply := map[string]interface{}{"success": false, "error": "an analysis is already in place for this URL and branch"}
-				return c.JSON(http.StatusConflict, reply)
-			}
-		}
-	}
-
-	// step 04: lets start this analysis!
-	log.Info(logActionReceiveRequest, logInfoAnalysis, 16, repository.Branch, repository.URL)
-	go analysis.StartAnalysis(RID, repository)
+		if err == analysis.ErrShuttingDown {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusServiceUnavailable, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
 	reply := map[string]interface{}{"success": true, "error": ""}
 	return c.JSON(http.StatusCreated, reply)
 }
+
+// ReceiveTarballRequest receives a new analysis of a repository whose code is sent as a
+// tarball of its working tree, in the tarball field of a multipart form, instead of being cloned.
+// The repositoryURL and repositoryBranch fields still identify the repository.
+func ReceiveTarballRequest(c echo.Context) error {
+
+	RID := c.Response().Header().Get(echo.HeaderXRequestID)
+	attemptToken := requestToken(c)
+
+	repository := types.Repository{
+		URL:    c.FormValue("repositoryURL"),
+		Branch: c.FormValue("repositoryBranch"),
+	}
+	if !tokenValidator.HasAuthorization(attemptToken, repository.URL) {
+		log.Error(logActionReceiveTarballRequest, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	sanitizedRepoURL, err := util.CheckValidInput(repository, c)
+	if err != nil {
+		return err
+	}
+	repository.URL = sanitizedRepoURL
+
+	tarball, err := readTarball(c)
+	if err != nil {
+		log.Error(logActionReceiveTarballRequest, logInfoAnalysis, 1045, err)
+		if err == errTarballTooLarge {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusRequestEntityTooLarge, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "invalid tarball"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	repository.Source = types.Source{Tarball: tarball}
+
+	if err := analysis.NewAnalysis(RID, repository); err != nil {
+		if err == analysis.ErrAnalysisAlreadyRunning {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusConflict, reply)
+		}
+		if err == analysis.ErrShuttingDown {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusServiceUnavailable, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+var errTarballTooLarge = fmt.Errorf("tarball is larger than %d MB", maxTarballSize>>20)
+
+// readTarball reads the tarball field of a multipart form, as it is kept in memory
+// while its analysis runs.
+func readTarball(c echo.Context) ([]byte, error) {
+	fileHeader, err := c.FormFile("tarball")
+	if err != nil {
+		return nil, err
+	}
+	if fileHeader.Size > maxTarballSize {
+		return nil, errTarballTooLarge
+	}
+	file, err := fileHeader.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	tarball, err := ioutil.ReadAll(io.LimitReader(file, maxTarballSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(tarball) > maxTarballSize {
+		return nil, errTarballTooLarge
+	}
+	if len(tarball) == 0 {
+		return nil, errors.New("empty tarball")
+	}
+	return tarball, nil
+}
+
+// GetAnalysisFindings returns a page of the unified findings of a given analysis.
+// Findings may be filtered by severity, tool, file and compliance query string params.
+func GetAnalysisFindings(c echo.Context) error {
+
+	RID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	page, pageSize, err := getPagination(c)
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error(logActionGetAnalysisFindings, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	findings := analysis.UnifyFindings(analysisResult.HuskyCIResults)
+	findings = analysis.FilterFindings(findings, c.QueryParam("severity"), c.QueryParam("tool"), c.QueryParam("file"))
+	findings, err = analysis.FilterByCompliance(findings, c.QueryParam("compliance"))
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	findings = analysis.SortByConfidence(findings)
+
+	total := len(findings)
+	start, end := pageBounds(page, pageSize, total)
+
+	reply := map[string]interface{}{
+		"findings": findings[start:end],
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// DiffAnalyses returns the findings the analysis of id introduced and fixed compared
+// to the analysis of the base query string param, such as the one of the target branch of
+// a pull request. Both analyses must be of the same repository.
+func DiffAnalyses(c echo.Context) error {
+
+	RID := c.Param("id")
+	baseRID := c.QueryParam("base")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	if err := util.CheckMaliciousRID(baseRID, c); err != nil {
+		return err
+	}
+	headAnalysis, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, headAnalysis.URL) {
+		log.Error(logActionDiffAnalyses, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	// the base analysis must be of the same repository, which the token is authorized to
+	newFindings, fixedFindings, err := analysis.DiffAnalyses(baseRID, RID)
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "base analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		if err == analysis.ErrDiffRepositoryMismatch {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	reply := map[string]interface{}{
+		"base":       baseRID,
+		"head":       RID,
+		"new":        newFindings,
+		"fixed":      fixedFindings,
+		"newSummary": analysis.Summarize(newFindings),
+	}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// ListAnalyses returns a page of the analyses of the repository of the repositoryURL query
+// string param, the most recent first, optionally of a branch and with a result. Each one is
+// summarized by its status, result, commit, timings and how many findings it has by severity.
+func ListAnalyses(c echo.Context) error {
+
+	repositoryURL, err := util.CheckMaliciousRepoURL(c.QueryParam("repositoryURL"))
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid repositoryURL"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	repositoryBranch := c.QueryParam("branch")
+	if repositoryBranch != "" {
+		if err := util.CheckMaliciousRepoBranch(repositoryBranch, c); err != nil {
+			return err
+		}
+	}
+	page, perPage := 1, defaultAnalysesPerPage
+	if rawPage := c.QueryParam("page"); rawPage != "" {
+		parsedPage, err := strconv.Atoi(rawPage)
+		if err != nil || parsedPage < 1 {
+			reply := map[string]interface{}{"success": false, "error": "invalid page"}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		page = parsedPage
+	}
+	if rawPerPage := c.QueryParam("perPage"); rawPerPage != "" {
+		parsedPerPage, err := strconv.Atoi(rawPerPage)
+		if err != nil || parsedPerPage < 1 || parsedPerPage > maxAnalysesPerPage {
+			reply := map[string]interface{}{"success": false, "error": "invalid perPage"}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		perPage = parsedPerPage
+	}
+	attemptToken := requestToken(c)
+	if !tokenValidator.HasAuthorization(attemptToken, repositoryURL) {
+		log.Error(logActionListAnalyses, logInfoAnalysis, 1027, repositoryURL)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+
+	analyses, total, err := analysis.ListAnalysesPage(repositoryURL, repositoryBranch, c.QueryParam("result"), page, perPage)
+	if err != nil {
+		if err == analysis.ErrInvalidResultFilter {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	var nextPage interface{}
+	if page*perPage < total {
+		nextPage = page + 1
+	}
+	reply := map[string]interface{}{
+		"analyses": analyses,
+		"page":     page,
+		"perPage":  perPage,
+		"total":    total,
+		"nextPage": nextPage,
+	}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// GetFindings returns a page of the findings of every analysis started between since
+// and until query string params. They may be filtered by repository, severity, tool, file
+// and compliance, such as PCI-DSS:6.5.1. Only admin can list findings of every repository.
+func GetFindings(c echo.Context) error {
+
+	page, pageSize, err := getPagination(c)
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	since, until, err := getTimeWindow(c)
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	repositoryURL := c.QueryParam("repository")
+	attemptToken := requestToken(c)
+	if !isAdmin(c) && (repositoryURL == "" || !tokenValidator.HasAuthorization(attemptToken, repositoryURL)) {
+		log.Error(logActionGetFindings, logInfoAnalysis, 1027, repositoryURL)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+
+	findings := []types.AnalysisFinding{}
+	err = analysis.ForEachAnalysisBetween(since, until, repositoryURL, func(analysisResult types.Analysis) error {
+		analysisFindings, err := analysis.ListFindings([]types.Analysis{analysisResult}, c.QueryParam("severity"), c.QueryParam("tool"), c.QueryParam("file"), c.QueryParam("compliance"))
+		findings = append(findings, analysisFindings...)
+		return err
+	})
+	if err != nil {
+		if err == analysis.ErrInvalidComplianceFilter {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	total := len(findings)
+	start, end := pageBounds(page, pageSize, total)
+
+	reply := map[string]interface{}{
+		"findings": findings[start:end],
+		"page":     page,
+		"pageSize": pageSize,
+		"total":    total,
+	}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// ExportAnalysis streams every finding of a given analysis as a CSV file or, if the format
+// query string param is cyclonedx, returns the CycloneDX bill of materials of its dependencies.
+func ExportAnalysis(c echo.Context) error {
+
+	RID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	format := c.QueryParam("format")
+	if format != "" && format != "csv" && format != "cyclonedx" {
+		reply := map[string]interface{}{"success": false, "error": "invalid format"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error(logActionExportAnalysis, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	if format == "cyclonedx" {
+		bom, err := analysis.CycloneDXReport(RID)
+		if err != nil {
+			log.Error(logActionExportAnalysis, logInfoAnalysis, 1020, err)
+			reply := map[string]interface{}{"success": false, "error": "internal error"}
+			return c.JSON(http.StatusInternalServerError, reply)
+		}
+		c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", fmt.Sprintf("huskyci-%s.cdx.json", RID)))
+		return c.Blob(http.StatusOK, "application/vnd.cyclonedx+json", bom)
+	}
+	return streamFindingsCSV(c, fmt.Sprintf("huskyci-%s.csv", RID), func(w io.Writer) error {
+		return analysis.WriteFindingsCSV(w, []types.Analysis{analysisResult}, "")
+	})
+}
+
+// ExportFindings streams the findings of every analysis started between since and
+// until query string params as a CSV file. They may also be filtered by severity and
+// repository. Only admin can export findings of every repository.
+func ExportFindings(c echo.Context) error {
+
+	since, until, err := getTimeWindow(c)
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	repositoryURL := c.QueryParam("repository")
+	attemptToken := requestToken(c)
+	if !isAdmin(c) && (repositoryURL == "" || !tokenValidator.HasAuthorization(attemptToken, repositoryURL)) {
+		log.Error(logActionExportFindings, logInfoAnalysis, 1027, repositoryURL)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+
+	severity := c.QueryParam("severity")
+	return streamFindingsCSV(c, "huskyci-findings.csv", func(w io.Writer) error {
+		return analysis.WriteFindingsBetweenCSV(w, since, until, repositoryURL, severity)
+	})
+}
+
+// streamFindingsCSV runs writeCSV in a goroutine into a pipe that is streamed as the
+// response, so the whole file is never held in memory. The response is cut short if
+// writeCSV fails once it started.
+func streamFindingsCSV(c echo.Context, filename string, writeCSV func(w io.Writer) error) error {
+	pipeReader, pipeWriter := io.Pipe()
+	// unblocks the goroutine if the client goes away before the CSV ends
+	defer pipeReader.Close()
+	go func() {
+		pipeWriter.CloseWithError(writeCSV(pipeWriter))
+	}()
+	c.Response().Header().Set(echo.HeaderContentDisposition, fmt.Sprintf("attachment; filename=%q", filename))
+	return c.Stream(http.StatusOK, "text/csv", pipeReader)
+}
+
+// getTimeWindow returns the since and until query string params as RFC3339
+// times. Without them, it returns the last defaultExportDays days.
+func getTimeWindow(c echo.Context) (time.Time, time.Time, error) {
+	until := time.Now()
+	since := until.AddDate(0, 0, -defaultExportDays)
+	var err error
+	if rawSince := c.QueryParam("since"); rawSince != "" {
+		if since, err = time.Parse(time.RFC3339, rawSince); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid since")
+		}
+	}
+	if rawUntil := c.QueryParam("until"); rawUntil != "" {
+		if until, err = time.Parse(time.RFC3339, rawUntil); err != nil {
+			return time.Time{}, time.Time{}, errors.New("invalid until")
+		}
+	}
+	return since, until, nil
+}
+
+func pageBounds(page, pageSize, total int) (int, int) {
+	start := (page - 1) * pageSize
+	if start > total {
+		start = total
+	}
+	end := start + pageSize
+	if end > total {
+		end = total
+	}
+	return start, end
+}
+
+func getPagination(c echo.Context) (int, int, error) {
+	page, pageSize := 1, defaultFindingsPageSize
+	if rawPage := c.QueryParam("page"); rawPage != "" {
+		parsedPage, err := strconv.Atoi(rawPage)
+		if err != nil || parsedPage < 1 {
+			return 0, 0, errors.New("invalid page")
+		}
+		page = parsedPage
+	}
+	if rawPageSize := c.QueryParam("page_size"); rawPageSize != "" {
+		parsedPageSize, err := strconv.Atoi(rawPageSize)
+		if err != nil || parsedPageSize < 1 || parsedPageSize > maxFindingsPageSize {
+			return 0, 0, errors.New("invalid page_size")
+		}
+		pageSize = parsedPageSize
+	}
+	return page, pageSize, nil
+}
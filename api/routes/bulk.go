@@ -0,0 +1,112 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/ratelimit"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+	"github.com/labstack/echo"
+)
+
+const logActionReceiveBulkRequest = "ReceiveBulkRequest"
+const logActionGetBulkAnalysis = "GetBulkAnalysis"
+
+// ReceiveBulkRequest starts the analysis of many repositories at once, such as every one of a
+// GitHub organization. Every repository must be valid and allowed to the token, or none is
+// analyzed. The analyses are followed with GetBulkAnalysis using the returned jobID.
+func ReceiveBulkRequest(c echo.Context) error {
+
+	RID := c.Response().Header().Get(echo.HeaderXRequestID)
+	attemptToken := requestToken(c)
+
+	bulkRequest := types.BulkAnalysisRequest{}
+	if err := c.Bind(&bulkRequest); err != nil {
+		log.Error(logActionReceiveBulkRequest, logInfoAnalysis, 1015, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid bulk analysis JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	// checked before each repository is, so a huge request is refused right away
+	if err := analysis.ValidateBulkRequest(bulkRequest); err != nil {
+		log.Error(logActionReceiveBulkRequest, logInfoAnalysis, 1065, err)
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	for i, repository := range bulkRequest.Repositories {
+		if !tokenValidator.HasAuthorization(attemptToken, repository.URL) {
+			log.Error(logActionReceiveBulkRequest, logInfoAnalysis, 1027, RID)
+			reply := map[string]interface{}{"success": false, "error": "permission denied", "repositoryURL": repository.URL}
+			return c.JSON(http.StatusUnauthorized, reply)
+		}
+		sanitizedRepoURL, err := util.CheckMaliciousRepoURL(repository.URL)
+		if err != nil {
+			log.Error(logActionReceiveBulkRequest, logInfoAnalysis, 1016, repository.URL)
+			reply := map[string]interface{}{"success": false, "error": "invalid repository URL", "repositoryURL": repository.URL}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		if err := util.CheckValidRepoBranch(repository.Branch); err != nil {
+			log.Error(logActionReceiveBulkRequest, logInfoAnalysis, 1017, repository.Branch)
+			reply := map[string]interface{}{"success": false, "error": "invalid repository branch", "repositoryURL": repository.URL}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		bulkRequest.Repositories[i].URL = sanitizedRepoURL
+	}
+	// sanitized URLs may turn out to be the same repository
+	if err := analysis.ValidateBulkRequest(bulkRequest); err != nil {
+		log.Error(logActionReceiveBulkRequest, logInfoAnalysis, 1065, err)
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+
+	// every repository counts against the rate limit, as if it was requested on its own
+	if exceededAnalysesLimit(c, len(bulkRequest.Repositories)) {
+		reply := map[string]interface{}{"success": false, "error": "too many analyses requested"}
+		return c.JSON(http.StatusTooManyRequests, reply)
+	}
+
+	client, _ := ratelimit.AnalysisClient(&tokenHandler, attemptToken, c.RealIP())
+	bulkJob, err := analysis.NewBulkAnalysis(RID, client, bulkRequest)
+	if err != nil {
+		if err == analysis.ErrBulkJobsRunning {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusTooManyRequests, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusCreated, bulkJob)
+}
+
+// GetBulkAnalysis returns a bulk analysis job along with the status of the analysis of each
+// of its repositories. The token must be allowed to every one of them.
+func GetBulkAnalysis(c echo.Context) error {
+
+	jobID := c.Param("jobID")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(jobID, c); err != nil {
+		return err
+	}
+	bulkJob, err := analysis.FindBulkAnalysis(jobID)
+	for _, entry := range bulkJob.Repositories {
+		if !tokenValidator.HasAuthorization(attemptToken, entry.RepositoryURL) {
+			log.Error(logActionGetBulkAnalysis, logInfoAnalysis, 1027, jobID)
+			reply := map[string]interface{}{"success": false, "error": "permission denied"}
+			return c.JSON(http.StatusUnauthorized, reply)
+		}
+	}
+	if err != nil {
+		if err == analysis.ErrBulkAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, bulkJob)
+}
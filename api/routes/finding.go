@@ -0,0 +1,194 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/auth"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/labstack/echo"
+)
+
+const logActionRequestSuppression = "RequestFindingSuppression"
+
+// fingerprintRegexp matches the fingerprints of analysis.RepositoryFindingFingerprint.
+var fingerprintRegexp = regexp.MustCompile(`^[a-f0-9]{64}$`)
+
+// suppressionRequestIDRegexp matches the IDs of suppression requests, which are request IDs.
+var suppressionRequestIDRegexp = regexp.MustCompile(`^[-a-zA-Z0-9]+$`)
+
+// GetFinding returns a finding of a repository by its fingerprint, with how many analyses
+// reported it and when it was first and last seen.
+func GetFinding(c echo.Context) error {
+	fingerprint := c.Param("fingerprint")
+	if !fingerprintRegexp.MatchString(fingerprint) {
+		reply := map[string]interface{}{"success": false, "error": "invalid fingerprint"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	finding, err := analysis.FindFinding(fingerprint)
+	attemptToken := requestToken(c)
+	// a finding not found has no repository, so only admin can tell it does not exist
+	if !isAdmin(c) && !tokenValidator.HasAuthorization(attemptToken, finding.RepositoryURL) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrFindingNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, finding)
+}
+
+// isSuppressionReviewer returns whether the request is authenticated, with basic auth, by
+// admin or by one of the suppression reviewers of the API, such as team leads.
+func isSuppressionReviewer(c echo.Context) bool {
+	if isAdmin(c) {
+		return true
+	}
+	username, password, ok := c.Request().BasicAuth()
+	if !ok {
+		return false
+	}
+	for _, reviewer := range apiContext.APIConfiguration.SuppressionReviewers {
+		if username == reviewer {
+			valid, err := auth.ValidateUser(username, password, c)
+			return err == nil && valid
+		}
+	}
+	return false
+}
+
+// suppressionRequester returns who requests a suppression as authenticated: the username of
+// admin or the UUID of the access token. It never comes from the request body, so whoever
+// requested a suppression can not review it as someone else.
+func suppressionRequester(c echo.Context) string {
+	if isAdmin(c) {
+		username, _, _ := c.Request().BasicAuth()
+		return username
+	}
+	accessToken, err := tokenHandler.Identify(requestToken(c))
+	if err != nil {
+		return ""
+	}
+	return fmt.Sprintf("token %s", accessToken.UUID)
+}
+
+// RequestFindingSuppression asks to suppress a finding, by its fingerprint, as a false positive,
+// with why and, optionally, evidence, on behalf of whoever is authenticated. The request is
+// pending until a reviewer approves it with ReviewFindingSuppression, so the finding is still
+// reported until then.
+func RequestFindingSuppression(c echo.Context) error {
+	RID := c.Response().Header().Get(echo.HeaderXRequestID)
+	fingerprint := c.Param("fingerprint")
+	if !fingerprintRegexp.MatchString(fingerprint) {
+		reply := map[string]interface{}{"success": false, "error": "invalid fingerprint"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	finding, err := analysis.FindFinding(fingerprint)
+	attemptToken := requestToken(c)
+	if !isAdmin(c) && !tokenValidator.HasAuthorization(attemptToken, finding.RepositoryURL) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrFindingNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	request := types.SuppressionRequest{}
+	if err := c.Bind(&request); err != nil {
+		log.Error(logActionRequestSuppression, logInfoAnalysis, 1066, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid suppression request JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	request.RequestedBy = suppressionRequester(c)
+	suppression, err := analysis.RequestSuppression(RID, finding, request)
+	switch err {
+	case nil:
+		return c.JSON(http.StatusCreated, suppression)
+	case analysis.ErrSuppressionRequester, analysis.ErrSuppressionReason:
+		log.Error(logActionRequestSuppression, logInfoAnalysis, 1066, err)
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	case analysis.ErrSuppressionPending:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusConflict, reply)
+	default:
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+}
+
+// ReviewFindingSuppression approves or rejects a pending suppression request of a finding,
+// with a comment, as in {"action": "approve", "comment": "..."}. Only admin and suppression
+// reviewers can review it, and not if they requested it. The review is appended to the
+// approval chain of the request.
+func ReviewFindingSuppression(c echo.Context) error {
+	if !isSuppressionReviewer(c) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	fingerprint := c.Param("fingerprint")
+	requestID := c.Param("requestID")
+	if !fingerprintRegexp.MatchString(fingerprint) || !suppressionRequestIDRegexp.MatchString(requestID) {
+		reply := map[string]interface{}{"success": false, "error": "invalid fingerprint or request ID"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	review := types.SuppressionReview{}
+	if err := c.Bind(&review); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid suppression review JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	reviewer, _, _ := c.Request().BasicAuth()
+	suppression, err := analysis.ReviewSuppression(fingerprint, requestID, reviewer, review)
+	switch err {
+	case nil:
+		return c.JSON(http.StatusOK, suppression)
+	case analysis.ErrSuppressionAction:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	case analysis.ErrSuppressionSelfReview:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusForbidden, reply)
+	case analysis.ErrSuppressionNotFound:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusNotFound, reply)
+	case analysis.ErrSuppressionReviewed:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusConflict, reply)
+	default:
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+}
+
+// GetPendingSuppressions returns the review queue: the suppression requests waiting for a
+// review, the oldest first. Only admin and suppression reviewers can list them.
+func GetPendingSuppressions(c echo.Context) error {
+	if !isSuppressionReviewer(c) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	suppressions, err := analysis.PendingSuppressions()
+	if err != nil {
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"suppressions": suppressions, "total": len(suppressions)})
+}
@@ -5,12 +5,278 @@
 package routes
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"sync"
+	"time"
 
+	"github.com/globocom/huskyCI/api/cache"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	docker "github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
 	"github.com/labstack/echo"
+	"gopkg.in/mgo.v2"
+	"gopkg.in/mgo.v2/bson"
 )
 
+// Status of a component or of the whole API, as returned by Healthz and Readyz.
+const (
+	ComponentOK       = "ok"
+	ComponentError    = "error"
+	ComponentDisabled = "disabled"
+
+	HealthOK          = "ok"
+	HealthDegraded    = "degraded"
+	HealthUnavailable = "unavailable"
+)
+
+// componentCheckTimeout is how long a component has to answer before it is reported as an error.
+const componentCheckTimeout = 5 * time.Second
+
+// dependencyCheckTimeout is how long each dependency has to answer DeepHealthCheck.
+const dependencyCheckTimeout = 3 * time.Second
+
+// errComponentDisabled is returned by the check of a component that is not configured.
+var errComponentDisabled = errors.New("component is not configured")
+
+// ComponentCheck checks whether a component huskyCI depends on is healthy. Without
+// a Critical component, huskyCI can not run or persist analyses.
+type ComponentCheck struct {
+	Name     string
+	Critical bool
+	Check    func() error
+}
+
+// HealthReport is the status of huskyCI and of each of its components.
+type HealthReport struct {
+	Status     string            `json:"status"`
+	Components map[string]string `json:"components"`
+}
+
+// DependencyStatus is the status of a dependency checked by DeepHealthCheck and how long it
+// took to answer, or to time out.
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	LatencyMs int64  `json:"latencyMs"`
+}
+
+// DeepHealthReport is the status of huskyCI and of each dependency checked by DeepHealthCheck.
+type DeepHealthReport struct {
+	Status       string             `json:"status"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// componentChecks are the components reported by Healthz and Readyz.
+var componentChecks = []ComponentCheck{
+	{Name: "docker", Critical: true, Check: checkDocker},
+	{Name: "mongodb", Critical: true, Check: checkDatabase},
+	{Name: "redis", Check: checkRedis},
+	{Name: "webhookQueue", Check: checkWebhookQueue},
+}
+
 // HealthCheck is the heath check function.
 func HealthCheck(c echo.Context) error {
 	return c.String(http.StatusOK, "WORKING\n")
 }
+
+// DeepHealthCheck pings MongoDB and every Docker host and checks that securityTests are
+// stored, returning the status and latency of each one. Its status code is 200 if every one
+// is healthy or 503 otherwise. Unlike HealthCheck, it proves analyses can run and be stored.
+func DeepHealthCheck(c echo.Context) error {
+	report := CheckDependencies(dependencyChecks(), dependencyCheckTimeout)
+	if report.Status != HealthOK {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// Readiness checks if both the database and the Docker API can be reached,
+// so the API does not accept analyses it can not run or persist. The state of
+// the circuit of the Docker API is returned in the X-Docker-Circuit header.
+func Readiness(c echo.Context) error {
+	circuitState := docker.DockerCircuitState()
+	c.Response().Header().Set("X-Docker-Circuit", string(circuitState))
+	if circuitState == docker.CircuitOpen {
+		return c.String(http.StatusServiceUnavailable, "DOCKER API CIRCUIT OPEN\n")
+	}
+	if err := apiContext.APIConfiguration.DBInstance.HealthCheckDB(); err != nil {
+		log.Error("Readiness", "DB", 2021, err)
+		return c.String(http.StatusServiceUnavailable, "DATABASE UNAVAILABLE\n")
+	}
+	if err := docker.HealthCheckDockerAPI(); err != nil {
+		log.Error("Readiness", "DOCKERAPI", 3011, err)
+		return c.String(http.StatusServiceUnavailable, "DOCKER API UNAVAILABLE\n")
+	}
+	return c.String(http.StatusOK, "READY\n")
+}
+
+// Healthz returns the status of each component of huskyCI. Its status code is 200 if every
+// component is healthy, 206 if only components that are not critical are failing and 503
+// if any critical one is.
+func Healthz(c echo.Context) error {
+	report := CheckComponents(componentChecks, componentCheckTimeout)
+	switch report.Status {
+	case HealthOK:
+		return c.JSON(http.StatusOK, report)
+	case HealthDegraded:
+		return c.JSON(http.StatusPartialContent, report)
+	}
+	return c.JSON(http.StatusServiceUnavailable, report)
+}
+
+// Readyz returns 200 only if every critical component of huskyCI is healthy, so it can be
+// used as a readiness probe, along with the status of each component.
+func Readyz(c echo.Context) error {
+	report := CheckComponents(componentChecks, componentCheckTimeout)
+	if report.Status == HealthUnavailable {
+		return c.JSON(http.StatusServiceUnavailable, report)
+	}
+	return c.JSON(http.StatusOK, report)
+}
+
+// checkResult is what a check returned and how long it took to.
+type checkResult struct {
+	err     error
+	latency time.Duration
+}
+
+// runChecks runs every check concurrently and returns the result of each one, in the order of
+// checks. A check that does not return within timeout is reported as an error.
+func runChecks(checks []ComponentCheck, timeout time.Duration) []checkResult {
+	results := make([]checkResult, len(checks))
+	var wg sync.WaitGroup
+	for i, componentCheck := range checks {
+		wg.Add(1)
+		go func(i int, componentCheck ComponentCheck) {
+			defer wg.Done()
+			startedAt := time.Now()
+			done := make(chan error, 1)
+			go func() {
+				done <- componentCheck.Check()
+			}()
+			select {
+			case err := <-done:
+				results[i] = checkResult{err, time.Since(startedAt)}
+			case <-time.After(timeout):
+				results[i] = checkResult{fmt.Errorf("no answer within %s", timeout), time.Since(startedAt)}
+			}
+		}(i, componentCheck)
+	}
+	wg.Wait()
+	return results
+}
+
+// CheckComponents runs every check concurrently and returns the status of each component.
+// A check that does not return within timeout is reported as an error.
+func CheckComponents(checks []ComponentCheck, timeout time.Duration) HealthReport {
+	results := runChecks(checks, timeout)
+	report := HealthReport{Status: HealthOK, Components: map[string]string{}}
+	for i, componentCheck := range checks {
+		err := results[i].err
+		switch {
+		case err == nil:
+			report.Components[componentCheck.Name] = ComponentOK
+		case err == errComponentDisabled:
+			report.Components[componentCheck.Name] = ComponentDisabled
+		default:
+			report.Components[componentCheck.Name] = ComponentError
+			if componentCheck.Critical {
+				report.Status = HealthUnavailable
+			} else if report.Status == HealthOK {
+				report.Status = HealthDegraded
+			}
+		}
+	}
+	return report
+}
+
+// CheckDependencies runs every check concurrently and returns the status of each dependency,
+// in the order of checks, along with how long it took. A check that does not return within
+// timeout is reported as an error. Any dependency that is not healthy makes huskyCI unavailable.
+func CheckDependencies(checks []ComponentCheck, timeout time.Duration) DeepHealthReport {
+	results := runChecks(checks, timeout)
+	report := DeepHealthReport{Status: HealthOK, Dependencies: []DependencyStatus{}}
+	for i, componentCheck := range checks {
+		dependency := DependencyStatus{Name: componentCheck.Name, Status: ComponentOK, LatencyMs: int64(results[i].latency / time.Millisecond)}
+		if results[i].err != nil {
+			dependency.Status = ComponentError
+			report.Status = HealthUnavailable
+		}
+		report.Dependencies = append(report.Dependencies, dependency)
+	}
+	return report
+}
+
+// dependencyChecks are the dependencies reported by DeepHealthCheck: MongoDB, the
+// securityTests stored in it and each Docker host.
+func dependencyChecks() []ComponentCheck {
+	checks := []ComponentCheck{
+		{Name: "mongodb", Critical: true, Check: checkDatabase},
+		{Name: "securityTests", Critical: true, Check: checkSecurityTests},
+	}
+	hosts, err := docker.DockerHosts()
+	if err != nil {
+		return append(checks, ComponentCheck{Name: "docker", Critical: true, Check: func() error { return err }})
+	}
+	for _, host := range hosts {
+		host := host
+		checks = append(checks, ComponentCheck{Name: "docker:" + host, Critical: true, Check: func() error {
+			if err := docker.HealthCheckDockerHost(host); err != nil {
+				log.Error("DeepHealthCheck", "DOCKERAPI", 3011, host, err)
+				return err
+			}
+			return nil
+		}})
+	}
+	return checks
+}
+
+func checkDocker() error {
+	if docker.DockerCircuitState() == docker.CircuitOpen {
+		return errors.New("docker API circuit is open")
+	}
+	if err := docker.HealthCheckDockerAPI(); err != nil {
+		log.Error("Healthz", "DOCKERAPI", 3011, err)
+		return err
+	}
+	return nil
+}
+
+func checkDatabase() error {
+	if err := apiContext.APIConfiguration.DBInstance.HealthCheckDB(); err != nil {
+		log.Error("Healthz", "DB", 2021, err)
+		return err
+	}
+	return nil
+}
+
+// checkSecurityTests returns an error if no securityTest is stored, as analyses would run none.
+func checkSecurityTests() error {
+	securityTests, err := apiContext.APIConfiguration.DBInstance.FindAllDBSecurityTest(map[string]interface{}{"name": bson.M{"$exists": true}})
+	if err != nil && err != mgo.ErrNotFound {
+		log.Error("DeepHealthCheck", "DB", 2021, err)
+		return err
+	}
+	if len(securityTests) == 0 {
+		return errors.New("no securityTest is stored")
+	}
+	return nil
+}
+
+func checkRedis() error {
+	pinger, ok := cache.Instance.(interface{ Ping() error })
+	if !ok {
+		return errComponentDisabled
+	}
+	return pinger.Ping()
+}
+
+func checkWebhookQueue() error {
+	if pending := notifier.Pending(); pending > notifier.MaxPendingNotifications {
+		return fmt.Errorf("%d notifications are waiting to be delivered", pending)
+	}
+	return nil
+}
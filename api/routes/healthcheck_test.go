@@ -0,0 +1,114 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/routes"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckComponents", func() {
+
+	healthy := func() error { return nil }
+	failing := func() error { return errors.New("connection refused") }
+
+	Context("When every component is healthy", func() {
+		It("Should return ok", func() {
+			report := routes.CheckComponents([]routes.ComponentCheck{
+				{Name: "docker", Critical: true, Check: healthy},
+				{Name: "redis", Check: healthy},
+			}, time.Second)
+			Expect(report).To(Equal(routes.HealthReport{
+				Status:     routes.HealthOK,
+				Components: map[string]string{"docker": routes.ComponentOK, "redis": routes.ComponentOK},
+			}))
+		})
+	})
+
+	Context("When a component that is not critical fails", func() {
+		It("Should return degraded", func() {
+			report := routes.CheckComponents([]routes.ComponentCheck{
+				{Name: "docker", Critical: true, Check: healthy},
+				{Name: "redis", Check: failing},
+			}, time.Second)
+			Expect(report.Status).To(Equal(routes.HealthDegraded))
+			Expect(report.Components["redis"]).To(Equal(routes.ComponentError))
+		})
+	})
+
+	Context("When a critical component fails", func() {
+		It("Should return unavailable", func() {
+			report := routes.CheckComponents([]routes.ComponentCheck{
+				{Name: "mongodb", Critical: true, Check: failing},
+				{Name: "redis", Check: failing},
+			}, time.Second)
+			Expect(report.Status).To(Equal(routes.HealthUnavailable))
+		})
+	})
+
+	Context("When a component does not answer within the timeout", func() {
+		It("Should report it as an error without waiting for it", func() {
+			started := time.Now()
+			report := routes.CheckComponents([]routes.ComponentCheck{
+				{Name: "docker", Critical: true, Check: func() error {
+					time.Sleep(time.Second)
+					return nil
+				}},
+			}, 10*time.Millisecond)
+			Expect(report.Components["docker"]).To(Equal(routes.ComponentError))
+			Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+		})
+	})
+})
+
+var _ = Describe("CheckDependencies", func() {
+
+	healthy := func() error { return nil }
+
+	Context("When every dependency is healthy", func() {
+		It("Should return ok with each one in order", func() {
+			report := routes.CheckDependencies([]routes.ComponentCheck{
+				{Name: "mongodb", Check: healthy},
+				{Name: "docker:dockerapi:2376", Check: healthy},
+			}, time.Second)
+			Expect(report.Status).To(Equal(routes.HealthOK))
+			Expect(report.Dependencies).To(HaveLen(2))
+			Expect(report.Dependencies[0].Name).To(Equal("mongodb"))
+			Expect(report.Dependencies[1].Name).To(Equal("docker:dockerapi:2376"))
+			Expect(report.Dependencies[1].Status).To(Equal(routes.ComponentOK))
+		})
+	})
+
+	Context("When any dependency fails", func() {
+		It("Should return unavailable", func() {
+			report := routes.CheckDependencies([]routes.ComponentCheck{
+				{Name: "mongodb", Check: healthy},
+				{Name: "securityTests", Check: func() error { return errors.New("no securityTest is stored") }},
+			}, time.Second)
+			Expect(report.Status).To(Equal(routes.HealthUnavailable))
+			Expect(report.Dependencies[1].Status).To(Equal(routes.ComponentError))
+		})
+	})
+
+	Context("When a dependency hangs", func() {
+		It("Should report it as an error once the timeout expires", func() {
+			started := time.Now()
+			report := routes.CheckDependencies([]routes.ComponentCheck{
+				{Name: "docker:dockerapi-2:2376", Check: func() error {
+					time.Sleep(time.Second)
+					return nil
+				}},
+			}, 20*time.Millisecond)
+			Expect(report.Status).To(Equal(routes.HealthUnavailable))
+			Expect(report.Dependencies[0].LatencyMs).To(BeNumerically(">=", 20))
+			Expect(time.Since(started)).To(BeNumerically("<", time.Second))
+		})
+	})
+})
@@ -0,0 +1,41 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/labstack/echo"
+)
+
+// GetImageVulns returns how many vulnerabilities Trivy found in the image of each securityTest
+// by severity, as of its last scan. Only admin can get them.
+func GetImageVulns(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can get the vulnerabilities of images"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	return c.JSON(http.StatusOK, analysis.GetImageVulnReports())
+}
+
+// ValidateImages reads the platforms the image of each securityTest is built for from its
+// registry and reports which ones only have amd64 manifests, so they would run under emulation
+// on an ARM Docker host. Only admin can validate them.
+func ValidateImages(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can validate the platforms of images"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	validation, err := analysis.ValidateImagePlatforms(dockers.NewManifestClient())
+	if err != nil {
+		log.Error("ValidateImages", logActionReceiveRequest, 1064, err)
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, validation)
+}
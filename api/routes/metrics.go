@@ -0,0 +1,49 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/labstack/echo"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// metricsHandler serves every metric of the default Prometheus registry.
+var metricsHandler = promhttp.Handler()
+
+// Metrics returns the Prometheus metrics of huskyCI, see the metrics package for their names
+// and labels. Only admin can scrape them, so anonymous requests never reach the health checks
+// of its critical components, which run on each scrape.
+func Metrics(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	RecordComponentHealth(componentChecks, componentCheckTimeout)
+	metricsHandler.ServeHTTP(c.Response(), c.Request())
+	return nil
+}
+
+// RecordComponentHealth sets whether each critical component of checks is healthy to
+// metrics.ComponentUp.
+func RecordComponentHealth(checks []ComponentCheck, timeout time.Duration) {
+	criticalChecks := []ComponentCheck{}
+	for _, componentCheck := range checks {
+		if componentCheck.Critical {
+			criticalChecks = append(criticalChecks, componentCheck)
+		}
+	}
+	report := CheckComponents(criticalChecks, timeout)
+	for name, status := range report.Components {
+		up := 0.0
+		if status == ComponentOK {
+			up = 1
+		}
+		metrics.ComponentUp.WithLabelValues(name).Set(up)
+	}
+}
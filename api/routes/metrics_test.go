@@ -0,0 +1,44 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/globocom/huskyCI/api/routes"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("RecordComponentHealth", func() {
+
+	scrape := func() string {
+		recorder := httptest.NewRecorder()
+		promhttp.Handler().ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+		Expect(recorder.Code).To(Equal(http.StatusOK))
+		return recorder.Body.String()
+	}
+
+	It("Should expose whether each critical component is healthy", func() {
+		routes.RecordComponentHealth([]routes.ComponentCheck{
+			{Name: "docker", Critical: true, Check: func() error { return nil }},
+			{Name: "mongodb", Critical: true, Check: func() error { return errors.New("connection refused") }},
+			{Name: "redis", Check: func() error { return nil }},
+		}, time.Second)
+		body := scrape()
+		Expect(body).To(ContainSubstring(`huskyci_component_up{component="docker"} 1`))
+		Expect(body).To(ContainSubstring(`huskyci_component_up{component="mongodb"} 0`))
+		Expect(body).ToNot(ContainSubstring(`huskyci_component_up{component="redis"}`))
+	})
+
+	It("Should expose the metrics of analyses", func() {
+		Expect(scrape()).To(ContainSubstring("# TYPE huskyci_analyses_started_total counter"))
+	})
+})
@@ -0,0 +1,114 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+	"github.com/labstack/echo"
+)
+
+const logActionReceivePRRequest = "ReceivePRRequest"
+const logActionGetPRAnalysis = "GetPRAnalysis"
+
+// ReceivePRRequest starts the analysis of a pull request: its head and base branches are
+// analyzed, unless baseCommit already was, and their findings are compared once both finish.
+// The comparison is polled with GetPRAnalysis using the returned id.
+func ReceivePRRequest(c echo.Context) error {
+
+	RID := c.Response().Header().Get(echo.HeaderXRequestID)
+	attemptToken := requestToken(c)
+
+	prRequest := types.PRAnalysisRequest{}
+	if err := c.Bind(&prRequest); err != nil {
+		log.Error(logActionReceivePRRequest, logInfoAnalysis, 1015, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid pull request JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if !tokenValidator.HasAuthorization(attemptToken, prRequest.URL) {
+		log.Error(logActionReceivePRRequest, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	sanitizedRepoURL, err := util.CheckMaliciousRepoURL(prRequest.URL)
+	if err != nil {
+		log.Error(logActionReceivePRRequest, logInfoAnalysis, 1059, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid repositoryURL"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	prRequest.URL = sanitizedRepoURL
+	if prRequest.HeadBranch == "" || prRequest.BaseBranch == "" {
+		reply := map[string]interface{}{"success": false, "error": "headBranch and baseBranch are required"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	for _, check := range []error{
+		util.CheckValidRepoBranch(prRequest.HeadBranch),
+		util.CheckValidRepoBranch(prRequest.BaseBranch),
+		util.CheckValidCommitSHA(prRequest.HeadCommit),
+		util.CheckValidCommitSHA(prRequest.BaseCommit),
+	} {
+		if check != nil {
+			log.Error(logActionReceivePRRequest, logInfoAnalysis, 1059, check)
+			reply := map[string]interface{}{"success": false, "error": check.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+	}
+
+	prAnalysis, err := analysis.NewPRAnalysis(RID, prRequest)
+	if err != nil {
+		switch err {
+		case analysis.ErrPRSameBranch:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		case analysis.ErrAnalysisAlreadyRunning:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusConflict, reply)
+		case analysis.ErrShuttingDown:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusServiceUnavailable, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{
+		"success":    true,
+		"error":      "",
+		"id":         prAnalysis.ID,
+		"headRID":    prAnalysis.HeadRID,
+		"baseRID":    prAnalysis.BaseRID,
+		"baseReused": prAnalysis.BaseReused,
+	}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// GetPRAnalysis returns a pull request analysis: the new, resolved and persistent findings
+// of its head branch compared to its base branch and a review to post with them.
+func GetPRAnalysis(c echo.Context) error {
+
+	ID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(ID, c); err != nil {
+		return err
+	}
+	prAnalysis, err := analysis.FindPRAnalysis(ID)
+	if !tokenValidator.HasAuthorization(attemptToken, prAnalysis.URL) {
+		log.Error(logActionGetPRAnalysis, logInfoAnalysis, 1027, ID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err != nil {
+		if err == analysis.ErrPRAnalysisNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, prAnalysis)
+}
@@ -0,0 +1,45 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/globocom/huskyCI/api/ratelimit"
+	"github.com/labstack/echo"
+)
+
+// RateLimitAnalyses refuses requests of clients that requested more analyses than their burst
+// or sustained limit, see ratelimit.AllowAnalysis, with 429 and a Retry-After header. The limit
+// closest to being exceeded is sent in the X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset headers.
+func RateLimitAnalyses(next echo.HandlerFunc) echo.HandlerFunc {
+	return func(c echo.Context) error {
+		if exceededAnalysesLimit(c, 1) {
+			reply := map[string]interface{}{"success": false, "error": "too many analyses requested"}
+			return c.JSON(http.StatusTooManyRequests, reply)
+		}
+		return next(c)
+	}
+}
+
+// exceededAnalysesLimit counts analyses requested at once by the client of c, as
+// RateLimitAnalyses does, setting its headers, and returns whether a limit was exceeded.
+func exceededAnalysesLimit(c echo.Context, analyses int) bool {
+	state, enforced := ratelimit.AllowAnalyses(ratelimit.DBStore{}, &tokenHandler, requestToken(c), c.RealIP(), analyses, time.Now())
+	if !enforced {
+		return false
+	}
+	header := c.Response().Header()
+	header.Set("X-RateLimit-Limit", strconv.Itoa(state.Limit))
+	header.Set("X-RateLimit-Remaining", strconv.Itoa(state.Remaining))
+	header.Set("X-RateLimit-Reset", strconv.FormatInt(state.Reset.Unix(), 10))
+	if state.Exceeded {
+		header.Set("Retry-After", strconv.Itoa(int(state.RetryAfter.Round(time.Second)/time.Second)))
+	}
+	return state.Exceeded
+}
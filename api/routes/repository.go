@@ -0,0 +1,190 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/util"
+	"github.com/labstack/echo"
+)
+
+const logActionGetRepositoryTrend = "GetRepositoryTrend"
+const logInfoRepository = "REPOSITORY"
+
+const defaultTrendDays = 30
+const maxTrendDays = 365
+
+// GetRepositoryTrend returns the daily snapshots of findings of a repository, given
+// by its URL escaped as repoID, and how many fewer findings it has than 30 days ago.
+func GetRepositoryTrend(c echo.Context) error {
+	repositoryURL, err := url.PathUnescape(c.Param("repoID"))
+	if err != nil || repositoryURL == "" {
+		reply := map[string]interface{}{"success": false, "error": "invalid repository"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	days := defaultTrendDays
+	if rawDays := c.QueryParam("days"); rawDays != "" {
+		parsedDays, err := strconv.Atoi(rawDays)
+		if err != nil || parsedDays < 1 || parsedDays > maxTrendDays {
+			reply := map[string]interface{}{"success": false, "error": "invalid days"}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		days = parsedDays
+	}
+	attemptToken := requestToken(c)
+	if !tokenValidator.HasAuthorization(attemptToken, repositoryURL) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+
+	repositoryTrend, err := analysis.GetRepositoryTrend(repositoryURL, days, c.QueryParam("tool"))
+	if err != nil {
+		log.Error(logActionGetRepositoryTrend, logInfoRepository, 2027, repositoryURL, err)
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, repositoryTrend)
+}
+
+// UpdateRepositorySlackChannel sets the Slack channel failed analyses of a repository, given
+// by its URL escaped as repoID, are notified to, as the slackChannel field of the JSON body.
+// An empty slackChannel restores the default one. Only admin can change it.
+func UpdateRepositorySlackChannel(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can change the Slack channel"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	repositoryURL, err := url.PathUnescape(c.Param("repoID"))
+	if err != nil || repositoryURL == "" {
+		reply := map[string]interface{}{"success": false, "error": "invalid repository"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	body := struct {
+		SlackChannel string `json:"slackChannel"`
+	}{}
+	if err := c.Bind(&body); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if err := util.CheckValidSlackChannel(body.SlackChannel); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid slackChannel"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := analysis.SetRepositorySlackChannel(repositoryURL, body.SlackChannel); err != nil {
+		if err == analysis.ErrRepositoryNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// UpdateRepositoryGitLab sets whether analyses of a repository, given by its URL escaped as
+// repoID, are reported to GitLab, as the enabled field of the JSON body.
+func UpdateRepositoryGitLab(c echo.Context) error {
+	repositoryURL, err := url.PathUnescape(c.Param("repoID"))
+	if err != nil || repositoryURL == "" {
+		reply := map[string]interface{}{"success": false, "error": "invalid repository"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	attemptToken := requestToken(c)
+	if !tokenValidator.HasAuthorization(attemptToken, repositoryURL) {
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	body := struct {
+		Enabled bool `json:"enabled"`
+	}{}
+	if err := c.Bind(&body); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if _, err := util.ParseGitLabProject(repositoryURL); body.Enabled && err != nil {
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := analysis.SetRepositoryGitLab(repositoryURL, body.Enabled); err != nil {
+		if err == analysis.ErrRepositoryNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// UpdateRepositoryHTTPSToken sets whether a repository, given by its URL escaped as repoID, is
+// cloned with HUSKYCI_API_GIT_HTTPS_TOKEN, as the enabled field of the JSON body. Its host must
+// be one of HUSKYCI_API_GIT_HTTPS_TOKEN_HOSTS. Only admin can set it, as the token can read
+// every repository it is scoped to.
+func UpdateRepositoryHTTPSToken(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can set the HTTPS token of repositories"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	repositoryURL, err := url.PathUnescape(c.Param("repoID"))
+	if err != nil || repositoryURL == "" {
+		reply := map[string]interface{}{"success": false, "error": "invalid repository"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	body := struct {
+		Enabled bool `json:"enabled"`
+	}{}
+	if err := c.Bind(&body); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if body.Enabled && !util.IsHTTPSTokenHost(repositoryURL, apiContext.APIConfiguration.GitHTTPSTokenHosts) {
+		reply := map[string]interface{}{"success": false, "error": "the HTTPS token is not allowed for the host of this repository"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := analysis.SetRepositoryHTTPSToken(repositoryURL, body.Enabled); err != nil {
+		if err == analysis.ErrRepositoryNotFound {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// ListRepositories returns every repository ranked by the risk score of its last finished
+// analysis, as given by the sort query string param, riskScore or repositoryURL, and the
+// order one, asc or desc. The risk score goes from 0 to 100 and is computed as
+// min(100, sum(CVSS * severityWeight * ageFactor) * (1 + suppressionRate) / 5) of the
+// unsuppressed findings, as documented in analysis.ComputeRiskScore. Only admin can list them.
+func ListRepositories(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can list repositories"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	repositories, err := analysis.ListRepositoryRisks(c.QueryParam("sort"), c.QueryParam("order"))
+	if err != nil {
+		if err == analysis.ErrInvalidRiskSort {
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{"repositories": repositories, "total": len(repositories)}
+	return c.JSON(http.StatusOK, reply)
+}
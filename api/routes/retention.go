@@ -0,0 +1,44 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/labstack/echo"
+)
+
+// GetRetention returns how long analyses keep their raw output before it is archived, where
+// it is archived to and how the last run of the archive job went. Only admin can get it.
+func GetRetention(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can get the retention policy"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	return c.JSON(http.StatusOK, analysis.GetRetentionPolicy())
+}
+
+// RunRetention runs the archive job right away and returns how it went. Only admin can run it.
+func RunRetention(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can run the archive job"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	run, err := analysis.RunArchiveJob()
+	if err != nil {
+		switch err {
+		case analysis.ErrArchiveRunning:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusConflict, reply)
+		case analysis.ErrArchiveDisabled:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, run)
+}
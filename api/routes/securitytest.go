@@ -0,0 +1,86 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"net/http"
+
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/labstack/echo"
+)
+
+// CreateSecurityTest registers a custom securityTest, which runs in analyses started
+// after it without a restart. Its output is parsed by the parser it chooses, either
+// generic or one of a built-in securityTest or plugin. Only admin can register it.
+func CreateSecurityTest(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can register securityTests"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	securityTest := types.SecurityTest{}
+	if err := c.Bind(&securityTest); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid securityTest JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if err := securitytest.CreateCustomSecurityTest(securityTest); err != nil {
+		return customSecurityTestError(c, err)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// UpdateSecurityTest replaces a custom securityTest. Only admin can update it.
+func UpdateSecurityTest(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can update securityTests"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	securityTest := types.SecurityTest{}
+	if err := c.Bind(&securityTest); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "invalid securityTest JSON"}
+		return c.JSON(http.StatusBadRequest, reply)
+	}
+	if err := securitytest.UpdateCustomSecurityTest(c.Param("securityTestName"), securityTest); err != nil {
+		return customSecurityTestError(c, err)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// DeleteSecurityTest removes a custom securityTest. Only admin can remove it.
+func DeleteSecurityTest(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can remove securityTests"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err := securitytest.DeleteCustomSecurityTest(c.Param("securityTestName")); err != nil {
+		return customSecurityTestError(c, err)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusOK, reply)
+}
+
+func customSecurityTestError(c echo.Context, err error) error {
+	switch {
+	case err == securitytest.ErrUnknownParser:
+		reply := map[string]interface{}{"success": false, "error": err.Error(), "parsers": securitytest.CustomParsers()}
+		return c.JSON(http.StatusBadRequest, reply)
+	case securitytest.IsInvalidCustomSecurityTest(err):
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusBadRequest, reply)
+	case err == securitytest.ErrBuiltinSecurityTest:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusForbidden, reply)
+	case err == securitytest.ErrSecurityTestAlreadyExists:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusConflict, reply)
+	case err == securitytest.ErrSecurityTestNotFound:
+		reply := map[string]interface{}{"success": false, "error": err.Error()}
+		return c.JSON(http.StatusNotFound, reply)
+	}
+	reply := map[string]interface{}{"success": false, "error": "internal error"}
+	return c.JSON(http.StatusInternalServerError, reply)
+}
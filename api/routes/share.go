@@ -0,0 +1,150 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package routes
+
+import (
+	"bytes"
+	"html/template"
+	"net/http"
+	"time"
+
+	"github.com/globocom/huskyCI/api/analysis"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/util"
+	"github.com/labstack/echo"
+)
+
+const logActionShareAnalysis = "ShareAnalysis"
+const logActionRevokeAnalysisShares = "RevokeAnalysisShares"
+
+var sharedAnalysisTemplate = template.Must(template.New("sharedAnalysis").Parse(`<!DOCTYPE html>
+<html>
+<head><meta charset="utf-8"><title>huskyCI analysis {{.RID}}</title></head>
+<body>
+<h1>huskyCI analysis {{.RID}}</h1>
+<p>{{.URL}} ({{.Branch}}): {{.Status}}, {{.Result}}. Shared until {{.ExpiresAt.UTC.Format "2006-01-02 15:04 MST"}}.</p>
+{{with .Summary}}<p>Critical: {{.Critical}}, High: {{.High}}, Medium: {{.Medium}}, Low: {{.Low}}, Info: {{.Info}}, Ignored: {{.Ignored}}</p>{{end}}
+{{with .Compliance}}<h2>Compliance</h2>
+<table>
+<tr><th>Framework</th><th>Requirement</th><th>Findings</th></tr>
+{{range .}}<tr><td>{{.Framework}}</td><td>{{.Requirement}}</td><td>{{.Findings}}</td></tr>
+{{end}}</table>
+<h2>Findings</h2>{{end}}
+<table>
+<tr><th>Severity</th><th>Tool</th><th>File</th><th>Line</th><th>Rule</th><th>Description</th></tr>
+{{range .Findings}}<tr><td>{{.Severity}}</td><td>{{.Tool}}</td><td>{{.File}}</td><td>{{.Line}}</td><td>{{.RuleID}}</td><td>{{.Description}}</td></tr>
+{{end}}</table>
+</body>
+</html>
+`))
+
+// ShareAnalysis returns a signed URL that gives read-only access to a given analysis without
+// authentication. It expires after the ttl query string param, 7 days by default and up to 30 days.
+func ShareAnalysis(c echo.Context) error {
+
+	RID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	ttl := analysis.DefaultShareTTL
+	if rawTTL := c.QueryParam("ttl"); rawTTL != "" {
+		parsedTTL, err := time.ParseDuration(rawTTL)
+		if err != nil {
+			reply := map[string]interface{}{"success": false, "error": "invalid ttl"}
+			return c.JSON(http.StatusBadRequest, reply)
+		}
+		ttl = parsedTTL
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error(logActionShareAnalysis, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err == analysis.ErrAnalysisNotFound {
+		reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+		return c.JSON(http.StatusNotFound, reply)
+	}
+
+	analysisShare, err := analysis.ShareAnalysis(RID, ttl)
+	if err != nil {
+		switch err {
+		case analysis.ErrInvalidShareTTL:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusBadRequest, reply)
+		case analysis.ErrShareNotConfigured:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusServiceUnavailable, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{
+		"success":   true,
+		"url":       c.Scheme() + "://" + c.Request().Host + "/analysis/shared/" + analysisShare.Token,
+		"expiresAt": analysisShare.ExpiresAt,
+	}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// RevokeAnalysisShares revokes every signed URL of a given analysis.
+func RevokeAnalysisShares(c echo.Context) error {
+
+	RID := c.Param("id")
+	attemptToken := requestToken(c)
+	if err := util.CheckMaliciousRID(RID, c); err != nil {
+		return err
+	}
+	analysisResult, err := analysis.FindAnalysis(RID)
+	if !tokenValidator.HasAuthorization(attemptToken, analysisResult.URL) {
+		log.Error(logActionRevokeAnalysisShares, logInfoAnalysis, 1027, RID)
+		reply := map[string]interface{}{"success": false, "error": "permission denied"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	if err == analysis.ErrAnalysisNotFound {
+		reply := map[string]interface{}{"success": false, "error": "analysis not found"}
+		return c.JSON(http.StatusNotFound, reply)
+	}
+
+	if err := analysis.RevokeAnalysisShares(RID); err != nil {
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	reply := map[string]interface{}{"success": true, "error": ""}
+	return c.JSON(http.StatusOK, reply)
+}
+
+// GetSharedAnalysis returns the read-only summary of the analysis of a signed URL, as
+// JSON or, if the format query string param is html, as an HTML page.
+func GetSharedAnalysis(c echo.Context) error {
+
+	sharedAnalysis, err := analysis.FindSharedAnalysis(c.Param("token"))
+	if err != nil {
+		switch err {
+		case analysis.ErrShareExpired:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusGone, reply)
+		case analysis.ErrInvalidShareToken, analysis.ErrShareNotFound, analysis.ErrAnalysisNotFound:
+			reply := map[string]interface{}{"success": false, "error": "shared analysis not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		case analysis.ErrShareNotConfigured:
+			reply := map[string]interface{}{"success": false, "error": err.Error()}
+			return c.JSON(http.StatusServiceUnavailable, reply)
+		}
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+
+	if c.QueryParam("format") == "html" {
+		var page bytes.Buffer
+		if err := sharedAnalysisTemplate.Execute(&page, sharedAnalysis); err != nil {
+			reply := map[string]interface{}{"success": false, "error": "internal error"}
+			return c.JSON(http.StatusInternalServerError, reply)
+		}
+		return c.HTML(http.StatusOK, page.String())
+	}
+	return c.JSON(http.StatusOK, sharedAnalysis)
+}
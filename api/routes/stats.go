@@ -7,9 +7,13 @@ package routes
 import (
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/globocom/huskyCI/api/auth"
 	apiContext "github.com/globocom/huskyCI/api/context"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/stats"
+	"github.com/globocom/huskyCI/api/user"
 	"github.com/labstack/echo"
 )
 
@@ -28,6 +32,53 @@ func GetMetric(c echo.Context) error {
 	return c.JSON(http.StatusOK, result)
 }
 
+// GetStats returns aggregated statistics of all analyses. They are cached for a few
+// minutes, unless an admin forces a refresh using ?refresh=true.
+func GetStats(c echo.Context) error {
+	refresh := c.QueryParam("refresh") == "true"
+	if refresh && !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can refresh stats"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	summary, err := stats.NewService().GetSummary(refresh)
+	if err != nil {
+		log.Error("GetStats", logInfoStats, 2023, err)
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	c.Response().Header().Set("ETag", summary.ETag)
+	if c.Request().Header.Get("If-None-Match") == summary.ETag {
+		return c.NoContent(http.StatusNotModified)
+	}
+	return c.JSONBlob(http.StatusOK, summary.Body)
+}
+
+// GetSLAReport returns the p50, p95 and p99 scan duration of each securityTest
+// in the last 7 days. Only admin can get it.
+func GetSLAReport(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can get the SLA report"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	slaReport, err := apiContext.APIConfiguration.DBInstance.GetSLAReport(time.Now().AddDate(0, 0, -7))
+	if err != nil {
+		log.Error("GetSLAReport", logInfoStats, 2025, err)
+		reply := map[string]interface{}{"success": false, "error": "internal error"}
+		return c.JSON(http.StatusInternalServerError, reply)
+	}
+	return c.JSON(http.StatusOK, slaReport)
+}
+
+// isAdmin returns true if the request has the basic auth credentials of the default API user.
+func isAdmin(c echo.Context) bool {
+	username, password, ok := c.Request().BasicAuth()
+	if !ok || username != user.DefaultAPIUser {
+		return false
+	}
+	valid, err := auth.ValidateUser(username, password, c)
+	return err == nil && valid
+}
+
 func checkError(err error, metricType string) (int, map[string]interface{}) {
 	switch err.Error() {
 	case "invalid time_range query string param":
@@ -6,12 +6,14 @@ package routes
 
 import (
 	"net/http"
+	"strings"
 
 	"github.com/globocom/huskyCI/api/auth"
 	"github.com/globocom/huskyCI/api/log"
 	"github.com/globocom/huskyCI/api/token"
 	"github.com/globocom/huskyCI/api/types"
 	"github.com/labstack/echo"
+	"gopkg.in/mgo.v2"
 )
 
 var (
@@ -35,6 +37,10 @@ func HandleToken(c echo.Context) error {
 		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "error": "invalid token JSON"})
 	}
 	log.Info("HandleToken", "TOKEN", 24, repoRequest.RepositoryURL)
+	if _, err := tokenHandler.CheckRequest(repoRequest); err != nil {
+		log.Error("HandleToken", "TOKEN", 1026, err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "error": "invalid token request"})
+	}
 	accessToken, err := tokenHandler.GenerateAccessToken(repoRequest)
 	if err != nil {
 		log.Error("HandleToken ", "TOKEN", 1026, err)
@@ -57,3 +63,93 @@ func HandleDeactivation(c echo.Context) error {
 	}
 	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "error": ""})
 }
+
+// requestToken returns the access token of the request, sent as a bearer token in the
+// Authorization header or, by clients that predate it, in the Husky-Token header.
+func requestToken(c echo.Context) string {
+	authorization := c.Request().Header.Get(echo.HeaderAuthorization)
+	if strings.HasPrefix(authorization, "Bearer ") {
+		return strings.TrimSpace(strings.TrimPrefix(authorization, "Bearer "))
+	}
+	return c.Request().Header.Get("Husky-Token")
+}
+
+// CreateAccessToken generates an access token for a repository URL or, if prefix is set,
+// for every repository under a URL prefix, optionally until expiresAt. Only admin can
+// create it, and its UUID is returned so it can be revoked later.
+func CreateAccessToken(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can create access tokens"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	repoRequest := types.TokenRequest{}
+	if err := c.Bind(&repoRequest); err != nil {
+		log.Error("CreateAccessToken", "TOKEN", 1025, err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "error": "invalid token JSON"})
+	}
+	log.Info("CreateAccessToken", "TOKEN", 24, repoRequest.RepositoryURL)
+	if _, err := tokenHandler.CheckRequest(repoRequest); err != nil {
+		log.Error("CreateAccessToken", "TOKEN", 1026, err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "error": "invalid token request"})
+	}
+	accessToken, err := tokenHandler.GenerateAccessToken(repoRequest)
+	if err != nil {
+		log.Error("CreateAccessToken", "TOKEN", 1026, err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "token generation failure"})
+	}
+	uUID, _, err := tokenHandler.GetSplitted(accessToken)
+	if err != nil {
+		log.Error("CreateAccessToken", "TOKEN", 1026, err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "token generation failure"})
+	}
+	reply := map[string]interface{}{"huskytoken": accessToken, "uuid": uUID}
+	if !repoRequest.ExpiresAt.IsZero() {
+		reply["expiresAt"] = repoRequest.ExpiresAt
+	}
+	return c.JSON(http.StatusCreated, reply)
+}
+
+// RevokeAccessToken deactivates the access token of a given UUID. Only admin can revoke it.
+func RevokeAccessToken(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can revoke access tokens"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	uUID := c.Param("uuid")
+	if err := tokenHandler.RevokeAccessToken(uUID); err != nil {
+		if err == mgo.ErrNotFound || err.Error() == "No data found" {
+			reply := map[string]interface{}{"success": false, "error": "access token not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		log.Error("RevokeAccessToken", "TOKEN", 1028, err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "token deactivation failure"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "error": ""})
+}
+
+// SetAccessTokenRateLimit sets how many analyses can be requested with the access token of a
+// given UUID, overriding the rate limits of the API for trusted heavy users. Only admin can set it.
+func SetAccessTokenRateLimit(c echo.Context) error {
+	if !isAdmin(c) {
+		reply := map[string]interface{}{"success": false, "error": "only admin can set rate limits of access tokens"}
+		return c.JSON(http.StatusUnauthorized, reply)
+	}
+	rateLimitRequest := types.RateLimitRequest{}
+	if err := c.Bind(&rateLimitRequest); err != nil {
+		log.Error("SetAccessTokenRateLimit", "TOKEN", 1025, err)
+		return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "error": "invalid rate limit JSON"})
+	}
+	uUID := c.Param("uuid")
+	if err := tokenHandler.SetRateLimit(uUID, rateLimitRequest.Burst, rateLimitRequest.Sustained); err != nil {
+		if err == token.ErrInvalidRateLimit {
+			return c.JSON(http.StatusBadRequest, map[string]interface{}{"success": false, "error": err.Error()})
+		}
+		if err == mgo.ErrNotFound || err.Error() == "No data found" {
+			reply := map[string]interface{}{"success": false, "error": "access token not found"}
+			return c.JSON(http.StatusNotFound, reply)
+		}
+		log.Error("SetAccessTokenRateLimit", "TOKEN", 1028, err)
+		return c.JSON(http.StatusInternalServerError, map[string]interface{}{"success": false, "error": "internal error"})
+	}
+	return c.JSON(http.StatusOK, map[string]interface{}{"success": true, "error": ""})
+}
@@ -6,22 +6,26 @@ package routes
 
 import (
 	"net/http"
+	"runtime"
 
 	apiContext "github.com/globocom/huskyCI/api/context"
 	"github.com/labstack/echo"
 )
 
-//GetAPIVersion returns the API version
+// GetAPIVersion returns the version of the API, along with the commit it was built from,
+// when it was built and with which Go version.
 func GetAPIVersion(c echo.Context) error {
 	configAPI := apiContext.APIConfiguration
 	return c.JSON(http.StatusOK, GetRequestResult(configAPI))
 }
 
-// GetRequestResult returns a map containing API's version and release date
+// GetRequestResult returns a map containing API's version, commit, build date and Go version
 func GetRequestResult(configAPI *apiContext.APIConfig) map[string]string {
 	requestResult := map[string]string{
-		"version": configAPI.Version,
-		"date":    configAPI.ReleaseDate,
+		"version":   configAPI.Version,
+		"commit":    configAPI.Commit,
+		"date":      configAPI.ReleaseDate,
+		"goVersion": runtime.Version(),
 	}
 	return requestResult
 }
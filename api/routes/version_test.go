@@ -5,6 +5,8 @@
 package routes_test
 
 import (
+	"runtime"
+
 	apiContext "github.com/globocom/huskyCI/api/context"
 	"github.com/globocom/huskyCI/api/routes"
 
@@ -15,17 +17,26 @@ import (
 var _ = Describe("getRequestResult", func() {
 
 	expected := map[string]string{
-		"version": apiContext.DefaultConf.GetAPIVersion(),
-		"date":    apiContext.DefaultConf.GetAPIReleaseDate(),
+		"version":   apiContext.DefaultConf.GetAPIVersion(),
+		"commit":    apiContext.DefaultConf.GetAPICommit(),
+		"date":      apiContext.DefaultConf.GetAPIReleaseDate(),
+		"goVersion": runtime.Version(),
 	}
 
 	apiContext.DefaultConf.SetOnceConfig()
 	config := apiContext.APIConfiguration
 
 	Context("When version and date are requested", func() {
-		It("Should return a map with API version and date", func() {
+		It("Should return a map with API version, commit, build date and Go version", func() {
 			Expect(routes.GetRequestResult(config)).To(Equal(expected))
 		})
 	})
 
+	Context("When it was not built with -ldflags", func() {
+		It("Should return dev ones", func() {
+			Expect(routes.GetRequestResult(config)).To(HaveKeyWithValue("version", "dev"))
+			Expect(routes.GetRequestResult(config)).To(HaveKeyWithValue("commit", "dev"))
+		})
+	})
+
 })
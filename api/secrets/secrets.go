@@ -0,0 +1,101 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+)
+
+// SecretsProvider provides the secrets of huskyCI, such as its SSH key or database password,
+// by the name of the environment variable huskyCI reads them from.
+type SecretsProvider interface {
+	// Names returns the environment variables whose secrets it provides.
+	Names() []string
+	// Secret returns the secret of the environment variable name.
+	Secret(name string) (string, error)
+}
+
+// EnvProvider provides secrets that are already set to environment variables, which is
+// how huskyCI is configured when Vault is not.
+type EnvProvider struct{}
+
+// Names returns no environment variable, as there is nothing to set.
+func (EnvProvider) Names() []string {
+	return nil
+}
+
+// Secret returns the environment variable name.
+func (EnvProvider) Secret(name string) (string, error) {
+	return os.Getenv(name), nil
+}
+
+// Export sets every secret of provider to its environment variable, so the configuration
+// of huskyCI reads it as if it was set by the environment.
+func Export(provider SecretsProvider) error {
+	for _, name := range provider.Names() {
+		secret, err := provider.Secret(name)
+		if err != nil {
+			return err
+		}
+		if err := os.Setenv(name, secret); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Environment variables that configure Vault.
+const (
+	VaultAddrEnv        = "HUSKYCI_VAULT_ADDR"
+	VaultTokenEnv       = "HUSKYCI_VAULT_TOKEN"
+	VaultSecretPathsEnv = "HUSKYCI_VAULT_SECRET_PATHS"
+	VaultRequiredEnv    = "HUSKYCI_VAULT_REQUIRED"
+)
+
+// NewProvider returns the SecretsProvider configured by the environment: a VaultClient if
+// HUSKYCI_VAULT_SECRET_PATHS is set, a JSON map of environment variable to Vault path,
+// or EnvProvider otherwise.
+func NewProvider() (SecretsProvider, error) {
+	rawPaths := os.Getenv(VaultSecretPathsEnv)
+	if strings.TrimSpace(rawPaths) == "" {
+		return EnvProvider{}, nil
+	}
+	paths := map[string]string{}
+	if err := json.Unmarshal([]byte(rawPaths), &paths); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", VaultSecretPathsEnv, err)
+	}
+	return NewVaultClient(os.Getenv(VaultAddrEnv), os.Getenv(VaultTokenEnv), paths), nil
+}
+
+// LoadSecrets exports the secrets of the SecretsProvider of the environment and returns
+// it. If they can not be read, such as when Vault is unavailable, huskyCI falls back to
+// its environment variables unless HUSKYCI_VAULT_REQUIRED is true.
+func LoadSecrets() (SecretsProvider, error) {
+	provider, err := NewProvider()
+	if err == nil {
+		err = Export(provider)
+	}
+	if err != nil {
+		if strings.EqualFold(os.Getenv(VaultRequiredEnv), "true") {
+			return nil, err
+		}
+		fmt.Println("Could not read secrets from Vault, using environment variables: ", err)
+		return EnvProvider{}, nil
+	}
+	return provider, nil
+}
+
+func sortedNames(paths map[string]string) []string {
+	names := []string{}
+	for name := range paths {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
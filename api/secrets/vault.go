@@ -0,0 +1,151 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package secrets
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// defaultSecretKey is the key of a Vault secret read by a path without "#key".
+const defaultSecretKey = "value"
+
+// renewRetryInterval is how long RenewToken waits to try again after failing to renew.
+const renewRetryInterval = time.Minute
+
+// VaultClient reads secrets from HashiCorp Vault through its HTTP API. Each environment
+// variable of Paths is read from a path such as "secret/data/huskyci#sshKey", the key of
+// the secret being "value" if it is not given. Both versions of the KV engine are supported.
+type VaultClient struct {
+	Address string
+	Token   string
+	Paths   map[string]string
+	Client  *http.Client
+}
+
+// NewVaultClient returns a VaultClient of the Vault at address authenticated by token.
+func NewVaultClient(address, token string, paths map[string]string) *VaultClient {
+	return &VaultClient{
+		Address: strings.TrimSuffix(address, "/"),
+		Token:   token,
+		Paths:   paths,
+		Client:  &http.Client{Timeout: 10 * time.Second, Transport: util.ProxiedTransport()},
+	}
+}
+
+// Names returns the environment variables of its Paths.
+func (vc *VaultClient) Names() []string {
+	return sortedNames(vc.Paths)
+}
+
+// Secret reads the secret of the environment variable name from its Vault path.
+func (vc *VaultClient) Secret(name string) (string, error) {
+	path, ok := vc.Paths[name]
+	if !ok {
+		return "", fmt.Errorf("no Vault path for %s", name)
+	}
+	key := defaultSecretKey
+	if separator := strings.LastIndex(path, "#"); separator >= 0 {
+		path, key = path[:separator], path[separator+1:]
+	}
+	response := struct {
+		Data map[string]interface{} `json:"data"`
+	}{}
+	if err := vc.do(http.MethodGet, "/v1/"+strings.TrimPrefix(path, "/"), &response); err != nil {
+		return "", err
+	}
+	data := response.Data
+	// the KV engine version 2 nests the secret along with its metadata
+	if nested, ok := data["data"].(map[string]interface{}); ok {
+		if _, versioned := data["metadata"]; versioned {
+			data = nested
+		}
+	}
+	value, ok := data[key].(string)
+	if !ok {
+		return "", fmt.Errorf("Vault secret %s has no key %s", path, key)
+	}
+	return value, nil
+}
+
+// tokenLease is how long the token of a VaultClient is valid for and whether it can be renewed.
+type tokenLease struct {
+	TTL       time.Duration
+	Renewable bool
+}
+
+// lookupToken returns the lease of its token.
+func (vc *VaultClient) lookupToken() (tokenLease, error) {
+	response := struct {
+		Data struct {
+			TTL       int64 `json:"ttl"`
+			Renewable bool  `json:"renewable"`
+		} `json:"data"`
+	}{}
+	if err := vc.do(http.MethodGet, "/v1/auth/token/lookup-self", &response); err != nil {
+		return tokenLease{}, err
+	}
+	return tokenLease{TTL: time.Duration(response.Data.TTL) * time.Second, Renewable: response.Data.Renewable}, nil
+}
+
+// renewToken renews its token and returns its new lease.
+func (vc *VaultClient) renewToken() (tokenLease, error) {
+	response := struct {
+		Auth struct {
+			LeaseDuration int64 `json:"lease_duration"`
+			Renewable     bool  `json:"renewable"`
+		} `json:"auth"`
+	}{}
+	if err := vc.do(http.MethodPost, "/v1/auth/token/renew-self", &response); err != nil {
+		return tokenLease{}, err
+	}
+	return tokenLease{TTL: time.Duration(response.Auth.LeaseDuration) * time.Second, Renewable: response.Auth.Renewable}, nil
+}
+
+// RenewToken renews its token once half of its lease passed, for as long as it is
+// renewable, until stop is closed. Tokens that never expire are not renewed.
+func (vc *VaultClient) RenewToken(stop <-chan struct{}) {
+	lease, err := vc.lookupToken()
+	for {
+		wait := renewRetryInterval
+		if err != nil {
+			log.Error("RenewToken", "VAULT", 2035, err)
+		} else if !lease.Renewable || lease.TTL <= 0 {
+			return
+		} else {
+			log.Info("RenewToken", "VAULT", 43, lease.TTL)
+			wait = lease.TTL / 2
+		}
+		select {
+		case <-stop:
+			return
+		case <-time.After(wait):
+		}
+		lease, err = vc.renewToken()
+	}
+}
+
+func (vc *VaultClient) do(method, path string, response interface{}) error {
+	req, err := http.NewRequest(method, vc.Address+path, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("X-Vault-Token", vc.Token)
+	resp, err := vc.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("Vault returned status code %d for %s", resp.StatusCode, path)
+	}
+	return json.NewDecoder(resp.Body).Decode(response)
+}
@@ -0,0 +1,134 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package secrets_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/secrets"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("VaultClient", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	var server *httptest.Server
+	var renewals int32
+	BeforeEach(func() {
+		atomic.StoreInt32(&renewals, 0)
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Vault-Token") != "s.token" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			switch r.URL.Path {
+			case "/v1/secret/data/huskyci":
+				w.Write([]byte(`{"data": {"data": {"sshKey": "private key"}, "metadata": {"version": 1}}}`))
+			case "/v1/kv/huskyci/mongo":
+				w.Write([]byte(`{"data": {"value": "mongo password"}}`))
+			case "/v1/auth/token/lookup-self":
+				w.Write([]byte(`{"data": {"ttl": 1, "renewable": true}}`))
+			case "/v1/auth/token/renew-self":
+				atomic.AddInt32(&renewals, 1)
+				w.Write([]byte(`{"auth": {"lease_duration": 1, "renewable": false}}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}))
+	})
+	AfterEach(func() {
+		server.Close()
+	})
+
+	Describe("Secret", func() {
+		vaultClient := func(token string) *secrets.VaultClient {
+			return secrets.NewVaultClient(server.URL+"/", token, map[string]string{
+				"HUSKYCI_API_GIT_PRIVATE_SSH_KEY": "secret/data/huskyci#sshKey",
+				"HUSKYCI_DATABASE_DB_PASSWORD":    "kv/huskyci/mongo",
+				"HUSKYCI_API_GIT_HTTPS_TOKEN":     "secret/data/huskyci#httpsToken",
+			})
+		}
+
+		It("Should read the key of a secret of the KV engine version 2", func() {
+			Expect(vaultClient("s.token").Secret("HUSKYCI_API_GIT_PRIVATE_SSH_KEY")).To(Equal("private key"))
+		})
+
+		It("Should read the value key of a secret of the KV engine version 1 by default", func() {
+			Expect(vaultClient("s.token").Secret("HUSKYCI_DATABASE_DB_PASSWORD")).To(Equal("mongo password"))
+		})
+
+		It("Should return an error if the secret has no such key", func() {
+			_, err := vaultClient("s.token").Secret("HUSKYCI_API_GIT_HTTPS_TOKEN")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("Should return an error if Vault refuses its token", func() {
+			_, err := vaultClient("s.other").Secret("HUSKYCI_DATABASE_DB_PASSWORD")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("RenewToken", func() {
+		It("Should renew a renewable token until it is not renewable anymore", func() {
+			vaultClient := secrets.NewVaultClient(server.URL, "s.token", nil)
+			vaultClient.RenewToken(make(chan struct{}))
+			Expect(atomic.LoadInt32(&renewals)).To(Equal(int32(1)))
+		})
+	})
+
+	Describe("LoadSecrets", func() {
+		AfterEach(func() {
+			for _, env := range []string{secrets.VaultAddrEnv, secrets.VaultTokenEnv, secrets.VaultSecretPathsEnv, secrets.VaultRequiredEnv, "HUSKYCI_DATABASE_DB_PASSWORD"} {
+				os.Unsetenv(env)
+			}
+		})
+
+		Context("When no Vault path is set", func() {
+			It("Should use the environment variables", func() {
+				provider, err := secrets.LoadSecrets()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(provider).To(Equal(secrets.EnvProvider{}))
+			})
+		})
+
+		Context("When Vault paths are set", func() {
+			It("Should set their secrets to their environment variables", func() {
+				os.Setenv(secrets.VaultAddrEnv, server.URL)
+				os.Setenv(secrets.VaultTokenEnv, "s.token")
+				os.Setenv(secrets.VaultSecretPathsEnv, `{"HUSKYCI_DATABASE_DB_PASSWORD": "kv/huskyci/mongo"}`)
+				provider, err := secrets.LoadSecrets()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(provider).To(BeAssignableToTypeOf(&secrets.VaultClient{}))
+				Expect(os.Getenv("HUSKYCI_DATABASE_DB_PASSWORD")).To(Equal("mongo password"))
+			})
+		})
+
+		Context("When Vault is unavailable", func() {
+			BeforeEach(func() {
+				os.Setenv(secrets.VaultAddrEnv, "http://127.0.0.1:1")
+				os.Setenv(secrets.VaultSecretPathsEnv, `{"HUSKYCI_DATABASE_DB_PASSWORD": "kv/huskyci/mongo"}`)
+			})
+
+			It("Should fall back to the environment variables", func() {
+				provider, err := secrets.LoadSecrets()
+				Expect(err).ToNot(HaveOccurred())
+				Expect(provider).To(Equal(secrets.EnvProvider{}))
+			})
+
+			It("Should return an error if Vault is required", func() {
+				os.Setenv(secrets.VaultRequiredEnv, "true")
+				_, err := secrets.LoadSecrets()
+				Expect(err).To(HaveOccurred())
+			})
+		})
+	})
+})
@@ -0,0 +1,29 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// advisoryDBURLPlaceholder is replaced in a cmd by the quoted mirror of the advisory
+// database of its securityTest or, if it uses its public database, by an empty string.
+const advisoryDBURLPlaceholder = "%ADVISORY_DB_URL%"
+
+// handleAdvisoryDBURL replaces %ADVISORY_DB_URL% in cmd by the mirror of a given
+// securityTest in advisoryDBURLs, so a cmd can check whether it is set.
+func handleAdvisoryDBURL(cmd, securityTestName string, advisoryDBURLs map[string]string) string {
+	return strings.Replace(cmd, advisoryDBURLPlaceholder, util.ShellQuote(advisoryDBURLs[securityTestName]), -1)
+}
+
+func advisoryDBURLs() map[string]string {
+	if apiContext.APIConfiguration == nil {
+		return nil
+	}
+	return apiContext.APIConfiguration.AdvisoryDBURLs
+}
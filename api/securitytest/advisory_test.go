@@ -0,0 +1,38 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Advisory database", func() {
+
+	cmd := `advisoryDB=%ADVISORY_DB_URL%
+npm audit --json ${advisoryDB:+--registry "$advisoryDB"}`
+	advisoryDBURLs := map[string]string{
+		"npmaudit": "https://npm.example.com/registry",
+		"safety":   "https://mirror.example.com/safety?token=it's",
+	}
+
+	Describe("HandleAdvisoryDBURL", func() {
+		Context("When the securityTest has a mirror", func() {
+			It("Should replace the placeholder by its quoted URL", func() {
+				Expect(securitytest.HandleAdvisoryDBURL(cmd, "npmaudit", advisoryDBURLs)).To(Equal(`advisoryDB='https://npm.example.com/registry'
+npm audit --json ${advisoryDB:+--registry "$advisoryDB"}`))
+				Expect(securitytest.HandleAdvisoryDBURL("db=%ADVISORY_DB_URL%", "safety", advisoryDBURLs)).To(Equal(`db='https://mirror.example.com/safety?token=it'\''s'`))
+			})
+		})
+		Context("When the securityTest uses its public database", func() {
+			It("Should replace the placeholder by an empty string", func() {
+				Expect(securitytest.HandleAdvisoryDBURL(cmd, "yarnaudit", advisoryDBURLs)).To(HavePrefix("advisoryDB=''\n"))
+				Expect(securitytest.HandleAdvisoryDBURL(cmd, "npmaudit", nil)).To(HavePrefix("advisoryDB=''\n"))
+			})
+		})
+	})
+})
@@ -5,7 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
 	"strconv"
 
 	"github.com/globocom/huskyCI/api/log"
@@ -36,10 +35,9 @@ func analyzeBandit(banditScan *SecTestScanInfo) error {
 	banditOutput := BanditOutput{}
 
 	// Unmarshall rawOutput into finalOutput, that is a Bandit struct.
-	if err := json.Unmarshal([]byte(banditScan.Container.COutput), &banditOutput); err != nil {
+	if err := banditScan.unmarshalOutput(&banditOutput); err != nil {
 		log.Error("analyzeBandit", "BANDIT", 1006, banditScan.Container.COutput, err)
-		banditScan.ErrorFound = err
-		return err
+		return nil
 	}
 	banditScan.FinalOutput = banditOutput
 
@@ -69,11 +67,17 @@ func (banditScan *SecTestScanInfo) prepareBanditVulns() {
 		}
 		banditVuln.Severity = issue.IssueSeverity
 		banditVuln.Confidence = issue.IssueConfidence
+		banditVuln.RuleID = issue.TestID
 		banditVuln.Details = issue.IssueText
 		banditVuln.File = issue.Filename
 		banditVuln.Line = strconv.Itoa(issue.LineNumber)
 		banditVuln.Code = issue.Code
 
+		// findings below the configured confidence are only warnings
+		if banditVuln.Severity != "NOSEC" && belowMinConfidence(banditScan.SecurityTestName, banditVuln.Confidence) {
+			huskyCIbanditResults.LowVulns = append(huskyCIbanditResults.LowVulns, banditVuln)
+			continue
+		}
 		switch banditVuln.Severity {
 		case "NOSEC":
 			huskyCIbanditResults.NoSecVulns = append(huskyCIbanditResults.NoSecVulns, banditVuln)
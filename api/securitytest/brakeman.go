@@ -5,7 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
 	"strconv"
 
 	"github.com/globocom/huskyCI/api/log"
@@ -14,7 +13,13 @@ import (
 
 // BrakemanOutput is the struct that holds issues and stats found on a Brakeman scan.
 type BrakemanOutput struct {
-	Warnings []WarningItem `json:"warnings"`
+	ScanInfo BrakemanScanInfo `json:"scan_info"`
+	Warnings []WarningItem    `json:"warnings"`
+}
+
+// BrakemanScanInfo is the struct that holds information about the Brakeman scan itself.
+type BrakemanScanInfo struct {
+	BrakemanVersion string `json:"brakeman_version"`
 }
 
 // WarningItem is the struct that holds all detailed information of a vulnerability found.
@@ -38,12 +43,12 @@ func analyzeBrakeman(brakemanScan *SecTestScanInfo) error {
 		return nil
 	}
 	// Unmarshall rawOutput into finalOutput, that is a Brakeman struct.
-	if err := json.Unmarshal([]byte(brakemanScan.Container.COutput), &brakemanOutput); err != nil {
+	if err := brakemanScan.unmarshalOutput(&brakemanOutput); err != nil {
 		log.Error("analyzeBrakeman", "BRAKEMAN", 1005, brakemanScan.Container.COutput, err)
-		brakemanScan.ErrorFound = err
-		return err
+		return nil
 	}
 	brakemanScan.FinalOutput = brakemanOutput
+	brakemanScan.Container.ScannerVersion = brakemanOutput.ScanInfo.BrakemanVersion
 
 	// check results and prepare all vulnerabilities found
 	brakemanScan.prepareBrakemanVulns()
@@ -67,6 +72,11 @@ func (brakemanScan *SecTestScanInfo) prepareBrakemanVulns() {
 		brakemanVuln.Code = warning.Code
 		brakemanVuln.Type = warning.Type
 
+		// Brakeman ranks warnings by confidence, so those below the configured one are only warnings
+		if belowMinConfidence(brakemanScan.SecurityTestName, brakemanVuln.Confidence) {
+			huskyCIbrakemanResults.LowVulns = append(huskyCIbrakemanResults.LowVulns, brakemanVuln)
+			continue
+		}
 		switch brakemanVuln.Confidence {
 		case "High":
 			huskyCIbrakemanResults.HighVulns = append(huskyCIbrakemanResults.HighVulns, brakemanVuln)
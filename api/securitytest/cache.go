@@ -0,0 +1,152 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// commitPrefix starts the line of the enry output with the commit SHA it scanned,
+// such as "HUSKYCI_COMMIT=9fceb02d0ae598e95dc970b74767f19372d61af8".
+const commitPrefix = "HUSKYCI_COMMIT="
+
+var commitRegexp = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// cacheKeyVersion is hashed along with every cache key, so changing it invalidates them all.
+const cacheKeyVersion = "huskyci-scan-cache-v1"
+
+// extractCommit returns the commit SHA printed in the first line of cOutput, if it is a valid
+// one, and cOutput without that line. Code scanned from a tarball has no commit.
+func extractCommit(cOutput string) (string, string) {
+	if !strings.HasPrefix(cOutput, commitPrefix) {
+		return "", cOutput
+	}
+	line, rest := cOutput, ""
+	if newLine := strings.Index(cOutput, "\n"); newLine >= 0 {
+		line, rest = cOutput[:newLine], cOutput[newLine+1:]
+	}
+	commit := strings.TrimSpace(strings.TrimPrefix(line, commitPrefix))
+	if !commitRegexp.MatchString(commit) {
+		return "", rest
+	}
+	return commit, rest
+}
+
+// cacheKeyPayload is everything the findings of a scan depend on: the code scanned, the
+// securityTest image, cmd and environment that scanned it and the settings that filter its
+// findings or decide whether they fail it.
+type cacheKeyPayload struct {
+	URL               string                   `json:"repositoryURL"`
+	Commit            string                   `json:"commit"`
+	SecurityTest      string                   `json:"securityTest"`
+	Cmd               string                   `json:"cmd"`
+	ImageDigest       string                   `json:"imageDigest"`
+	Subpath           string                   `json:"subpath"`
+	BaseCommit        string                   `json:"baseCommit"`
+	ExcludedPaths     []string                 `json:"excludedPaths"`
+	IgnoredIDs        []string                 `json:"ignoredIDs"`
+	GitleaksAllowlist *types.GitleaksAllowlist `json:"gitleaksAllowlist"`
+	NpmAuditFailOn    string                   `json:"npmAuditFailOn"`
+	// Suppressions and the fields below are omitted if empty, so they do not change the keys
+	// of existing caches.
+	Suppressions []string `json:"suppressions,omitempty"`
+	// ExtraEnv has the values of the variables too, as they may change what is scanned. It is
+	// only ever hashed, so they are not stored.
+	ExtraEnv                    map[string]string `json:"extraEnv,omitempty"`
+	BlockingMode                string            `json:"blockingMode,omitempty"`
+	DependencyCheckFailSeverity string            `json:"dependencyCheckFailSeverity,omitempty"`
+	GenericFailSeverity         string            `json:"genericFailSeverity,omitempty"`
+}
+
+// cacheKey returns the key of the findings of a scan of its commit by the image of imageDigest.
+func (scanInfo *SecTestScanInfo) cacheKey(imageDigest string) string {
+	payload, _ := json.Marshal(cacheKeyPayload{
+		URL:               scanInfo.URL,
+		Commit:            scanInfo.Commit,
+		SecurityTest:      scanInfo.SecurityTestName,
+		Cmd:               scanInfo.Container.SecurityTest.Cmd,
+		ImageDigest:       imageDigest,
+		Subpath:           scanInfo.Subpath,
+		BaseCommit:        scanInfo.BaseCommit,
+		ExcludedPaths:     scanInfo.ExcludedPaths,
+		IgnoredIDs:        ignoredIDs(scanInfo.SecurityTestName, scanInfo.Ignores, time.Now()),
+		Suppressions:      scanInfo.Suppressions,
+		GitleaksAllowlist: scanInfo.GitleaksAllowlist,
+		NpmAuditFailOn:    scanInfo.NpmAuditFailOn,
+		ExtraEnv:          scanInfo.ExtraEnv,
+		BlockingMode:      scanInfo.Container.SecurityTest.BlockingMode,
+		// both are hashed, whatever the parser, as custom securityTests may use either
+		DependencyCheckFailSeverity: apiContext.APIConfiguration.DependencyCheckFailSeverity,
+		GenericFailSeverity:         apiContext.APIConfiguration.GenericFailSeverity,
+	})
+	sum := sha256.Sum256(append([]byte(cacheKeyVersion), payload...))
+	return hex.EncodeToString(sum[:])
+}
+
+// isCacheable returns whether the findings of a scan can be cached: HUSKYCI_SCAN_CACHE is
+// set, the request did not force a new scan and its commit is known.
+func (scanInfo *SecTestScanInfo) isCacheable() bool {
+	return apiContext.APIConfiguration.ScanCache && !scanInfo.Force && scanInfo.Commit != "" && scanInfo.SecurityTestName != "gitauthors"
+}
+
+// loadCachedScan reuses the container and findings of a finished analysis with the cache key
+// of a scan, if it passed or failed, and returns whether it did so instead of running it. The
+// image of its securityTest must be loaded already, so its digest is known before it runs.
+func (scanInfo *SecTestScanInfo) loadCachedScan() bool {
+	if !scanInfo.isCacheable() {
+		return false
+	}
+	securityTest := scanInfo.Container.SecurityTest
+	imageDigest, err := huskydocker.ImageDigest(securityTest.Image, securityTest.ImageTag)
+	if err != nil || imageDigest == "" {
+		return false
+	}
+	cacheKey := scanInfo.cacheKey(imageDigest)
+	analysisQuery := map[string]interface{}{"containers.cacheKey": cacheKey, "status": "finished"}
+	cachedAnalysis, err := apiContext.APIConfiguration.DBInstance.FindOneDBAnalysis(analysisQuery)
+	if err != nil {
+		return false
+	}
+	for _, container := range cachedAnalysis.Containers {
+		if container.CacheKey != cacheKey || container.Findings == nil || !isCacheableResult(container.CResult) {
+			continue
+		}
+		log.Info("loadCachedScan", "SECURITYTEST", 38, scanInfo.SecurityTestName, cachedAnalysis.RID)
+		now := time.Now()
+		container.CID = ""
+		container.Cached = true
+		container.StartedAt, container.FinishedAt, container.DurationMs = now, now, 0
+		container.Retries = 0
+		scanInfo.Container = container
+		scanInfo.Vulnerabilities = *container.Findings
+		return true
+	}
+	return false
+}
+
+// storeCacheFindings keeps the findings of a scan in its container along with its cache key,
+// so a later scan can reuse them. Scans that did not finish, or could not be parsed, are not cached.
+func (scanInfo *SecTestScanInfo) storeCacheFindings() {
+	if !scanInfo.isCacheable() || scanInfo.Container.ImageDigest == "" || !isCacheableResult(scanInfo.Container.CResult) {
+		return
+	}
+	scanInfo.Container.CacheKey = scanInfo.cacheKey(scanInfo.Container.ImageDigest)
+	findings := scanInfo.Vulnerabilities
+	scanInfo.Container.Findings = &findings
+}
+
+func isCacheableResult(cResult string) bool {
+	return cResult == "passed" || cResult == "failed"
+}
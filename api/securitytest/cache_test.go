@@ -0,0 +1,152 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Scan cache", func() {
+
+	commit := "9fceb02d0ae598e95dc970b74767f19372d61af8"
+	digest := "huskyci/gosec@sha256:3b3a1e2c4d4f5a9b8c7d6e5f4a3b2c1d0e9f8a7b6c5d4e3f2a1b0c9d8e7f6a5b"
+	newScan := func() securitytest.SecTestScanInfo {
+		scanInfo := securitytest.SecTestScanInfo{
+			SecurityTestName: "gosec",
+			URL:              "https://github.com/globocom/huskyCI.git",
+			Commit:           commit,
+		}
+		scanInfo.Container.SecurityTest.Cmd = "gosec ./..."
+		return scanInfo
+	}
+
+	var previousConfig *apiContext.APIConfig
+	BeforeEach(func() {
+		previousConfig = apiContext.APIConfiguration
+		apiContext.APIConfiguration = &apiContext.APIConfig{ScanCache: true}
+	})
+	AfterEach(func() {
+		apiContext.APIConfiguration = previousConfig
+	})
+
+	Describe("ExtractCommit", func() {
+		Context("When the output starts with a commit SHA", func() {
+			It("Should return it and the rest of the output", func() {
+				extractedCommit, rest := securitytest.ExtractCommit("HUSKYCI_COMMIT=" + commit + "\n{\"Go\":[\"main.go\"]}")
+				Expect(extractedCommit).To(Equal(commit))
+				Expect(rest).To(Equal(`{"Go":["main.go"]}`))
+			})
+		})
+		Context("When the code has no commit, such as a tarball", func() {
+			It("Should return an empty commit and the rest of the output", func() {
+				extractedCommit, rest := securitytest.ExtractCommit("HUSKYCI_COMMIT=\n{}")
+				Expect(extractedCommit).To(BeEmpty())
+				Expect(rest).To(Equal("{}"))
+			})
+		})
+	})
+
+	Describe("CacheKey", func() {
+		Context("When the commit, image and settings are the same", func() {
+			It("Should return the same key", func() {
+				scanInfo, otherScan := newScan(), newScan()
+				Expect(scanInfo.CacheKey(digest)).To(Equal(otherScan.CacheKey(digest)))
+			})
+		})
+		Context("When the commit, image or settings change", func() {
+			It("Should return another key", func() {
+				baseScan := newScan()
+				baseKey := baseScan.CacheKey(digest)
+				mutations := map[string]func(scanInfo *securitytest.SecTestScanInfo){
+					"commit": func(scanInfo *securitytest.SecTestScanInfo) {
+						scanInfo.Commit = "0000000000000000000000000000000000000000"
+					},
+					"cmd": func(scanInfo *securitytest.SecTestScanInfo) {
+						scanInfo.Container.SecurityTest.Cmd = "gosec -quiet ./..."
+					},
+					"subpath":       func(scanInfo *securitytest.SecTestScanInfo) { scanInfo.Subpath = "services/api" },
+					"excludedPaths": func(scanInfo *securitytest.SecTestScanInfo) { scanInfo.ExcludedPaths = []string{"vendor"} },
+					"ignores": func(scanInfo *securitytest.SecTestScanInfo) {
+						scanInfo.Ignores = []types.Ignore{{SecurityTest: "gosec", ID: "G104"}}
+					},
+					"suppressions": func(scanInfo *securitytest.SecTestScanInfo) {
+						scanInfo.Suppressions = []string{"0b5c3e7f"}
+					},
+					"extraEnv": func(scanInfo *securitytest.SecTestScanInfo) {
+						scanInfo.ExtraEnv = map[string]string{"GOFLAGS": "-mod=vendor"}
+					},
+					"blockingMode": func(scanInfo *securitytest.SecTestScanInfo) {
+						scanInfo.Container.SecurityTest.BlockingMode = securitytest.BlockingModeWarning
+					},
+				}
+				for setting, mutate := range mutations {
+					scanInfo := newScan()
+					mutate(&scanInfo)
+					Expect(scanInfo.CacheKey(digest)).ToNot(Equal(baseKey), setting)
+				}
+				scanInfo := newScan()
+				Expect(scanInfo.CacheKey("huskyci/gosec@sha256:another")).ToNot(Equal(baseKey))
+			})
+			It("Should return another key when a value of ExtraEnv changes", func() {
+				scanInfo, otherScan := newScan(), newScan()
+				scanInfo.ExtraEnv = map[string]string{"GOFLAGS": "-mod=vendor"}
+				otherScan.ExtraEnv = map[string]string{"GOFLAGS": "-mod=mod"}
+				Expect(scanInfo.CacheKey(digest)).ToNot(Equal(otherScan.CacheKey(digest)))
+			})
+			It("Should return another key when a fail severity changes", func() {
+				baseScan := newScan()
+				baseKey := baseScan.CacheKey(digest)
+				apiContext.APIConfiguration.GenericFailSeverity = "LOW"
+				Expect(baseScan.CacheKey(digest)).ToNot(Equal(baseKey))
+				apiContext.APIConfiguration.GenericFailSeverity = ""
+				apiContext.APIConfiguration.DependencyCheckFailSeverity = "HIGH"
+				Expect(baseScan.CacheKey(digest)).ToNot(Equal(baseKey))
+			})
+		})
+	})
+
+	Describe("StoreCacheFindings", func() {
+		findings := types.HuskyCISecurityTestOutput{
+			HighVulns: []types.HuskyCIVulnerability{{SecurityTool: "GoSec", File: "main.go", RuleID: "G101"}},
+		}
+		Context("When a scan of a known commit failed", func() {
+			It("Should store its findings along with its cache key", func() {
+				scanInfo := newScan()
+				scanInfo.Container.ImageDigest = digest
+				scanInfo.Container.CResult = "failed"
+				scanInfo.Vulnerabilities = findings
+				scanInfo.StoreCacheFindings()
+				Expect(scanInfo.Container.CacheKey).To(Equal(scanInfo.CacheKey(digest)))
+				Expect(scanInfo.Container.Findings).To(Equal(&findings))
+			})
+		})
+		Context("When the scan did not finish, was forced or the cache is not enabled", func() {
+			It("Should not cache it", func() {
+				scans := map[string]func(scanInfo *securitytest.SecTestScanInfo){
+					"error":    func(scanInfo *securitytest.SecTestScanInfo) { scanInfo.Container.CResult = "error" },
+					"timedout": func(scanInfo *securitytest.SecTestScanInfo) { scanInfo.Container.CResult = "timedout" },
+					"forced":   func(scanInfo *securitytest.SecTestScanInfo) { scanInfo.Force = true },
+					"tarball":  func(scanInfo *securitytest.SecTestScanInfo) { scanInfo.Commit = "" },
+					"disabled": func(scanInfo *securitytest.SecTestScanInfo) { apiContext.APIConfiguration.ScanCache = false },
+				}
+				for reason, mutate := range scans {
+					apiContext.APIConfiguration.ScanCache = true
+					scanInfo := newScan()
+					scanInfo.Container.ImageDigest = digest
+					scanInfo.Container.CResult = "passed"
+					mutate(&scanInfo)
+					scanInfo.StoreCacheFindings()
+					Expect(scanInfo.Container.CacheKey).To(BeEmpty(), reason)
+					Expect(scanInfo.Container.Findings).To(BeNil(), reason)
+				}
+			})
+		})
+	})
+})
@@ -0,0 +1,93 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// cloneErrorMarker starts the output of a securityTest that could not clone the repository.
+const cloneErrorMarker = "ERROR_CLONING"
+
+// cloneErrorPlaceholder is replaced in a cmd by cloneErrorCmd. It reads the stderr of a
+// failed clone from its stdin, such as "%CLONE_ERROR% < /tmp/errorGitClone", and prints
+// cloneErrorMarker followed by its CloneError:
+//
+//	ERROR_CLONING:{"code":"auth_failed","stderr":"fatal: Authentication failed for ..."}
+const cloneErrorPlaceholder = "%CLONE_ERROR%"
+
+// cloneErrorCmd classifies the git stderr it reads by its messages. The stderr is printed in a
+// single line with its quotes and backslashes escaped, so it is a valid JSON string.
+const cloneErrorCmd = `{ huskyciStderr=$(cat); huskyciCode=unknown
+  case "$huskyciStderr" in
+    *"Authentication failed"*|*"could not read Username"*|*"Permission denied"*|*"Access denied"*) huskyciCode=auth_failed ;;
+    *"Remote branch"*"not found"*|*"did not match any"*) huskyciCode=branch_not_found ;;
+    *"not found"*|*"does not appear to be a git repository"*|*"does not exist"*) huskyciCode=repo_not_found ;;
+    *"timed out"*|*"Timeout"*) huskyciCode=timeout ;;
+    *"Could not resolve host"*|*"Connection refused"*|*"unreachable"*|*"No route to host"*|*"Failed to connect"*) huskyciCode=host_unreachable ;;
+  esac
+  printf 'ERROR_CLONING:{"code":"%s","stderr":"%s"}\n' "$huskyciCode" "$(printf '%s' "$huskyciStderr" | tr '\n\r\t' '   ' | tr -d '\000-\037' | sed 's/\\/\\\\/g; s/"/\\"/g')"; }`
+
+const (
+	// CloneErrorAuthFailed is the code of a clone denied to the credentials of huskyCI.
+	CloneErrorAuthFailed = "auth_failed"
+	// CloneErrorRepoNotFound is the code of a clone of a repository that does not exist.
+	CloneErrorRepoNotFound = "repo_not_found"
+	// CloneErrorBranchNotFound is the code of a clone of a branch that does not exist.
+	CloneErrorBranchNotFound = "branch_not_found"
+	// CloneErrorTimeout is the code of a clone whose connection timed out.
+	CloneErrorTimeout = "timeout"
+	// CloneErrorHostUnreachable is the code of a clone whose git host could not be reached.
+	CloneErrorHostUnreachable = "host_unreachable"
+	// CloneErrorUnknown is the code of any other clone error, including the ones of cmds
+	// that only print the legacy ERROR_CLONING marker.
+	CloneErrorUnknown = "unknown"
+)
+
+var cloneErrorMessages = map[string]string{
+	CloneErrorAuthFailed:      "could not clone the repository: authentication failed, check huskyCI has access to it",
+	CloneErrorRepoNotFound:    "could not clone the repository: repository not found",
+	CloneErrorBranchNotFound:  "could not clone the repository: branch not found",
+	CloneErrorTimeout:         "could not clone the repository: connection timed out",
+	CloneErrorHostUnreachable: "could not clone the repository: git host is unreachable",
+}
+
+// CloneError is why a securityTest could not clone the repository it scans.
+type CloneError struct {
+	Code   string `json:"code"`
+	Stderr string `json:"stderr"`
+}
+
+// Error returns the message shown to users for the code of cloneErr.
+func (cloneErr *CloneError) Error() string {
+	if message, ok := cloneErrorMessages[cloneErr.Code]; ok {
+		return message
+	}
+	return "could not clone the repository"
+}
+
+// ParseCloneError returns the CloneError printed in cOutput, if any. An output with only
+// the legacy ERROR_CLONING marker, followed by the stderr of git, has an unknown code.
+func ParseCloneError(cOutput string) (*CloneError, bool) {
+	index := strings.Index(cOutput, cloneErrorMarker)
+	if index < 0 {
+		return nil, false
+	}
+	rest := cOutput[index+len(cloneErrorMarker):]
+	if strings.HasPrefix(rest, ":") {
+		line := strings.SplitN(rest[1:], "\n", 2)[0]
+		cloneErr := CloneError{}
+		if err := json.Unmarshal([]byte(line), &cloneErr); err == nil && cloneErr.Code != "" {
+			return &cloneErr, true
+		}
+	}
+	return &CloneError{Code: CloneErrorUnknown, Stderr: strings.TrimSpace(rest)}, true
+}
+
+// handleCloneError replaces %CLONE_ERROR% in cmd by cloneErrorCmd.
+func handleCloneError(cmd string) string {
+	return strings.Replace(cmd, cloneErrorPlaceholder, cloneErrorCmd, -1)
+}
@@ -0,0 +1,62 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Clone errors", func() {
+
+	Describe("ParseCloneError", func() {
+		Context("When the output has a structured clone error", func() {
+			It("Should return its code and stderr", func() {
+				cloneErr, ok := securitytest.ParseCloneError("HUSKYCI_TOOL_VERSION=\nERROR_CLONING:{\"code\":\"branch_not_found\",\"stderr\":\"fatal: Remote branch nope not found\"}\n")
+				Expect(ok).To(BeTrue())
+				Expect(cloneErr).To(Equal(&securitytest.CloneError{Code: securitytest.CloneErrorBranchNotFound, Stderr: "fatal: Remote branch nope not found"}))
+				Expect(cloneErr.Error()).To(Equal("could not clone the repository: branch not found"))
+			})
+		})
+		Context("When the output has the legacy clone error", func() {
+			It("Should return an unknown code and the rest of the output as its stderr", func() {
+				cloneErr, ok := securitytest.ParseCloneError("ERROR_CLONING\nfatal: could not read from remote repository\n")
+				Expect(ok).To(BeTrue())
+				Expect(cloneErr).To(Equal(&securitytest.CloneError{Code: securitytest.CloneErrorUnknown, Stderr: "fatal: could not read from remote repository"}))
+				Expect(cloneErr.Error()).To(Equal("could not clone the repository"))
+			})
+		})
+		Context("When the structured clone error is malformed", func() {
+			It("Should still be recognized with an unknown code", func() {
+				cloneErr, ok := securitytest.ParseCloneError(`ERROR_CLONING:{"code":`)
+				Expect(ok).To(BeTrue())
+				Expect(cloneErr.Code).To(Equal(securitytest.CloneErrorUnknown))
+			})
+		})
+		Context("When the output has no clone error", func() {
+			It("Should return false", func() {
+				_, ok := securitytest.ParseCloneError(`{"Issues":[]}`)
+				Expect(ok).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("Analyze", func() {
+		Context("When the securityTest could not clone the repository", func() {
+			It("Should mark its container as an error telling why", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+				scanInfo.Container.COutput = `ERROR_CLONING:{"code":"auth_failed","stderr":"fatal: Authentication failed"}`
+				err := scanInfo.Analyze()
+				Expect(err).To(HaveOccurred())
+				Expect(err.Error()).To(Equal("could not clone the repository: authentication failed, check huskyCI has access to it"))
+				scanInfo.PrepareContainerAfterScan()
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+				Expect(scanInfo.Container.CInfo).To(Equal(err.Error()))
+			})
+		})
+	})
+})
@@ -0,0 +1,21 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+)
+
+// belowMinConfidence returns whether a finding of a given securityTest reported with a given
+// confidence (LOW, MEDIUM or HIGH) is below the minimum confidence configured for it, so it is
+// only a warning whatever its severity. Findings without a confidence are never below it.
+func belowMinConfidence(securityTestName, confidence string) bool {
+	if apiContext.APIConfiguration == nil || confidence == "" {
+		return false
+	}
+	minConfidence := apiContext.APIConfiguration.MinConfidence[securityTestName]
+	// confidences are ranked the same way as severities
+	return minConfidence != "" && severityRank(confidence) < severityRank(minConfidence)
+}
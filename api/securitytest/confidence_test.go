@@ -0,0 +1,82 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const gosecLowConfidenceOutput = `{
+  "Issues": [
+    {"severity": "HIGH", "confidence": "LOW", "rule_id": "G101", "details": "Potential hardcoded credentials", "file": "main.go", "code": "password := \"x\"", "line": "10"}
+  ],
+  "Stats": {"files": 1, "lines": 20, "nosec": 0, "found": 1},
+  "GosecVersion": "2.4.0"
+}`
+
+const brakemanMediumConfidenceOutput = `{
+  "scan_info": {"brakeman_version": "4.8.2"},
+  "warnings": [
+    {"warning_type": "SQL Injection", "code": "User.where(params[:q])", "message": "Possible SQL injection", "file": "app/models/user.rb", "line": 3, "link": "https://brakemanscanner.org/docs/warning_types/sql_injection/", "confidence": "Medium"}
+  ]
+}`
+
+var _ = Describe("Confidence", func() {
+
+	analyze := func(securityTestName, output string) securitytest.SecTestScanInfo {
+		scanInfo := securitytest.SecTestScanInfo{SecurityTestName: securityTestName}
+		scanInfo.Container.COutput = output
+		Expect(scanInfo.Analyze()).To(Succeed())
+		return scanInfo
+	}
+
+	var previousConfig *apiContext.APIConfig
+	BeforeEach(func() {
+		previousConfig = apiContext.APIConfiguration
+	})
+	AfterEach(func() {
+		apiContext.APIConfiguration = previousConfig
+	})
+
+	Context("When no minimum confidence is configured", func() {
+		It("Should fail on a high severity finding of low confidence", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{}
+			scanInfo := analyze("gosec", gosecLowConfidenceOutput)
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+
+	Context("When a minimum confidence is configured for the securityTest", func() {
+		It("Should only warn about a high severity finding of low confidence", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{MinConfidence: map[string]string{"gosec": "MEDIUM"}}
+			scanInfo := analyze("gosec", gosecLowConfidenceOutput)
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(BeEmpty())
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.LowVulns[0].Severity).To(Equal("HIGH"))
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+		It("Should compare the confidence of Brakeman regardless of its case", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{MinConfidence: map[string]string{"brakeman": "HIGH"}}
+			scanInfo := analyze("brakeman", brakemanMediumConfidenceOutput)
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(BeEmpty())
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+	})
+
+	Context("When a minimum confidence is configured for another securityTest", func() {
+		It("Should still fail on a high severity finding of low confidence", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{MinConfidence: map[string]string{"bandit": "HIGH"}}
+			scanInfo := analyze("gosec", gosecLowConfidenceOutput)
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+})
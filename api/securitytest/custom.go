@@ -0,0 +1,267 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"errors"
+	"regexp"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/parser"
+	"github.com/globocom/huskyCI/api/types"
+	mgo "gopkg.in/mgo.v2"
+)
+
+// maxCustomTimeOutInSeconds is the longest timeout of a custom securityTest.
+const maxCustomTimeOutInSeconds = 3600
+
+var (
+	// ErrInvalidSecurityTestName is returned when a custom securityTest name is not lowercase letters, digits, - and _.
+	ErrInvalidSecurityTestName = errors.New("name must start with a lowercase letter or digit followed by lowercase letters, digits, - or _")
+	// ErrImageNotAllowed is returned when a custom securityTest image is not in customSecurityTestImages of config.yaml.
+	ErrImageNotAllowed = errors.New("image is not allowed for custom securityTests")
+	// ErrInvalidSecurityTestCmd is returned when a custom securityTest cmd does not fetch the code or has unknown placeholders.
+	ErrInvalidSecurityTestCmd = errors.New("cmd must contain %FETCH_CODE% and only known placeholders")
+	// ErrInvalidSecurityTestTimeout is returned when a custom securityTest timeout is not between 1 and 3600 seconds.
+	ErrInvalidSecurityTestTimeout = errors.New("timeOutSeconds must be between 1 and 3600")
+	// ErrInvalidSecurityTestType is returned when a custom securityTest is not Generic or a Language one with its language.
+	ErrInvalidSecurityTestType = errors.New("type must be Generic or Language with a language")
+	// ErrInvalidDependsOn is returned when a custom securityTest depends on one that does not exist or on itself.
+	ErrInvalidDependsOn = errors.New("dependsOn must name other securityTests and not lead back to this one")
+	// ErrUnknownParser is returned when a custom securityTest output can not be parsed by any parser.
+	ErrUnknownParser = errors.New("parser must be generic or the name of a securityTest parser")
+	// ErrBuiltinSecurityTest is returned when a securityTest of config.yaml would be changed through the API.
+	ErrBuiltinSecurityTest = errors.New("securityTests of config.yaml can not be changed through the API")
+	// ErrSecurityTestAlreadyExists is returned when a custom securityTest name is taken.
+	ErrSecurityTestAlreadyExists = errors.New("securityTest already exists")
+	// ErrSecurityTestNotFound is returned when no custom securityTest has a given name.
+	ErrSecurityTestNotFound = errors.New("securityTest not found")
+)
+
+// invalidCustomSecurityTestErrors are the errors of a custom securityTest that is not valid.
+var invalidCustomSecurityTestErrors = []error{
+	ErrInvalidSecurityTestName,
+	ErrImageNotAllowed,
+	ErrInvalidSecurityTestCmd,
+	ErrInvalidSecurityTestTimeout,
+	ErrInvalidSecurityTestType,
+	ErrUnknownParser,
+	ErrInvalidDependsOn,
+	huskydocker.ErrMutableTag,
+}
+
+// cmdPlaceholders are the placeholders huskyCI replaces in the cmd of a securityTest.
+var cmdPlaceholders = map[string]bool{
+	"%FETCH_CODE%":                true,
+	"%CLONE_ERROR%":               true,
+	"%GIT_REPO%":                  true,
+	"%GIT_BRANCH%":                true,
+	"%GIT_COMMIT%":                true,
+	"%GIT_BASE_COMMIT%":           true,
+	"%GIT_SUBPATH%":               true,
+	"%CHANGED_FILES%":             true,
+	"%EXCLUDED_PATHS%":            true,
+	"%IGNORED_IDS%":               true,
+	"%ADVISORY_DB_URL%":           true,
+	"%GITLEAKS_CONFIG%":           true,
+	"%GIT_HTTPS_TOKEN%":           true,
+	"%INTERNAL_PACKAGE_PREFIXES%": true,
+}
+
+// internalParsers are not securityTools, so their output is not findings.
+var internalParsers = map[string]bool{"enry": true, "gitauthors": true, "gitdiff": true}
+
+var (
+	securityTestNameRegexp = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	placeholderRegexp      = regexp.MustCompile(`%[A-Z_]+%`)
+)
+
+// IsInvalidCustomSecurityTest returns whether err is returned because a custom securityTest is not valid.
+func IsInvalidCustomSecurityTest(err error) bool {
+	for _, invalidErr := range invalidCustomSecurityTestErrors {
+		if err == invalidErr {
+			return true
+		}
+	}
+	return false
+}
+
+// CustomParsers returns the parsers custom securityTests can choose from, sorted.
+func CustomParsers() []string {
+	parsers := []string{}
+	for _, name := range parser.Registry.Names() {
+		if !internalParsers[name] {
+			parsers = append(parsers, name)
+		}
+	}
+	return parsers
+}
+
+// ValidateCustomSecurityTest returns an error if securityTest can not be registered
+// through the API, as its image is not one of allowedImages, its cmd does not fetch
+// the code the huskyCI way or its output can not be parsed.
+func ValidateCustomSecurityTest(securityTest types.SecurityTest, allowedImages []string) error {
+	if !securityTestNameRegexp.MatchString(securityTest.Name) {
+		return ErrInvalidSecurityTestName
+	}
+	if !isImageAllowed(securityTest.Image, allowedImages) {
+		return ErrImageNotAllowed
+	}
+	if !strings.Contains(securityTest.Cmd, fetchCodePlaceholder) {
+		return ErrInvalidSecurityTestCmd
+	}
+	for _, placeholder := range placeholderRegexp.FindAllString(securityTest.Cmd, -1) {
+		if !cmdPlaceholders[placeholder] {
+			return ErrInvalidSecurityTestCmd
+		}
+	}
+	if securityTest.TimeOutInSeconds < 1 || securityTest.TimeOutInSeconds > maxCustomTimeOutInSeconds {
+		return ErrInvalidSecurityTestTimeout
+	}
+	if securityTest.Type != "Generic" && (securityTest.Type != "Language" || securityTest.Language == "") {
+		return ErrInvalidSecurityTestType
+	}
+	if _, ok := parser.Registry.Get(securityTest.Parser); !ok || internalParsers[securityTest.Parser] {
+		return ErrUnknownParser
+	}
+	return nil
+}
+
+// validateCustomSecurityTest validates securityTest against the configuration of the API.
+func validateCustomSecurityTest(securityTest types.SecurityTest) error {
+	if err := ValidateCustomSecurityTest(securityTest, apiContext.APIConfiguration.CustomSecurityTestImages); err != nil {
+		return err
+	}
+	if apiContext.APIConfiguration.EnforceImmutableTags && huskydocker.IsMutableTag(securityTest.ImageTag) {
+		return huskydocker.ErrMutableTag
+	}
+	if len(securityTest.DependsOn) > 0 {
+		return checkCustomDependencies(securityTest)
+	}
+	return nil
+}
+
+// checkCustomDependencies returns ErrInvalidDependsOn if securityTest, once stored, would
+// depend on a securityTest that does not exist or on itself.
+func checkCustomDependencies(securityTest types.SecurityTest) error {
+	securityTests, err := apiContext.APIConfiguration.DBInstance.FindAllDBSecurityTest(map[string]interface{}{})
+	if err != nil && !isNotFound(err) {
+		log.Error("checkCustomDependencies", "SECURITYTEST", 2005, err)
+		return err
+	}
+	allSecurityTests := []types.SecurityTest{securityTest}
+	for _, other := range securityTests {
+		if other.Name != securityTest.Name {
+			allSecurityTests = append(allSecurityTests, other)
+		}
+	}
+	if err := CheckDependencies(allSecurityTests); err != nil {
+		log.Warning("checkCustomDependencies", "SECURITYTEST", 131, securityTest.Name, err)
+		return ErrInvalidDependsOn
+	}
+	return nil
+}
+
+// isImageAllowed returns whether image is one of allowedImages or is under one
+// of them that ends in "/".
+func isImageAllowed(image string, allowedImages []string) bool {
+	if image == "" {
+		return false
+	}
+	for _, allowedImage := range allowedImages {
+		if image == allowedImage || (strings.HasSuffix(allowedImage, "/") && strings.HasPrefix(image, allowedImage)) {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateCustomSecurityTest validates and stores a new custom securityTest. Analyses
+// started after it is stored run it, as securityTests are read from the database.
+func CreateCustomSecurityTest(securityTest types.SecurityTest) error {
+	if isBuiltin(securityTest.Name) {
+		return ErrBuiltinSecurityTest
+	}
+	if err := validateCustomSecurityTest(securityTest); err != nil {
+		return err
+	}
+	if _, err := findSecurityTest(securityTest.Name); err != ErrSecurityTestNotFound {
+		if err != nil {
+			return err
+		}
+		return ErrSecurityTestAlreadyExists
+	}
+	return upsertCustomSecurityTest(securityTest)
+}
+
+// UpdateCustomSecurityTest validates and replaces the custom securityTest of a given name.
+func UpdateCustomSecurityTest(name string, securityTest types.SecurityTest) error {
+	if err := checkCustomSecurityTest(name); err != nil {
+		return err
+	}
+	securityTest.Name = name
+	if err := validateCustomSecurityTest(securityTest); err != nil {
+		return err
+	}
+	return upsertCustomSecurityTest(securityTest)
+}
+
+// DeleteCustomSecurityTest removes the custom securityTest of a given name.
+func DeleteCustomSecurityTest(name string) error {
+	if err := checkCustomSecurityTest(name); err != nil {
+		return err
+	}
+	securityTestQuery := map[string]interface{}{"name": name, "custom": true}
+	if err := apiContext.APIConfiguration.DBInstance.RemoveDBSecurityTest(securityTestQuery); err != nil {
+		log.Error("DeleteCustomSecurityTest", "SECURITYTEST", 2031, name, err)
+		return err
+	}
+	log.Info("DeleteCustomSecurityTest", "SECURITYTEST", 29, name)
+	return nil
+}
+
+// checkCustomSecurityTest returns an error unless name is a custom securityTest.
+func checkCustomSecurityTest(name string) error {
+	if isBuiltin(name) {
+		return ErrBuiltinSecurityTest
+	}
+	securityTest, err := findSecurityTest(name)
+	if err != nil {
+		return err
+	}
+	if !securityTest.Custom {
+		return ErrBuiltinSecurityTest
+	}
+	return nil
+}
+
+func findSecurityTest(name string) (types.SecurityTest, error) {
+	securityTest, err := apiContext.APIConfiguration.DBInstance.FindOneDBSecurityTest(map[string]interface{}{"name": name})
+	if err != nil && isNotFound(err) {
+		return securityTest, ErrSecurityTestNotFound
+	}
+	if err != nil {
+		log.Error("findSecurityTest", "SECURITYTEST", 1012, err)
+	}
+	return securityTest, err
+}
+
+func upsertCustomSecurityTest(securityTest types.SecurityTest) error {
+	securityTest.Custom = true
+	securityTestQuery := map[string]interface{}{"name": securityTest.Name}
+	if _, err := apiContext.APIConfiguration.DBInstance.UpsertOneDBSecurityTest(securityTestQuery, securityTest); err != nil {
+		log.Error("upsertCustomSecurityTest", "SECURITYTEST", 2031, securityTest.Name, err)
+		return err
+	}
+	log.Info("upsertCustomSecurityTest", "SECURITYTEST", 19, securityTest.Name)
+	return nil
+}
+
+func isNotFound(err error) bool {
+	return err == mgo.ErrNotFound || err.Error() == "No data found"
+}
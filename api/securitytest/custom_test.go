@@ -0,0 +1,118 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Custom securityTests", func() {
+
+	allowedImages := []string{"registry.example.com/security/", "huskyci/semgrep"}
+	validSecurityTest := func() types.SecurityTest {
+		return types.SecurityTest{
+			Name:             "semgrep",
+			Image:            "huskyci/semgrep",
+			ImageTag:         "0.9.0",
+			Cmd:              "%FETCH_CODE% && semgrep --json --exclude %EXCLUDED_PATHS% code",
+			Type:             "Language",
+			Language:         "Python",
+			TimeOutInSeconds: 360,
+			Parser:           securitytest.GenericParser,
+		}
+	}
+
+	Describe("ValidateCustomSecurityTest", func() {
+		Context("When the securityTest is valid", func() {
+			It("Should return nil", func() {
+				Expect(securitytest.ValidateCustomSecurityTest(validSecurityTest(), allowedImages)).To(Succeed())
+			})
+			It("Should allow images under an allowed prefix", func() {
+				securityTest := validSecurityTest()
+				securityTest.Image = "registry.example.com/security/scanner"
+				Expect(securitytest.ValidateCustomSecurityTest(securityTest, allowedImages)).To(Succeed())
+			})
+			It("Should allow the parser of a built-in securityTest", func() {
+				securityTest := validSecurityTest()
+				securityTest.Parser = "bandit"
+				Expect(securitytest.ValidateCustomSecurityTest(securityTest, allowedImages)).To(Succeed())
+			})
+		})
+		Context("When the securityTest is not valid", func() {
+			It("Should return why", func() {
+				invalid := map[error]func(securityTest *types.SecurityTest){
+					securitytest.ErrInvalidSecurityTestName:    func(securityTest *types.SecurityTest) { securityTest.Name = "Semgrep;" },
+					securitytest.ErrImageNotAllowed:            func(securityTest *types.SecurityTest) { securityTest.Image = "evil/semgrep" },
+					securitytest.ErrInvalidSecurityTestCmd:     func(securityTest *types.SecurityTest) { securityTest.Cmd = "semgrep code" },
+					securitytest.ErrInvalidSecurityTestTimeout: func(securityTest *types.SecurityTest) { securityTest.TimeOutInSeconds = 0 },
+					securitytest.ErrInvalidSecurityTestType:    func(securityTest *types.SecurityTest) { securityTest.Language = "" },
+					securitytest.ErrUnknownParser:              func(securityTest *types.SecurityTest) { securityTest.Parser = "sarif" },
+				}
+				for expectedErr, change := range invalid {
+					securityTest := validSecurityTest()
+					change(&securityTest)
+					err := securitytest.ValidateCustomSecurityTest(securityTest, allowedImages)
+					Expect(err).To(Equal(expectedErr))
+					Expect(securitytest.IsInvalidCustomSecurityTest(err)).To(BeTrue())
+				}
+			})
+			It("Should not allow unknown placeholders", func() {
+				securityTest := validSecurityTest()
+				securityTest.Cmd = "%FETCH_CODE% && echo %HUSKYCI_API_DB_PASSWORD%"
+				Expect(securitytest.ValidateCustomSecurityTest(securityTest, allowedImages)).To(Equal(securitytest.ErrInvalidSecurityTestCmd))
+			})
+			It("Should not allow parsers that do not report findings", func() {
+				securityTest := validSecurityTest()
+				securityTest.Parser = "enry"
+				Expect(securitytest.ValidateCustomSecurityTest(securityTest, allowedImages)).To(Equal(securitytest.ErrUnknownParser))
+				Expect(securitytest.CustomParsers()).ToNot(ContainElement("enry"))
+				Expect(securitytest.CustomParsers()).To(ContainElement(securitytest.GenericParser))
+			})
+		})
+	})
+
+	Describe("CreateCustomSecurityTest", func() {
+		var previousConfig *apiContext.APIConfig
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+		})
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+		Context("When immutable tags are enforced", func() {
+			It("Should not register a securityTest with a mutable tag", func() {
+				apiContext.APIConfiguration = &apiContext.APIConfig{CustomSecurityTestImages: allowedImages, EnforceImmutableTags: true}
+				for _, imageTag := range []string{"latest", "Stable", ""} {
+					securityTest := validSecurityTest()
+					securityTest.ImageTag = imageTag
+					err := securitytest.CreateCustomSecurityTest(securityTest)
+					Expect(err).To(Equal(huskydocker.ErrMutableTag))
+					Expect(securitytest.IsInvalidCustomSecurityTest(err)).To(BeTrue())
+				}
+			})
+		})
+	})
+
+	Describe("Generic parser", func() {
+		Context("When a custom securityTest is parsed by it", func() {
+			It("Should be analyzed with its parser instead of its name", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "semgrep"}
+				scanInfo.Container.SecurityTest = validSecurityTest()
+				scanInfo.Container.COutput = `[{"severity": "medium", "file": "app.py", "line": 3, "title": "eval"}]`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Vulnerabilities.MediumVulns).To(Equal([]types.HuskyCIVulnerability{
+					{SecurityTool: "semgrep", Severity: "MEDIUM", File: "app.py", Line: "3", Type: "eval"},
+				}))
+				Expect(scanInfo.Container.CResult).To(Equal("failed"))
+			})
+		})
+	})
+})
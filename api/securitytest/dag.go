@@ -0,0 +1,110 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// ErrDependencyCycle is returned when securityTests depend on each other, so none of them could start.
+var ErrDependencyCycle = errors.New("securityTests depend on each other")
+
+// CheckDependencies returns an error if a securityTest depends on one that is not in
+// securityTests or on itself, even through other ones.
+func CheckDependencies(securityTests []types.SecurityTest) error {
+	indexes := map[string]int{}
+	for i, securityTest := range securityTests {
+		indexes[securityTest.Name] = i
+	}
+	names := make([]string, len(securityTests))
+	dependencies := make([][]int, len(securityTests))
+	for i, securityTest := range securityTests {
+		names[i] = securityTest.Name
+		for _, dependency := range securityTest.DependsOn {
+			j, ok := indexes[dependency]
+			if !ok {
+				return fmt.Errorf("%s depends on %s, which is not a securityTest", securityTest.Name, dependency)
+			}
+			dependencies[i] = append(dependencies[i], j)
+		}
+	}
+	return checkCycles(names, dependencies)
+}
+
+// targetDependencies returns the indexes of the targets each one of targets depends on. A
+// target depends on the targets of the securityTests of its DependsOn that scan its subpath
+// or the whole repository. Dependencies that are not scanned by the analysis are ignored.
+func targetDependencies(targets []scanTarget) ([][]int, error) {
+	names := make([]string, len(targets))
+	dependencies := make([][]int, len(targets))
+	for i, target := range targets {
+		names[i] = target.securityTest.Name
+		for _, dependency := range target.securityTest.DependsOn {
+			for j, other := range targets {
+				if other.securityTest.Name == dependency && (other.subpath == target.subpath || other.subpath == "") {
+					dependencies[i] = append(dependencies[i], j)
+				}
+			}
+		}
+	}
+	if err := checkCycles(names, dependencies); err != nil {
+		return nil, err
+	}
+	return dependencies, nil
+}
+
+// chainedTargets returns whether each target, by its index, depends on another one or
+// another one depends on it, given the dependencies of targetDependencies.
+func chainedTargets(dependencies [][]int) map[int]bool {
+	chained := map[int]bool{}
+	for i, targetDependencies := range dependencies {
+		for _, j := range targetDependencies {
+			chained[i] = true
+			chained[j] = true
+		}
+	}
+	return chained
+}
+
+// checkCycles returns ErrDependencyCycle, along with the names of the nodes of the cycle,
+// if the graph of dependencies between nodes has one.
+func checkCycles(names []string, dependencies [][]int) error {
+	const (
+		unvisited = iota
+		visiting
+		visited
+	)
+	state := make([]int, len(names))
+	path := []string{}
+	var visit func(node int) error
+	visit = func(node int) error {
+		switch state[node] {
+		case visiting:
+			return fmt.Errorf("%w: %s", ErrDependencyCycle, strings.Join(append(path, names[node]), " -> "))
+		case visited:
+			return nil
+		}
+		state[node] = visiting
+		path = append(path, names[node])
+		for _, dependency := range dependencies[node] {
+			if err := visit(dependency); err != nil {
+				return err
+			}
+		}
+		path = path[:len(path)-1]
+		state[node] = visited
+		return nil
+	}
+	for node := range names {
+		if err := visit(node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
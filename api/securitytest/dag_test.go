@@ -0,0 +1,86 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"errors"
+
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("CheckDependencies", func() {
+
+	Context("When securityTests depend on other ones", func() {
+		It("Should return a nil error", func() {
+			securityTests := []types.SecurityTest{
+				{Name: "gosec"},
+				{Name: "maven"},
+				{Name: "spotbugs", DependsOn: []string{"maven"}},
+				{Name: "dependencycheck", DependsOn: []string{"maven", "spotbugs"}},
+			}
+			Expect(securitytest.CheckDependencies(securityTests)).To(BeNil())
+		})
+	})
+
+	Context("When a securityTest depends on one that does not exist", func() {
+		It("Should return an error", func() {
+			securityTests := []types.SecurityTest{{Name: "spotbugs", DependsOn: []string{"maven"}}}
+			Expect(securitytest.CheckDependencies(securityTests)).ToNot(BeNil())
+		})
+	})
+
+	Context("When securityTests depend on each other", func() {
+		It("Should return ErrDependencyCycle with the cycle", func() {
+			securityTests := []types.SecurityTest{
+				{Name: "maven", DependsOn: []string{"spotbugs"}},
+				{Name: "spotbugs", DependsOn: []string{"maven"}},
+			}
+			err := securitytest.CheckDependencies(securityTests)
+			Expect(errors.Is(err, securitytest.ErrDependencyCycle)).To(BeTrue())
+			Expect(err.Error()).To(ContainSubstring("maven -> spotbugs -> maven"))
+		})
+		It("Should not let a securityTest depend on itself", func() {
+			securityTests := []types.SecurityTest{{Name: "maven", DependsOn: []string{"maven"}}}
+			Expect(errors.Is(securitytest.CheckDependencies(securityTests), securitytest.ErrDependencyCycle)).To(BeTrue())
+		})
+	})
+})
+
+var _ = Describe("TargetDependencies", func() {
+
+	maven := types.SecurityTest{Name: "maven"}
+	spotbugs := types.SecurityTest{Name: "spotbugs", DependsOn: []string{"maven", "gitleaks"}}
+
+	Context("When a securityTest scans several subpaths", func() {
+		It("Should depend on its dependencies at the same subpath or at the repository root", func() {
+			gitleaks := types.SecurityTest{Name: "gitleaks"}
+			securityTests := []types.SecurityTest{gitleaks, maven, maven, spotbugs, spotbugs}
+			subpaths := []string{"", "api", "web", "api", "web"}
+			Expect(securitytest.TargetDependencies(securityTests, subpaths)).To(Equal([][]int{nil, nil, nil, {1, 0}, {2, 0}}))
+		})
+	})
+
+	Context("When a dependency is not scanned by the analysis", func() {
+		It("Should ignore it", func() {
+			Expect(securitytest.TargetDependencies([]types.SecurityTest{spotbugs}, []string{""})).To(Equal([][]int{nil}))
+		})
+	})
+})
+
+var _ = Describe("ChainedTargets", func() {
+
+	It("Should chain the targets that depend on another one or that another one depends on", func() {
+		Expect(securitytest.ChainedTargets([][]int{nil, nil, nil, {1, 0}})).To(Equal(map[int]bool{0: true, 1: true, 3: true}))
+	})
+
+	It("Should mount the shared volume of chained targets at HUSKYCI_SHARED_DIR", func() {
+		scanInfo := securitytest.SecTestScanInfo{ExtraEnv: map[string]string{"A": "1"}, SharedVolume: "huskyci-shared-RID"}
+		Expect(scanInfo.ContainerEnv()).To(Equal([]string{"A=1", "HUSKYCI_SHARED_DIR=/huskyci/shared"}))
+	})
+})
@@ -0,0 +1,73 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// dedupVulnerabilities collapses vulnerabilities of the same tool, identifier,
+// component and version into a single one, keeping how many times it was
+// found and every file where it was found. It must only be used by tools
+// that report dependencies, as SAST findings differ by file and line.
+func (scanInfo *SecTestScanInfo) dedupVulnerabilities() {
+	scanInfo.Vulnerabilities.LowVulns = dedupVulns(scanInfo.Vulnerabilities.LowVulns)
+	scanInfo.Vulnerabilities.MediumVulns = dedupVulns(scanInfo.Vulnerabilities.MediumVulns)
+	scanInfo.Vulnerabilities.HighVulns = dedupVulns(scanInfo.Vulnerabilities.HighVulns)
+}
+
+func dedupVulns(vulns []types.HuskyCIVulnerability) []types.HuskyCIVulnerability {
+	if len(vulns) < 2 {
+		return vulns
+	}
+
+	deduped := []types.HuskyCIVulnerability{}
+	positions := map[string]int{}
+
+	for _, vuln := range vulns {
+		occurrences := vuln.Occurrences
+		if occurrences == 0 {
+			occurrences = 1
+		}
+		key := dedupKey(vuln)
+		position, found := positions[key]
+		if !found {
+			vuln.Occurrences = occurrences
+			vuln.Files = appendFile(nil, vuln.File)
+			positions[key] = len(deduped)
+			deduped = append(deduped, vuln)
+			continue
+		}
+		deduped[position].Occurrences += occurrences
+		deduped[position].Files = appendFile(deduped[position].Files, vuln.File)
+		for _, file := range vuln.Files {
+			deduped[position].Files = appendFile(deduped[position].Files, file)
+		}
+	}
+
+	return deduped
+}
+
+func dedupKey(vuln types.HuskyCIVulnerability) string {
+	identifier := vuln.RuleID
+	if identifier == "" {
+		identifier = vuln.Details
+	}
+	return fmt.Sprintf("%s|%s|%s|%s", vuln.SecurityTool, identifier, vuln.Code, vuln.Version)
+}
+
+func appendFile(files []string, file string) []string {
+	if file == "" {
+		return files
+	}
+	for _, existing := range files {
+		if existing == file {
+			return files
+		}
+	}
+	return append(files, file)
+}
@@ -0,0 +1,73 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Dedup", func() {
+
+	jquery := types.HuskyCIVulnerability{
+		SecurityTool: "YarnAudit",
+		Severity:     "medium",
+		Code:         "jquery",
+		Version:      "1.8.1",
+		Details:      "Cross-Site Scripting",
+	}
+
+	Context("When the same component is reported in different files", func() {
+		first, second, third := jquery, jquery, jquery
+		first.File = "static/a/jquery.js"
+		second.File = "static/b/jquery.js"
+		third.File = "static/a/jquery.js"
+		deduped := securitytest.DedupVulns([]types.HuskyCIVulnerability{first, second, third})
+
+		It("Should collapse them into a single vulnerability", func() {
+			Expect(deduped).To(HaveLen(1))
+			Expect(deduped[0].Occurrences).To(Equal(3))
+			Expect(deduped[0].Files).To(Equal([]string{"static/a/jquery.js", "static/b/jquery.js"}))
+		})
+	})
+
+	Context("When the same component is reported with different versions", func() {
+		It("Should keep both vulnerabilities", func() {
+			other := jquery
+			other.Version = "3.4.0"
+			Expect(securitytest.DedupVulns([]types.HuskyCIVulnerability{jquery, other})).To(HaveLen(2))
+		})
+	})
+
+	Context("When the securityTest does not enable dedup", func() {
+		It("Should keep every vulnerability", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+			scanInfo.Container.COutput = `{"Issues": [
+				{"severity": "MEDIUM", "confidence": "HIGH", "rule_id": "G104", "details": "Errors unhandled.", "file": "a.go", "code": "f()", "line": "1"},
+				{"severity": "MEDIUM", "confidence": "HIGH", "rule_id": "G104", "details": "Errors unhandled.", "file": "b.go", "code": "f()", "line": "1"}
+			], "Stats": {"nosec": 0}}`
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(HaveLen(2))
+		})
+	})
+
+	Context("When the securityTest enables dedup", func() {
+		It("Should collapse vulnerabilities before deciding the result", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+			scanInfo.Container.SecurityTest.Dedup = true
+			scanInfo.Container.COutput = `{"Issues": [
+				{"severity": "MEDIUM", "confidence": "HIGH", "rule_id": "G104", "details": "Errors unhandled.", "file": "a.go", "code": "f()", "line": "1"},
+				{"severity": "MEDIUM", "confidence": "HIGH", "rule_id": "G104", "details": "Errors unhandled.", "file": "b.go", "code": "f()", "line": "1"}
+			], "Stats": {"nosec": 0}}`
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].Occurrences).To(Equal(2))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+})
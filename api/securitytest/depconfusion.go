@@ -0,0 +1,110 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// internalPackagePrefixesPlaceholder is replaced in a cmd by the quoted internalPackagePrefixes
+// of config.yaml, separated by spaces, or by an empty string if there is none.
+const internalPackagePrefixesPlaceholder = "%INTERNAL_PACKAGE_PREFIXES%"
+
+// DepConfusionOutput is the struct that holds every internal-looking dependency checked
+// against its public registry by the depconfusion securityTest.
+type DepConfusionOutput struct {
+	Dependencies []DepConfusionDependency `json:"dependencies"`
+}
+
+// DepConfusionDependency is a dependency of a manifest, such as package.json or requirements.txt,
+// and the HTTP status its public registry answered, which is "000" if it could not be reached.
+type DepConfusionDependency struct {
+	Ecosystem  string `json:"ecosystem"`
+	File       string `json:"file"`
+	Name       string `json:"name"`
+	HTTPStatus string `json:"httpStatus"`
+}
+
+// handleInternalPackagePrefixes replaces %INTERNAL_PACKAGE_PREFIXES% in cmd by prefixes.
+func handleInternalPackagePrefixes(cmd string, prefixes []string) string {
+	return strings.Replace(cmd, internalPackagePrefixesPlaceholder, util.ShellQuote(strings.Join(prefixes, " ")), -1)
+}
+
+func internalPackagePrefixes() []string {
+	if apiContext.APIConfiguration == nil {
+		return nil
+	}
+	return apiContext.APIConfiguration.InternalPackagePrefixes
+}
+
+func analyzeDepConfusion(depConfusionScan *SecTestScanInfo) error {
+	depConfusionOutput := DepConfusionOutput{}
+	depConfusionScan.FinalOutput = depConfusionOutput
+
+	// nil cOutput states that no Issues were found.
+	if depConfusionScan.Container.COutput == "" {
+		depConfusionScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// Unmarshall rawOutput into finalOutput, that is a DepConfusionOutput struct.
+	if err := depConfusionScan.unmarshalOutput(&depConfusionOutput); err != nil {
+		log.Error("analyzeDepConfusion", "DEPCONFUSION", 1054, depConfusionScan.Container.COutput, err)
+		return nil
+	}
+	depConfusionScan.FinalOutput = depConfusionOutput
+
+	depConfusionScan.prepareDepConfusionVulns()
+	depConfusionScan.prepareContainerAfterScan()
+	return nil
+}
+
+func (depConfusionScan *SecTestScanInfo) prepareDepConfusionVulns() {
+
+	huskyCIdepConfusionResults := types.HuskyCISecurityTestOutput{}
+	depConfusionOutput := depConfusionScan.FinalOutput.(DepConfusionOutput)
+
+	for _, dependency := range depConfusionOutput.Dependencies {
+		depConfusionVuln := types.HuskyCIVulnerability{
+			Language:     depConfusionLanguage(dependency.Ecosystem),
+			SecurityTool: "DepConfusion",
+			File:         dependency.File,
+			Code:         dependency.Name,
+		}
+		switch dependency.HTTPStatus {
+		case "200":
+			depConfusionVuln.Severity = "high"
+			depConfusionVuln.Type = "PublicPackage"
+			depConfusionVuln.Details = fmt.Sprintf("The internal package %s also exists in the public %s registry, which may be installed instead of it.", dependency.Name, dependency.Ecosystem)
+			huskyCIdepConfusionResults.HighVulns = append(huskyCIdepConfusionResults.HighVulns, depConfusionVuln)
+		case "404":
+			depConfusionVuln.Severity = "high"
+			depConfusionVuln.Type = "UnclaimedPackage"
+			depConfusionVuln.Details = fmt.Sprintf("The internal package %s is unclaimed in the public %s registry, so anyone can publish it there.", dependency.Name, dependency.Ecosystem)
+			huskyCIdepConfusionResults.HighVulns = append(huskyCIdepConfusionResults.HighVulns, depConfusionVuln)
+		default:
+			// a registry that could not be checked is a warning, not an error of the analysis
+			depConfusionVuln.Severity = "low"
+			depConfusionVuln.Type = "RegistryUnavailable"
+			depConfusionVuln.Details = fmt.Sprintf("The public %s registry could not be checked for the internal package %s (HTTP status %s).", dependency.Ecosystem, dependency.Name, dependency.HTTPStatus)
+			huskyCIdepConfusionResults.LowVulns = append(huskyCIdepConfusionResults.LowVulns, depConfusionVuln)
+		}
+	}
+
+	depConfusionScan.Vulnerabilities = huskyCIdepConfusionResults
+}
+
+func depConfusionLanguage(ecosystem string) string {
+	if ecosystem == "pypi" {
+		return "Python"
+	}
+	return "JavaScript"
+}
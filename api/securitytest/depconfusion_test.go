@@ -0,0 +1,62 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const depConfusionReport = `{"dependencies":[
+  {"ecosystem":"npm","file":"web/package.json","name":"@acme/ui","httpStatus":"200"},
+  {"ecosystem":"pypi","file":"requirements.txt","name":"acme-lib","httpStatus":"404"},
+  {"ecosystem":"npm","file":"package.json","name":"acme-tools","httpStatus":"000"}
+]}`
+
+var _ = Describe("DepConfusion", func() {
+
+	Describe("HandleInternalPackagePrefixes", func() {
+		It("Should replace %INTERNAL_PACKAGE_PREFIXES% by the quoted prefixes", func() {
+			cmd := "prefixes=%INTERNAL_PACKAGE_PREFIXES%"
+			Expect(securitytest.HandleInternalPackagePrefixes(cmd, []string{"@acme/", "acme-"})).To(Equal("prefixes='@acme/ acme-'"))
+			Expect(securitytest.HandleInternalPackagePrefixes(cmd, nil)).To(Equal("prefixes=''"))
+		})
+	})
+
+	Context("When internal packages exist in or are unclaimed by public registries", func() {
+		scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "depconfusion"}
+		scanInfo.Container.COutput = depConfusionReport
+		err := scanInfo.Analyze()
+
+		It("Should report both as high severity findings", func() {
+			Expect(err).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(2))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Code).To(Equal("@acme/ui"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Type).To(Equal("PublicPackage"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Language).To(Equal("JavaScript"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[1].Type).To(Equal("UnclaimedPackage"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[1].Language).To(Equal("Python"))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+
+		It("Should report a registry that could not be checked as a low severity finding", func() {
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.LowVulns[0].Type).To(Equal("RegistryUnavailable"))
+		})
+	})
+
+	Context("When no registry could be checked", func() {
+		It("Should not fail the securityTest", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "depconfusion"}
+			scanInfo.Container.COutput = `{"dependencies":[{"ecosystem":"npm","file":"package.json","name":"@acme/ui","httpStatus":"503"}]}`
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(BeEmpty())
+			Expect(scanInfo.Container.CResult).ToNot(Equal("failed"))
+			Expect(scanInfo.Container.CResult).ToNot(Equal("error"))
+		})
+	})
+})
@@ -0,0 +1,208 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"errors"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// DependencyCheckOutput is the struct that holds all data from OWASP Dependency-Check JSON report.
+type DependencyCheckOutput struct {
+	ScanInfo     DependencyCheckScanInfo     `json:"scanInfo"`
+	Dependencies []DependencyCheckDependency `json:"dependencies"`
+}
+
+// DependencyCheckScanInfo is the struct that holds information about the Dependency-Check engine.
+type DependencyCheckScanInfo struct {
+	EngineVersion string `json:"engineVersion"`
+}
+
+// DependencyCheckDependency is the struct that holds a dependency analyzed by Dependency-Check.
+type DependencyCheckDependency struct {
+	FileName        string                           `json:"fileName"`
+	FilePath        string                           `json:"filePath"`
+	Packages        []DependencyCheckPackage         `json:"packages"`
+	Vulnerabilities []DependencyCheckVulnerabilities `json:"vulnerabilities"`
+}
+
+// DependencyCheckPackage is the struct that holds the package identifier of a dependency.
+type DependencyCheckPackage struct {
+	ID string `json:"id"`
+}
+
+// DependencyCheckVulnerabilities is the struct that holds a vulnerability of a dependency.
+type DependencyCheckVulnerabilities struct {
+	Name        string               `json:"name"`
+	Severity    string               `json:"severity"`
+	Description string               `json:"description"`
+	CVSSv2      *DependencyCheckCVSS `json:"cvssv2,omitempty"`
+	CVSSv3      *DependencyCheckCVSS `json:"cvssv3,omitempty"`
+	CWEs        []string             `json:"cwes"`
+}
+
+// DependencyCheckCVSS is the struct that holds both CVSSv2 and CVSSv3 scores.
+type DependencyCheckCVSS struct {
+	Score     float64 `json:"score"`
+	BaseScore float64 `json:"baseScore"`
+}
+
+func analyzeDependencyCheck(dependencyCheckScan *SecTestScanInfo) error {
+
+	dependencyCheckOutput := DependencyCheckOutput{}
+	dependencyCheckScan.FinalOutput = dependencyCheckOutput
+
+	// check if Dependency-Check failed running
+	errorRunning := strings.Contains(dependencyCheckScan.Container.COutput, "ERROR_RUNNING_DEPENDENCY_CHECK")
+	if errorRunning {
+		dependencyCheckScan.ErrorFound = errors.New("error running dependency-check")
+		dependencyCheckScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// nil cOutput states that no Issues were found.
+	if dependencyCheckScan.Container.COutput == "" {
+		dependencyCheckScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// Unmarshall rawOutput into finalOutput, that is a DependencyCheckOutput struct.
+	if err := dependencyCheckScan.unmarshalOutput(&dependencyCheckOutput); err != nil {
+		log.Error("analyzeDependencyCheck", "DEPENDENCYCHECK", 1041, dependencyCheckScan.Container.COutput, err)
+		return nil
+	}
+	dependencyCheckScan.FinalOutput = dependencyCheckOutput
+	dependencyCheckScan.Container.ScannerVersion = dependencyCheckOutput.ScanInfo.EngineVersion
+
+	// check results and prepare all vulnerabilities found
+	dependencyCheckScan.prepareDependencyCheckVulns()
+	dependencyCheckScan.prepareContainerAfterScan()
+	return nil
+}
+
+func (dependencyCheckScan *SecTestScanInfo) prepareDependencyCheckVulns() {
+
+	huskyCIdependencyCheckResults := types.HuskyCISecurityTestOutput{}
+	dependencyCheckOutput := dependencyCheckScan.FinalOutput.(DependencyCheckOutput)
+
+	failSeverity := "MEDIUM"
+	if apiContext.APIConfiguration != nil && apiContext.APIConfiguration.DependencyCheckFailSeverity != "" {
+		failSeverity = apiContext.APIConfiguration.DependencyCheckFailSeverity
+	}
+
+	// the same CVE is usually reported for many files of the same library
+	seenCVEs := map[string]*types.HuskyCIVulnerability{}
+	orderedCVEs := []string{}
+
+	for _, dependency := range dependencyCheckOutput.Dependencies {
+		for _, vulnerability := range dependency.Vulnerabilities {
+			if vulnerability.Name == "" {
+				continue
+			}
+			if vuln, ok := seenCVEs[vulnerability.Name]; ok {
+				vuln.Occurrences++
+				vuln.Files = appendFile(vuln.Files, dependency.FilePath)
+				continue
+			}
+			dependencyCheckVuln := types.HuskyCIVulnerability{
+				Language:     "Java",
+				SecurityTool: "DependencyCheck",
+				Severity:     dependencyCheckSeverity(vulnerability),
+				File:         dependency.FilePath,
+				Code:         dependencyName(dependency),
+				Details:      vulnerability.Description,
+				Type:         strings.Join(vulnerability.CWEs, ", "),
+				RuleID:       vulnerability.Name,
+				Occurrences:  1,
+				Files:        appendFile(nil, dependency.FilePath),
+			}
+			if score := dependencyCheckScore(vulnerability); score >= 0 {
+				dependencyCheckVuln.CVSSScore = score
+			}
+			if dependencyCheckVuln.Details == "" {
+				dependencyCheckVuln.Details = vulnerability.Name
+			}
+			seenCVEs[vulnerability.Name] = &dependencyCheckVuln
+			orderedCVEs = append(orderedCVEs, vulnerability.Name)
+		}
+	}
+
+	for _, cve := range orderedCVEs {
+		dependencyCheckVuln := *seenCVEs[cve]
+		// vulnerabilities below the configured threshold are only warnings
+		if severityRank(dependencyCheckVuln.Severity) < severityRank(failSeverity) {
+			huskyCIdependencyCheckResults.LowVulns = append(huskyCIdependencyCheckResults.LowVulns, dependencyCheckVuln)
+			continue
+		}
+		switch dependencyCheckVuln.Severity {
+		case "LOW":
+			huskyCIdependencyCheckResults.LowVulns = append(huskyCIdependencyCheckResults.LowVulns, dependencyCheckVuln)
+		case "MEDIUM":
+			huskyCIdependencyCheckResults.MediumVulns = append(huskyCIdependencyCheckResults.MediumVulns, dependencyCheckVuln)
+		case "HIGH":
+			huskyCIdependencyCheckResults.HighVulns = append(huskyCIdependencyCheckResults.HighVulns, dependencyCheckVuln)
+		}
+	}
+
+	dependencyCheckScan.Vulnerabilities = huskyCIdependencyCheckResults
+}
+
+// dependencyCheckSeverity maps CVSSv3 or, if missing, CVSSv2 score into LOW, MEDIUM
+// or HIGH. If no score is present, the severity reported by Dependency-Check is used.
+func dependencyCheckSeverity(vulnerability DependencyCheckVulnerabilities) string {
+	score := dependencyCheckScore(vulnerability)
+	switch {
+	case score >= 7.0:
+		return "HIGH"
+	case score >= 4.0:
+		return "MEDIUM"
+	case score >= 0:
+		return "LOW"
+	}
+	switch strings.ToUpper(vulnerability.Severity) {
+	case "CRITICAL", "HIGH":
+		return "HIGH"
+	case "MEDIUM", "MODERATE":
+		return "MEDIUM"
+	}
+	return "LOW"
+}
+
+// dependencyCheckScore returns CVSSv3 base score or, if missing, CVSSv2 score.
+// It returns -1 when the vulnerability has no score at all.
+func dependencyCheckScore(vulnerability DependencyCheckVulnerabilities) float64 {
+	if vulnerability.CVSSv3 != nil {
+		return vulnerability.CVSSv3.BaseScore
+	}
+	if vulnerability.CVSSv2 != nil {
+		return vulnerability.CVSSv2.Score
+	}
+	return -1
+}
+
+func dependencyName(dependency DependencyCheckDependency) string {
+	for _, pkg := range dependency.Packages {
+		if pkg.ID != "" {
+			return pkg.ID
+		}
+	}
+	return dependency.FileName
+}
+
+func severityRank(severity string) int {
+	switch strings.ToUpper(severity) {
+	case "HIGH":
+		return 3
+	case "MEDIUM":
+		return 2
+	case "LOW":
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,94 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const dependencyCheckReport = `{
+  "reportSchema": "1.1",
+  "scanInfo": {"engineVersion": "5.3.0"},
+  "dependencies": [
+    {
+      "fileName": "jackson-databind-2.9.8.jar",
+      "filePath": "/code/lib/jackson-databind-2.9.8.jar",
+      "packages": [{"id": "pkg:maven/com.fasterxml.jackson.core/jackson-databind@2.9.8"}],
+      "vulnerabilities": [
+        {"name": "CVE-2019-12384", "severity": "MEDIUM", "cvssv2": {"score": 5.9}, "cvssv3": {"baseScore": 5.9}, "cwes": ["CWE-502"], "description": "FasterXML jackson-databind RCE."},
+        {"name": "CVE-2019-14379", "severity": "CRITICAL", "cvssv3": {"baseScore": 9.8}, "description": "SubTypeValidator mishandles default typing."}
+      ]
+    },
+    {
+      "fileName": "jackson-databind-2.9.8-copy.jar",
+      "filePath": "/code/other/jackson-databind-2.9.8.jar",
+      "vulnerabilities": [
+        {"name": "CVE-2019-14379", "severity": "CRITICAL", "cvssv3": {"baseScore": 9.8}}
+      ]
+    },
+    {
+      "fileName": "commons-text-1.4.jar",
+      "filePath": "/code/lib/commons-text-1.4.jar",
+      "vulnerabilities": [
+        {"name": "CVE-2022-0001", "severity": "LOW"}
+      ]
+    },
+    {
+      "fileName": "clean.jar",
+      "filePath": "/code/lib/clean.jar"
+    }
+  ]
+}`
+
+var _ = Describe("DependencyCheck", func() {
+
+	Context("When the report has vulnerabilities", func() {
+		scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "dependencycheck"}
+		scanInfo.Container.COutput = dependencyCheckReport
+		err := scanInfo.Analyze()
+
+		It("Should store the engine version as the scanner version", func() {
+			Expect(scanInfo.Container.ScannerVersion).To(Equal("5.3.0"))
+		})
+
+		It("Should map CVSS scores into severities", func() {
+			Expect(err).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].CVSSScore).To(Equal(9.8))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].Type).To(Equal("CWE-502"))
+		})
+		It("Should de-dupe vulnerabilities by CVE", func() {
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].RuleID).To(Equal("CVE-2019-14379"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Occurrences).To(Equal(2))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Files).To(Equal([]string{"/code/lib/jackson-databind-2.9.8.jar", "/code/other/jackson-databind-2.9.8.jar"}))
+		})
+		It("Should fail the container", func() {
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+
+	Context("When the report has no vulnerabilities", func() {
+		It("Should pass the container", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "dependencycheck"}
+			scanInfo.Container.COutput = `{"reportSchema": "1.1", "dependencies": [{"fileName": "clean.jar"}]}`
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+	})
+
+	Context("When Dependency-Check failed running", func() {
+		It("Should mark the container as an error", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "dependencycheck"}
+			scanInfo.Container.COutput = "ERROR_RUNNING_DEPENDENCY_CHECK\nNVD update failed"
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Container.CResult).To(Equal("error"))
+		})
+	})
+})
@@ -19,10 +19,11 @@ type EnryOutput struct {
 }
 
 func analyzeEnry(enryScan *SecTestScanInfo) error {
+	enryScan.Commit, enryScan.Container.COutput = extractCommit(enryScan.Container.COutput)
 	// Unmarshall rawOutput into finalOutput, that is a EnryOutput struct.
-	if err := json.Unmarshal([]byte(enryScan.Container.COutput), &enryScan.FinalOutput); err != nil {
+	// enry output is required by every other securityTest, so it still aborts the analysis.
+	if err := enryScan.unmarshalOutput(&enryScan.FinalOutput); err != nil {
 		log.Error("analyzeEnry", "ENRY", 1003, enryScan.Container.COutput, err)
-		enryScan.ErrorFound = err
 		return err
 	}
 	// get all languages and files found based on Enry output
@@ -58,6 +59,6 @@ func (enryScan *SecTestScanInfo) prepareEnryOutput() error {
 		}
 		repositoryLanguages = append(repositoryLanguages, newLanguage)
 	}
-	enryScan.Codes = repositoryLanguages
+	enryScan.Codes = addManifestLanguages(repositoryLanguages)
 	return nil
 }
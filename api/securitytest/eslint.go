@@ -0,0 +1,118 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// EslintOutput is the struct that holds every file reported by ESLint with eslint-plugin-security.
+type EslintOutput []EslintFile
+
+// EslintFile is a file linted by ESLint and the messages of its rules.
+type EslintFile struct {
+	FilePath string          `json:"filePath"`
+	Messages []EslintMessage `json:"messages"`
+}
+
+// EslintMessage is a problem found by an ESLint rule. Its severity is 1 for a warning
+// and 2 for an error. A message without ruleId is a file ESLint could not parse.
+type EslintMessage struct {
+	RuleID   string `json:"ruleId"`
+	Severity int    `json:"severity"`
+	Line     int    `json:"line"`
+	Column   int    `json:"column"`
+	Message  string `json:"message"`
+}
+
+// ESLint severities of a message.
+const (
+	eslintSeverityWarning = 1
+	eslintSeverityError   = 2
+)
+
+func analyzeEslint(eslintScan *SecTestScanInfo) error {
+
+	eslintOutput := EslintOutput{}
+	eslintScan.FinalOutput = eslintOutput
+
+	// check if ESLint failed running, such as with an invalid config
+	if strings.Contains(eslintScan.Container.COutput, "ERROR_RUNNING_ESLINT") {
+		eslintScan.ErrorFound = errors.New("error running eslint")
+		eslintScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// nil cOutput states that no Issues were found.
+	if eslintScan.Container.COutput == "" {
+		eslintScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// Unmarshall rawOutput into finalOutput, that is an EslintOutput struct.
+	if err := eslintScan.unmarshalOutput(&eslintOutput); err != nil {
+		log.Error("analyzeEslint", "ESLINT", 1058, eslintScan.Container.COutput, err)
+		return nil
+	}
+	eslintScan.FinalOutput = eslintOutput
+
+	// check results and prepare all vulnerabilities found
+	eslintScan.prepareEslintVulns()
+	eslintScan.prepareContainerAfterScan()
+	return nil
+}
+
+func (eslintScan *SecTestScanInfo) prepareEslintVulns() {
+
+	huskyCIeslintResults := types.HuskyCISecurityTestOutput{}
+	eslintOutput := eslintScan.FinalOutput.(EslintOutput)
+
+	failOnWarnings := apiContext.APIConfiguration != nil && apiContext.APIConfiguration.ESLintFailOnWarnings
+
+	// a rule may report the same line more than once, such as for each argument of a call
+	seen := map[string]bool{}
+
+	for _, file := range eslintOutput {
+		for _, message := range file.Messages {
+			key := fmt.Sprintf("%s\x00%s\x00%d", message.RuleID, file.FilePath, message.Line)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+
+			eslintVuln := types.HuskyCIVulnerability{
+				Language:     "JavaScript",
+				SecurityTool: "ESLint",
+				RuleID:       message.RuleID,
+				Details:      message.Message,
+				File:         file.FilePath,
+				Line:         strconv.Itoa(message.Line),
+			}
+
+			switch {
+			case message.RuleID == "":
+				// files that could not be parsed are reported, but are not security issues
+				eslintVuln.Severity = "low"
+				eslintVuln.Type = "ParseError"
+				huskyCIeslintResults.LowVulns = append(huskyCIeslintResults.LowVulns, eslintVuln)
+			case message.Severity == eslintSeverityError || (message.Severity == eslintSeverityWarning && failOnWarnings):
+				eslintVuln.Severity = "medium"
+				huskyCIeslintResults.MediumVulns = append(huskyCIeslintResults.MediumVulns, eslintVuln)
+			default:
+				eslintVuln.Severity = "low"
+				huskyCIeslintResults.LowVulns = append(huskyCIeslintResults.LowVulns, eslintVuln)
+			}
+		}
+	}
+
+	eslintScan.Vulnerabilities = huskyCIeslintResults
+}
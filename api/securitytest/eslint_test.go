@@ -0,0 +1,89 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const eslintReport = `[
+  {"filePath":"src/server.js","messages":[
+    {"ruleId":"security/detect-eval-with-expression","severity":2,"line":10,"column":3,"message":"eval with argument of type Identifier"},
+    {"ruleId":"security/detect-eval-with-expression","severity":2,"line":10,"column":20,"message":"eval with argument of type Identifier"},
+    {"ruleId":"security/detect-object-injection","severity":1,"line":22,"column":5,"message":"Generic Object Injection Sink"}
+  ]},
+  {"filePath":"src/broken.js","messages":[
+    {"ruleId":null,"severity":2,"line":1,"column":1,"message":"Parsing error: Unexpected token <","fatal":true}
+  ]}
+]`
+
+var _ = Describe("ESLint", func() {
+
+	Context("When ESLint reports errors and warnings", func() {
+		scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "eslint"}
+		scanInfo.Container.COutput = eslintReport
+		err := scanInfo.Analyze()
+
+		It("Should fail on errors reported once per rule, file and line", func() {
+			Expect(err).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].RuleID).To(Equal("security/detect-eval-with-expression"))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].File).To(Equal("src/server.js"))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].Line).To(Equal("10"))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].SecurityTool).To(Equal("ESLint"))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+
+		It("Should report warnings and files that could not be parsed as low severity findings", func() {
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(2))
+			Expect(scanInfo.Vulnerabilities.LowVulns[0].RuleID).To(Equal("security/detect-object-injection"))
+			Expect(scanInfo.Vulnerabilities.LowVulns[1].Type).To(Equal("ParseError"))
+		})
+	})
+
+	Context("When ESLint only reports warnings", func() {
+		It("Should not fail the securityTest", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "eslint"}
+			scanInfo.Container.COutput = `[{"filePath":"index.js","messages":[{"ruleId":"security/detect-non-literal-regexp","severity":1,"line":3,"column":9,"message":"RegExp() called with a variable"}]}]`
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+	})
+
+	Context("When no file has a message", func() {
+		It("Should pass the securityTest", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "eslint"}
+			scanInfo.Container.COutput = `[]`
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(BeEmpty())
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(BeEmpty())
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+			Expect(scanInfo.Container.CInfo).To(Equal("No issues found."))
+		})
+	})
+
+	Context("When ESLint could not run", func() {
+		It("Should mark its container as an error", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "eslint"}
+			scanInfo.Container.COutput = "ERROR_RUNNING_ESLINT\nESLint couldn't find the plugin"
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.Container.CResult).To(Equal("error"))
+		})
+	})
+
+	Context("When the repository could not be cloned", func() {
+		It("Should return the clone error", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "eslint"}
+			scanInfo.Container.COutput = `ERROR_CLONING:{"code":"branch_not_found","stderr":"fatal: Remote branch nope not found"}`
+			err := scanInfo.Analyze()
+			Expect(err).To(HaveOccurred())
+			Expect(scanInfo.ErrorFound).To(Equal(&securitytest.CloneError{Code: securitytest.CloneErrorBranchNotFound, Stderr: "fatal: Remote branch nope not found"}))
+		})
+	})
+})
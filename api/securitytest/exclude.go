@@ -0,0 +1,86 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// excludedPathsFormatters formats excluded paths as the flags of the securityTests
+// that can skip them. Findings of every securityTest are also filtered after parsing.
+var excludedPathsFormatters = map[string]func(excludedPaths []string) string{
+	"bandit": banditExcludedPaths,
+	"gosec":  gosecExcludedPaths,
+}
+
+// excludedPathsArgs returns the excluded paths formatted for a given securityTest, or an
+// empty string if it can not skip them by itself.
+func excludedPathsArgs(securityTestName string, excludedPaths []string) string {
+	formatter, ok := excludedPathsFormatters[securityTestName]
+	if !ok || len(excludedPaths) == 0 {
+		return ""
+	}
+	return formatter(excludedPaths)
+}
+
+func banditExcludedPaths(excludedPaths []string) string {
+	return fmt.Sprintf("-x %s", util.ShellQuote(strings.Join(excludedPaths, ",")))
+}
+
+// gosecExcludedPaths skips directories only, as gosec does not match file globs.
+func gosecExcludedPaths(excludedPaths []string) string {
+	args := []string{}
+	for _, excludedPath := range excludedPaths {
+		if strings.ContainsAny(excludedPath, "*?[") {
+			continue
+		}
+		args = append(args, fmt.Sprintf("-exclude-dir=%s", util.ShellQuote(strings.Trim(excludedPath, "/"))))
+	}
+	return strings.Join(args, " ")
+}
+
+// filterExcludedPaths removes findings of files under an excluded path or matching an
+// excluded glob. Findings without a file, such as the ones of a dependency, are kept.
+func (scanInfo *SecTestScanInfo) filterExcludedPaths() {
+	scanInfo.Vulnerabilities.LowVulns = filterExcludedVulns(scanInfo.ExcludedPaths, scanInfo.Vulnerabilities.LowVulns)
+	scanInfo.Vulnerabilities.MediumVulns = filterExcludedVulns(scanInfo.ExcludedPaths, scanInfo.Vulnerabilities.MediumVulns)
+	scanInfo.Vulnerabilities.HighVulns = filterExcludedVulns(scanInfo.ExcludedPaths, scanInfo.Vulnerabilities.HighVulns)
+	scanInfo.Vulnerabilities.NoSecVulns = filterExcludedVulns(scanInfo.ExcludedPaths, scanInfo.Vulnerabilities.NoSecVulns)
+}
+
+func filterExcludedVulns(excludedPaths []string, vulns []types.HuskyCIVulnerability) []types.HuskyCIVulnerability {
+	keptVulns := []types.HuskyCIVulnerability{}
+	for _, vuln := range vulns {
+		if vuln.File != "" && isExcludedPath(vuln.File, excludedPaths) {
+			continue
+		}
+		keptVulns = append(keptVulns, vuln)
+	}
+	return keptVulns
+}
+
+// isExcludedPath returns whether file is under an excluded directory or if it, or
+// its base name, matches an excluded glob such as "*.pb.go".
+func isExcludedPath(file string, excludedPaths []string) bool {
+	file = strings.TrimPrefix(path.Clean(file), "./")
+	for _, excludedPath := range excludedPaths {
+		excludedPath = strings.Trim(path.Clean(excludedPath), "/")
+		if file == excludedPath || strings.HasPrefix(file, excludedPath+"/") {
+			return true
+		}
+		if matched, _ := path.Match(excludedPath, file); matched {
+			return true
+		}
+		if matched, _ := path.Match(excludedPath, path.Base(file)); matched {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// Analyze exposes analyze to securitytest_test.
+func (scanInfo *SecTestScanInfo) Analyze() error {
+	return scanInfo.analyze()
+}
+
+// DedupVulns exposes dedupVulns to securitytest_test.
+func DedupVulns(vulns []types.HuskyCIVulnerability) []types.HuskyCIVulnerability {
+	return dedupVulns(vulns)
+}
+
+// DetectLanguagesFromFiles exposes detectLanguagesFromFiles to securitytest_test.
+func DetectLanguagesFromFiles(files []string) []string {
+	return detectLanguagesFromFiles(files)
+}
+
+// AddManifestLanguages exposes addManifestLanguages to securitytest_test.
+func AddManifestLanguages(codes []types.Code) []types.Code {
+	return addManifestLanguages(codes)
+}
+
+// SetTimedOut exposes setTimedOut to securitytest_test.
+func (scanInfo *SecTestScanInfo) SetTimedOut(elapsed time.Duration, timeOutInSeconds int) {
+	scanInfo.setTimedOut(elapsed, timeOutInSeconds)
+}
+
+// TruncatePassedOutput exposes truncatePassedOutput to securitytest_test.
+func (scanInfo *SecTestScanInfo) TruncatePassedOutput(maxSize int) {
+	scanInfo.truncatePassedOutput(maxSize)
+}
+
+// CanRetry exposes canRetry to securitytest_test.
+func (scanInfo *SecTestScanInfo) CanRetry(now time.Time, maxAttempts int) bool {
+	return scanInfo.canRetry(now, maxAttempts)
+}
+
+// SLAViolation exposes slaViolation to securitytest_test.
+func SLAViolation(container types.Container, sla map[string]time.Duration) (time.Duration, bool) {
+	return slaViolation(container, sla)
+}
+
+// SetSubpath exposes setSubpath to securitytest_test.
+func (scanInfo *SecTestScanInfo) SetSubpath(subpath string) {
+	scanInfo.setSubpath(subpath)
+}
+
+// PrefixSubpath exposes prefixSubpath to securitytest_test.
+func (scanInfo *SecTestScanInfo) PrefixSubpath() {
+	scanInfo.prefixSubpath()
+}
+
+// ExcludedPathsArgs exposes excludedPathsArgs to securitytest_test.
+func ExcludedPathsArgs(securityTestName string, excludedPaths []string) string {
+	return excludedPathsArgs(securityTestName, excludedPaths)
+}
+
+// FilterExcludedPaths exposes filterExcludedPaths to securitytest_test.
+func (scanInfo *SecTestScanInfo) FilterExcludedPaths() {
+	scanInfo.filterExcludedPaths()
+}
+
+// HandleFetchCode exposes handleFetchCode to securitytest_test.
+func HandleFetchCode(cmd string, source types.Source) string {
+	return handleFetchCode(cmd, source)
+}
+
+// CanScanSource exposes canScanSource to securitytest_test.
+func CanScanSource(securityTest types.SecurityTest, source types.Source) bool {
+	return canScanSource(securityTest, source)
+}
+
+// ExtractToolVersion exposes extractToolVersion to securitytest_test.
+func ExtractToolVersion(cOutput string) (string, string) {
+	return extractToolVersion(cOutput)
+}
+
+// HasExpectedVersion exposes hasExpectedVersion to securitytest_test.
+func (scanInfo *SecTestScanInfo) HasExpectedVersion() bool {
+	return scanInfo.hasExpectedVersion()
+}
+
+// ContainerFiles exposes containerFiles to securitytest_test.
+func (scanInfo *SecTestScanInfo) ContainerFiles() []types.ContainerFile {
+	return scanInfo.containerFiles()
+}
+
+// AddScan exposes addScan to securitytest_test.
+func (results *RunAllInfo) AddScan(scan SecTestScanInfo) {
+	results.addScan(scan)
+}
+
+// PrepareContainerAfterScan exposes prepareContainerAfterScan to securitytest_test.
+func (scanInfo *SecTestScanInfo) PrepareContainerAfterScan() {
+	scanInfo.prepareContainerAfterScan()
+}
+
+// HandleIgnoredIDs exposes handleIgnoredIDs to securitytest_test.
+func HandleIgnoredIDs(cmd, securityTestName string, ignores []types.Ignore) string {
+	return handleIgnoredIDs(cmd, securityTestName, ignores)
+}
+
+// HandleAdvisoryDBURL exposes handleAdvisoryDBURL to securitytest_test.
+func HandleAdvisoryDBURL(cmd, securityTestName string, advisoryDBURLs map[string]string) string {
+	return handleAdvisoryDBURL(cmd, securityTestName, advisoryDBURLs)
+}
+
+// HandleGitleaksConfig exposes handleGitleaksConfig to securitytest_test.
+func HandleGitleaksConfig(cmd string, allowlist *types.GitleaksAllowlist) string {
+	return handleGitleaksConfig(cmd, allowlist)
+}
+
+// ExtractCommit exposes extractCommit to securitytest_test.
+func ExtractCommit(cOutput string) (string, string) {
+	return extractCommit(cOutput)
+}
+
+// CacheKey exposes cacheKey to securitytest_test.
+func (scanInfo *SecTestScanInfo) CacheKey(imageDigest string) string {
+	return scanInfo.cacheKey(imageDigest)
+}
+
+// StoreCacheFindings exposes storeCacheFindings to securitytest_test.
+func (scanInfo *SecTestScanInfo) StoreCacheFindings() {
+	scanInfo.storeCacheFindings()
+}
+
+// HandleInternalPackagePrefixes exposes handleInternalPackagePrefixes to securitytest_test.
+func HandleInternalPackagePrefixes(cmd string, prefixes []string) string {
+	return handleInternalPackagePrefixes(cmd, prefixes)
+}
+
+// TargetDependencies exposes targetDependencies to securitytest_test, scanning each
+// securityTest at the subpath of the same index.
+func TargetDependencies(securityTests []types.SecurityTest, subpaths []string) ([][]int, error) {
+	targets := []scanTarget{}
+	for i, securityTest := range securityTests {
+		targets = append(targets, scanTarget{securityTest: securityTest, subpath: subpaths[i]})
+	}
+	return targetDependencies(targets)
+}
+
+// ChainedTargets exposes chainedTargets to securitytest_test.
+var ChainedTargets = chainedTargets
+
+// ContainerEnv exposes containerEnv to securitytest_test.
+func (scanInfo *SecTestScanInfo) ContainerEnv() []string {
+	return scanInfo.containerEnv()
+}
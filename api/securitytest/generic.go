@@ -0,0 +1,136 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// GenericParser parses the output of custom securityTests that print their findings
+// as a JSON array of GenericFinding, such as:
+//
+//	[{"severity": "HIGH", "file": "app/views.py", "line": 42, "title": "SQL injection",
+//	  "details": "User input reaches a raw query."}]
+//
+// severity, file and title are required and severity is HIGH, MEDIUM, LOW or NOSEC, in
+// any case. Unknown fields are not allowed, so a typo is not silently dropped. An output
+// that does not follow it, or that has more than maxGenericFindings, is an error.
+const GenericParser = "generic"
+
+// maxGenericFindings is how many findings a securityTest parsed by GenericParser can report.
+const maxGenericFindings = 10000
+
+// GenericFinding is a finding of a securityTest parsed by GenericParser.
+type GenericFinding struct {
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Title    string `json:"title"`
+	Details  string `json:"details"`
+}
+
+// parseGenericOutput returns the findings of cOutput or an error telling which
+// finding does not follow the GenericParser schema and why.
+func parseGenericOutput(cOutput string) ([]GenericFinding, error) {
+	decoder := json.NewDecoder(bytes.NewReader([]byte(cOutput)))
+	decoder.DisallowUnknownFields()
+	findings := []GenericFinding{}
+	if err := decoder.Decode(&findings); err != nil {
+		return nil, fmt.Errorf("output must be a JSON array of findings: %v", err)
+	}
+	if decoder.More() {
+		return nil, fmt.Errorf("output must be a single JSON array of findings")
+	}
+	if len(findings) > maxGenericFindings {
+		return nil, fmt.Errorf("output has %d findings, more than the limit of %d", len(findings), maxGenericFindings)
+	}
+	for i, finding := range findings {
+		missing := []string{}
+		if finding.Severity == "" {
+			missing = append(missing, "severity")
+		}
+		if finding.File == "" {
+			missing = append(missing, "file")
+		}
+		if finding.Title == "" {
+			missing = append(missing, "title")
+		}
+		if len(missing) > 0 {
+			return nil, fmt.Errorf("finding %d is missing %s", i, strings.Join(missing, ", "))
+		}
+		switch strings.ToUpper(finding.Severity) {
+		case "HIGH", "MEDIUM", "LOW", "NOSEC":
+		default:
+			return nil, fmt.Errorf("finding %d has unknown severity %q, it must be HIGH, MEDIUM, LOW or NOSEC", i, finding.Severity)
+		}
+		if finding.Line < 0 {
+			return nil, fmt.Errorf("finding %d has negative line %d", i, finding.Line)
+		}
+	}
+	return findings, nil
+}
+
+func analyzeGeneric(genericScan *SecTestScanInfo) error {
+
+	findings, err := parseGenericOutput(genericScan.Container.COutput)
+	if err != nil {
+		genericScan.setParseError(err)
+		// the schema error is what the owner of the securityTest has to fix
+		genericScan.Container.CInfo = fmt.Sprintf("Could not parse securityTest output: %v", err)
+		return nil
+	}
+
+	failSeverity := "MEDIUM"
+	if apiContext.APIConfiguration != nil && apiContext.APIConfiguration.GenericFailSeverity != "" {
+		failSeverity = apiContext.APIConfiguration.GenericFailSeverity
+	}
+
+	huskyCIGenericResults := types.HuskyCISecurityTestOutput{}
+	for _, finding := range findings {
+		genericVuln := types.HuskyCIVulnerability{
+			SecurityTool: genericScan.SecurityTestName,
+			Severity:     strings.ToUpper(finding.Severity),
+			File:         finding.File,
+			Type:         finding.Title,
+			Details:      finding.Details,
+		}
+		if finding.Line > 0 {
+			genericVuln.Line = strconv.Itoa(finding.Line)
+		}
+
+		if genericVuln.Severity == "NOSEC" {
+			huskyCIGenericResults.NoSecVulns = append(huskyCIGenericResults.NoSecVulns, genericVuln)
+			continue
+		}
+		// findings below the configured threshold are only warnings
+		if severityRank(genericVuln.Severity) < severityRank(failSeverity) {
+			huskyCIGenericResults.LowVulns = append(huskyCIGenericResults.LowVulns, genericVuln)
+			continue
+		}
+		switch genericVuln.Severity {
+		case "LOW":
+			huskyCIGenericResults.LowVulns = append(huskyCIGenericResults.LowVulns, genericVuln)
+		case "MEDIUM":
+			huskyCIGenericResults.MediumVulns = append(huskyCIGenericResults.MediumVulns, genericVuln)
+		case "HIGH":
+			huskyCIGenericResults.HighVulns = append(huskyCIGenericResults.HighVulns, genericVuln)
+		}
+	}
+
+	genericScan.Vulnerabilities = huskyCIGenericResults
+	genericScan.prepareContainerAfterScan()
+	// low findings only fail the container if the threshold is LOW
+	if failSeverity == "LOW" && len(huskyCIGenericResults.LowVulns) > 0 && genericScan.Container.CResult == "passed" {
+		genericScan.setIssuesFound()
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"fmt"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Generic", func() {
+
+	analyzeGeneric := func(cOutput string) securitytest.SecTestScanInfo {
+		scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "semgrep"}
+		scanInfo.Container.SecurityTest.Parser = securitytest.GenericParser
+		scanInfo.Container.COutput = cOutput
+		Expect(scanInfo.Analyze()).To(BeNil())
+		return scanInfo
+	}
+
+	Context("When the output has no findings", func() {
+		It("Should pass", func() {
+			scanInfo := analyzeGeneric(`[]`)
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+	})
+	Context("When the output follows the schema", func() {
+		It("Should fail on findings at or above MEDIUM by default", func() {
+			scanInfo := analyzeGeneric(`[
+				{"severity": "HIGH", "file": "a.py", "line": 1, "title": "sqli", "details": "raw query"},
+				{"severity": "low", "file": "b.py", "title": "weak hash"},
+				{"severity": "NOSEC", "file": "c.py", "title": "eval"}
+			]`)
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Details).To(Equal("raw query"))
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.NoSecVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+	Context("When a fail severity is configured", func() {
+		var previousConfig *apiContext.APIConfig
+		BeforeEach(func() {
+			previousConfig = apiContext.APIConfiguration
+		})
+		AfterEach(func() {
+			apiContext.APIConfiguration = previousConfig
+		})
+		It("Should only warn about findings below HIGH", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{GenericFailSeverity: "HIGH"}
+			scanInfo := analyzeGeneric(`[{"severity": "MEDIUM", "file": "a.py", "title": "sqli"}]`)
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+		It("Should fail on LOW findings if it is LOW", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{GenericFailSeverity: "LOW"}
+			scanInfo := analyzeGeneric(`[{"severity": "LOW", "file": "a.py", "title": "weak hash"}]`)
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+	Context("When the output does not follow the schema", func() {
+		It("Should mark the container as an error telling why", func() {
+			invalidOutputs := map[string]string{
+				`{"findings": []}`:                                                        "output must be a JSON array of findings",
+				`[{"severity": "HIGH", "file": "a.py"}]`:                                  "finding 0 is missing title",
+				`[{"file": "a.py", "title": "sqli"}, {"title": "xss"}]`:                   "finding 0 is missing severity",
+				`[{"severity": "CRITICAL", "file": "a.py", "title": "sqli"}]`:             `finding 0 has unknown severity "CRITICAL"`,
+				`[{"severity": "HIGH", "file": "a.py", "title": "sqli", "rule": "B101"}]`: `unknown field "rule"`,
+				`[] []`: "single JSON array",
+			}
+			for cOutput, reason := range invalidOutputs {
+				scanInfo := analyzeGeneric(cOutput)
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+				Expect(scanInfo.Container.CInfo).To(ContainSubstring(reason))
+				Expect(scanInfo.ErrorFound).ToNot(BeNil())
+			}
+		})
+		It("Should not accept more than 10000 findings", func() {
+			findings := []string{}
+			for i := 0; i < 10001; i++ {
+				findings = append(findings, fmt.Sprintf(`{"severity": "LOW", "file": "a%d.py", "title": "t"}`, i))
+			}
+			scanInfo := analyzeGeneric("[" + strings.Join(findings, ",") + "]")
+			Expect(scanInfo.Container.CResult).To(Equal("error"))
+			Expect(scanInfo.Container.CInfo).To(ContainSubstring("more than the limit of 10000"))
+		})
+	})
+})
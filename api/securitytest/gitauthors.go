@@ -5,8 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
-
 	"github.com/globocom/huskyCI/api/log"
 )
 
@@ -21,11 +19,9 @@ func analyzeGitAuthors(gitAuthorsScan *SecTestScanInfo) error {
 	gitAuthorsScan.FinalOutput = gitAuthorsOutput
 
 	// Unmarshall rawOutput into finalOutput, that is a GitAuthors struct.
-	if err := json.Unmarshal([]byte(gitAuthorsScan.Container.COutput), &gitAuthorsOutput); err != nil {
+	if err := gitAuthorsScan.unmarshalOutput(&gitAuthorsOutput); err != nil {
 		log.Error("analyzeGitAuthors", "GITAUTHORS", 1035, gitAuthorsScan.Container.COutput, err)
-		gitAuthorsScan.ErrorFound = err
-		gitAuthorsScan.prepareContainerAfterScan()
-		return err
+		return nil
 	}
 	gitAuthorsScan.FinalOutput = gitAuthorsOutput
 
@@ -0,0 +1,31 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"errors"
+	"strings"
+)
+
+func analyzeGitDiff(gitDiffScan *SecTestScanInfo) error {
+
+	// check if git diff failed running, usually due to an unknown base commit
+	if strings.Contains(gitDiffScan.Container.COutput, "ERROR_RUNNING_GIT_DIFF") {
+		gitDiffScan.ErrorFound = errors.New("error running git diff")
+		gitDiffScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// git diff --name-only prints a changed file per line
+	changedFiles := []string{}
+	for _, line := range strings.Split(gitDiffScan.Container.COutput, "\n") {
+		if file := strings.TrimSpace(line); file != "" {
+			changedFiles = append(changedFiles, file)
+		}
+	}
+	gitDiffScan.ChangedFiles = changedFiles
+	gitDiffScan.prepareContainerAfterScan()
+	return nil
+}
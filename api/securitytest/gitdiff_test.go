@@ -0,0 +1,35 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("GitDiff", func() {
+
+	Context("When git diff lists changed files", func() {
+		It("Should set ChangedFiles", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gitdiff"}
+			scanInfo.Container.COutput = "api/server.go\n\napp/views.py\n"
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.ChangedFiles).To(Equal([]string{"api/server.go", "app/views.py"}))
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+	})
+
+	Context("When git diff failed running", func() {
+		It("Should not set ChangedFiles", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gitdiff"}
+			scanInfo.Container.COutput = "ERROR_RUNNING_GIT_DIFF\nfatal: bad revision 'abc1234..HEAD'"
+			Expect(scanInfo.Analyze()).To(BeNil())
+			Expect(scanInfo.ChangedFiles).To(BeEmpty())
+			Expect(scanInfo.ErrorFound).To(HaveOccurred())
+		})
+	})
+})
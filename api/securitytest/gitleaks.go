@@ -5,7 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
 	"strings"
 
 	"github.com/globocom/huskyCI/api/log"
@@ -60,11 +59,9 @@ func analyseGitleaks(gitleaksScan *SecTestScanInfo) error {
 	}
 
 	// Unmarshall rawOutput into finalOutput, that is a GitleaksOutput struct.
-	if err := json.Unmarshal([]byte(gitleaksScan.Container.COutput), &gitLeaksOutput); err != nil {
+	if err := gitleaksScan.unmarshalOutput(&gitLeaksOutput); err != nil {
 		log.Error("analyzeGitleaks", "GITLEAKS", 1038, gitleaksScan.Container.COutput, err)
-		gitleaksScan.ErrorFound = err
-		gitleaksScan.prepareContainerAfterScan()
-		return err
+		return nil
 	}
 	gitleaksScan.FinalOutput = gitLeaksOutput
 
@@ -109,6 +106,7 @@ func (gitleaksScan *SecTestScanInfo) prepareGitleaksVulns() {
 
 		gitleaksVuln := types.HuskyCIVulnerability{}
 		gitleaksVuln.SecurityTool = "GitLeaks"
+		gitleaksVuln.RuleID = issue.Rule
 		gitleaksVuln.Details = issue.Rule + " @ [" + issue.Commit + "]"
 		gitleaksVuln.File = issue.File
 		gitleaksVuln.Code = issue.Line
@@ -0,0 +1,123 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// gitleaksConfigPlaceholder is replaced in a cmd by the flag of the gitleaks config to use:
+// the rendered one if the repository sets a GitleaksAllowlist or, if not, its own.
+const gitleaksConfigPlaceholder = "%GITLEAKS_CONFIG%"
+
+// gitleaksConfigPath is where the rendered gitleaks config is copied into the container.
+const gitleaksConfigPath = "/tmp/huskyci_gitleaks.toml"
+
+// gitleaksEntropyRule is the generic rule a GitleaksAllowlist can disable, as it
+// reports any high-entropy string assigned to a secret-looking name.
+const gitleaksEntropyRule = "Generic High Entropy"
+
+type gitleaksRule struct {
+	description string
+	regex       string
+	tags        []string
+	entropies   [][2]string
+}
+
+// gitleaksRules are the default rules of gitleaks, which a rendered config must list
+// as it replaces them.
+var gitleaksRules = []gitleaksRule{
+	{description: "AWS Client ID", regex: `(A3T[A-Z0-9]|AKIA|AGPA|AIDA|AROA|AIPA|ANPA|ANVA|ASIA)[A-Z0-9]{16}`, tags: []string{"key", "AWS"}},
+	{description: "AWS Secret Key", regex: `(?i)aws(.{0,20})?(?-i)['\"][0-9a-zA-Z\/+]{40}['\"]`, tags: []string{"key", "AWS"}},
+	{description: "AWS MWS key", regex: `amzn\.mws\.[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`, tags: []string{"key", "AWS", "MWS"}},
+	{description: "Facebook Secret Key", regex: `(?i)(facebook|fb)(.{0,20})?(?-i)['\"][0-9a-f]{32}['\"]`, tags: []string{"key", "Facebook"}},
+	{description: "Github", regex: `(?i)github(.{0,20})?(?-i)['\"][0-9a-zA-Z]{35,40}['\"]`, tags: []string{"key", "Github"}},
+	{description: "Google API key", regex: `AIza[0-9A-Za-z\\-_]{35}`, tags: []string{"key", "Google"}},
+	{description: "Heroku API key", regex: `(?i)heroku(.{0,20})?['"][0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}['"]`, tags: []string{"key", "Heroku"}},
+	{description: "Slack", regex: `xox[baprs]-([0-9a-zA-Z]{10,48})?`, tags: []string{"key", "Slack"}},
+	{description: "Slack Webhook", regex: `https://hooks.slack.com/services/T[a-zA-Z0-9_]{8}/B[a-zA-Z0-9_]{8}/[a-zA-Z0-9_]{24}`, tags: []string{"key", "slack"}},
+	{description: "Stripe API key", regex: `(?i)stripe(.{0,20})?['\"][sk|rk]_live_[0-9a-zA-Z]{24}`, tags: []string{"key", "Stripe"}},
+	{description: "Twilio API key", regex: `(?i)twilio(.{0,20})?['\"][0-9a-f]{32}['\"]`, tags: []string{"key", "twilio"}},
+	{description: "Asymmetric Private Key", regex: `-----BEGIN ((EC|PGP|DSA|RSA|OPENSSH) )?PRIVATE KEY( BLOCK)?-----`, tags: []string{"key", "AsymmetricPrivateKey"}},
+	{description: gitleaksEntropyRule, regex: `(?i)(api_key|apikey|secret|token|passw(or)?d)(.{0,20})?['\"]([0-9a-zA-Z\-_=+\/]{16,64})['\"]`, tags: []string{"entropy", "generic"}, entropies: [][2]string{{"4.5", "6.0"}}},
+}
+
+// handleGitleaksConfig replaces %GITLEAKS_CONFIG% in cmd by the flag of the config gitleaks uses.
+func handleGitleaksConfig(cmd string, allowlist *types.GitleaksAllowlist) string {
+	flag := "--repo-config"
+	if allowlist != nil {
+		flag = "--config=" + gitleaksConfigPath
+	}
+	return strings.Replace(cmd, gitleaksConfigPlaceholder, flag, -1)
+}
+
+// gitleaksConfigFiles returns the rendered gitleaks config of a scan, if it has a GitleaksAllowlist.
+func (scanInfo *SecTestScanInfo) gitleaksConfigFiles() []types.ContainerFile {
+	if scanInfo.GitleaksAllowlist == nil || scanInfo.SecurityTestName != "gitleaks" {
+		return nil
+	}
+	return []types.ContainerFile{{Path: gitleaksConfigPath, Content: []byte(renderGitleaksConfig(*scanInfo.GitleaksAllowlist))}}
+}
+
+// renderGitleaksConfig returns the TOML config of gitleaks with allowlist set as the whitelist
+// of every rule, except of the ones tagged with any of its ProtectedTags.
+func renderGitleaksConfig(allowlist types.GitleaksAllowlist) string {
+	var config strings.Builder
+	config.WriteString("title = \"huskyCI gitleaks config\"\n")
+	for _, rule := range gitleaksRules {
+		if allowlist.DisableEntropy && rule.description == gitleaksEntropyRule {
+			continue
+		}
+		fmt.Fprintf(&config, "\n[[rules]]\ndescription = %q\nregex = '''%s'''\ntags = [%s]\n", rule.description, rule.regex, quoteTOMLStrings(rule.tags))
+		for _, entropy := range rule.entropies {
+			fmt.Fprintf(&config, "[[rules.Entropies]]\nMin = %q\nMax = %q\n", entropy[0], entropy[1])
+		}
+		if hasProtectedTag(rule.tags, allowlist.ProtectedTags) {
+			continue
+		}
+		for _, allowedPath := range allowlist.Paths {
+			fmt.Fprintf(&config, "[[rules.whitelist]]\ndescription = \"huskyCI allowlisted path\"\nfile = '''%s'''\n", globToRegex(allowedPath))
+		}
+		for _, allowedRegex := range allowlist.Regexes {
+			fmt.Fprintf(&config, "[[rules.whitelist]]\ndescription = \"huskyCI allowlisted regex\"\nregex = '''%s'''\n", allowedRegex)
+		}
+	}
+	return config.String()
+}
+
+func hasProtectedTag(tags, protectedTags []string) bool {
+	for _, tag := range tags {
+		for _, protectedTag := range protectedTags {
+			if strings.EqualFold(tag, protectedTag) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func quoteTOMLStrings(values []string) string {
+	quoted := []string{}
+	for _, value := range values {
+		quoted = append(quoted, fmt.Sprintf("%q", value))
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// globToRegex converts a path, or file glob, as matched by isExcludedPath into a regex:
+// it matches the path itself, any file under it or, for a glob, any file it matches.
+func globToRegex(glob string) string {
+	glob = strings.Trim(glob, "/")
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.Replace(quoted, `\*\*`, `.*`, -1)
+	quoted = strings.Replace(quoted, `\*`, `[^/]*`, -1)
+	quoted = strings.Replace(quoted, `\?`, `[^/]`, -1)
+	quoted = strings.Replace(strings.Replace(quoted, `\[`, `[`, -1), `\]`, `]`, -1)
+	return `(^|/)` + quoted + `(/|$)`
+}
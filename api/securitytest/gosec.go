@@ -5,16 +5,15 @@
 package securitytest
 
 import (
-	"encoding/json"
-
 	"github.com/globocom/huskyCI/api/log"
 	"github.com/globocom/huskyCI/api/types"
 )
 
 // GosecOutput is the struct that holds all data from Gosec output.
 type GosecOutput struct {
-	GosecIssues []GosecIssue `json:"Issues"`
-	GosecStats  GosecStats   `json:"Stats"`
+	GosecIssues  []GosecIssue `json:"Issues"`
+	GosecStats   GosecStats   `json:"Stats"`
+	GosecVersion string       `json:"GosecVersion"`
 }
 
 // GosecIssue is the struct that holds all issues from Gosec output.
@@ -48,13 +47,12 @@ func analyzeGosec(gosecScan *SecTestScanInfo) error {
 	}
 
 	// Unmarshall rawOutput into finalOutput, that is a GosecOutput struct.
-	if err := json.Unmarshal([]byte(gosecScan.Container.COutput), &goSecOutput); err != nil {
+	if err := gosecScan.unmarshalOutput(&goSecOutput); err != nil {
 		log.Error("analyzeGosec", "GOSEC", 1002, gosecScan.Container.COutput, err)
-		gosecScan.ErrorFound = err
-		gosecScan.prepareContainerAfterScan()
-		return err
+		return nil
 	}
 	gosecScan.FinalOutput = goSecOutput
+	gosecScan.Container.ScannerVersion = goSecOutput.GosecVersion
 
 	// check results and prepare all vulnerabilities found
 	gosecScan.prepareGosecVulns()
@@ -73,11 +71,17 @@ func (gosecScan *SecTestScanInfo) prepareGosecVulns() {
 		gosecVuln.SecurityTool = "GoSec"
 		gosecVuln.Severity = issue.Severity
 		gosecVuln.Confidence = issue.Confidence
+		gosecVuln.RuleID = issue.RuleID
 		gosecVuln.Details = issue.Details
 		gosecVuln.File = issue.File
 		gosecVuln.Line = issue.Line
 		gosecVuln.Code = issue.Code
 
+		// findings below the configured confidence are only warnings
+		if belowMinConfidence(gosecScan.SecurityTestName, gosecVuln.Confidence) {
+			huskyCIgosecResults.LowVulns = append(huskyCIgosecResults.LowVulns, gosecVuln)
+			continue
+		}
 		switch gosecVuln.Severity {
 		case "LOW":
 			huskyCIgosecResults.LowVulns = append(huskyCIgosecResults.LowVulns, gosecVuln)
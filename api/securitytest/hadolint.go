@@ -0,0 +1,153 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// HadolintOutput is the struct that holds every rule violation Hadolint found in the
+// Dockerfiles of a repository. A clean Dockerfile is reported as an empty array.
+type HadolintOutput []HadolintIssue
+
+// HadolintIssue is a rule violation of a Dockerfile, such as DL3008, or of one of its shell
+// commands, such as SC2086. Its level is error, warning, info or style.
+type HadolintIssue struct {
+	File    string `json:"file"`
+	Line    int    `json:"line"`
+	Column  int    `json:"column"`
+	Code    string `json:"code"`
+	Level   string `json:"level"`
+	Message string `json:"message"`
+}
+
+// Hadolint levels of an issue, from the most to the least severe.
+const (
+	hadolintLevelError   = "error"
+	hadolintLevelWarning = "warning"
+	hadolintLevelInfo    = "info"
+	hadolintLevelStyle   = "style"
+)
+
+// hadolintLevelRank ranks the levels of Hadolint, so they can be compared to the fail level.
+func hadolintLevelRank(level string) int {
+	switch level {
+	case hadolintLevelError:
+		return 4
+	case hadolintLevelWarning:
+		return 3
+	case hadolintLevelInfo:
+		return 2
+	case hadolintLevelStyle:
+		return 1
+	}
+	return 0
+}
+
+// hadolintSeverity maps a level of Hadolint into the severity of its findings.
+func hadolintSeverity(level string) string {
+	switch level {
+	case hadolintLevelError:
+		return "HIGH"
+	case hadolintLevelWarning:
+		return "MEDIUM"
+	}
+	return "LOW"
+}
+
+func analyzeHadolint(hadolintScan *SecTestScanInfo) error {
+
+	hadolintOutput := HadolintOutput{}
+	hadolintScan.FinalOutput = hadolintOutput
+
+	// check if Hadolint failed running, such as with a Dockerfile it could not read
+	if strings.Contains(hadolintScan.Container.COutput, "ERROR_RUNNING_HADOLINT") {
+		hadolintScan.ErrorFound = errors.New("error running hadolint")
+		hadolintScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// nil cOutput states that no Issues were found.
+	if hadolintScan.Container.COutput == "" {
+		hadolintScan.prepareContainerAfterScan()
+		return nil
+	}
+
+	// Unmarshall rawOutput into finalOutput, that is a HadolintOutput struct.
+	if err := hadolintScan.unmarshalOutput(&hadolintOutput); err != nil {
+		log.Error("analyzeHadolint", "HADOLINT", 1062, hadolintScan.Container.COutput, err)
+		return nil
+	}
+	hadolintScan.FinalOutput = hadolintOutput
+
+	// check results and prepare all vulnerabilities found
+	failsOnLow := hadolintScan.prepareHadolintVulns()
+	hadolintScan.prepareContainerAfterScan()
+	// findings of info or style level only fail the container if the fail level is as low
+	if failsOnLow && hadolintScan.Container.CResult == "passed" {
+		hadolintScan.setIssuesFound()
+	}
+	return nil
+}
+
+// prepareHadolintVulns stores the findings of Hadolint and returns whether a low severity
+// one is at or above the fail level, as those do not fail the container by themselves.
+func (hadolintScan *SecTestScanInfo) prepareHadolintVulns() bool {
+
+	huskyCIhadolintResults := types.HuskyCISecurityTestOutput{}
+	hadolintOutput := hadolintScan.FinalOutput.(HadolintOutput)
+
+	failLevel := hadolintLevelError
+	if apiContext.APIConfiguration != nil && apiContext.APIConfiguration.HadolintFailLevel != "" {
+		failLevel = apiContext.APIConfiguration.HadolintFailLevel
+	}
+	failsOnLow := false
+
+	// Hadolint reports a rule once per instruction, but the same line may hold more than one
+	seen := map[string]bool{}
+
+	for _, issue := range hadolintOutput {
+		key := fmt.Sprintf("%s\x00%s\x00%d", issue.Code, issue.File, issue.Line)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		hadolintVuln := types.HuskyCIVulnerability{
+			Language:     "Dockerfile",
+			SecurityTool: "Hadolint",
+			Severity:     hadolintSeverity(issue.Level),
+			RuleID:       issue.Code,
+			Details:      issue.Message,
+			File:         issue.File,
+			Line:         strconv.Itoa(issue.Line),
+		}
+
+		// findings below the configured level are only warnings
+		if hadolintLevelRank(issue.Level) < hadolintLevelRank(failLevel) {
+			huskyCIhadolintResults.LowVulns = append(huskyCIhadolintResults.LowVulns, hadolintVuln)
+			continue
+		}
+		switch hadolintVuln.Severity {
+		case "HIGH":
+			huskyCIhadolintResults.HighVulns = append(huskyCIhadolintResults.HighVulns, hadolintVuln)
+		case "MEDIUM":
+			huskyCIhadolintResults.MediumVulns = append(huskyCIhadolintResults.MediumVulns, hadolintVuln)
+		default:
+			failsOnLow = true
+			huskyCIhadolintResults.LowVulns = append(huskyCIhadolintResults.LowVulns, hadolintVuln)
+		}
+	}
+
+	hadolintScan.Vulnerabilities = huskyCIhadolintResults
+	return failsOnLow
+}
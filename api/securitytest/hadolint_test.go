@@ -0,0 +1,108 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const hadolintReport = `[
+  {"file":"Dockerfile","line":1,"column":1,"code":"DL3007","level":"warning","message":"Using latest is prone to errors if the image will ever update."},
+  {"file":"Dockerfile","line":3,"column":1,"code":"DL3020","level":"error","message":"Use COPY instead of ADD for files and folders"},
+  {"file":"Dockerfile","line":3,"column":1,"code":"DL3020","level":"error","message":"Use COPY instead of ADD for files and folders"},
+  {"file":"build/Dockerfile.ci","line":5,"column":1,"code":"DL3059","level":"info","message":"Multiple consecutive RUN instructions. Consider consolidation."}
+]`
+
+var _ = Describe("Hadolint", func() {
+
+	analyzeHadolint := func(output string) securitytest.SecTestScanInfo {
+		scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "hadolint"}
+		scanInfo.Container.COutput = output
+		Expect(scanInfo.Analyze()).To(BeNil())
+		return scanInfo
+	}
+
+	var previousConfig *apiContext.APIConfig
+	BeforeEach(func() {
+		previousConfig = apiContext.APIConfiguration
+	})
+	AfterEach(func() {
+		apiContext.APIConfiguration = previousConfig
+	})
+
+	Context("When Hadolint reports issues of every level", func() {
+		It("Should only fail on errors, reported once per code, file and line", func() {
+			scanInfo := analyzeHadolint(hadolintReport)
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].RuleID).To(Equal("DL3020"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].File).To(Equal("Dockerfile"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].Line).To(Equal("3"))
+			Expect(scanInfo.Vulnerabilities.HighVulns[0].SecurityTool).To(Equal("Hadolint"))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+
+		It("Should report warnings and info as low findings keeping their severity", func() {
+			scanInfo := analyzeHadolint(hadolintReport)
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(BeEmpty())
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(2))
+			Expect(scanInfo.Vulnerabilities.LowVulns[0].Severity).To(Equal("MEDIUM"))
+			Expect(scanInfo.Vulnerabilities.LowVulns[1].Severity).To(Equal("LOW"))
+		})
+	})
+
+	Context("When a lower fail level is configured", func() {
+		It("Should fail on warnings", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{HadolintFailLevel: "warning"}
+			scanInfo := analyzeHadolint(`[{"file":"Dockerfile","line":1,"column":1,"code":"DL3007","level":"warning","message":"Using latest"}]`)
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+		It("Should fail on style findings if it is style", func() {
+			apiContext.APIConfiguration = &apiContext.APIConfig{HadolintFailLevel: "style"}
+			scanInfo := analyzeHadolint(`[{"file":"Dockerfile","line":2,"column":1,"code":"DL3059","level":"style","message":"Multiple consecutive RUN instructions"}]`)
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+	})
+
+	Context("When Hadolint only reports warnings", func() {
+		It("Should not fail the securityTest", func() {
+			scanInfo := analyzeHadolint(`[{"file":"Dockerfile","line":1,"column":1,"code":"DL3007","level":"warning","message":"Using latest"}]`)
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+		})
+	})
+
+	Context("When the Dockerfiles are clean", func() {
+		It("Should pass the securityTest", func() {
+			scanInfo := analyzeHadolint(`[]`)
+			Expect(scanInfo.Vulnerabilities.HighVulns).To(BeEmpty())
+			Expect(scanInfo.Vulnerabilities.LowVulns).To(BeEmpty())
+			Expect(scanInfo.Container.CResult).To(Equal("passed"))
+			Expect(scanInfo.Container.CInfo).To(Equal("No issues found."))
+		})
+	})
+
+	Context("When Hadolint could not run", func() {
+		It("Should mark its container as an error", func() {
+			scanInfo := analyzeHadolint("ERROR_RUNNING_HADOLINT\nhadolint: Dockerfile: openBinaryFile: permission denied")
+			Expect(scanInfo.Container.CResult).To(Equal("error"))
+		})
+	})
+
+	Context("When the repository could not be cloned", func() {
+		It("Should return the clone error", func() {
+			scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "hadolint"}
+			scanInfo.Container.COutput = `ERROR_CLONING:{"code":"branch_not_found","stderr":"fatal: Remote branch nope not found"}`
+			err := scanInfo.Analyze()
+			Expect(err).To(HaveOccurred())
+			Expect(scanInfo.ErrorFound).To(Equal(&securitytest.CloneError{Code: securitytest.CloneErrorBranchNotFound, Stderr: "fatal: Remote branch nope not found"}))
+		})
+	})
+})
@@ -0,0 +1,89 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+)
+
+// ignoredIDsPlaceholder is replaced in a cmd by the flags that make its securityTest
+// skip the vulnerability IDs ignored by the repository, or by an empty string.
+const ignoredIDsPlaceholder = "%IGNORED_IDS%"
+
+// ignoredIDsFormatters formats ignored vulnerability IDs as the flags of the securityTests
+// that can skip them. Findings of every securityTest are also filtered after parsing.
+var ignoredIDsFormatters = map[string]func(ignoredIDs []string) string{
+	"safety": safetyIgnoredIDs,
+}
+
+// ActiveIgnores returns the ignores that have not expired at now.
+func ActiveIgnores(ignores []types.Ignore, now time.Time) []types.Ignore {
+	activeIgnores := []types.Ignore{}
+	for _, ignore := range ignores {
+		if ignore.IsActive(now) {
+			activeIgnores = append(activeIgnores, ignore)
+		}
+	}
+	return activeIgnores
+}
+
+// ignoredIDs returns the vulnerability IDs of a given securityTest ignored at now.
+func ignoredIDs(securityTestName string, ignores []types.Ignore, now time.Time) []string {
+	IDs := []string{}
+	for _, ignore := range ActiveIgnores(ignores, now) {
+		if ignore.SecurityTest == securityTestName {
+			IDs = append(IDs, ignore.ID)
+		}
+	}
+	return IDs
+}
+
+// handleIgnoredIDs replaces %IGNORED_IDS% in cmd by the flags of a given securityTest.
+func handleIgnoredIDs(cmd, securityTestName string, ignores []types.Ignore) string {
+	args := ""
+	IDs := ignoredIDs(securityTestName, ignores, time.Now())
+	if formatter, ok := ignoredIDsFormatters[securityTestName]; ok && len(IDs) > 0 {
+		args = formatter(IDs)
+	}
+	return strings.Replace(cmd, ignoredIDsPlaceholder, args, -1)
+}
+
+func safetyIgnoredIDs(ignoredIDs []string) string {
+	args := []string{}
+	for _, ID := range ignoredIDs {
+		args = append(args, fmt.Sprintf("--ignore %s", util.ShellQuote(ID)))
+	}
+	return strings.Join(args, " ")
+}
+
+// filterIgnoredIDs moves findings whose vulnerability ID is ignored to NoSecVulns, so
+// they are stored and counted as ignored instead of failing the analysis.
+func (scanInfo *SecTestScanInfo) filterIgnoredIDs() {
+	IDs := ignoredIDs(scanInfo.SecurityTestName, scanInfo.Ignores, time.Now())
+	if len(IDs) == 0 {
+		return
+	}
+	ignored := map[string]bool{}
+	for _, ID := range IDs {
+		ignored[ID] = true
+	}
+	vulnerabilities := &scanInfo.Vulnerabilities
+	for _, vulns := range []*[]types.HuskyCIVulnerability{&vulnerabilities.HighVulns, &vulnerabilities.MediumVulns, &vulnerabilities.LowVulns} {
+		keptVulns := []types.HuskyCIVulnerability{}
+		for _, vuln := range *vulns {
+			if vuln.RuleID != "" && ignored[vuln.RuleID] {
+				vulnerabilities.NoSecVulns = append(vulnerabilities.NoSecVulns, vuln)
+				continue
+			}
+			keptVulns = append(keptVulns, vuln)
+		}
+		*vulns = keptVulns
+	}
+}
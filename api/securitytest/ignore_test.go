@@ -0,0 +1,95 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"time"
+
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Ignore", func() {
+
+	now := time.Now()
+	ignores := []types.Ignore{
+		{SecurityTest: "safety", ID: "38414", Reason: "no fix yet"},
+		{SecurityTest: "safety", ID: "25853", ExpiresAt: now.Add(-time.Hour)},
+		{SecurityTest: "safety", ID: "36810", ExpiresAt: now.Add(time.Hour)},
+		{SecurityTest: "bandit", ID: "B101"},
+	}
+
+	Describe("ActiveIgnores", func() {
+		Context("When some ignores expired", func() {
+			It("Should return only the ones that did not", func() {
+				Expect(securitytest.ActiveIgnores(ignores, now)).To(Equal([]types.Ignore{ignores[0], ignores[2], ignores[3]}))
+			})
+		})
+	})
+
+	Describe("HandleIgnoredIDs", func() {
+		cmd := "safety check --json %IGNORED_IDS% > output.json"
+		Context("When the securityTest can skip ignored IDs", func() {
+			It("Should pass the ones that did not expire to it", func() {
+				Expect(securitytest.HandleIgnoredIDs(cmd, "safety", ignores)).To(Equal("safety check --json --ignore '38414' --ignore '36810' > output.json"))
+			})
+		})
+		Context("When no ID of the securityTest is ignored", func() {
+			It("Should replace the placeholder by an empty string", func() {
+				Expect(securitytest.HandleIgnoredIDs(cmd, "safety", ignores[3:])).To(Equal("safety check --json  > output.json"))
+			})
+		})
+	})
+
+	Describe("Analyze", func() {
+		Context("When Safety still reports an ignored ID", func() {
+			It("Should store it as ignored instead of failing", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "safety", Ignores: ignores}
+				scanInfo.Container.COutput = `{"issues":[{"dependency":"django","vulnerable_below":"<2.2.10","installed_version":"2.2.0","description":"SQL injection","id":"38414"},{"dependency":"jinja2","vulnerable_below":"<2.10.1","installed_version":"2.10","description":"Sandbox escape","id":"25853"}]}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+				Expect(scanInfo.Vulnerabilities.HighVulns[0].RuleID).To(Equal("25853"))
+				Expect(scanInfo.Vulnerabilities.NoSecVulns).To(HaveLen(1))
+				Expect(scanInfo.Vulnerabilities.NoSecVulns[0].RuleID).To(Equal("38414"))
+				Expect(scanInfo.Container.CResult).To(Equal("failed"))
+			})
+		})
+	})
+
+	Describe("Analyze with suppressions", func() {
+		BeforeEach(func() {
+			securitytest.SuppressionFingerprint = func(repositoryURL, securityTestName string, vuln types.HuskyCIVulnerability) string {
+				return repositoryURL + "|" + securityTestName + "|" + vuln.RuleID
+			}
+		})
+		AfterEach(func() {
+			securitytest.SuppressionFingerprint = nil
+		})
+		Context("When a finding has an approved suppression", func() {
+			It("Should store it as suppressed instead of failing", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "safety", URL: "https://github.com/globocom/huskyCI.git"}
+				scanInfo.Suppressions = []string{"https://github.com/globocom/huskyCI.git|safety|38414", "https://github.com/globocom/huskyCI.git|safety|25853"}
+				scanInfo.Container.COutput = `{"issues":[{"dependency":"django","vulnerable_below":"<2.2.10","installed_version":"2.2.0","description":"SQL injection","id":"38414"},{"dependency":"jinja2","vulnerable_below":"<2.10.1","installed_version":"2.10","description":"Sandbox escape","id":"25853"}]}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Vulnerabilities.HighVulns).To(BeEmpty())
+				Expect(scanInfo.Vulnerabilities.NoSecVulns).To(HaveLen(2))
+				Expect(scanInfo.Container.CResult).To(Equal("passed"))
+			})
+		})
+		Context("When the suppression of another repository is approved", func() {
+			It("Should still fail", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "safety", URL: "https://github.com/globocom/huskyCI.git"}
+				scanInfo.Suppressions = []string{"https://github.com/globocom/gokong.git|safety|38414"}
+				scanInfo.Container.COutput = `{"issues":[{"dependency":"django","vulnerable_below":"<2.2.10","installed_version":"2.2.0","description":"SQL injection","id":"38414"}]}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Vulnerabilities.NoSecVulns).To(BeEmpty())
+				Expect(scanInfo.Container.CResult).To(Equal("failed"))
+			})
+		})
+	})
+})
@@ -0,0 +1,112 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// manifestLanguages maps a manifest file name into the language of its repository.
+var manifestLanguages = map[string]string{
+	"package.json":      "JavaScript",
+	"package-lock.json": "JavaScript",
+	"yarn.lock":         "JavaScript",
+	"requirements.txt":  "Python",
+	"Pipfile":           "Python",
+	"setup.py":          "Python",
+	"go.mod":            "Go",
+	"Gopkg.toml":        "Go",
+	"Gemfile":           "Ruby",
+	"Gemfile.lock":      "Ruby",
+	"pom.xml":           "Java",
+	"build.gradle":      "Java",
+}
+
+// extensionLanguages maps a file extension into its language.
+var extensionLanguages = map[string]string{
+	".js":   "JavaScript",
+	".jsx":  "JavaScript",
+	".py":   "Python",
+	".go":   "Go",
+	".rb":   "Ruby",
+	".java": "Java",
+}
+
+// detectLanguagesFromFiles returns the sorted languages found in a list of files.
+func detectLanguagesFromFiles(files []string) []string {
+	seen := map[string]bool{}
+	languages := []string{}
+	for _, file := range files {
+		language, ok := manifestLanguages[filepath.Base(file)]
+		if !ok {
+			language, ok = extensionLanguages[strings.ToLower(filepath.Ext(file))]
+		}
+		if !ok || seen[language] {
+			continue
+		}
+		seen[language] = true
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// addManifestLanguages adds to enry codes the languages that are only noticed
+// by their manifest files, such as a Java project without any .java file.
+func addManifestLanguages(codes []types.Code) []types.Code {
+	found := map[string]bool{}
+	files := []string{}
+	for _, code := range codes {
+		found[code.Language] = true
+		files = append(files, code.Files...)
+	}
+	for _, language := range detectLanguagesFromFiles(files) {
+		if found[language] {
+			continue
+		}
+		manifests := []string{}
+		for _, file := range files {
+			if manifestLanguages[filepath.Base(file)] == language {
+				manifests = append(manifests, file)
+			}
+		}
+		codes = append(codes, types.Code{Language: language, Files: manifests})
+	}
+	return codes
+}
+
+// getLanguageSecurityTests returns the securityTests that run for a given language.
+// Languages set in LanguageToolMapping run only their configured securityTests,
+// while the others run every default securityTest of their language.
+func getLanguageSecurityTests(language string) ([]types.SecurityTest, error) {
+	securityTestNames, ok := apiContext.APIConfiguration.LanguageToolMapping[strings.ToLower(language)]
+	if !ok {
+		return getAllDefaultSecurityTests("Language", language)
+	}
+	securityTests := []types.SecurityTest{}
+	for _, securityTestName := range securityTestNames {
+		securityTestQuery := map[string]interface{}{"name": securityTestName}
+		securityTest, err := apiContext.APIConfiguration.DBInstance.FindOneDBSecurityTest(securityTestQuery)
+		if err != nil {
+			log.Error("getLanguageSecurityTests", "SECURITYTEST", 2009, err)
+			return securityTests, err
+		}
+		securityTests = append(securityTests, securityTest)
+	}
+	// custom securityTests are not in languageToolMapping, so they run without a restart
+	customQuery := map[string]interface{}{"language": language, "default": true, "custom": true}
+	customSecurityTests, err := apiContext.APIConfiguration.DBInstance.FindAllDBSecurityTest(customQuery)
+	if err != nil && !isNotFound(err) {
+		log.Error("getLanguageSecurityTests", "SECURITYTEST", 2009, err)
+		return securityTests, err
+	}
+	return append(securityTests, customSecurityTests...), nil
+}
@@ -0,0 +1,41 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Languages", func() {
+
+	Describe("DetectLanguagesFromFiles", func() {
+		Context("When files have known extensions and manifests", func() {
+			It("Should return their sorted languages once", func() {
+				files := []string{"main.go", "cmd/server.go", "web/package.json", "requirements.txt", "README.md", "pom.xml", "Gemfile"}
+				Expect(securitytest.DetectLanguagesFromFiles(files)).To(Equal([]string{"Go", "Java", "JavaScript", "Python", "Ruby"}))
+			})
+		})
+		Context("When no file is known", func() {
+			It("Should return no language", func() {
+				Expect(securitytest.DetectLanguagesFromFiles([]string{"README.md", "Makefile"})).To(BeEmpty())
+			})
+		})
+	})
+
+	Describe("AddManifestLanguages", func() {
+		Context("When a manifest belongs to a language not found by enry", func() {
+			It("Should add it with its manifest files", func() {
+				codes := []types.Code{{Language: "Go", Files: []string{"main.go"}}, {Language: "XML", Files: []string{"pom.xml"}}}
+				codes = securitytest.AddManifestLanguages(codes)
+				Expect(codes).To(HaveLen(3))
+				Expect(codes[2]).To(Equal(types.Code{Language: "Java", Files: []string{"pom.xml"}}))
+			})
+		})
+	})
+})
@@ -5,7 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
 	"strings"
 
 	"github.com/globocom/huskyCI/api/log"
@@ -27,11 +26,14 @@ type Vulnerability struct {
 	VulnerableVersions string    `json:"vulnerable_versions"`
 	Severity           string    `json:"severity"`
 	Overview           string    `json:"overview"`
+	PatchedVersions    string    `json:"patched_versions"`
 }
 
-// Finding holds the version of a given security issue found
+// Finding holds the version of a given security issue found and the dependency paths,
+// such as "express>qs", that require it.
 type Finding struct {
-	Version string `json:"version"`
+	Version string   `json:"version"`
+	Paths   []string `json:"paths"`
 }
 
 // Metadata is the struct that holds vulnerabilities summary
@@ -69,9 +71,9 @@ func analyzeNpmaudit(npmAuditScan *SecTestScanInfo) error {
 	}
 
 	// Unmarshall rawOutput into finalOutput, that is a NpmAuditOutput struct.
-	if err := json.Unmarshal([]byte(npmAuditScan.Container.COutput), &npmAuditOutput); err != nil {
+	if err := npmAuditScan.unmarshalOutput(&npmAuditOutput); err != nil {
 		log.Error("analyzeNpmaudit", "NPMAUDIT", 1014, npmAuditScan.Container.COutput, err)
-		return err
+		return nil
 	}
 	npmAuditScan.FinalOutput = npmAuditOutput
 
@@ -107,6 +109,9 @@ func (npmAuditScan *SecTestScanInfo) prepareNpmAuditVulns() {
 		for _, findings := range issue.Findings {
 			npmauditVuln.Version = findings.Version
 		}
+		npmauditVuln.DependencyDepth = dependencyDepth(issue.Findings)
+		// vulnerabilities that do not fail the scan are reported as low, keeping their severity
+		fails := npmAuditFails(npmAuditScan.NpmAuditFailOn, npmauditVuln.DependencyDepth, hasFix(issue))
 
 		switch issue.Severity {
 		case "info", "low":
@@ -114,9 +119,17 @@ func (npmAuditScan *SecTestScanInfo) prepareNpmAuditVulns() {
 			huskyCInpmauditResults.LowVulns = append(huskyCInpmauditResults.LowVulns, npmauditVuln)
 		case "moderate":
 			npmauditVuln.Severity = "medium"
+			if !fails {
+				huskyCInpmauditResults.LowVulns = append(huskyCInpmauditResults.LowVulns, npmauditVuln)
+				continue
+			}
 			huskyCInpmauditResults.MediumVulns = append(huskyCInpmauditResults.MediumVulns, npmauditVuln)
 		case "high", "critical":
 			npmauditVuln.Severity = "high"
+			if !fails {
+				huskyCInpmauditResults.LowVulns = append(huskyCInpmauditResults.LowVulns, npmauditVuln)
+				continue
+			}
 			huskyCInpmauditResults.HighVulns = append(huskyCInpmauditResults.HighVulns, npmauditVuln)
 		}
 
@@ -124,3 +137,34 @@ func (npmAuditScan *SecTestScanInfo) prepareNpmAuditVulns() {
 
 	npmAuditScan.Vulnerabilities = huskyCInpmauditResults
 }
+
+// dependencyDepth returns whether any path of findings requires the vulnerable package directly,
+// such as "qs", or all of them do so through other packages, such as "express>qs".
+func dependencyDepth(findings []Finding) string {
+	for _, finding := range findings {
+		for _, path := range finding.Paths {
+			if !strings.Contains(path, ">") {
+				return types.DependencyDirect
+			}
+		}
+	}
+	return types.DependencyTransitive
+}
+
+// hasFix returns whether some version of the vulnerable package of an advisory is patched,
+// as npm audit sets patched_versions to "<0.0.0" when none is.
+func hasFix(advisory Vulnerability) bool {
+	return advisory.PatchedVersions != "" && advisory.PatchedVersions != "<0.0.0"
+}
+
+// npmAuditFails returns whether a vulnerability of a package of a given dependency depth
+// fails a scan with a given types.NpmAuditFailOn option.
+func npmAuditFails(failOn, depth string, fixable bool) bool {
+	switch failOn {
+	case types.NpmAuditFailOnDirect:
+		return depth == types.DependencyDirect
+	case types.NpmAuditFailOnDirectOrFixable:
+		return depth == types.DependencyDirect || fixable
+	}
+	return true
+}
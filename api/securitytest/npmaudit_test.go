@@ -0,0 +1,84 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const npmAuditReport = `{
+  "advisories": {
+    "1": {"id": 1, "module_name": "lodash", "severity": "high", "overview": "Prototype pollution.", "patched_versions": "<0.0.0",
+          "findings": [{"version": "4.17.4", "paths": ["lodash", "async>lodash"]}]},
+    "2": {"id": 2, "module_name": "minimist", "severity": "critical", "overview": "Prototype pollution.", "patched_versions": ">=1.2.6",
+          "findings": [{"version": "1.2.0", "paths": ["mkdirp>minimist"]}]},
+    "3": {"id": 3, "module_name": "qs", "severity": "moderate", "overview": "Denial of service.", "patched_versions": "<0.0.0",
+          "findings": [{"version": "6.0.0", "paths": ["express>body-parser>qs"]}]}
+  },
+  "metadata": {"vulnerabilities": {"moderate": 1, "high": 1, "critical": 1}}
+}`
+
+func npmAuditScan(failOn string) securitytest.SecTestScanInfo {
+	scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "npmaudit", NpmAuditFailOn: failOn}
+	scanInfo.Container.COutput = npmAuditReport
+	Expect(scanInfo.Analyze()).To(BeNil())
+	return scanInfo
+}
+
+func vulnsByModule(vulns []types.HuskyCIVulnerability) map[string]types.HuskyCIVulnerability {
+	byModule := map[string]types.HuskyCIVulnerability{}
+	for _, vuln := range vulns {
+		byModule[vuln.Code] = vuln
+	}
+	return byModule
+}
+
+var _ = Describe("NpmAudit", func() {
+
+	Context("When no npm audit fail on is set", func() {
+		It("Should fail on every medium and high vulnerability", func() {
+			scanInfo := npmAuditScan("")
+			Expect(vulnsByModule(scanInfo.Vulnerabilities.HighVulns)).To(HaveLen(2))
+			Expect(vulnsByModule(scanInfo.Vulnerabilities.MediumVulns)).To(HaveKey("qs"))
+			Expect(scanInfo.Container.CResult).To(Equal("failed"))
+		})
+
+		It("Should set the dependency depth of each vulnerability", func() {
+			scanInfo := npmAuditScan(types.NpmAuditFailOnAll)
+			highVulns := vulnsByModule(scanInfo.Vulnerabilities.HighVulns)
+			Expect(highVulns["lodash"].DependencyDepth).To(Equal(types.DependencyDirect))
+			Expect(highVulns["minimist"].DependencyDepth).To(Equal(types.DependencyTransitive))
+			Expect(scanInfo.Vulnerabilities.MediumVulns[0].DependencyDepth).To(Equal(types.DependencyTransitive))
+		})
+	})
+
+	Context("When it fails on direct dependencies only", func() {
+		It("Should report vulnerabilities of transitive ones as low, keeping their severity", func() {
+			scanInfo := npmAuditScan(types.NpmAuditFailOnDirect)
+			Expect(vulnsByModule(scanInfo.Vulnerabilities.HighVulns)).To(HaveLen(1))
+			Expect(vulnsByModule(scanInfo.Vulnerabilities.HighVulns)).To(HaveKey("lodash"))
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(BeEmpty())
+			lowVulns := vulnsByModule(scanInfo.Vulnerabilities.LowVulns)
+			Expect(lowVulns["minimist"].Severity).To(Equal("high"))
+			Expect(lowVulns["minimist"].DependencyDepth).To(Equal(types.DependencyTransitive))
+			Expect(lowVulns["qs"].Severity).To(Equal("medium"))
+		})
+	})
+
+	Context("When it fails on direct dependencies or fixable transitive ones", func() {
+		It("Should report only transitive vulnerabilities without a fix as low", func() {
+			scanInfo := npmAuditScan(types.NpmAuditFailOnDirectOrFixable)
+			highVulns := vulnsByModule(scanInfo.Vulnerabilities.HighVulns)
+			Expect(highVulns).To(HaveKey("lodash"))
+			Expect(highVulns).To(HaveKey("minimist"))
+			Expect(scanInfo.Vulnerabilities.MediumVulns).To(BeEmpty())
+			Expect(vulnsByModule(scanInfo.Vulnerabilities.LowVulns)).To(HaveKey("qs"))
+		})
+	})
+})
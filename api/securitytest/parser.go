@@ -0,0 +1,109 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"github.com/globocom/huskyCI/api/parser"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// builtinParser is a securityTool shipped with huskyCI. Its analyze function parses the
+// output of a scan and also sets the scan flags, such as ReqNotFound, used by its result.
+type builtinParser struct {
+	name    string
+	analyze func(scanInfo *SecTestScanInfo) error
+}
+
+func init() {
+	builtinParsers := []builtinParser{
+		{bandit, analyzeBandit},
+		{brakeman, analyzeBrakeman},
+		{"enry", analyzeEnry},
+		{"gitauthors", analyzeGitAuthors},
+		{gosec, analyzeGosec},
+		{npmaudit, analyzeNpmaudit},
+		{yarnaudit, analyzeYarnaudit},
+		{eslint, analyzeEslint},
+		{spotbugs, analyzeSpotBugs},
+		{gitleaks, analyseGitleaks},
+		{safety, analyzeSafety},
+		{dependencycheck, analyzeDependencyCheck},
+		{depconfusion, analyzeDepConfusion},
+		{hadolint, analyzeHadolint},
+		{"gitdiff", analyzeGitDiff},
+		{GenericParser, analyzeGeneric},
+	}
+	for _, builtin := range builtinParsers {
+		if err := parser.Registry.Register(builtin); err != nil {
+			panic(err)
+		}
+	}
+}
+
+func (builtin builtinParser) Name() string {
+	return builtin.name
+}
+
+// Parse returns every vulnerability builtin finds in cOutput, suppressed ones included.
+func (builtin builtinParser) Parse(cOutput string) ([]types.HuskyCIVulnerability, error) {
+	scanInfo := SecTestScanInfo{SecurityTestName: builtin.name}
+	scanInfo.Container.COutput = cOutput
+	if err := builtin.analyze(&scanInfo); err != nil {
+		return nil, err
+	}
+	if scanInfo.ErrorFound != nil {
+		return nil, scanInfo.ErrorFound
+	}
+	vulns := []types.HuskyCIVulnerability{}
+	vulns = append(vulns, scanInfo.Vulnerabilities.HighVulns...)
+	vulns = append(vulns, scanInfo.Vulnerabilities.MediumVulns...)
+	vulns = append(vulns, scanInfo.Vulnerabilities.LowVulns...)
+	vulns = append(vulns, scanInfo.Vulnerabilities.NoSecVulns...)
+	return vulns, nil
+}
+
+// ToolImage is empty, as the images of built-in securityTools are set in config.yaml.
+func (builtin builtinParser) ToolImage() parser.Image {
+	return parser.Image{}
+}
+
+// isBuiltin returns whether securityTestName is a securityTool shipped with huskyCI.
+func isBuiltin(securityTestName string) bool {
+	registered, ok := parser.Registry.Get(securityTestName)
+	if !ok {
+		return false
+	}
+	_, ok = registered.(builtinParser)
+	return ok
+}
+
+// analyzePlugin parses the output of a securityTest with the Parser of a plugin and
+// sorts its findings by severity, as built-in securityTools do.
+func (scanInfo *SecTestScanInfo) analyzePlugin(pluginParser parser.Parser) error {
+	vulns, err := pluginParser.Parse(scanInfo.Container.COutput)
+	if err != nil {
+		scanInfo.setParseError(err)
+		return nil
+	}
+	for _, vuln := range vulns {
+		// findings below the configured confidence are only warnings
+		if vuln.Severity != "NOSEC" && belowMinConfidence(scanInfo.SecurityTestName, vuln.Confidence) {
+			scanInfo.Vulnerabilities.LowVulns = append(scanInfo.Vulnerabilities.LowVulns, vuln)
+			continue
+		}
+		switch vuln.Severity {
+		case "NOSEC":
+			scanInfo.Vulnerabilities.NoSecVulns = append(scanInfo.Vulnerabilities.NoSecVulns, vuln)
+		case "LOW":
+			scanInfo.Vulnerabilities.LowVulns = append(scanInfo.Vulnerabilities.LowVulns, vuln)
+		case "MEDIUM":
+			scanInfo.Vulnerabilities.MediumVulns = append(scanInfo.Vulnerabilities.MediumVulns, vuln)
+		case "HIGH":
+			scanInfo.Vulnerabilities.HighVulns = append(scanInfo.Vulnerabilities.HighVulns, vuln)
+		}
+	}
+	scanInfo.prepareContainerAfterScan()
+	return nil
+}
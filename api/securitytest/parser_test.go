@@ -0,0 +1,100 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"encoding/json"
+
+	"github.com/globocom/huskyCI/api/parser"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// internalScanner is a plugin whose output is a JSON array of vulnerabilities.
+type internalScanner struct{}
+
+func (internalScanner) Name() string {
+	return "internalscanner"
+}
+
+func (internalScanner) Parse(cOutput string) ([]types.HuskyCIVulnerability, error) {
+	vulns := []types.HuskyCIVulnerability{}
+	err := json.Unmarshal([]byte(cOutput), &vulns)
+	return vulns, err
+}
+
+func (internalScanner) ToolImage() parser.Image {
+	return parser.Image{Name: "example/internalscanner", Tag: "1.0.0"}
+}
+
+// the plugin is registered once, as a huskyCI API loads its plugins
+var _ = parser.Registry.Register(internalScanner{})
+
+var _ = Describe("Parser", func() {
+
+	Describe("Built-in parsers", func() {
+		It("Should be registered at init", func() {
+			Expect(parser.Registry.Names()).To(ContainElement("bandit"))
+			Expect(parser.Registry.Names()).To(ContainElement("gitleaks"))
+		})
+		It("Should parse the output of their securityTool", func() {
+			bandit, ok := parser.Registry.Get("bandit")
+			Expect(ok).To(BeTrue())
+			vulns, err := bandit.Parse(`{"results": [{"filename": "app.py", "issue_severity": "HIGH", "line_number": 3, "test_id": "B105"}]}`)
+			Expect(err).To(BeNil())
+			Expect(vulns).To(HaveLen(1))
+			Expect(vulns[0].RuleID).To(Equal("B105"))
+			Expect(vulns[0].Line).To(Equal("3"))
+		})
+		It("Should return an error if the output can not be parsed", func() {
+			gosec, _ := parser.Registry.Get("gosec")
+			_, err := gosec.Parse(`{"Issues": [`)
+			Expect(err).ToNot(BeNil())
+		})
+	})
+
+	Describe("Analyze", func() {
+		Context("When a securityTest is parsed by a plugin", func() {
+			It("Should sort its findings by severity", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "internalscanner"}
+				scanInfo.Container.COutput = `[{"severity": "HIGH", "file": "a.go"}, {"severity": "LOW", "file": "b.go"}, {"severity": "NOSEC", "file": "c.go"}]`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+				Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(1))
+				Expect(scanInfo.Vulnerabilities.NoSecVulns).To(HaveLen(1))
+				Expect(scanInfo.Container.CResult).To(Equal("failed"))
+			})
+		})
+		Context("When a plugin can not parse the output", func() {
+			It("Should mark the container as an error", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "internalscanner"}
+				scanInfo.Container.COutput = "<html>"
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+			})
+		})
+		Context("When no parser is registered for the securityTest", func() {
+			It("Should return an error", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "unknownscanner"}
+				Expect(scanInfo.Analyze()).ToNot(BeNil())
+			})
+		})
+	})
+
+	Describe("AddScan", func() {
+		Context("When a securityTest parsed by a plugin finishes", func() {
+			It("Should store its findings under its name", func() {
+				results := securitytest.RunAllInfo{}
+				scan := securitytest.SecTestScanInfo{SecurityTestName: "internalscanner"}
+				scan.Vulnerabilities.HighVulns = []types.HuskyCIVulnerability{{File: "a.go"}}
+				results.AddScan(scan)
+				Expect(results.HuskyCIResults.PluginResults["internalscanner"].HighVulns).To(HaveLen(1))
+			})
+		})
+	})
+})
@@ -1,11 +1,15 @@
 package securitytest
 
 import (
+	"fmt"
+	"strings"
 	"sync"
 
 	apiContext "github.com/globocom/huskyCI/api/context"
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
 	"github.com/globocom/huskyCI/api/log"
 	"github.com/globocom/huskyCI/api/types"
+	"golang.org/x/sync/errgroup"
 )
 
 // RunAllInfo store all scans results of an Analysis
@@ -16,8 +20,30 @@ type RunAllInfo struct {
 	CommitAuthors  []string
 	Codes          []types.Code
 	FinalResult    string
+	ScanType       string
 	ErrorFound     error
 	HuskyCIResults types.HuskyCIResults
+	// Ref is the branch or tag scanned, if it is another ref than the Branch of the analysis.
+	Ref string
+	// Commit is the SHA of the commit scanned, if it is known.
+	Commit string
+	// Subpaths, if set, are the directories scanned by language securityTests instead of the repository root.
+	Subpaths []types.Subpath
+	// OnContainerFinished, if set, is called with every container finished so far
+	// each time one finishes, so the analysis can be updated while it runs.
+	OnContainerFinished func(containers []types.Container)
+	// OnOutput, if set, is called with the last lines written by the container of securityTest
+	// scanning subpath while it runs, see OutputTail.
+	OnOutput func(securityTest, subpath string, lines []string)
+	// mutex guards the results of securityTests that finish at the same time.
+	mutex sync.Mutex
+}
+
+// scanTarget is a securityTest and the subpath it scans, which is empty
+// for generic securityTests as they scan the whole repository.
+type scanTarget struct {
+	securityTest types.SecurityTest
+	subpath      string
 }
 
 const bandit = "bandit"
@@ -28,181 +54,229 @@ const npmaudit = "npmaudit"
 const yarnaudit = "yarnaudit"
 const spotbugs = "spotbugs"
 const gitleaks = "gitleaks"
+const dependencycheck = "dependencycheck"
+const depconfusion = "depconfusion"
+const hadolint = "hadolint"
+const eslint = "eslint"
+
+// ScanErrors holds the error of each securityTest of an analysis that could not run.
+type ScanErrors []error
+
+func (scanErrors ScanErrors) Error() string {
+	messages := []string{}
+	for _, err := range scanErrors {
+		messages = append(messages, err.Error())
+	}
+	return strings.Join(messages, "; ")
+}
 
-// Start runs both generic and language security
+// Start runs the generic and language securityTests of an analysis, at most
+// SecurityTestParallelism of them at a time. A securityTest that could not run does not
+// stop the other ones, so every container is kept and their errors are returned together.
+// A securityTest that depends on other ones only starts once all of them finished
+// successfully, and does not run if any of them did not.
 func (results *RunAllInfo) Start(enryScan SecTestScanInfo) error {
 
 	results.Codes = enryScan.Codes
-	errChan := make(chan error)
-	waitChan := make(chan struct{})
-	syncChan := make(chan struct{})
-
-	var wg sync.WaitGroup
+	results.Commit = enryScan.Commit
 
-	defer close(errChan)
-	defer results.setToAnalysis()
-	wg.Add(2)
-
-	go func() {
-		defer wg.Done()
-		if err := results.runGenericScans(enryScan); err != nil {
-			select {
-			case <-syncChan:
-				return
-			case errChan <- err:
-				return
-			}
-		}
-	}()
-
-	go func() {
-		defer wg.Done()
-		if err := results.runLanguageScans(enryScan); err != nil {
-			select {
-			case <-syncChan:
-				return
-			case errChan <- err:
-				return
-			}
-		}
-	}()
-
-	go func() {
-		wg.Wait()
-		close(waitChan)
-	}()
-
-	select {
-	case <-waitChan:
-		return nil
-	case err := <-errChan:
-		close(syncChan)
+	genericTargets, err := genericTargets(enryScan.Source)
+	if err != nil {
 		results.ErrorFound = err
 		return err
 	}
-}
-
-func (results *RunAllInfo) runGenericScans(enryScan SecTestScanInfo) error {
-
-	errChan := make(chan error)
-	waitChan := make(chan struct{})
-	syncChan := make(chan struct{})
-
-	var wg sync.WaitGroup
-
-	defer close(errChan)
-
-	genericTests, err := getAllDefaultSecurityTests("Generic", "")
+	languageTargets, err := results.languageTargets(enryScan.Codes, enryScan.Source)
+	if err != nil {
+		results.ErrorFound = err
+		return err
+	}
+	targets := append(genericTargets, languageTargets...)
+	dependencies, err := targetDependencies(targets)
 	if err != nil {
+		results.ErrorFound = err
 		return err
 	}
+	// the targets that depend on each other share a volume, such as to scan what a build wrote
+	chained := chainedTargets(dependencies)
+	sharedVolume := ""
+	if len(chained) > 0 {
+		sharedVolume = huskydocker.SharedVolumeName(enryScan.RID)
+		defer huskydocker.RemoveSharedVolume(sharedVolume)
+	}
 
-	for genericTestIndex := range genericTests {
-		wg.Add(1)
-		go func(genericTest *types.SecurityTest) {
-			defer wg.Done()
-			newGenericScan := SecTestScanInfo{}
-			if err := newGenericScan.New(enryScan.RID, enryScan.URL, enryScan.Branch, genericTest.Name); err != nil {
-				select {
-				case <-syncChan:
-					return
-				case errChan <- err:
-					return
-				}
-			}
-			if err := newGenericScan.Start(); err != nil {
-				select {
-				case <-syncChan:
-					return
-				case errChan <- err:
-					return
+	parallelism := apiContext.APIConfiguration.SecurityTestParallelism
+	if parallelism <= 0 {
+		parallelism = 1
+	}
+	slots := make(chan struct{}, parallelism)
+	scanErrors := ScanErrors{}
+	var scanErrorsMutex sync.Mutex
+	var group errgroup.Group
+	// finished is closed once the target of the same index finished, succeeded tells whether it did successfully
+	finished := make([]chan struct{}, len(targets))
+	succeeded := make([]bool, len(targets))
+	for i := range targets {
+		finished[i] = make(chan struct{})
+	}
+
+	for i, target := range targets {
+		i, target := i, target
+		group.Go(func() error {
+			defer close(finished[i])
+			err := waitDependencies(target.securityTest.Name, targets, dependencies[i], finished, succeeded)
+			if err == nil {
+				slots <- struct{}{}
+				volume := ""
+				if chained[i] {
+					volume = sharedVolume
 				}
+				succeeded[i], err = results.runScan(enryScan, target, volume)
+				<-slots
 			}
-			results.Containers = append(results.Containers, newGenericScan.Container)
-			if genericTest.Name == "gitauthors" {
-				results.CommitAuthors = newGenericScan.CommitAuthors.Authors
-			} else if genericTest.Name == "gitleaks" {
-				results.setVulns(newGenericScan)
+			if err != nil {
+				scanErrorsMutex.Lock()
+				scanErrors = append(scanErrors, err)
+				scanErrorsMutex.Unlock()
 			}
-		}(&genericTests[genericTestIndex])
+			return nil
+		})
 	}
+	group.Wait()
 
-	go func() {
-		wg.Wait()
-		close(waitChan)
-	}()
-
-	select {
-	case <-waitChan:
-		return nil
-	case err := <-errChan:
-		close(syncChan)
-		return err
+	if len(scanErrors) > 0 {
+		results.ErrorFound = scanErrors
+		return scanErrors
 	}
+	return nil
 }
 
-func (results *RunAllInfo) runLanguageScans(enryScan SecTestScanInfo) error {
-
-	errChan := make(chan error)
-	waitChan := make(chan struct{})
-	syncChan := make(chan struct{})
+// waitDependencies waits for the targets of dependencies of the securityTest name to finish
+// and returns an error if any of them did not succeed.
+func waitDependencies(name string, targets []scanTarget, dependencies []int, finished []chan struct{}, succeeded []bool) error {
+	for _, dependency := range dependencies {
+		<-finished[dependency]
+		if !succeeded[dependency] {
+			return fmt.Errorf("%s did not run as %s did not finish successfully", name, targets[dependency].securityTest.Name)
+		}
+	}
+	return nil
+}
 
-	var wg sync.WaitGroup
+// runScan runs the securityTest of target with the settings of enryScan, mounting
+// sharedVolume if it is set, and adds its container and findings to results, even if the
+// securityTest could not complete. It returns whether the securityTest finished
+// successfully, whatever it found.
+func (results *RunAllInfo) runScan(enryScan SecTestScanInfo, target scanTarget, sharedVolume string) (bool, error) {
+	newScan := SecTestScanInfo{}
+	if err := newScan.New(enryScan.RID, enryScan.URL, enryScan.Branch, target.securityTest.Name); err != nil {
+		return false, err
+	}
+	newScan.ChangedFiles = enryScan.ChangedFiles
+	newScan.Deadline = enryScan.Deadline
+	newScan.Context = enryScan.Context
+	newScan.ExcludedPaths = enryScan.ExcludedPaths
+	newScan.Source = enryScan.Source
+	newScan.ExtraEnv = enryScan.ExtraEnv
+	newScan.Ignores = enryScan.Ignores
+	newScan.Suppressions = enryScan.Suppressions
+	newScan.GitleaksAllowlist = enryScan.GitleaksAllowlist
+	newScan.NpmAuditFailOn = enryScan.NpmAuditFailOn
+	newScan.Commit = enryScan.Commit
+	newScan.CommitSHA = enryScan.CommitSHA
+	newScan.Force = enryScan.Force
+	newScan.HTTPSToken = enryScan.HTTPSToken
+	newScan.Files = enryScan.Files
+	newScan.SharedVolume = sharedVolume
+	newScan.OutputHandler = results.TailOutput(target.securityTest.Name, target.subpath)
+	newScan.setSubpath(target.subpath)
+	// an unchanged commit scanned by the same image is not scanned again, unless what it
+	// writes to the shared volume is needed by the ones depending on it
+	if sharedVolume == "" && newScan.loadCachedScan() {
+		results.addScan(newScan)
+		return true, nil
+	}
+	err := newScan.Start()
+	newScan.storeCacheFindings()
+	results.addScan(newScan)
+	return err == nil && !newScan.TimedOut && !newScan.Canceled, err
+}
 
-	defer close(errChan)
+// addScan adds the container and findings of a finished scan to results and
+// reports every container finished so far to OnContainerFinished.
+func (results *RunAllInfo) addScan(scan SecTestScanInfo) {
+	results.mutex.Lock()
+	defer results.mutex.Unlock()
+	// a cached container may have scanned the same commit through another ref
+	scan.Container.Ref = scan.Branch
+	results.Containers = append(results.Containers, scan.Container)
+	if scan.SecurityTestName == "gitauthors" {
+		results.CommitAuthors = scan.CommitAuthors.Authors
+	} else {
+		results.setVulns(scan)
+	}
+	// called while locked, so an older list of containers is never reported after a newer one
+	if results.OnContainerFinished != nil {
+		results.OnContainerFinished(append([]types.Container{}, results.Containers...))
+	}
+}
 
-	languageTests := []types.SecurityTest{}
-	for _, code := range enryScan.Codes {
-		codeTests, err := getAllDefaultSecurityTests("Language", code.Language)
-		if err != nil {
-			return err
+// genericTargets returns the default generic securityTests that can scan source.
+func genericTargets(source types.Source) ([]scanTarget, error) {
+	genericTests, err := getAllDefaultSecurityTests("Generic", "")
+	if err != nil {
+		return nil, err
+	}
+	genericTargets := []scanTarget{}
+	for _, genericTest := range genericTests {
+		if canScanSource(genericTest, source) {
+			genericTargets = append(genericTargets, scanTarget{securityTest: genericTest})
 		}
-		languageTests = append(languageTests, codeTests...)
 	}
+	return genericTargets, nil
+}
 
-	for languageTestIndex := range languageTests {
-		wg.Add(1)
-		go func(languageTest *types.SecurityTest) {
-			defer wg.Done()
-			newLanguageScan := SecTestScanInfo{}
-			if err := newLanguageScan.New(enryScan.RID, enryScan.URL, enryScan.Branch, languageTest.Name); err != nil {
-				select {
-				case <-syncChan:
-					return
-				case errChan <- err:
-					return
-				}
+// languageTargets returns the language securityTests of the languages found in codes, run at the
+// repository root, or, if subpaths were given, the ones of each subpath, run at that subpath.
+// securityTests that can not scan source are left out.
+func (results *RunAllInfo) languageTargets(codes []types.Code, source types.Source) ([]scanTarget, error) {
+	codeLanguages := []string{}
+	for _, code := range codes {
+		codeLanguages = append(codeLanguages, code.Language)
+	}
+	subpaths := results.Subpaths
+	if len(subpaths) == 0 {
+		subpaths = []types.Subpath{{Languages: codeLanguages}}
+	}
+
+	languageTargets := []scanTarget{}
+	for _, subpath := range subpaths {
+		languages := subpath.Languages
+		if len(languages) == 0 {
+			languages = codeLanguages
+		}
+		for _, language := range languages {
+			languageTests, err := getLanguageSecurityTests(language)
+			if err != nil {
+				return nil, err
 			}
-			if err := newLanguageScan.Start(); err != nil {
-				results.Containers = append(results.Containers, newLanguageScan.Container)
-				select {
-				case <-syncChan:
-					return
-				case errChan <- err:
-					return
+			for _, languageTest := range languageTests {
+				if !canScanSource(languageTest, source) {
+					continue
 				}
+				languageTargets = append(languageTargets, scanTarget{securityTest: languageTest, subpath: subpath.Path})
 			}
-			results.Containers = append(results.Containers, newLanguageScan.Container)
-			results.setVulns(newLanguageScan)
-		}(&languageTests[languageTestIndex])
-	}
-
-	go func() {
-		wg.Wait()
-		close(waitChan)
-	}()
-
-	select {
-	case <-waitChan:
-		return nil
-	case err := <-errChan:
-		close(syncChan)
-		return err
+		}
 	}
+	return languageTargets, nil
 }
 
 func (results *RunAllInfo) setVulns(securityTestScan SecTestScanInfo) {
 
+	if !isBuiltin(securityTestScan.SecurityTestName) {
+		results.setPluginVulns(securityTestScan)
+		return
+	}
+
 	for _, highVuln := range securityTestScan.Vulnerabilities.HighVulns {
 		switch securityTestScan.SecurityTestName {
 		case bandit:
@@ -217,10 +291,18 @@ func (results *RunAllInfo) setVulns(securityTestScan SecTestScanInfo) {
 			results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.HighVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.HighVulns, highVuln)
 		case yarnaudit:
 			results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.HighVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.HighVulns, highVuln)
+		case eslint:
+			results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.HighVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.HighVulns, highVuln)
 		case spotbugs:
 			results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.HighVulns = append(results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.HighVulns, highVuln)
 		case gitleaks:
 			results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.HighVulns = append(results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.HighVulns, highVuln)
+		case dependencycheck:
+			results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.HighVulns = append(results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.HighVulns, highVuln)
+		case depconfusion:
+			results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.HighVulns = append(results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.HighVulns, highVuln)
+		case hadolint:
+			results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.HighVulns = append(results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.HighVulns, highVuln)
 		}
 	}
 
@@ -238,10 +320,18 @@ func (results *RunAllInfo) setVulns(securityTestScan SecTestScanInfo) {
 			results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.MediumVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.MediumVulns, mediumVuln)
 		case yarnaudit:
 			results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.MediumVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.MediumVulns, mediumVuln)
+		case eslint:
+			results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.MediumVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.MediumVulns, mediumVuln)
 		case spotbugs:
 			results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.MediumVulns = append(results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.MediumVulns, mediumVuln)
 		case gitleaks:
 			results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.MediumVulns = append(results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.MediumVulns, mediumVuln)
+		case dependencycheck:
+			results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.MediumVulns = append(results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.MediumVulns, mediumVuln)
+		case depconfusion:
+			results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.MediumVulns = append(results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.MediumVulns, mediumVuln)
+		case hadolint:
+			results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.MediumVulns = append(results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.MediumVulns, mediumVuln)
 		}
 	}
 
@@ -259,10 +349,18 @@ func (results *RunAllInfo) setVulns(securityTestScan SecTestScanInfo) {
 			results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.LowVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.LowVulns, lowVuln)
 		case yarnaudit:
 			results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.LowVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.LowVulns, lowVuln)
+		case eslint:
+			results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.LowVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.LowVulns, lowVuln)
 		case spotbugs:
 			results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.LowVulns = append(results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.LowVulns, lowVuln)
 		case gitleaks:
 			results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.LowVulns = append(results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.LowVulns, lowVuln)
+		case dependencycheck:
+			results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.LowVulns = append(results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.LowVulns, lowVuln)
+		case depconfusion:
+			results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.LowVulns = append(results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.LowVulns, lowVuln)
+		case hadolint:
+			results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.LowVulns = append(results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.LowVulns, lowVuln)
 		}
 	}
 
@@ -280,51 +378,42 @@ func (results *RunAllInfo) setVulns(securityTestScan SecTestScanInfo) {
 			results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.NoSecVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCINpmAuditOutput.NoSecVulns, noSec)
 		case yarnaudit:
 			results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.NoSecVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIYarnAuditOutput.NoSecVulns, noSec)
+		case eslint:
+			results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.NoSecVulns = append(results.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.NoSecVulns, noSec)
 		case spotbugs:
 			results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.NoSecVulns = append(results.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.NoSecVulns, noSec)
 		case gitleaks:
 			results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.NoSecVulns = append(results.HuskyCIResults.GenericResults.HuskyCIGitleaksOutput.NoSecVulns, noSec)
+		case dependencycheck:
+			results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.NoSecVulns = append(results.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.NoSecVulns, noSec)
+		case depconfusion:
+			results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.NoSecVulns = append(results.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.NoSecVulns, noSec)
+		case hadolint:
+			results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.NoSecVulns = append(results.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.NoSecVulns, noSec)
 		}
 	}
 }
 
-// SetAnalysisError sets error on an analysis that did not got to the setToAnalysis phase
-func (results *RunAllInfo) SetAnalysisError(err error) {
-	results.ErrorFound = err
-	results.Status = "error running"
-	results.FinalResult = "error"
-}
-
-func (results *RunAllInfo) setToAnalysis() {
-
-	results.Status = "finished"
-	results.FinalResult = "passed"
-
-	if results.ErrorFound != nil {
-		results.Status = "error running"
-		results.FinalResult = "error"
+// setPluginVulns stores the vulnerabilities of a securityTest parsed by a plugin under its name.
+func (results *RunAllInfo) setPluginVulns(securityTestScan SecTestScanInfo) {
+	vulns := securityTestScan.Vulnerabilities
+	if len(vulns.HighVulns)+len(vulns.MediumVulns)+len(vulns.LowVulns)+len(vulns.NoSecVulns) == 0 {
 		return
 	}
-
-	jsWarningFlag := false
-
-	for _, container := range results.Containers {
-		switch container.CResult {
-		case "warning":
-			if container.SecurityTest.Language == "JavaScript" {
-				if jsWarningFlag {
-					results.FinalResult = "warning"
-				} else {
-					jsWarningFlag = true
-				}
-			} else {
-				results.FinalResult = "warning"
-			}
-		case "failed":
-			results.FinalResult = "failed"
-			return
-		}
+	if results.HuskyCIResults.PluginResults == nil {
+		results.HuskyCIResults.PluginResults = map[string]types.HuskyCISecurityTestOutput{}
 	}
+	output := results.HuskyCIResults.PluginResults[securityTestScan.SecurityTestName]
+	output.HighVulns = append(output.HighVulns, vulns.HighVulns...)
+	output.MediumVulns = append(output.MediumVulns, vulns.MediumVulns...)
+	output.LowVulns = append(output.LowVulns, vulns.LowVulns...)
+	output.NoSecVulns = append(output.NoSecVulns, vulns.NoSecVulns...)
+	results.HuskyCIResults.PluginResults[securityTestScan.SecurityTestName] = output
+}
+
+// SetAnalysisError sets error on an analysis that could not run all of its securityTests.
+func (results *RunAllInfo) SetAnalysisError(err error) {
+	results.ErrorFound = err
 }
 
 func getAllDefaultSecurityTests(typeOf, language string) ([]types.SecurityTest, error) {
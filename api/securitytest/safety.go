@@ -5,7 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
 	"errors"
 	"strings"
 
@@ -69,11 +68,9 @@ func analyzeSafety(safetyScan *SecTestScanInfo) error {
 	safetyScan.Container.COutput = cOutputSanitized
 
 	// Unmarshall rawOutput into finalOutput, that is a Safety struct.
-	if err := json.Unmarshal([]byte(safetyScan.Container.COutput), &safetyOutput); err != nil {
+	if err := safetyScan.unmarshalOutput(&safetyOutput); err != nil {
 		log.Error("analyzeSafety", "SAFETY", 1018, safetyScan.Container.COutput, err)
-		safetyScan.ErrorFound = err
-		safetyScan.prepareContainerAfterScan()
-		return err
+		return nil
 	}
 	safetyScan.FinalOutput = safetyOutput
 
@@ -128,6 +125,7 @@ func (safetyScan *SecTestScanInfo) prepareSafetyVulns() {
 		safetyVuln.Details = issue.Comment
 		safetyVuln.Code = issue.Dependency + " " + issue.Version
 		safetyVuln.VunerableBelow = issue.Below
+		safetyVuln.RuleID = issue.ID
 
 		huskyCIsafetyResults.HighVulns = append(huskyCIsafetyResults.HighVulns, safetyVuln)
 	}
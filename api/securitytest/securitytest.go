@@ -1,30 +1,21 @@
 package securitytest
 
 import (
-	"errors"
-	"strings"
+	"encoding/json"
+	"fmt"
 	"time"
+	"unicode/utf8"
 
 	apiContext "github.com/globocom/huskyCI/api/context"
 	huskydocker "github.com/globocom/huskyCI/api/dockers"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/parser"
+	"github.com/globocom/huskyCI/api/timeline"
 	"github.com/globocom/huskyCI/api/types"
 	"github.com/globocom/huskyCI/api/util"
+	goContext "golang.org/x/net/context"
 )
 
-var securityTestAnalyze = map[string]func(scanInfo *SecTestScanInfo) error{
-	"bandit":     analyzeBandit,
-	"brakeman":   analyzeBrakeman,
-	"enry":       analyzeEnry,
-	"gitauthors": analyzeGitAuthors,
-	"gosec":      analyzeGosec,
-	"npmaudit":   analyzeNpmaudit,
-	"yarnaudit":  analyzeYarnaudit,
-	"spotbugs":   analyzeSpotBugs,
-	"gitleaks":   analyseGitleaks,
-	"safety":     analyzeSafety,
-}
-
 // SecTestScanInfo holds all information of securityTest scan.
 type SecTestScanInfo struct {
 	RID                   string
@@ -39,14 +30,76 @@ type SecTestScanInfo struct {
 	YarnErrorRunning      bool
 	GitleaksErrorRunning  bool
 	GitleaksTimeout       bool
+	ParseErrorFound       bool
+	TimedOut              bool
+	Canceled              bool
 	CommitAuthorsNotFound bool
 	CommitAuthors         GitAuthorsOutput
 	Codes                 []types.Code
 	Container             types.Container
 	FinalOutput           interface{}
 	Vulnerabilities       types.HuskyCISecurityTestOutput
+	// BaseCommit and ChangedFiles are only set during an incremental scan.
+	BaseCommit   string
+	ChangedFiles []string
+	// HTTPSToken is whether the repository opted in to be cloned with HUSKYCI_API_GIT_HTTPS_TOKEN.
+	HTTPSToken bool
+	// OutputHandler, if set, receives each line written by the container while it runs.
+	OutputHandler func(line string)
+	// Deadline, if set, is when the analysis of this scan is finalized with an error,
+	// so a securityTest is not retried when it could not finish before it.
+	Deadline time.Time
+	// Context, if set, cancels the scan once it is done, such as when its analysis times out.
+	Context goContext.Context
+	// Subpath, if set, is the directory of the repository scanned instead of its root.
+	Subpath string
+	// Source is where the code scanned comes from.
+	Source types.Source
+	// ExcludedPaths are the paths, or file globs, whose findings are not reported.
+	ExcludedPaths []string
+	// Files are copied into the container before it starts, such as a scanner config.
+	Files []types.ContainerFile
+	// ExtraEnv are environment variables set in the container, in addition to the ones of its image.
+	ExtraEnv map[string]string
+	// SharedVolume, if set, is the volume shared with the securityTests it depends on, or
+	// that depend on it, see huskydocker.SharedVolumeName.
+	SharedVolume string
+	// Ignores are the findings the repository accepts, by their vulnerability ID.
+	Ignores []types.Ignore
+	// Suppressions are the fingerprints of the findings of the repository whose suppression was approved.
+	Suppressions []string
+	// GitleaksAllowlist, if set, is rendered into the gitleaks config.
+	GitleaksAllowlist *types.GitleaksAllowlist
+	// NpmAuditFailOn is which vulnerabilities found by npm audit fail the scan.
+	NpmAuditFailOn string
+	// Commit is the SHA of the commit scanned, if it is known.
+	Commit string
+	// CommitSHA, if set, is the commit of Branch checked out instead of its HEAD.
+	CommitSHA string
+	// Force runs the securityTest even if its findings are cached.
+	Force bool
+	// subpathPrefixed is whether findings file paths already start with Subpath.
+	subpathPrefixed bool
+	// attemptStartedAt is when the last attempt to run the container started.
+	attemptStartedAt time.Time
 }
 
+// BlockingMode of a securityTest. An empty BlockingMode is blocking.
+const (
+	// BlockingModeBlocking fails the analysis when the securityTest finds issues.
+	BlockingModeBlocking = "blocking"
+	// BlockingModeWarning stores and counts issues found, but only warns about them.
+	BlockingModeWarning = "warning"
+)
+
+// maxInfrastructureAttempts is how many times a securityTest that could not run due to
+// an infrastructure error is run, counting its first run.
+const maxInfrastructureAttempts = 3
+
+// infrastructureRetryBackoff is how long RunWithRetry waits before running a securityTest
+// again for the first time. It doubles after each retry.
+var infrastructureRetryBackoff = 2 * time.Second
+
 // New creates a new huskyCI scan based given RID, URL, Branch and a securityTest name and returns an error.
 func (scanInfo *SecTestScanInfo) New(RID, URL, branch, securityTestName string) error {
 	scanInfo.RID = RID
@@ -63,14 +116,33 @@ func (scanInfo *SecTestScanInfo) setSecurityTestContainer(securityTestName strin
 		log.Error("createSecurityTestContainer", "SECURITYTEST", 2012, err)
 		return err
 	}
+	// plugins may leave the image of their securityTool to their Parser
+	if registered, ok := parser.Registry.Get(securityTestName); ok && securityTest.Image == "" {
+		toolImage := registered.ToolImage()
+		securityTest.Image, securityTest.ImageTag = toolImage.Name, toolImage.Tag
+	}
 	scanInfo.Container.StartedAt = time.Now()
 	scanInfo.Container.SecurityTest = securityTest
+	scanInfo.Container.User = securityTest.User
 	return nil
 }
 
-// Start starts a new huskyCI scan!
+// Start starts a new huskyCI scan! A securityTest that does not finish before its
+// timeOutInSeconds is not an error to the other ones, so it only marks the container as timedout.
 func (scanInfo *SecTestScanInfo) Start() error {
-	if err := scanInfo.dockerRun(scanInfo.Container.SecurityTest.TimeOutInSeconds); err != nil {
+	defer scanInfo.checkSLA()
+	defer scanInfo.observeDuration()
+	timeOutInSeconds := scanInfo.Container.SecurityTest.TimeOutInSeconds
+	err := scanInfo.RunWithRetry(scanInfo.Context, maxInfrastructureAttempts)
+	if err != nil {
+		if err == huskydocker.ErrContainerTimeout {
+			scanInfo.setTimedOut(time.Since(scanInfo.attemptStartedAt), timeOutInSeconds)
+			return nil
+		}
+		if err == huskydocker.ErrContainerCanceled {
+			scanInfo.setCanceled()
+			return nil
+		}
 		scanInfo.ErrorFound = err
 		scanInfo.prepareContainerAfterScan()
 		return err
@@ -78,51 +150,253 @@ func (scanInfo *SecTestScanInfo) Start() error {
 	if err := scanInfo.analyze(); err != nil {
 		scanInfo.ErrorFound = err
 		scanInfo.prepareContainerAfterScan()
+		scanInfo.recordParsed(err)
 		return err
 	}
 	scanInfo.prepareContainerAfterScan()
+	scanInfo.recordParsed(nil)
 	return nil
 }
 
+// recordParsed records in the timeline of the analysis that the output of the container was
+// parsed, with err or, if its output could not be parsed, why.
+func (scanInfo *SecTestScanInfo) recordParsed(err error) {
+	if err == nil && scanInfo.ParseErrorFound {
+		err = scanInfo.ErrorFound
+	}
+	timeline.Record(scanInfo.RID, scanInfo.Container.CID, scanInfo.SecurityTestName, timeline.EventParsed, err)
+}
+
+// RunWithRetry runs the container of the securityTest, on the URL and branch of scanInfo, up
+// to maxAttempts times while it can not run due to an infrastructure error, waiting longer
+// before each new attempt. Any other error, such as a timeout, is returned right away, as is
+// a scan that ran, whatever it found. Each failed attempt removes its own container.
+func (scanInfo *SecTestScanInfo) RunWithRetry(ctx goContext.Context, maxAttempts int) error {
+	if ctx == nil {
+		ctx = goContext.Background()
+	}
+	scanInfo.Context = ctx
+	timeOutInSeconds := scanInfo.Container.SecurityTest.TimeOutInSeconds
+	backoff := infrastructureRetryBackoff
+	for {
+		scanInfo.attemptStartedAt = time.Now()
+		err := scanInfo.dockerRun(timeOutInSeconds)
+		if !huskydocker.IsInfraError(err) || !scanInfo.canRetry(time.Now().Add(backoff), maxAttempts) {
+			return err
+		}
+		// pull, create or start errors are usually not caused by the repository itself
+		log.Warning("RunWithRetry", "SECURITYTEST", 118, scanInfo.SecurityTestName, scanInfo.RID, err)
+		select {
+		case <-ctx.Done():
+			return huskydocker.ErrContainerCanceled
+		case <-time.After(backoff):
+		}
+		scanInfo.Container.Retries++
+		backoff *= 2
+	}
+}
+
+// canRetry returns whether a securityTest that could not run can run again at now, given it
+// did not run maxAttempts times yet and it can finish before the analysis deadline.
+func (scanInfo *SecTestScanInfo) canRetry(now time.Time, maxAttempts int) bool {
+	if scanInfo.Container.Retries+1 >= maxAttempts {
+		return false
+	}
+	if scanInfo.Deadline.IsZero() {
+		return true
+	}
+	timeOut := time.Duration(scanInfo.Container.SecurityTest.TimeOutInSeconds) * time.Second
+	return now.Add(timeOut).Before(scanInfo.Deadline)
+}
+
 func (scanInfo *SecTestScanInfo) dockerRun(timeOutInSeconds int) error {
 	image := scanInfo.Container.SecurityTest.Image
 	imageTag := scanInfo.Container.SecurityTest.ImageTag
-	cmd := util.HandleCmd(scanInfo.URL, scanInfo.Branch, scanInfo.Container.SecurityTest.Cmd)
+	// HTTPS repositories are cloned using a token while SSH ones use the private SSH key. The
+	// token is only sent to the allowed hosts of repositories that opted in to it.
+	tokenHosts := []string{}
+	if scanInfo.HTTPSToken {
+		tokenHosts = apiContext.APIConfiguration.GitHTTPSTokenHosts
+	}
+	repositoryURL := util.InjectHTTPSToken(scanInfo.URL, tokenHosts)
+	cmd := handleFetchCode(scanInfo.Container.SecurityTest.Cmd, scanInfo.Source)
+	cmd = handleCloneError(cmd)
+	cmd = handleIgnoredIDs(cmd, scanInfo.SecurityTestName, scanInfo.Ignores)
+	cmd = handleAdvisoryDBURL(cmd, scanInfo.SecurityTestName, advisoryDBURLs())
+	cmd = handleGitleaksConfig(cmd, scanInfo.GitleaksAllowlist)
+	cmd = handleInternalPackagePrefixes(cmd, internalPackagePrefixes())
+	cmd = util.HandleCmd(repositoryURL, scanInfo.Branch, cmd, scanInfo.ChangedFiles)
+	cmd = util.HandleGitCommit(cmd, scanInfo.CommitSHA)
+	cmd = util.HandleBaseCommit(cmd, scanInfo.BaseCommit)
+	cmd = util.HandleSubpath(cmd, scanInfo.Subpath)
+	cmd = util.HandleExcludedPaths(cmd, excludedPathsArgs(scanInfo.SecurityTestName, scanInfo.ExcludedPaths))
+	cmd = util.HandleGitHTTPSToken(cmd, scanInfo.URL, tokenHosts)
 	finalCMD := util.HandlePrivateSSHKey(cmd)
-	CID, cOutput, err := huskydocker.DockerRun(image, imageTag, finalCMD, timeOutInSeconds)
+	var onLine func(line string)
+	if scanInfo.OutputHandler != nil {
+		onLine = func(line string) {
+			scanInfo.OutputHandler(util.RedactHTTPSToken(line))
+		}
+	}
+	ctx := scanInfo.Context
+	if ctx == nil {
+		ctx = goContext.Background()
+	}
+	labels := map[string]string{huskydocker.LabelAnalysisID: scanInfo.RID, huskydocker.LabelSecurityTest: scanInfo.SecurityTestName}
+	CID, cOutput, err := huskydocker.DockerRunWithProgress(ctx, image, imageTag, finalCMD, scanInfo.Container.User, scanInfo.containerEnv(), scanInfo.containerFiles(), scanInfo.SharedVolume, labels, timeOutInSeconds, onLine)
+	scanInfo.Container.CID = CID
+	if err == huskydocker.ErrContainerTimeout {
+		// the tail of its output is kept to show where the securityTest got stuck
+		scanInfo.Container.COutput = util.RedactHTTPSToken(cOutput)
+	}
 	if err != nil {
 		return err
 	}
-	scanInfo.Container.CID = CID
-	scanInfo.Container.COutput = cOutput
+	scanInfo.Container.COutput = util.RedactHTTPSToken(cOutput)
+	scanInfo.setScannerVersion()
 	return nil
 }
 
 func (scanInfo *SecTestScanInfo) analyze() error {
-	errorClonning := strings.Contains(scanInfo.Container.COutput, "ERROR_CLONING")
-	if errorClonning {
-		errorMsg := errors.New("error clonning")
-		log.Error("analyze", "SECURITYTEST", 1031, scanInfo.URL, scanInfo.Branch, errorMsg)
-		scanInfo.ErrorFound = errorMsg
-		return errorMsg
+	if cloneErr, ok := ParseCloneError(scanInfo.Container.COutput); ok {
+		log.Error("analyze", "SECURITYTEST", 1031, scanInfo.URL, scanInfo.Branch, cloneErr.Code, cloneErr.Stderr)
+		scanInfo.ErrorFound = cloneErr
+		return cloneErr
+	}
+	// custom securityTests choose the parser of their output
+	parserName := scanInfo.SecurityTestName
+	if scanInfo.Container.SecurityTest.Parser != "" {
+		parserName = scanInfo.Container.SecurityTest.Parser
+	}
+	registered, ok := parser.Registry.Get(parserName)
+	if !ok {
+		return fmt.Errorf("no parser registered for %s", parserName)
+	}
+	if builtin, ok := registered.(builtinParser); ok {
+		return builtin.analyze(scanInfo)
+	}
+	return scanInfo.analyzePlugin(registered)
+}
+
+// unmarshalOutput unmarshals the container output into v. If it fails,
+// the container is marked as an error instead of being left without result.
+func (scanInfo *SecTestScanInfo) unmarshalOutput(v interface{}) error {
+	err := json.Unmarshal([]byte(scanInfo.Container.COutput), v)
+	if err != nil {
+		scanInfo.setParseError(err)
+	}
+	return err
+}
+
+// setParseError marks the scan as failed because its output could not be parsed.
+func (scanInfo *SecTestScanInfo) setParseError(err error) {
+	scanInfo.ParseErrorFound = true
+	scanInfo.ErrorFound = fmt.Errorf("could not parse %s output: %v", scanInfo.SecurityTestName, err)
+	scanInfo.prepareContainerAfterScan()
+}
+
+// setTimedOut marks the scan as timed out, recording how long it ran and its limit
+// followed by the last output of its container, if any.
+func (scanInfo *SecTestScanInfo) setTimedOut(elapsed time.Duration, timeOutInSeconds int) {
+	scanInfo.TimedOut = true
+	scanInfo.ErrorFound = fmt.Errorf("%s timed out", scanInfo.SecurityTestName)
+	cOutputTail := scanInfo.Container.COutput
+	scanInfo.Container.COutput = fmt.Sprintf("%s timed out after %s. Its timeout is %d seconds.", scanInfo.SecurityTestName, elapsed.Round(time.Second), timeOutInSeconds)
+	if cOutputTail != "" {
+		scanInfo.Container.COutput += "\nLast output:\n" + cOutputTail
 	}
-	securityTestAnalyze := securityTestAnalyze[scanInfo.SecurityTestName]
-	return securityTestAnalyze(scanInfo)
+	scanInfo.prepareContainerAfterScan()
+}
+
+// setCanceled marks the scan as canceled, as its analysis timed out before it finished.
+func (scanInfo *SecTestScanInfo) setCanceled() {
+	scanInfo.Canceled = true
+	scanInfo.ErrorFound = fmt.Errorf("%s was canceled", scanInfo.SecurityTestName)
+	scanInfo.Container.COutput = fmt.Sprintf("%s was canceled as its analysis did not finish before its timeout.", scanInfo.SecurityTestName)
+	scanInfo.prepareContainerAfterScan()
 }
 
 func (scanInfo *SecTestScanInfo) prepareContainerAfterScan() {
 
 	cOutputMaxSize := 1000000
+	parseErrorOutputMaxSize := 2048
+	// its result is only known once every check below returns
+	defer scanInfo.truncatePassedOutput(passedOutputMaxSize())
 	scanInfo.Container.FinishedAt = time.Now()
+	scanInfo.Container.DurationMs = scanInfo.Container.FinishedAt.Sub(scanInfo.Container.StartedAt).Nanoseconds() / int64(time.Millisecond)
+
+	// findings of a subpath are relative to it, not to the repository
+	if scanInfo.Subpath != "" && !scanInfo.subpathPrefixed {
+		scanInfo.prefixSubpath()
+	}
+
+	// vendored and generated code is filtered even if the securityTest can not skip it
+	if len(scanInfo.ExcludedPaths) > 0 {
+		scanInfo.filterExcludedPaths()
+	}
+
+	// securityTests that can not skip ignored IDs by themselves have them filtered here
+	if len(scanInfo.Ignores) > 0 {
+		scanInfo.filterIgnoredIDs()
+	}
+
+	// only findings whose suppression was reviewed and approved are suppressed
+	if len(scanInfo.Suppressions) > 0 {
+		scanInfo.filterSuppressedFindings()
+	}
+
+	// collapse findings of the same component before storing them and deciding the result
+	if scanInfo.Container.SecurityTest.Dedup {
+		scanInfo.dedupVulnerabilities()
+	}
+
+	// scanners that do not print their version are identified by their image
+	if scanInfo.Container.ScannerVersion == "" && scanInfo.Container.SecurityTest.Image != "" {
+		scanInfo.Container.ScannerVersion = fmt.Sprintf("%s:%s", scanInfo.Container.SecurityTest.Image, scanInfo.Container.SecurityTest.ImageTag)
+	}
+
 	scanInfo.Container.CInfo = "No issues found."
 	scanInfo.Container.CResult = "passed"
 	scanInfo.Container.CStatus = "finished"
 
+	// keep only the beginning of an unparseable output for debugging
+	if scanInfo.ParseErrorFound && len(scanInfo.Container.COutput) > parseErrorOutputMaxSize {
+		scanInfo.Container.COutput = scanInfo.Container.COutput[:runeBoundary(scanInfo.Container.COutput, parseErrorOutputMaxSize)]
+	}
+
 	// change scanInfo.Container.COutput to prevent error writing to MongoDB
 	if len(scanInfo.Container.COutput) > cOutputMaxSize {
 		scanInfo.Container.COutput = "Container Output is too large."
 	}
 
+	if scanInfo.ParseErrorFound {
+		scanInfo.Container.CInfo = "Could not parse securityTest output."
+		scanInfo.Container.CResult = "error"
+		scanInfo.Container.CStatus = "error running"
+		return
+	}
+
+	if scanInfo.TimedOut {
+		scanInfo.Container.CInfo = "securityTest did not finish before its timeout."
+		scanInfo.Container.CResult = "timedout"
+		scanInfo.Container.CStatus = "timedout"
+		return
+	}
+
+	if scanInfo.Canceled {
+		scanInfo.Container.CInfo = "securityTest was canceled as its analysis did not finish before its timeout."
+		scanInfo.Container.CResult = "timedout"
+		scanInfo.Container.CStatus = "canceled"
+		return
+	}
+
+	if cloneErr, ok := scanInfo.ErrorFound.(*CloneError); ok {
+		scanInfo.Container.CInfo = cloneErr.Error()
+		scanInfo.Container.CResult = "error"
+		scanInfo.Container.CStatus = "error running"
+		return
+	}
+
 	if scanInfo.ErrorFound != nil {
 		scanInfo.Container.CInfo = "Error found running container"
 		scanInfo.Container.CResult = "error"
@@ -154,11 +428,57 @@ func (scanInfo *SecTestScanInfo) prepareContainerAfterScan() {
 	}
 
 	if len(scanInfo.Vulnerabilities.MediumVulns) > 0 || len(scanInfo.Vulnerabilities.HighVulns) > 0 {
-		scanInfo.Container.CInfo = "Issues found."
-		scanInfo.Container.CResult = "failed"
+		scanInfo.setIssuesFound()
 	} else if len(scanInfo.Vulnerabilities.LowVulns) > 0 {
 		scanInfo.Container.CInfo = "Warnings found."
 		scanInfo.Container.CResult = "passed"
 	}
 
 }
+
+// defaultPassedOutputMaxSize is how many bytes of the output of a passed container are
+// stored when HUSKYCI_API_PASSED_OUTPUT_MAX_SIZE is not set.
+const defaultPassedOutputMaxSize = 4096
+
+func passedOutputMaxSize() int {
+	if apiContext.APIConfiguration == nil || apiContext.APIConfiguration.PassedOutputMaxSize <= 0 {
+		return defaultPassedOutputMaxSize
+	}
+	return apiContext.APIConfiguration.PassedOutputMaxSize
+}
+
+// truncatePassedOutput keeps only the first maxSize bytes of the output of a passed
+// container, as its findings are already stored apart. Containers that failed or could
+// not run keep their output for triage.
+func (scanInfo *SecTestScanInfo) truncatePassedOutput(maxSize int) {
+	cOutput := scanInfo.Container.COutput
+	if scanInfo.Container.CResult != "passed" || len(cOutput) <= maxSize {
+		return
+	}
+	size := runeBoundary(cOutput, maxSize)
+	scanInfo.Container.COutput = fmt.Sprintf("%s\n[output truncated: %d of %d bytes stored]", cOutput[:size], size, len(cOutput))
+}
+
+// runeBoundary returns the size of the longest prefix of s of at most maxSize bytes that
+// does not split a multi-byte character.
+func runeBoundary(s string, maxSize int) int {
+	if len(s) <= maxSize {
+		return len(s)
+	}
+	size := maxSize
+	for size > 0 && !utf8.RuneStart(s[size]) {
+		size--
+	}
+	return size
+}
+
+// setIssuesFound fails the container, unless its securityTest is in warning mode.
+func (scanInfo *SecTestScanInfo) setIssuesFound() {
+	scanInfo.Container.CInfo = "Issues found."
+	scanInfo.Container.CResult = "failed"
+	// teams onboarding a securityTest see its issues without having merges blocked
+	if scanInfo.Container.SecurityTest.BlockingMode == BlockingModeWarning {
+		scanInfo.Container.CInfo = "Issues found. This securityTest is in warning mode."
+		scanInfo.Container.CResult = "warning"
+	}
+}
@@ -0,0 +1,407 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"errors"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/securitytest"
+	"github.com/globocom/huskyCI/api/types"
+	"github.com/globocom/huskyCI/api/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+const htmlErrorPage = `<html>
+<head><title>502 Bad Gateway</title></head>
+<body><center><h1>502 Bad Gateway</h1></center></body>
+</html>`
+
+var _ = Describe("Securitytest", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	Describe("Analyze", func() {
+		Context("When gosec output is a truncated JSON", func() {
+			It("Should mark the container as an error and keep running", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+				scanInfo.Container.COutput = `{"Issues": [{"severity": "HIGH", "confidence": "HI`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.ErrorFound).ToNot(BeNil())
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+				Expect(scanInfo.Container.CStatus).To(Equal("error running"))
+				Expect(scanInfo.Container.COutput).To(Equal(`{"Issues": [{"severity": "HIGH", "confidence": "HI`))
+			})
+		})
+		Context("When npmaudit output is an HTML error page from a proxy", func() {
+			It("Should mark the container as an error", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "npmaudit"}
+				scanInfo.Container.COutput = htmlErrorPage
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+				Expect(scanInfo.Container.CInfo).To(Equal("Could not parse securityTest output."))
+			})
+		})
+		Context("When bandit output is empty", func() {
+			It("Should mark the container as an error", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "bandit"}
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+			})
+		})
+		Context("When an unparseable output is larger than 2KB", func() {
+			It("Should keep only its first 2KB", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "brakeman"}
+				scanInfo.Container.COutput = "<html>" + strings.Repeat("a", 5000)
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+				Expect(scanInfo.Container.COutput).To(HaveLen(2048))
+				Expect(scanInfo.Container.COutput).To(HavePrefix("<html>"))
+			})
+		})
+		Context("When gosec output is a valid JSON", func() {
+			It("Should not mark the container as an error", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+				scanInfo.Container.COutput = `{"Issues": []}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.ErrorFound).To(BeNil())
+				Expect(scanInfo.Container.CResult).To(Equal("passed"))
+			})
+		})
+		Context("When gosec finds issues in warning mode", func() {
+			It("Should store them and mark the container as a warning", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+				scanInfo.Container.SecurityTest = types.SecurityTest{BlockingMode: securitytest.BlockingModeWarning}
+				scanInfo.Container.COutput = `{"Issues": [{"severity": "HIGH", "confidence": "HIGH", "rule_id": "G101", "details": "Potential hardcoded credentials", "file": "main.go", "code": "password := \"secret\"", "line": "3"}]}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Vulnerabilities.HighVulns).To(HaveLen(1))
+				Expect(scanInfo.Container.CResult).To(Equal("warning"))
+			})
+		})
+		Context("When gosec output has its version", func() {
+			It("Should store it as the scanner version", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+				scanInfo.Container.SecurityTest = types.SecurityTest{Image: "huskyci/gosec", ImageTag: "v2.2.0"}
+				scanInfo.Container.COutput = `{"Issues": [], "GosecVersion": "2.2.0"}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Container.ScannerVersion).To(Equal("2.2.0"))
+			})
+		})
+		Context("When gosec output does not have its version", func() {
+			It("Should store its image as the scanner version", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+				scanInfo.Container.SecurityTest = types.SecurityTest{Image: "huskyci/gosec", ImageTag: "v2.2.0"}
+				scanInfo.Container.COutput = `{"Issues": []}`
+				Expect(scanInfo.Analyze()).To(BeNil())
+				Expect(scanInfo.Container.ScannerVersion).To(Equal("huskyci/gosec:v2.2.0"))
+			})
+		})
+	})
+
+	Describe("SetTimedOut", func() {
+		Context("When a securityTest does not finish before its timeout", func() {
+			It("Should set the container as timedout with its elapsed time and limit", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "brakeman"}
+				scanInfo.SetTimedOut(361*time.Second, 360)
+				Expect(scanInfo.TimedOut).To(BeTrue())
+				Expect(scanInfo.ErrorFound).To(HaveOccurred())
+				Expect(scanInfo.Container.CResult).To(Equal("timedout"))
+				Expect(scanInfo.Container.CStatus).To(Equal("timedout"))
+				Expect(scanInfo.Container.COutput).To(Equal("brakeman timed out after 6m1s. Its timeout is 360 seconds."))
+			})
+		})
+		Context("When the output of the securityTest was read before it was killed", func() {
+			It("Should keep it after its elapsed time and limit", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "brakeman"}
+				scanInfo.Container.COutput = "Processing app/models/user.rb"
+				scanInfo.SetTimedOut(361*time.Second, 360)
+				Expect(scanInfo.Container.COutput).To(Equal("brakeman timed out after 6m1s. Its timeout is 360 seconds.\nLast output:\nProcessing app/models/user.rb"))
+			})
+		})
+	})
+
+	Describe("TruncatePassedOutput", func() {
+		longOutput := strings.Repeat("a", 5000)
+		Context("When a passed container printed more than the limit", func() {
+			It("Should keep only its beginning", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "bandit"}
+				scanInfo.Container.COutput = longOutput
+				scanInfo.PrepareContainerAfterScan()
+				Expect(scanInfo.Container.CResult).To(Equal("passed"))
+				Expect(scanInfo.Container.COutput).To(Equal(strings.Repeat("a", 4096) + "\n[output truncated: 4096 of 5000 bytes stored]"))
+			})
+			It("Should not split a multi-byte character", func() {
+				scanInfo := securitytest.SecTestScanInfo{}
+				scanInfo.Container.CResult = "passed"
+				scanInfo.Container.COutput = "aé"
+				scanInfo.TruncatePassedOutput(2)
+				Expect(scanInfo.Container.COutput).To(Equal("a\n[output truncated: 1 of 3 bytes stored]"))
+			})
+		})
+		Context("When a container did not pass", func() {
+			It("Should keep its whole output", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "bandit"}
+				scanInfo.Container.COutput = longOutput
+				scanInfo.Vulnerabilities.HighVulns = []types.HuskyCIVulnerability{{Severity: "high"}}
+				scanInfo.PrepareContainerAfterScan()
+				Expect(scanInfo.Container.CResult).To(Equal("failed"))
+				Expect(scanInfo.Container.COutput).To(Equal(longOutput))
+			})
+		})
+	})
+
+	Describe("PrepareContainerAfterScan", func() {
+		Context("When an unparseable output has a multi-byte character at its size limit", func() {
+			It("Should keep its beginning without splitting the character", func() {
+				scanInfo := securitytest.SecTestScanInfo{SecurityTestName: "bandit", ParseErrorFound: true}
+				scanInfo.Container.COutput = strings.Repeat("a", 2047) + "é" + strings.Repeat("a", 100)
+				scanInfo.PrepareContainerAfterScan()
+				Expect(scanInfo.Container.CResult).To(Equal("error"))
+				Expect(scanInfo.Container.COutput).To(Equal(strings.Repeat("a", 2047)))
+				Expect(utf8.ValidString(scanInfo.Container.COutput)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("CanRetry", func() {
+		now := time.Now()
+		Context("When a securityTest was not retried yet and there is no deadline", func() {
+			It("Should return true", func() {
+				scanInfo := securitytest.SecTestScanInfo{}
+				Expect(scanInfo.CanRetry(now, 3)).To(BeTrue())
+			})
+		})
+		Context("When a securityTest was retried but has attempts left", func() {
+			It("Should return true", func() {
+				scanInfo := securitytest.SecTestScanInfo{}
+				scanInfo.Container.Retries = 1
+				Expect(scanInfo.CanRetry(now, 3)).To(BeTrue())
+			})
+		})
+		Context("When a securityTest already ran maxAttempts times", func() {
+			It("Should return false", func() {
+				scanInfo := securitytest.SecTestScanInfo{}
+				scanInfo.Container.Retries = 2
+				Expect(scanInfo.CanRetry(now, 3)).To(BeFalse())
+			})
+		})
+		Context("When a securityTest could not finish before the analysis deadline", func() {
+			It("Should return false", func() {
+				scanInfo := securitytest.SecTestScanInfo{Deadline: now.Add(5 * time.Minute)}
+				scanInfo.Container.SecurityTest.TimeOutInSeconds = 360
+				Expect(scanInfo.CanRetry(now, 3)).To(BeFalse())
+			})
+		})
+		Context("When a securityTest can finish before the analysis deadline", func() {
+			It("Should return true", func() {
+				scanInfo := securitytest.SecTestScanInfo{Deadline: now.Add(time.Hour)}
+				scanInfo.Container.SecurityTest.TimeOutInSeconds = 360
+				Expect(scanInfo.CanRetry(now, 3)).To(BeTrue())
+			})
+		})
+	})
+
+	Describe("SLAViolation", func() {
+		startedAt := time.Now()
+		sla := map[string]time.Duration{"bandit": 5 * time.Minute}
+		Context("When a container took longer than the SLA of its securityTest", func() {
+			It("Should return its duration and true", func() {
+				container := types.Container{SecurityTest: types.SecurityTest{Name: "bandit"}, StartedAt: startedAt, FinishedAt: startedAt.Add(6 * time.Minute)}
+				duration, violated := securitytest.SLAViolation(container, sla)
+				Expect(duration).To(Equal(6 * time.Minute))
+				Expect(violated).To(BeTrue())
+			})
+		})
+		Context("When a container finished within the SLA of its securityTest", func() {
+			It("Should return false", func() {
+				container := types.Container{SecurityTest: types.SecurityTest{Name: "bandit"}, StartedAt: startedAt, FinishedAt: startedAt.Add(time.Minute)}
+				_, violated := securitytest.SLAViolation(container, sla)
+				Expect(violated).To(BeFalse())
+			})
+		})
+		Context("When a securityTest has no SLA", func() {
+			It("Should return false", func() {
+				container := types.Container{SecurityTest: types.SecurityTest{Name: "gosec"}, StartedAt: startedAt, FinishedAt: startedAt.Add(time.Hour)}
+				_, violated := securitytest.SLAViolation(container, sla)
+				Expect(violated).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("SetSubpath", func() {
+		Context("When files were changed inside and outside of the subpath", func() {
+			It("Should keep only the ones inside of it, relative to it", func() {
+				scanInfo := securitytest.SecTestScanInfo{ChangedFiles: []string{"services/api/main.go", "web/app.js", "services/api-v2/main.go"}}
+				scanInfo.SetSubpath("services/api/")
+				Expect(scanInfo.Subpath).To(Equal("services/api"))
+				Expect(scanInfo.Container.Subpath).To(Equal("services/api"))
+				Expect(scanInfo.ChangedFiles).To(Equal([]string{"main.go"}))
+			})
+		})
+	})
+
+	Describe("PrefixSubpath", func() {
+		Context("When a scan of a subpath found vulnerabilities", func() {
+			It("Should prefix their files with the subpath", func() {
+				scanInfo := securitytest.SecTestScanInfo{Subpath: "services/api"}
+				scanInfo.Vulnerabilities.HighVulns = []types.HuskyCIVulnerability{{File: "./handler.go"}}
+				scanInfo.Vulnerabilities.LowVulns = []types.HuskyCIVulnerability{{Files: []string{"go.sum", "vendor/go.sum"}}}
+				scanInfo.PrefixSubpath()
+				Expect(scanInfo.Vulnerabilities.HighVulns[0].File).To(Equal("services/api/handler.go"))
+				Expect(scanInfo.Vulnerabilities.LowVulns[0].File).To(Equal(""))
+				Expect(scanInfo.Vulnerabilities.LowVulns[0].Files).To(Equal([]string{"services/api/go.sum", "services/api/vendor/go.sum"}))
+			})
+		})
+	})
+
+	Describe("ExcludedPathsArgs", func() {
+		excludedPaths := []string{"vendor", "web/node_modules/", "*.pb.go"}
+		Context("When the securityTest is bandit", func() {
+			It("Should template its cmd with a single -x flag", func() {
+				cmd := util.HandleExcludedPaths("bandit -r . -f json %EXCLUDED_PATHS%", securitytest.ExcludedPathsArgs("bandit", excludedPaths))
+				Expect(cmd).To(Equal("bandit -r . -f json -x 'vendor,web/node_modules/,*.pb.go'"))
+			})
+		})
+		Context("When the securityTest is gosec", func() {
+			It("Should template its cmd with an -exclude-dir flag per directory", func() {
+				cmd := util.HandleExcludedPaths("gosec %EXCLUDED_PATHS% ./...", securitytest.ExcludedPathsArgs("gosec", excludedPaths))
+				Expect(cmd).To(Equal("gosec -exclude-dir='vendor' -exclude-dir='web/node_modules' ./..."))
+			})
+		})
+		Context("When the securityTest can not skip paths or none are excluded", func() {
+			It("Should remove the placeholder from its cmd", func() {
+				Expect(util.HandleExcludedPaths("npm audit %EXCLUDED_PATHS%", securitytest.ExcludedPathsArgs("npmaudit", excludedPaths))).To(Equal("npm audit "))
+				Expect(util.HandleExcludedPaths("gosec %EXCLUDED_PATHS%", securitytest.ExcludedPathsArgs("gosec", nil))).To(Equal("gosec "))
+			})
+		})
+	})
+
+	Describe("FilterExcludedPaths", func() {
+		Context("When findings are under an excluded path or match an excluded glob", func() {
+			It("Should remove only them", func() {
+				scanInfo := securitytest.SecTestScanInfo{ExcludedPaths: []string{"vendor/", "*.pb.go"}}
+				scanInfo.Vulnerabilities.HighVulns = []types.HuskyCIVulnerability{
+					{File: "./vendor/github.com/lib/pq/conn.go"},
+					{File: "api/handler.go"},
+					{File: "api/proto/user.pb.go"},
+				}
+				scanInfo.Vulnerabilities.LowVulns = []types.HuskyCIVulnerability{
+					{Details: "lodash"},
+					{File: "vendors/app.go"},
+				}
+				scanInfo.FilterExcludedPaths()
+				Expect(scanInfo.Vulnerabilities.HighVulns).To(Equal([]types.HuskyCIVulnerability{{File: "api/handler.go"}}))
+				Expect(scanInfo.Vulnerabilities.LowVulns).To(HaveLen(2))
+			})
+		})
+	})
+
+	Describe("HandleFetchCode", func() {
+		cmd := "%FETCH_CODE% 2> /tmp/errorGitClone"
+		Context("When the code comes from git", func() {
+			It("Should clone the repository into code", func() {
+				Expect(securitytest.HandleFetchCode(cmd, types.Source{})).To(Equal("(git clone -b %GIT_BRANCH% --single-branch %GIT_REPO% code --quiet && git -C code checkout --quiet %GIT_COMMIT%) 2> /tmp/errorGitClone"))
+			})
+		})
+		Context("When the code comes from a tarball", func() {
+			It("Should extract the tarball copied into the container into code", func() {
+				Expect(securitytest.HandleFetchCode(cmd, types.Source{Tarball: []byte("tar")})).To(Equal("mkdir -p code && tar -xf /tmp/huskyci-source.tar -C code 2> /tmp/errorGitClone"))
+			})
+		})
+	})
+
+	Describe("CanScanSource", func() {
+		tarball := types.Source{Tarball: []byte("tar")}
+		Context("When a securityTest fetches the code through %FETCH_CODE%", func() {
+			It("Should scan git repositories and tarballs", func() {
+				securityTest := types.SecurityTest{Cmd: "%FETCH_CODE% && bandit -r code"}
+				Expect(securitytest.CanScanSource(securityTest, types.Source{})).To(BeTrue())
+				Expect(securitytest.CanScanSource(securityTest, tarball)).To(BeTrue())
+			})
+		})
+		Context("When a securityTest clones the repository by itself", func() {
+			It("Should only scan git repositories", func() {
+				securityTest := types.SecurityTest{Cmd: "git clone %GIT_REPO% code && gitleaks --repo-path=./code"}
+				Expect(securitytest.CanScanSource(securityTest, types.Source{})).To(BeTrue())
+				Expect(securitytest.CanScanSource(securityTest, tarball)).To(BeFalse())
+			})
+		})
+	})
+
+	Describe("ContainerFiles", func() {
+		config := types.ContainerFile{Path: "/tmp/.bandit", Content: []byte("[bandit]\nskips: B101")}
+		Context("When the code comes from git", func() {
+			It("Should only copy the scan files", func() {
+				scanInfo := securitytest.SecTestScanInfo{Files: []types.ContainerFile{config}}
+				Expect(scanInfo.ContainerFiles()).To(Equal([]types.ContainerFile{config}))
+			})
+		})
+		Context("When the code comes from a tarball", func() {
+			It("Should also copy the tarball", func() {
+				scanInfo := securitytest.SecTestScanInfo{Files: []types.ContainerFile{config}, Source: types.Source{Tarball: []byte("tar")}}
+				Expect(scanInfo.ContainerFiles()).To(Equal([]types.ContainerFile{
+					config,
+					{Path: "/tmp/huskyci-source.tar", Content: []byte("tar")},
+				}))
+			})
+		})
+	})
+
+	Describe("AddScan", func() {
+		Context("When scans finish at the same time", func() {
+			It("Should keep every container and report them as they finish", func() {
+				results := securitytest.RunAllInfo{}
+				reported := []int{}
+				results.OnContainerFinished = func(containers []types.Container) {
+					reported = append(reported, len(containers))
+				}
+				var wg sync.WaitGroup
+				for i := 0; i < 10; i++ {
+					wg.Add(1)
+					go func() {
+						defer wg.Done()
+						scan := securitytest.SecTestScanInfo{SecurityTestName: "gosec"}
+						scan.Vulnerabilities.HighVulns = []types.HuskyCIVulnerability{{File: "main.go"}}
+						results.AddScan(scan)
+					}()
+				}
+				wg.Wait()
+				Expect(results.Containers).To(HaveLen(10))
+				Expect(results.HuskyCIResults.GoResults.HuskyCIGosecOutput.HighVulns).To(HaveLen(10))
+				Expect(reported).To(Equal([]int{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}))
+			})
+		})
+		Context("When gitauthors finishes", func() {
+			It("Should set the commit authors", func() {
+				results := securitytest.RunAllInfo{}
+				scan := securitytest.SecTestScanInfo{SecurityTestName: "gitauthors"}
+				scan.CommitAuthors.Authors = []string{"dev@example.com"}
+				results.AddScan(scan)
+				Expect(results.CommitAuthors).To(Equal([]string{"dev@example.com"}))
+			})
+		})
+		Context("When a scan of a ref finishes", func() {
+			It("Should set the ref its container scanned", func() {
+				results := securitytest.RunAllInfo{}
+				scan := securitytest.SecTestScanInfo{SecurityTestName: "gosec", Branch: "main"}
+				results.AddScan(scan)
+				Expect(results.Containers[0].Ref).To(Equal("main"))
+			})
+		})
+	})
+
+	Describe("ScanErrors", func() {
+		It("Should join the error of every securityTest", func() {
+			scanErrors := securitytest.ScanErrors{errors.New("bandit timed out"), errors.New("gosec was canceled")}
+			Expect(scanErrors.Error()).To(Equal("bandit timed out; gosec was canceled"))
+		})
+	})
+})
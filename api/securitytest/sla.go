@@ -0,0 +1,58 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/metrics"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// slaViolation returns how long container took and whether it took longer than
+// the SLA of its securityTest. securityTests without an SLA never violate it.
+func slaViolation(container types.Container, sla map[string]time.Duration) (time.Duration, bool) {
+	duration := container.FinishedAt.Sub(container.StartedAt)
+	expected, ok := sla[container.SecurityTest.Name]
+	return duration, ok && duration > expected
+}
+
+// observeDuration records how long the container of a securityTest took, if it finished.
+func (scanInfo *SecTestScanInfo) observeDuration() {
+	container := scanInfo.Container
+	if container.StartedAt.IsZero() || container.FinishedAt.IsZero() {
+		return
+	}
+	duration := container.FinishedAt.Sub(container.StartedAt)
+	metrics.SecurityTestDuration.WithLabelValues(container.SecurityTest.Name).Observe(duration.Seconds())
+}
+
+// checkSLA counts and alerts on Slack, if configured, a container that took
+// longer than the SLA of its securityTest.
+func (scanInfo *SecTestScanInfo) checkSLA() {
+	sla := apiContext.APIConfiguration.SLA
+	duration, violated := slaViolation(scanInfo.Container, sla)
+	if !violated {
+		return
+	}
+	securityTestName := scanInfo.Container.SecurityTest.Name
+	log.Warning("checkSLA", "SECURITYTEST", 119, securityTestName, scanInfo.RID, duration)
+	metrics.SLAViolations.WithLabelValues(securityTestName).Inc()
+
+	slackNotifier := notifier.NewSlackNotifier(apiContext.APIConfiguration.SlackWebhookURL)
+	if slackNotifier == nil {
+		return
+	}
+	text := fmt.Sprintf("huskyCI: %s took %s to scan %s (%s), longer than its SLA of %s. RID: %s",
+		securityTestName, duration.Round(time.Second), scanInfo.URL, scanInfo.Branch, sla[securityTestName], scanInfo.RID)
+	// alerts must not delay the analysis
+	slackNotifier.NotifyAsync(text, func(err error) {
+		log.Error("checkSLA", "SECURITYTEST", 2024, err)
+	})
+}
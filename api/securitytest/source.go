@@ -0,0 +1,66 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// fetchCodePlaceholder is replaced in a cmd by the step that puts the code in ./code.
+const fetchCodePlaceholder = "%FETCH_CODE%"
+
+// gitCloneCmd checks out %GIT_COMMIT% in a subshell, so the stderr of both git commands is
+// redirected along with %FETCH_CODE%.
+const gitCloneCmd = "(git clone -b %GIT_BRANCH% --single-branch %GIT_REPO% code --quiet && git -C code checkout --quiet %GIT_COMMIT%)"
+
+// sourceTarballPath is where the tarball of a Source is copied into a container.
+const sourceTarballPath = "/tmp/huskyci-source.tar"
+
+var tarballExtractCmd = fmt.Sprintf("mkdir -p code && tar -xf %s -C code", sourceTarballPath)
+
+// handleFetchCode replaces %FETCH_CODE% in cmd by a git clone or, if the code
+// comes from a tarball, by its extraction.
+func handleFetchCode(cmd string, source types.Source) string {
+	if source.IsTarball() {
+		return strings.Replace(cmd, fetchCodePlaceholder, tarballExtractCmd, -1)
+	}
+	return strings.Replace(cmd, fetchCodePlaceholder, gitCloneCmd, -1)
+}
+
+// canScanSource returns whether a securityTest can scan the code of source. The ones
+// that clone the repository by themselves, such as to read its history, can not scan a tarball.
+func canScanSource(securityTest types.SecurityTest, source types.Source) bool {
+	return !source.IsTarball() || strings.Contains(securityTest.Cmd, fetchCodePlaceholder)
+}
+
+// containerEnv returns the ExtraEnv of a scan as the sorted KEY=value list of Docker, along
+// with where its shared volume is mounted, if it has one.
+func (scanInfo *SecTestScanInfo) containerEnv() []string {
+	env := []string{}
+	for key, value := range scanInfo.ExtraEnv {
+		env = append(env, key+"="+value)
+	}
+	if scanInfo.SharedVolume != "" {
+		env = append(env, huskydocker.SharedVolumeEnv+"="+huskydocker.SharedVolumePath)
+	}
+	sort.Strings(env)
+	return env
+}
+
+// containerFiles returns the files copied into the container of a scan before it
+// starts: its Files, its rendered gitleaks config and, if the code comes from a tarball, the tarball.
+func (scanInfo *SecTestScanInfo) containerFiles() []types.ContainerFile {
+	files := append([]types.ContainerFile{}, scanInfo.Files...)
+	files = append(files, scanInfo.gitleaksConfigFiles()...)
+	if scanInfo.Source.IsTarball() {
+		files = append(files, types.ContainerFile{Path: sourceTarballPath, Content: scanInfo.Source.Tarball})
+	}
+	return files
+}
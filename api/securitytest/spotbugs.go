@@ -23,6 +23,7 @@ const (
 // SpotBugsOutput is the struct that holds all data from SpotBugs output.
 type SpotBugsOutput struct {
 	XMLName       xml.Name        `xml:"BugCollection"`
+	Version       string          `xml:"version,attr"`
 	Project       Project         `xml:"Project"`
 	SpotBugsIssue []SpotBugsIssue `xml:"BugInstance"`
 	Errors        Error           `xml:"Errors"`
@@ -108,12 +109,12 @@ func analyzeSpotBugs(spotbugsScan *SecTestScanInfo) error {
 	spotBugsOutput, err := parseXMLtoJSON([]byte(spotbugsScan.Container.COutput))
 	if err != nil {
 		log.Error("analyzeSpotBugs", "SPOTBUGS", 1039, spotbugsScan.Container.COutput, err)
-		spotbugsScan.ErrorFound = err
-		spotbugsScan.prepareContainerAfterScan()
-		return err
+		spotbugsScan.setParseError(err)
+		return nil
 	}
 
 	spotbugsScan.FinalOutput = spotBugsOutput
+	spotbugsScan.Container.ScannerVersion = spotBugsOutput.Version
 
 	// check results and prepare all vulnerabilities found
 	spotbugsScan.prepareSpotBugsVulns()
@@ -189,12 +190,23 @@ func (spotbugsScan *SecTestScanInfo) prepareSpotBugsVulns() {
 			switch {
 			case rank < highSeverityValue:
 				spotbugsVuln.Severity = "HIGH"
-				huskyCIspotbugsResults.HighVulns = append(huskyCIspotbugsResults.HighVulns, spotbugsVuln)
 			case rank < mediumSeverityValue:
 				spotbugsVuln.Severity = "MEDIUM"
-				huskyCIspotbugsResults.MediumVulns = append(huskyCIspotbugsResults.MediumVulns, spotbugsVuln)
 			default:
 				spotbugsVuln.Severity = "LOW"
+			}
+
+			// findings below the configured confidence are only warnings
+			if belowMinConfidence(spotbugsScan.SecurityTestName, spotbugsVuln.Confidence) {
+				huskyCIspotbugsResults.LowVulns = append(huskyCIspotbugsResults.LowVulns, spotbugsVuln)
+				continue
+			}
+			switch spotbugsVuln.Severity {
+			case "HIGH":
+				huskyCIspotbugsResults.HighVulns = append(huskyCIspotbugsResults.HighVulns, spotbugsVuln)
+			case "MEDIUM":
+				huskyCIspotbugsResults.MediumVulns = append(huskyCIspotbugsResults.MediumVulns, spotbugsVuln)
+			default:
 				huskyCIspotbugsResults.LowVulns = append(huskyCIspotbugsResults.LowVulns, spotbugsVuln)
 			}
 		}
@@ -0,0 +1,50 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"path"
+	"strings"
+
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// setSubpath makes the scan run at subpath. Changed files outside of it are not
+// given to the securityTest and the ones inside of it become relative to it.
+func (scanInfo *SecTestScanInfo) setSubpath(subpath string) {
+	scanInfo.Subpath = strings.Trim(subpath, "/")
+	scanInfo.Container.Subpath = scanInfo.Subpath
+	if scanInfo.Subpath == "" || len(scanInfo.ChangedFiles) == 0 {
+		return
+	}
+	changedFiles := []string{}
+	for _, changedFile := range scanInfo.ChangedFiles {
+		if strings.HasPrefix(changedFile, scanInfo.Subpath+"/") {
+			changedFiles = append(changedFiles, strings.TrimPrefix(changedFile, scanInfo.Subpath+"/"))
+		}
+	}
+	scanInfo.ChangedFiles = changedFiles
+}
+
+// prefixSubpath prefixes the file paths of every finding with the scan subpath.
+func (scanInfo *SecTestScanInfo) prefixSubpath() {
+	scanInfo.subpathPrefixed = true
+	scanInfo.Vulnerabilities.LowVulns = prefixVulns(scanInfo.Subpath, scanInfo.Vulnerabilities.LowVulns)
+	scanInfo.Vulnerabilities.MediumVulns = prefixVulns(scanInfo.Subpath, scanInfo.Vulnerabilities.MediumVulns)
+	scanInfo.Vulnerabilities.HighVulns = prefixVulns(scanInfo.Subpath, scanInfo.Vulnerabilities.HighVulns)
+	scanInfo.Vulnerabilities.NoSecVulns = prefixVulns(scanInfo.Subpath, scanInfo.Vulnerabilities.NoSecVulns)
+}
+
+func prefixVulns(subpath string, vulns []types.HuskyCIVulnerability) []types.HuskyCIVulnerability {
+	for i := range vulns {
+		if vulns[i].File != "" {
+			vulns[i].File = path.Join(subpath, vulns[i].File)
+		}
+		for j := range vulns[i].Files {
+			vulns[i].Files[j] = path.Join(subpath, vulns[i].Files[j])
+		}
+	}
+	return vulns
+}
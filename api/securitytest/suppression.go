@@ -0,0 +1,38 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"github.com/globocom/huskyCI/api/types"
+)
+
+// SuppressionFingerprint returns the fingerprint that suppressions of a finding of the
+// securityTest of securityTestName in the repository of repositoryURL are approved for. It is
+// set by the analysis package, which fingerprints findings, so no finding is suppressed until then.
+var SuppressionFingerprint func(repositoryURL, securityTestName string, vuln types.HuskyCIVulnerability) string
+
+// filterSuppressedFindings moves findings with an approved suppression to NoSecVulns, so they
+// are reported as suppressed and do not fail the scan.
+func (scanInfo *SecTestScanInfo) filterSuppressedFindings() {
+	if SuppressionFingerprint == nil {
+		return
+	}
+	suppressed := map[string]bool{}
+	for _, fingerprint := range scanInfo.Suppressions {
+		suppressed[fingerprint] = true
+	}
+	vulnerabilities := &scanInfo.Vulnerabilities
+	for _, vulns := range []*[]types.HuskyCIVulnerability{&vulnerabilities.HighVulns, &vulnerabilities.MediumVulns, &vulnerabilities.LowVulns} {
+		keptVulns := []types.HuskyCIVulnerability{}
+		for _, vuln := range *vulns {
+			if suppressed[SuppressionFingerprint(scanInfo.URL, scanInfo.SecurityTestName, vuln)] {
+				vulnerabilities.NoSecVulns = append(vulnerabilities.NoSecVulns, vuln)
+				continue
+			}
+			keptVulns = append(keptVulns, vuln)
+		}
+		*vulns = keptVulns
+	}
+}
@@ -0,0 +1,83 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"sync"
+	"time"
+)
+
+// OutputTailLines is how many of the last lines written by a running container are reported.
+const OutputTailLines = 20
+
+// outputTailInterval is how often, at most, the tail of a running container is reported, so a
+// scanner writing thousands of lines does not update its analysis thousands of times.
+var outputTailInterval = 2 * time.Second
+
+// OutputTail keeps the last lines written by a running container in a ring buffer and reports
+// them to onUpdate as they are written, at most once every interval.
+type OutputTail struct {
+	mutex      sync.Mutex
+	lines      []string
+	next       int
+	full       bool
+	interval   time.Duration
+	reportedAt time.Time
+	onUpdate   func(lines []string)
+}
+
+// NewOutputTail returns an OutputTail of the last size lines that reports them to onUpdate at
+// most once every interval.
+func NewOutputTail(size int, interval time.Duration, onUpdate func(lines []string)) *OutputTail {
+	return &OutputTail{lines: make([]string, size), interval: interval, onUpdate: onUpdate}
+}
+
+// Add adds line to the tail, dropping its oldest line if it is full, and reports the tail
+// if it was not reported within the last interval.
+func (tail *OutputTail) Add(line string) {
+	tail.mutex.Lock()
+	defer tail.mutex.Unlock()
+	if len(tail.lines) == 0 {
+		return
+	}
+	tail.lines[tail.next] = line
+	tail.next = (tail.next + 1) % len(tail.lines)
+	if tail.next == 0 {
+		tail.full = true
+	}
+	now := time.Now()
+	if !tail.reportedAt.IsZero() && now.Sub(tail.reportedAt) < tail.interval {
+		return
+	}
+	tail.reportedAt = now
+	// called while locked, so an older tail is never reported after a newer one
+	tail.onUpdate(tail.linesLocked())
+}
+
+// Lines returns the lines of the tail, the oldest first.
+func (tail *OutputTail) Lines() []string {
+	tail.mutex.Lock()
+	defer tail.mutex.Unlock()
+	return tail.linesLocked()
+}
+
+func (tail *OutputTail) linesLocked() []string {
+	if !tail.full {
+		return append([]string{}, tail.lines[:tail.next]...)
+	}
+	return append(append([]string{}, tail.lines[tail.next:]...), tail.lines[:tail.next]...)
+}
+
+// TailOutput returns an OutputHandler that reports the tail of the container of securityTest
+// scanning subpath to OnOutput, or nil if it is not set.
+func (results *RunAllInfo) TailOutput(securityTest, subpath string) func(line string) {
+	if results.OnOutput == nil {
+		return nil
+	}
+	tail := NewOutputTail(OutputTailLines, outputTailInterval, func(lines []string) {
+		results.OnOutput(securityTest, subpath, lines)
+	})
+	return tail.Add
+}
@@ -0,0 +1,69 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("OutputTail", func() {
+
+	Context("When a running container writes more lines than the tail keeps", func() {
+		It("Should report its last lines while it is still writing", func() {
+			reported := [][]string{}
+			tail := securitytest.NewOutputTail(3, 0, func(lines []string) {
+				reported = append(reported, lines)
+			})
+			tail.Add("line 1")
+			Expect(reported).To(Equal([][]string{{"line 1"}}))
+			for i := 2; i <= 5; i++ {
+				tail.Add(fmt.Sprintf("line %d", i))
+			}
+			Expect(reported).To(HaveLen(5))
+			Expect(reported[4]).To(Equal([]string{"line 3", "line 4", "line 5"}))
+			Expect(tail.Lines()).To(Equal([]string{"line 3", "line 4", "line 5"}))
+		})
+	})
+
+	Context("When lines are written faster than the interval", func() {
+		It("Should report the tail once per interval", func() {
+			reported := [][]string{}
+			tail := securitytest.NewOutputTail(3, time.Hour, func(lines []string) {
+				reported = append(reported, lines)
+			})
+			tail.Add("line 1")
+			tail.Add("line 2")
+			Expect(reported).To(Equal([][]string{{"line 1"}}))
+			Expect(tail.Lines()).To(Equal([]string{"line 1", "line 2"}))
+		})
+	})
+
+	Describe("TailOutput", func() {
+		Context("When OnOutput is not set", func() {
+			It("Should return no OutputHandler", func() {
+				results := securitytest.RunAllInfo{}
+				Expect(results.TailOutput("bandit", "")).To(BeNil())
+			})
+		})
+
+		Context("When OnOutput is set", func() {
+			It("Should report the tail of the container of each securityTest as it runs", func() {
+				reported := map[string][]string{}
+				results := securitytest.RunAllInfo{OnOutput: func(securityTest, subpath string, lines []string) {
+					reported[securityTest+"@"+subpath] = lines
+				}}
+				onLine := results.TailOutput("bandit", "services/api")
+				onLine("Run started")
+				Expect(reported).To(Equal(map[string][]string{"bandit@services/api": {"Run started"}}))
+			})
+		})
+	})
+})
@@ -0,0 +1,62 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest
+
+import (
+	"regexp"
+	"strings"
+
+	huskydocker "github.com/globocom/huskyCI/api/dockers"
+	"github.com/globocom/huskyCI/api/log"
+)
+
+// toolVersionPrefix starts the first line of a cmd output that prints its tool version,
+// such as "HUSKYCI_TOOL_VERSION=bandit 1.6.2".
+const toolVersionPrefix = "HUSKYCI_TOOL_VERSION="
+
+var versionRegexp = regexp.MustCompile(`\d+(\.\d+)+(-[0-9A-Za-z.]+)?`)
+
+// extractToolVersion returns the tool version printed in the first line of cOutput
+// and cOutput without that line, so securityTests parse their output as before.
+func extractToolVersion(cOutput string) (string, string) {
+	if !strings.HasPrefix(cOutput, toolVersionPrefix) {
+		return "", cOutput
+	}
+	line, rest := cOutput, ""
+	if newLine := strings.Index(cOutput, "\n"); newLine >= 0 {
+		line, rest = cOutput[:newLine], cOutput[newLine+1:]
+	}
+	return normalizeVersion(strings.TrimPrefix(strings.TrimSpace(line), toolVersionPrefix)), rest
+}
+
+// normalizeVersion returns only the version number of what a tool prints as its
+// version, such as 1.6.2 of "bandit 1.6.2", or all of it if it has no version number.
+func normalizeVersion(version string) string {
+	if number := versionRegexp.FindString(version); number != "" {
+		return number
+	}
+	return strings.TrimSpace(version)
+}
+
+// setScannerVersion records the tool version printed by the container as its ScannerVersion
+// and the digest of its image, and warns if the version is not the one expected by the
+// securityTest. Scanners that report their version in their output set it again once parsed.
+func (scanInfo *SecTestScanInfo) setScannerVersion() {
+	securityTest := scanInfo.Container.SecurityTest
+	scanInfo.Container.ScannerVersion, scanInfo.Container.COutput = extractToolVersion(scanInfo.Container.COutput)
+	if digest, err := huskydocker.ImageDigest(securityTest.Image, securityTest.ImageTag); err == nil {
+		scanInfo.Container.ImageDigest = digest
+	}
+	if !scanInfo.hasExpectedVersion() {
+		log.Warning("setScannerVersion", "SECURITYTEST", 123, scanInfo.SecurityTestName, securityTest.ExpectedVersion, scanInfo.Container.ScannerVersion)
+	}
+}
+
+// hasExpectedVersion returns whether the container ran the version expected by its
+// securityTest, which is always true if the securityTest does not expect one.
+func (scanInfo *SecTestScanInfo) hasExpectedVersion() bool {
+	expectedVersion := scanInfo.Container.SecurityTest.ExpectedVersion
+	return expectedVersion == "" || normalizeVersion(expectedVersion) == scanInfo.Container.ScannerVersion
+}
@@ -0,0 +1,68 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package securitytest_test
+
+import (
+	"github.com/globocom/huskyCI/api/securitytest"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Version", func() {
+
+	Describe("ExtractToolVersion", func() {
+		Context("When the cmd printed its tool version", func() {
+			It("Should return its version number and the output without it", func() {
+				outputs := map[string]string{
+					"HUSKYCI_TOOL_VERSION=bandit 1.6.2\r\n{\"results\":[]}":                        "1.6.2",
+					"HUSKYCI_TOOL_VERSION=Version: 2.2.0\r\n{\"results\":[]}":                      "2.2.0",
+					"HUSKYCI_TOOL_VERSION=safety, version 1.8.5\r\n{\"results\":[]}":               "1.8.5",
+					"HUSKYCI_TOOL_VERSION=Dependency-Check Core version 5.3.2\r\n{\"results\":[]}": "5.3.2",
+					"HUSKYCI_TOOL_VERSION=4.0.0-beta4\r\n{\"results\":[]}":                         "4.0.0-beta4",
+				}
+				for cOutput, version := range outputs {
+					toolVersion, rest := securitytest.ExtractToolVersion(cOutput)
+					Expect(toolVersion).To(Equal(version))
+					Expect(rest).To(Equal("{\"results\":[]}"))
+				}
+			})
+		})
+		Context("When the tool did not print a version number", func() {
+			It("Should return what it printed", func() {
+				toolVersion, rest := securitytest.ExtractToolVersion("HUSKYCI_TOOL_VERSION=\nERROR_CLONING")
+				Expect(toolVersion).To(BeEmpty())
+				Expect(rest).To(Equal("ERROR_CLONING"))
+			})
+		})
+		Context("When the cmd did not print its tool version", func() {
+			It("Should return the output as it is", func() {
+				toolVersion, rest := securitytest.ExtractToolVersion("{\"results\":[]}")
+				Expect(toolVersion).To(BeEmpty())
+				Expect(rest).To(Equal("{\"results\":[]}"))
+			})
+		})
+	})
+
+	Describe("HasExpectedVersion", func() {
+		scanInfo := securitytest.SecTestScanInfo{}
+
+		It("Should be true if the securityTest does not expect a version", func() {
+			scanInfo.Container.ScannerVersion = "1.6.2"
+			Expect(scanInfo.HasExpectedVersion()).To(BeTrue())
+		})
+		It("Should compare only version numbers", func() {
+			scanInfo.Container.SecurityTest.ExpectedVersion = "v1.6.2"
+			Expect(scanInfo.HasExpectedVersion()).To(BeTrue())
+		})
+		It("Should be false if the tool ran a different or an unknown version", func() {
+			scanInfo.Container.SecurityTest.ExpectedVersion = "1.6.2"
+			scanInfo.Container.ScannerVersion = "1.7.0"
+			Expect(scanInfo.HasExpectedVersion()).To(BeFalse())
+			scanInfo.Container.ScannerVersion = ""
+			Expect(scanInfo.HasExpectedVersion()).To(BeFalse())
+		})
+	})
+})
@@ -5,7 +5,6 @@
 package securitytest
 
 import (
-	"encoding/json"
 	"strings"
 
 	"github.com/globocom/huskyCI/api/log"
@@ -79,9 +78,9 @@ func analyzeYarnaudit(yarnAuditScan *SecTestScanInfo) error {
 	}
 
 	// Unmarshall rawOutput into finalOutput, that is a YarnAuditOutput struct.
-	if err := json.Unmarshal([]byte(yarnAuditScan.Container.COutput), &yarnAuditOutput); err != nil {
+	if err := yarnAuditScan.unmarshalOutput(&yarnAuditOutput); err != nil {
 		log.Error("analyzeYarnaudit", "YARNAUDIT", 1036, yarnAuditScan.Container.COutput, err)
-		return err
+		return nil
 	}
 	yarnAuditScan.FinalOutput = yarnAuditOutput
 
@@ -5,14 +5,26 @@
 package main
 
 import (
+	"context"
+	"flag"
 	"fmt"
 	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/globocom/huskyCI/api/analysis"
 	"github.com/globocom/huskyCI/api/auth"
+	"github.com/globocom/huskyCI/api/cache"
 	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/dockers"
+	huskyGrpc "github.com/globocom/huskyCI/api/grpc"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/notifier"
+	"github.com/globocom/huskyCI/api/parser"
 	"github.com/globocom/huskyCI/api/routes"
+	"github.com/globocom/huskyCI/api/secrets"
 	"github.com/globocom/huskyCI/api/util"
 	apiUtil "github.com/globocom/huskyCI/api/util/api"
 	"github.com/labstack/echo"
@@ -21,6 +33,16 @@ import (
 
 func main() {
 
+	backfillFindings := flag.Bool("backfill-findings", false, "record the findings of existing analyses by fingerprint and exit")
+	flag.Parse()
+
+	// secrets of Vault are set to their environment variables before the configuration reads them
+	secretsProvider, err := secrets.LoadSecrets()
+	if err != nil {
+		fmt.Println("Error reading secrets from Vault: ", err)
+		os.Exit(1)
+	}
+
 	configAPI, err := apiContext.DefaultConf.GetAPIConfig()
 
 	if err != nil {
@@ -34,7 +56,12 @@ func main() {
 		configAPI.GraylogConfig.Protocol,
 		configAPI.GraylogConfig.AppName,
 		configAPI.GraylogConfig.Tag)
-	log.Info("main", "SERVER", 11)
+	buildInfo := routes.GetRequestResult(configAPI)
+	log.Info("main", "SERVER", 11, buildInfo["version"], buildInfo["commit"], buildInfo["date"], buildInfo["goVersion"])
+
+	if vaultClient, ok := secretsProvider.(*secrets.VaultClient); ok {
+		go vaultClient.RenewToken(nil)
+	}
 
 	checkHandler := &apiUtil.CheckUtils{}
 
@@ -47,6 +74,74 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *backfillFindings {
+		recorded, err := analysis.BackfillFindings()
+		if err != nil {
+			log.Error("main", "SERVER", 2041, err)
+			os.Exit(1)
+		}
+		fmt.Printf("Findings of %d analyses recorded\n", recorded)
+		os.Exit(0)
+	}
+
+	// Redis is optional, as it only caches data that can be computed again
+	if configAPI.RedisConfig.Address != "" {
+		redisCache, err := cache.NewRedisCache(configAPI.RedisConfig.Address, configAPI.RedisConfig.Password, configAPI.RedisConfig.DB)
+		if err != nil {
+			log.Error("main", "SERVER", 2022, err)
+		} else {
+			cache.Instance = redisCache
+			log.Info("main", "SERVER", 27)
+		}
+	}
+
+	// findings are only mapped to compliance frameworks if the mapping is loaded
+	if err := analysis.LoadComplianceMapping("api/compliance.json"); err != nil {
+		log.Error("main", "SERVER", 1046, err)
+	}
+
+	// securityTools of plugins are parsed as the built-in ones
+	if configAPI.PluginDir != "" {
+		loaded, err := parser.Registry.LoadPlugins(configAPI.PluginDir)
+		if err != nil {
+			log.Error("main", "SERVER", 1048, err)
+		}
+		for _, name := range loaded {
+			log.Info("main", "SERVER", 28, name)
+		}
+	}
+
+	// keep NVD data used to enrich CVEs up to date
+	analysis.StartNVDCacheRefresher(24 * time.Hour)
+
+	// finalize analyses whose containers never reported a result
+	analysis.StartStaleAnalysesFinalizer(configAPI.AnalysisDeadline)
+
+	// follow again the bulk analysis jobs left running on the last shutdown
+	if err := analysis.ResumeBulkAnalyses(); err != nil {
+		log.Error("main", "SERVER", 2051, err)
+	}
+
+	// remove share tokens of analyses once they expire
+	analysis.StartExpiredSharesCleaner(time.Hour)
+
+	// archive the raw output of analyses older than the retention every night
+	analysis.StartArchiveJob()
+
+	// delete analyses older than their maximum age every night, keeping the most recent ones
+	analysis.StartDeletionJob()
+
+	// images built for another architecture than the Docker host run under emulation
+	dockers.DetectHostPlatform()
+
+	// refuse to pull securityTest images with CRITICAL vulnerabilities and scan them every night
+	analysis.StartImageVulnScanJob()
+
+	// fail analyses whose containers exit without huskyCI noticing, such as when OOM-killed
+	if err := analysis.StartDockerEventMonitor(); err != nil {
+		log.Error("main", "SERVER", 3032, err)
+	}
+
 	echoInstance := echo.New()
 	echoInstance.HideBanner = true
 
@@ -71,24 +166,69 @@ func main() {
 
 	// generic routes
 	echoInstance.GET("/healthcheck", routes.HealthCheck)
+	echoInstance.GET("/healthcheck/deep", routes.DeepHealthCheck)
+	echoInstance.GET("/ready", routes.Readiness)
+	echoInstance.GET("/healthz", routes.Healthz)
+	echoInstance.GET("/readyz", routes.Readyz)
+	echoInstance.GET("/metrics", routes.Metrics)
 	echoInstance.GET("/version", routes.GetAPIVersion)
 
-	// analysis routes
-	echoInstance.POST("/analysis", routes.ReceiveRequest)
+	// analysis routes, whose creation is rate limited by access token or IP
+	echoInstance.POST("/analysis", routes.ReceiveRequest, routes.RateLimitAnalyses)
+	echoInstance.POST("/analysis/tarball", routes.ReceiveTarballRequest, routes.RateLimitAnalyses)
 	echoInstance.GET("/analysis/:id", routes.GetAnalysis)
+	echoInstance.GET("/analyses", routes.ListAnalyses)
+	echoInstance.GET("/analysis/:id/findings", routes.GetAnalysisFindings)
+	echoInstance.GET("/analysis/:id/diff", routes.DiffAnalyses)
+	echoInstance.GET("/analysis/:id/export", routes.ExportAnalysis)
+	echoInstance.GET("/analysis/:id/verify", routes.VerifyAnalysis)
+	echoInstance.GET("/analysis/:id/timeline", routes.GetAnalysisTimeline)
+	echoInstance.POST("/analysis/:id/retry", routes.RetryAnalysis, routes.RateLimitAnalyses)
+	echoInstance.GET("/findings", routes.GetFindings)
+	echoInstance.GET("/findings/export", routes.ExportFindings)
+	echoInstance.POST("/analysis/:id/share", routes.ShareAnalysis)
+	echoInstance.DELETE("/analysis/:id/share", routes.RevokeAnalysisShares)
+	echoInstance.GET("/analysis/shared/:token", routes.GetSharedAnalysis)
+	echoInstance.POST("/analysis/pr", routes.ReceivePRRequest, routes.RateLimitAnalyses)
+	echoInstance.GET("/analysis/pr/:id", routes.GetPRAnalysis)
+	// each repository of a bulk analysis is rate limited by ReceiveBulkRequest
+	echoInstance.POST("/analysis/bulk", routes.ReceiveBulkRequest)
+	echoInstance.GET("/analysis/bulk/:jobID", routes.GetBulkAnalysis)
 	// echoInstance.PUT("/analysis/:id", routes.UpdateAnalysis)
-	// echoInstance.DELETE("/analysis/:id", routes.DeleteAnalysis)
+	echoInstance.DELETE("/analysis/:id", routes.DeleteAnalysis)
 
 	// stats routes
+	echoInstance.GET("/stats", routes.GetStats)
 	echoInstance.GET("/stats/:metric_type", routes.GetMetric)
 
+	// admin routes
+	echoInstance.GET("/admin/sla/report", routes.GetSLAReport)
+	echoInstance.GET("/admin/retention", routes.GetRetention)
+	echoInstance.POST("/admin/retention/run", routes.RunRetention)
+	echoInstance.GET("/admin/images/vulns", routes.GetImageVulns)
+	echoInstance.POST("/admin/images/validate", routes.ValidateImages)
+	echoInstance.POST("/admin/tokens", routes.CreateAccessToken)
+	echoInstance.DELETE("/admin/tokens/:uuid", routes.RevokeAccessToken)
+	echoInstance.PUT("/admin/tokens/:uuid/ratelimit", routes.SetAccessTokenRateLimit)
+
 	// securityTest routes
 	// echoInstance.GET("securityTest/:securityTestName", routes.GetSecurityTest)
-	// echoInstance.POST("/securitytest", routes.CreateNewSecurityTest)
-	// echoInstance.PUT("/securityTest/:securityTestName", routes.UpdateSecurityTest)
-	// echoInstance.DELETE("/securityTest/:securityTestName", routes.DeleteSecurityTest)
+	echoInstance.POST("/securitytest", routes.CreateSecurityTest)
+	echoInstance.PUT("/securitytest/:securityTestName", routes.UpdateSecurityTest)
+	echoInstance.DELETE("/securitytest/:securityTestName", routes.DeleteSecurityTest)
+
+	// finding routes
+	echoInstance.GET("/findings/:fingerprint", routes.GetFinding)
+	echoInstance.POST("/findings/:fingerprint/suppress", routes.RequestFindingSuppression)
+	echoInstance.PATCH("/findings/:fingerprint/suppress/:requestID", routes.ReviewFindingSuppression)
+	echoInstance.GET("/suppressions/pending", routes.GetPendingSuppressions)
 
 	// repository routes
+	echoInstance.GET("/repos", routes.ListRepositories)
+	echoInstance.GET("/repos/:repoID/trend", routes.GetRepositoryTrend)
+	echoInstance.PUT("/repos/:repoID/slack", routes.UpdateRepositorySlackChannel)
+	echoInstance.PUT("/repos/:repoID/gitlab", routes.UpdateRepositoryGitLab)
+	echoInstance.PUT("/repos/:repoID/https-token", routes.UpdateRepositoryHTTPSToken)
 	// echoInstance.GET("/repository/:repoID", routes.GetRepository)
 	// echoInstance.POST("/repository", routes.CreateNewRepository)
 	// echoInstance.PUT("/repository/:repoID)
@@ -100,11 +240,47 @@ func main() {
 	echoInstance.PUT("/user", routes.UpdateUser)
 	// echoInstance.DELETE("/user)
 
+	// gRPC API runs alongside the REST API
+	go func() {
+		if err := huskyGrpc.Start(configAPI.GRPCPort, configAPI.UseTLS); err != nil {
+			log.Error("main", "SERVER", 1040, err)
+			os.Exit(1)
+		}
+	}()
+
 	huskyAPIport := fmt.Sprintf(":%d", configAPI.Port)
 
-	if !configAPI.UseTLS {
-		echoInstance.Logger.Fatal(echoInstance.Start(huskyAPIport))
-	} else {
-		echoInstance.Logger.Fatal(echoInstance.StartTLS(huskyAPIport, util.CertFile, util.KeyFile))
+	go func() {
+		var err error
+		if !configAPI.UseTLS {
+			err = echoInstance.Start(huskyAPIport)
+		} else {
+			err = echoInstance.StartTLS(huskyAPIport, util.CertFile, util.KeyFile)
+		}
+		if err != http.ErrServerClosed {
+			echoInstance.Logger.Fatal(err)
+		}
+	}()
+
+	// analyses already running are drained before huskyCI stops
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+	log.Info("main", "SERVER", 39, <-signals)
+	shutdown(echoInstance, configAPI.ShutdownTimeout)
+}
+
+// shutdown refuses new analyses, waits up to timeout for the running ones before
+// interrupting them and then stops the REST API once pending notifications are sent.
+func shutdown(echoInstance *echo.Echo, timeout time.Duration) {
+	interrupted := analysis.Shutdown(timeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := echoInstance.Shutdown(ctx); err != nil {
+		log.Error("main", "SERVER", 1055, err)
+	}
+	if !notifier.Flush(10 * time.Second) {
+		log.Warning("main", "SERVER", 128, 10*time.Second)
 	}
+	log.Info("main", "SERVER", 40, interrupted)
 }
@@ -0,0 +1,82 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/globocom/huskyCI/api/cache"
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+)
+
+const logInfoStats = "STATS"
+
+// CacheKey is the key of the cached analysis stats.
+const CacheKey = "huskyci:stats"
+
+// Summary is the JSON encoded AnalysisStats and its ETag.
+type Summary struct {
+	Body []byte
+	ETag string
+}
+
+// Service computes AnalysisStats and caches them, as they are expensive to aggregate.
+type Service struct {
+	Cache   cache.Cache
+	Compute func() (types.AnalysisStats, error)
+	TTL     time.Duration
+}
+
+// NewService returns a Service that caches the stats of the API database
+// for 5 minutes, if Redis is configured.
+func NewService() *Service {
+	return &Service{
+		Cache:   cache.Instance,
+		Compute: apiContext.APIConfiguration.DBInstance.GetAnalysisStats,
+		TTL:     5 * time.Minute,
+	}
+}
+
+// GetSummary returns the cached stats, or computes them if they are not cached
+// or if refresh is true.
+func (s *Service) GetSummary(refresh bool) (Summary, error) {
+	if s.Cache != nil && !refresh {
+		body, err := s.Cache.Get(CacheKey)
+		if err == nil {
+			return newSummary(body), nil
+		}
+		if err != cache.ErrCacheMiss {
+			log.Warning("GetSummary", logInfoStats, 116, err)
+		}
+	}
+
+	analysisStats, err := s.Compute()
+	if err != nil {
+		return Summary{}, err
+	}
+	body, err := json.Marshal(analysisStats)
+	if err != nil {
+		return Summary{}, err
+	}
+
+	if s.Cache != nil {
+		if err := s.Cache.Set(CacheKey, body, s.TTL); err != nil {
+			log.Warning("GetSummary", logInfoStats, 116, err)
+		}
+	}
+	return newSummary(body), nil
+}
+
+func newSummary(body []byte) Summary {
+	return Summary{
+		Body: body,
+		ETag: fmt.Sprintf(`"%x"`, sha256.Sum256(body)),
+	}
+}
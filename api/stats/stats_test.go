@@ -0,0 +1,119 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package stats_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/cache"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/stats"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+type FakeCache struct {
+	values   map[string][]byte
+	getError error
+	ttl      time.Duration
+}
+
+func (fC *FakeCache) Get(key string) ([]byte, error) {
+	if fC.getError != nil {
+		return nil, fC.getError
+	}
+	value, ok := fC.values[key]
+	if !ok {
+		return nil, cache.ErrCacheMiss
+	}
+	return value, nil
+}
+
+func (fC *FakeCache) Set(key string, value []byte, ttl time.Duration) error {
+	fC.values[key] = value
+	fC.ttl = ttl
+	return nil
+}
+
+var _ = Describe("Stats", func() {
+
+	log.InitLog(true, "", "", "log_test", "log_test")
+
+	var computed int
+	compute := func() (types.AnalysisStats, error) {
+		computed++
+		return types.AnalysisStats{Totals: types.AnalysisTotals{Last24Hours: computed}}, nil
+	}
+
+	BeforeEach(func() {
+		computed = 0
+	})
+
+	Context("When stats are not cached", func() {
+		It("Should compute and cache them with the configured TTL", func() {
+			fakeCache := &FakeCache{values: map[string][]byte{}}
+			service := stats.Service{Cache: fakeCache, Compute: compute, TTL: 5 * time.Minute}
+			summary, err := service.GetSummary(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(computed).To(Equal(1))
+			Expect(fakeCache.values[stats.CacheKey]).To(Equal(summary.Body))
+			Expect(fakeCache.ttl).To(Equal(5 * time.Minute))
+			Expect(summary.ETag).To(HavePrefix(`"`))
+		})
+	})
+
+	Context("When stats are cached", func() {
+		It("Should return them with the same ETag without computing", func() {
+			fakeCache := &FakeCache{values: map[string][]byte{}}
+			service := stats.Service{Cache: fakeCache, Compute: compute, TTL: time.Minute}
+			first, _ := service.GetSummary(false)
+			second, err := service.GetSummary(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(computed).To(Equal(1))
+			Expect(second).To(Equal(first))
+		})
+		It("Should compute them again when refresh is set", func() {
+			fakeCache := &FakeCache{values: map[string][]byte{}}
+			service := stats.Service{Cache: fakeCache, Compute: compute, TTL: time.Minute}
+			first, _ := service.GetSummary(false)
+			second, err := service.GetSummary(true)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(computed).To(Equal(2))
+			Expect(second.ETag).NotTo(Equal(first.ETag))
+		})
+	})
+
+	Context("When the cache can not be reached", func() {
+		It("Should still compute the stats", func() {
+			fakeCache := &FakeCache{values: map[string][]byte{}, getError: errors.New("connection refused")}
+			service := stats.Service{Cache: fakeCache, Compute: compute, TTL: time.Minute}
+			_, err := service.GetSummary(false)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(computed).To(Equal(1))
+		})
+	})
+
+	Context("When there is no cache", func() {
+		It("Should compute the stats every time", func() {
+			service := stats.Service{Compute: compute}
+			service.GetSummary(false)
+			service.GetSummary(false)
+			Expect(computed).To(Equal(2))
+		})
+	})
+
+	Context("When stats can not be computed", func() {
+		It("Should return the error", func() {
+			service := stats.Service{Compute: func() (types.AnalysisStats, error) {
+				return types.AnalysisStats{}, errors.New("aggregation failed")
+			}}
+			_, err := service.GetSummary(false)
+			Expect(err).To(MatchError("aggregation failed"))
+		})
+	})
+})
@@ -0,0 +1,81 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+// Package timeline records what happened to each container of an analysis, from the pull of
+// its image to its result being stored, so a stuck analysis can be traced without its logs.
+package timeline
+
+import (
+	"sort"
+	"time"
+
+	apiContext "github.com/globocom/huskyCI/api/context"
+	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/types"
+	mgo "gopkg.in/mgo.v2"
+)
+
+const logInfoTimeline = "TIMELINE"
+
+// Types of the events of a container, in the order they happen. A container whose image is
+// already loaded has no pulled event.
+const (
+	EventPulled    = "container.pulled"
+	EventCreated   = "container.created"
+	EventStarted   = "container.started"
+	EventFinished  = "container.finished"
+	EventParsed    = "container.parsed"
+	EventPersisted = "container.persisted"
+)
+
+// NewEvent returns the event of eventType of the container CID of securityTest, in the
+// analysis of RID, that happened at now. err, if not nil, is why that step failed.
+func NewEvent(RID, CID, securityTest, eventType string, err error, now time.Time) types.AnalysisEvent {
+	event := types.AnalysisEvent{
+		RID:          RID,
+		CID:          CID,
+		SecurityTest: securityTest,
+		Type:         eventType,
+		Time:         now,
+	}
+	if err != nil {
+		event.Error = err.Error()
+	}
+	return event
+}
+
+// Record stores the event of eventType of the container CID of securityTest in the timeline of
+// the analysis of RID. Containers not run by an analysis, such as the one of Trivy, have no RID,
+// so they have no timeline. An event that can not be stored is only logged, as the analysis
+// goes on without it.
+func Record(RID, CID, securityTest, eventType string, err error) {
+	if RID == "" || apiContext.APIConfiguration == nil || apiContext.APIConfiguration.DBInstance == nil {
+		return
+	}
+	event := NewEvent(RID, CID, securityTest, eventType, err, time.Now())
+	if err := apiContext.APIConfiguration.DBInstance.InsertDBAnalysisEvent(event); err != nil {
+		log.Error("Record", logInfoTimeline, 2047, RID, eventType, err)
+	}
+}
+
+// Events returns the timeline of the analysis of RID, the oldest event first.
+func Events(RID string) ([]types.AnalysisEvent, error) {
+	events, err := apiContext.APIConfiguration.DBInstance.FindAllDBAnalysisEvents(map[string]interface{}{"RID": RID})
+	if err != nil {
+		if err == mgo.ErrNotFound || err.Error() == "No data found" {
+			return []types.AnalysisEvent{}, nil
+		}
+		return nil, err
+	}
+	Sort(events)
+	return events, nil
+}
+
+// Sort sorts events by when they happened, keeping the order they were recorded in when they
+// happened at the same time.
+func Sort(events []types.AnalysisEvent) {
+	sort.SliceStable(events, func(i, j int) bool {
+		return events[i].Time.Before(events[j].Time)
+	})
+}
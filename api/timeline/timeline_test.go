@@ -0,0 +1,50 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package timeline_test
+
+import (
+	"errors"
+	"time"
+
+	"github.com/globocom/huskyCI/api/timeline"
+	"github.com/globocom/huskyCI/api/types"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Timeline", func() {
+
+	createdAt := time.Date(2020, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	Describe("NewEvent", func() {
+		It("Should carry the container, its securityTest and when it happened", func() {
+			Expect(timeline.NewEvent("RID", "CID", "gosec", timeline.EventCreated, nil, createdAt)).To(Equal(types.AnalysisEvent{
+				RID:          "RID",
+				CID:          "CID",
+				SecurityTest: "gosec",
+				Type:         timeline.EventCreated,
+				Time:         createdAt,
+			}))
+		})
+
+		It("Should carry why the step failed", func() {
+			event := timeline.NewEvent("RID", "CID", "gosec", timeline.EventParsed, errors.New("could not parse gosec output"), createdAt)
+			Expect(event.Error).To(Equal("could not parse gosec output"))
+		})
+	})
+
+	Describe("Sort", func() {
+		It("Should sort events by when they happened, keeping the ones at the same time in order", func() {
+			events := []types.AnalysisEvent{
+				{Type: timeline.EventStarted, Time: createdAt.Add(time.Second)},
+				{Type: timeline.EventPulled, Time: createdAt},
+				{Type: timeline.EventCreated, Time: createdAt},
+			}
+			timeline.Sort(events)
+			Expect([]string{events[0].Type, events[1].Type, events[2].Type}).To(Equal([]string{timeline.EventPulled, timeline.EventCreated, timeline.EventStarted}))
+		})
+	})
+})
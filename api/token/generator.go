@@ -20,6 +20,11 @@ func (tC *TCaller) ValidateURL(url string) (string, error) {
 	return util.CheckMaliciousRepoURL(url)
 }
 
+// ValidateURLPrefix validates if an URL prefix is malicious or not.
+func (tC *TCaller) ValidateURLPrefix(prefix string) (string, error) {
+	return util.CheckMaliciousRepoURLPrefix(prefix)
+}
+
 func generateRandomBytes() ([]byte, error) {
 	b := make([]byte, 32)
 	_, err := rand.Read(b)
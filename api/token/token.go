@@ -13,22 +13,45 @@ import (
 	"github.com/globocom/huskyCI/api/types"
 )
 
-// GenerateAccessToken will generate a valid access token
-// for a the requested repository URL. The access token
-// consists in two parts. The first is the UUID that is
-// used for identification in DB. The second part is a
-// random data. The hash of the random data is stored
-// using PBKDF2 algorithm. It is returned the base64 of
-// the two parts separated by two points.
-func (tH *THandler) GenerateAccessToken(repo types.TokenRequest) (string, error) {
-	accessToken := types.DBToken{}
-	validatedURL, err := tH.External.ValidateURL(repo.RepositoryURL)
+// ErrExpiryInPast is returned when an access token is
+// requested with an expiry that already passed.
+var ErrExpiryInPast = errors.New("Access token expiry is in the past")
+
+// CheckRequest will verify if an access token can be
+// generated for the request, returning its validated
+// repository URL or URL prefix.
+func (tH *THandler) CheckRequest(repo types.TokenRequest) (string, error) {
+	validate := tH.External.ValidateURL
+	if repo.Prefix {
+		validate = tH.External.ValidateURLPrefix
+	}
+	validatedURL, err := validate(repo.RepositoryURL)
 	if err != nil {
 		return "", err
 	}
 	if validatedURL == "" {
 		return "", errors.New("Empty URL is not valid")
 	}
+	if !repo.ExpiresAt.IsZero() && !repo.ExpiresAt.After(tH.External.GetTimeNow()) {
+		return "", ErrExpiryInPast
+	}
+	return validatedURL, nil
+}
+
+// GenerateAccessToken will generate a valid access token
+// for a the requested repository URL, or for every
+// repository under the URL prefix if requested. The
+// access token consists in two parts. The first is the
+// UUID that is used for identification in DB. The second
+// part is a random data. The hash of the random data is
+// stored using PBKDF2 algorithm. It is returned the
+// base64 of the two parts separated by two points.
+func (tH *THandler) GenerateAccessToken(repo types.TokenRequest) (string, error) {
+	accessToken := types.DBToken{}
+	validatedURL, err := tH.CheckRequest(repo)
+	if err != nil {
+		return "", err
+	}
 	token, err := tH.External.GenerateToken()
 	if err != nil {
 		return "", err
@@ -50,6 +73,8 @@ func (tH *THandler) GenerateAccessToken(repo types.TokenRequest) (string, error)
 	}
 	accessToken.HuskyToken = tH.HashGen.GenHashValue([]byte(token), bSalt, iterations, keyLength, h)
 	accessToken.URL = validatedURL
+	accessToken.Prefix = repo.Prefix
+	accessToken.ExpiresAt = repo.ExpiresAt
 	accessToken.IsValid = true
 	accessToken.CreatedAt = tH.External.GetTimeNow()
 	accessToken.Salt = salt
@@ -102,9 +127,9 @@ func (tH *THandler) ValidateRandomData(rdata, hashdata, salt string) error {
 // ValidateToken will validate the received token.
 // It will verify if it exists an entry through the
 // returned UUID. If it exists, it will verify if it
-// is a valid token. It will verify the access token
-// has permission to start an analysis for the received
-// repository URL.
+// is a valid token that did not expire. It will verify
+// the access token has permission to start an analysis
+// for the received repository URL.
 func (tH *THandler) ValidateToken(token, repositoryURL string) error {
 	validURL, err := tH.External.ValidateURL(repositoryURL)
 	if err != nil {
@@ -121,12 +146,39 @@ func (tH *THandler) ValidateToken(token, repositoryURL string) error {
 	if !accessToken.IsValid {
 		return errors.New("Access token is invalid")
 	}
-	if accessToken.URL != validURL {
+	if !accessToken.ExpiresAt.IsZero() && !tH.External.GetTimeNow().Before(accessToken.ExpiresAt) {
+		return errors.New("Access token expired")
+	}
+	if !Covers(accessToken, validURL) {
 		return errors.New("Access token doesn't have permission to run analysis in the provided repository")
 	}
 	return tH.ValidateRandomData(randomData, accessToken.HuskyToken, accessToken.Salt)
 }
 
+// Identify will return the access token stored for
+// the received token if it is valid and did not
+// expire, whatever the repositories it gives access to.
+func (tH *THandler) Identify(token string) (types.DBToken, error) {
+	uUID, randomData, err := tH.GetSplitted(token)
+	if err != nil {
+		return types.DBToken{}, err
+	}
+	accessToken, err := tH.External.FindAccessToken(uUID)
+	if err != nil {
+		return types.DBToken{}, err
+	}
+	if !accessToken.IsValid {
+		return types.DBToken{}, errors.New("Access token is invalid")
+	}
+	if !accessToken.ExpiresAt.IsZero() && !tH.External.GetTimeNow().Before(accessToken.ExpiresAt) {
+		return types.DBToken{}, errors.New("Access token expired")
+	}
+	if err := tH.ValidateRandomData(randomData, accessToken.HuskyToken, accessToken.Salt); err != nil {
+		return types.DBToken{}, err
+	}
+	return accessToken, nil
+}
+
 // VerifyRepo will verify if exists an entry
 // for the received repository
 func (tH *THandler) VerifyRepo(repositoryURL string) error {
@@ -137,6 +189,17 @@ func (tH *THandler) VerifyRepo(repositoryURL string) error {
 	return tH.External.FindRepoURL(validURL)
 }
 
+// Covers will verify if the access token gives access
+// to the repository URL: it is the URL of the token or,
+// for a token of an URL prefix, it starts with it.
+func Covers(accessToken types.DBToken, repositoryURL string) bool {
+	if !accessToken.Prefix {
+		return accessToken.URL == repositoryURL
+	}
+	// prefixes end with "/", so org matches org/repo.git but not org2/repo.git
+	return strings.HasPrefix(repositoryURL, accessToken.URL) && !strings.Contains(repositoryURL, "..")
+}
+
 // InvalidateToken will set boolean flag IsValid
 // to false if the passed access token is found
 // in DB.
@@ -145,6 +208,13 @@ func (tH *THandler) InvalidateToken(token string) error {
 	if err != nil {
 		return err
 	}
+	return tH.RevokeAccessToken(uUID)
+}
+
+// RevokeAccessToken will set boolean flag IsValid
+// to false if the access token of the UUID is found
+// in DB.
+func (tH *THandler) RevokeAccessToken(uUID string) error {
 	accessToken, err := tH.External.FindAccessToken(uUID)
 	if err != nil {
 		return err
@@ -152,3 +222,24 @@ func (tH *THandler) InvalidateToken(token string) error {
 	accessToken.IsValid = false
 	return tH.External.UpdateAccessToken(uUID, accessToken)
 }
+
+// ErrInvalidRateLimit is returned when a rate limit
+// of an access token is negative.
+var ErrInvalidRateLimit = errors.New("Rate limit can not be negative")
+
+// SetRateLimit will set how many analyses the access
+// token of the UUID can request in a minute (burst)
+// and in an hour (sustained). A limit of 0 restores
+// the one of the API.
+func (tH *THandler) SetRateLimit(uUID string, burst, sustained int) error {
+	if burst < 0 || sustained < 0 {
+		return ErrInvalidRateLimit
+	}
+	accessToken, err := tH.External.FindAccessToken(uUID)
+	if err != nil {
+		return err
+	}
+	accessToken.RateLimitBurst = burst
+	accessToken.RateLimitSustained = sustained
+	return tH.External.UpdateAccessToken(uUID, accessToken)
+}
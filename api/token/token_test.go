@@ -49,6 +49,10 @@ func (fE *FakeExternal) ValidateURL(url string) (string, error) {
 	return fE.expectedURL, fE.expectedValidateError
 }
 
+func (fE *FakeExternal) ValidateURLPrefix(prefix string) (string, error) {
+	return fE.expectedURL, fE.expectedValidateError
+}
+
 func (fE *FakeExternal) GenerateToken() (string, error) {
 	return fE.expectedToken, fE.expectedGenerateError
 }
@@ -147,6 +151,23 @@ var _ = Describe("Token", func() {
 			Expect(err).To(Equal(errors.New("Empty URL is not valid")))
 		})
 	})
+	Context("When the requested expiry is in the past", func() {
+		It("Should return ErrExpiryInPast and an empty string", func() {
+			fakeExt := FakeExternal{
+				expectedURL:  "ValidURLRepo",
+				expectedTime: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+			}
+			tokenGen := THandler{
+				External: &fakeExt,
+			}
+			accessToken, err := tokenGen.GenerateAccessToken(types.TokenRequest{
+				RepositoryURL: "myRepo.com",
+				ExpiresAt:     time.Date(2020, 5, 1, 0, 0, 0, 0, time.UTC),
+			})
+			Expect(accessToken).To(Equal(""))
+			Expect(err).To(Equal(ErrExpiryInPast))
+		})
+	})
 	Context("When GenerateToken returns an error", func() {
 		It("Should return the same error and an empty string", func() {
 			fakeExt := FakeExternal{
@@ -491,6 +512,25 @@ var _ = Describe("Token", func() {
 				Expect(tokenVal.ValidateToken("EncodedRcvToken", "RcvRepo")).To(Equal(errors.New("Access token doesn't have permission to run analysis in the provided repository")))
 			})
 		})
+		Context("When access token from DB expired", func() {
+			It("Should return the expected error", func() {
+				fakeExt := FakeExternal{
+					expectedURL:  "MyValidURL",
+					expectedTime: time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+					expectedAccessToken: types.DBToken{
+						IsValid:    true,
+						HuskyToken: "StoredHash",
+						URL:        "MyValidURL",
+						ExpiresAt:  time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC),
+					},
+					expectedDecodedString: "UUID:RandomVal",
+				}
+				tokenVal := THandler{
+					External: &fakeExt,
+				}
+				Expect(tokenVal.ValidateToken("EncodedRcvToken", "RcvRepo")).To(Equal(errors.New("Access token expired")))
+			})
+		})
 		Context("When hash of random data is different from the stored hash", func() {
 			It("Should return the expected error from ValidateRandomData", func() {
 				fakeHash := FakeHashGen{
@@ -550,6 +590,20 @@ var _ = Describe("Token", func() {
 			})
 		})
 	})
+	Describe("Covers", func() {
+		It("Should only cover the URL of a repository token", func() {
+			accessToken := types.DBToken{URL: "https://github.com/globocom/huskyCI.git"}
+			Expect(Covers(accessToken, "https://github.com/globocom/huskyCI.git")).To(BeTrue())
+			Expect(Covers(accessToken, "https://github.com/globocom/huskyCI.git/other.git")).To(BeFalse())
+		})
+		It("Should cover every repository under the URL prefix of a prefix token", func() {
+			accessToken := types.DBToken{URL: "https://github.com/globocom/", Prefix: true}
+			Expect(Covers(accessToken, "https://github.com/globocom/huskyCI.git")).To(BeTrue())
+			Expect(Covers(accessToken, "https://github.com/globocomics/huskyCI.git")).To(BeFalse())
+			Expect(Covers(accessToken, "https://github.com/globocom/../other/repo.git")).To(BeFalse())
+		})
+	})
+
 	Describe("VerifyRepo", func() {
 		Context("When ValidateURL returns an error", func() {
 			It("Should return the same error", func() {
@@ -643,4 +697,70 @@ var _ = Describe("Token", func() {
 			})
 		})
 	})
+	Describe("Identify", func() {
+		Context("When access token from DB is not valid", func() {
+			It("Should return an error", func() {
+				fakeExt := FakeExternal{
+					expectedDecodedString: "MyUUID:MyRandom",
+					expectedAccessToken:   types.DBToken{IsValid: false},
+				}
+				identify := THandler{
+					External: &fakeExt,
+				}
+				_, err := identify.Identify("RcvToken")
+				Expect(err).To(Equal(errors.New("Access token is invalid")))
+			})
+		})
+		Context("When hash of random data is equal to the stored hash", func() {
+			It("Should return the access token whatever its URL", func() {
+				fakeExt := FakeExternal{
+					expectedDecodedString: "MyUUID:MyRandom",
+					expectedAccessToken: types.DBToken{
+						IsValid:        true,
+						HuskyToken:     "StoredEncodedRandomData",
+						UUID:           "MyUUID",
+						URL:            "MyURL",
+						Salt:           "MySalt",
+						RateLimitBurst: 100,
+					},
+				}
+				fakeHash := FakeHashGen{
+					expectedDecodedSalt: []byte("MySalt"),
+					expectedHashName:    "Sha512",
+					expectedHashValue:   "StoredEncodedRandomData",
+				}
+				identify := THandler{
+					External: &fakeExt,
+					HashGen:  &fakeHash,
+				}
+				accessToken, err := identify.Identify("RcvToken")
+				Expect(err).To(BeNil())
+				Expect(accessToken).To(Equal(fakeExt.expectedAccessToken))
+			})
+		})
+	})
+	Describe("SetRateLimit", func() {
+		Context("When a rate limit is negative", func() {
+			It("Should return ErrInvalidRateLimit", func() {
+				setLimit := THandler{
+					External: &FakeExternal{},
+				}
+				Expect(setLimit.SetRateLimit("MyUUID", -1, 10)).To(Equal(ErrInvalidRateLimit))
+			})
+		})
+		Context("When FindAccessToken returns a valid access token", func() {
+			It("Should update its rate limits in DB", func() {
+				fakeExt := FakeExternal{
+					expectedAccessToken: types.DBToken{IsValid: true, UUID: "MyUUID"},
+				}
+				setLimit := THandler{
+					External: &fakeExt,
+				}
+				Expect(setLimit.SetRateLimit("MyUUID", 100, 1000)).To(BeNil())
+				Expect(fakeExt.returnedAccessToken.UUID).To(Equal("MyUUID"))
+				Expect(fakeExt.returnedAccessToken.RateLimitBurst).To(Equal(100))
+				Expect(fakeExt.returnedAccessToken.RateLimitSustained).To(Equal(1000))
+			})
+		})
+	})
 })
@@ -4,19 +4,15 @@
 
 package token
 
-// HasAuthorization will verify if exists a valid
-// access token for the given repository. If exists,
-// it will validate the received access token. A true
-// bool is returned if it has authorization. If not,
-// it will return false.
+// HasAuthorization will validate the received access
+// token for the given repository. A true bool is
+// returned if it has authorization. If not, it will
+// return false, including when the repository has no
+// access token at all, so callers never tell whether
+// it exists.
 func (tV TValidator) HasAuthorization(accessToken, repositoryURL string) bool {
-	// Temporary: Verify if exists an access token
-	// for that repo
-	if err := tV.TokenVerifier.VerifyRepo(repositoryURL); err != nil {
-		return true
-	}
-	if err := tV.TokenVerifier.ValidateToken(accessToken, repositoryURL); err != nil {
+	if accessToken == "" || repositoryURL == "" {
 		return false
 	}
-	return true
+	return tV.TokenVerifier.ValidateToken(accessToken, repositoryURL) == nil
 }
@@ -33,15 +33,24 @@ func (fV *FakeVerifier) VerifyRepo(repositoryURL string) error {
 
 var _ = Describe("Tokenvalidator", func() {
 	Describe("HasAuthorization", func() {
-		Context("When VerifyRepo returns an error", func() {
-			It("Should return a true boolean", func() {
+		Context("When the repository has no access token", func() {
+			It("Should return a false boolean", func() {
 				fakeVerifier := FakeVerifier{
-					expectedVerifyError: errors.New("Could not find the repository URL"),
+					expectedVerifyError:   errors.New("Could not find the repository URL"),
+					expectedValidateError: errors.New("Could not find the access token"),
 				}
 				validator := TValidator{
 					TokenVerifier: &fakeVerifier,
 				}
-				Expect(validator.HasAuthorization("MyToken", "MyRepo")).To(BeTrue())
+				Expect(validator.HasAuthorization("MyToken", "MyRepo")).To(BeFalse())
+			})
+		})
+		Context("When no access token is received", func() {
+			It("Should return a false boolean", func() {
+				validator := TValidator{
+					TokenVerifier: &FakeVerifier{},
+				}
+				Expect(validator.HasAuthorization("", "MyRepo")).To(BeFalse())
 			})
 		})
 		Context("When ValidateToken returns an error", func() {
@@ -16,6 +16,7 @@ import (
 // necessary information about TokenHandler.
 type ExternalCalls interface {
 	ValidateURL(url string) (string, error)
+	ValidateURLPrefix(prefix string) (string, error)
 	GenerateToken() (string, error)
 	GetTimeNow() time.Time
 	StoreAccessToken(accessToken types.DBToken) error
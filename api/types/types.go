@@ -5,6 +5,7 @@
 package types
 
 import (
+	"strings"
 	"time"
 )
 
@@ -13,6 +14,109 @@ type Repository struct {
 	URL       string    `bson:"repositoryURL" json:"repositoryURL"`
 	Branch    string    `json:"repositoryBranch"`
 	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	// RiskScore is the risk score of the last finished analysis of the repository.
+	RiskScore       float64   `bson:"riskScore,omitempty" json:"riskScore"`
+	IncrementalScan bool      `bson:"-" json:"incrementalScan"`
+	BaseCommit      string    `bson:"-" json:"baseCommit"`
+	Subpaths        []Subpath `bson:"-" json:"subpaths"`
+	// ExcludedPaths, if set, override the excludedPaths of config.yaml.
+	ExcludedPaths []string `bson:"-" json:"excludedPaths"`
+	// ExtraEnv are environment variables set in every container of the analysis, such as
+	// a token of a scanner database. Only keys allowed by HUSKYCI_ALLOWED_ENV_KEYS are accepted.
+	ExtraEnv map[string]string `bson:"-" json:"extraEnv"`
+	// Ignores are findings the repository accepts, such as a vulnerability of a dependency without a fix.
+	// Each one is only respected once its suppression request is approved.
+	Ignores []Ignore `bson:"-" json:"ignores"`
+	// GitleaksAllowlist, if set, is rendered into the gitleaks config instead of the one of the repository.
+	GitleaksAllowlist *GitleaksAllowlist `bson:"-" json:"gitleaksAllowlist"`
+	// Force runs every securityTest even if HUSKYCI_SCAN_CACHE has findings of the same commit.
+	Force bool `bson:"-" json:"force"`
+	// Refs are other branches or tags, such as the base branch of a pull request, scanned
+	// after Branch in the same analysis so their findings can be compared.
+	Refs []string `bson:"-" json:"refs"`
+	// NpmAuditFailOn is which vulnerabilities found by npm audit fail the analysis, NpmAuditFailOnAll by default.
+	NpmAuditFailOn string `bson:"-" json:"npmAuditFailOn"`
+	Source         Source `bson:"-" json:"-"`
+	// CommitSHA, if set, is the commit of Branch scanned instead of its HEAD.
+	CommitSHA string `bson:"-" json:"commitSHA"`
+	// CommitAuthor, PullRequestID and Metadata describe who requested the analysis, such as
+	// a CI job, so integrations can link its results back to the pull request.
+	CommitAuthor  string            `bson:"-" json:"commitAuthor"`
+	PullRequestID string            `bson:"-" json:"pullRequestID"`
+	Metadata      map[string]string `bson:"-" json:"metadata"`
+	// CallbackURL, if set, is posted the result of the analysis once it finishes.
+	CallbackURL string `bson:"-" json:"callbackURL"`
+	// RetryOf is the RID of the analysis this one runs again, if any. It is not set by analysis requests.
+	RetryOf string `bson:"-" json:"-"`
+	// SlackChannel, if set, is where failed analyses of the repository are notified instead
+	// of the channel of HUSKYCI_API_SLACK_CHANNEL. It is not set by analysis requests.
+	SlackChannel string `bson:"slackChannel,omitempty" json:"slackChannel,omitempty"`
+	// GitLab, if set, reports analyses of the repository to the GitLab of HUSKYCI_API_GITLAB_URL.
+	// It is not set by analysis requests.
+	GitLab bool `bson:"gitlab,omitempty" json:"gitlab,omitempty"`
+	// HTTPSToken, if set, clones the repository with HUSKYCI_API_GIT_HTTPS_TOKEN, as long as its
+	// host is one of HUSKYCI_API_GIT_HTTPS_TOKEN_HOSTS. It is not set by analysis requests.
+	HTTPSToken bool `bson:"httpsToken,omitempty" json:"httpsToken,omitempty"`
+}
+
+// NpmAuditFailOn values set which vulnerabilities found by npm audit fail an analysis, by the
+// dependencyDepth of their package. The ones that do not fail it are still reported as low.
+const (
+	// NpmAuditFailOnAll fails on every medium and high vulnerability.
+	NpmAuditFailOnAll = "all"
+	// NpmAuditFailOnDirect fails only on vulnerabilities of direct dependencies.
+	NpmAuditFailOnDirect = "direct"
+	// NpmAuditFailOnDirectOrFixable also fails on vulnerabilities of transitive dependencies with a fix available.
+	NpmAuditFailOnDirectOrFixable = "directOrFixable"
+)
+
+// DependencyDepth values tell whether a vulnerable package is required by the repository itself.
+const (
+	DependencyDirect     = "direct"
+	DependencyTransitive = "transitive"
+)
+
+// Source is where the code scanned by securityTests comes from. By default, each
+// securityTest clones the repository itself. If Tarball is set, it is the tar archive
+// of the repository working tree copied into each container instead.
+type Source struct {
+	Tarball []byte
+}
+
+// IsTarball returns whether the code is scanned from a tarball instead of a git clone.
+func (source Source) IsTarball() bool {
+	return len(source.Tarball) > 0
+}
+
+// Ignore is a finding of a securityTest, identified by its vulnerability ID such as the
+// one of Safety, that a repository accepts until it expires. Without an expiry, it never does.
+type Ignore struct {
+	SecurityTest string    `bson:"securityTest" json:"securityTest"`
+	ID           string    `bson:"id" json:"id"`
+	Reason       string    `bson:"reason,omitempty" json:"reason,omitempty"`
+	ExpiresAt    time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+}
+
+// GitleaksAllowlist is what gitleaks does not report in a repository, such as test fixtures.
+// ProtectedTags are set by huskyCI from gitleaksProtectedTags of config.yaml: the rules tagged
+// with one of them, such as private keys, are never allowlisted.
+type GitleaksAllowlist struct {
+	Paths          []string `bson:"paths,omitempty" json:"paths,omitempty"`
+	Regexes        []string `bson:"regexes,omitempty" json:"regexes,omitempty"`
+	DisableEntropy bool     `bson:"disableEntropy,omitempty" json:"disableEntropy,omitempty"`
+	ProtectedTags  []string `bson:"protectedTags,omitempty" json:"protectedTags,omitempty"`
+}
+
+// IsActive returns whether ignore has not expired at now.
+func (ignore Ignore) IsActive(now time.Time) bool {
+	return ignore.ExpiresAt.IsZero() || now.Before(ignore.ExpiresAt)
+}
+
+// Subpath is a directory of a monorepo whose code is scanned by the securityTests of
+// its languages. If no language is given, the ones found in the repository are used.
+type Subpath struct {
+	Path      string   `bson:"path" json:"path"`
+	Languages []string `bson:"languages,omitempty" json:"languages,omitempty"`
 }
 
 // SecurityTest is the struct that stores all data from the security tests to be executed.
@@ -25,6 +129,21 @@ type SecurityTest struct {
 	Language         string `bson:"language" json:"language"`
 	Default          bool   `bson:"default" json:"default"`
 	TimeOutInSeconds int    `bson:"timeOutSeconds" json:"timeOutSeconds"`
+	Dedup            bool   `bson:"dedup" json:"dedup"`
+	User             string `bson:"user,omitempty" json:"user,omitempty"`
+	BlockingMode     string `bson:"blockingMode,omitempty" json:"blockingMode,omitempty"`
+	ExpectedVersion  string `bson:"expectedVersion,omitempty" json:"expectedVersion,omitempty"`
+	// URL is the canonical URL of the securityTool.
+	URL string `bson:"url,omitempty" json:"url,omitempty"`
+	// Parser is the parser of the output of a custom securityTest: the name of a
+	// built-in securityTest or of a plugin, or "generic".
+	Parser string `bson:"parser,omitempty" json:"parser,omitempty"`
+	// Custom is whether the securityTest was registered through the API instead of config.yaml.
+	Custom bool `bson:"custom,omitempty" json:"custom,omitempty"`
+	// DependsOn are the securityTests of the same analysis that must finish successfully
+	// before this one starts, such as a build whose output it scans. They share a volume
+	// mounted at $HUSKYCI_SHARED_DIR, where the build writes what this one scans.
+	DependsOn []string `bson:"dependsOn,omitempty" json:"dependsOn,omitempty"`
 }
 
 // Analysis is the struct that stores all data from analysis performed.
@@ -41,6 +160,100 @@ type Analysis struct {
 	FinishedAt     time.Time      `bson:"finishedAt" json:"finishedAt"`
 	Codes          []Code         `bson:"codes" json:"codes"`
 	HuskyCIResults HuskyCIResults `bson:"huskyciresults,omitempty" json:"huskyciresults"`
+	ScanType       string         `bson:"scanType,omitempty" json:"scanType,omitempty"`
+	BaseCommit     string         `bson:"baseCommit,omitempty" json:"baseCommit,omitempty"`
+	Subpaths       []Subpath      `bson:"subpaths,omitempty" json:"subpaths,omitempty"`
+	ExcludedPaths  []string       `bson:"excludedPaths,omitempty" json:"excludedPaths,omitempty"`
+	SourceType     string         `bson:"sourceType,omitempty" json:"sourceType,omitempty"`
+	// Commit is the SHA of the commit of Branch scanned, if it is known.
+	Commit string `bson:"commit,omitempty" json:"commit,omitempty"`
+	// ExtraEnvKeys are the keys of the ExtraEnv of the analysis request, as its values may be secrets.
+	ExtraEnvKeys []string `bson:"extraEnvKeys,omitempty" json:"extraEnvKeys,omitempty"`
+	// Ignored are the approved ignores of the analysis request that had not expired when it started.
+	Ignored []Ignore `bson:"ignored,omitempty" json:"ignored,omitempty"`
+	// GitleaksAllowlist is the effective allowlist gitleaks ran with, if the request set one.
+	GitleaksAllowlist *GitleaksAllowlist `bson:"gitleaksAllowlist,omitempty" json:"gitleaksAllowlist,omitempty"`
+	// NpmAuditFailOn is set if the request changed which npm audit vulnerabilities fail the analysis.
+	NpmAuditFailOn string `bson:"npmAuditFailOn,omitempty" json:"npmAuditFailOn,omitempty"`
+	// Refs are the other refs the request asked to scan. Result and HuskyCIResults are the ones of
+	// Branch, while the ones of each other ref are kept in RefResults.
+	Refs       []string    `bson:"refs,omitempty" json:"refs,omitempty"`
+	RefResults []RefResult `bson:"refResults,omitempty" json:"refResults,omitempty"`
+	// Summary is nil for analyses finished before it was stored.
+	Summary *SeveritySummary `bson:"summary,omitempty" json:"summary,omitempty"`
+	// RiskScore goes from 0 to 100, see analysis.ComputeRiskScore.
+	RiskScore float64 `bson:"riskScore,omitempty" json:"riskScore"`
+	// Integrity is the HMAC of the analysis once finished, see analysis.ComputeIntegrity.
+	Integrity string `bson:"integrity,omitempty" json:"integrity,omitempty"`
+	// CommitSHA, CommitAuthor, PullRequestID and Metadata are the ones of the analysis request.
+	CommitSHA     string            `bson:"commitSHA,omitempty" json:"commitSHA,omitempty"`
+	CommitAuthor  string            `bson:"commitAuthor,omitempty" json:"commitAuthor,omitempty"`
+	PullRequestID string            `bson:"pullRequestID,omitempty" json:"pullRequestID,omitempty"`
+	Metadata      map[string]string `bson:"metadata,omitempty" json:"metadata,omitempty"`
+	// CallbackURL is posted the result of the analysis once it finishes, see Callback for how it went.
+	CallbackURL string            `bson:"callbackURL,omitempty" json:"callbackURL,omitempty"`
+	Callback    *CallbackDelivery `bson:"callback,omitempty" json:"callback,omitempty"`
+	// RetryOf is the RID of the analysis this one was asked to run again, see analysis.RetryAnalysis.
+	RetryOf string `bson:"retryOf,omitempty" json:"retryOf,omitempty"`
+	// AttemptCount is how many times the analysis ran, RetryCount how many of them were retries
+	// after a transient error, see RetryPolicy.
+	AttemptCount int `bson:"attemptCount,omitempty" json:"attemptCount,omitempty"`
+	RetryCount   int `bson:"retryCount" json:"retryCount"`
+	// GitHubCheckRunID is the Check Run the analysis is reported to GitHub as, if any.
+	GitHubCheckRunID int64 `bson:"githubCheckRunID,omitempty" json:"githubCheckRunID,omitempty"`
+	// Archived is set once the cOutput of its containers was moved to ArchiveKey of the archive
+	// backend, see analysis.ArchiveAnalyses. Its results and summary are kept.
+	Archived   bool      `bson:"archived,omitempty" json:"archived,omitempty"`
+	ArchivedAt time.Time `bson:"archivedAt,omitempty" json:"archivedAt,omitempty"`
+	ArchiveKey string    `bson:"archiveKey,omitempty" json:"archiveKey,omitempty"`
+	// OutputTails are the last lines written by each container of the analysis while it runs,
+	// by securityTest, see analysis.OutputTailKey.
+	OutputTails map[string][]string `bson:"outputTails,omitempty" json:"outputTails,omitempty"`
+	// FindingsRecorded is set once its findings are recorded in the finding collection, see
+	// analysis.RecordFindings.
+	FindingsRecorded bool `bson:"findingsRecorded,omitempty" json:"-"`
+}
+
+// RetryPolicy is how an analysis that could not run is retried. Its error is transient if it
+// contains one of RetryableErrors, such as "connection refused", so the analysis runs again up
+// to MaxAttempts times. Any other error, such as an image not found, fails it right away.
+type RetryPolicy struct {
+	MaxAttempts     int
+	RetryableErrors []string
+}
+
+// Retryable returns whether err is transient according to policy.
+func (policy RetryPolicy) Retryable(err error) bool {
+	if err == nil {
+		return false
+	}
+	message := strings.ToLower(err.Error())
+	for _, retryableError := range policy.RetryableErrors {
+		if retryableError != "" && strings.Contains(message, strings.ToLower(retryableError)) {
+			return true
+		}
+	}
+	return false
+}
+
+// AnalysisCallback is the payload posted to the callback URL of an analysis once it finishes.
+type AnalysisCallback struct {
+	RID        string           `json:"RID"`
+	URL        string           `json:"repositoryURL"`
+	Branch     string           `json:"repositoryBranch"`
+	Status     string           `json:"status"`
+	Result     string           `json:"result"`
+	Summary    *SeveritySummary `json:"summary,omitempty"`
+	Link       string           `json:"link,omitempty"`
+	FinishedAt time.Time        `json:"finishedAt"`
+}
+
+// CallbackDelivery is how the delivery of the callback of an analysis went.
+type CallbackDelivery struct {
+	Status    string    `bson:"status" json:"status"`
+	Attempts  int       `bson:"attempts" json:"attempts"`
+	Error     string    `bson:"error,omitempty" json:"error,omitempty"`
+	UpdatedAt time.Time `bson:"updatedAt" json:"updatedAt"`
 }
 
 // Container is the struct that stores all data from a container run.
@@ -53,6 +266,43 @@ type Container struct {
 	CInfo        string       `bson:"cInfo" json:"cInfo"`
 	StartedAt    time.Time    `bson:"startedAt" json:"startedAt"`
 	FinishedAt   time.Time    `bson:"finishedAt" json:"finishedAt"`
+	Retries      int          `bson:"retries,omitempty" json:"retries,omitempty"`
+	DurationMs   int64        `bson:"durationMs" json:"durationMs"`
+	// ScannerVersion is the version the securityTest cmd printed in its first line, the one the
+	// scanner reported in its output or, if it reported none, its image.
+	ScannerVersion string `bson:"scannerVersion,omitempty" json:"scannerVersion,omitempty"`
+	// ImageDigest identifies the image the container ran, so a mutable tag can not hide which one it was.
+	ImageDigest string `bson:"imageDigest,omitempty" json:"imageDigest,omitempty"`
+	// User is the "uid:gid" the scanner runs as. It is empty to run as the image's user,
+	// usually root. A non-root user can only write where the image allows it, such as its
+	// HOME and working directory, so a read-only rootfs must leave those paths in a tmpfs
+	// and its cmd can not write to paths such as /etc/ssh/ssh_config.
+	User string `bson:"user,omitempty" json:"user,omitempty"`
+	// Subpath is the directory of the repository scanned, if not the whole repository.
+	Subpath string `bson:"subpath,omitempty" json:"subpath,omitempty"`
+	// Ref is the branch or tag the container scanned.
+	Ref string `bson:"ref,omitempty" json:"ref,omitempty"`
+	// CacheKey identifies the commit, image and settings the container scanned, if HUSKYCI_SCAN_CACHE is set.
+	CacheKey string `bson:"cacheKey,omitempty" json:"cacheKey,omitempty"`
+	// Cached is whether the findings were reused from a container of a previous analysis with the same CacheKey.
+	Cached bool `bson:"cached,omitempty" json:"cached,omitempty"`
+	// Findings are only stored along with a CacheKey, so a later analysis can reuse them.
+	Findings *HuskyCISecurityTestOutput `bson:"findings,omitempty" json:"-"`
+}
+
+// RefResult is the result and findings of a ref scanned by an analysis along with its Branch.
+type RefResult struct {
+	Ref            string         `bson:"ref" json:"ref"`
+	Result         string         `bson:"result" json:"result"`
+	ErrorFound     string         `bson:"errorFound,omitempty" json:"errorFound,omitempty"`
+	Codes          []Code         `bson:"codes" json:"codes"`
+	HuskyCIResults HuskyCIResults `bson:"huskyciresults" json:"huskyciresults"`
+}
+
+// ContainerFile is a file copied into a container before it starts, such as a scanner config.
+type ContainerFile struct {
+	Path    string
+	Content []byte
 }
 
 // Code is the struct that stores all data from code found in a repository.
@@ -75,18 +325,303 @@ type User struct {
 
 // HuskyCIVulnerability is the struct that stores vulnerability information.
 type HuskyCIVulnerability struct {
-	Language       string `bson:"language" json:"language,omitempty"`
-	SecurityTool   string `bson:"securitytool" json:"securitytool,omitempty"`
-	Severity       string `bson:"severity,omitempty" json:"severity,omitempty"`
-	Confidence     string `bson:"confidence,omitempty" json:"confidence,omitempty"`
-	File           string `bson:"file,omitempty" json:"file,omitempty"`
-	Line           string `bson:"line,omitempty" json:"line,omitempty"`
-	Code           string `bson:"code,omitempty" json:"code,omitempty"`
-	Details        string `bson:"details" json:"details,omitempty"`
-	Type           string `bson:"type,omitempty" json:"type,omitempty"`
-	VunerableBelow string `bson:"vulnerablebelow,omitempty" json:"vulnerablebelow,omitempty"`
-	Version        string `bson:"version,omitempty" json:"version,omitempty"`
-	Occurrences    int    `bson:"occurrences,omitempty" json:"occurrences,omitempty"`
+	Language       string   `bson:"language" json:"language,omitempty"`
+	SecurityTool   string   `bson:"securitytool" json:"securitytool,omitempty"`
+	Severity       string   `bson:"severity,omitempty" json:"severity,omitempty"`
+	Confidence     string   `bson:"confidence,omitempty" json:"confidence,omitempty"`
+	File           string   `bson:"file,omitempty" json:"file,omitempty"`
+	Line           string   `bson:"line,omitempty" json:"line,omitempty"`
+	Code           string   `bson:"code,omitempty" json:"code,omitempty"`
+	Details        string   `bson:"details" json:"details,omitempty"`
+	Type           string   `bson:"type,omitempty" json:"type,omitempty"`
+	VunerableBelow string   `bson:"vulnerablebelow,omitempty" json:"vulnerablebelow,omitempty"`
+	Version        string   `bson:"version,omitempty" json:"version,omitempty"`
+	Occurrences    int      `bson:"occurrences,omitempty" json:"occurrences,omitempty"`
+	RuleID         string   `bson:"ruleid,omitempty" json:"ruleid,omitempty"`
+	CVSSScore      float64  `bson:"cvssscore,omitempty" json:"cvssscore,omitempty"`
+	Files          []string `bson:"files,omitempty" json:"files,omitempty"`
+	CVSSVector     string   `bson:"cvssvector,omitempty" json:"cvssvector,omitempty"`
+	NVDURL         string   `bson:"nvdurl,omitempty" json:"nvdurl,omitempty"`
+	// DependencyDepth is set by securityTests of dependencies that tell a direct one from a transitive one.
+	DependencyDepth string `bson:"dependencydepth,omitempty" json:"dependencyDepth,omitempty"`
+}
+
+// NVDEntry is the struct that stores NVD data of a CVE.
+type NVDEntry struct {
+	CVE          string    `bson:"cve" json:"cve"`
+	CVSSv3Score  float64   `bson:"cvssv3Score" json:"cvssv3Score"`
+	CVSSv3Vector string    `bson:"cvssv3Vector" json:"cvssv3Vector"`
+	URL          string    `bson:"url" json:"url"`
+	UpdatedAt    time.Time `bson:"updatedAt" json:"updatedAt"`
+}
+
+// UnifiedFinding is a single finding of an analysis regardless of the securityTool that found it.
+type UnifiedFinding struct {
+	File            string            `json:"file"`
+	Line            int               `json:"line"`
+	Tool            string            `json:"tool"`
+	RuleID          string            `json:"ruleID"`
+	Severity        string            `json:"severity"`
+	CVSSScore       float64           `json:"cvssScore"`
+	CVSSVector      string            `json:"cvssVector,omitempty"`
+	URL             string            `json:"url,omitempty"`
+	Description     string            `json:"description"`
+	Suppressed      bool              `json:"suppressed"`
+	ConfidenceScore float64           `json:"confidenceScore,omitempty"`
+	DependencyDepth string            `json:"dependencyDepth,omitempty"`
+	Compliance      ComplianceMapping `json:"compliance"`
+}
+
+// ComplianceMapping holds the requirements of each compliance framework a finding violates.
+type ComplianceMapping struct {
+	OWASP  []string `json:"owasp,omitempty"`
+	CWE    []string `json:"cwe,omitempty"`
+	PCIDSS []string `json:"pcidss,omitempty"`
+	NIST   []string `json:"nist,omitempty"`
+}
+
+// ComplianceViolation holds how many findings violate a requirement of a compliance framework.
+type ComplianceViolation struct {
+	Framework   string `json:"framework"`
+	Requirement string `json:"requirement"`
+	Findings    int    `json:"findings"`
+}
+
+// AnalysisFinding is a finding along with the analysis and the repository it was found in.
+type AnalysisFinding struct {
+	RID    string `json:"RID"`
+	URL    string `json:"repositoryURL"`
+	Branch string `json:"repositoryBranch"`
+	UnifiedFinding
+}
+
+// AnalysisListItem is the summary of an analysis listed among the ones of its repository.
+type AnalysisListItem struct {
+	RID        string           `json:"RID"`
+	Branch     string           `json:"repositoryBranch"`
+	Commit     string           `json:"commit,omitempty"`
+	Status     string           `json:"status"`
+	Result     string           `json:"result"`
+	StartedAt  time.Time        `json:"startedAt"`
+	FinishedAt time.Time        `json:"finishedAt"`
+	Summary    *SeveritySummary `json:"summary,omitempty"`
+}
+
+// AnalysisReport is an analysis along with the status of each of its securityTests and its
+// findings normalized across securityTools, so clients do not need to parse their outputs.
+type AnalysisReport struct {
+	Analysis
+	DurationMs    int64                `json:"durationMs"`
+	SecurityTests []SecurityTestReport `json:"securityTests"`
+	Findings      []UnifiedFinding     `json:"findings"`
+}
+
+// SecurityTestReport is the status, result and timings of a container of an analysis.
+type SecurityTestReport struct {
+	Name       string    `json:"name"`
+	Status     string    `json:"status"`
+	Result     string    `json:"result"`
+	Info       string    `json:"info,omitempty"`
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	DurationMs int64     `json:"durationMs"`
+	Ref        string    `json:"ref,omitempty"`
+	Subpath    string    `json:"subpath,omitempty"`
+	Cached     bool      `json:"cached,omitempty"`
+}
+
+// SeveritySummary holds how many findings of an analysis there are by severity and by securityTool.
+type SeveritySummary struct {
+	Critical      int            `bson:"critical" json:"critical"`
+	High          int            `bson:"high" json:"high"`
+	Medium        int            `bson:"medium" json:"medium"`
+	Low           int            `bson:"low" json:"low"`
+	Info          int            `bson:"info" json:"info"`
+	ByTool        map[string]int `bson:"byTool" json:"byTool"`
+	FilesAffected int            `bson:"filesAffected" json:"filesAffected"`
+	SecretsFound  bool           `bson:"secretsFound" json:"secretsFound"`
+	Ignored       int            `bson:"ignored" json:"ignored"`
+}
+
+// VulnerabilityTrend is a daily snapshot of how many findings a securityTool reported in a repository.
+type VulnerabilityTrend struct {
+	RepositoryURL string    `bson:"repository" json:"repository"`
+	Date          time.Time `bson:"date" json:"date"`
+	Tool          string    `bson:"tool" json:"tool"`
+	HighCount     int       `bson:"highCount" json:"highCount"`
+	MediumCount   int       `bson:"mediumCount" json:"mediumCount"`
+	LowCount      int       `bson:"lowCount" json:"lowCount"`
+	TotalCount    int       `bson:"totalCount" json:"totalCount"`
+}
+
+// Finding is a finding of a repository tracked across its analyses, so it is stored once
+// however many analyses report it. Its Fingerprint is given by the repository, the
+// securityTool and the ContentHash of the finding, see analysis.RepositoryFindingFingerprint.
+type Finding struct {
+	Fingerprint   string `bson:"fingerprint" json:"fingerprint"`
+	RepositoryURL string `bson:"repositoryURL" json:"repositoryURL"`
+	Tool          string `bson:"tool" json:"tool"`
+	ContentHash   string `bson:"contentHash" json:"contentHash"`
+	// Finding is how the last analysis that reported it did, such as its line. It is not
+	// stored, but read from the analysis of LastRID, see analysis.FindFinding.
+	Finding UnifiedFinding `bson:"-" json:"finding"`
+	// OccurrenceCount is how many analyses reported it.
+	OccurrenceCount int       `bson:"occurrenceCount" json:"occurrenceCount"`
+	FirstSeenAt     time.Time `bson:"firstSeenAt" json:"firstSeenAt"`
+	FirstRID        string    `bson:"firstRID" json:"firstRID"`
+	LastSeenAt      time.Time `bson:"lastSeenAt" json:"lastSeenAt"`
+	LastRID         string    `bson:"lastRID" json:"lastRID"`
+	// JIRAIssueKey is the key of the JIRA issue opened for it, such as SEC-42.
+	JIRAIssueKey string `bson:"jiraIssueKey,omitempty" json:"jiraIssueKey,omitempty"`
+}
+
+// RetentionPolicy is how long analyses keep their raw output in the database and where it is
+// archived to afterwards. Backend is empty if archiving is disabled.
+type RetentionPolicy struct {
+	RetentionDays int           `json:"retentionDays"`
+	Backend       string        `json:"backend"`
+	LastRun       *RetentionRun `json:"lastRun,omitempty"`
+	Running       bool          `json:"running"`
+	// MaxAgeDays is how long analyses are kept before they are deleted, unless they are one of
+	// the KeepPerRepository most recent of their repository. 0 never deletes them.
+	MaxAgeDays        int          `json:"maxAgeDays"`
+	KeepPerRepository int          `json:"keepPerRepository"`
+	LastDeletion      *DeletionRun `json:"lastDeletion,omitempty"`
+}
+
+// RetentionRun is how a run of the archive job went: how many analyses it archived and how
+// many it could not, along with the first error it got.
+type RetentionRun struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Archived   int       `json:"archived"`
+	Failed     int       `json:"failed"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// ImageVulnReport is how many vulnerabilities Trivy found in the image of a securityTest, by
+// severity, as of ScannedAt. Error is set if the image could not be scanned.
+type ImageVulnReport struct {
+	Image     string    `json:"image"`
+	ScannedAt time.Time `json:"scannedAt"`
+	Critical  int       `json:"critical"`
+	High      int       `json:"high"`
+	Medium    int       `json:"medium"`
+	Low       int       `json:"low"`
+	Unknown   int       `json:"unknown"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// ImagePlatformReport is the platforms, such as linux/arm64, the image of a securityTest is
+// built for, as its registry lists them. Error is set if they could not be read.
+type ImagePlatformReport struct {
+	Image         string   `json:"image"`
+	Platforms     []string `json:"platforms"`
+	MultiArch     bool     `json:"multiArch"`
+	HostSupported bool     `json:"hostSupported"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// ImagePlatformValidation is the report of the image of each securityTest on the platform of the
+// Docker host. AMD64Only lists the images built for linux/amd64 only, that run under emulation
+// on ARM Docker hosts.
+type ImagePlatformValidation struct {
+	HostPlatform string                `json:"hostPlatform"`
+	Images       []ImagePlatformReport `json:"images"`
+	AMD64Only    []string              `json:"amd64Only"`
+}
+
+// AnalysisEvent is a step of a container of an analysis, such as its creation or the parsing of
+// its output, and when it happened. Error is set if the step failed.
+type AnalysisEvent struct {
+	RID          string    `bson:"RID" json:"RID"`
+	CID          string    `bson:"CID,omitempty" json:"CID,omitempty"`
+	SecurityTest string    `bson:"securityTest" json:"securityTest"`
+	Type         string    `bson:"type" json:"type"`
+	Error        string    `bson:"error,omitempty" json:"error,omitempty"`
+	Time         time.Time `bson:"time" json:"time"`
+}
+
+// DeletionRun is how a run of the deletion job went: how many analyses it deleted, along
+// with the error that stopped it, if any.
+type DeletionRun struct {
+	StartedAt  time.Time `json:"startedAt"`
+	FinishedAt time.Time `json:"finishedAt"`
+	Deleted    int       `json:"deleted"`
+	Error      string    `json:"error,omitempty"`
+}
+
+// RepositoryTrend holds the daily snapshots of a repository and how many fewer
+// findings it has than 30 days ago. ImprovementDelta is negative if it has more.
+type RepositoryTrend struct {
+	RepositoryURL    string               `json:"repository"`
+	Days             int                  `json:"days"`
+	Tool             string               `json:"tool,omitempty"`
+	ImprovementDelta int                  `json:"improvementDelta"`
+	Trend            []VulnerabilityTrend `json:"trend"`
+}
+
+// CorrelatedGroup holds findings of one or more securityTools that point to the same issue.
+type CorrelatedGroup struct {
+	File            string           `json:"file"`
+	Line            int              `json:"line"`
+	Category        string           `json:"category"`
+	Tools           []string         `json:"tools"`
+	ConfidenceScore float64          `json:"confidenceScore"`
+	Findings        []UnifiedFinding `json:"findings"`
+}
+
+// AnalysisStats holds aggregated statistics of all analyses for dashboards.
+type AnalysisStats struct {
+	Totals                AnalysisTotals       `bson:"totals" json:"totals"`
+	Tools                 []ToolStats          `bson:"tools" json:"tools"`
+	TopFailedRepositories []RepositoryFailures `bson:"topFailedRepositories" json:"topFailedRepositories"`
+	CVESeverities         []SeverityCount      `bson:"cveSeverities" json:"cveSeverities"`
+	FindingsTrend         []FindingsTrend      `bson:"findingsTrend" json:"findingsTrend"`
+	GeneratedAt           time.Time            `bson:"generatedAt" json:"generatedAt"`
+}
+
+// AnalysisTotals holds how many analyses started in the last 24 hours, 7 and 30 days.
+type AnalysisTotals struct {
+	Last24Hours int `bson:"last24Hours" json:"last24Hours"`
+	Last7Days   int `bson:"last7Days" json:"last7Days"`
+	Last30Days  int `bson:"last30Days" json:"last30Days"`
+}
+
+// ToolStats holds the pass rate and the mean scan duration of a securityTest.
+type ToolStats struct {
+	SecurityTest        string  `bson:"securityTest" json:"securityTest"`
+	Total               int     `bson:"total" json:"total"`
+	PassRate            float64 `bson:"passRate" json:"passRate"`
+	MeanDurationSeconds float64 `bson:"meanDurationSeconds" json:"meanDurationSeconds"`
+}
+
+// SLAReport holds the percentiles of the scan duration of a securityTest.
+type SLAReport struct {
+	SecurityTest string  `bson:"securityTest" json:"securityTest"`
+	Total        int     `bson:"total" json:"total"`
+	P50Seconds   float64 `bson:"p50Seconds" json:"p50Seconds"`
+	P95Seconds   float64 `bson:"p95Seconds" json:"p95Seconds"`
+	P99Seconds   float64 `bson:"p99Seconds" json:"p99Seconds"`
+}
+
+// RepositoryFailures holds how many analyses of a repository failed.
+type RepositoryFailures struct {
+	RepositoryURL string `bson:"repositoryURL" json:"repositoryURL"`
+	Failures      int    `bson:"failures" json:"failures"`
+}
+
+// SeverityCount holds how many vulnerabilities of a severity were found.
+type SeverityCount struct {
+	Severity string `bson:"severity" json:"severity"`
+	Count    int    `bson:"count" json:"count"`
+}
+
+// FindingsTrend holds how many findings were first and last reported in a day.
+type FindingsTrend struct {
+	Date     time.Time `bson:"date" json:"date"`
+	New      int       `bson:"new" json:"new"`
+	Resolved int       `bson:"resolved" json:"resolved"`
 }
 
 // HuskyCIResults is a struct that represents huskyCI scan results.
@@ -97,6 +632,8 @@ type HuskyCIResults struct {
 	RubyResults       RubyResults       `bson:"rubyresults,omitempty" json:"rubyresults,omitempty"`
 	JavaResults       JavaResults       `bson:"javaresults,omitempty" json:"javaresults,omitempty"`
 	GenericResults    GenericResults    `bson:"genericresults,omitempty" json:"genericresults,omitempty"`
+	// PluginResults holds the results of securityTests parsed by plugins, by securityTest name.
+	PluginResults map[string]HuskyCISecurityTestOutput `bson:"pluginresults,omitempty" json:"pluginresults,omitempty"`
 }
 
 // GoResults represents all Golang security tests results.
@@ -114,11 +651,13 @@ type PythonResults struct {
 type JavaScriptResults struct {
 	HuskyCINpmAuditOutput  HuskyCISecurityTestOutput `bson:"npmauditoutput,omitempty" json:"npmauditoutput,omitempty"`
 	HuskyCIYarnAuditOutput HuskyCISecurityTestOutput `bson:"yarnauditoutput,omitempty" json:"yarnauditoutput,omitempty"`
+	HuskyCIEslintOutput    HuskyCISecurityTestOutput `bson:"eslintoutput,omitempty" json:"eslintoutput,omitempty"`
 }
 
 // JavaResults represents all Java security tests results.
 type JavaResults struct {
-	HuskyCISpotBugsOutput HuskyCISecurityTestOutput `bson:"spotbugsoutput,omitempty" json:"spotbugsoutput,omitempty"`
+	HuskyCISpotBugsOutput        HuskyCISecurityTestOutput `bson:"spotbugsoutput,omitempty" json:"spotbugsoutput,omitempty"`
+	HuskyCIDependencyCheckOutput HuskyCISecurityTestOutput `bson:"dependencycheckoutput,omitempty" json:"dependencycheckoutput,omitempty"`
 }
 
 // RubyResults represents all Ruby security tests results.
@@ -128,7 +667,9 @@ type RubyResults struct {
 
 // GenericResults represents all generic securityTests results
 type GenericResults struct {
-	HuskyCIGitleaksOutput HuskyCISecurityTestOutput `bson:"gitleaksoutput,omitempty" json:"gitleaksoutput,omitempty"`
+	HuskyCIGitleaksOutput     HuskyCISecurityTestOutput `bson:"gitleaksoutput,omitempty" json:"gitleaksoutput,omitempty"`
+	HuskyCIDepConfusionOutput HuskyCISecurityTestOutput `bson:"depconfusionoutput,omitempty" json:"depconfusionoutput,omitempty"`
+	HuskyCIHadolintOutput     HuskyCISecurityTestOutput `bson:"hadolintoutput,omitempty" json:"hadolintoutput,omitempty"`
 }
 
 // HuskyCISecurityTestOutput stores all Low, Medium and High vulnerabilities for a sec test
@@ -139,9 +680,14 @@ type HuskyCISecurityTestOutput struct {
 	HighVulns   []HuskyCIVulnerability `bson:"highvulns,omitempty" json:"highvulns,omitempty"`
 }
 
-// TokenRequest defines the JSON struct for an access token request
+// TokenRequest defines the JSON struct for an access token request. If Prefix is
+// true, RepositoryURL is a URL prefix ending with "/", such as the URL of an
+// organization, and the token is valid for every repository under it. A token
+// without ExpiresAt never expires.
 type TokenRequest struct {
-	RepositoryURL string `json:"repositoryURL"`
+	RepositoryURL string    `json:"repositoryURL"`
+	Prefix        bool      `json:"prefix,omitempty"`
+	ExpiresAt     time.Time `json:"expiresAt,omitempty"`
 }
 
 // AccessToken defines the struct generated when a new token
@@ -159,7 +705,231 @@ type DBToken struct {
 	CreatedAt  time.Time `bson:"createdAt" json:"createdAt"`
 	Salt       string    `bson:"salt" json:"salt"`
 	UUID       string    `bson:"uuid" json:"uuid"`
+	Prefix     bool      `bson:"prefix,omitempty" json:"prefix,omitempty"`
+	ExpiresAt  time.Time `bson:"expiresAt,omitempty" json:"expiresAt,omitempty"`
+	// RateLimitBurst and RateLimitSustained override the rate limits of analyses requested
+	// with the access token, for trusted heavy users, if they are set.
+	RateLimitBurst     int `bson:"rateLimitBurst,omitempty" json:"rateLimitBurst,omitempty"`
+	RateLimitSustained int `bson:"rateLimitSustained,omitempty" json:"rateLimitSustained,omitempty"`
+}
+
+// RateLimitRequest sets the rate limits of analyses requested with an access token. A limit
+// of 0 restores the one of the API.
+type RateLimitRequest struct {
+	Burst     int `json:"burst"`
+	Sustained int `json:"sustained"`
 }
 
 // NohuskyFunction represents all the #nohusky verifier methods.
 type NohuskyFunction func(string, int) bool
+
+// AnalysisShare is a signed token that gives read-only access to an analysis until it expires.
+type AnalysisShare struct {
+	Token     string    `bson:"token" json:"token"`
+	RID       string    `bson:"RID" json:"RID"`
+	CreatedAt time.Time `bson:"createdAt" json:"createdAt"`
+	ExpiresAt time.Time `bson:"expiresAt" json:"expiresAt"`
+}
+
+// SharedAnalysis is the read-only summary of an analysis given to whoever has one of its share tokens.
+type SharedAnalysis struct {
+	RID        string                `json:"RID"`
+	URL        string                `json:"repositoryURL"`
+	Branch     string                `json:"repositoryBranch"`
+	Status     string                `json:"status"`
+	Result     string                `json:"result"`
+	StartedAt  time.Time             `json:"startedAt"`
+	FinishedAt time.Time             `json:"finishedAt"`
+	Summary    *SeveritySummary      `json:"summary,omitempty"`
+	Findings   []UnifiedFinding      `json:"findings"`
+	Compliance []ComplianceViolation `json:"compliance"`
+	ExpiresAt  time.Time             `json:"expiresAt"`
+}
+
+// PRAnalysisRequest asks to analyze a pull or merge request by comparing its head and base branches.
+type PRAnalysisRequest struct {
+	URL        string `json:"repositoryURL"`
+	HeadBranch string `json:"headBranch"`
+	BaseBranch string `json:"baseBranch"`
+	HeadCommit string `json:"headCommit"`
+	BaseCommit string `json:"baseCommit"`
+}
+
+// PRAnalysisResult compares the analysis of the head of a pull or merge request with the one of its base.
+type PRAnalysisResult struct {
+	ID         string `bson:"id" json:"id"`
+	URL        string `bson:"repositoryURL" json:"repositoryURL"`
+	HeadBranch string `bson:"headBranch" json:"headBranch"`
+	BaseBranch string `bson:"baseBranch" json:"baseBranch"`
+	HeadCommit string `bson:"headCommit,omitempty" json:"headCommit,omitempty"`
+	BaseCommit string `bson:"baseCommit,omitempty" json:"baseCommit,omitempty"`
+	HeadRID    string `bson:"headRID" json:"headRID"`
+	BaseRID    string `bson:"baseRID" json:"baseRID"`
+	// BaseReused is set if BaseCommit had already been analyzed, so it was not analyzed again.
+	BaseReused         bool             `bson:"baseReused" json:"baseReused"`
+	Status             string           `bson:"status" json:"status"`
+	ErrorFound         string           `bson:"errorFound,omitempty" json:"errorFound,omitempty"`
+	NewFindings        []UnifiedFinding `bson:"newFindings" json:"newFindings"`
+	ResolvedFindings   []UnifiedFinding `bson:"resolvedFindings" json:"resolvedFindings"`
+	PersistentFindings []UnifiedFinding `bson:"persistentFindings" json:"persistentFindings"`
+	NewSummary         *SeveritySummary `bson:"newSummary,omitempty" json:"newSummary,omitempty"`
+	Review             *PRReview        `bson:"review,omitempty" json:"review,omitempty"`
+	StartedAt          time.Time        `bson:"startedAt" json:"startedAt"`
+	FinishedAt         time.Time        `bson:"finishedAt" json:"finishedAt"`
+}
+
+// BulkAnalysisRequest asks for the analysis of many repositories at once, such as every one of
+// a GitHub organization, running at most MaxConcurrency of them at the same time.
+type BulkAnalysisRequest struct {
+	Repositories   []BulkRepository `json:"repositories"`
+	MaxConcurrency int              `json:"maxConcurrency"`
+}
+
+// BulkRepository is a repository and branch of a bulk analysis request.
+type BulkRepository struct {
+	URL    string `json:"url"`
+	Branch string `json:"branch"`
+}
+
+// BulkAnalysisJob is a bulk analysis request along with the analysis of each of its repositories.
+type BulkAnalysisJob struct {
+	JobID string `bson:"jobID" json:"jobID"`
+	// Client is who requested the job, see ratelimit.AnalysisClient.
+	Client         string              `bson:"client,omitempty" json:"-"`
+	MaxConcurrency int                 `bson:"maxConcurrency" json:"maxConcurrency"`
+	Status         string              `bson:"status" json:"status"`
+	Repositories   []BulkAnalysisEntry `bson:"repositories" json:"repositories"`
+	CreatedAt      time.Time           `bson:"createdAt" json:"createdAt"`
+	FinishedAt     time.Time           `bson:"finishedAt" json:"finishedAt"`
+}
+
+// BulkAnalysisEntry is the analysis of a repository of a bulk analysis job. AnalysisID is known
+// before the analysis starts, while its status is queued. Error is set if it could not start.
+type BulkAnalysisEntry struct {
+	RepositoryURL string `bson:"repositoryURL" json:"repositoryURL"`
+	Branch        string `bson:"repositoryBranch" json:"repositoryBranch"`
+	AnalysisID    string `bson:"analysisID" json:"analysisID"`
+	Status        string `bson:"status" json:"status"`
+	Error         string `bson:"error,omitempty" json:"error,omitempty"`
+}
+
+// PRReview is a review of a pull or merge request that can be posted to GitHub or GitLab,
+// with an inline comment on the line of each new finding.
+type PRReview struct {
+	Body     string            `bson:"body" json:"body"`
+	Comments []PRReviewComment `bson:"comments" json:"comments"`
+}
+
+// PRReviewComment is an inline comment on a line of a file changed by a pull or merge request.
+type PRReviewComment struct {
+	Path string `bson:"path" json:"path"`
+	Line int    `bson:"line" json:"line"`
+	Body string `bson:"body" json:"body"`
+}
+
+// SuppressionRequest is a request to suppress a finding, by its fingerprint, as a false
+// positive. It is only respected once a reviewer other than its requester approves it.
+type SuppressionRequest struct {
+	RequestID     string    `bson:"requestID" json:"requestID"`
+	Fingerprint   string    `bson:"fingerprint" json:"fingerprint"`
+	RepositoryURL string    `bson:"repositoryURL" json:"repositoryURL"`
+	Status        string    `bson:"status" json:"status"`
+	RequestedBy   string    `bson:"requestedBy" json:"requestedBy"`
+	Reason        string    `bson:"reason" json:"reason"`
+	Evidence      string    `bson:"evidence,omitempty" json:"evidence,omitempty"`
+	RequestedAt   time.Time `bson:"requestedAt" json:"requestedAt"`
+	ReviewedBy    string    `bson:"reviewedBy,omitempty" json:"reviewedBy,omitempty"`
+	ReviewedAt    time.Time `bson:"reviewedAt,omitempty" json:"reviewedAt,omitempty"`
+	// Chain is every step the request went through, from its creation to its review.
+	Chain []SuppressionStep `bson:"chain" json:"chain"`
+	// Ignore is the ignore of an analysis request it was made for, if any, respected by the
+	// analyses of the repository once the request is approved.
+	Ignore *Ignore `bson:"ignore,omitempty" json:"ignore,omitempty"`
+}
+
+// SuppressionStep is a step of the approval chain of a suppression request: who requested,
+// approved or rejected it, when and why.
+type SuppressionStep struct {
+	Action  string    `bson:"action" json:"action"`
+	By      string    `bson:"by" json:"by"`
+	Comment string    `bson:"comment,omitempty" json:"comment,omitempty"`
+	At      time.Time `bson:"at" json:"at"`
+}
+
+// SuppressionReview approves or rejects a pending suppression request.
+type SuppressionReview struct {
+	Action  string `json:"action"`
+	Comment string `json:"comment"`
+}
+
+// CycloneDXBOM is a CycloneDX 1.4 bill of materials of the dependencies found by the
+// securityTests of an analysis, along with their known vulnerabilities.
+type CycloneDXBOM struct {
+	BOMFormat       string                   `json:"bomFormat"`
+	SpecVersion     string                   `json:"specVersion"`
+	SerialNumber    string                   `json:"serialNumber"`
+	Version         int                      `json:"version"`
+	Metadata        CycloneDXMetadata        `json:"metadata"`
+	Components      []CycloneDXComponent     `json:"components"`
+	Vulnerabilities []CycloneDXVulnerability `json:"vulnerabilities"`
+}
+
+// CycloneDXMetadata is when and by what a bill of materials was made, and of what.
+type CycloneDXMetadata struct {
+	Timestamp string             `json:"timestamp,omitempty"`
+	Tools     []CycloneDXTool    `json:"tools"`
+	Component CycloneDXComponent `json:"component"`
+}
+
+// CycloneDXTool is a tool that made a bill of materials.
+type CycloneDXTool struct {
+	Vendor  string `json:"vendor"`
+	Name    string `json:"name"`
+	Version string `json:"version,omitempty"`
+}
+
+// CycloneDXComponent is a component of a bill of materials, such as a dependency of a
+// repository. BOMRef identifies it within the bill of materials.
+type CycloneDXComponent struct {
+	Type       string              `json:"type"`
+	BOMRef     string              `json:"bom-ref,omitempty"`
+	Group      string              `json:"group,omitempty"`
+	Name       string              `json:"name"`
+	Version    string              `json:"version,omitempty"`
+	PURL       string              `json:"purl,omitempty"`
+	Properties []CycloneDXProperty `json:"properties,omitempty"`
+}
+
+// CycloneDXProperty is a name-value pair of a component, such as the securityTool that found it.
+type CycloneDXProperty struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// CycloneDXVulnerability is a known vulnerability of the components it Affects.
+type CycloneDXVulnerability struct {
+	ID          string             `json:"id,omitempty"`
+	Source      *CycloneDXSource   `json:"source,omitempty"`
+	Ratings     []CycloneDXRating  `json:"ratings,omitempty"`
+	Description string             `json:"description,omitempty"`
+	Affects     []CycloneDXAffects `json:"affects"`
+}
+
+// CycloneDXSource is where a vulnerability is described, such as NVD.
+type CycloneDXSource struct {
+	Name string `json:"name,omitempty"`
+	URL  string `json:"url,omitempty"`
+}
+
+// CycloneDXRating is the severity of a vulnerability and, if known, its CVSS score.
+type CycloneDXRating struct {
+	Score    float64 `json:"score,omitempty"`
+	Severity string  `json:"severity"`
+	Method   string  `json:"method,omitempty"`
+	Vector   string  `json:"vector,omitempty"`
+}
+
+// CycloneDXAffects is the BOMRef of a component affected by a vulnerability.
+type CycloneDXAffects struct {
+	Ref string `json:"ref"`
+}
@@ -8,6 +8,7 @@ import (
 	apiContext "github.com/globocom/huskyCI/api/context"
 	docker "github.com/globocom/huskyCI/api/dockers"
 	"github.com/globocom/huskyCI/api/log"
+	"github.com/globocom/huskyCI/api/securitytest"
 	"github.com/globocom/huskyCI/api/types"
 	"github.com/globocom/huskyCI/api/user"
 	mgo "gopkg.in/mgo.v2"
@@ -117,7 +118,7 @@ func (cH *CheckUtils) checkDB(configAPI *apiContext.APIConfig) error {
 }
 
 func (cH *CheckUtils) checkEachSecurityTest(configAPI *apiContext.APIConfig) error {
-	securityTests := []string{"enry", "gitauthors", "gosec", "brakeman", "bandit", "npmaudit", "yarnaudit", "spotbugs", "gitleaks", "safety"}
+	securityTests := []string{"enry", "gitauthors", "gosec", "brakeman", "bandit", "npmaudit", "yarnaudit", "eslint", "spotbugs", "gitleaks", "safety", "dependencycheck", "depconfusion", "hadolint", "gitdiff"}
 	for _, securityTest := range securityTests {
 		if err := checkSecurityTest(securityTest, configAPI); err != nil {
 			errMsg := fmt.Sprintf("%s %s", securityTest, err)
@@ -126,6 +127,16 @@ func (cH *CheckUtils) checkEachSecurityTest(configAPI *apiContext.APIConfig) err
 		}
 		log.Info("checkEachSecurityTest", logInfoAPIUtil, 19, securityTest)
 	}
+	// custom securityTests may depend on the ones of config.yaml and the other way around
+	allSecurityTests, err := configAPI.DBInstance.FindAllDBSecurityTest(map[string]interface{}{})
+	if err != nil {
+		log.Error("checkEachSecurityTest", logInfoAPIUtil, 2005, err)
+		return err
+	}
+	if err := securitytest.CheckDependencies(allSecurityTests); err != nil {
+		log.Error("checkEachSecurityTest", logInfoAPIUtil, 1061, err)
+		return err
+	}
 	return nil
 }
 
@@ -171,10 +182,24 @@ func checkSecurityTest(securityTestName string, configAPI *apiContext.APIConfig)
 		securityTestConfig = *configAPI.GitleaksSecurityTest
 	case "safety":
 		securityTestConfig = *configAPI.SafetySecurityTest
+	case "dependencycheck":
+		securityTestConfig = *configAPI.DependencyCheckSecurityTest
+	case "depconfusion":
+		securityTestConfig = *configAPI.DepConfusionSecurityTest
+	case "hadolint":
+		securityTestConfig = *configAPI.HadolintSecurityTest
+	case "eslint":
+		securityTestConfig = *configAPI.EslintSecurityTest
+	case "gitdiff":
+		securityTestConfig = *configAPI.GitDiffSecurityTest
 	default:
 		return errors.New("securityTest name not defined")
 	}
 
+	if docker.IsMutableTag(securityTestConfig.ImageTag) {
+		log.Warning("checkSecurityTest", logInfoAPIUtil, 124, securityTestName, securityTestConfig.Image, securityTestConfig.ImageTag)
+	}
+
 	securityTestQuery := map[string]interface{}{"name": securityTestName}
 	_, err := configAPI.DBInstance.UpsertOneDBSecurityTest(securityTestQuery, securityTestConfig)
 	if err != nil {
@@ -0,0 +1,136 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrCallbacksDisabled is returned for a callback URL when no callback host is allowed.
+var ErrCallbacksDisabled = errors.New("callbacks are not enabled")
+
+// ErrCallbackProxied is returned for a callback URL whose host is only allowed by a CIDR when
+// callbacks go through a proxy.
+var ErrCallbackProxied = errors.New("callback hosts only allowed by a CIDR can not be reached through a proxy")
+
+// lookupIP resolves the hosts of callback URLs allowed by a CIDR.
+var lookupIP = net.LookupIP
+
+// CheckValidCallbackURL returns an error if callbackURL is not an http or https URL whose
+// host is in allowlist, so callbacks can not reach internal services. Entries of allowlist
+// are hosts, such as "ci.example.com", domains with a leading dot, such as ".example.com",
+// or CIDRs, such as "10.0.0.0/8", which every IP of the host must be in. An empty
+// allowlist refuses every callback.
+func CheckValidCallbackURL(callbackURL string, allowlist []string) error {
+	parsedURL, networks, err := callbackNetworks(callbackURL, allowlist)
+	if err != nil || len(networks) == 0 {
+		return err
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+
+	ips := []net.IP{}
+	if ip := net.ParseIP(host); ip != nil {
+		ips = append(ips, ip)
+	} else if ips, err = lookupIP(host); err != nil {
+		return err
+	}
+	if len(ips) == 0 {
+		return fmt.Errorf("Callback host is not allowed: %s", host)
+	}
+	for _, ip := range ips {
+		if !ipInNetworks(ip, networks) {
+			return fmt.Errorf("Callback host is not allowed: %s", host)
+		}
+	}
+	return nil
+}
+
+// CallbackTransport returns the transport callbacks to callbackURL are posted with. If its
+// host is only allowed by the CIDRs of allowlist, the IP each connection dials must be in
+// them, so the host can not resolve to an internal IP once CheckValidCallbackURL accepted
+// it. Such a host can not be reached through a proxy, as the IP the proxy dials can not be
+// checked, so ErrCallbackProxied is returned instead.
+func CallbackTransport(callbackURL string, allowlist []string) (*http.Transport, error) {
+	parsedURL, networks, err := callbackNetworks(callbackURL, allowlist)
+	if err != nil {
+		return nil, err
+	}
+	transport := ProxiedTransport()
+	if len(networks) == 0 {
+		return transport, nil
+	}
+	proxyURL, err := transport.Proxy(&http.Request{URL: parsedURL})
+	if err != nil {
+		return nil, err
+	}
+	if proxyURL != nil {
+		return nil, ErrCallbackProxied
+	}
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		// called with the IP resolved for each connection, right before it is dialed
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || !ipInNetworks(ip, networks) {
+				return fmt.Errorf("Callback IP is not allowed: %s", host)
+			}
+			return nil
+		},
+	}
+	transport.DialContext = dialer.DialContext
+	return transport, nil
+}
+
+// callbackNetworks returns callbackURL parsed and, if its host is not allowed by name by
+// allowlist, the CIDRs of allowlist every IP of its host must be in.
+func callbackNetworks(callbackURL string, allowlist []string) (*url.URL, []*net.IPNet, error) {
+	if len(allowlist) == 0 {
+		return nil, nil, ErrCallbacksDisabled
+	}
+	parsedURL, err := url.Parse(callbackURL)
+	if err != nil {
+		return nil, nil, err
+	}
+	if (parsedURL.Scheme != "http" && parsedURL.Scheme != "https") || parsedURL.Hostname() == "" || parsedURL.User != nil {
+		return nil, nil, fmt.Errorf("Invalid callback URL: %s", callbackURL)
+	}
+	host := strings.ToLower(parsedURL.Hostname())
+
+	networks := []*net.IPNet{}
+	for _, entry := range allowlist {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if _, network, err := net.ParseCIDR(entry); err == nil {
+			networks = append(networks, network)
+			continue
+		}
+		if host == strings.TrimPrefix(entry, ".") || (strings.HasPrefix(entry, ".") && strings.HasSuffix(host, entry)) {
+			return parsedURL, nil, nil
+		}
+	}
+	if len(networks) == 0 {
+		return nil, nil, fmt.Errorf("Callback host is not allowed: %s", host)
+	}
+	return parsedURL, networks, nil
+}
+
+func ipInNetworks(ip net.IP, networks []*net.IPNet) bool {
+	for _, network := range networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util
+
+import (
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+)
+
+// HTTPProxyEnv is the proxy outbound requests of huskyCI go through, such as the ones to
+// NVD or Slack. If it is not set, the standard HTTP_PROXY and HTTPS_PROXY are used.
+const HTTPProxyEnv = "HUSKYCI_HTTP_PROXY"
+
+// NoProxyEnv is a comma-separated list of hosts reached without HUSKYCI_HTTP_PROXY, such
+// as "docker-host,.internal.example.com". A leading dot matches any subdomain and "*" any host.
+const NoProxyEnv = "HUSKYCI_NO_PROXY"
+
+// ProxyFunc returns the Proxy function of an *http.Transport that routes requests through
+// HUSKYCI_HTTP_PROXY, except the ones to hosts of HUSKYCI_NO_PROXY.
+func ProxyFunc() func(*http.Request) (*url.URL, error) {
+	rawProxy := os.Getenv(HTTPProxyEnv)
+	if rawProxy == "" {
+		return http.ProxyFromEnvironment
+	}
+	proxyURL, err := url.Parse(rawProxy)
+	if err != nil || proxyURL.Host == "" {
+		// a proxy such as "proxy.example.com:3128" has no scheme
+		proxyURL, err = url.Parse("http://" + rawProxy)
+	}
+	noProxy := strings.Split(os.Getenv(NoProxyEnv), ",")
+	return func(req *http.Request) (*url.URL, error) {
+		if err != nil {
+			return nil, err
+		}
+		if isNoProxyHost(req.URL.Hostname(), noProxy) {
+			return nil, nil
+		}
+		return proxyURL, nil
+	}
+}
+
+// ProxiedTransport returns a new *http.Transport with the defaults of http.DefaultTransport
+// whose requests go through HUSKYCI_HTTP_PROXY.
+func ProxiedTransport() *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = ProxyFunc()
+	return transport
+}
+
+func isNoProxyHost(host string, noProxy []string) bool {
+	host = strings.ToLower(host)
+	for _, entry := range noProxy {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" || host == strings.TrimPrefix(entry, ".") || strings.HasSuffix(host, "."+strings.TrimPrefix(entry, ".")) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,66 @@
+// Copyright 2020 Globo.com authors. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package util_test
+
+import (
+	"net/http"
+	"os"
+
+	"github.com/globocom/huskyCI/api/util"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("ProxiedTransport", func() {
+
+	AfterEach(func() {
+		os.Unsetenv(util.HTTPProxyEnv)
+		os.Unsetenv(util.NoProxyEnv)
+	})
+
+	proxyOf := func(transport *http.Transport, rawURL string) string {
+		req, err := http.NewRequest(http.MethodGet, rawURL, nil)
+		Expect(err).To(BeNil())
+		proxyURL, err := transport.Proxy(req)
+		Expect(err).To(BeNil())
+		if proxyURL == nil {
+			return ""
+		}
+		return proxyURL.String()
+	}
+
+	Context("When HUSKYCI_HTTP_PROXY is set", func() {
+		It("Should route requests through it", func() {
+			os.Setenv(util.HTTPProxyEnv, "http://proxy.example.com:3128")
+			transport := util.ProxiedTransport()
+			Expect(proxyOf(transport, "https://services.nvd.nist.gov/rest/json/cve/1.0/CVE-2020-1234")).To(Equal("http://proxy.example.com:3128"))
+		})
+		It("Should accept a proxy without scheme", func() {
+			os.Setenv(util.HTTPProxyEnv, "proxy.example.com:3128")
+			transport := util.ProxiedTransport()
+			Expect(proxyOf(transport, "https://hooks.slack.com/services/T0/B0/X")).To(Equal("http://proxy.example.com:3128"))
+		})
+	})
+
+	Context("When the host is in HUSKYCI_NO_PROXY", func() {
+		It("Should not route requests to it through the proxy", func() {
+			os.Setenv(util.HTTPProxyEnv, "http://proxy.example.com:3128")
+			os.Setenv(util.NoProxyEnv, "docker-host, .internal.example.com")
+			transport := util.ProxiedTransport()
+			Expect(proxyOf(transport, "https://docker-host:2376/_ping")).To(BeEmpty())
+			Expect(proxyOf(transport, "https://registry.internal.example.com/v2/")).To(BeEmpty())
+			Expect(proxyOf(transport, "https://internal.example.com/")).To(BeEmpty())
+			Expect(proxyOf(transport, "https://example.com/")).To(Equal("http://proxy.example.com:3128"))
+		})
+	})
+
+	Context("When HUSKYCI_HTTP_PROXY is not set", func() {
+		It("Should use the standard proxy environment variables", func() {
+			transport := util.ProxiedTransport()
+			Expect(transport.Proxy).ToNot(BeNil())
+		})
+	})
+})
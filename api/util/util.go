@@ -7,6 +7,7 @@ package util
 import (
 	"bufio"
 	"net/http"
+	"net/url"
 	"os"
 	"regexp"
 	"strconv"
@@ -31,16 +32,62 @@ const (
 const logInfoAnalysis = "ANALYSIS"
 const logActionReceiveRequest = "ReceiveRequest"
 
-// HandleCmd will extract %GIT_REPO%, %GIT_BRANCH% and %INTERNAL_DEP_URL% from cmd and replace it with the proper repository URL.
-func HandleCmd(repositoryURL, repositoryBranch, cmd string) string {
+// HandleCmd will extract %GIT_REPO%, %GIT_BRANCH% and %CHANGED_FILES% from cmd and replace it with the proper repository URL.
+// %CHANGED_FILES% is replaced by changedFiles, each one single quoted, or by an empty string during a full scan.
+func HandleCmd(repositoryURL, repositoryBranch, cmd string, changedFiles []string) string {
 	if repositoryURL != "" && repositoryBranch != "" && cmd != "" {
 		replace1 := strings.Replace(cmd, "%GIT_REPO%", repositoryURL, -1)
 		replace2 := strings.Replace(replace1, "%GIT_BRANCH%", repositoryBranch, -1)
-		return replace2
+		replace3 := strings.Replace(replace2, "%CHANGED_FILES%", quoteFiles(changedFiles), -1)
+		return replace3
 	}
 	return ""
 }
 
+// HandleBaseCommit will extract %GIT_BASE_COMMIT% from cmd and replace it with the given commit.
+// If no commit is given, changes are compared against origin/master.
+func HandleBaseCommit(rawString, baseCommit string) string {
+	if baseCommit == "" {
+		baseCommit = "origin/master"
+	}
+	return strings.Replace(rawString, "%GIT_BASE_COMMIT%", baseCommit, -1)
+}
+
+// HandleGitCommit will extract %GIT_COMMIT% from cmd and replace it with the given commit.
+// If no commit is given, it is HEAD, so the cloned branch is scanned as it is.
+func HandleGitCommit(rawString, commit string) string {
+	if commit == "" {
+		commit = "HEAD"
+	}
+	return strings.Replace(rawString, "%GIT_COMMIT%", commit, -1)
+}
+
+// HandleSubpath will extract %GIT_SUBPATH% from cmd and replace it with the given subpath,
+// so that code/%GIT_SUBPATH% is the cloned repository itself when no subpath is given.
+func HandleSubpath(rawString, subpath string) string {
+	return strings.Replace(rawString, "%GIT_SUBPATH%", subpath, -1)
+}
+
+// HandleExcludedPaths will extract %EXCLUDED_PATHS% from cmd and replace it with the
+// given args, the excluded paths already formatted as flags of the securityTest.
+func HandleExcludedPaths(rawString, excludedPathsArgs string) string {
+	return strings.Replace(rawString, "%EXCLUDED_PATHS%", excludedPathsArgs, -1)
+}
+
+// quoteFiles single quotes each file name, as they come from the repository itself.
+func quoteFiles(files []string) string {
+	quotedFiles := []string{}
+	for _, file := range files {
+		quotedFiles = append(quotedFiles, ShellQuote(file))
+	}
+	return strings.Join(quotedFiles, " ")
+}
+
+// ShellQuote single quotes a given string so that a shell reads it as a single word.
+func ShellQuote(rawString string) string {
+	return "'" + strings.Replace(rawString, "'", `'\''`, -1) + "'"
+}
+
 // HandlePrivateSSHKey will extract %GIT_PRIVATE_SSH_KEY% from cmd and replace it with the proper private SSH key.
 func HandlePrivateSSHKey(rawString string) string {
 	privKey := os.Getenv("HUSKYCI_API_GIT_PRIVATE_SSH_KEY")
@@ -48,6 +95,60 @@ func HandlePrivateSSHKey(rawString string) string {
 	return cmdReplaced
 }
 
+// HandleGitHTTPSToken will extract %GIT_HTTPS_TOKEN% from cmd and replace it with the proper HTTPS token
+// if repositoryURL is of one of allowedHosts, see IsHTTPSTokenHost, or with nothing otherwise.
+func HandleGitHTTPSToken(rawString, repositoryURL string, allowedHosts []string) string {
+	token := ""
+	if IsHTTPSTokenHost(repositoryURL, allowedHosts) {
+		token = os.Getenv("HUSKYCI_API_GIT_HTTPS_TOKEN")
+	}
+	return strings.Replace(rawString, "%GIT_HTTPS_TOKEN%", token, -1)
+}
+
+// InjectHTTPSToken returns an HTTPS repository URL of one of allowedHosts as
+// https://x-access-token:<token>@host/... using HUSKYCI_API_GIT_HTTPS_TOKEN. Any other
+// URL, such as an SSH one or one of another host, is returned as it is.
+func InjectHTTPSToken(repositoryURL string, allowedHosts []string) string {
+	token := os.Getenv("HUSKYCI_API_GIT_HTTPS_TOKEN")
+	if token == "" || !IsHTTPSTokenHost(repositoryURL, allowedHosts) {
+		return repositoryURL
+	}
+	parsedURL, err := url.Parse(repositoryURL)
+	if err != nil {
+		return repositoryURL
+	}
+	parsedURL.User = url.UserPassword("x-access-token", token)
+	return parsedURL.String()
+}
+
+// IsHTTPSTokenHost returns whether repositoryURL is an HTTPS URL whose host is one of
+// allowedHosts, such as "github.com", the only ones HUSKYCI_API_GIT_HTTPS_TOKEN is sent to.
+func IsHTTPSTokenHost(repositoryURL string, allowedHosts []string) bool {
+	parsedURL, err := url.Parse(repositoryURL)
+	if err != nil || parsedURL.Scheme != "https" {
+		return false
+	}
+	for _, allowedHost := range allowedHosts {
+		if strings.EqualFold(parsedURL.Host, strings.TrimSpace(allowedHost)) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHTTPSToken removes HUSKYCI_API_GIT_HTTPS_TOKEN from a given output, as git
+// may print the clone URL when it fails.
+func RedactHTTPSToken(output string) string {
+	token := os.Getenv("HUSKYCI_API_GIT_HTTPS_TOKEN")
+	if token == "" {
+		return output
+	}
+	output = strings.Replace(output, token, "[REDACTED]", -1)
+	// the token may be escaped inside the clone URL
+	escapedToken := strings.TrimPrefix(url.UserPassword("", token).String(), ":")
+	return strings.Replace(output, escapedToken, "[REDACTED]", -1)
+}
+
 // GetLastLine receives a string with multiple lines and returns it's last
 func GetLastLine(s string) string {
 	if s == "" {
@@ -119,6 +220,66 @@ func CheckValidInput(repository types.Repository, c echo.Context) (string, error
 		return "", err
 	}
 
+	if err := CheckValidBaseCommit(repository.BaseCommit); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1042, repository.BaseCommit)
+		reply := map[string]interface{}{"success": false, "error": "invalid base commit"}
+		return "", c.JSON(http.StatusBadRequest, reply)
+	}
+
+	for _, subpath := range repository.Subpaths {
+		if err := CheckValidSubpath(subpath.Path); err != nil {
+			log.Error(logActionReceiveRequest, logInfoAnalysis, 1043, subpath.Path)
+			reply := map[string]interface{}{"success": false, "error": "invalid subpath"}
+			return "", c.JSON(http.StatusBadRequest, reply)
+		}
+	}
+
+	for _, excludedPath := range repository.ExcludedPaths {
+		if err := CheckValidExcludedPath(excludedPath); err != nil {
+			log.Error(logActionReceiveRequest, logInfoAnalysis, 1044, excludedPath)
+			reply := map[string]interface{}{"success": false, "error": "invalid excluded path"}
+			return "", c.JSON(http.StatusBadRequest, reply)
+		}
+	}
+
+	for _, ignore := range repository.Ignores {
+		if err := CheckValidIgnore(ignore); err != nil {
+			log.Error(logActionReceiveRequest, logInfoAnalysis, 1050, ignore.SecurityTest, ignore.ID)
+			reply := map[string]interface{}{"success": false, "error": "invalid ignore"}
+			return "", c.JSON(http.StatusBadRequest, reply)
+		}
+	}
+
+	if err := CheckValidGitleaksAllowlist(repository.GitleaksAllowlist); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1051, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid gitleaks allowlist"}
+		return "", c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := CheckValidRefs(repository.Refs); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1053, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid refs"}
+		return "", c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := CheckValidNpmAuditFailOn(repository.NpmAuditFailOn); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1052, repository.NpmAuditFailOn)
+		reply := map[string]interface{}{"success": false, "error": "invalid npm audit fail on"}
+		return "", c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := CheckValidCommitSHA(repository.CommitSHA); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1056, repository.CommitSHA)
+		reply := map[string]interface{}{"success": false, "error": "invalid commit SHA"}
+		return "", c.JSON(http.StatusBadRequest, reply)
+	}
+
+	if err := CheckValidRequesterMetadata(repository); err != nil {
+		log.Error(logActionReceiveRequest, logInfoAnalysis, 1057, err)
+		reply := map[string]interface{}{"success": false, "error": "invalid requester metadata"}
+		return "", c.JSON(http.StatusBadRequest, reply)
+	}
+
 	return sanitiziedURL, nil
 }
 
@@ -137,6 +298,22 @@ func CheckMaliciousRepoURL(repositoryURL string) (string, error) {
 	return r.FindString(repositoryURL), nil
 }
 
+// CheckMaliciousRepoURLPrefix verifies if a given URL prefix, such as the URL of an organization, is
+// the start of git repository URLs and returns it. Prefixes must end with "/" so they can not match
+// another organization that starts with the same name.
+func CheckMaliciousRepoURLPrefix(prefix string) (string, error) {
+	regexpPrefix := `^((git|ssh|http(s)?)://|(git@|gitlab@)[\w\.]+:)[\w\.@\:/\-~]*/$`
+	valid, err := regexp.MatchString(regexpPrefix, prefix)
+	if err != nil {
+		return "matchStringError", err
+	}
+	if !valid || strings.Contains(prefix, "..") {
+		errorMsg := fmt.Sprintf("Invalid URL prefix format: %s", prefix)
+		return "", errors.New(errorMsg)
+	}
+	return prefix, nil
+}
+
 // CheckMaliciousRepoBranch verifies if a given branch is "malicious" or not
 func CheckMaliciousRepoBranch(repositoryBranch string, c echo.Context) error {
 	regexpBranch := `^[a-zA-Z0-9_\/.-]*$`
@@ -154,6 +331,293 @@ func CheckMaliciousRepoBranch(repositoryBranch string, c echo.Context) error {
 	return nil
 }
 
+// CheckValidRepoBranch returns an error if a given branch is "malicious".
+// Unlike CheckMaliciousRepoBranch, it does not depend on an echo context.
+func CheckValidRepoBranch(repositoryBranch string) error {
+	regexpBranch := `^[a-zA-Z0-9_\/.-]*$`
+	valid, err := regexp.MatchString(regexpBranch, repositoryBranch)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("Invalid branch format: %s", repositoryBranch)
+	}
+	return nil
+}
+
+// CheckValidBaseCommit returns an error if a given base commit is not a commit SHA.
+// An empty base commit is valid, as changes are then compared against master.
+func CheckValidBaseCommit(baseCommit string) error {
+	if baseCommit == "" {
+		return nil
+	}
+	valid, err := regexp.MatchString(`^[0-9a-fA-F]{7,40}$`, baseCommit)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("Invalid base commit format: %s", baseCommit)
+	}
+	return nil
+}
+
+// CheckValidSubpath returns an error if a given subpath is not a directory inside a repository.
+func CheckValidSubpath(subpath string) error {
+	valid, err := regexp.MatchString(`^[a-zA-Z0-9_][a-zA-Z0-9_\/.-]*$`, subpath)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("Invalid subpath format: %s", subpath)
+	}
+	for _, dir := range strings.Split(subpath, "/") {
+		if dir == ".." {
+			return fmt.Errorf("Invalid subpath format: %s", subpath)
+		}
+	}
+	return nil
+}
+
+// CheckValidExcludedPath returns an error if a given excluded path is not a path, or
+// file glob, inside a repository.
+func CheckValidExcludedPath(excludedPath string) error {
+	valid, err := regexp.MatchString(`^[a-zA-Z0-9_*?\[\]][a-zA-Z0-9_*?\[\]\/.-]*$`, excludedPath)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("Invalid excluded path format: %s", excludedPath)
+	}
+	return nil
+}
+
+// CheckValidExtraEnv returns an error if a given environment variable can not be set in
+// the containers of an analysis: its key must be one of allowedKeys, so a request can not
+// control the Docker daemon or the securityTool itself, and its value must not be empty.
+func CheckValidExtraEnv(extraEnv map[string]string, allowedKeys []string) error {
+	for key, value := range extraEnv {
+		if key == "" || strings.ContainsAny(key, "=\x00") {
+			return fmt.Errorf("Invalid env key format: %q", key)
+		}
+		if !isAllowedEnvKey(key, allowedKeys) {
+			return fmt.Errorf("Env key is not allowed: %s", key)
+		}
+		if value == "" || strings.Contains(value, "\x00") {
+			return fmt.Errorf("Invalid env value of key: %s", key)
+		}
+	}
+	return nil
+}
+
+func isAllowedEnvKey(key string, allowedKeys []string) bool {
+	for _, allowedKey := range allowedKeys {
+		if key == allowedKey {
+			return true
+		}
+	}
+	return false
+}
+
+// CheckValidIgnore returns an error if a given ignore does not name a securityTest and
+// one of its vulnerability IDs, as the ID may be passed to the securityTest cmd, or does not
+// say why, as it is reviewed before it is respected.
+func CheckValidIgnore(ignore types.Ignore) error {
+	if !regexp.MustCompile(`^[a-z0-9_-]+$`).MatchString(ignore.SecurityTest) {
+		return fmt.Errorf("Invalid ignore securityTest format: %s", ignore.SecurityTest)
+	}
+	if !regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9._:-]*$`).MatchString(ignore.ID) {
+		return fmt.Errorf("Invalid ignore ID format: %s", ignore.ID)
+	}
+	if strings.TrimSpace(ignore.Reason) == "" {
+		return fmt.Errorf("Ignore of %s has no reason", ignore.ID)
+	}
+	return nil
+}
+
+// CheckValidGitleaksAllowlist returns an error if a given gitleaks allowlist can not be
+// rendered into a gitleaks config: its paths must be valid excluded paths and its regexes
+// must compile and fit in a TOML literal string.
+func CheckValidGitleaksAllowlist(allowlist *types.GitleaksAllowlist) error {
+	if allowlist == nil {
+		return nil
+	}
+	for _, allowedPath := range allowlist.Paths {
+		if err := CheckValidExcludedPath(allowedPath); err != nil {
+			return err
+		}
+	}
+	for _, allowedRegex := range allowlist.Regexes {
+		if allowedRegex == "" || strings.Contains(allowedRegex, "'''") || strings.ContainsAny(allowedRegex, "\r\n") {
+			return fmt.Errorf("Invalid gitleaks allowlist regex format: %q", allowedRegex)
+		}
+		if _, err := regexp.Compile(allowedRegex); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// MaxRefs is how many other refs an analysis can scan along with its branch, as each one runs every securityTest again.
+const MaxRefs = 5
+
+// CheckValidRefs returns an error if there are more than MaxRefs refs or one of them is empty or "malicious".
+func CheckValidRefs(refs []string) error {
+	if len(refs) > MaxRefs {
+		return fmt.Errorf("Too many refs: %d", len(refs))
+	}
+	for _, ref := range refs {
+		if ref == "" {
+			return errors.New("Invalid empty ref")
+		}
+		if err := CheckValidRepoBranch(ref); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckValidNpmAuditFailOn returns an error if a given npm audit fail on is not empty or one of types.NpmAuditFailOn*.
+func CheckValidNpmAuditFailOn(failOn string) error {
+	switch failOn {
+	case "", types.NpmAuditFailOnAll, types.NpmAuditFailOnDirect, types.NpmAuditFailOnDirectOrFixable:
+		return nil
+	}
+	return fmt.Errorf("Invalid npm audit fail on: %s", failOn)
+}
+
+// CheckValidCommitSHA returns an error if a given commit SHA is not empty nor a commit SHA.
+func CheckValidCommitSHA(commitSHA string) error {
+	if commitSHA == "" {
+		return nil
+	}
+	if !commitSHARegexp.MatchString(commitSHA) {
+		return fmt.Errorf("Invalid commit SHA format: %s", commitSHA)
+	}
+	return nil
+}
+
+var commitSHARegexp = regexp.MustCompile(`^[0-9a-fA-F]{7,40}$`)
+
+// Limits of the requester metadata of an analysis, as it is stored along with it.
+const (
+	MaxCommitAuthorLength  = 256
+	MaxPullRequestIDLength = 64
+	MaxMetadataKeys        = 20
+	MaxMetadataKeyLength   = 64
+	MaxMetadataValueLength = 512
+)
+
+var metadataKeyRegexp = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CheckValidRequesterMetadata returns an error if the commit author, pull request ID or
+// metadata of repository are over their limits. Metadata keys can only have letters,
+// digits, "_" and "-", as MongoDB does not accept field names with "." or "$".
+func CheckValidRequesterMetadata(repository types.Repository) error {
+	if len(repository.CommitAuthor) > MaxCommitAuthorLength {
+		return fmt.Errorf("Commit author is longer than %d bytes", MaxCommitAuthorLength)
+	}
+	if len(repository.PullRequestID) > MaxPullRequestIDLength {
+		return fmt.Errorf("Pull request ID is longer than %d bytes", MaxPullRequestIDLength)
+	}
+	if len(repository.Metadata) > MaxMetadataKeys {
+		return fmt.Errorf("Too many metadata keys: %d", len(repository.Metadata))
+	}
+	for key, value := range repository.Metadata {
+		if len(key) > MaxMetadataKeyLength || !metadataKeyRegexp.MatchString(key) {
+			return fmt.Errorf("Invalid metadata key: %.64q", key)
+		}
+		if len(value) > MaxMetadataValueLength {
+			return fmt.Errorf("Value of metadata key %s is longer than %d bytes", key, MaxMetadataValueLength)
+		}
+	}
+	return nil
+}
+
+var slackChannelRegexp = regexp.MustCompile(`^[#@]?[a-z0-9_.-]{1,80}$`)
+
+// CheckValidSlackChannel returns an error if a given Slack channel is not empty nor the
+// name of a channel, such as "#security", or of a user, such as "@alice".
+func CheckValidSlackChannel(channel string) error {
+	if channel != "" && !slackChannelRegexp.MatchString(channel) {
+		return fmt.Errorf("Invalid Slack channel: %s", channel)
+	}
+	return nil
+}
+
+// ErrInvalidGitHubRepository is returned when a repository URL is not the one of a GitHub repository.
+var ErrInvalidGitHubRepository = errors.New("not a GitHub repository URL")
+
+// gitHubRepositoryRegexp matches https://host/owner/repo, ssh://git@host/owner/repo
+// and git@host:owner/repo clone URLs, with or without .git.
+var gitHubRepositoryRegexp = regexp.MustCompile(`^(?:(?:https?|ssh|git)://(?:[^@/]+@)?([^/:]+)(?::\d+)?/|[^@/]+@([^:/]+):)([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// ParseGitHubRepository returns the host of the GitHub repository cloned from repositoryURL,
+// be it an HTTPS or SSH clone URL, its owner and its name.
+func ParseGitHubRepository(repositoryURL string) (string, string, string, error) {
+	match := gitHubRepositoryRegexp.FindStringSubmatch(strings.TrimSpace(repositoryURL))
+	if match == nil {
+		return "", "", "", ErrInvalidGitHubRepository
+	}
+	host := match[1]
+	if host == "" {
+		host = match[2]
+	}
+	return strings.ToLower(host), match[3], match[4], nil
+}
+
+// ErrInvalidGitLabProject is returned when a repository URL is not the one of a GitLab project.
+var ErrInvalidGitLabProject = errors.New("not a GitLab project URL")
+
+// gitLabProjectRegexp matches the same clone URLs as gitHubRepositoryRegexp, but projects
+// can be nested in subgroups.
+var gitLabProjectRegexp = regexp.MustCompile(`^(?:(?:https?|ssh|git)://(?:[^@/]+@)?[^/]+/|[^@/]+@[^:/]+:)((?:[\w.-]+/)+[\w.-]+?)(?:\.git)?/?$`)
+
+// ParseGitLabProject returns the path of the GitLab project cloned from repositoryURL, such
+// as group/subgroup/project, be it an HTTPS or SSH clone URL.
+func ParseGitLabProject(repositoryURL string) (string, error) {
+	match := gitLabProjectRegexp.FindStringSubmatch(strings.TrimSpace(repositoryURL))
+	if match == nil {
+		return "", ErrInvalidGitLabProject
+	}
+	return match[1], nil
+}
+
+// ErrInvalidBitbucketRepository is returned when a repository URL is not the one of a Bitbucket repository.
+var ErrInvalidBitbucketRepository = errors.New("not a Bitbucket repository URL")
+
+// bitbucketRepositoryRegexp matches the clone URLs of Bitbucket Cloud, such as
+// https://bitbucket.org/workspace/repo.git, and of Bitbucket Server, such as
+// https://host/scm/project/repo.git and ssh://git@host:7999/project/repo.git.
+var bitbucketRepositoryRegexp = regexp.MustCompile(`^(?:(?:https?|ssh|git)://(?:[^@/]+@)?([^/:]+)(?::\d+)?/|[^@/]+@([^:/]+):)(?:scm/)?([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// ParseBitbucketRepository returns the host of the Bitbucket repository cloned from
+// repositoryURL, its workspace or project key and its slug.
+func ParseBitbucketRepository(repositoryURL string) (string, string, string, error) {
+	match := bitbucketRepositoryRegexp.FindStringSubmatch(strings.TrimSpace(repositoryURL))
+	if match == nil {
+		return "", "", "", ErrInvalidBitbucketRepository
+	}
+	host := match[1]
+	if host == "" {
+		host = match[2]
+	}
+	return strings.ToLower(host), match[3], match[4], nil
+}
+
+// CheckValidRID returns an error if a given RID is "malicious".
+// Unlike CheckMaliciousRID, it does not depend on an echo context.
+func CheckValidRID(RID string) error {
+	regexpRID := `^[-a-zA-Z0-9]*$`
+	valid, err := regexp.MatchString(regexpRID, RID)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		return fmt.Errorf("Invalid RID format: %s", RID)
+	}
+	return nil
+}
+
 // CheckMaliciousRID verifies if a given RID is "malicious" or not
 func CheckMaliciousRID(RID string, c echo.Context) error {
 	regexpRID := `^[-a-zA-Z0-9]*$`
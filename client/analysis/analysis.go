@@ -45,7 +45,7 @@ func StartAnalysis() (string, error) {
 	}
 
 	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Husky-Token", config.HuskyToken)
+	req.Header.Add("Authorization", "Bearer "+config.HuskyToken)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -93,7 +93,7 @@ func GetAnalysis(RID string) (types.Analysis, error) {
 		return analysis, err
 	}
 
-	req.Header.Add("Husky-Token", config.HuskyToken)
+	req.Header.Add("Authorization", "Bearer "+config.HuskyToken)
 
 	resp, err := httpClient.Do(req)
 	if err != nil {
@@ -135,6 +135,10 @@ func MonitorAnalysis(RID string) (types.Analysis, error) {
 				return analysis, nil
 			} else if analysis.Status == "error running" {
 				return analysis, fmt.Errorf("huskyCI encountered an error trying to execute this analysis: %v", analysis.ErrorFound)
+			} else if analysis.Status == "timeout" {
+				return analysis, fmt.Errorf("huskyCI could not finish this analysis before its timeout: %v", analysis.ErrorFound)
+			} else if analysis.Status == "interrupted" {
+				return analysis, fmt.Errorf("huskyCI was stopped before finishing this analysis, please try again: %v", analysis.ErrorFound)
 			}
 			if !types.IsJSONoutput {
 				fmt.Println("[HUSKYCI][!] Hold on! huskyCI is still running...")
@@ -159,3 +163,30 @@ func PrintResults(analysis types.Analysis) error {
 
 	return nil
 }
+
+// GetAPIVersion gets the version of the huskyCI API.
+func GetAPIVersion() (types.APIVersion, error) {
+
+	apiVersion := types.APIVersion{}
+	getVersionURL := config.HuskyAPI + "/version"
+
+	httpClient, err := util.NewClient(config.HuskyUseTLS)
+	if err != nil {
+		return apiVersion, err
+	}
+	httpClient.Timeout = 10 * time.Second
+
+	resp, err := httpClient.Get(getVersionURL)
+	if err != nil {
+		return apiVersion, err
+	}
+
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return apiVersion, fmt.Errorf("Error getting huskyCI API version! StatusCode received: %d", resp.StatusCode)
+	}
+
+	err = json.NewDecoder(resp.Body).Decode(&apiVersion)
+	return apiVersion, err
+}
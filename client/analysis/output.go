@@ -7,6 +7,7 @@ package analysis
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/globocom/huskyCI/client/types"
@@ -67,6 +68,33 @@ func printSTDOUTOutput(analysis types.Analysis) {
 	printSTDOUTOutputSpotBugs(outputJSON.JavaResults.HuskyCISpotBugsOutput.MediumVulns)
 	printSTDOUTOutputSpotBugs(outputJSON.JavaResults.HuskyCISpotBugsOutput.HighVulns)
 
+	// dependencycheck
+	printSTDOUTOutputDependencyCheck(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.LowVulns)
+	printSTDOUTOutputDependencyCheck(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.MediumVulns)
+	printSTDOUTOutputDependencyCheck(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.HighVulns)
+
+	// depconfusion
+	printSTDOUTOutputDepConfusion(outputJSON.GenericResults.HuskyCIDepConfusionOutput.LowVulns)
+	printSTDOUTOutputDepConfusion(outputJSON.GenericResults.HuskyCIDepConfusionOutput.MediumVulns)
+	printSTDOUTOutputDepConfusion(outputJSON.GenericResults.HuskyCIDepConfusionOutput.HighVulns)
+
+	// eslint
+	printSTDOUTOutputEslint(outputJSON.JavaScriptResults.HuskyCIEslintOutput.LowVulns)
+	printSTDOUTOutputEslint(outputJSON.JavaScriptResults.HuskyCIEslintOutput.MediumVulns)
+	printSTDOUTOutputEslint(outputJSON.JavaScriptResults.HuskyCIEslintOutput.HighVulns)
+
+	// hadolint
+	printSTDOUTOutputHadolint(outputJSON.GenericResults.HuskyCIHadolintOutput.LowVulns)
+	printSTDOUTOutputHadolint(outputJSON.GenericResults.HuskyCIHadolintOutput.MediumVulns)
+	printSTDOUTOutputHadolint(outputJSON.GenericResults.HuskyCIHadolintOutput.HighVulns)
+
+	// plugin and custom securityTests
+	for _, name := range pluginNames() {
+		printSTDOUTOutputPlugin(outputJSON.PluginResults[name].LowVulns)
+		printSTDOUTOutputPlugin(outputJSON.PluginResults[name].MediumVulns)
+		printSTDOUTOutputPlugin(outputJSON.PluginResults[name].HighVulns)
+	}
+
 	printAllSummary(analysis)
 }
 
@@ -80,6 +108,7 @@ func prepareAllSummary(analysis types.Analysis) {
 	outputJSON.RubyResults = analysis.HuskyCIResults.RubyResults
 	outputJSON.JavaResults = analysis.HuskyCIResults.JavaResults
 	outputJSON.GenericResults = analysis.HuskyCIResults.GenericResults
+	outputJSON.PluginResults = analysis.HuskyCIResults.PluginResults
 
 	// GoSec summary
 	outputJSON.Summary.GosecSummary.NoSecVuln = len(outputJSON.GoResults.HuskyCIGosecOutput.NoSecVulns)
@@ -172,22 +201,118 @@ func prepareAllSummary(analysis types.Analysis) {
 		outputJSON.Summary.GitleaksSummary.FoundVuln = true
 	}
 
+	// DependencyCheck summary
+	outputJSON.Summary.DependencyCheckSummary.NoSecVuln = len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.NoSecVulns)
+	outputJSON.Summary.DependencyCheckSummary.LowVuln = len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.LowVulns)
+	outputJSON.Summary.DependencyCheckSummary.MediumVuln = len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.MediumVulns)
+	outputJSON.Summary.DependencyCheckSummary.HighVuln = len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.HighVulns)
+	if len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.LowVulns) > 0 || len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.NoSecVulns) > 0 {
+		outputJSON.Summary.DependencyCheckSummary.FoundInfo = true
+	}
+	if len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.MediumVulns) > 0 || len(outputJSON.JavaResults.HuskyCIDependencyCheckOutput.HighVulns) > 0 {
+		outputJSON.Summary.DependencyCheckSummary.FoundVuln = true
+	}
+
+	// DepConfusion summary
+	outputJSON.Summary.DepConfusionSummary.NoSecVuln = len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.NoSecVulns)
+	outputJSON.Summary.DepConfusionSummary.LowVuln = len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.LowVulns)
+	outputJSON.Summary.DepConfusionSummary.MediumVuln = len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.MediumVulns)
+	outputJSON.Summary.DepConfusionSummary.HighVuln = len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.HighVulns)
+	if len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.LowVulns) > 0 || len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.NoSecVulns) > 0 {
+		outputJSON.Summary.DepConfusionSummary.FoundInfo = true
+	}
+	if len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.MediumVulns) > 0 || len(outputJSON.GenericResults.HuskyCIDepConfusionOutput.HighVulns) > 0 {
+		outputJSON.Summary.DepConfusionSummary.FoundVuln = true
+	}
+
+	// Eslint summary
+	outputJSON.Summary.EslintSummary.NoSecVuln = len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.NoSecVulns)
+	outputJSON.Summary.EslintSummary.LowVuln = len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.LowVulns)
+	outputJSON.Summary.EslintSummary.MediumVuln = len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.MediumVulns)
+	outputJSON.Summary.EslintSummary.HighVuln = len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.HighVulns)
+	if len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.LowVulns) > 0 || len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.NoSecVulns) > 0 {
+		outputJSON.Summary.EslintSummary.FoundInfo = true
+	}
+	if len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.MediumVulns) > 0 || len(outputJSON.JavaScriptResults.HuskyCIEslintOutput.HighVulns) > 0 {
+		outputJSON.Summary.EslintSummary.FoundVuln = true
+	}
+
+	// Hadolint summary
+	outputJSON.Summary.HadolintSummary.NoSecVuln = len(outputJSON.GenericResults.HuskyCIHadolintOutput.NoSecVulns)
+	outputJSON.Summary.HadolintSummary.LowVuln = len(outputJSON.GenericResults.HuskyCIHadolintOutput.LowVulns)
+	outputJSON.Summary.HadolintSummary.MediumVuln = len(outputJSON.GenericResults.HuskyCIHadolintOutput.MediumVulns)
+	outputJSON.Summary.HadolintSummary.HighVuln = len(outputJSON.GenericResults.HuskyCIHadolintOutput.HighVulns)
+	if len(outputJSON.GenericResults.HuskyCIHadolintOutput.LowVulns) > 0 || len(outputJSON.GenericResults.HuskyCIHadolintOutput.NoSecVulns) > 0 {
+		outputJSON.Summary.HadolintSummary.FoundInfo = true
+	}
+	if len(outputJSON.GenericResults.HuskyCIHadolintOutput.MediumVulns) > 0 || len(outputJSON.GenericResults.HuskyCIHadolintOutput.HighVulns) > 0 {
+		outputJSON.Summary.HadolintSummary.FoundVuln = true
+	}
+
+	// Plugins summary
+	var pluginNoSec, pluginLow, pluginMedium, pluginHigh int
+	outputJSON.Summary.PluginSummaries = map[string]types.HuskyCISummary{}
+	for name, pluginOutput := range outputJSON.PluginResults {
+		pluginSummary := types.HuskyCISummary{
+			NoSecVuln:  len(pluginOutput.NoSecVulns),
+			LowVuln:    len(pluginOutput.LowVulns),
+			MediumVuln: len(pluginOutput.MediumVulns),
+			HighVuln:   len(pluginOutput.HighVulns),
+		}
+		pluginSummary.FoundInfo = pluginSummary.LowVuln > 0 || pluginSummary.NoSecVuln > 0
+		pluginSummary.FoundVuln = pluginSummary.MediumVuln > 0 || pluginSummary.HighVuln > 0
+		outputJSON.Summary.PluginSummaries[name] = pluginSummary
+		pluginNoSec += pluginSummary.NoSecVuln
+		pluginLow += pluginSummary.LowVuln
+		pluginMedium += pluginSummary.MediumVuln
+		pluginHigh += pluginSummary.HighVuln
+	}
+
 	// Total summary
-	if outputJSON.Summary.GosecSummary.FoundVuln || outputJSON.Summary.BanditSummary.FoundVuln || outputJSON.Summary.SafetySummary.FoundVuln || outputJSON.Summary.BrakemanSummary.FoundVuln || outputJSON.Summary.NpmAuditSummary.FoundVuln || outputJSON.Summary.YarnAuditSummary.FoundVuln || outputJSON.Summary.GitleaksSummary.FoundVuln || outputJSON.Summary.SpotBugsSummary.FoundVuln {
+	summaries := map[string]types.HuskyCISummary{
+		"gosec":           outputJSON.Summary.GosecSummary,
+		"bandit":          outputJSON.Summary.BanditSummary,
+		"safety":          outputJSON.Summary.SafetySummary,
+		"brakeman":        outputJSON.Summary.BrakemanSummary,
+		"npmaudit":        outputJSON.Summary.NpmAuditSummary,
+		"yarnaudit":       outputJSON.Summary.YarnAuditSummary,
+		"spotbugs":        outputJSON.Summary.SpotBugsSummary,
+		"gitleaks":        outputJSON.Summary.GitleaksSummary,
+		"dependencycheck": outputJSON.Summary.DependencyCheckSummary,
+		"depconfusion":    outputJSON.Summary.DepConfusionSummary,
+		"eslint":          outputJSON.Summary.EslintSummary,
+		"hadolint":        outputJSON.Summary.HadolintSummary,
+	}
+	for name, pluginSummary := range outputJSON.Summary.PluginSummaries {
+		summaries[name] = pluginSummary
+	}
+	// securityTests in warning mode found issues that do not block, and so did every one of
+	// them if huskyCI API did not fail the analysis
+	warningTests := warningSecurityTests(analysis)
+	blocking := analysis.Result != "passed" && analysis.Result != "warning"
+	for name, summary := range summaries {
+		if summary.FoundVuln && (warningTests[name] || !blocking) {
+			types.FoundWarning = true
+		} else if summary.FoundVuln {
+			types.FoundVuln = true
+		}
+		if summary.FoundInfo {
+			types.FoundInfo = true
+		}
+	}
+	if types.FoundVuln {
 		outputJSON.Summary.TotalSummary.FoundVuln = true
-		types.FoundVuln = true
-	} else if outputJSON.Summary.GosecSummary.FoundInfo || outputJSON.Summary.BanditSummary.FoundInfo || outputJSON.Summary.SafetySummary.FoundInfo || outputJSON.Summary.BrakemanSummary.FoundInfo || outputJSON.Summary.NpmAuditSummary.FoundInfo || outputJSON.Summary.YarnAuditSummary.FoundInfo || outputJSON.Summary.GitleaksSummary.FoundInfo || outputJSON.Summary.SpotBugsSummary.FoundInfo {
+	} else if types.FoundWarning || types.FoundInfo {
 		outputJSON.Summary.TotalSummary.FoundInfo = true
-		types.FoundInfo = true
 	}
 
-	totalNoSec = outputJSON.Summary.BanditSummary.NoSecVuln + outputJSON.Summary.GosecSummary.NoSecVuln + outputJSON.Summary.GitleaksSummary.NoSecVuln
+	totalNoSec = pluginNoSec + outputJSON.Summary.BanditSummary.NoSecVuln + outputJSON.Summary.GosecSummary.NoSecVuln + outputJSON.Summary.GitleaksSummary.NoSecVuln + outputJSON.Summary.DependencyCheckSummary.NoSecVuln + outputJSON.Summary.DepConfusionSummary.NoSecVuln + outputJSON.Summary.EslintSummary.NoSecVuln + outputJSON.Summary.HadolintSummary.NoSecVuln
 
-	totalLow = outputJSON.Summary.BrakemanSummary.LowVuln + outputJSON.Summary.SafetySummary.LowVuln + outputJSON.Summary.BanditSummary.LowVuln + outputJSON.Summary.GosecSummary.LowVuln + outputJSON.Summary.NpmAuditSummary.LowVuln + outputJSON.Summary.YarnAuditSummary.LowVuln + outputJSON.Summary.GitleaksSummary.LowVuln + outputJSON.Summary.SpotBugsSummary.LowVuln
+	totalLow = pluginLow + outputJSON.Summary.BrakemanSummary.LowVuln + outputJSON.Summary.SafetySummary.LowVuln + outputJSON.Summary.BanditSummary.LowVuln + outputJSON.Summary.GosecSummary.LowVuln + outputJSON.Summary.NpmAuditSummary.LowVuln + outputJSON.Summary.YarnAuditSummary.LowVuln + outputJSON.Summary.GitleaksSummary.LowVuln + outputJSON.Summary.SpotBugsSummary.LowVuln + outputJSON.Summary.DependencyCheckSummary.LowVuln + outputJSON.Summary.DepConfusionSummary.LowVuln + outputJSON.Summary.EslintSummary.LowVuln + outputJSON.Summary.HadolintSummary.LowVuln
 
-	totalMedium = outputJSON.Summary.BrakemanSummary.MediumVuln + outputJSON.Summary.SafetySummary.MediumVuln + outputJSON.Summary.BanditSummary.MediumVuln + outputJSON.Summary.GosecSummary.MediumVuln + outputJSON.Summary.NpmAuditSummary.MediumVuln + outputJSON.Summary.YarnAuditSummary.MediumVuln + outputJSON.Summary.GitleaksSummary.MediumVuln + outputJSON.Summary.SpotBugsSummary.MediumVuln
+	totalMedium = pluginMedium + outputJSON.Summary.BrakemanSummary.MediumVuln + outputJSON.Summary.SafetySummary.MediumVuln + outputJSON.Summary.BanditSummary.MediumVuln + outputJSON.Summary.GosecSummary.MediumVuln + outputJSON.Summary.NpmAuditSummary.MediumVuln + outputJSON.Summary.YarnAuditSummary.MediumVuln + outputJSON.Summary.GitleaksSummary.MediumVuln + outputJSON.Summary.SpotBugsSummary.MediumVuln + outputJSON.Summary.DependencyCheckSummary.MediumVuln + outputJSON.Summary.DepConfusionSummary.MediumVuln + outputJSON.Summary.EslintSummary.MediumVuln + outputJSON.Summary.HadolintSummary.MediumVuln
 
-	totalHigh = outputJSON.Summary.BrakemanSummary.HighVuln + outputJSON.Summary.SafetySummary.HighVuln + outputJSON.Summary.BanditSummary.HighVuln + outputJSON.Summary.GosecSummary.HighVuln + outputJSON.Summary.NpmAuditSummary.HighVuln + outputJSON.Summary.YarnAuditSummary.HighVuln + outputJSON.Summary.GitleaksSummary.HighVuln + outputJSON.Summary.SpotBugsSummary.HighVuln
+	totalHigh = pluginHigh + outputJSON.Summary.BrakemanSummary.HighVuln + outputJSON.Summary.SafetySummary.HighVuln + outputJSON.Summary.BanditSummary.HighVuln + outputJSON.Summary.GosecSummary.HighVuln + outputJSON.Summary.NpmAuditSummary.HighVuln + outputJSON.Summary.YarnAuditSummary.HighVuln + outputJSON.Summary.GitleaksSummary.HighVuln + outputJSON.Summary.SpotBugsSummary.HighVuln + outputJSON.Summary.DependencyCheckSummary.HighVuln + outputJSON.Summary.DepConfusionSummary.HighVuln + outputJSON.Summary.EslintSummary.HighVuln + outputJSON.Summary.HadolintSummary.HighVuln
 
 	outputJSON.Summary.TotalSummary.HighVuln = totalHigh
 	outputJSON.Summary.TotalSummary.MediumVuln = totalMedium
@@ -196,11 +321,27 @@ func prepareAllSummary(analysis types.Analysis) {
 
 }
 
+// warningSecurityTests returns the names of the securityTests of analysis that found issues in
+// warning mode.
+func warningSecurityTests(analysis types.Analysis) map[string]bool {
+	warningTests := map[string]bool{}
+	for _, container := range analysis.Containers {
+		if container.CResult == "warning" {
+			warningTests[container.SecurityTest.Name] = true
+		}
+	}
+	return warningTests
+}
+
 func printAllSummary(analysis types.Analysis) {
 
-	var gosecVersion, banditVersion, safetyVersion, brakemanVersion, npmauditVersion, yarnauditVersion, gitleaksVersion, spotbugsVersion string
+	var gosecVersion, banditVersion, safetyVersion, brakemanVersion, npmauditVersion, yarnauditVersion, gitleaksVersion, spotbugsVersion, dependencycheckVersion, depconfusionVersion, eslintVersion, hadolintVersion string
+	pluginVersions := map[string]string{}
 
 	for _, container := range analysis.Containers {
+		if _, ok := outputJSON.PluginResults[container.SecurityTest.Name]; ok {
+			pluginVersions[container.SecurityTest.Name] = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
+		}
 		switch container.SecurityTest.Name {
 		case "gosec":
 			gosecVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
@@ -218,6 +359,14 @@ func printAllSummary(analysis types.Analysis) {
 			spotbugsVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
 		case "gitleaks":
 			gitleaksVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
+		case "hadolint":
+			hadolintVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
+		case "eslint":
+			eslintVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
+		case "depconfusion":
+			depconfusionVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
+		case "dependencycheck":
+			dependencycheckVersion = fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
 		}
 	}
 
@@ -293,6 +442,54 @@ func printAllSummary(analysis types.Analysis) {
 		fmt.Printf("[HUSKYCI][SUMMARY] NoSecHusky: %d\n", outputJSON.Summary.GitleaksSummary.NoSecVuln)
 	}
 
+	if outputJSON.Summary.DependencyCheckSummary.FoundVuln || outputJSON.Summary.DependencyCheckSummary.FoundInfo {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][SUMMARY] Java -> %s\n", dependencycheckVersion)
+		fmt.Printf("[HUSKYCI][SUMMARY] High: %d\n", outputJSON.Summary.DependencyCheckSummary.HighVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Medium: %d\n", outputJSON.Summary.DependencyCheckSummary.MediumVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Low: %d\n", outputJSON.Summary.DependencyCheckSummary.LowVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] NoSecHusky: %d\n", outputJSON.Summary.DependencyCheckSummary.NoSecVuln)
+	}
+
+	if outputJSON.Summary.DepConfusionSummary.FoundVuln || outputJSON.Summary.DepConfusionSummary.FoundInfo {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][SUMMARY] Generic -> %s\n", depconfusionVersion)
+		fmt.Printf("[HUSKYCI][SUMMARY] High: %d\n", outputJSON.Summary.DepConfusionSummary.HighVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Medium: %d\n", outputJSON.Summary.DepConfusionSummary.MediumVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Low: %d\n", outputJSON.Summary.DepConfusionSummary.LowVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] NoSecHusky: %d\n", outputJSON.Summary.DepConfusionSummary.NoSecVuln)
+	}
+
+	if outputJSON.Summary.EslintSummary.FoundVuln || outputJSON.Summary.EslintSummary.FoundInfo {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][SUMMARY] JavaScript -> %s\n", eslintVersion)
+		fmt.Printf("[HUSKYCI][SUMMARY] High: %d\n", outputJSON.Summary.EslintSummary.HighVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Medium: %d\n", outputJSON.Summary.EslintSummary.MediumVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Low: %d\n", outputJSON.Summary.EslintSummary.LowVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] NoSecHusky: %d\n", outputJSON.Summary.EslintSummary.NoSecVuln)
+	}
+
+	if outputJSON.Summary.HadolintSummary.FoundVuln || outputJSON.Summary.HadolintSummary.FoundInfo {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][SUMMARY] Dockerfile -> %s\n", hadolintVersion)
+		fmt.Printf("[HUSKYCI][SUMMARY] High: %d\n", outputJSON.Summary.HadolintSummary.HighVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Medium: %d\n", outputJSON.Summary.HadolintSummary.MediumVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] Low: %d\n", outputJSON.Summary.HadolintSummary.LowVuln)
+		fmt.Printf("[HUSKYCI][SUMMARY] NoSecHusky: %d\n", outputJSON.Summary.HadolintSummary.NoSecVuln)
+	}
+
+	for _, name := range pluginNames() {
+		pluginSummary := outputJSON.Summary.PluginSummaries[name]
+		if pluginSummary.FoundVuln || pluginSummary.FoundInfo {
+			fmt.Println()
+			fmt.Printf("[HUSKYCI][SUMMARY] %s -> %s\n", name, pluginVersions[name])
+			fmt.Printf("[HUSKYCI][SUMMARY] High: %d\n", pluginSummary.HighVuln)
+			fmt.Printf("[HUSKYCI][SUMMARY] Medium: %d\n", pluginSummary.MediumVuln)
+			fmt.Printf("[HUSKYCI][SUMMARY] Low: %d\n", pluginSummary.LowVuln)
+			fmt.Printf("[HUSKYCI][SUMMARY] NoSecHusky: %d\n", pluginSummary.NoSecVuln)
+		}
+	}
+
 	if outputJSON.Summary.TotalSummary.FoundVuln || outputJSON.Summary.TotalSummary.FoundInfo {
 		fmt.Println()
 		fmt.Printf("[HUSKYCI][SUMMARY] Total\n")
@@ -417,3 +614,80 @@ func printSTDOUTOutputGitleaks(issues []types.HuskyCIVulnerability) {
 		fmt.Printf("[HUSKYCI][!] Code: %s\n", issue.Code)
 	}
 }
+
+func printSTDOUTOutputDependencyCheck(issues []types.HuskyCIVulnerability) {
+	for _, issue := range issues {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][!] Language: %s\n", issue.Language)
+		fmt.Printf("[HUSKYCI][!] Tool: %s\n", issue.SecurityTool)
+		fmt.Printf("[HUSKYCI][!] Severity: %s\n", issue.Severity)
+		fmt.Printf("[HUSKYCI][!] CVE: %s\n", issue.RuleID)
+		fmt.Printf("[HUSKYCI][!] Details: %s\n", issue.Details)
+		fmt.Printf("[HUSKYCI][!] File: %s\n", issue.File)
+		fmt.Printf("[HUSKYCI][!] Code: %s\n", issue.Code)
+		fmt.Printf("[HUSKYCI][!] Type: %s\n", issue.Type)
+	}
+}
+
+func printSTDOUTOutputDepConfusion(issues []types.HuskyCIVulnerability) {
+	for _, issue := range issues {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][!] Language: %s\n", issue.Language)
+		fmt.Printf("[HUSKYCI][!] Tool: %s\n", issue.SecurityTool)
+		fmt.Printf("[HUSKYCI][!] Severity: %s\n", issue.Severity)
+		fmt.Printf("[HUSKYCI][!] Type: %s\n", issue.Type)
+		fmt.Printf("[HUSKYCI][!] Details: %s\n", issue.Details)
+		fmt.Printf("[HUSKYCI][!] File: %s\n", issue.File)
+		fmt.Printf("[HUSKYCI][!] Code: %s\n", issue.Code)
+	}
+}
+
+func printSTDOUTOutputEslint(issues []types.HuskyCIVulnerability) {
+	for _, issue := range issues {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][!] Language: %s\n", issue.Language)
+		fmt.Printf("[HUSKYCI][!] Tool: %s\n", issue.SecurityTool)
+		fmt.Printf("[HUSKYCI][!] Severity: %s\n", issue.Severity)
+		fmt.Printf("[HUSKYCI][!] Rule: %s\n", issue.RuleID)
+		fmt.Printf("[HUSKYCI][!] Details: %s\n", issue.Details)
+		fmt.Printf("[HUSKYCI][!] File: %s\n", issue.File)
+		fmt.Printf("[HUSKYCI][!] Line: %s\n", issue.Line)
+	}
+}
+
+func printSTDOUTOutputHadolint(issues []types.HuskyCIVulnerability) {
+	for _, issue := range issues {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][!] Language: %s\n", issue.Language)
+		fmt.Printf("[HUSKYCI][!] Tool: %s\n", issue.SecurityTool)
+		fmt.Printf("[HUSKYCI][!] Severity: %s\n", issue.Severity)
+		fmt.Printf("[HUSKYCI][!] Rule: %s\n", issue.RuleID)
+		fmt.Printf("[HUSKYCI][!] Details: %s\n", issue.Details)
+		fmt.Printf("[HUSKYCI][!] File: %s\n", issue.File)
+		fmt.Printf("[HUSKYCI][!] Line: %s\n", issue.Line)
+	}
+}
+
+// pluginNames returns the names of the plugin and custom securityTests with results, sorted
+// so they are always printed in the same order.
+func pluginNames() []string {
+	names := []string{}
+	for name := range outputJSON.PluginResults {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func printSTDOUTOutputPlugin(issues []types.HuskyCIVulnerability) {
+	for _, issue := range issues {
+		fmt.Println()
+		fmt.Printf("[HUSKYCI][!] Language: %s\n", issue.Language)
+		fmt.Printf("[HUSKYCI][!] Tool: %s\n", issue.SecurityTool)
+		fmt.Printf("[HUSKYCI][!] Severity: %s\n", issue.Severity)
+		fmt.Printf("[HUSKYCI][!] Details: %s\n", issue.Details)
+		fmt.Printf("[HUSKYCI][!] File: %s\n", issue.File)
+		fmt.Printf("[HUSKYCI][!] Line: %s\n", issue.Line)
+		fmt.Printf("[HUSKYCI][!] Code: %s\n", issue.Code)
+	}
+}
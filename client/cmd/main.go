@@ -13,13 +13,22 @@ import (
 	"github.com/globocom/huskyCI/client/analysis"
 	"github.com/globocom/huskyCI/client/config"
 	"github.com/globocom/huskyCI/client/types"
+	"github.com/globocom/huskyCI/client/util"
 )
 
+// version is the version of huskyci-client, set when it is built with -ldflags -X main.version.
+var version = "dev"
+
 func main() {
 
 	types.FoundVuln = false
 	types.IsJSONoutput = false
 
+	if len(os.Args) > 1 && os.Args[1] == "--version" {
+		printVersion()
+		os.Exit(0)
+	}
+
 	if len(os.Args) > 1 && os.Args[1] == "JSON" {
 		types.IsJSONoutput = true
 	}
@@ -32,6 +41,10 @@ func main() {
 		os.Exit(1)
 	}
 	config.SetConfigs()
+	// best-effort: an API whose version can not be read is not warned about
+	if apiVersion, err := analysis.GetAPIVersion(); err == nil && !types.IsJSONoutput {
+		warnAPIVersion(apiVersion)
+	}
 
 	// step 1: start analysis and get its RID.
 	if !types.IsJSONoutput {
@@ -57,14 +70,17 @@ func main() {
 	var passedList []string
 	var failedList []string
 	var errorList []string
+	var warningList []string
 	for _, container := range huskyAnalysis.Containers {
 		securityTestFullName := fmt.Sprintf("%s:%s", container.SecurityTest.Image, container.SecurityTest.ImageTag)
 		if container.CResult == "passed" && container.SecurityTest.Name != "gitauthors" {
 			passedList = append(passedList, securityTestFullName)
 		} else if container.CResult == "failed" {
 			failedList = append(failedList, securityTestFullName)
-		} else if container.CResult == "error" {
-			failedList = append(errorList, securityTestFullName)
+		} else if container.CResult == "warning" {
+			warningList = append(warningList, securityTestFullName)
+		} else if container.CResult == "error" || container.CResult == "timedout" {
+			errorList = append(errorList, securityTestFullName)
 		}
 	}
 
@@ -89,7 +105,7 @@ func main() {
 	}
 
 	// step 4: block developer CI if vulnerabilities were found
-	if !types.FoundVuln && !types.FoundInfo {
+	if !types.FoundVuln && !types.FoundInfo && !types.FoundWarning {
 		if !types.IsJSONoutput {
 			if len(errorList) > 0 {
 				fmt.Println("[HUSKYCI][*] The following securityTests failed to run:")
@@ -102,7 +118,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	if !types.FoundVuln && types.FoundInfo {
+	if !types.FoundVuln {
 		if !types.IsJSONoutput {
 			if len(errorList) > 0 {
 				fmt.Println("[HUSKYCI][*] The following securityTests failed to run:")
@@ -110,7 +126,13 @@ func main() {
 			}
 			fmt.Println("[HUSKYCI][*] The following securityTests were executed and no blocking vulnerabilities were found:")
 			fmt.Println("[HUSKYCI][*]", passedList)
-			fmt.Println("[HUSKYCI][*] However, some LOW/INFO issues were found...")
+			if len(warningList) > 0 {
+				fmt.Println("[HUSKYCI][*] The following securityTests are in warning mode and found HIGH/MEDIUM issues that do not block:")
+				fmt.Println("[HUSKYCI][*]", warningList)
+			}
+			if types.FoundInfo {
+				fmt.Println("[HUSKYCI][*] However, some LOW/INFO issues were found...")
+			}
 		}
 		os.Exit(0)
 	}
@@ -124,9 +146,38 @@ func main() {
 			fmt.Println("[HUSKYCI][*] The following securityTests were executed and no blocking vulnerabilities were found:")
 			fmt.Println("[HUSKYCI][*]", passedList)
 		}
+		if len(warningList) > 0 {
+			fmt.Println("[HUSKYCI][*] The following securityTests are in warning mode and found HIGH/MEDIUM issues that do not block:")
+			fmt.Println("[HUSKYCI][*]", warningList)
+		}
 		fmt.Println("[HUSKYCI][*] Some HIGH/MEDIUM issues were found in these securityTests:")
 		fmt.Println("[HUSKYCI][*]", failedList)
 	}
 
 	os.Exit(190)
 }
+
+// printVersion prints the version of huskyci-client and, if HUSKYCI_CLIENT_API_ADDR is set,
+// the one of the huskyCI API.
+func printVersion() {
+	fmt.Println("huskyci-client version:", version)
+	if os.Getenv("HUSKYCI_CLIENT_API_ADDR") == "" {
+		return
+	}
+	config.SetConfigs()
+	apiVersion, err := analysis.GetAPIVersion()
+	if err != nil {
+		fmt.Println("[HUSKYCI][ERROR] Getting huskyCI API version:", err)
+		return
+	}
+	fmt.Println(fmt.Sprintf("huskyCI API version: %s (commit %s, built %s with %s)", apiVersion.Version, apiVersion.Commit, apiVersion.Date, apiVersion.GoVersion))
+	warnAPIVersion(apiVersion)
+}
+
+// warnAPIVersion warns if the major version of the huskyCI API differs from the one of
+// huskyci-client, as they may not understand each other.
+func warnAPIVersion(apiVersion types.APIVersion) {
+	if util.MajorVersionDiffers(version, apiVersion.Version) {
+		fmt.Println(fmt.Sprintf("[HUSKYCI][!] huskyCI API version %s has a different major version than huskyci-client %s. Please use a matching huskyci-client.", apiVersion.Version, version))
+	}
+}
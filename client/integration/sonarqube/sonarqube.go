@@ -68,6 +68,26 @@ func GenerateOutputFile(analysis types.Analysis, outputPath, outputFileName stri
 	allVulns = append(allVulns, analysis.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.MediumVulns...)
 	allVulns = append(allVulns, analysis.HuskyCIResults.JavaResults.HuskyCISpotBugsOutput.HighVulns...)
 
+	// dependencycheck
+	allVulns = append(allVulns, analysis.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.LowVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.MediumVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.JavaResults.HuskyCIDependencyCheckOutput.HighVulns...)
+
+	// depconfusion
+	allVulns = append(allVulns, analysis.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.LowVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.MediumVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.GenericResults.HuskyCIDepConfusionOutput.HighVulns...)
+
+	// eslint
+	allVulns = append(allVulns, analysis.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.LowVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.MediumVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.JavaScriptResults.HuskyCIEslintOutput.HighVulns...)
+
+	// hadolint
+	allVulns = append(allVulns, analysis.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.LowVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.MediumVulns...)
+	allVulns = append(allVulns, analysis.HuskyCIResults.GenericResults.HuskyCIHadolintOutput.HighVulns...)
+
 	var sonarOutput HuskyCISonarOutput
 	sonarOutput.Issues = make([]SonarIssue, 0)
 
@@ -16,6 +16,9 @@ var FoundVuln bool
 // FoundInfo is the boolean that will be checked to verify if only low/info severity vulnerabilites were found.
 var FoundInfo bool
 
+// FoundWarning is the boolean that will be checked to verify if medium/high severity vulnerabilites were found by securityTests in warning mode, which do not block.
+var FoundWarning bool
+
 // IsJSONoutput is the boolean that will be checked to verity if the output is expected to be printed in a JSON format
 var IsJSONoutput bool
 
@@ -25,6 +28,15 @@ type JSONPayload struct {
 	RepositoryBranch string `json:"repositoryBranch"`
 }
 
+// APIVersion is the version of a huskyCI API along with the commit it was built from, when it
+// was built and with which Go version.
+type APIVersion struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
 // Target is the struct that represents HuskyCI API target
 type Target struct {
 	Label        string
@@ -63,6 +75,8 @@ type HuskyCIResults struct {
 	RubyResults       RubyResults       `bson:"rubyresults,omitempty" json:"rubyresults,omitempty"`
 	JavaResults       JavaResults       `bson:"javaresults,omitempty" json:"javaresults,omitempty"`
 	GenericResults    GenericResults    `bson:"genericresults,omitempty" json:"genericresults,omitempty"`
+	// PluginResults holds the results of plugin and custom securityTests, by securityTest name.
+	PluginResults map[string]HuskyCISecurityTestOutput `bson:"pluginresults,omitempty" json:"pluginresults,omitempty"`
 }
 
 // Container is the struct that stores all data from a container run.
@@ -103,17 +117,19 @@ type HuskyCIVulnerability struct {
 	VunerableBelow string `json:"vulnerablebelow,omitempty"`
 	Version        string `json:"version,omitempty"`
 	Occurrences    int    `json:"occurrences,omitempty"`
+	RuleID         string `json:"ruleid,omitempty"`
 }
 
 // JSONOutput is a truct that represents huskyCI output in a JSON format.
 type JSONOutput struct {
-	GoResults         GoResults         `json:"goresults,omitempty"`
-	PythonResults     PythonResults     `json:"pythonresults,omitempty"`
-	JavaScriptResults JavaScriptResults `json:"javascriptresults,omitempty"`
-	RubyResults       RubyResults       `json:"rubyresults,omitempty"`
-	JavaResults       JavaResults       `json:"javaresults,omitempty"`
-	GenericResults    GenericResults    `json:"genericresults,omitempty"`
-	Summary           Summary           `json:"summary,omitempty"`
+	GoResults         GoResults                            `json:"goresults,omitempty"`
+	PythonResults     PythonResults                        `json:"pythonresults,omitempty"`
+	JavaScriptResults JavaScriptResults                    `json:"javascriptresults,omitempty"`
+	RubyResults       RubyResults                          `json:"rubyresults,omitempty"`
+	JavaResults       JavaResults                          `json:"javaresults,omitempty"`
+	GenericResults    GenericResults                       `json:"genericresults,omitempty"`
+	PluginResults     map[string]HuskyCISecurityTestOutput `json:"pluginresults,omitempty"`
+	Summary           Summary                              `json:"summary,omitempty"`
 }
 
 // GoResults represents all Golang security tests results.
@@ -131,11 +147,13 @@ type PythonResults struct {
 type JavaScriptResults struct {
 	HuskyCINpmAuditOutput  HuskyCISecurityTestOutput `bson:"npmauditoutput,omitempty" json:"npmauditoutput,omitempty"`
 	HuskyCIYarnAuditOutput HuskyCISecurityTestOutput `bson:"yarnauditoutput,omitempty" json:"yarnauditoutput,omitempty"`
+	HuskyCIEslintOutput    HuskyCISecurityTestOutput `bson:"eslintoutput,omitempty" json:"eslintoutput,omitempty"`
 }
 
 // JavaResults represents all Java security tests results.
 type JavaResults struct {
-	HuskyCISpotBugsOutput HuskyCISecurityTestOutput `bson:"spotbugsoutput,omitempty" json:"spotbugsoutput,omitempty"`
+	HuskyCISpotBugsOutput        HuskyCISecurityTestOutput `bson:"spotbugsoutput,omitempty" json:"spotbugsoutput,omitempty"`
+	HuskyCIDependencyCheckOutput HuskyCISecurityTestOutput `bson:"dependencycheckoutput,omitempty" json:"dependencycheckoutput,omitempty"`
 }
 
 // RubyResults represents all Ruby security tests results.
@@ -145,7 +163,9 @@ type RubyResults struct {
 
 // GenericResults represents all generic securityTests results.
 type GenericResults struct {
-	HuskyCIGitleaksOutput HuskyCISecurityTestOutput `bson:"gitleaksoutput,omitempty" json:"gitleaksoutput,omitempty"`
+	HuskyCIGitleaksOutput     HuskyCISecurityTestOutput `bson:"gitleaksoutput,omitempty" json:"gitleaksoutput,omitempty"`
+	HuskyCIDepConfusionOutput HuskyCISecurityTestOutput `bson:"depconfusionoutput,omitempty" json:"depconfusionoutput,omitempty"`
+	HuskyCIHadolintOutput     HuskyCISecurityTestOutput `bson:"hadolintoutput,omitempty" json:"hadolintoutput,omitempty"`
 }
 
 // HuskyCISecurityTestOutput stores all Low, Medium and High vulnerabilities for a sec test
@@ -158,18 +178,23 @@ type HuskyCISecurityTestOutput struct {
 
 // Summary holds a summary of the information on all security tests.
 type Summary struct {
-	URL              string         `json:"repositoryURL"`
-	Branch           string         `json:"repositoryBranch"`
-	RID              string         `json:"RID"`
-	GosecSummary     HuskyCISummary `json:"gosecsummary,omitempty"`
-	BanditSummary    HuskyCISummary `json:"banditsummary,omitempty"`
-	SafetySummary    HuskyCISummary `json:"safetysummary,omitempty"`
-	NpmAuditSummary  HuskyCISummary `json:"npmauditsummary,omitempty"`
-	YarnAuditSummary HuskyCISummary `json:"yarnauditsummary,omitempty"`
-	BrakemanSummary  HuskyCISummary `json:"brakemansummary,omitempty"`
-	SpotBugsSummary  HuskyCISummary `json:"spotbugssummary,omitempty"`
-	GitleaksSummary  HuskyCISummary `json:"gitleakssummary,omitempty"`
-	TotalSummary     HuskyCISummary `json:"totalsummary,omitempty"`
+	URL                    string                    `json:"repositoryURL"`
+	Branch                 string                    `json:"repositoryBranch"`
+	RID                    string                    `json:"RID"`
+	GosecSummary           HuskyCISummary            `json:"gosecsummary,omitempty"`
+	BanditSummary          HuskyCISummary            `json:"banditsummary,omitempty"`
+	SafetySummary          HuskyCISummary            `json:"safetysummary,omitempty"`
+	NpmAuditSummary        HuskyCISummary            `json:"npmauditsummary,omitempty"`
+	YarnAuditSummary       HuskyCISummary            `json:"yarnauditsummary,omitempty"`
+	BrakemanSummary        HuskyCISummary            `json:"brakemansummary,omitempty"`
+	SpotBugsSummary        HuskyCISummary            `json:"spotbugssummary,omitempty"`
+	GitleaksSummary        HuskyCISummary            `json:"gitleakssummary,omitempty"`
+	DependencyCheckSummary HuskyCISummary            `json:"dependencychecksummary,omitempty"`
+	DepConfusionSummary    HuskyCISummary            `json:"depconfusionsummary,omitempty"`
+	EslintSummary          HuskyCISummary            `json:"eslintsummary,omitempty"`
+	HadolintSummary        HuskyCISummary            `json:"hadolintsummary,omitempty"`
+	PluginSummaries        map[string]HuskyCISummary `json:"pluginsummaries,omitempty"`
+	TotalSummary           HuskyCISummary            `json:"totalsummary,omitempty"`
 }
 
 // HuskyCISummary is the struct that holds summary information.
@@ -11,6 +11,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 )
 
@@ -110,3 +111,18 @@ func CreateFile(output []byte, filePath, fileName string) error {
 
 	return nil
 }
+
+// MajorVersionDiffers returns whether the semantic versions a and b, such as v0.13.0 and
+// 1.0.0, have different major versions. Versions that are not semantic ones, such as dev
+// builds, are never told apart.
+func MajorVersionDiffers(a, b string) bool {
+	majorA, okA := majorVersion(a)
+	majorB, okB := majorVersion(b)
+	return okA && okB && majorA != majorB
+}
+
+func majorVersion(version string) (int, bool) {
+	major := strings.SplitN(strings.TrimPrefix(strings.TrimSpace(version), "v"), ".", 2)[0]
+	number, err := strconv.Atoi(major)
+	return number, err == nil
+}
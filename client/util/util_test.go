@@ -43,4 +43,16 @@ var _ = Describe("Util", func() {
 			Expect(outputString).To(Equal(fileString))
 		})
 	})
+	Describe("MajorVersionDiffers", func() {
+		It("Should tell apart versions of different major versions", func() {
+			Expect(util.MajorVersionDiffers("v0.13.0", "1.0.0")).To(BeTrue())
+		})
+		It("Should not tell apart versions of the same major version", func() {
+			Expect(util.MajorVersionDiffers("v1.2.0", "1.4.1")).To(BeFalse())
+		})
+		It("Should not tell apart dev builds", func() {
+			Expect(util.MajorVersionDiffers("dev", "1.4.1")).To(BeFalse())
+			Expect(util.MajorVersionDiffers("v1.2.0", "dev")).To(BeFalse())
+		})
+	})
 })
@@ -68,7 +68,10 @@ CREATE TABLE public.analysis (
     "startedAt" timestamp without time zone,
     "finishedAt" timestamp without time zone,
     codes jsonb,
-    huskyciresults jsonb
+    huskyciresults jsonb,
+    "scanType" text,
+    "baseCommit" text,
+    summary jsonb
 );
 
 
@@ -0,0 +1,12 @@
+#!/bin/bash
+#
+# Copyright 2020 Globo.com authors. All rights reserved.
+# Use of this source code is governed by a BSD-style
+# license that can be found in the LICENSE file.
+#
+# This script records the findings of the analyses stored before findings were tracked
+# by fingerprint. It can run again, such as after it fails, as the findings of an analysis
+# are only recorded once.
+#
+
+docker exec huskyCI_API go run api/server.go -backfill-findings
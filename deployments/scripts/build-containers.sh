@@ -14,6 +14,11 @@ docker build deployments/dockerfiles/gitauthors/ -t huskyci/gitauthors:latest
 docker build deployments/dockerfiles/gosec/ -t huskyci/gosec:latest
 docker build deployments/dockerfiles/npmaudit/ -t huskyci/npmaudit:latest
 docker build deployments/dockerfiles/npmaudit/ -t huskyci/yarnaudit:latest
+docker build deployments/dockerfiles/eslint/ -t huskyci/eslint:latest
 docker build deployments/dockerfiles/safety/ -t huskyci/safety:latest
 docker build deployments/dockerfiles/gitleaks/ -t huskyci/gitleaks:latest
-docker build deployments/dockerfiles/spotbugs/ -t huskyci/spotbugs:latest
\ No newline at end of file
+docker build deployments/dockerfiles/spotbugs/ -t huskyci/spotbugs:latest
+docker build deployments/dockerfiles/dependencycheck/ -t huskyci/dependencycheck:latest
+docker build deployments/dockerfiles/depconfusion/ -t huskyci/depconfusion:latest
+docker build deployments/dockerfiles/hadolint/ -t huskyci/hadolint:latest
+docker build deployments/dockerfiles/trivy/ -t huskyci/trivy:latest
\ No newline at end of file
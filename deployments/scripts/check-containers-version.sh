@@ -15,9 +15,14 @@ gitAuthorsVersion=$(docker run --rm huskyci/gitauthors:latest git --version | aw
 gosecVersion=$(docker run --rm huskyci/gosec:latest gosec --version | grep Version | awk -F " " '{print $2}')
 npmAuditVersion=$(docker run --rm huskyci/npmaudit:latest npm audit --version)
 yarnAuditVersion=$(docker run --rm huskyci/yarnaudit:latest yarn audit --version )
+eslintVersion=$(docker run --rm huskyci/eslint:latest /eslint/node_modules/.bin/eslint --version | sed 's/^v//')
 safetyVersion=$(docker run --rm huskyci/safety:latest safety --version | awk -F " " '{print $3}')
 gitleaksVersion=$(docker run --rm huskyci/gitleaks:latest gitleaks --version)
 spotbugsVersion=$(docker run --rm huskyci/spotbugs:latest cat /opt/spotbugs/version)
+dependencyCheckVersion=$(docker run --rm huskyci/dependencycheck:latest /usr/share/dependency-check/bin/dependency-check.sh --version | awk -F " " '{print $NF}')
+depConfusionVersion=$(docker run --rm huskyci/depconfusion:latest curl --version | head -n 1 | awk -F " " '{print $2}')
+hadolintVersion=$(docker run --rm huskyci/hadolint:latest hadolint --version | awk -F " " '{print $NF}' | sed 's/-.*//')
+trivyVersion=$(docker run --rm huskyci/trivy:latest trivy --version | awk -F " " '{print $NF}')
 
 echo "bandit: $banditVersion"
 echo "brakeman: $brakemanVersion"
@@ -26,6 +31,11 @@ echo "gitauthors: $gitAuthorsVersion"
 echo "gosecVersion: $gosecVersion"
 echo "npmauditVersion: $npmAuditVersion"
 echo "yarnauditVersion: $yarnAuditVersion"
+echo "eslintVersion: $eslintVersion"
 echo "safetyVersion: $safetyVersion"
 echo "gitleaksVersion: $gitleaksVersion"
-echo "spotbugsVersion: $spotbugsVersion"
\ No newline at end of file
+echo "spotbugsVersion: $spotbugsVersion"
+echo "dependencycheckVersion: $dependencyCheckVersion"
+echo "depconfusionVersion: $depConfusionVersion"
+echo "hadolintVersion: $hadolintVersion"
+echo "trivyVersion: $trivyVersion"
\ No newline at end of file
@@ -14,9 +14,14 @@ gitAuthorsVersion=$(docker run --rm huskyci/gitauthors:latest git --version | aw
 gosecVersion=$(curl -s https://api.github.com/repos/securego/gosec/releases/latest | grep "tag_name" | awk -F '"' '{print $4}')
 npmAuditVersion=$(docker run --rm huskyci/npmaudit:latest npm audit --version)
 yarnAuditVersion=$(docker run --rm huskyci/yarnaudit:latest yarn audit --version )
+eslintVersion=$(docker run --rm huskyci/eslint:latest /eslint/node_modules/.bin/eslint --version | sed 's/^v//')
 safetyVersion=$(docker run --rm huskyci/safety:latest safety --version | awk -F " " '{print $3}')
 gitleaksVersion=$(docker run --rm huskyci/gitleaks:latest gitleaks --version)
 spotbugsVersion=$(docker run --rm huskyci/spotbugs:latest cat /opt/spotbugs/version)
+dependencyCheckVersion=$(docker run --rm huskyci/dependencycheck:latest /usr/share/dependency-check/bin/dependency-check.sh --version | awk -F " " '{print $NF}')
+depConfusionVersion=$(docker run --rm huskyci/depconfusion:latest curl --version | head -n 1 | awk -F " " '{print $2}')
+hadolintVersion=$(docker run --rm huskyci/hadolint:latest hadolint --version | awk -F " " '{print $NF}' | sed 's/-.*//')
+trivyVersion=$(docker run --rm huskyci/trivy:latest trivy --version | awk -F " " '{print $NF}')
 
 docker tag "huskyci/bandit:latest" "huskyci/bandit:$banditVersion"
 docker tag "huskyci/brakeman:latest" "huskyci/brakeman:$brakemanVersion"
@@ -25,9 +30,14 @@ docker tag "huskyci/gitauthors:latest" "huskyci/gitauthors:$gitAuthorsVersion"
 docker tag "huskyci/gosec:latest" "huskyci/gosec:$gosecVersion"
 docker tag "huskyci/npmaudit:latest" "huskyci/npmaudit:$npmAuditVersion"
 docker tag "huskyci/yarnaudit:latest" "huskyci/yarnaudit:$yarnAuditVersion"
+docker tag "huskyci/eslint:latest" "huskyci/eslint:$eslintVersion"
 docker tag "huskyci/safety:latest" "huskyci/safety:$safetyVersion"
 docker tag "huskyci/gitleaks:latest" "huskyci/gitleaks:$gitleaksVersion"
 docker tag "huskyci/spotbugs:latest" "huskyci/spotbugs:$spotbugsVersion"
+docker tag "huskyci/dependencycheck:latest" "huskyci/dependencycheck:$dependencyCheckVersion"
+docker tag "huskyci/depconfusion:latest" "huskyci/depconfusion:$depConfusionVersion"
+docker tag "huskyci/hadolint:latest" "huskyci/hadolint:$hadolintVersion"
+docker tag "huskyci/trivy:latest" "huskyci/trivy:$trivyVersion"
 
 docker push "huskyci/bandit:latest" && docker push "huskyci/bandit:$banditVersion"
 docker push "huskyci/brakeman:latest" && docker push "huskyci/brakeman:$brakemanVersion"
@@ -36,6 +46,11 @@ docker push "huskyci/gitauthors:latest" && docker push "huskyci/gitauthors:$gitA
 docker push "huskyci/gosec:latest" && docker push "huskyci/gosec:$gosecVersion"
 docker push "huskyci/npmaudit:latest" && docker push "huskyci/npmaudit:$npmAuditVersion"
 docker push "huskyci/yarnaudit:latest" && docker push "huskyci/yarnaudit:$yarnAuditVersion"
+docker push "huskyci/eslint:latest" && docker push "huskyci/eslint:$eslintVersion"
 docker push "huskyci/safety:latest" && docker push "huskyci/safety:$safetyVersion"
 docker push "huskyci/gitleaks:latest" && docker push "huskyci/gitleaks:$gitleaksVersion"
 docker push "huskyci/spotbugs:latest" && docker push "huskyci/spotbugs:$spotbugsVersion"
+docker push "huskyci/dependencycheck:latest" && docker push "huskyci/dependencycheck:$dependencyCheckVersion"
+docker push "huskyci/depconfusion:latest" && docker push "huskyci/depconfusion:$depConfusionVersion"
+docker push "huskyci/hadolint:latest" && docker push "huskyci/hadolint:$hadolintVersion"
+docker push "huskyci/trivy:latest" && docker push "huskyci/trivy:$trivyVersion"